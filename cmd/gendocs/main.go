@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 	"sort"
@@ -25,6 +27,21 @@ type Expected struct {
 }
 
 func main() {
+	schemasDir := flag.String("schemas", "", "Document the schema files (.json, .yaml) of this directory instead of the syntax reference")
+	server := flag.String("server", "", "Document the schemas registered on this rsearch server instead of the syntax reference")
+	apiKey := flag.String("api-key", "", "X-API-Key sent to -server")
+	outDir := flag.String("out", "docs/schemas", "Directory the schema documentation is written to")
+	templatePath := flag.String("template", "", "text/template rendering each schema, instead of the built-in Markdown")
+	flag.Parse()
+
+	if *schemasDir != "" || *server != "" {
+		if err := runSchemaDocs(*schemasDir, *server, *apiKey, *templatePath, *outDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating schema documentation: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Load test cases
 	data, err := os.ReadFile("tests/testcases.json")
 	if err != nil {
@@ -63,6 +80,36 @@ func main() {
 	fmt.Println("Documentation generated: docs/syntax-reference.md")
 }
 
+// runSchemaDocs generates the documentation of the schemas of a directory or server
+func runSchemaDocs(schemasDir, server, apiKey, templatePath, outDir string) error {
+	if schemasDir != "" && server != "" {
+		return fmt.Errorf("-schemas and -server are exclusive")
+	}
+	tmpl, err := parseSchemaTemplate(templatePath)
+	if err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+
+	var source schemaSource
+	if server != "" {
+		source, err = serverSource(server, apiKey)
+	} else {
+		source, err = fileSource(schemasDir)
+	}
+	if err != nil {
+		return err
+	}
+
+	written, err := generateSchemaDocs(context.Background(), source, tmpl, outDir)
+	if err != nil {
+		return err
+	}
+	for _, path := range written {
+		fmt.Println("Documentation generated:", path)
+	}
+	return nil
+}
+
 func generateDocs(categoryNames []string, categories map[string][]TestCase) string {
 	var sb strings.Builder
 
@@ -131,7 +178,8 @@ func generateDocs(categoryNames []string, categories map[string][]TestCase) stri
 
 	sb.WriteString("### Parameter Safety\n\n")
 	sb.WriteString("All queries use parameterized statements with numbered placeholders (`$1`, `$2`, etc.) ")
-	sb.WriteString("to prevent SQL injection attacks. Parameters are typed according to the schema field definitions.\n\n")
+	sb.WriteString("to prevent SQL injection attacks. Parameters are typed according to the schema field definitions. ")
+	sb.WriteString("Embedded PostgreSQL translators can emit `?` or named `@p1` placeholders instead with `WithPlaceholderStyle`.\n\n")
 
 	sb.WriteString("### Schema Configuration\n\n")
 	sb.WriteString("Field names are resolved according to the schema's naming convention (e.g., `snake_case`). ")
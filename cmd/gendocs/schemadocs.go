@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/infiniv/rsearch/internal/api"
+	"github.com/infiniv/rsearch/internal/migration"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/infiniv/rsearch/pkg/client"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// defaultSchemaTemplate renders the documentation of one schema as Markdown
+//
+//go:embed schema.md.tmpl
+var defaultSchemaTemplate string
+
+// typeExamples are the values documented for fields without examples of their own
+var typeExamples = map[string]string{
+	string(schema.TypeText):     "example",
+	string(schema.TypeInteger):  "[1 TO 10]",
+	string(schema.TypeFloat):    ">=9.99",
+	string(schema.TypeBoolean):  "true",
+	string(schema.TypeDateTime): "[2024-01-01 TO 2024-12-31]",
+	string(schema.TypeDate):     "[2024-01-01 TO 2024-12-31]",
+}
+
+// SchemaDoc is the data a schema template is executed with
+type SchemaDoc struct {
+	Name      string
+	Dialects  []string // registered names of the dialects documented
+	Fields    []client.FieldDoc
+	Fragments []rsearch.FragmentDoc
+	Examples  []ExampleDoc
+}
+
+// ExampleDoc is an example query of a field, with its translation in every dialect
+type ExampleDoc struct {
+	Field        string
+	Query        string
+	Translations []TranslationDoc
+}
+
+// TranslationDoc is the translation of an example query in one dialect, or the error
+// translating it
+type TranslationDoc struct {
+	Dialect  string
+	Language string // language of Output, for code blocks: sql or json
+	Output   string
+	Error    string
+}
+
+// schemaSource is the API the schemas are documented from: a running server, or one
+// serving schema files in process
+type schemaSource interface {
+	ListSchemas(ctx context.Context) ([]*client.Schema, error)
+	Fields(ctx context.Context, name string) (*client.FieldsResponse, error)
+	Translate(ctx context.Context, req *client.TranslateRequest) (*client.TranslateResponse, error)
+}
+
+// serverSource documents the schemas registered on a running server
+func serverSource(baseURL, apiKey string) (schemaSource, error) {
+	var opts []client.Option
+	if apiKey != "" {
+		opts = append(opts, client.WithAPIKey(apiKey))
+	}
+	return client.New(baseURL, opts...)
+}
+
+// fileSource documents the schema files (.json, .yaml or .yml) of a directory, served
+// in process by the API handlers so that the documentation is the server's
+func fileSource(dir string) (schemaSource, error) {
+	var paths []string
+	for _, pattern := range []string{"*.json", "*.yaml", "*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no schema files in %s", dir)
+	}
+
+	schemaRegistry := schema.NewRegistry()
+	for _, path := range paths {
+		s, err := migration.LoadSchema(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := schemaRegistry.Register(s); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	translatorRegistry := translator.NewRegistry()
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+	translatorRegistry.Register("mysql", translator.NewMySQLTranslator())
+	translatorRegistry.Register("sqlite", translator.NewSQLiteTranslator())
+	translatorRegistry.Register("mongodb", translator.NewMongoDBTranslator())
+
+	r := chi.NewRouter()
+	r.Get("/api/v1/schemas", api.NewHandler(schemaRegistry).ListSchemas)
+	r.Get("/api/v1/schemas/{name}/fields", api.NewFieldsHandler(schemaRegistry, translatorRegistry).ServeHTTP)
+	r.Post("/api/v1/translate", api.NewTranslateHandler(schemaRegistry, translatorRegistry).ServeHTTP)
+
+	return client.New("http://gendocs", client.WithHTTPClient(&http.Client{Transport: handlerTransport{r}}))
+}
+
+// handlerTransport answers requests with a handler instead of the network
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	w := httptest.NewRecorder()
+	t.handler.ServeHTTP(w, req)
+	return w.Result(), nil
+}
+
+// generateSchemaDocs writes the documentation of every schema of a source to
+// <outDir>/<schema>.md, rendered with tmpl
+func generateSchemaDocs(ctx context.Context, source schemaSource, tmpl *template.Template, outDir string) ([]string, error) {
+	schemas, err := source.ListSchemas(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schemas: %w", err)
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, err
+	}
+
+	var written []string
+	for _, s := range schemas {
+		doc, err := buildSchemaDoc(ctx, source, s.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, doc); err != nil {
+			return nil, fmt.Errorf("failed to render schema %s: %w", s.Name, err)
+		}
+		path := filepath.Join(outDir, s.Name+".md")
+		if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+			return nil, err
+		}
+		written = append(written, path)
+	}
+	sort.Strings(written)
+	return written, nil
+}
+
+// buildSchemaDoc collects the field documentation of a schema, and translates an example
+// query of each field in every dialect
+func buildSchemaDoc(ctx context.Context, source schemaSource, name string) (*SchemaDoc, error) {
+	fields, err := source.Fields(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fields of schema %s: %w", name, err)
+	}
+
+	doc := &SchemaDoc{Name: name, Fields: fields.Fields, Fragments: fields.Fragments}
+	dialects := make(map[string]bool)
+	for _, field := range fields.Fields {
+		for dialect := range field.Dialects {
+			dialects[dialect] = true
+		}
+	}
+	for dialect := range dialects {
+		doc.Dialects = append(doc.Dialects, dialect)
+	}
+	sort.Strings(doc.Dialects)
+
+	for _, field := range fields.Fields {
+		examples := field.Examples
+		if len(examples) == 0 {
+			example, ok := typeExamples[field.Type]
+			if !ok {
+				continue
+			}
+			examples = []string{example}
+		}
+		for _, example := range examples {
+			query := field.Name + ":" + example
+			ex := ExampleDoc{Field: field.Name, Query: query}
+			for _, dialect := range doc.Dialects {
+				translation, err := translateExample(ctx, source, name, dialect, query)
+				if err != nil {
+					return nil, fmt.Errorf("failed to translate %s for %s: %w", query, dialect, err)
+				}
+				ex.Translations = append(ex.Translations, translation)
+			}
+			doc.Examples = append(doc.Examples, ex)
+		}
+	}
+	return doc, nil
+}
+
+// translateExample translates an example query. Queries the server rejects are documented
+// with the error; other errors, such as an unreachable server, are returned.
+func translateExample(ctx context.Context, source schemaSource, schemaName, dialect, query string) (TranslationDoc, error) {
+	result := TranslationDoc{Dialect: dialect}
+	resp, err := source.Translate(ctx, &client.TranslateRequest{Schema: schemaName, Database: dialect, Query: query})
+	if err != nil {
+		var apiErr *client.Error
+		if !errors.As(err, &apiErr) || apiErr.StatusCode >= http.StatusInternalServerError {
+			return result, err
+		}
+		result.Error = apiErr.Message
+		return result, nil
+	}
+
+	if resp.Type == "mongodb" {
+		filter, _ := json.MarshalIndent(resp.Filter, "", "  ")
+		result.Language = "json"
+		result.Output = string(filter)
+		return result, nil
+	}
+	result.Language = "sql"
+	result.Output = resp.WhereClause
+	if len(resp.Parameters) > 0 {
+		params, _ := json.Marshal(resp.Parameters)
+		result.Output += "\n-- parameters: " + string(params)
+	}
+	return result, nil
+}
+
+// parseSchemaTemplate parses the template at path, or the built-in one when path is empty
+func parseSchemaTemplate(path string) (*template.Template, error) {
+	text := defaultSchemaTemplate
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	return template.New("schema").Funcs(template.FuncMap{
+		"join": strings.Join,
+		// cell escapes text for a Markdown table cell
+		"cell": func(text string) string {
+			return strings.NewReplacer("|", "\\|", "\n", " ").Replace(text)
+		},
+		// operators lists the operators of a field in a dialect, or - when it has none
+		"operators": func(field client.FieldDoc, dialect string) string {
+			if ops := field.Dialects[dialect].Operators; len(ops) > 0 {
+				return strings.Join(ops, ", ")
+			}
+			return "-"
+		},
+	}).Parse(text)
+}
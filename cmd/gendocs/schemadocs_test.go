@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const productsSchema = `name: products
+fields:
+  name:
+    type: text
+    description: Product name
+    examples: ['laptop']
+  rodLength:
+    type: integer
+    examples: ['[10 TO 1]']
+  metadata:
+    type: json
+options:
+  namingConvention: snake_case
+`
+
+func TestGenerateSchemaDocs(t *testing.T) {
+	schemaDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(schemaDir, "products.yaml"), []byte(productsSchema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	source, err := fileSource(schemaDir)
+	if err != nil {
+		t.Fatalf("fileSource: %v", err)
+	}
+	tmpl, err := parseSchemaTemplate("")
+	if err != nil {
+		t.Fatalf("parseSchemaTemplate: %v", err)
+	}
+
+	outDir := t.TempDir()
+	written, err := generateSchemaDocs(context.Background(), source, tmpl, outDir)
+	if err != nil {
+		t.Fatalf("generateSchemaDocs: %v", err)
+	}
+	if len(written) != 1 || filepath.Base(written[0]) != "products.md" {
+		t.Fatalf("generateSchemaDocs() wrote %v, want products.md", written)
+	}
+	data, err := os.ReadFile(written[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := string(data)
+
+	for _, want := range []string{
+		"# products",
+		"| `rodLength` | integer | `rod_length` |  |  |",
+		"| Field | mongodb | mysql | postgres | sqlite |",
+		"### `name:laptop`",
+		"name = $1\n-- parameters: [\"laptop\"]",
+		"\"name\": \"laptop\"",
+		// Examples that do not translate are documented with the error
+		"### `rodLength:[10 TO 1]`",
+		"Rejected: ",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("documentation is missing %q:\n%s", want, doc)
+		}
+	}
+	// Fields without examples nor a type example have none
+	if strings.Contains(doc, "### `metadata:") {
+		t.Errorf("documentation has an example of metadata:\n%s", doc)
+	}
+}
+
+func TestGenerateSchemaDocs_Template(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.tmpl")
+	if err := os.WriteFile(path, []byte("{{.Name}}:{{range .Fields}} {{.Name}}={{operators . \"postgres\"}};{{end}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	schemaDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(schemaDir, "products.yaml"), []byte(productsSchema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+	if err := runSchemaDocs(schemaDir, "", "", path, outDir); err != nil {
+		t.Fatalf("runSchemaDocs: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(outDir, "products.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "products: metadata=") || !strings.Contains(string(data), "name=term, phrase") {
+		t.Errorf("template rendered %q", data)
+	}
+}
+
+func TestRunSchemaDocs_Errors(t *testing.T) {
+	if err := runSchemaDocs("schemas", "http://localhost:8080", "", "", t.TempDir()); err == nil {
+		t.Error("runSchemaDocs() accepted both -schemas and -server")
+	}
+	if err := runSchemaDocs(t.TempDir(), "", "", "", t.TempDir()); err == nil {
+		t.Error("runSchemaDocs() accepted a directory without schema files")
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/infiniv/rsearch/internal/migration"
+)
+
+// Exit codes of the assess command
+const (
+	assessOK         = 0 // compatibility at or above --fail-under
+	assessIncomplete = 1 // compatibility below --fail-under
+	assessUsage      = 2 // invalid arguments or unreadable input
+)
+
+// indexFlags collects repeated --index pattern=schema flags
+type indexFlags []migration.IndexMapping
+
+func (f *indexFlags) String() string {
+	mappings := make([]string, len(*f))
+	for i, mapping := range *f {
+		mappings[i] = mapping.Pattern + "=" + mapping.Schema
+	}
+	return strings.Join(mappings, ",")
+}
+
+func (f *indexFlags) Set(value string) error {
+	mapping, err := migration.ParseIndexMapping(value)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, mapping)
+	return nil
+}
+
+// runAssess implements `rsearch assess`, which extracts the query_string queries of
+// OpenSearch or Elasticsearch slow logs and audit logs and reports how many of them
+// translate under the schemas replacing their indices
+func runAssess(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("assess", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: rsearch assess --schemas schemas/ [options] slowlog.log [audit.json ...]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Extracts the query_string queries of OpenSearch or Elasticsearch search slow logs")
+		fmt.Fprintln(stderr, "and audit logs (- for stdin), translates them with the schema of their index and")
+		fmt.Fprintln(stderr, "reports the compatible queries and the reasons the others fail, per schema.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+
+	var mappings indexFlags
+	schemasDir := flags.String("schemas", "", "Directory of schema files (YAML or JSON)")
+	flags.Var(&mappings, "index", "Map indices to a schema, as pattern=schema with * globs (repeatable); indices map to the schema of the same name by default")
+	database := flags.String("database", "postgres", "Database to translate for: postgres, mysql, sqlite or mongodb")
+	format := flags.String("format", "text", "Report format: text or json")
+	failUnder := flags.Float64("fail-under", 0, "Exit with status 1 when less than this fraction of the queries is compatible, e.g. 0.95")
+
+	if err := flags.Parse(args); err != nil {
+		return assessUsage
+	}
+	if *schemasDir == "" || flags.NArg() == 0 {
+		fmt.Fprintln(stderr, "--schemas and at least one log file are required")
+		flags.Usage()
+		return assessUsage
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "invalid --format %q: must be text or json\n", *format)
+		return assessUsage
+	}
+	if *failUnder < 0 || *failUnder > 1 {
+		fmt.Fprintf(stderr, "invalid --fail-under %g: must be between 0 and 1\n", *failUnder)
+		return assessUsage
+	}
+
+	trans, err := newTranslatorRegistry().Get(*database)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return assessUsage
+	}
+
+	schemas, err := migration.LoadSchemas(*schemasDir)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return assessUsage
+	}
+	names := make(map[string]bool, len(schemas))
+	for _, s := range schemas {
+		names[s.Name] = true
+	}
+	for _, mapping := range mappings {
+		if !names[mapping.Schema] {
+			fmt.Fprintf(stderr, "invalid --index %s=%s: schema %s not found in %s\n", mapping.Pattern, mapping.Schema, mapping.Schema, *schemasDir)
+			return assessUsage
+		}
+	}
+
+	var scan migration.LogScan
+	for _, path := range flags.Args() {
+		logScan, err := readLog(path)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return assessUsage
+		}
+		scan.Queries = append(scan.Queries, logScan.Queries...)
+		scan.Unreadable += logScan.Unreadable
+	}
+
+	assessment := migration.Assess(scan, schemas, mappings, trans)
+
+	if *format == "json" {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(assessment)
+	} else {
+		err = assessment.WriteText(stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to write report: %v\n", err)
+		return assessUsage
+	}
+
+	if assessment.Compatible() < *failUnder {
+		return assessIncomplete
+	}
+	return assessOK
+}
+
+// readLog extracts the queries of a log file, or of stdin for -
+func readLog(path string) (migration.LogScan, error) {
+	if path == "-" {
+		return migration.ReadLogQueries(os.Stdin)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return migration.LogScan{}, fmt.Errorf("failed to open log: %v", err)
+	}
+	defer file.Close()
+
+	scan, err := migration.ReadLogQueries(file)
+	if err != nil {
+		return migration.LogScan{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return scan, nil
+}
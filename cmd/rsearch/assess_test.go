@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunAssess(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	schemas := filepath.Join(dir, "schemas")
+	if err := os.Mkdir(schemas, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write("schemas/products.yaml", "name: products\nfields:\n  name: {type: text}\n")
+	slowlog := write("slowlog.log", strings.Join([]string{
+		`[2025-01-01T12:00:00,000][WARN ][i.s.s.query] [node-1] [products-v2][0] took[1.2s], source[{"query":{"query_string":{"query":"name:laptop"}}}], id[],`,
+		`[2025-01-01T12:00:01,000][WARN ][i.s.s.query] [node-1] [products-v2][0] took[1.2s], source[{"query":{"query_string":{"query":"sku:A-1"}}}], id[],`,
+	}, "\n"))
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+	}{
+		{"report", []string{"--schemas", schemas, "--index", "products-*=products", slowlog}, assessOK},
+		{"json report", []string{"--schemas", schemas, "--index", "products-*=products", "--format", "json", slowlog}, assessOK},
+		{"below fail-under", []string{"--schemas", schemas, "--index", "products-*=products", "--fail-under", "0.9", slowlog}, assessIncomplete},
+		{"at fail-under", []string{"--schemas", schemas, "--index", "products-*=products", "--fail-under", "0.5", slowlog}, assessOK},
+		{"unknown schema in mapping", []string{"--schemas", schemas, "--index", "products-*=catalog", slowlog}, assessUsage},
+		{"invalid mapping", []string{"--schemas", schemas, "--index", "products", slowlog}, assessUsage},
+		{"missing log", []string{"--schemas", schemas}, assessUsage},
+		{"unreadable log", []string{"--schemas", schemas, filepath.Join(dir, "missing.log")}, assessUsage},
+		{"unknown database", []string{"--schemas", schemas, "--database", "oracle", slowlog}, assessUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := runAssess(tt.args, &stdout, &stderr); code != tt.wantCode {
+				t.Errorf("runAssess() = %d, want %d\nstdout: %s\nstderr: %s", code, tt.wantCode, stdout.String(), stderr.String())
+			}
+		})
+	}
+}
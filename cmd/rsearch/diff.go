@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/infiniv/rsearch/internal/migration"
+)
+
+// Exit codes of the diff command
+const (
+	diffOK         = 0 // no query affected beyond --fail-on
+	diffRegression = 1 // some queries break (or change, with --fail-on=changed)
+	diffUsage      = 2 // invalid arguments or unreadable input
+)
+
+// runDiff implements `rsearch diff`, which translates a query corpus under two
+// schema versions and reports the queries that break or change
+func runDiff(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: rsearch diff --schema-old v1.yaml --schema-new v2.yaml --queries queries.txt [options]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Translates every query (one per line, # for comments) under both schema versions")
+		fmt.Fprintln(stderr, "and reports the queries that break or whose translation changes.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+
+	oldPath := flags.String("schema-old", "", "Current schema (YAML or JSON)")
+	newPath := flags.String("schema-new", "", "Proposed schema (YAML or JSON)")
+	queriesPath := flags.String("queries", "", "Query corpus, one query per line (- for stdin)")
+	database := flags.String("database", "postgres", "Database to translate for: postgres, mysql, sqlite or mongodb")
+	format := flags.String("format", "text", "Report format: text or json")
+	failOn := flags.String("fail-on", "broken", "Exit with status 1 when queries are: broken, changed (broken or changed) or none")
+
+	if err := flags.Parse(args); err != nil {
+		return diffUsage
+	}
+	if *oldPath == "" || *newPath == "" || *queriesPath == "" {
+		fmt.Fprintln(stderr, "--schema-old, --schema-new and --queries are required")
+		flags.Usage()
+		return diffUsage
+	}
+
+	var failStatuses []migration.Status
+	switch *failOn {
+	case "broken":
+		failStatuses = []migration.Status{migration.StatusBroken}
+	case "changed":
+		failStatuses = []migration.Status{migration.StatusBroken, migration.StatusChanged}
+	case "none":
+	default:
+		fmt.Fprintf(stderr, "invalid --fail-on %q: must be broken, changed or none\n", *failOn)
+		return diffUsage
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "invalid --format %q: must be text or json\n", *format)
+		return diffUsage
+	}
+
+	trans, err := newTranslatorRegistry().Get(*database)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return diffUsage
+	}
+
+	oldSchema, err := migration.LoadSchema(*oldPath)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return diffUsage
+	}
+	newSchema, err := migration.LoadSchema(*newPath)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return diffUsage
+	}
+
+	input := io.Reader(os.Stdin)
+	if *queriesPath != "-" {
+		file, err := os.Open(*queriesPath)
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to open queries: %v\n", err)
+			return diffUsage
+		}
+		defer file.Close()
+		input = file
+	}
+	queries, err := migration.ReadQueries(input)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return diffUsage
+	}
+
+	report := migration.Compare(oldSchema, newSchema, trans, queries)
+
+	if *format == "json" {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	} else {
+		err = report.WriteText(stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to write report: %v\n", err)
+		return diffUsage
+	}
+
+	if report.Count(failStatuses...) > 0 {
+		return diffRegression
+	}
+	return diffOK
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunDiff(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	oldSchema := write("v1.yaml", "name: products\nfields:\n  name: {type: text}\n  sku: {type: text}\n")
+	newSchema := write("v2.yaml", "name: products\nfields:\n  name: {type: text, column: title}\n")
+	queries := write("queries.txt", "name:laptop\nsku:A-1\n")
+	safeQueries := write("safe.txt", "name:laptop\n")
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+	}{
+		{"broken query", []string{"--schema-old", oldSchema, "--schema-new", newSchema, "--queries", queries}, diffRegression},
+		{"changed only", []string{"--schema-old", oldSchema, "--schema-new", newSchema, "--queries", safeQueries}, diffOK},
+		{"fail on changed", []string{"--schema-old", oldSchema, "--schema-new", newSchema, "--queries", safeQueries, "--fail-on", "changed"}, diffRegression},
+		{"fail on none", []string{"--schema-old", oldSchema, "--schema-new", newSchema, "--queries", queries, "--fail-on", "none", "--format", "json"}, diffOK},
+		{"missing flags", []string{"--schema-old", oldSchema}, diffUsage},
+		{"unknown database", []string{"--schema-old", oldSchema, "--schema-new", newSchema, "--queries", queries, "--database", "oracle"}, diffUsage},
+		{"missing schema file", []string{"--schema-old", filepath.Join(dir, "missing.yaml"), "--schema-new", newSchema, "--queries", queries}, diffUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := runDiff(tt.args, &stdout, &stderr); code != tt.wantCode {
+				t.Errorf("runDiff() = %d, want %d\nstdout: %s\nstderr: %s", code, tt.wantCode, stdout.String(), stderr.String())
+			}
+		})
+	}
+}
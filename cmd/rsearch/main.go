@@ -9,16 +9,33 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/google/uuid"
+	"github.com/infiniv/rsearch/internal/analytics"
 	"github.com/infiniv/rsearch/internal/api"
+	"github.com/infiniv/rsearch/internal/cluster"
 	"github.com/infiniv/rsearch/internal/config"
+	"github.com/infiniv/rsearch/internal/events"
+	"github.com/infiniv/rsearch/internal/executor"
 	"github.com/infiniv/rsearch/internal/observability"
 	"github.com/infiniv/rsearch/internal/ratelimit"
 	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/shadow"
 	"github.com/infiniv/rsearch/internal/translator"
 	"github.com/infiniv/rsearch/pkg/rsearch"
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		os.Exit(runDiff(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "assess" {
+		os.Exit(runAssess(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "version" {
+		os.Exit(runVersion(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	// Parse command line flags
 	configPath := flag.String("config", "", "Path to configuration file")
 	flag.Parse()
@@ -37,7 +54,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	logger.Infof("Starting rsearch v%s", rsearch.Version)
+	logger.Infof("Starting rsearch %s", rsearch.ReadBuildInfo())
 	logger.Infof("Server will listen on %s", cfg.GetAddress())
 
 	// Initialize metrics if enabled
@@ -49,14 +66,73 @@ func main() {
 
 	// Initialize schema registry
 	schemaRegistry := schema.NewRegistry()
+	for _, codecConfig := range cfg.Security.Codecs {
+		c, err := codecConfig.NewCodec()
+		if err != nil {
+			logger.Fatalf("Failed to create codec %s: %v", codecConfig.Name, err)
+		}
+		if err := schemaRegistry.RegisterCodec(codecConfig.Name, c); err != nil {
+			logger.Fatalf("Failed to register codec %s: %v", codecConfig.Name, err)
+		}
+	}
+	for _, hashKeyConfig := range cfg.Security.HashKeys {
+		secret, err := hashKeyConfig.Secret()
+		if err != nil {
+			logger.Fatalf("Failed to read hash key %s: %v", hashKeyConfig.Name, err)
+		}
+		if err := schemaRegistry.RegisterHashKey(hashKeyConfig.Name, secret); err != nil {
+			logger.Fatalf("Failed to register hash key %s: %v", hashKeyConfig.Name, err)
+		}
+	}
+	for _, dictionaryConfig := range cfg.Schemas.Dictionaries {
+		if err := schemaRegistry.RegisterDictionary(dictionaryConfig.Name, dictionaryConfig.NewDictionary()); err != nil {
+			logger.Fatalf("Failed to register dictionary %s: %v", dictionaryConfig.Name, err)
+		}
+	}
 	logger.Info("Schema registry initialized")
 
+	// Propagate schema changes to other replicas if enabled
+	if cfg.Cluster.Enabled {
+		nodeID := cfg.Cluster.NodeID
+		if nodeID == "" {
+			nodeID = uuid.New().String()
+		}
+		transport, err := cluster.NewNATSTransport(cfg.Cluster.NATSURL, cfg.Cluster.Subject, cfg.Cluster.ConnectTimeout)
+		if err != nil {
+			logger.Fatalf("Failed to initialize cluster transport: %v", err)
+		}
+		syncer := cluster.NewSyncer(schemaRegistry, transport, nodeID, logger)
+		if err := syncer.Start(); err != nil {
+			logger.Fatalf("Failed to start cluster sync: %v", err)
+		}
+		defer syncer.Stop()
+		logger.Infof("Cluster schema sync enabled on %s (subject %s, node %s)", cfg.Cluster.NATSURL, cfg.Cluster.Subject, nodeID)
+	}
+
+	// Notify external systems of schema changes if enabled
+	if cfg.Events.Enabled {
+		var sinks []events.Sink
+		for _, webhook := range cfg.Events.Webhooks {
+			sinks = append(sinks, events.NewWebhookSink(webhook.URL, webhook.Secret, cfg.Events.Timeout))
+		}
+		if cfg.Events.NATSSubject != "" {
+			transport, err := cluster.NewNATSTransport(cfg.Cluster.NATSURL, cfg.Events.NATSSubject, cfg.Cluster.ConnectTimeout)
+			if err != nil {
+				logger.Fatalf("Failed to initialize event transport: %v", err)
+			}
+			defer transport.Close()
+			sinks = append(sinks, events.NewPublisherSink("nats:"+cfg.Events.NATSSubject, transport))
+		}
+
+		emitter := events.NewEmitter(logger, cfg.Events.QueueSize, sinks...).
+			WithRetries(cfg.Events.MaxRetries, events.DefaultBackoff)
+		emitter.Start(schemaRegistry)
+		defer emitter.Stop()
+		logger.Infof("Schema change events enabled (%d webhooks, NATS subject %q)", len(cfg.Events.Webhooks), cfg.Events.NATSSubject)
+	}
+
 	// Initialize translator registry with all supported databases
-	translatorRegistry := translator.NewRegistry()
-	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
-	translatorRegistry.Register("mysql", translator.NewMySQLTranslator())
-	translatorRegistry.Register("sqlite", translator.NewSQLiteTranslator())
-	translatorRegistry.Register("mongodb", translator.NewMongoDBTranslator())
+	translatorRegistry := newTranslatorRegistry()
 	logger.Info("Translator registry initialized with PostgreSQL, MySQL, SQLite, and MongoDB support")
 
 	// Initialize rate limiter
@@ -67,8 +143,54 @@ func main() {
 			cfg.Limits.RateLimit.RequestsPerMinute, cfg.Limits.RateLimit.Burst)
 	}
 
+	// Initialize query usage analytics if enabled
+	var tracker *analytics.Tracker
+	if cfg.Analytics.Enabled {
+		tracker = analytics.NewTracker(metrics)
+		tracker.Start(cfg.Analytics.SnapshotInterval)
+		defer tracker.Stop()
+		logger.Infof("Query usage analytics enabled (snapshot every %s)", cfg.Analytics.SnapshotInterval)
+	}
+
+	// Open the database translations are run on, if enabled
+	var queryExecutor *executor.Executor
+	if cfg.Executor.Enabled {
+		queryExecutor, err = executor.Open(cfg.Executor.Database, cfg.Executor.DSN, cfg.Executor.Tables)
+		if err != nil {
+			logger.Fatalf("Failed to initialize executor: %v", err)
+		}
+		defer queryExecutor.Close()
+		logger.Infof("Executor enabled: %s translations can be counted and explained on the database", cfg.Executor.Database)
+	}
+
+	// Initialize the shadow comparison with OpenSearch if enabled
+	var comparer *shadow.Comparer
+	if cfg.Shadow.Enabled {
+		counter := shadow.NewOpenSearch(cfg.Shadow.OpenSearch.URL, cfg.Shadow.OpenSearch.Indices, &http.Client{Timeout: cfg.Shadow.Timeout}).
+			WithBasicAuth(cfg.Shadow.OpenSearch.Username, cfg.Shadow.OpenSearch.Password)
+		comparer = shadow.NewComparer(queryExecutor.Database(), queryExecutor, counter, logger, metrics).
+			WithSampling(cfg.Shadow.SampleRate).
+			WithTimeout(cfg.Shadow.Timeout).
+			WithQueueSize(cfg.Shadow.QueueSize)
+		comparer.Start()
+		defer comparer.Stop()
+		logger.Infof("Shadow comparison enabled: %s translations compared with %s (sample rate %g)",
+			queryExecutor.Database(), cfg.Shadow.OpenSearch.URL, cfg.Shadow.SampleRate)
+	}
+
+	// Save the plan cache to disk, and warm it from the last save, if enabled
+	var planStore *api.PlanStore
+	if cfg.Cache.Enabled && cfg.Cache.Persist.Path != "" {
+		planStore = api.NewPlanStore(cfg.Cache.Persist.Path, logger)
+	}
+
 	// Setup routes
-	router := api.SetupRoutes(cfg, logger, metrics, schemaRegistry, translatorRegistry, rateLimiter)
+	router := api.SetupRoutes(cfg, logger, metrics, schemaRegistry, translatorRegistry, rateLimiter, tracker, comparer, planStore, queryExecutor)
+	if planStore != nil {
+		planStore.Start(cfg.Cache.Persist.Interval)
+		defer planStore.Stop()
+		logger.Infof("Plan cache saved to %s every %s", cfg.Cache.Persist.Path, cfg.Cache.Persist.Interval)
+	}
 
 	// Create HTTP server
 	server := &http.Server{
@@ -88,9 +210,18 @@ func main() {
 	// Start metrics server if enabled
 	var metricsServer *http.Server
 	if cfg.Metrics.Enabled && metrics != nil {
+		metricsHandler := metrics.Handler()
+		if cfg.Metrics.Debug.Enabled {
+			// Profiles and runtime statistics, for API keys only
+			mux := http.NewServeMux()
+			mux.Handle("/debug/", observability.DebugHandler(cfg.Metrics.Debug.APIKeys))
+			mux.Handle("/", metricsHandler)
+			metricsHandler = mux
+			logger.Infof("Debug endpoints enabled on %s/debug/pprof/ and /debug/runtime", cfg.GetMetricsAddress())
+		}
 		metricsServer = &http.Server{
 			Addr:    cfg.GetMetricsAddress(),
-			Handler: metrics.Handler(),
+			Handler: metricsHandler,
 		}
 		go func() {
 			logger.Infof("Metrics server listening on %s", cfg.GetMetricsAddress())
@@ -136,3 +267,13 @@ func main() {
 		logger.Info("Server stopped gracefully")
 	}
 }
+
+// newTranslatorRegistry registers the translators of all supported databases
+func newTranslatorRegistry() *translator.Registry {
+	registry := translator.NewRegistry()
+	registry.Register("postgres", translator.NewPostgresTranslator())
+	registry.Register("mysql", translator.NewMySQLTranslator())
+	registry.Register("sqlite", translator.NewSQLiteTranslator())
+	registry.Register("mongodb", translator.NewMongoDBTranslator())
+	return registry
+}
@@ -56,7 +56,7 @@ func TestServerIntegration(t *testing.T) {
 	rateLimiter := ratelimit.NewRateLimiter(100, 10)
 	defer rateLimiter.Stop()
 
-	router := api.SetupRoutes(cfg, logger, nil, schemaRegistry, translatorRegistry, rateLimiter)
+	router := api.SetupRoutes(cfg, logger, nil, schemaRegistry, translatorRegistry, rateLimiter, nil, nil, nil, nil)
 
 	// Create server
 	server := &http.Server{
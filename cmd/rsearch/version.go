@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// runVersion implements `rsearch version`, which prints the build of the binary
+func runVersion(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("version", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: rsearch version [--format text|json]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Prints the version, commit and build date of the binary, as GET /version does.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+
+	format := flags.String("format", "text", "Output format: text or json")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	build := rsearch.ReadBuildInfo()
+	switch *format {
+	case "text":
+		fmt.Fprintf(stdout, "rsearch %s\n", build)
+	case "json":
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(build); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	default:
+		fmt.Fprintf(stderr, "invalid --format %q: must be text or json\n", *format)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+func TestRunVersion(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runVersion(nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("runVersion() = %d, stderr: %s", code, stderr.String())
+	}
+	if want := "rsearch " + rsearch.ReadBuildInfo().String() + "\n"; stdout.String() != want {
+		t.Errorf("output = %q, want %q", stdout.String(), want)
+	}
+
+	stdout.Reset()
+	if code := runVersion([]string{"--format", "json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("runVersion(--format json) = %d, stderr: %s", code, stderr.String())
+	}
+	var build rsearch.BuildInfo
+	if err := json.Unmarshal(stdout.Bytes(), &build); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if build != rsearch.ReadBuildInfo() {
+		t.Errorf("build = %+v, want %+v", build, rsearch.ReadBuildInfo())
+	}
+
+	stderr.Reset()
+	if code := runVersion([]string{"--format", "yaml"}, &stdout, &stderr); code != 2 {
+		t.Errorf("runVersion(--format yaml) = %d, want 2", code)
+	}
+	if !strings.Contains(stderr.String(), "invalid --format") {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+}
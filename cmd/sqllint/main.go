@@ -0,0 +1,315 @@
+// Command sqllint checks that the SQL emitters only interpolate trusted text into SQL.
+//
+// Translators bind every query value as a parameter; the only text of a query that ends up
+// in SQL is a column name, resolved and validated against the schema. sqllint parses the
+// non-test Go files of the given directories and reports each fmt.Sprintf whose %s, %q or
+// %v arguments are not trusted: string constants, resolved column names, or SQL fragments
+// the emitters built themselves, which are named after what they hold (see trustedNames).
+// Numeric verbs are not checked, as go vet rejects non-numeric arguments for them, and
+// neither are Error methods, whose text never ends up in SQL.
+//
+// Arguments trusted for a reason the names cannot tell, such as an operator picked from a
+// fixed set, are accepted with a comment stating the reason, on the line of the call, or
+// of the statement holding it, or the line above. The comment covers the whole statement,
+// so one comment above a switch covers all its cases:
+//
+//	// sqllint:trusted cond.Op is one of the havingOperators
+//
+// Usage:
+//
+//	go run ./cmd/sqllint [dir ...]
+//
+// Without arguments it checks internal/translator. It exits with status 1 when it finds
+// untrusted arguments.
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultDirs are the packages emitting SQL
+var defaultDirs = []string{"internal/translator"}
+
+// trustedNames are the variables holding resolved column names, or SQL fragments built by
+// the emitters from column names, placeholders and constants
+var trustedNames = map[string]bool{
+	// Resolved column names
+	"columnName": true,
+	"column":     true,
+	// Collation names, validated when schemas are registered
+	"collation": true,
+	// SQL operators picked by the emitters, such as = or NOT IN, and AND or OR from
+	// sqlBooleanOperator
+	"op":       true,
+	"operator": true,
+	// Placeholders and SQL fragments
+	"placeholder":  true,
+	"placeholders": true,
+	"left":         true,
+	"right":        true,
+	"leftClause":   true,
+	"rightClause":  true,
+	"operand":      true,
+	"inner":        true,
+	"clause":       true,
+	"clauses":      true,
+}
+
+// trustedFields are the struct fields holding trusted text
+var trustedFields = map[string]bool{
+	// schema.Field.Collation, validated when schemas are registered
+	"Collation": true,
+}
+
+// trustedMarker marks a statement whose arguments are trusted for a stated reason
+const trustedMarker = "sqllint:trusted"
+
+// Finding is an untrusted argument interpolated into SQL text
+type Finding struct {
+	Pos      token.Position
+	Argument string // the argument as written
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: fmt.Sprintf interpolates %s into SQL text; bind it as a parameter, or mark the line %q with the reason it is trusted",
+		f.Pos, f.Argument, "// "+trustedMarker)
+}
+
+func main() {
+	dirs := os.Args[1:]
+	if len(dirs) == 0 {
+		dirs = defaultDirs
+	}
+
+	var findings []Finding
+	for _, dir := range dirs {
+		found, err := lintDir(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error checking %s: %v\n", dir, err)
+			os.Exit(2)
+		}
+		findings = append(findings, found...)
+	}
+
+	for _, f := range findings {
+		fmt.Println(f)
+	}
+	if len(findings) > 0 {
+		os.Exit(1)
+	}
+}
+
+// lintDir checks the non-test Go files of a directory
+func lintDir(dir string) ([]Finding, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	fset := token.NewFileSet()
+	var findings []Finding
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, lintFile(fset, file)...)
+	}
+	return findings, nil
+}
+
+// lintFile reports the untrusted arguments of the fmt.Sprintf calls of a file
+func lintFile(fset *token.FileSet, file *ast.File) []Finding {
+	trustedLines := make(map[int]bool)
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			if strings.Contains(comment.Text, trustedMarker) {
+				// The reason may continue on the following lines of the comment
+				for line := fset.Position(comment.Pos()).Line; line <= fset.Position(group.End()).Line+1; line++ {
+					trustedLines[line] = true
+				}
+			}
+		}
+	}
+	constants := fileConstants(file)
+
+	var findings []Finding
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.FuncDecl:
+			if n.Recv != nil && n.Name.Name == "Error" {
+				return false
+			}
+		case ast.Stmt:
+			if trustedLines[fset.Position(n.Pos()).Line] {
+				return false
+			}
+		}
+		call, ok := node.(*ast.CallExpr)
+		if !ok || !isSprintf(call) || len(call.Args) == 0 {
+			return true
+		}
+		pos := fset.Position(call.Pos())
+		if trustedLines[pos.Line] {
+			return true
+		}
+
+		format, ok := stringLiteral(call.Args[0])
+		if !ok {
+			findings = append(findings, Finding{Pos: pos, Argument: "a format that is not a constant"})
+			return true
+		}
+		for _, v := range verbs(format) {
+			if v.arg+1 >= len(call.Args) {
+				continue
+			}
+			arg := call.Args[v.arg+1]
+			if !strings.ContainsRune("sqvx", v.verb) || trusted(arg, constants) {
+				continue
+			}
+			findings = append(findings, Finding{Pos: fset.Position(arg.Pos()), Argument: expression(fset, arg)})
+		}
+		return true
+	})
+	return findings
+}
+
+// isSprintf reports whether a call is fmt.Sprintf
+func isSprintf(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Sprintf" {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "fmt"
+}
+
+// verbUse is a verb of a format string and the index of the argument it formats
+type verbUse struct {
+	verb rune
+	arg  int
+}
+
+// verbs returns the verbs of a format string with the arguments they format. Widths and
+// precisions given as * consume an argument; explicit indexes such as %[2]s select one.
+func verbs(format string) []verbUse {
+	var found []verbUse
+	arg := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		for i++; i < len(format); i++ {
+			c := format[i]
+			if c == '*' {
+				arg++
+				continue
+			}
+			if c == '[' {
+				end := strings.IndexByte(format[i:], ']')
+				if end < 0 {
+					return found
+				}
+				if n, err := strconv.Atoi(format[i+1 : i+end]); err == nil {
+					arg = n - 1
+				}
+				i += end
+				continue
+			}
+			if strings.IndexByte("+-# 0123456789.", c) < 0 {
+				break
+			}
+		}
+		if i < len(format) && format[i] != '%' {
+			found = append(found, verbUse{verb: rune(format[i]), arg: arg})
+			arg++
+		}
+	}
+	return found
+}
+
+// trusted reports whether an argument is a constant, a trusted name, a call joining or
+// repeating trusted text, or the result of another fmt.Sprintf
+func trusted(arg ast.Expr, constants map[string]bool) bool {
+	switch a := arg.(type) {
+	case *ast.BasicLit:
+		return true
+	case *ast.Ident:
+		return trustedNames[a.Name] || constants[a.Name]
+	case *ast.SelectorExpr:
+		return trustedFields[a.Sel.Name]
+	case *ast.ParenExpr:
+		return trusted(a.X, constants)
+	case *ast.IndexExpr:
+		// An element of a trusted slice, such as placeholders[i]
+		return trusted(a.X, constants)
+	case *ast.BinaryExpr:
+		return a.Op == token.ADD && trusted(a.X, constants) && trusted(a.Y, constants)
+	case *ast.CallExpr:
+		if isSprintf(a) {
+			// Checked on its own
+			return true
+		}
+		if fun, ok := a.Fun.(*ast.SelectorExpr); ok {
+			if pkg, ok := fun.X.(*ast.Ident); ok && pkg.Name == "strings" {
+				switch fun.Sel.Name {
+				case "Join", "TrimSuffix":
+					return len(a.Args) == 2 && trusted(a.Args[0], constants) && trusted(a.Args[1], constants)
+				case "Repeat":
+					// The count is an int
+					return len(a.Args) == 2 && trusted(a.Args[0], constants)
+				}
+			}
+		}
+	}
+	return false
+}
+
+// fileConstants returns the names of the string constants declared in a file
+func fileConstants(file *ast.File) map[string]bool {
+	constants := make(map[string]bool)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			for _, name := range spec.(*ast.ValueSpec).Names {
+				constants[name.Name] = true
+			}
+		}
+	}
+	return constants
+}
+
+// stringLiteral returns the value of a string literal
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(lit.Value)
+	return value, err == nil
+}
+
+// expression returns the source of an expression, for reports
+func expression(fset *token.FileSet, expr ast.Expr) string {
+	var sb strings.Builder
+	if err := printer.Fprint(&sb, fset, expr); err != nil {
+		return "an argument"
+	}
+	return sb.String()
+}
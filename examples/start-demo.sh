@@ -25,7 +25,7 @@ if [ ! -f "bin/rsearch" ]; then
     echo "Building rsearch..."
     export PATH="/usr/local/go/bin:$PATH"
     export GOPATH="$HOME/go"
-    go build -o bin/rsearch cmd/rsearch/main.go
+    go build -o bin/rsearch ./cmd/rsearch
 fi
 
 # Create demo config with CORS enabled
@@ -0,0 +1,355 @@
+// Package analytics tracks how schemas are queried: which fields and operators
+// are used, how large queries are and how often they fail. The statistics are
+// kept in memory and are meant to inform index design and schema cleanup.
+package analytics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/observability"
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// Tracker accumulates per-schema query statistics
+type Tracker struct {
+	mu      sync.Mutex
+	schemas map[string]*schemaStats
+	metrics *observability.Metrics
+
+	latest   Snapshot
+	hasTaken bool
+
+	runMu    sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// schemaStats holds the raw counters of one schema
+type schemaStats struct {
+	queries       int64
+	errors        int64
+	clauses       int64
+	unknownFields int64
+	fields        map[string]int64
+	operators     map[string]int64
+	shapes        map[string]int64 // normalized query hash -> count
+}
+
+// Snapshot is a point-in-time copy of all statistics
+type Snapshot struct {
+	GeneratedAt time.Time                `json:"generatedAt"`
+	Schemas     map[string]SchemaSummary `json:"schemas"`
+}
+
+// SchemaSummary summarizes the queries run against one schema
+type SchemaSummary struct {
+	Queries           int64        `json:"queries"`
+	Errors            int64        `json:"errors"`
+	ErrorRate         float64      `json:"errorRate"`
+	AverageClauses    float64      `json:"averageClauses"`
+	UnknownFieldUsage int64        `json:"unknownFieldUsage"`
+	TopFields         []UsageCount `json:"topFields"`
+	Operators         []UsageCount `json:"operators"`
+	DistinctQueries   int64        `json:"distinctQueries"` // lower bound once maxTrackedQueries is reached
+	TopQueries        []UsageCount `json:"topQueries"`      // named by normalized query hash
+}
+
+// UsageCount is the number of times a field or operator was used
+type UsageCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// maxTopFields caps the number of fields reported per schema
+const maxTopFields = 20
+
+// maxTopQueries caps the number of normalized queries reported per schema
+const maxTopQueries = 20
+
+// maxTrackedQueries caps the number of distinct normalized queries counted per schema,
+// so that memory stays bounded when every query is different
+const maxTrackedQueries = 10000
+
+// NewTracker creates a tracker. metrics may be nil to skip Prometheus export.
+func NewTracker(metrics *observability.Metrics) *Tracker {
+	return &Tracker{
+		schemas:  make(map[string]*schemaStats),
+		metrics:  metrics,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Record adds a query to the statistics of its schema.
+// ast may be nil when the query failed to parse; err is the parse or translation error, if any.
+func (t *Tracker) Record(sch *schema.Schema, ast parser.Node, err error) {
+	if sch == nil {
+		return
+	}
+
+	fields, unknown, operators, clauses := inspect(sch, ast)
+
+	// Equivalent queries such as "a AND b" and "b AND a" count as one
+	var hash string
+	if ast != nil {
+		hash = parser.Hash(ast)
+	}
+
+	t.mu.Lock()
+	stats, exists := t.schemas[sch.Name]
+	if !exists {
+		stats = &schemaStats{
+			fields:    make(map[string]int64),
+			operators: make(map[string]int64),
+			shapes:    make(map[string]int64),
+		}
+		t.schemas[sch.Name] = stats
+	}
+	stats.queries++
+	if err != nil {
+		stats.errors++
+	}
+	stats.clauses += int64(clauses)
+	stats.unknownFields += int64(unknown)
+	for _, field := range fields {
+		stats.fields[field]++
+	}
+	for _, op := range operators {
+		stats.operators[op]++
+	}
+	if _, seen := stats.shapes[hash]; hash != "" && (seen || len(stats.shapes) < maxTrackedQueries) {
+		stats.shapes[hash]++
+	}
+	t.mu.Unlock()
+
+	if t.metrics != nil {
+		t.metrics.RecordSchemaQuery(sch.Name, err == nil)
+		for _, field := range fields {
+			t.metrics.RecordFieldUsage(sch.Name, field)
+		}
+		for _, op := range operators {
+			t.metrics.RecordOperatorUsage(sch.Name, op)
+		}
+	}
+}
+
+// inspect extracts the resolved fields, unknown field count, operators and clause count of a query
+func inspect(sch *schema.Schema, ast parser.Node) (fields []string, unknown int, operators []string, clauses int) {
+	parser.Walk(ast, func(node parser.Node) bool {
+		if op := operatorName(node); op != "" {
+			operators = append(operators, op)
+		}
+
+		field := fieldName(node)
+		if field != "" {
+			if column, _, err := sch.ResolveField(field); err == nil {
+				fields = append(fields, column)
+			} else {
+				unknown++
+			}
+		}
+
+		switch node.(type) {
+		case *parser.BinaryOp, *parser.UnaryOp, *parser.GroupQuery, *parser.BoostQuery,
+			*parser.RequiredQuery, *parser.ProhibitedQuery:
+			// Structural nodes wrap clauses but are not clauses themselves
+		default:
+			clauses++
+		}
+
+		// Field group members are values of the group's field, not separate clauses
+		_, isFieldGroup := node.(*parser.FieldGroupQuery)
+		return !isFieldGroup
+	})
+	return fields, unknown, operators, clauses
+}
+
+// fieldName returns the field a node queries, if any
+func fieldName(node parser.Node) string {
+	switch n := node.(type) {
+	case *parser.FieldQuery:
+		return n.Field
+	case *parser.FieldGroupQuery:
+		return n.Field
+	case *parser.InListQuery:
+		return n.Field
+	case *parser.RangeQuery:
+		return n.Field
+	case *parser.FuzzyQuery:
+		return n.Field
+	case *parser.ProximityQuery:
+		return n.Field
+	case *parser.ExistsQuery:
+		return n.Field
+	}
+	return ""
+}
+
+// operatorName returns the operator a node represents, if any
+func operatorName(node parser.Node) string {
+	switch n := node.(type) {
+	case *parser.BinaryOp:
+		return strings.ToLower(n.Op)
+	case *parser.UnaryOp:
+		return "not"
+	case *parser.RequiredQuery:
+		return "required"
+	case *parser.ProhibitedQuery:
+		return "prohibited"
+	case *parser.RangeQuery:
+		return "range"
+	case *parser.FuzzyQuery:
+		return "fuzzy"
+	case *parser.ProximityQuery:
+		return "proximity"
+	case *parser.ExistsQuery:
+		return "exists"
+	case *parser.BoostQuery:
+		return "boost"
+	case *parser.FieldGroupQuery:
+		return "field_group"
+	case *parser.InListQuery:
+		return "in"
+	case *parser.WildcardQuery:
+		return "wildcard"
+	case *parser.TermQuery:
+		return "term"
+	case *parser.PhraseQuery:
+		return "phrase"
+	case *parser.FieldQuery:
+		switch n.Value.(type) {
+		case *parser.WildcardValue:
+			return "wildcard"
+		case *parser.RegexValue:
+			return "regex"
+		case *parser.PhraseValue:
+			return "phrase"
+		}
+		return "term"
+	}
+	return ""
+}
+
+// Snapshot computes the current statistics
+func (t *Tracker) Snapshot() Snapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshot := Snapshot{
+		GeneratedAt: time.Now().UTC(),
+		Schemas:     make(map[string]SchemaSummary, len(t.schemas)),
+	}
+
+	for name, stats := range t.schemas {
+		summary := SchemaSummary{
+			Queries:           stats.queries,
+			Errors:            stats.errors,
+			UnknownFieldUsage: stats.unknownFields,
+			TopFields:         sortedCounts(stats.fields, maxTopFields),
+			Operators:         sortedCounts(stats.operators, 0),
+			DistinctQueries:   int64(len(stats.shapes)),
+			TopQueries:        sortedCounts(stats.shapes, maxTopQueries),
+		}
+		if stats.queries > 0 {
+			summary.ErrorRate = float64(stats.errors) / float64(stats.queries)
+			summary.AverageClauses = float64(stats.clauses) / float64(stats.queries)
+		}
+		snapshot.Schemas[name] = summary
+	}
+
+	return snapshot
+}
+
+// sortedCounts orders usage counts by descending count, then name. limit <= 0 means no limit.
+func sortedCounts(counts map[string]int64, limit int) []UsageCount {
+	result := make([]UsageCount, 0, len(counts))
+	for name, count := range counts {
+		result = append(result, UsageCount{Name: name, Count: count})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// Start refreshes the latest snapshot every interval until Stop is called
+func (t *Tracker) Start(interval time.Duration) {
+	t.runMu.Lock()
+	defer t.runMu.Unlock()
+
+	if t.running {
+		return
+	}
+
+	t.running = true
+	t.wg.Add(1)
+
+	go func() {
+		defer t.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				t.takeSnapshot()
+			case <-t.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts periodic snapshotting
+func (t *Tracker) Stop() {
+	t.runMu.Lock()
+	defer t.runMu.Unlock()
+
+	if !t.running {
+		return
+	}
+
+	t.running = false
+	close(t.stopChan)
+	t.wg.Wait()
+
+	// Recreate stopChan for potential restart
+	t.stopChan = make(chan struct{})
+}
+
+// takeSnapshot stores a fresh snapshot as the latest one
+func (t *Tracker) takeSnapshot() {
+	snapshot := t.Snapshot()
+
+	t.mu.Lock()
+	t.latest = snapshot
+	t.hasTaken = true
+	t.mu.Unlock()
+}
+
+// Latest returns the most recent periodic snapshot.
+// Before the first periodic snapshot has been taken, a fresh one is computed.
+func (t *Tracker) Latest() Snapshot {
+	t.mu.Lock()
+	if t.hasTaken {
+		snapshot := t.latest
+		t.mu.Unlock()
+		return snapshot
+	}
+	t.mu.Unlock()
+
+	return t.Snapshot()
+}
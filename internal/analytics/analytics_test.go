@@ -0,0 +1,177 @@
+package analytics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/observability"
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parse(t *testing.T, query string) parser.Node {
+	t.Helper()
+	ast, err := parser.NewParser(query).Parse()
+	require.NoError(t, err)
+	return ast
+}
+
+func TestTracker_Record(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText},
+		"price":  {Type: schema.TypeFloat},
+		"status": {Type: schema.TypeText, Column: "product_status"},
+	}, schema.SchemaOptions{DefaultField: "name"})
+
+	tracker := NewTracker(nil)
+
+	tracker.Record(s, parse(t, `name:foo AND price:[1 TO 10]`), nil)
+	tracker.Record(s, parse(t, `name:bar OR status:(active OR pending)`), nil)
+	tracker.Record(s, parse(t, `color:red`), errors.New("field color not found"))
+
+	summary := tracker.Snapshot().Schemas["products"]
+	assert.Equal(t, int64(3), summary.Queries)
+	assert.Equal(t, int64(1), summary.Errors)
+	assert.InDelta(t, 1.0/3.0, summary.ErrorRate, 0.0001)
+	assert.InDelta(t, 5.0/3.0, summary.AverageClauses, 0.0001)
+	assert.Equal(t, int64(1), summary.UnknownFieldUsage)
+
+	assert.Equal(t, []UsageCount{
+		{Name: "name", Count: 2},
+		{Name: "price", Count: 1},
+		{Name: "product_status", Count: 1},
+	}, summary.TopFields)
+
+	operators := map[string]int64{}
+	for _, op := range summary.Operators {
+		operators[op.Name] = op.Count
+	}
+	assert.Equal(t, int64(1), operators["and"])
+	assert.Equal(t, int64(1), operators["or"])
+	assert.Equal(t, int64(1), operators["range"])
+	assert.Equal(t, int64(1), operators["field_group"])
+	assert.Equal(t, int64(3), operators["term"])
+}
+
+func TestTracker_RecordParseFailure(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText},
+		"price":  {Type: schema.TypeFloat},
+		"status": {Type: schema.TypeText, Column: "product_status"},
+	}, schema.SchemaOptions{DefaultField: "name"})
+
+	tracker := NewTracker(nil)
+
+	tracker.Record(s, nil, errors.New("parse error"))
+
+	summary := tracker.Snapshot().Schemas["products"]
+	assert.Equal(t, int64(1), summary.Queries)
+	assert.Equal(t, int64(1), summary.Errors)
+	assert.Equal(t, float64(0), summary.AverageClauses)
+	assert.Empty(t, summary.TopFields)
+}
+
+func TestTracker_DistinctQueries(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText},
+		"price":  {Type: schema.TypeFloat},
+		"status": {Type: schema.TypeText, Column: "product_status"},
+	}, schema.SchemaOptions{DefaultField: "name"})
+
+	tracker := NewTracker(nil)
+
+	tracker.Record(s, parse(t, `name:foo AND price:[1 TO 10]`), nil)
+	tracker.Record(s, parse(t, `price:[1 TO 10] AND name:foo`), nil)
+	tracker.Record(s, parse(t, `(name:foo) && price:[1 TO 10]`), nil)
+	tracker.Record(s, parse(t, `name:bar`), nil)
+	tracker.Record(s, nil, errors.New("parse error"))
+
+	summary := tracker.Snapshot().Schemas["products"]
+	assert.Equal(t, int64(2), summary.DistinctQueries)
+	require.Len(t, summary.TopQueries, 2)
+	assert.Equal(t, parser.Hash(parse(t, `name:foo AND price:[1 TO 10]`)), summary.TopQueries[0].Name)
+	assert.Equal(t, int64(3), summary.TopQueries[0].Count)
+}
+
+func TestTracker_RecordNilSchema(t *testing.T) {
+	tracker := NewTracker(nil)
+
+	tracker.Record(nil, nil, errors.New("schema not found"))
+
+	assert.Empty(t, tracker.Snapshot().Schemas)
+}
+
+func TestTracker_TopFieldsLimit(t *testing.T) {
+	fields := map[string]schema.Field{}
+	for i := 0; i < maxTopFields+5; i++ {
+		fields[string(rune('a'+i))] = schema.Field{Type: schema.TypeText}
+	}
+	s := schema.NewSchema("wide", fields, schema.SchemaOptions{})
+
+	tracker := NewTracker(nil)
+	for name := range fields {
+		tracker.Record(s, &parser.FieldQuery{Field: name, Value: &parser.TermValue{Term: "x"}}, nil)
+	}
+
+	assert.Len(t, tracker.Snapshot().Schemas["wide"].TopFields, maxTopFields)
+}
+
+func TestTracker_PrometheusExport(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText},
+		"price":  {Type: schema.TypeFloat},
+		"status": {Type: schema.TypeText, Column: "product_status"},
+	}, schema.SchemaOptions{DefaultField: "name"})
+
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	metrics := observability.NewMetrics()
+	tracker := NewTracker(metrics)
+
+	tracker.Record(s, parse(t, `name:foo AND name:bar`), nil)
+	tracker.Record(s, parse(t, `name:foo`), errors.New("failed"))
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.SchemaQueries.WithLabelValues("products", "success")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.SchemaQueries.WithLabelValues("products", "error")))
+	assert.Equal(t, float64(3), testutil.ToFloat64(metrics.FieldUsage.WithLabelValues("products", "name")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.OperatorUsage.WithLabelValues("products", "and")))
+}
+
+func TestTracker_PeriodicSnapshots(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText},
+		"price":  {Type: schema.TypeFloat},
+		"status": {Type: schema.TypeText, Column: "product_status"},
+	}, schema.SchemaOptions{DefaultField: "name"})
+
+	tracker := NewTracker(nil)
+
+	// Without a periodic snapshot, Latest is computed on demand
+	tracker.Record(s, parse(t, `name:foo`), nil)
+	assert.Equal(t, int64(1), tracker.Latest().Schemas["products"].Queries)
+
+	tracker.Start(10 * time.Millisecond)
+	defer tracker.Stop()
+
+	require.Eventually(t, func() bool {
+		tracker.mu.Lock()
+		defer tracker.mu.Unlock()
+		return tracker.hasTaken
+	}, time.Second, 5*time.Millisecond)
+
+	// Latest serves the periodic snapshot, so new records appear on the next tick
+	tracker.Record(s, parse(t, `name:bar`), nil)
+	require.Eventually(t, func() bool {
+		return tracker.Latest().Schemas["products"].Queries == 2
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestTracker_StopWithoutStart(t *testing.T) {
+	tracker := NewTracker(nil)
+	tracker.Stop()
+	tracker.Stop()
+}
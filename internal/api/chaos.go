@@ -0,0 +1,89 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"github.com/infiniv/rsearch/internal/chaos"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// ChaosMiddleware injects the request and response stage faults of injector: delays and
+// errors before the handler runs, and delays, errors and truncated bodies after it. The
+// response is buffered so that it can be replaced. Faults of the parse and translate
+// stages are injected by TranslateHandler.WithChaos.
+func ChaosMiddleware(injector *chaos.Injector) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := injector.Inject(r.Context(), w, chaos.StageRequest); err != nil {
+				respondChaos(w, err)
+				return
+			}
+
+			buffered := &bufferedWriter{ResponseWriter: w}
+			next.ServeHTTP(buffered, r)
+
+			if err := injector.Inject(r.Context(), w, chaos.StageResponse); err != nil {
+				respondChaos(w, err)
+				return
+			}
+			body := buffered.body.Bytes()
+			if injector.Malform(w, chaos.StageResponse) {
+				body = body[:len(body)/2]
+				w.Header().Del("Content-Length")
+			}
+			if buffered.status != 0 {
+				w.WriteHeader(buffered.status)
+			}
+			w.Write(body)
+		})
+	}
+}
+
+// bufferedWriter holds a response until the response stage faults are injected. Headers
+// are written to the underlying writer's header map directly.
+type bufferedWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedWriter) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+// Unwrap returns the underlying writer
+func (b *bufferedWriter) Unwrap() http.ResponseWriter {
+	return b.ResponseWriter
+}
+
+// respondChaos answers an injected failure. A delay cut short by the client going away
+// gets no answer.
+func respondChaos(w http.ResponseWriter, err error) {
+	var chaosErr *chaos.Error
+	if !errors.As(err, &chaosErr) {
+		return
+	}
+	code := rsearch.ErrorCodeInternalError
+	switch status := chaosErr.Status; {
+	case status == http.StatusTooManyRequests:
+		code = rsearch.ErrorCodeRateLimited
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		code = rsearch.ErrorCodeTimeout
+	case status == http.StatusServiceUnavailable:
+		code = rsearch.ErrorCodeServiceUnavailable
+	case status < http.StatusInternalServerError:
+		code = rsearch.ErrorCodeInvalidRequest
+	}
+	RespondError(w, chaosErr.Status, code, err.Error())
+}
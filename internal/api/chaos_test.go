@@ -0,0 +1,73 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/chaos"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChaosMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RespondJSON(w, http.StatusCreated, map[string]string{"status": "registered"})
+	})
+	serve := func(faults map[string]chaos.Fault) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ChaosMiddleware(chaos.New(faults, 1))(ok).ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/schemas", nil))
+		return w
+	}
+
+	w := serve(nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{"status": "registered"}`, w.Body.String())
+
+	w = serve(map[string]chaos.Fault{chaos.StageRequest: {ErrorRate: 1, ErrorStatus: http.StatusTooManyRequests}})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	var response rsearch.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, rsearch.ErrorCodeRateLimited, response.Error.Code)
+	assert.Equal(t, "request:error", w.Header().Get(chaos.Header))
+
+	w = serve(map[string]chaos.Fault{chaos.StageResponse: {ErrorRate: 1}})
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, rsearch.ErrorCodeInternalError, response.Error.Code)
+
+	w = serve(map[string]chaos.Fault{chaos.StageResponse: {MalformedRate: 1}})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "response:malformed", w.Header().Get(chaos.Header))
+	assert.False(t, json.Valid(w.Body.Bytes()), "body %q should be truncated", w.Body.String())
+}
+
+func TestTranslateHandler_Chaos(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+	require.NoError(t, schemaRegistry.Register(schema.NewSchema("products", map[string]schema.Field{
+		"status": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).
+		WithChaos(chaos.New(map[string]chaos.Fault{chaos.StageTranslate: {ErrorRate: 1, ErrorStatus: http.StatusServiceUnavailable}}, 1))
+
+	body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: "status:active"})
+	req := httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var response rsearch.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, rsearch.ErrorCodeServiceUnavailable, response.Error.Code)
+	assert.Equal(t, "injected failure at translate stage", response.Error.Message)
+	assert.Equal(t, "translate:error", w.Header().Get(chaos.Header))
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// FieldDoc is the machine-readable documentation of one schema field.
+type FieldDoc = rsearch.FieldDoc
+
+// FieldUse describes how a field can be queried in one dialect.
+type FieldUse = rsearch.FieldUse
+
+// FragmentDoc documents a schema fragment usable as a pseudo-field.
+type FragmentDoc = rsearch.FragmentDoc
+
+// FieldsResponse is the response body of the fields endpoint.
+type FieldsResponse = rsearch.FieldsResponse
+
+// FieldsHandler serves field documentation for query-builder UIs.
+type FieldsHandler struct {
+	schemaRegistry     *schema.Registry
+	translatorRegistry *translator.Registry
+}
+
+// NewFieldsHandler creates a new fields handler.
+func NewFieldsHandler(schemaRegistry *schema.Registry, translatorRegistry *translator.Registry) *FieldsHandler {
+	return &FieldsHandler{
+		schemaRegistry:     schemaRegistry,
+		translatorRegistry: translatorRegistry,
+	}
+}
+
+// ServeHTTP handles GET /api/v1/schemas/{name}/fields.
+// Fields are sorted by name; each lists its operators for every registered dialect.
+// Fragments follow, with the dialects they are defined for.
+func (h *FieldsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	noteAccess(r.Context(), name, "")
+
+	s, err := h.schemaRegistry.Get(name)
+	if err != nil {
+		RespondError(w, http.StatusNotFound, "SCHEMA_NOT_FOUND", err.Error())
+		return
+	}
+
+	dialects := make(map[string]string) // registered name -> database type
+	for _, dbType := range h.translatorRegistry.List() {
+		if trans, err := h.translatorRegistry.Get(dbType); err == nil {
+			dialects[dbType] = trans.DatabaseType()
+		}
+	}
+
+	fields := make([]FieldDoc, 0, len(s.Fields))
+	for fieldName, field := range s.Fields {
+		column, _, err := s.ResolveField(fieldName)
+		if err != nil {
+			continue
+		}
+
+		doc := FieldDoc{
+			Name:        fieldName,
+			Type:        string(field.Type),
+			Column:      column,
+			Aliases:     field.Aliases,
+			Description: field.Description,
+			Examples:    field.Examples,
+			Unit:        field.Unit,
+			Indexed:     field.Indexed,
+			Default:     fieldName == s.Options.DefaultField,
+			Dialects:    make(map[string]FieldUse, len(dialects)),
+		}
+		for registered, dbType := range dialects {
+			doc.Dialects[registered] = FieldUse{
+				Operators:     translator.FieldOperators(dbType, field, s.Options),
+				CaseSensitive: translator.CaseSensitive(dbType, field),
+			}
+		}
+		fields = append(fields, doc)
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Name < fields[j].Name
+	})
+
+	// Fragments are listed without their definitions, which stay server-side
+	var fragments []FragmentDoc
+	for fragmentName, fragment := range s.Fragments {
+		doc := FragmentDoc{Name: fragmentName, Description: fragment.Description, Dialects: []string{}}
+		for registered, dbType := range dialects {
+			if dbType == "mongodb" && len(fragment.MongoDB) > 0 || dbType != "mongodb" && fragment.SQL != "" {
+				doc.Dialects = append(doc.Dialects, registered)
+			}
+		}
+		sort.Strings(doc.Dialects)
+		fragments = append(fragments, doc)
+	}
+	sort.Slice(fragments, func(i, j int) bool {
+		return fragments[i].Name < fragments[j].Name
+	})
+
+	RespondJSON(w, http.StatusOK, FieldsResponse{Schema: s.Name, Fields: fields, Fragments: fragments})
+}
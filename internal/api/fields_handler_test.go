@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldsHandler(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name": {
+			Type:        schema.TypeText,
+			Column:      "product_name",
+			Aliases:     []string{"title"},
+			Description: "Display name of the product",
+			Examples:    []string{"laptop", `"gaming mouse"`},
+		},
+		"price": {Type: schema.TypeFloat, Unit: "USD", Indexed: true},
+		"city":  {Type: schema.TypeText, Unaccent: true},
+	}, schema.SchemaOptions{
+		DefaultField:    "name",
+		EnabledFeatures: schema.EnabledFeatures{Fuzzy: true},
+	})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+	translatorRegistry.Register("sqlite", translator.NewSQLiteTranslator())
+
+	router := chi.NewRouter()
+	router.Get("/api/v1/schemas/{name}/fields", NewFieldsHandler(schemaRegistry, translatorRegistry).ServeHTTP)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/schemas/products/fields", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response FieldsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "products", response.Schema)
+	require.Len(t, response.Fields, 3)
+	assert.Equal(t, []string{"city", "name", "price"}, []string{response.Fields[0].Name, response.Fields[1].Name, response.Fields[2].Name})
+
+	name := response.Fields[1]
+	assert.Equal(t, "product_name", name.Column)
+	assert.Equal(t, "Display name of the product", name.Description)
+	assert.Equal(t, []string{"laptop", `"gaming mouse"`}, name.Examples)
+	assert.Equal(t, []string{"title"}, name.Aliases)
+	assert.True(t, name.Default)
+	assert.Contains(t, name.Dialects["postgres"].Operators, "fuzzy")
+	assert.NotContains(t, name.Dialects["sqlite"].Operators, "fuzzy")
+	require.NotNil(t, name.Dialects["postgres"].CaseSensitive)
+	assert.True(t, *name.Dialects["postgres"].CaseSensitive)
+
+	city := response.Fields[0]
+	require.NotNil(t, city.Dialects["sqlite"].CaseSensitive)
+	assert.False(t, *city.Dialects["sqlite"].CaseSensitive)
+
+	price := response.Fields[2]
+	assert.Equal(t, "USD", price.Unit)
+	assert.True(t, price.Indexed)
+	assert.Contains(t, price.Dialects["postgres"].Operators, "range")
+	assert.NotContains(t, price.Dialects["postgres"].Operators, "wildcard")
+	assert.Nil(t, price.Dialects["postgres"].CaseSensitive)
+
+	// Unknown schemas are reported as not found
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/schemas/missing/fields", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestFieldsHandler_Fragments(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"stock": {Type: schema.TypeInteger},
+	}, schema.SchemaOptions{})
+	testSchema.Fragments = map[string]schema.Fragment{
+		"inStock":  {SQL: "{stock} > 0", MongoDB: map[string]interface{}{"stock": map[string]interface{}{"$gt": 0}}, Description: "Available to order"},
+		"lowStock": {SQL: "{stock} < 5"},
+	}
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+	translatorRegistry.Register("mongodb", translator.NewMongoDBTranslator())
+
+	router := chi.NewRouter()
+	router.Get("/api/v1/schemas/{name}/fields", NewFieldsHandler(schemaRegistry, translatorRegistry).ServeHTTP)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/schemas/products/fields", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response FieldsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, []FragmentDoc{
+		{Name: "inStock", Description: "Available to order", Dialects: []string{"mongodb", "postgres"}},
+		{Name: "lowStock", Dialects: []string{"postgres"}},
+	}, response.Fragments)
+
+	// Definitions stay server-side
+	assert.NotContains(t, w.Body.String(), "> 0")
+}
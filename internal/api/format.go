@@ -0,0 +1,149 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// vendorMediaTypePrefix starts the media types of versioned translate responses
+const vendorMediaTypePrefix = "application/vnd.rsearch.v"
+
+// negotiateFormat returns the translate response format version a request asks for,
+// and the content type to answer with. ?format=v<version> takes precedence over the
+// Accept header, which may list versioned media types among others; the first supported
+// version listed wins. Requests asking for neither get rsearch.FormatLatest as
+// application/json. A request only accepting unsupported versions is an error.
+func negotiateFormat(r *http.Request) (int, string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		number, isVersion := strings.CutPrefix(format, "v")
+		version, ok := parseFormatVersion(number)
+		if !isVersion || !ok {
+			return 0, "", fmt.Errorf("unsupported format %q, expected one of %s", format, supportedFormats())
+		}
+		return version, "application/json", nil
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return rsearch.FormatLatest, "application/json", nil
+	}
+
+	acceptsUnversioned := false
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, _, _ := strings.Cut(mediaRange, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+		if rest, ok := strings.CutPrefix(mediaType, vendorMediaTypePrefix); ok {
+			number, isJSON := strings.CutSuffix(rest, "+json")
+			if version, ok := parseFormatVersion(number); ok && isJSON {
+				return version, rsearch.FormatMediaType(version), nil
+			}
+			continue
+		}
+		acceptsUnversioned = true
+	}
+	if !acceptsUnversioned {
+		return 0, "", fmt.Errorf("none of the accepted formats is supported, expected one of %s", supportedFormats())
+	}
+	return rsearch.FormatLatest, "application/json", nil
+}
+
+// parseFormatVersion parses a supported format version number
+func parseFormatVersion(s string) (int, bool) {
+	version, err := strconv.Atoi(s)
+	if err != nil || version < rsearch.FormatV1 || version > rsearch.FormatLatest {
+		return 0, false
+	}
+	return version, true
+}
+
+// supportedFormats lists the supported versions as written in ?format=
+func supportedFormats() string {
+	versions := make([]string, 0, rsearch.FormatLatest)
+	for version := rsearch.FormatV1; version <= rsearch.FormatLatest; version++ {
+		versions = append(versions, "v"+strconv.Itoa(version))
+	}
+	return strings.Join(versions, ", ")
+}
+
+// formatResponse returns the response in a version of the format. Fields a version
+// does not define are left out, so clients of older versions see the responses they
+// were written against.
+func formatResponse(response TranslateResponse, version int) TranslateResponse {
+	if version == rsearch.FormatV1 {
+		parameters := make([]interface{}, len(response.Parameters))
+		for i, parameter := range response.Parameters {
+			parameters[i] = v1Value(parameter)
+		}
+		response = TranslateResponse{
+			Type:           response.Type,
+			WhereClause:    response.WhereClause,
+			Parameters:     parameters,
+			ParameterTypes: response.ParameterTypes,
+			Filter:         v1Filter(response.Filter),
+		}
+	}
+	response.FormatVersion = version
+	return response
+}
+
+// v1Value returns a bound value as version 1 wrote it. Version 1 bound numbers as the
+// strings they were written as, so numbers are written back as decimal strings, in
+// their normalized form: 1e2 is "100".
+func v1Value(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return value
+}
+
+// v1ComparisonOperators are the MongoDB operators whose operands are bound values
+var v1ComparisonOperators = map[string]bool{
+	"$eq": true, "$ne": true, "$gt": true, "$gte": true, "$lt": true, "$lte": true, "$in": true, "$nin": true,
+}
+
+// v1Filter returns a copy of a MongoDB filter with the values compared against written
+// as version 1 wrote them (see v1Value). Operands of other operators, such as $size,
+// are left alone.
+func v1Filter(filter interface{}) interface{} {
+	switch f := filter.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(f))
+		for key, value := range f {
+			_, isDocument := value.(map[string]interface{})
+			if v1ComparisonOperators[key] || (!strings.HasPrefix(key, "$") && !isDocument) {
+				copied[key] = v1Operand(value)
+			} else {
+				copied[key] = v1Filter(value)
+			}
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(f))
+		for i, value := range f {
+			copied[i] = v1Filter(value)
+		}
+		return copied
+	}
+	return filter
+}
+
+// v1Operand writes a compared value, or each value of a list, as version 1 did
+func v1Operand(value interface{}) interface{} {
+	values, ok := value.([]interface{})
+	if !ok {
+		return v1Value(value)
+	}
+	copied := make([]interface{}, len(values))
+	for i, v := range values {
+		copied[i] = v1Value(v)
+	}
+	return copied
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		accept      string
+		version     int
+		contentType string
+		wantErr     bool
+	}{
+		{"nothing requested", "/translate", "", rsearch.FormatLatest, "application/json", false},
+		{"plain JSON", "/translate", "application/json", rsearch.FormatLatest, "application/json", false},
+		{"any type", "/translate", "*/*", rsearch.FormatLatest, "application/json", false},
+		{"versioned media type", "/translate", "application/vnd.rsearch.v1+json", rsearch.FormatV1, "application/vnd.rsearch.v1+json", false},
+		{"first supported version", "/translate", "application/vnd.rsearch.v9+json, application/vnd.rsearch.v1+json;q=0.5", rsearch.FormatV1, "application/vnd.rsearch.v1+json", false},
+		{"unsupported version with a fallback", "/translate", "application/vnd.rsearch.v9+json, application/json", rsearch.FormatLatest, "application/json", false},
+		{"only unsupported versions", "/translate", "application/vnd.rsearch.v9+json", 0, "", true},
+		{"query parameter", "/translate?format=v1", "application/vnd.rsearch.v2+json", rsearch.FormatV1, "application/json", false},
+		{"unsupported query parameter", "/translate?format=1", "", 0, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", tt.target, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			version, contentType, err := negotiateFormat(r)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.version, version)
+			assert.Equal(t, tt.contentType, contentType)
+		})
+	}
+}
+
+func TestTranslateHandler_FormatVersions(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":  {Type: schema.TypeText},
+		"price": {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry)
+
+	send := func(target, accept string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: "name:laptop", Fields: []string{"name"}})
+		req := httptest.NewRequest("POST", target, bytes.NewReader(body))
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	w, response := send("/api/v1/translate", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, float64(rsearch.FormatLatest), response["formatVersion"])
+	assert.Contains(t, response, "selectClause")
+	assert.Contains(t, response, "referencedFields")
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+
+	// Version 1 clients get the original response and nothing added since
+	w, response = send("/api/v1/translate", rsearch.FormatMediaType(rsearch.FormatV1))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "application/vnd.rsearch.v1+json", w.Header().Get("Content-Type"))
+	assert.Equal(t, map[string]interface{}{
+		"type":           "sql",
+		"formatVersion":  float64(1),
+		"whereClause":    "name = $1",
+		"parameters":     []interface{}{"laptop"},
+		"parameterTypes": []interface{}{"text"},
+	}, response)
+
+	w, response = send("/api/v1/translate?format=v1", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, float64(1), response["formatVersion"])
+	assert.NotContains(t, response, "selectClause")
+
+	w, _ = send("/api/v1/translate?format=v3", "")
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	assert.Contains(t, w.Body.String(), "expected one of v1, v2")
+}
+
+func TestTranslateHandler_FormatV1Values(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+	require.NoError(t, schemaRegistry.Register(schema.NewSchema("products", map[string]schema.Field{
+		"name":  {Type: schema.TypeText},
+		"price": {Type: schema.TypeFloat},
+		"stock": {Type: schema.TypeInteger},
+	}, schema.SchemaOptions{})))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+	translatorRegistry.Register("mongodb", translator.NewMongoDBTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry)
+
+	send := func(database, query string) map[string]interface{} {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: database, Query: query})
+		req := httptest.NewRequest("POST", "/api/v1/translate?format=v1", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	// Responses match those of version 1, which bound numbers as the strings they were written as
+	response := send("postgres", "stock:100 AND price:[1.5 TO 10] AND name:(laptop OR phone)")
+	assert.Equal(t, "(stock = $1 AND price BETWEEN $2 AND $3) AND (name = $4 OR name = $5)", response["whereClause"])
+	assert.Equal(t, []interface{}{"100", "1.5", "10", "laptop", "phone"}, response["parameters"])
+
+	response = send("mongodb", "stock:(1 OR 2) AND price:>=1.5")
+	assert.Equal(t, map[string]interface{}{
+		"$and": []interface{}{
+			map[string]interface{}{"$or": []interface{}{
+				map[string]interface{}{"stock": "1"},
+				map[string]interface{}{"stock": "2"},
+			}},
+			map[string]interface{}{"price": map[string]interface{}{"$gte": "1.5"}},
+		},
+	}, response["filter"])
+}
@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/infiniv/rsearch/internal/observability"
 	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
 )
 
 // Handler handles HTTP API requests for schema management
@@ -23,8 +25,9 @@ func NewHandler(registry *schema.Registry) *Handler {
 
 // ErrorResponse represents an API error response
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
+	Error     string `json:"error"`
+	Message   string `json:"message"`
+	RequestID string `json:"requestId,omitempty"` // ID of the failed request, as in its logs
 }
 
 // SuccessResponse represents a successful API response
@@ -46,18 +49,28 @@ func (h *Handler) RegisterSchema(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Default filters and partial index predicates are queries themselves and must parse
+	if err := translator.ValidateDefaultFilters(&s); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid schema: %v", err))
+		return
+	}
+	if err := translator.ValidatePartialIndexes(&s); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid schema: %v", err))
+		return
+	}
+
 	// Register schema
 	if err := h.registry.Register(&s); err != nil {
 		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// Return created schema
+	// Return created schema, without its fragment definitions
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(SuccessResponse{
 		Message: "schema registered successfully",
-		Data:    s,
+		Data:    s.WithoutFragmentDefinitions(),
 	})
 }
 
@@ -84,10 +97,10 @@ func (h *Handler) GetSchema(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Return schema
+	// Return schema; fragment definitions stay server-side
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(s)
+	json.NewEncoder(w).Encode(s.WithoutFragmentDefinitions())
 }
 
 // DeleteSchema handles DELETE /api/v1/schemas/{name}
@@ -123,8 +136,11 @@ func (h *Handler) ListSchemas(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// List all schemas
+	// List all schemas, without their fragment definitions
 	schemas := h.registry.List()
+	for i, s := range schemas {
+		schemas[i] = s.WithoutFragmentDefinitions()
+	}
 
 	// Return schemas
 	w.Header().Set("Content-Type", "application/json")
@@ -134,13 +150,37 @@ func (h *Handler) ListSchemas(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// auditFragmentChanges returns a registry listener logging the fragments each change
+// adds, removes or redefines. Definitions are left out of the log, as out of the schema
+// endpoints.
+func auditFragmentChanges(logger *observability.Logger) func(schema.Event) {
+	return func(e schema.Event) {
+		diff := schema.Compare(e.Previous, e.Schema)
+		if len(diff.AddedFragments) == 0 && len(diff.RemovedFragments) == 0 && len(diff.ChangedFragments) == 0 {
+			return
+		}
+		fields := map[string]interface{}{"schema": e.Name, "version": e.Version, "remote": e.Remote}
+		for key, names := range map[string][]string{
+			"added_fragments":   diff.AddedFragments,
+			"removed_fragments": diff.RemovedFragments,
+			"changed_fragments": diff.ChangedFragments,
+		} {
+			if len(names) > 0 {
+				fields[key] = names
+			}
+		}
+		logger.WithFields(fields).Info("Schema fragments changed")
+	}
+}
+
 // writeError writes an error response
 func (h *Handler) writeError(w http.ResponseWriter, statusCode int, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(ErrorResponse{
-		Error:   http.StatusText(statusCode),
-		Message: message,
+		Error:     http.StatusText(statusCode),
+		Message:   message,
+		RequestID: requestIDOf(w),
 	})
 }
 
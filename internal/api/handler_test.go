@@ -5,8 +5,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/infiniv/rsearch/internal/observability"
 	"github.com/infiniv/rsearch/internal/schema"
 )
 
@@ -50,6 +55,31 @@ func TestRegisterSchema_Success(t *testing.T) {
 	}
 }
 
+func TestRegisterSchema_InvalidDefaultFilter(t *testing.T) {
+	registry := schema.NewRegistry()
+	handler := NewHandler(registry)
+
+	schemaJSON := `{
+		"name": "users",
+		"fields": {"deleted_at": {"type": "date"}},
+		"options": {
+			"defaultFilters": [{"name": "not_deleted", "query": "NOT (_exists_:deleted_at"}]
+		}
+	}`
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/schemas", bytes.NewBufferString(schemaJSON))
+	rec := httptest.NewRecorder()
+
+	handler.RegisterSchema(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("RegisterSchema() status = %v, want %v", rec.Code, http.StatusBadRequest)
+	}
+	if registry.Exists("users") {
+		t.Error("schema with invalid default filter should not be registered")
+	}
+}
+
 func TestRegisterSchema_InvalidJSON(t *testing.T) {
 	registry := schema.NewRegistry()
 	handler := NewHandler(registry)
@@ -156,6 +186,117 @@ func TestGetSchema_Success(t *testing.T) {
 	}
 }
 
+func TestSchemaEndpoints_WithoutFragmentDefinitions(t *testing.T) {
+	registry := schema.NewRegistry()
+	handler := NewHandler(registry)
+
+	schemaJSON := `{
+		"name": "products",
+		"fields": {"stockQty": {"type": "integer"}},
+		"fragments": {
+			"inStock": {
+				"sql": "{stockQty} > 0",
+				"mongodb": {"stockQty": {"$gt": 0}},
+				"description": "Products with stock left"
+			}
+		}
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/schemas", bytes.NewBufferString(schemaJSON))
+	rec := httptest.NewRecorder()
+	handler.RegisterSchema(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("RegisterSchema() status = %v, want %v: %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	responses := map[string]string{"POST /api/v1/schemas": rec.Body.String()}
+
+	rec = httptest.NewRecorder()
+	handler.GetSchema(rec, httptest.NewRequest(http.MethodGet, "/api/v1/schemas/products", nil))
+	responses["GET /api/v1/schemas/products"] = rec.Body.String()
+
+	rec = httptest.NewRecorder()
+	handler.ListSchemas(rec, httptest.NewRequest(http.MethodGet, "/api/v1/schemas", nil))
+	responses["GET /api/v1/schemas"] = rec.Body.String()
+
+	for endpoint, body := range responses {
+		if strings.Contains(body, "stockQty} > 0") || strings.Contains(body, "$gt") {
+			t.Errorf("%s exposes the fragment definition: %s", endpoint, body)
+		}
+		if !strings.Contains(body, `"inStock":{"description":"Products with stock left"}`) {
+			t.Errorf("%s lacks the fragment description: %s", endpoint, body)
+		}
+	}
+
+	// The registry keeps the definition for translation
+	s, err := registry.Get("products")
+	if err != nil {
+		t.Fatalf("Get() unexpected error = %v", err)
+	}
+	if s.Fragments["inStock"].SQL != "{stockQty} > 0" {
+		t.Errorf("Fragment SQL = %q, want %q", s.Fragments["inStock"].SQL, "{stockQty} > 0")
+	}
+}
+
+func TestAuditFragmentChanges(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := observability.NewLogger("info", "json", logPath)
+	if err != nil {
+		t.Fatalf("NewLogger() unexpected error = %v", err)
+	}
+	registry := schema.NewRegistry()
+	registry.Subscribe(auditFragmentChanges(logger))
+
+	newSchema := func(fragments map[string]schema.Fragment) *schema.Schema {
+		s := schema.NewSchema("products", map[string]schema.Field{
+			"stockQty": {Type: schema.TypeInteger},
+		}, schema.SchemaOptions{})
+		s.Fragments = fragments
+		return s
+	}
+	for _, s := range []*schema.Schema{
+		newSchema(map[string]schema.Fragment{"inStock": {SQL: "{stockQty} > 0"}}),
+		newSchema(map[string]schema.Fragment{"inStock": {SQL: "{stockQty} > 0"}}),
+		newSchema(map[string]schema.Fragment{"inStock": {SQL: "{stockQty} > 1"}, "lowStock": {SQL: "{stockQty} < 5"}}),
+	} {
+		if _, err := registry.RegisterOrReplace(s); err != nil {
+			t.Fatalf("RegisterOrReplace() unexpected error = %v", err)
+		}
+	}
+	if err := registry.Delete("products"); err != nil {
+		t.Fatalf("Delete() unexpected error = %v", err)
+	}
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("ReadFile() unexpected error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	want := []map[string]interface{}{
+		{"added_fragments": []interface{}{"inStock"}},
+		{"added_fragments": []interface{}{"lowStock"}, "changed_fragments": []interface{}{"inStock"}},
+		{"removed_fragments": []interface{}{"inStock", "lowStock"}},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("Logged %d lines, want %d: %s", len(lines), len(want), content)
+	}
+	for i, line := range lines {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Failed to decode log line: %v", err)
+		}
+		if entry["schema"] != "products" || entry["message"] != "Schema fragments changed" {
+			t.Errorf("Log line %d = %s", i, line)
+		}
+		for _, key := range []string{"added_fragments", "removed_fragments", "changed_fragments"} {
+			if !reflect.DeepEqual(entry[key], want[i][key]) {
+				t.Errorf("Log line %d %s = %v, want %v", i, key, entry[key], want[i][key])
+			}
+		}
+	}
+	if strings.Contains(string(content), "stockQty}") {
+		t.Errorf("Audit log exposes fragment definitions: %s", content)
+	}
+}
+
 func TestGetSchema_NotFound(t *testing.T) {
 	registry := schema.NewRegistry()
 	handler := NewHandler(registry)
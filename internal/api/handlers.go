@@ -13,6 +13,7 @@ type Handlers struct {
 	config  *config.Config
 	logger  *observability.Logger
 	metrics *observability.Metrics
+	build   rsearch.BuildInfo
 }
 
 // NewHandlers creates a new handlers instance
@@ -21,6 +22,7 @@ func NewHandlers(cfg *config.Config, logger *observability.Logger, metrics *obse
 		config:  cfg,
 		logger:  logger,
 		metrics: metrics,
+		build:   rsearch.ReadBuildInfo(),
 	}
 }
 
@@ -28,7 +30,7 @@ func NewHandlers(cfg *config.Config, logger *observability.Logger, metrics *obse
 func (h *Handlers) Health(w http.ResponseWriter, r *http.Request) {
 	response := rsearch.HealthResponse{
 		Status:  "healthy",
-		Version: rsearch.Version,
+		Version: h.build.Version,
 	}
 	RespondJSON(w, http.StatusOK, response)
 }
@@ -37,11 +39,16 @@ func (h *Handlers) Health(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) Ready(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{
 		"ready":   true,
-		"version": rsearch.Version,
+		"version": h.build.Version,
 	}
 	RespondJSON(w, http.StatusOK, response)
 }
 
+// Version handles the version endpoint, identifying the build serving translations
+func (h *Handlers) Version(w http.ResponseWriter, r *http.Request) {
+	RespondJSON(w, http.StatusOK, h.build)
+}
+
 // Metrics handles the metrics endpoint (wrapped by Prometheus handler in routes)
 func (h *Handlers) Metrics() http.Handler {
 	if h.metrics == nil {
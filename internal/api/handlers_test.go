@@ -107,6 +107,32 @@ func TestReadyHandler(t *testing.T) {
 	}
 }
 
+func TestVersionHandler(t *testing.T) {
+	handlers := setupTestHandlers(t, false)
+
+	req := httptest.NewRequest("GET", "/version", nil)
+	w := httptest.NewRecorder()
+
+	handlers.Version(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var build rsearch.BuildInfo
+	if err := json.NewDecoder(resp.Body).Decode(&build); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if build != rsearch.ReadBuildInfo() {
+		t.Errorf("Expected build %+v, got %+v", rsearch.ReadBuildInfo(), build)
+	}
+	if build.Version == "" || build.GoVersion == "" || build.Platform == "" {
+		t.Errorf("Expected version, Go version and platform, got %+v", build)
+	}
+}
+
 func TestMetricsHandler(t *testing.T) {
 	handlers := setupTestHandlers(t, true)
 
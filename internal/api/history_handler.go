@@ -0,0 +1,141 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/infiniv/rsearch/internal/history"
+	"github.com/infiniv/rsearch/internal/observability"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// HistoryResponse lists recorded translate requests, newest first
+type HistoryResponse struct {
+	Entries []history.Entry `json:"entries"`
+}
+
+// ReplayRequest overrides the schema or database of a replayed request, to check how it
+// translates against another schema or for another database. Both are optional.
+type ReplayRequest struct {
+	Schema   string `json:"schema,omitempty"`
+	Database string `json:"database,omitempty"`
+}
+
+// replayKey marks the context of replayed translate requests
+type replayKey struct{}
+
+// isReplay reports whether a translate request is a replay
+func isReplay(ctx context.Context) bool {
+	replay, _ := ctx.Value(replayKey{}).(bool)
+	return replay
+}
+
+// HistoryHandler lists and replays the translate requests kept in a history store.
+type HistoryHandler struct {
+	store     *history.Store
+	translate http.Handler
+	apiKeys   []string
+}
+
+// NewHistoryHandler creates a history handler replaying requests through translate.
+// Requests must carry one of apiKeys in the X-API-Key header, see Authorize.
+func NewHistoryHandler(store *history.Store, translate http.Handler, apiKeys []string) *HistoryHandler {
+	return &HistoryHandler{store: store, translate: translate, apiKeys: apiKeys}
+}
+
+// Authorize rejects requests without one of the handler's API keys, as the history holds
+// the queries clients sent
+func (h *HistoryHandler) Authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !observability.KeyAllowed(r.Header.Get("X-API-Key"), h.apiKeys) {
+			RespondError(w, http.StatusUnauthorized, rsearch.ErrorCodeUnauthorized, "A valid X-API-Key is required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// List returns the recorded requests, newest first, optionally of the schema query
+// parameter only and at most limit of them
+func (h *HistoryHandler) List(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+			RespondError(w, http.StatusBadRequest, rsearch.ErrorCodeInvalidRequest, "limit must be a non-negative integer")
+			return
+		}
+	}
+
+	entries := h.store.List(r.URL.Query().Get("schema"), limit)
+	if entries == nil {
+		entries = []history.Entry{}
+	}
+	RespondJSON(w, http.StatusOK, HistoryResponse{Entries: entries})
+}
+
+// Get returns one recorded request
+func (h *HistoryHandler) Get(w http.ResponseWriter, r *http.Request) {
+	if entry, ok := h.entry(w, r); ok {
+		RespondJSON(w, http.StatusOK, entry)
+	}
+}
+
+// Replay translates a recorded request again, against the schema's current version or
+// the schema and database of the optional ReplayRequest body. The response is the
+// translate endpoint's, with the ID of the replayed entry in X-Replay-Of. Replays run
+// with the API key of the replaying request, and are not recorded themselves.
+func (h *HistoryHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	entry, ok := h.entry(w, r)
+	if !ok {
+		return
+	}
+
+	var replay ReplayRequest
+	if err := json.NewDecoder(r.Body).Decode(&replay); err != nil && !errors.Is(err, io.EOF) {
+		RespondError(w, http.StatusBadRequest, rsearch.ErrorCodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	req := entry.Request
+	if replay.Schema != "" {
+		req.Schema = replay.Schema
+	}
+	if replay.Database != "" {
+		req.Database = replay.Database
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		RespondInternalError(w, "Failed to encode the replayed request")
+		return
+	}
+
+	translateReq := r.Clone(context.WithValue(r.Context(), replayKey{}, true))
+	translateReq.Body = io.NopCloser(bytes.NewReader(body))
+	translateReq.ContentLength = int64(len(body))
+
+	w.Header().Set("X-Replay-Of", strconv.FormatUint(entry.ID, 10))
+	h.translate.ServeHTTP(w, translateReq)
+}
+
+// entry returns the entry named by the id URL parameter, answering the request when
+// there is none
+func (h *HistoryHandler) entry(w http.ResponseWriter, r *http.Request) (history.Entry, bool) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		RespondError(w, http.StatusBadRequest, rsearch.ErrorCodeInvalidRequest, "History entry IDs are positive integers")
+		return history.Entry{}, false
+	}
+	entry, ok := h.store.Get(id)
+	if !ok {
+		RespondError(w, http.StatusNotFound, rsearch.ErrorCodeHistoryNotFound, "No history entry "+strconv.FormatUint(id, 10)+"; it may have been overwritten by newer requests")
+		return history.Entry{}, false
+	}
+	return entry, true
+}
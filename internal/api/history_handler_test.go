@@ -0,0 +1,133 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/infiniv/rsearch/internal/history"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupHistory returns a router translating requests and serving their history, with
+// "admin" as the history API key
+func setupHistory(t *testing.T) (*chi.Mux, *history.Store) {
+	t.Helper()
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+	require.NoError(t, schemaRegistry.Register(schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})))
+	require.NoError(t, schemaRegistry.Register(schema.NewSchema("products_v2", map[string]schema.Field{
+		"name": {Type: schema.TypeText, Column: "title"},
+	}, schema.SchemaOptions{})))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+	translatorRegistry.Register("mysql", translator.NewMySQLTranslator())
+
+	store := history.NewStore(10)
+	translateHandler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithHistory(store)
+	historyHandler := NewHistoryHandler(store, translateHandler, []string{"admin"})
+
+	r := chi.NewRouter()
+	r.Post("/api/v1/translate", translateHandler.ServeHTTP)
+	r.Group(func(r chi.Router) {
+		r.Use(historyHandler.Authorize)
+		r.Get("/api/v1/admin/history", historyHandler.List)
+		r.Get("/api/v1/admin/history/{id}", historyHandler.Get)
+		r.Post("/api/v1/admin/history/{id}/replay", historyHandler.Replay)
+	})
+	return r, store
+}
+
+func serveHistory(r http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("X-API-Key", "admin")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestHistoryHandler_ListAndGet(t *testing.T) {
+	r, _ := setupHistory(t)
+	for _, query := range []string{"name:foo", "name:(", "name:bar"} {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: query})
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+	}
+	// Requests for unknown schemas are not recorded
+	body, _ := json.Marshal(TranslateRequest{Schema: "missing", Database: "postgres", Query: "a:b"})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+
+	w := serveHistory(r, "GET", "/api/v1/admin/history?schema=products&limit=2", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list HistoryResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Entries, 2)
+	assert.Equal(t, "name:bar", list.Entries[0].Request.Query)
+	assert.Equal(t, http.StatusOK, list.Entries[0].Status)
+	assert.Equal(t, "name:(", list.Entries[1].Request.Query)
+	assert.Equal(t, http.StatusBadRequest, list.Entries[1].Status)
+	assert.Equal(t, uint64(1), list.Entries[1].SchemaVersion)
+
+	w = serveHistory(r, "GET", "/api/v1/admin/history/1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var entry history.Entry
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entry))
+	assert.Equal(t, "name:foo", entry.Request.Query)
+
+	assert.Equal(t, http.StatusNotFound, serveHistory(r, "GET", "/api/v1/admin/history/99", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serveHistory(r, "GET", "/api/v1/admin/history/first", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serveHistory(r, "GET", "/api/v1/admin/history?limit=-1", "").Code)
+}
+
+func TestHistoryHandler_Replay(t *testing.T) {
+	r, store := setupHistory(t)
+	body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: "name:foo"})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+
+	tests := []struct {
+		name  string
+		body  string
+		where string
+	}{
+		{"as recorded", "", "name = $1"},
+		{"another database", `{"database":"mysql"}`, "name = ?"},
+		{"another schema", `{"schema":"products_v2"}`, "title = $1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveHistory(r, "POST", "/api/v1/admin/history/1/replay", tt.body)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			assert.Equal(t, "1", w.Header().Get("X-Replay-Of"))
+
+			var response TranslateResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.where, response.WhereClause)
+		})
+	}
+
+	// Replays are not recorded
+	assert.Len(t, store.List("", 0), 1)
+
+	assert.Equal(t, http.StatusBadRequest, serveHistory(r, "POST", "/api/v1/admin/history/1/replay", "{").Code)
+	assert.Equal(t, http.StatusNotFound, serveHistory(r, "POST", "/api/v1/admin/history/2/replay", "").Code)
+}
+
+func TestHistoryHandler_Authorize(t *testing.T) {
+	r, _ := setupHistory(t)
+	for _, key := range []string{"", "wrong"} {
+		req := httptest.NewRequest("GET", "/api/v1/admin/history", nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+}
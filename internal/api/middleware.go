@@ -1,55 +1,226 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net"
 	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/google/uuid"
 	"github.com/infiniv/rsearch/internal/config"
 	"github.com/infiniv/rsearch/internal/observability"
 	"github.com/infiniv/rsearch/internal/ratelimit"
 	"github.com/infiniv/rsearch/internal/validation"
+	"github.com/infiniv/rsearch/pkg/rsearch"
 )
 
-// RequestIDMiddleware adds a request ID to each request
+// requestIDRegex matches request IDs honored from clients: UUIDs, trace IDs and other
+// tokens that are safe to log and echo, up to 128 characters
+var requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9._:/+=-]{1,128}$`)
+
+// RequestIDMiddleware gives each request an ID, honoring a valid one sent by the client
+// and generating one otherwise. The ID is returned in the same header, carried by the
+// request context for logging, and added to error responses.
 func RequestIDMiddleware(cfg *config.Config) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			requestID := r.Header.Get(cfg.Features.RequestIDHeader)
-			if requestID == "" {
+			if !requestIDRegex.MatchString(requestID) {
 				requestID = uuid.New().String()
 			}
 			w.Header().Set(cfg.Features.RequestIDHeader, requestID)
-			next.ServeHTTP(w, r)
+			ctx := observability.ContextWithRequestID(r.Context(), requestID)
+			next.ServeHTTP(&requestIDWriter{ResponseWriter: w, requestID: requestID}, r.WithContext(ctx))
 		})
 	}
 }
 
-// LoggingMiddleware logs HTTP requests
+// requestIDWriter lets error responses find the request ID, as they are written
+// without the request
+type requestIDWriter struct {
+	http.ResponseWriter
+	requestID string
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController
+func (w *requestIDWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// requestIDOf returns the ID of the request a response is written for, looking through
+// the writers wrapping it, or "" outside RequestIDMiddleware
+func requestIDOf(w http.ResponseWriter) string {
+	for {
+		switch writer := w.(type) {
+		case *requestIDWriter:
+			return writer.requestID
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = writer.Unwrap()
+		default:
+			return ""
+		}
+	}
+}
+
+// Headers selecting the schema and database of a translation, for clients that cannot
+// easily put them in the body, such as curl one-liners and read-only dashboards
+const (
+	SchemaHeader  = "X-RSearch-Schema"
+	DialectHeader = "X-RSearch-Dialect"
+)
+
+// selectionKey carries the headers' selection in the context of a request
+type selectionKey struct{}
+
+// selection is the schema and database named by a request's headers
+type selection struct {
+	Schema   string
+	Database string
+}
+
+// SelectionMiddleware carries the schema and database named by the X-RSearch-Schema and
+// X-RSearch-Dialect headers in the request context, where the translate handler uses
+// them for requests whose body leaves them out. As the headers change the response,
+// they are added to its Vary header for caches.
+func SelectionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", SchemaHeader+", "+DialectHeader)
+		selected := selection{
+			Schema:   strings.TrimSpace(r.Header.Get(SchemaHeader)),
+			Database: strings.TrimSpace(r.Header.Get(DialectHeader)),
+		}
+		if selected == (selection{}) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), selectionKey{}, selected)))
+	})
+}
+
+// applySelection completes a translate request with the schema and database selected by
+// SelectionMiddleware. A header naming a different schema or database than the body is
+// an error, as one of them is most likely stale.
+func applySelection(ctx context.Context, req *TranslateRequest) error {
+	selected, _ := ctx.Value(selectionKey{}).(selection)
+	if selected.Schema != "" {
+		if req.Schema != "" && req.Schema != selected.Schema {
+			return fmt.Errorf("schema %q of the %s header differs from %q in the request body", selected.Schema, SchemaHeader, req.Schema)
+		}
+		req.Schema = selected.Schema
+	}
+	if selected.Database != "" {
+		if req.Database != "" && req.Database != selected.Database {
+			return fmt.Errorf("database %q of the %s header differs from %q in the request body", selected.Database, DialectHeader, req.Database)
+		}
+		req.Database = selected.Database
+	}
+	return nil
+}
+
+// accessKey carries the access log entry of a request in its context
+type accessKey struct{}
+
+// accessEntry collects what handlers learn about a request for its access log line
+type accessEntry struct {
+	schema  string
+	dialect string
+}
+
+// noteAccess records the schema and dialect a request was served with in its access log
+// entry. Empty values leave what was recorded before.
+func noteAccess(ctx context.Context, schema, dialect string) {
+	entry, ok := ctx.Value(accessKey{}).(*accessEntry)
+	if !ok {
+		return
+	}
+	if schema != "" {
+		entry.schema = schema
+	}
+	if dialect != "" {
+		entry.dialect = dialect
+	}
+}
+
+// unmatchedRoute labels requests no route matched, so that arbitrary paths do not each
+// become a metric series
+const unmatchedRoute = "unmatched"
+
+// routePattern returns the pattern of the route that served a request, such as
+// /api/v1/schemas/{name}, once it has been served
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return unmatchedRoute
+}
+
+// countingBody counts the bytes read from a request body
+type countingBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+// LoggingMiddleware writes an access log line per request, with its method, path and
+// route, status, latency, request and response sizes, and the schema and dialect it was
+// served with when the handler records them
 func LoggingMiddleware(logger *observability.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
 
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			entry := &accessEntry{}
+			r = r.WithContext(context.WithValue(r.Context(), accessKey{}, entry))
+			var body *countingBody
+			if r.Body != nil && r.Body != http.NoBody {
+				body = &countingBody{ReadCloser: r.Body}
+				r.Body = body
+			}
 
 			defer func() {
 				duration := time.Since(start)
-				requestID := w.Header().Get("X-Request-ID")
-
-				logger.WithFields(map[string]interface{}{
-					"request_id": requestID,
-					"method":     r.Method,
-					"path":       r.URL.Path,
-					"status":     ww.Status(),
-					"bytes":      ww.BytesWritten(),
-					"duration":   duration.Milliseconds(),
-					"remote":     r.RemoteAddr,
-				}).Infof("%s %s %d %dms", r.Method, r.URL.Path, ww.Status(), duration.Milliseconds())
+
+				// The declared length counts bodies handlers do not read to the end
+				requestBytes := r.ContentLength
+				if requestBytes < 0 && body != nil {
+					requestBytes = body.n
+				}
+				fields := map[string]interface{}{
+					"method":        r.Method,
+					"path":          r.URL.Path,
+					"route":         routePattern(r),
+					"status":        ww.Status(),
+					"bytes":         ww.BytesWritten(),
+					"request_bytes": max(requestBytes, 0),
+					"duration":      duration.Milliseconds(),
+					"duration_us":   duration.Microseconds(),
+					"remote":        r.RemoteAddr,
+				}
+				if entry.schema != "" {
+					fields["schema"] = entry.schema
+				}
+				if entry.dialect != "" {
+					fields["dialect"] = entry.dialect
+				}
+				logger.WithContext(r.Context()).WithFields(fields).
+					Infof("%s %s %d %dms", r.Method, r.URL.Path, ww.Status(), duration.Milliseconds())
 			}()
 
 			next.ServeHTTP(ww, r)
@@ -63,10 +234,8 @@ func RecoveryMiddleware(logger *observability.Logger) func(next http.Handler) ht
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
 				if err := recover(); err != nil {
-					requestID := w.Header().Get("X-Request-ID")
-					logger.WithFields(map[string]interface{}{
-						"request_id": requestID,
-						"panic":      err,
+					logger.WithContext(r.Context()).WithFields(map[string]interface{}{
+						"panic": err,
 					}).Error("Panic recovered")
 
 					RespondInternalError(w, "An unexpected error occurred")
@@ -106,6 +275,7 @@ func CORSMiddleware(cfg *config.Config) func(next http.Handler) http.Handler {
 
 				// Set other CORS headers
 				w.Header().Set("Access-Control-Allow-Credentials", "true")
+				w.Header().Set("Access-Control-Expose-Headers", cfg.Features.RequestIDHeader)
 
 				// Handle preflight
 				if r.Method == "OPTIONS" {
@@ -117,7 +287,7 @@ func CORSMiddleware(cfg *config.Config) func(next http.Handler) http.Handler {
 						methods += method
 					}
 					w.Header().Set("Access-Control-Allow-Methods", methods)
-					w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID")
+					w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+SchemaHeader+", "+DialectHeader+", "+cfg.Features.RequestIDHeader)
 					w.Header().Set("Access-Control-Max-Age", "86400")
 					w.WriteHeader(http.StatusNoContent)
 					return
@@ -142,6 +312,7 @@ func MetricsMiddleware(metrics *observability.Metrics) func(next http.Handler) h
 
 			duration := time.Since(start).Seconds()
 			metrics.RecordRequest(r.URL.Path, ww.Status(), duration)
+			metrics.RecordRouteLatency(r.Method, routePattern(r), duration)
 		})
 	}
 }
@@ -171,8 +342,10 @@ func ValidationMiddleware(validator *validation.Validator, cfg *config.Config) f
 	}
 }
 
-// RateLimitMiddleware implements per-IP rate limiting
-func RateLimitMiddleware(limiter *ratelimit.RateLimiter, cfg *config.Config) func(next http.Handler) http.Handler {
+// RateLimitMiddleware implements per-IP rate limiting. Responses report the client's
+// burst and remaining requests, and rejections when its next request will be allowed.
+// Rejections and the number of tracked clients are recorded when metrics is not nil.
+func RateLimitMiddleware(limiter *ratelimit.RateLimiter, cfg *config.Config, metrics *observability.Metrics) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Skip rate limiting if disabled
@@ -181,25 +354,76 @@ func RateLimitMiddleware(limiter *ratelimit.RateLimiter, cfg *config.Config) fun
 				return
 			}
 
-			// Extract client IP
-			clientIP := extractClientIP(r)
-
 			// Check rate limit
-			if !limiter.Allow(clientIP) {
-				// Calculate retry after based on requests per minute
-				retryAfterSeconds := 60 / cfg.Limits.RateLimit.RequestsPerMinute
-				if retryAfterSeconds < 1 {
-					retryAfterSeconds = 1
-				}
+			decision := limiter.Take(extractClientIP(r))
+			if metrics != nil {
+				metrics.SetRateLimitClients(limiter.Stats().Clients)
+			}
 
-				w.Header().Set("Retry-After", fmt.Sprintf("%d", retryAfterSeconds))
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limiter.Limit()))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+
+			if !decision.Allowed {
+				if metrics != nil {
+					metrics.RecordRateLimitHit()
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(decision.RetryAfter)))
 				RespondError(w, http.StatusTooManyRequests, "RATE_LIMIT_EXCEEDED",
 					"Rate limit exceeded. Please try again later.")
 				return
 			}
 
-			// Add rate limit remaining header (informational)
-			w.Header().Set("X-RateLimit-Remaining", "available")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// retryAfterSeconds rounds a wait up to whole seconds for a Retry-After header, between
+// one second and an hour
+func retryAfterSeconds(wait time.Duration) int {
+	seconds := int(math.Ceil(wait.Seconds()))
+	return max(1, min(seconds, 3600))
+}
+
+// ConcurrencyLimitMiddleware bounds the requests processed at once with limiter. Requests
+// it sheds, because its queue is full or they waited too long, get 503 with a
+// Retry-After of one second. Occupancy, waits and shed requests are recorded when
+// metrics is not nil.
+func ConcurrencyLimitMiddleware(limiter *ratelimit.ConcurrencyLimiter, metrics *observability.Metrics) func(next http.Handler) http.Handler {
+	record := func() {
+		if metrics != nil {
+			stats := limiter.Stats()
+			metrics.SetTranslationConcurrency(stats.InFlight, stats.Queued)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			release, err := limiter.Acquire(r.Context())
+			if err != nil {
+				if metrics != nil {
+					reason := "canceled"
+					switch {
+					case errors.Is(err, ratelimit.ErrQueueFull):
+						reason = "queue_full"
+					case errors.Is(err, ratelimit.ErrQueueTimeout):
+						reason = "queue_timeout"
+					}
+					metrics.RecordShedTranslation(reason)
+				}
+				record()
+				w.Header().Set("Retry-After", "1")
+				RespondError(w, http.StatusServiceUnavailable, rsearch.ErrorCodeServiceUnavailable,
+					"Server is overloaded. Please try again later.")
+				return
+			}
+			if metrics != nil {
+				metrics.RecordTranslationQueueWait(time.Since(start).Seconds())
+			}
+			record()
+			defer record()
+			defer release()
 
 			next.ServeHTTP(w, r)
 		})
@@ -22,7 +22,7 @@ func TestRateLimitMiddleware_Disabled(t *testing.T) {
 	limiter := ratelimit.NewRateLimiter(10, 5)
 	defer limiter.Stop()
 
-	middleware := RateLimitMiddleware(limiter, cfg)
+	middleware := RateLimitMiddleware(limiter, cfg, nil)
 
 	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -56,7 +56,7 @@ func TestRateLimitMiddleware_AllowsWithinLimit(t *testing.T) {
 	limiter := ratelimit.NewRateLimiter(cfg.Limits.RateLimit.RequestsPerMinute, cfg.Limits.RateLimit.Burst)
 	defer limiter.Stop()
 
-	middleware := RateLimitMiddleware(limiter, cfg)
+	middleware := RateLimitMiddleware(limiter, cfg, nil)
 
 	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -96,7 +96,7 @@ func TestRateLimitMiddleware_BlocksExceededLimit(t *testing.T) {
 	limiter := ratelimit.NewRateLimiter(cfg.Limits.RateLimit.RequestsPerMinute, cfg.Limits.RateLimit.Burst)
 	defer limiter.Stop()
 
-	middleware := RateLimitMiddleware(limiter, cfg)
+	middleware := RateLimitMiddleware(limiter, cfg, nil)
 
 	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -151,7 +151,7 @@ func TestRateLimitMiddleware_ExtractsIPFromXForwardedFor(t *testing.T) {
 	limiter := ratelimit.NewRateLimiter(cfg.Limits.RateLimit.RequestsPerMinute, cfg.Limits.RateLimit.Burst)
 	defer limiter.Stop()
 
-	middleware := RateLimitMiddleware(limiter, cfg)
+	middleware := RateLimitMiddleware(limiter, cfg, nil)
 
 	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -201,7 +201,7 @@ func TestRateLimitMiddleware_ExtractsIPFromXRealIP(t *testing.T) {
 	limiter := ratelimit.NewRateLimiter(cfg.Limits.RateLimit.RequestsPerMinute, cfg.Limits.RateLimit.Burst)
 	defer limiter.Stop()
 
-	middleware := RateLimitMiddleware(limiter, cfg)
+	middleware := RateLimitMiddleware(limiter, cfg, nil)
 
 	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -239,7 +239,7 @@ func TestRateLimitMiddleware_UsesRemoteAddrFallback(t *testing.T) {
 	limiter := ratelimit.NewRateLimiter(cfg.Limits.RateLimit.RequestsPerMinute, cfg.Limits.RateLimit.Burst)
 	defer limiter.Stop()
 
-	middleware := RateLimitMiddleware(limiter, cfg)
+	middleware := RateLimitMiddleware(limiter, cfg, nil)
 
 	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -276,7 +276,7 @@ func TestRateLimitMiddleware_RetryAfterHeader(t *testing.T) {
 	limiter := ratelimit.NewRateLimiter(cfg.Limits.RateLimit.RequestsPerMinute, cfg.Limits.RateLimit.Burst)
 	defer limiter.Stop()
 
-	middleware := RateLimitMiddleware(limiter, cfg)
+	middleware := RateLimitMiddleware(limiter, cfg, nil)
 
 	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -317,3 +317,82 @@ func TestRateLimitMiddleware_RetryAfterHeader(t *testing.T) {
 		t.Errorf("Retry-After should be between 1 and 60 seconds, got: %d", seconds)
 	}
 }
+
+func TestRateLimitMiddleware_ComputesRetryAfter(t *testing.T) {
+	cfg := &config.Config{
+		Limits: config.LimitsConfig{
+			RateLimit: config.RateLimitConfig{
+				Enabled:           true,
+				RequestsPerMinute: 6, // a token every 10 seconds
+				Burst:             2,
+			},
+		},
+	}
+
+	limiter := ratelimit.NewRateLimiter(cfg.Limits.RateLimit.RequestsPerMinute, cfg.Limits.RateLimit.Burst)
+	defer limiter.Stop()
+
+	handler := RateLimitMiddleware(limiter, cfg, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	var responses []*httptest.ResponseRecorder
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = "192.168.1.1:12345"
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		responses = append(responses, rr)
+	}
+
+	if got := responses[0].Header().Get("X-RateLimit-Limit"); got != "2" {
+		t.Errorf("expected X-RateLimit-Limit 2, got %q", got)
+	}
+	if got := responses[0].Header().Get("X-RateLimit-Remaining"); got != "1" {
+		t.Errorf("expected X-RateLimit-Remaining 1 after the first request, got %q", got)
+	}
+	if got := responses[2].Header().Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("expected X-RateLimit-Remaining 0 when rejected, got %q", got)
+	}
+	if got := responses[2].Header().Get("Retry-After"); got != "10" {
+		t.Errorf("expected Retry-After 10 until the next token, got %q", got)
+	}
+}
+
+func TestConcurrencyLimitMiddleware_ShedsOverload(t *testing.T) {
+	limiter := ratelimit.NewConcurrencyLimiter(1, 0, 0)
+
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+	handler := ConcurrencyLimitMiddleware(limiter, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-unblock
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	done := make(chan int)
+	go func() {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/translate", nil))
+		done <- rr.Code
+	}()
+	<-entered
+
+	// No slot and no queue: the next request is shed right away
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/translate", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("expected Retry-After 1, got %q", got)
+	}
+
+	close(unblock)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("expected the first request to succeed, got %d", code)
+	}
+	if stats := limiter.Stats(); stats != (ratelimit.ConcurrencyStats{Shed: 1}) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/infiniv/rsearch/internal/config"
+	"github.com/infiniv/rsearch/internal/observability"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	cfg := &config.Config{Features: config.FeaturesConfig{RequestIDHeader: "X-Correlation-ID"}}
+
+	var seen string
+	handler := RequestIDMiddleware(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = observability.RequestIDFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name     string
+		incoming string
+		honored  bool
+	}{
+		{"generated when missing", "", false},
+		{"honored when valid", "4bf92f3577b34da6a3ce929d0e0e4736", true},
+		{"replaced when unsafe to log", "abc\n{\"level\":\"error\"}", false},
+		{"replaced when too long", strings.Repeat("a", 129), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/health", nil)
+			if tt.incoming != "" {
+				req.Header.Set("X-Correlation-ID", tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			returned := w.Header().Get("X-Correlation-ID")
+			require.NotEmpty(t, returned)
+			assert.Equal(t, returned, seen)
+			if tt.honored {
+				assert.Equal(t, tt.incoming, returned)
+			} else {
+				assert.NotEqual(t, tt.incoming, returned)
+			}
+		})
+	}
+}
+
+func TestRequestIDMiddleware_CorrelatesErrorsAndLogs(t *testing.T) {
+	cfg := &config.Config{Features: config.FeaturesConfig{RequestIDHeader: "X-Request-ID"}}
+	logPath := filepath.Join(t.TempDir(), "rsearch.log")
+	logger, err := observability.NewLogger("info", "json", logPath)
+	require.NoError(t, err)
+
+	// The logging middleware wraps the writer, as in the server
+	failing := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RespondError(w, http.StatusBadRequest, rsearch.ErrorCodeParseError, "bad query")
+	})
+	handler := RequestIDMiddleware(cfg)(LoggingMiddleware(logger)(failing))
+
+	req := httptest.NewRequest("POST", "/api/v1/translate", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var response rsearch.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "req-42", response.Error.RequestID)
+
+	logs, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(logs), `"request_id":"req-42"`)
+}
+
+func TestLoggingMiddleware_AccessLog(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "rsearch.log")
+	logger, err := observability.NewLogger("info", "json", logPath)
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Use(LoggingMiddleware(logger))
+	router.Post("/api/v1/schemas/{name}/translate", func(w http.ResponseWriter, r *http.Request) {
+		noteAccess(r.Context(), chi.URLParam(r, "name"), "postgres")
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	req := httptest.NewRequest("POST", "/api/v1/schemas/products/translate", strings.NewReader(`{"query":"name:laptop"}`))
+	req.ContentLength = -1 // chunked, so the bytes read are counted
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/nowhere", nil))
+
+	logs, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(logs)), "\n")
+	require.Len(t, lines, 2)
+
+	var served, unmatched map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &served))
+	assert.Equal(t, "POST", served["method"])
+	assert.Equal(t, "/api/v1/schemas/products/translate", served["path"])
+	assert.Equal(t, "/api/v1/schemas/{name}/translate", served["route"])
+	assert.Equal(t, float64(http.StatusAccepted), served["status"])
+	assert.Equal(t, float64(len(`{"query":"name:laptop"}`)), served["request_bytes"])
+	assert.Equal(t, "products", served["schema"])
+	assert.Equal(t, "postgres", served["dialect"])
+	assert.Contains(t, served, "duration_us")
+
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &unmatched))
+	assert.Equal(t, unmatchedRoute, unmatched["route"])
+	assert.Equal(t, float64(0), unmatched["request_bytes"])
+	assert.NotContains(t, unmatched, "schema")
+}
+
+func TestRespondError_WithoutRequestID(t *testing.T) {
+	w := httptest.NewRecorder()
+	RespondError(w, http.StatusBadRequest, rsearch.ErrorCodeParseError, "bad query")
+
+	assert.NotContains(t, w.Body.String(), "requestId")
+}
@@ -0,0 +1,472 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/infiniv/rsearch/internal/analytics"
+	"github.com/infiniv/rsearch/internal/history"
+	"github.com/infiniv/rsearch/internal/openapi"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// OpenAPIPath is where the generated OpenAPI document is served
+const OpenAPIPath = "/openapi.json"
+
+// OpenAPIHandler serves an OpenAPI 3 document describing the mounted routes.
+// Request and response schemas are generated from the handler types, so the
+// document stays in sync with the wire format.
+type OpenAPIHandler struct {
+	routes      chi.Routes
+	metricsPath string
+
+	once sync.Once
+	body []byte
+	err  error
+}
+
+// NewOpenAPIHandler creates a handler documenting the routes of a router.
+// The router is walked on the first request, once all routes are mounted.
+// metricsPath is the configured Prometheus endpoint, or empty when metrics are disabled.
+func NewOpenAPIHandler(routes chi.Routes, metricsPath string) *OpenAPIHandler {
+	return &OpenAPIHandler{
+		routes:      routes,
+		metricsPath: metricsPath,
+	}
+}
+
+// ServeHTTP handles GET /openapi.json
+func (h *OpenAPIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.once.Do(func() {
+		var doc openapi.Object
+		doc, h.err = BuildOpenAPI(h.routes, h.metricsPath)
+		if h.err == nil {
+			h.body, h.err = json.Marshal(doc)
+		}
+	})
+	if h.err != nil {
+		RespondInternalError(w, h.err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(h.body)
+}
+
+// BuildOpenAPI generates the OpenAPI document of the routes mounted on a router.
+// Routes without a documented operation are left out.
+func BuildOpenAPI(routes chi.Routes, metricsPath string) (openapi.Object, error) {
+	g := openapi.NewGenerator()
+	operations := apiOperations(g)
+
+	paths := make(openapi.Object)
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		key := method + " " + route
+		if route == metricsPath {
+			// Handle mounts the metrics endpoint for every method; only GET is meaningful
+			if method != http.MethodGet {
+				return nil
+			}
+			key = "GET /metrics"
+		}
+
+		operation, ok := operations[key]
+		if !ok {
+			return nil
+		}
+
+		item, _ := paths[route].(openapi.Object)
+		if item == nil {
+			item = make(openapi.Object)
+			paths[route] = item
+		}
+		item[strings.ToLower(method)] = withCommonResponses(operation, g)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk routes: %w", err)
+	}
+
+	return openapi.Object{
+		"openapi": openapi.Version,
+		"info": openapi.Object{
+			"title":       "rsearch API",
+			"description": "Translates OpenSearch-style query strings into parameterized database queries.",
+			"version":     rsearch.Version,
+		},
+		"paths":      paths,
+		"components": openapi.Object{"schemas": g.Components()},
+	}, nil
+}
+
+// apiOperations returns the documented operations keyed by "METHOD route"
+func apiOperations(g *openapi.Generator) map[string]openapi.Object {
+	g.Enum(schema.FieldType(""),
+		string(schema.TypeText), string(schema.TypeInteger), string(schema.TypeFloat),
+		string(schema.TypeBoolean), string(schema.TypeDateTime), string(schema.TypeDate),
+		string(schema.TypeTime), string(schema.TypeJSON), string(schema.TypeArray))
+	g.Require(schema.Schema{}, "name", "fields")
+	g.Require(schema.Field{}, "type")
+	g.Require(schema.DefaultFilter{}, "name", "query")
+	g.Require(TranslateRequest{}, "query") // schema and database may come from headers
+	g.Require(SuggestValuesRequest{}, "schema", "database", "field")
+
+	// Legacy handlers answer with a flat error; newer ones with a coded error
+	legacyError := g.Ref(ErrorResponse{})
+	codedError := g.Ref(rsearch.ErrorResponse{})
+
+	schemaRef := g.Ref(schema.Schema{})
+	translated := translateResponse(g)
+	historyID := openapi.Object{
+		"name":     "id",
+		"in":       "path",
+		"required": true,
+		"schema":   openapi.Object{"type": "integer", "minimum": 1},
+	}
+	schemaName := openapi.Object{
+		"name":     "name",
+		"in":       "path",
+		"required": true,
+		"schema":   openapi.Object{"type": "string"},
+	}
+
+	return map[string]openapi.Object{
+		"GET /health": {
+			"operationId": "healthCheck",
+			"summary":     "Liveness check",
+			"responses": openapi.Object{
+				"200": jsonResponse("Service is healthy", g.Ref(rsearch.HealthResponse{})),
+			},
+		},
+		"GET /ready": {
+			"operationId": "readinessCheck",
+			"summary":     "Readiness check",
+			"responses": openapi.Object{
+				"200": jsonResponse("Service is ready", g.Define("ReadyResponse", openapi.Object{
+					"type": "object",
+					"properties": openapi.Object{
+						"ready":   openapi.Object{"type": "boolean"},
+						"version": openapi.Object{"type": "string"},
+					},
+				})),
+			},
+		},
+		"GET /version": {
+			"operationId": "getVersion",
+			"summary":     "Build of the running server",
+			"responses": openapi.Object{
+				"200": jsonResponse("Version, commit and build date of the server", g.Ref(rsearch.BuildInfo{})),
+			},
+		},
+		"GET /metrics": {
+			"operationId": "getMetrics",
+			"summary":     "Prometheus metrics",
+			"responses": openapi.Object{
+				"200": openapi.Object{
+					"description": "Metrics in the Prometheus text exposition format",
+					"content":     openapi.Object{"text/plain": openapi.Object{"schema": openapi.Object{"type": "string"}}},
+				},
+			},
+		},
+		"GET " + OpenAPIPath: {
+			"operationId": "getOpenAPI",
+			"summary":     "This OpenAPI document",
+			"responses": openapi.Object{
+				"200": jsonResponse("OpenAPI 3 document", openapi.Object{"type": "object"}),
+			},
+		},
+		"POST /api/v1/schemas": {
+			"operationId": "registerSchema",
+			"summary":     "Register a schema",
+			"requestBody": jsonBody(schemaRef),
+			"responses": openapi.Object{
+				"201": jsonResponse("Schema registered", successResponse(schemaRef)),
+				"400": jsonResponse("Invalid schema or schema already registered", legacyError),
+			},
+		},
+		"GET /api/v1/schemas": {
+			"operationId": "listSchemas",
+			"summary":     "List registered schemas",
+			"responses": openapi.Object{
+				"200": jsonResponse("Registered schemas", successResponse(openapi.Object{"type": "array", "items": schemaRef})),
+			},
+		},
+		"GET /api/v1/schemas/{name}": {
+			"operationId": "getSchema",
+			"summary":     "Get a schema",
+			"parameters":  []openapi.Object{schemaName},
+			"responses": openapi.Object{
+				"200": jsonResponse("The schema", schemaRef),
+				"404": jsonResponse("Schema not found", legacyError),
+			},
+		},
+		"DELETE /api/v1/schemas/{name}": {
+			"operationId": "deleteSchema",
+			"summary":     "Delete a schema",
+			"parameters":  []openapi.Object{schemaName},
+			"responses": openapi.Object{
+				"204": openapi.Object{"description": "Schema deleted"},
+				"404": jsonResponse("Schema not found", legacyError),
+			},
+		},
+		"GET /api/v1/schemas/{name}/fields": {
+			"operationId": "getSchemaFields",
+			"summary":     "Document the fields of a schema",
+			"parameters":  []openapi.Object{schemaName},
+			"responses": openapi.Object{
+				"200": jsonResponse("Field documentation", g.Ref(FieldsResponse{})),
+				"404": jsonResponse("Schema not found", codedError),
+			},
+		},
+		"POST /api/v1/translate": {
+			"operationId": "translateQuery",
+			"summary":     "Translate a query",
+			"parameters": []openapi.Object{
+				{"name": "format", "in": "query", "description": "Response format version, overriding the Accept header", "schema": openapi.Object{
+					"type": "string", "enum": []string{"v1", "v2"},
+				}},
+				{"name": SchemaHeader, "in": "header", "description": "Schema, when the body does not name it", "schema": openapi.Object{"type": "string"}},
+				{"name": DialectHeader, "in": "header", "description": "Database, when the body does not name it", "schema": openapi.Object{"type": "string"}},
+			},
+			"requestBody": jsonBody(g.Ref(TranslateRequest{})),
+			"responses": openapi.Object{
+				"200": jsonResponse("Translated query", translated),
+				"400": jsonResponse("Invalid request, query or database type, or headers conflicting with the body", openapi.Object{
+					"oneOf": []openapi.Object{legacyError, codedError},
+				}),
+				"403": jsonResponse("Skipping default filters is not authorized", legacyError),
+				"404": jsonResponse("Schema not found", legacyError),
+				"406": jsonResponse("Unsupported response format version", legacyError),
+				"503": jsonResponse("Server overloaded beyond its concurrency limit", codedError),
+			},
+		},
+		"GET /api/v1/translate": {
+			"operationId": "translateQueryFromURL",
+			"summary":     "Translate a query given in the URL, for caches in front of public search widgets",
+			"parameters": []openapi.Object{
+				{"name": queryParam, "in": "query", "required": true, "description": "Query string, URL encoded", "schema": openapi.Object{"type": "string"}},
+				{"name": schemaParam, "in": "query", "description": "Schema, unless the X-RSearch-Schema header names it", "schema": openapi.Object{"type": "string"}},
+				{"name": dialectParam, "in": "query", "description": "Database, unless the X-RSearch-Dialect header names it", "schema": openapi.Object{"type": "string"}},
+				{"name": "format", "in": "query", "description": "Response format version, overriding the Accept header", "schema": openapi.Object{
+					"type": "string", "enum": []string{"v1", "v2"},
+				}},
+				{"name": SchemaHeader, "in": "header", "description": "Schema, when the URL does not name it", "schema": openapi.Object{"type": "string"}},
+				{"name": DialectHeader, "in": "header", "description": "Database, when the URL does not name it", "schema": openapi.Object{"type": "string"}},
+				{"name": "If-None-Match", "in": "header", "description": "ETag of a cached translation, answered with 304 when unchanged", "schema": openapi.Object{"type": "string"}},
+			},
+			"responses": openapi.Object{
+				"200": jsonResponse("Translated query, with an ETag and Cache-Control", translated),
+				"304": openapi.Object{"description": "The cached translation is unchanged"},
+				"400": jsonResponse("Invalid query string, query or database type, or headers conflicting with the URL", openapi.Object{
+					"oneOf": []openapi.Object{legacyError, codedError},
+				}),
+				"404": jsonResponse("Schema not found", legacyError),
+				"406": jsonResponse("Unsupported response format version", legacyError),
+				"414": jsonResponse("Query string over the configured length; send the query with POST", codedError),
+				"503": jsonResponse("Server overloaded beyond its concurrency limit", codedError),
+			},
+		},
+		"GET /api/v1/suggest/fields": {
+			"operationId": "suggestFields",
+			"summary":     "Rank the fields a partial or misspelled name may refer to",
+			"parameters": []openapi.Object{
+				{"name": "schema", "in": "query", "required": true, "schema": openapi.Object{"type": "string"}},
+				{"name": "q", "in": "query", "required": true, "description": "Partial field name", "schema": openapi.Object{"type": "string"}},
+				{"name": "limit", "in": "query", "description": "Fields to return", "schema": openapi.Object{
+					"type": "integer", "minimum": 1, "maximum": rsearch.MaxSuggestLimit, "default": rsearch.DefaultSuggestLimit,
+				}},
+			},
+			"responses": openapi.Object{
+				"200": jsonResponse("Matching fields, best first", g.Ref(FieldSuggestionsResponse{})),
+				"400": jsonResponse("Missing schema or q, or invalid limit", codedError),
+				"404": jsonResponse("Schema not found", codedError),
+			},
+		},
+		"POST /api/v1/suggest/values": {
+			"operationId": "suggestValues",
+			"summary":     "Build a query listing distinct values of a field",
+			"requestBody": jsonBody(g.Ref(SuggestValuesRequest{})),
+			"responses": openapi.Object{
+				"200": jsonResponse("Query for the caller to run", g.Ref(SuggestValuesResponse{})),
+				"400": jsonResponse("Invalid request, field or database type", codedError),
+				"404": jsonResponse("Schema not found", codedError),
+			},
+		},
+		"GET /api/v1/patterns": {
+			"operationId": "listPatterns",
+			"summary":     "List the library of query patterns, rendered from their examples",
+			"responses": openapi.Object{
+				"200": jsonResponse("Patterns sorted by name, with their translations by dialect", g.Ref(PatternsResponse{})),
+			},
+		},
+		"GET /api/v1/patterns/{name}": {
+			"operationId": "getPattern",
+			"summary":     "Render a query pattern from parameter values, given as query parameters named after the pattern's parameters",
+			"parameters": []openapi.Object{
+				{"name": "name", "in": "path", "required": true, "schema": openapi.Object{"type": "string"}},
+				{"name": "schema", "in": "query", "description": "Schema whose field the pattern is applied to, for previews against it", "schema": openapi.Object{"type": "string"}},
+			},
+			"responses": openapi.Object{
+				"200": jsonResponse("The rendered pattern, with its translations by dialect", g.Ref(QueryPattern{})),
+				"400": jsonResponse("Missing or invalid parameter values, or a field of the wrong type", codedError),
+				"404": jsonResponse("Pattern or schema not found", codedError),
+			},
+		},
+		"GET /api/v1/stats": {
+			"operationId": "getStats",
+			"summary":     "Query usage statistics",
+			"parameters": []openapi.Object{{
+				"name":        "schema",
+				"in":          "query",
+				"description": "Only report statistics of this schema",
+				"schema":      openapi.Object{"type": "string"},
+			}},
+			"responses": openapi.Object{
+				"200": jsonResponse("Usage snapshot", g.Ref(analytics.Snapshot{})),
+				"404": jsonResponse("No statistics recorded for the schema", codedError),
+			},
+		},
+		"GET /api/v1/admin/history": {
+			"operationId": "listHistory",
+			"summary":     "List recent translate requests, newest first",
+			"parameters": []openapi.Object{
+				{"name": "schema", "in": "query", "description": "Only list requests of this schema", "schema": openapi.Object{"type": "string"}},
+				{"name": "limit", "in": "query", "description": "Requests to return, all kept when 0", "schema": openapi.Object{"type": "integer", "minimum": 0}},
+			},
+			"responses": openapi.Object{
+				"200": jsonResponse("Recorded requests", g.Ref(HistoryResponse{})),
+				"400": jsonResponse("Invalid limit", codedError),
+				"401": jsonResponse("Missing or unknown X-API-Key", codedError),
+			},
+		},
+		"GET /api/v1/admin/history/{id}": {
+			"operationId": "getHistoryEntry",
+			"summary":     "Get a recorded translate request",
+			"parameters":  []openapi.Object{historyID},
+			"responses": openapi.Object{
+				"200": jsonResponse("Recorded request", g.Ref(history.Entry{})),
+				"400": jsonResponse("Invalid ID", codedError),
+				"401": jsonResponse("Missing or unknown X-API-Key", codedError),
+				"404": jsonResponse("No such request kept", codedError),
+			},
+		},
+		"POST /api/v1/admin/history/{id}/replay": {
+			"operationId": "replayHistoryEntry",
+			"summary":     "Translate a recorded request again, optionally against another schema or database",
+			"parameters":  []openapi.Object{historyID},
+			"requestBody": openapi.Object{
+				"required": false,
+				"content":  openapi.Object{"application/json": openapi.Object{"schema": g.Ref(ReplayRequest{})}},
+			},
+			"responses": openapi.Object{
+				"200": jsonResponse("Translated query, with the replayed ID in X-Replay-Of", translated),
+				"400": jsonResponse("Invalid ID, body, or replayed query", openapi.Object{
+					"oneOf": []openapi.Object{legacyError, codedError},
+				}),
+				"401": jsonResponse("Missing or unknown X-API-Key", codedError),
+				"404": jsonResponse("No such request kept, or schema not found", openapi.Object{
+					"oneOf": []openapi.Object{legacyError, codedError},
+				}),
+			},
+		},
+	}
+}
+
+// translateOutputs lists the properties of TranslateResponse set by each output type
+var translateOutputs = []struct {
+	name       string
+	outputType string
+	required   []string
+	properties []string
+}{
+	{"SQLTranslateResponse", "sql", []string{"type", "whereClause"},
+		[]string{"type", "formatVersion", "whereClause", "parameters", "parameterTypes", "selectClause", "defaultFilters", "executionHints", "warnings", "warningDetails", "explain", "plan", "appliedPolicies", "referencedFields", "facets", "downgrades", "coercions", "partialIndexes", "normalizedQuery", "orderByClause"}},
+	{"MongoDBTranslateResponse", "mongodb", []string{"type", "filter"},
+		[]string{"type", "formatVersion", "filter", "projection", "defaultFilters", "executionHints", "warnings", "warningDetails", "explain", "plan", "minTextScore", "appliedPolicies", "referencedFields", "facets", "downgrades", "coercions", "partialIndexes", "normalizedQuery", "sort"}},
+}
+
+// translateResponse defines one component per output type, using the generated
+// TranslateResponse properties, and returns their union discriminated by type
+func translateResponse(g *openapi.Generator) openapi.Object {
+	g.Ref(TranslateResponse{})
+	generated := g.Components()["TranslateResponse"].(openapi.Object)["properties"].(openapi.Object)
+
+	variants := make([]openapi.Object, 0, len(translateOutputs))
+	mapping := make(openapi.Object, len(translateOutputs))
+	for _, output := range translateOutputs {
+		properties := make(openapi.Object, len(output.properties))
+		for _, name := range output.properties {
+			property, ok := generated[name]
+			if !ok {
+				panic("TranslateResponse has no property " + name)
+			}
+			properties[name] = property
+		}
+		properties["type"] = openapi.Object{"type": "string", "enum": []string{output.outputType}}
+
+		variant := g.Define(output.name, openapi.Object{
+			"type":       "object",
+			"required":   output.required,
+			"properties": properties,
+		})
+		variants = append(variants, variant)
+		mapping[output.outputType] = variant["$ref"]
+	}
+
+	return g.Define("TranslateResponse", openapi.Object{
+		"oneOf": variants,
+		"discriminator": openapi.Object{
+			"propertyName": "type",
+			"mapping":      mapping,
+		},
+	})
+}
+
+// withCommonResponses adds the errors every route can return from middleware
+func withCommonResponses(operation openapi.Object, g *openapi.Generator) openapi.Object {
+	responses := make(openapi.Object)
+	for status, response := range operation["responses"].(openapi.Object) {
+		responses[status] = response
+	}
+	codedError := g.Ref(rsearch.ErrorResponse{})
+	responses["429"] = jsonResponse("Rate limit exceeded", codedError)
+	responses["500"] = jsonResponse("Internal error", codedError)
+
+	result := make(openapi.Object, len(operation))
+	for key, value := range operation {
+		result[key] = value
+	}
+	result["responses"] = responses
+	return result
+}
+
+// successResponse wraps data in the SuccessResponse envelope
+func successResponse(data openapi.Object) openapi.Object {
+	return openapi.Object{
+		"type": "object",
+		"properties": openapi.Object{
+			"message": openapi.Object{"type": "string"},
+			"data":    data,
+		},
+	}
+}
+
+func jsonBody(schema openapi.Object) openapi.Object {
+	return openapi.Object{
+		"required": true,
+		"content":  openapi.Object{"application/json": openapi.Object{"schema": schema}},
+	}
+}
+
+func jsonResponse(description string, schema openapi.Object) openapi.Object {
+	return openapi.Object{
+		"description": description,
+		"content":     openapi.Object{"application/json": openapi.Object{"schema": schema}},
+	}
+}
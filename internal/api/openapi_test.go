@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/infiniv/rsearch/internal/analytics"
+	"github.com/infiniv/rsearch/internal/config"
+	"github.com/infiniv/rsearch/internal/observability"
+	"github.com/infiniv/rsearch/internal/ratelimit"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRouter(t *testing.T) *chi.Mux {
+	cfg := &config.Config{
+		Logging:  config.LoggingConfig{Level: "error", Format: "json", Output: "stdout"},
+		Features: config.FeaturesConfig{RequestIDHeader: "X-Request-ID"},
+		API:      config.APIConfig{GetTranslate: config.GetTranslateConfig{Enabled: true}},
+	}
+	logger, err := observability.NewLogger(cfg.Logging.Level, cfg.Logging.Format, cfg.Logging.Output)
+	require.NoError(t, err)
+
+	rateLimiter := ratelimit.NewRateLimiter(100, 10)
+	t.Cleanup(rateLimiter.Stop)
+
+	return SetupRoutes(cfg, logger, nil, schema.NewRegistry(), translator.NewRegistry(), rateLimiter, analytics.NewTracker(nil), nil, nil, nil)
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	router := newTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", OpenAPIPath, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc["openapi"])
+
+	// Every mounted route is documented
+	var mounted []string
+	require.NoError(t, chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		mounted = append(mounted, method+" "+route)
+		return nil
+	}))
+	sort.Strings(mounted)
+
+	var documented []string
+	for route, item := range doc["paths"].(map[string]interface{}) {
+		for method := range item.(map[string]interface{}) {
+			documented = append(documented, strings.ToUpper(method)+" "+route)
+		}
+	}
+	sort.Strings(documented)
+	assert.Equal(t, mounted, documented)
+
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	for _, name := range []string{"Schema", "Field", "TranslateRequest", "TranslateResponse", "SQLTranslateResponse", "MongoDBTranslateResponse", "FieldsResponse", "Snapshot", "ErrorResponse", "RsearchErrorResponse"} {
+		assert.Contains(t, schemas, name)
+	}
+}
+
+func TestBuildOpenAPI_Metrics(t *testing.T) {
+	router := chi.NewRouter()
+	router.Handle("/internal/metrics", http.NotFoundHandler())
+
+	doc, err := BuildOpenAPI(router, "/internal/metrics")
+	require.NoError(t, err)
+
+	paths := doc["paths"].(map[string]interface{})
+	require.Contains(t, paths, "/internal/metrics")
+	assert.Equal(t, []string{"get"}, keys(paths["/internal/metrics"].(map[string]interface{})))
+}
+
+func TestBuildOpenAPI_TranslateResponseVariants(t *testing.T) {
+	// Every TranslateResponse property belongs to at least one output type
+	covered := make(map[string]bool)
+	for _, output := range translateOutputs {
+		for _, property := range output.properties {
+			covered[property] = true
+		}
+	}
+
+	responseType := reflect.TypeOf(TranslateResponse{})
+	for i := 0; i < responseType.NumField(); i++ {
+		name, _, _ := strings.Cut(responseType.Field(i).Tag.Get("json"), ",")
+		assert.True(t, covered[name], "property %s is not part of any output type", name)
+	}
+
+	doc, err := BuildOpenAPI(chi.NewRouter(), "")
+	require.NoError(t, err)
+
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	response := schemas["TranslateResponse"].(map[string]interface{})
+	assert.Len(t, response["oneOf"], len(translateOutputs))
+
+	sql := schemas["SQLTranslateResponse"].(map[string]interface{})
+	assert.Equal(t, []string{"type", "whereClause"}, sql["required"])
+	assert.Contains(t, sql["properties"], "parameters")
+	assert.NotContains(t, sql["properties"], "filter")
+}
+
+func keys(m map[string]interface{}) []string {
+	result := make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/patterns"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// QueryPattern is a parameterized query from the pattern library.
+type QueryPattern = rsearch.QueryPattern
+
+// PatternsResponse is the response body of the patterns endpoint.
+type PatternsResponse = rsearch.PatternsResponse
+
+// PatternsHandler serves the library of query patterns, for query-builder UIs offering
+// them as snippets.
+type PatternsHandler struct {
+	schemaRegistry     *schema.Registry
+	translatorRegistry *translator.Registry
+}
+
+// NewPatternsHandler creates a new patterns handler.
+func NewPatternsHandler(schemaRegistry *schema.Registry, translatorRegistry *translator.Registry) *PatternsHandler {
+	return &PatternsHandler{
+		schemaRegistry:     schemaRegistry,
+		translatorRegistry: translatorRegistry,
+	}
+}
+
+// List handles GET /api/v1/patterns.
+// Patterns are sorted by name, each rendered from its parameter examples and previewed
+// in every registered dialect.
+func (h *PatternsHandler) List(w http.ResponseWriter, r *http.Request) {
+	all := patterns.All()
+	response := PatternsResponse{Patterns: make([]QueryPattern, 0, len(all))}
+	for _, pattern := range all {
+		query, err := pattern.Render(pattern.ExampleArgs())
+		if err != nil {
+			RespondInternalError(w, fmt.Sprintf("Rendering pattern %s failed: %s", pattern.Name, err.Error()))
+			return
+		}
+		response.Patterns = append(response.Patterns, h.describe(pattern, query, exampleSchema(pattern, pattern.ExampleArgs()[patterns.FieldParam])))
+	}
+	RespondJSON(w, http.StatusOK, response)
+}
+
+// Get handles GET /api/v1/patterns/{name}?schema=...&<parameter>=....
+// Without parameter values the pattern is rendered from its examples; with any, every
+// parameter is required. With a schema, the field must be one of its fields of a type the
+// pattern applies to, and previews translate against it.
+func (h *PatternsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	pattern, ok := patterns.Get(chi.URLParam(r, "name"))
+	if !ok {
+		RespondError(w, http.StatusNotFound, rsearch.ErrorCodePatternNotFound, fmt.Sprintf("No query pattern named %s", chi.URLParam(r, "name")))
+		return
+	}
+
+	values := r.URL.Query()
+	args := make(map[string]string, len(pattern.Params))
+	for _, param := range pattern.Params {
+		if value := values.Get(param.Name); value != "" {
+			args[param.Name] = value
+		}
+	}
+	if len(args) == 0 {
+		args = pattern.ExampleArgs()
+	}
+	query, err := pattern.Render(args)
+	if err != nil {
+		RespondError(w, http.StatusBadRequest, rsearch.ErrorCodeInvalidRequest, fmt.Sprintf("Invalid parameters: %s", err.Error()))
+		return
+	}
+
+	s := exampleSchema(pattern, args[patterns.FieldParam])
+	if name := values.Get("schema"); name != "" {
+		noteAccess(r.Context(), name, "")
+		if s, err = h.schemaRegistry.Get(name); err != nil {
+			RespondError(w, http.StatusNotFound, rsearch.ErrorCodeSchemaNotFound, err.Error())
+			return
+		}
+		_, field, err := s.ResolveField(args[patterns.FieldParam])
+		if err != nil {
+			RespondError(w, http.StatusBadRequest, rsearch.ErrorCodeFieldNotFound, err.Error())
+			return
+		}
+		if !pattern.AppliesTo(field.Type) {
+			RespondError(w, http.StatusBadRequest, rsearch.ErrorCodeTypeMismatch,
+				fmt.Sprintf("Pattern %s does not apply to field %s of type %s", pattern.Name, args[patterns.FieldParam], field.Type))
+			return
+		}
+	}
+
+	RespondJSON(w, http.StatusOK, h.describe(pattern, query, s))
+}
+
+// describe documents a pattern rendered as query, with its translations against s
+func (h *PatternsHandler) describe(pattern patterns.Pattern, query string, s *schema.Schema) QueryPattern {
+	described := QueryPattern{
+		Name:        pattern.Name,
+		Description: pattern.Description,
+		Template:    pattern.Template,
+		Parameters:  make([]rsearch.PatternParameter, len(pattern.Params)),
+		FieldTypes:  make([]string, len(pattern.FieldTypes)),
+		Query:       query,
+		Previews:    make(map[string]rsearch.PatternPreview),
+	}
+	for i, param := range pattern.Params {
+		described.Parameters[i] = rsearch.PatternParameter{Name: param.Name, Kind: param.Kind, Description: param.Description, Example: param.Example}
+	}
+	for i, fieldType := range pattern.FieldTypes {
+		described.FieldTypes[i] = string(fieldType)
+	}
+
+	ast, parseErr := parser.NewParser(query).Parse()
+	for _, dbType := range h.translatorRegistry.List() {
+		trans, err := h.translatorRegistry.Get(dbType)
+		if err != nil {
+			continue
+		}
+		if parseErr != nil {
+			described.Previews[dbType] = rsearch.PatternPreview{Error: parseErr.Error()}
+			continue
+		}
+		output, err := trans.Translate(ast, s)
+		if err != nil {
+			described.Previews[dbType] = rsearch.PatternPreview{Error: err.Error()}
+			continue
+		}
+		described.Previews[dbType] = rsearch.PatternPreview{WhereClause: output.WhereClause, Parameters: output.Parameters, Filter: output.Filter}
+	}
+	return described
+}
+
+// exampleSchema returns a schema holding only a field of the type a pattern prefers,
+// for previews of patterns not applied to a registered schema
+func exampleSchema(pattern patterns.Pattern, field string) *schema.Schema {
+	return schema.NewSchema("patterns", map[string]schema.Field{
+		field: {Type: pattern.FieldTypes[0]},
+	}, schema.SchemaOptions{})
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPatternsRouter(t *testing.T) *chi.Mux {
+	schemaRegistry := schema.NewRegistry()
+	require.NoError(t, schemaRegistry.Register(schema.NewSchema("orders", map[string]schema.Field{
+		"status": {Type: schema.TypeText, Column: "order_status"},
+		"total":  {Type: schema.TypeInteger},
+	}, schema.SchemaOptions{})))
+	translatorRegistry := translator.NewRegistry()
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+	translatorRegistry.Register("mongodb", translator.NewMongoDBTranslator())
+
+	handler := NewPatternsHandler(schemaRegistry, translatorRegistry)
+	router := chi.NewRouter()
+	router.Get("/api/v1/patterns", handler.List)
+	router.Get("/api/v1/patterns/{name}", handler.Get)
+	return router
+}
+
+func TestPatternsHandler_List(t *testing.T) {
+	w := httptest.NewRecorder()
+	newPatternsRouter(t).ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/patterns", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response PatternsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	var names []string
+	for _, pattern := range response.Patterns {
+		names = append(names, pattern.Name)
+		for dbType, preview := range pattern.Previews {
+			assert.Empty(t, preview.Error, "%s in %s", pattern.Name, dbType)
+		}
+		assert.Len(t, pattern.Previews, 2)
+	}
+	assert.Equal(t, []string{"contains_any", "numeric_between", "one_of", "recent_days"}, names)
+
+	between := response.Patterns[1]
+	assert.Equal(t, "{field}:[{min} TO {max}]", between.Template)
+	assert.Equal(t, "price:[10 TO 99.99]", between.Query)
+	assert.Equal(t, []string{"float", "integer"}, between.FieldTypes)
+	assert.Equal(t, "price BETWEEN $1 AND $2", between.Previews["postgres"].WhereClause)
+	assert.Equal(t, map[string]interface{}{"price": map[string]interface{}{"$gte": float64(10), "$lte": 99.99}}, between.Previews["mongodb"].Filter)
+}
+
+func TestPatternsHandler_Get(t *testing.T) {
+	router := newPatternsRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/patterns/one_of?schema=orders&field=status&values=shipped,new+york,OR", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var pattern QueryPattern
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pattern))
+	assert.Equal(t, `status:(shipped, "new york", "OR")`, pattern.Query)
+	assert.Equal(t, "order_status IN ($1, $2, $3)", pattern.Previews["postgres"].WhereClause)
+	assert.Equal(t, []interface{}{"shipped", "new york", "OR"}, pattern.Previews["postgres"].Parameters)
+
+	// Without values, the examples are rendered against a field of the preferred type
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/patterns/recent_days", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pattern))
+	assert.Equal(t, "createdAt:last_7_days", pattern.Query)
+	assert.Equal(t, "createdAt >= $1 AND createdAt < $2", pattern.Previews["postgres"].WhereClause)
+
+	tests := []struct {
+		name   string
+		url    string
+		status int
+		code   string
+	}{
+		{"unknown pattern", "/api/v1/patterns/nearby", http.StatusNotFound, rsearch.ErrorCodePatternNotFound},
+		{"missing parameter", "/api/v1/patterns/numeric_between?field=total&min=1", http.StatusBadRequest, rsearch.ErrorCodeInvalidRequest},
+		{"injected bound", "/api/v1/patterns/numeric_between?field=total&min=1&max=5]+OR+x:[*", http.StatusBadRequest, rsearch.ErrorCodeInvalidRequest},
+		{"unknown schema", "/api/v1/patterns/one_of?schema=users&field=status&values=a", http.StatusNotFound, rsearch.ErrorCodeSchemaNotFound},
+		{"unknown field", "/api/v1/patterns/one_of?schema=orders&field=region&values=a", http.StatusBadRequest, rsearch.ErrorCodeFieldNotFound},
+		{"field of another type", "/api/v1/patterns/numeric_between?schema=orders&field=status&min=1&max=5", http.StatusBadRequest, rsearch.ErrorCodeTypeMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+			assert.Equal(t, tt.status, w.Code)
+
+			var response rsearch.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.code, response.Error.Code)
+		})
+	}
+}
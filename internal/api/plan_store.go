@@ -0,0 +1,313 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/observability"
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+)
+
+// planFileVersion is the format of plan cache files; files of other versions are ignored
+const planFileVersion = 1
+
+// planFile is the content of a plan cache file
+type planFile struct {
+	Version int          `json:"version"`
+	SavedAt time.Time    `json:"savedAt"`
+	Plans   []storedPlan `json:"plans"` // most recently used first
+}
+
+// storedPlan is a cached translation as saved to disk: the query it translates rather
+// than its output, so that restarted instances translate it again with their own
+// translators, and the fingerprint of the schema, so that plans of a schema that changed
+// since are discarded
+type storedPlan struct {
+	Schema      string `json:"schema"`
+	Fingerprint string `json:"fingerprint"`
+	Dialect     string `json:"dialect"` // database type of the translator
+	Query       string `json:"query"`   // as translated, default filters included
+}
+
+// cachedPlan is a plan cache entry: a translation and the query it translates. The query
+// is only rendered for saving, keeping the cost off the path of requests.
+type cachedPlan struct {
+	output  translator.TranslatorOutput
+	schema  string
+	version uint64
+	dialect string      // database type of the translator
+	ast     parser.Node // nil for schema copies with per-request overrides, which are not kept
+}
+
+// planKey returns the plan cache key of a query. Registry versions tell apart the
+// definitions a schema name had in this process.
+func planKey(sch *schema.Schema, version uint64, dbType string, ast parser.Node) string {
+	return fmt.Sprintf("%s\x00%d\x00%s\x00%s\x00%s", sch.Name, version, dbType, sch.Overrides(), parser.Fingerprint(ast))
+}
+
+// storePlan caches the output of a query. Outputs with warnings are not cached, as
+// warnings point at positions in the query, which queries sharing a key may not share.
+func (h *TranslateHandler) storePlan(key string, sch *schema.Schema, version uint64, dbType string, ast parser.Node, output *translator.TranslatorOutput) {
+	if _, warned := output.Metadata["warnings"]; warned {
+		return
+	}
+	plan := &cachedPlan{output: *output, schema: sch.Name, version: version, dialect: dbType}
+	if sch.Overrides() == "" {
+		plan.ast = ast
+	}
+	h.planCache.Set(key, plan)
+}
+
+// warmPlan translates a saved query into the plan cache
+func (h *TranslateHandler) warmPlan(trans translator.Translator, sch *schema.Schema, version uint64, query string) error {
+	ast, err := parser.NewParser(query).Parse()
+	if err != nil {
+		return err
+	}
+	if translator.UsesDateKeywords(ast) {
+		return fmt.Errorf("query resolves date keywords")
+	}
+	output, err := trans.Translate(ast, sch)
+	if err != nil {
+		return err
+	}
+	h.storePlan(planKey(sch, version, trans.DatabaseType(), ast), sch, version, trans.DatabaseType(), ast, output)
+	return nil
+}
+
+// PlanStore saves the plan cache of a translate handler to a file and warms the cache
+// from it after a restart. Saved queries are translated again as their schemas are
+// registered, when the schema's fingerprint still matches; plans of schemas that changed
+// are discarded.
+type PlanStore struct {
+	path   string
+	logger *observability.Logger
+
+	handler     *TranslateHandler
+	schemas     *schema.Registry
+	translators map[string]translator.Translator // by database type
+
+	mu      sync.Mutex
+	pending map[string][]storedPlan // loaded plans of schemas not registered yet, by schema
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewPlanStore creates a store for the plan cache at path. It takes effect once routes are
+// set up with it, and saves the cache periodically once started.
+func NewPlanStore(path string, logger *observability.Logger) *PlanStore {
+	return &PlanStore{
+		path:    path,
+		logger:  logger,
+		pending: make(map[string][]storedPlan),
+	}
+}
+
+// attach loads the saved plans into the plan cache of h: plans of registered schemas at
+// once, others as their schemas are registered
+func (s *PlanStore) attach(h *TranslateHandler, schemas *schema.Registry, translators *translator.Registry) {
+	s.handler = h
+	s.schemas = schemas
+	s.translators = make(map[string]translator.Translator)
+	for _, name := range translators.List() {
+		if trans, err := translators.Get(name); err == nil {
+			s.translators[trans.DatabaseType()] = trans
+		}
+	}
+
+	plans, err := s.load()
+	if err != nil {
+		s.logger.Warnf("Ignoring saved plan cache %s: %v", s.path, err)
+	}
+	bySchema := make(map[string][]storedPlan)
+	for _, plan := range plans {
+		bySchema[plan.Schema] = append(bySchema[plan.Schema], plan)
+	}
+
+	s.mu.Lock()
+	s.pending = bySchema
+	s.mu.Unlock()
+
+	schemas.Subscribe(func(e schema.Event) {
+		if e.Type != schema.EventRegistered {
+			return
+		}
+		s.mu.Lock()
+		plans, ok := s.pending[e.Name]
+		delete(s.pending, e.Name)
+		s.mu.Unlock()
+		if ok {
+			go s.warm(e.Name, plans)
+		}
+	})
+
+	for _, sch := range schemas.List() {
+		s.mu.Lock()
+		plans, ok := s.pending[sch.Name]
+		delete(s.pending, sch.Name)
+		s.mu.Unlock()
+		if ok {
+			s.warm(sch.Name, plans)
+		}
+	}
+}
+
+// load reads the saved plans. A missing file holds no plans.
+func (s *PlanStore) load() ([]storedPlan, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file planFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid plan cache file: %w", err)
+	}
+	if file.Version != planFileVersion {
+		return nil, fmt.Errorf("plan cache file version %d is not %d", file.Version, planFileVersion)
+	}
+	return file.Plans, nil
+}
+
+// warm translates the saved plans of a schema into the cache, in reverse so that the
+// most recently used plans are the last evicted
+func (s *PlanStore) warm(name string, plans []storedPlan) {
+	sch, version, err := s.schemas.GetVersioned(name)
+	if err != nil {
+		return
+	}
+	fingerprint := sch.Fingerprint()
+
+	warmed, discarded := 0, 0
+	for i := len(plans) - 1; i >= 0; i-- {
+		plan := plans[i]
+		trans, ok := s.translators[plan.Dialect]
+		if !ok || plan.Fingerprint != fingerprint {
+			discarded++
+			continue
+		}
+		if err := s.handler.warmPlan(trans, sch, version, plan.Query); err != nil {
+			discarded++
+			continue
+		}
+		warmed++
+	}
+	s.logger.Infof("Warmed the plan cache with %d translations of schema %s; discarded %d stale or failing", warmed, name, discarded)
+}
+
+// Save writes the plans in the cache to the file, with the plans loaded for schemas that
+// have not been registered since. Plans of schemas that changed are left out. The file is
+// replaced atomically and only readable by its owner, as it holds queries.
+//
+// Queries are raw text, so plans are only saved when the handler logs full queries;
+// otherwise the file is emptied. Plans querying fields with a codec or a hash are never
+// saved, as the values those fields protect would be written in plaintext.
+func (s *PlanStore) Save() error {
+	if s.handler == nil || s.handler.planCache == nil {
+		return nil
+	}
+	file := planFile{Version: planFileVersion, SavedAt: time.Now().UTC(), Plans: []storedPlan{}}
+	if s.handler.queryLogMode != observability.QueryLogFull {
+		return s.write(file)
+	}
+
+	// Plans of earlier versions of a schema are left out, to expire
+	schemas := make(map[string]*schema.Schema)
+	fingerprints := make(map[string]string)
+	versions := make(map[string]uint64)
+	for _, listed := range s.schemas.List() {
+		if sch, version, err := s.schemas.GetVersioned(listed.Name); err == nil {
+			schemas[sch.Name] = sch
+			fingerprints[sch.Name] = sch.Fingerprint()
+			versions[sch.Name] = version
+		}
+	}
+
+	seen := make(map[storedPlan]bool)
+	for _, value := range s.handler.planCache.Values() {
+		plan, ok := value.(*cachedPlan)
+		if !ok || plan.ast == nil || versions[plan.schema] != plan.version || encodesValues(plan.ast, schemas[plan.schema]) {
+			continue
+		}
+		stored := storedPlan{Schema: plan.schema, Fingerprint: fingerprints[plan.schema], Dialect: plan.dialect, Query: parser.Render(plan.ast)}
+		if !seen[stored] {
+			seen[stored] = true
+			file.Plans = append(file.Plans, stored)
+		}
+	}
+	s.mu.Lock()
+	for _, plans := range s.pending {
+		file.Plans = append(file.Plans, plans...)
+	}
+	s.mu.Unlock()
+	return s.write(file)
+}
+
+// write replaces the file with the plans of file
+func (s *PlanStore) write(file planFile) error {
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save the plan cache: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save the plan cache: %w", err)
+	}
+	return nil
+}
+
+// encodesValues reports whether a query reads a field of the schema with a codec or a hash
+func encodesValues(ast parser.Node, sch *schema.Schema) bool {
+	for _, referenced := range translator.ReferencedFields(ast, sch) {
+		if field := sch.Fields[referenced.Field]; field.Codec != "" || field.Hash != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Start saves the plan cache every interval until Stop is called
+func (s *PlanStore) Start(interval time.Duration) {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.Save(); err != nil {
+					s.logger.ErrorWithErr(err, "Saving the plan cache failed")
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic saves and saves the plan cache a last time
+func (s *PlanStore) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+	}
+	if err := s.Save(); err != nil {
+		s.logger.ErrorWithErr(err, "Saving the plan cache failed")
+	}
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/cache"
+	"github.com/infiniv/rsearch/internal/observability"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanStore_WarmsAfterRestart(t *testing.T) {
+	logger, err := observability.NewLogger("error", "json", "stdout")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "plans.json")
+
+	newSchema := func(column string) *schema.Schema {
+		return schema.NewSchema("products", map[string]schema.Field{
+			"name":  {Type: schema.TypeText, Column: column},
+			"price": {Type: schema.TypeFloat},
+		}, schema.SchemaOptions{})
+	}
+	translators := translator.NewRegistry()
+	translators.Register("postgres", translator.NewPostgresTranslator())
+
+	// start sets up an instance with the plan cache saved at path
+	start := func(schemas *schema.Registry) (*TranslateHandler, *cache.Cache, *PlanStore) {
+		planCache := cache.NewCache(100, 0)
+		handler := NewTranslateHandler(schemas, translators).
+			WithPlanCache(planCache, nil).
+			WithQueryLogging(observability.QueryLogFull)
+		store := NewPlanStore(path, logger)
+		store.attach(handler, schemas, translators)
+		return handler, planCache, store
+	}
+	send := func(handler *TranslateHandler, query string) string {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: query})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		return w.Body.String()
+	}
+
+	schemas := schema.NewRegistry()
+	require.NoError(t, schemas.Register(newSchema("name")))
+	handler, planCache, store := start(schemas)
+	assert.Equal(t, 0, planCache.Len())
+	first := send(handler, "price:>10 AND name:foo")
+	send(handler, "name:bar")
+	require.NoError(t, store.Save())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// A restarted instance warms the plans once their schema is registered
+	restarted := schema.NewRegistry()
+	handler, planCache, store = start(restarted)
+	assert.Equal(t, 0, planCache.Len())
+
+	// Plans loaded for schemas not registered yet are saved again
+	require.NoError(t, store.Save())
+	var file planFile
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &file))
+	assert.Len(t, file.Plans, 2)
+
+	require.NoError(t, restarted.Register(newSchema("name")))
+	require.Eventually(t, func() bool { return planCache.Len() == 2 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, first, send(handler, "price:>10  &&  name:foo"))
+	assert.Equal(t, 2, planCache.Len())
+
+	// Plans of a schema that changed since they were saved are discarded
+	changed := schema.NewRegistry()
+	require.NoError(t, changed.Register(newSchema("product_name")))
+	_, planCache, _ = start(changed)
+	assert.Equal(t, 0, planCache.Len())
+}
+
+func TestPlanStore_IgnoresOtherFileVersions(t *testing.T) {
+	logger, err := observability.NewLogger("error", "json", "stdout")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "plans.json")
+
+	schemas := schema.NewRegistry()
+	require.NoError(t, schemas.Register(schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})))
+	translators := translator.NewRegistry()
+	translators.Register("postgres", translator.NewPostgresTranslator())
+
+	data, _ := json.Marshal(planFile{Version: planFileVersion + 1, Plans: []storedPlan{
+		{Schema: "products", Fingerprint: schemas.List()[0].Fingerprint(), Dialect: "postgres", Query: "name:foo"},
+	}})
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	planCache := cache.NewCache(100, 0)
+	handler := NewTranslateHandler(schemas, translators).WithPlanCache(planCache, nil)
+	NewPlanStore(path, logger).attach(handler, schemas, translators)
+	assert.Equal(t, 0, planCache.Len())
+}
+
+func TestPlanStore_KeepsProtectedValuesOffDisk(t *testing.T) {
+	logger, err := observability.NewLogger("error", "json", "stdout")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "plans.json")
+
+	schemas := schema.NewRegistry()
+	require.NoError(t, schemas.RegisterCodec("pii", schema.CodecFunc(func(value string) (string, error) {
+		return "enc:" + value, nil
+	})))
+	require.NoError(t, schemas.Register(schema.NewSchema("users", map[string]schema.Field{
+		"ssn":  {Type: schema.TypeText, Codec: "pii", Column: "ssn_encrypted"},
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{DefaultField: "name"})))
+	translators := translator.NewRegistry()
+	translators.Register("postgres", translator.NewPostgresTranslator())
+
+	saved := func(mode observability.QueryLogMode) string {
+		handler := NewTranslateHandler(schemas, translators).
+			WithPlanCache(cache.NewCache(100, 0), nil).
+			WithQueryLogging(mode)
+		store := NewPlanStore(path, logger)
+		store.attach(handler, schemas, translators)
+		for _, query := range []string{"ssn:123-45-6789 AND name:alice", "name:bob"} {
+			body, _ := json.Marshal(TranslateRequest{Schema: "users", Database: "postgres", Query: query})
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		}
+		require.NoError(t, store.Save())
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	// Queries of encrypted fields are left out even when full queries may be logged
+	data := saved(observability.QueryLogFull)
+	assert.NotContains(t, data, "123-45-6789")
+	assert.Contains(t, data, "bob")
+
+	// Other modes save no queries at all
+	data = saved(observability.QueryLogRedacted)
+	assert.NotContains(t, data, "123-45-6789")
+	assert.NotContains(t, data, "bob")
+}
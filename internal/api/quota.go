@@ -0,0 +1,45 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/ratelimit"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// setQuotaHeaders reports a key's quota usage: the limit and remaining amount of each
+// limited quota, and when usage resets, in Unix seconds
+func setQuotaHeaders(w http.ResponseWriter, status ratelimit.QuotaStatus) {
+	if status.Quota.Queries > 0 {
+		w.Header().Set("X-Quota-Queries-Limit", strconv.Itoa(status.Quota.Queries))
+		w.Header().Set("X-Quota-Queries-Remaining", strconv.Itoa(max(0, status.Quota.Queries-status.Queries)))
+	}
+	if status.Quota.Complexity > 0 {
+		w.Header().Set("X-Quota-Complexity-Limit", strconv.Itoa(status.Quota.Complexity))
+		w.Header().Set("X-Quota-Complexity-Remaining", strconv.Itoa(max(0, status.Quota.Complexity-status.Complexity)))
+	}
+	w.Header().Set("X-Quota-Reset", strconv.FormatInt(status.Reset.Unix(), 10))
+}
+
+// respondQuotaExceeded rejects a request of a key over its quota with 429, retrying
+// when usage resets
+func respondQuotaExceeded(w http.ResponseWriter, status ratelimit.QuotaStatus) {
+	w.Header().Set("Retry-After", strconv.Itoa(max(1, int(math.Ceil(time.Until(status.Reset).Seconds())))))
+	RespondJSON(w, http.StatusTooManyRequests, rsearch.ErrorResponse{
+		Error: rsearch.ErrorDetail{
+			Code:      rsearch.ErrorCodeQuotaExceeded,
+			Message:   "Daily quota of this API key exceeded",
+			RequestID: requestIDOf(w),
+			Quota: &rsearch.QuotaUsage{
+				QueriesLimit:    status.Quota.Queries,
+				QueriesUsed:     status.Queries,
+				ComplexityLimit: status.Quota.Complexity,
+				ComplexityUsed:  status.Complexity,
+				ResetsAt:        status.Reset.Format(time.RFC3339),
+			},
+		},
+	})
+}
@@ -25,8 +25,9 @@ func RespondJSON(w http.ResponseWriter, status int, data interface{}) {
 func RespondError(w http.ResponseWriter, status int, code, message string) {
 	RespondJSON(w, status, rsearch.ErrorResponse{
 		Error: rsearch.ErrorDetail{
-			Code:    code,
-			Message: message,
+			Code:      code,
+			Message:   message,
+			RequestID: requestIDOf(w),
 		},
 	})
 }
@@ -35,10 +36,11 @@ func RespondError(w http.ResponseWriter, status int, code, message string) {
 func RespondErrorWithDetails(w http.ResponseWriter, status int, code, message, query string, details []rsearch.ErrorInfo) {
 	RespondJSON(w, status, rsearch.ErrorResponse{
 		Error: rsearch.ErrorDetail{
-			Code:    code,
-			Message: message,
-			Query:   query,
-			Details: details,
+			Code:      code,
+			Message:   message,
+			Query:     query,
+			Details:   details,
+			RequestID: requestIDOf(w),
 		},
 	})
 }
@@ -1,26 +1,91 @@
 package api
 
 import (
+	"time"
+
 	"github.com/go-chi/chi/v5"
+	"github.com/infiniv/rsearch/internal/analytics"
+	"github.com/infiniv/rsearch/internal/cache"
+	"github.com/infiniv/rsearch/internal/chaos"
 	"github.com/infiniv/rsearch/internal/config"
+	"github.com/infiniv/rsearch/internal/executor"
+	"github.com/infiniv/rsearch/internal/history"
 	"github.com/infiniv/rsearch/internal/observability"
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/policy"
 	"github.com/infiniv/rsearch/internal/ratelimit"
 	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/shadow"
 	"github.com/infiniv/rsearch/internal/translator"
 )
 
-// SetupRoutes sets up all HTTP routes
-func SetupRoutes(cfg *config.Config, logger *observability.Logger, metrics *observability.Metrics, schemaRegistry *schema.Registry, translatorRegistry *translator.Registry, rateLimiter *ratelimit.RateLimiter) *chi.Mux {
+// SetupRoutes sets up all HTTP routes.
+// tracker may be nil, in which case query usage is not recorded and the stats endpoint is not mounted.
+// comparer may be nil, in which case translations are not compared with OpenSearch.
+// plans may be nil, in which case the plan cache is not saved to disk.
+// queryExecutor may be nil, in which case query plans are not available.
+func SetupRoutes(cfg *config.Config, logger *observability.Logger, metrics *observability.Metrics, schemaRegistry *schema.Registry, translatorRegistry *translator.Registry, rateLimiter *ratelimit.RateLimiter, tracker *analytics.Tracker, comparer *shadow.Comparer, plans *PlanStore, queryExecutor *executor.Executor) *chi.Mux {
 	r := chi.NewRouter()
 
 	// Create handlers
 	handlers := NewHandlers(cfg, logger, metrics)
 	schemaHandler := NewHandler(schemaRegistry)
-	translateHandler := NewTranslateHandler(schemaRegistry, translatorRegistry)
+	translateHandler := NewTranslateHandler(schemaRegistry, translatorRegistry).
+		WithPolicy(policy.New(policy.Features{
+			Regex:           cfg.Features.Query.Regex,
+			Fuzzy:           cfg.Features.Query.Fuzzy,
+			Proximity:       cfg.Features.Query.Proximity,
+			LeadingWildcard: cfg.Features.Query.LeadingWildcard,
+			Exists:          cfg.Features.Query.Exists,
+		})).
+		WithTracker(tracker).
+		WithSlowLog(logger, metrics, cfg.Logging.SlowTranslationThreshold).
+		WithQueryLogging(observability.QueryLogMode(cfg.Logging.Queries)).
+		WithFragmentAudit(logger).
+		WithDefaultFilterBypass(cfg.Security.DefaultFilterBypassKeys).
+		WithParseLimits(parser.Limits{MaxTokens: cfg.Limits.MaxTokens, MaxLiteralBytes: cfg.Limits.MaxLiteralBytes}).
+		WithOutputLimit(cfg.Limits.MaxOutputBytes).
+		WithShadow(comparer).
+		WithGetTranslate(cfg.API.GetTranslate.MaxQueryStringLength, cfg.API.GetTranslate.CacheMaxAge)
+	if queryExecutor != nil {
+		translateHandler.WithExecutor(queryExecutor, cfg.Executor.Timeout, logger)
+	}
+	if quota := cfg.Limits.Quota; quota.Enabled {
+		overrides := make(map[string]ratelimit.Quota, len(quota.Keys))
+		for _, key := range quota.Keys {
+			overrides[key.Key] = ratelimit.Quota{Queries: key.QueriesPerDay, Complexity: key.ComplexityPerDay}
+		}
+		defaults := ratelimit.Quota{Queries: quota.QueriesPerDay, Complexity: quota.ComplexityPerDay}
+		translateHandler.WithQuotas(ratelimit.NewQuotaTracker(defaults, overrides, cfg.Security.Auth.APIKeys), metrics)
+	}
+	schemaRegistry.Subscribe(auditFragmentChanges(logger))
+	var historyStore *history.Store
+	if cfg.History.Enabled {
+		historyStore = history.NewStore(cfg.History.Size)
+		translateHandler.WithHistory(historyStore)
+		schemaRegistry.Subscribe(func(e schema.Event) {
+			if e.Type == schema.EventDeleted {
+				historyStore.Forget(e.Name)
+			}
+		})
+	}
+	if cfg.Cache.Enabled {
+		translateHandler.WithPlanCache(cache.NewCache(cfg.Cache.MaxSize, time.Duration(cfg.Cache.TTL)*time.Second), metrics)
+		if plans != nil {
+			plans.attach(translateHandler, schemaRegistry, translatorRegistry)
+		}
+	}
+
+	var chaosInjector *chaos.Injector
+	if cfg.Chaos.Enabled {
+		chaosInjector = chaos.New(cfg.Chaos.Faults(), cfg.Chaos.Seed)
+		translateHandler.WithChaos(chaosInjector)
+		logger.Warn("Chaos mode is enabled: faults are injected into API requests; never enable it in production")
+	}
 
 	// Global middleware
 	r.Use(RequestIDMiddleware(cfg))
-	r.Use(RateLimitMiddleware(rateLimiter, cfg))
+	r.Use(RateLimitMiddleware(rateLimiter, cfg, metrics))
 	r.Use(LoggingMiddleware(logger))
 	r.Use(RecoveryMiddleware(logger))
 	r.Use(CORSMiddleware(cfg))
@@ -30,25 +95,70 @@ func SetupRoutes(cfg *config.Config, logger *observability.Logger, metrics *obse
 		r.Use(MetricsMiddleware(metrics))
 	}
 
-	// Health and readiness endpoints (no /api prefix)
+	// Health, readiness and version endpoints (no /api prefix)
 	r.Get("/health", handlers.Health)
 	r.Get("/ready", handlers.Ready)
+	r.Get("/version", handlers.Version)
 
 	// Metrics endpoint (only if enabled)
+	metricsPath := ""
 	if cfg.Metrics.Enabled && metrics != nil {
-		r.Handle(cfg.Metrics.Path, handlers.Metrics())
+		metricsPath = cfg.Metrics.Path
+		r.Handle(metricsPath, handlers.Metrics())
 	}
 
+	// OpenAPI document of the routes mounted below
+	r.Get(OpenAPIPath, NewOpenAPIHandler(r, metricsPath).ServeHTTP)
+
 	// API v1 routes
 	r.Route("/api/v1", func(r chi.Router) {
+		if chaosInjector != nil {
+			r.Use(ChaosMiddleware(chaosInjector))
+		}
+
 		// Schema endpoints
 		r.Post("/schemas", schemaHandler.RegisterSchema)
 		r.Get("/schemas", schemaHandler.ListSchemas)
 		r.Get("/schemas/{name}", schemaHandler.GetSchema)
 		r.Delete("/schemas/{name}", schemaHandler.DeleteSchema)
+		r.Get("/schemas/{name}/fields", NewFieldsHandler(schemaRegistry, translatorRegistry).ServeHTTP)
+
+		// Translation endpoint, shedding load beyond the concurrency limit
+		translate := r.With(SelectionMiddleware)
+		if limits := cfg.Limits.Concurrency; limits.MaxInFlight > 0 {
+			concurrencyLimiter := ratelimit.NewConcurrencyLimiter(limits.MaxInFlight, limits.MaxQueue, limits.QueueTimeout)
+			translate = translate.With(ConcurrencyLimitMiddleware(concurrencyLimiter, metrics))
+		}
+		translate.Post("/translate", translateHandler.ServeHTTP)
+		if cfg.API.GetTranslate.Enabled {
+			translate.Get("/translate", translateHandler.ServeHTTP)
+		}
+
+		// Autocompletion of field names and values
+		suggestHandler := NewSuggestHandler(schemaRegistry, translatorRegistry)
+		r.Get("/suggest/fields", suggestHandler.SuggestFields)
+		r.Post("/suggest/values", suggestHandler.SuggestValues)
+
+		// Library of query patterns, with their translations
+		patternsHandler := NewPatternsHandler(schemaRegistry, translatorRegistry)
+		r.Get("/patterns", patternsHandler.List)
+		r.Get("/patterns/{name}", patternsHandler.Get)
+
+		// Query usage statistics
+		if tracker != nil {
+			r.Get("/stats", NewStatsHandler(tracker).ServeHTTP)
+		}
 
-		// Translation endpoint
-		r.Post("/translate", translateHandler.ServeHTTP)
+		// Recent translate requests, for listing and replay by operators
+		if historyStore != nil {
+			historyHandler := NewHistoryHandler(historyStore, translateHandler, cfg.History.APIKeys)
+			r.Group(func(r chi.Router) {
+				r.Use(historyHandler.Authorize)
+				r.Get("/admin/history", historyHandler.List)
+				r.Get("/admin/history/{id}", historyHandler.Get)
+				r.Post("/admin/history/{id}/replay", historyHandler.Replay)
+			})
+		}
 	})
 
 	return r
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/infiniv/rsearch/internal/analytics"
+)
+
+// StatsHandler serves query usage statistics.
+type StatsHandler struct {
+	tracker *analytics.Tracker
+}
+
+// NewStatsHandler creates a new stats handler.
+func NewStatsHandler(tracker *analytics.Tracker) *StatsHandler {
+	return &StatsHandler{tracker: tracker}
+}
+
+// ServeHTTP returns the latest usage snapshot, optionally filtered by the schema query parameter.
+func (h *StatsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	snapshot := h.tracker.Latest()
+
+	if name := r.URL.Query().Get("schema"); name != "" {
+		summary, exists := snapshot.Schemas[name]
+		if !exists {
+			RespondError(w, http.StatusNotFound, "STATS_NOT_FOUND", "No statistics recorded for schema "+name)
+			return
+		}
+		snapshot.Schemas = map[string]analytics.SchemaSummary{name: summary}
+	}
+
+	RespondJSON(w, http.StatusOK, snapshot)
+}
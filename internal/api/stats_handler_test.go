@@ -0,0 +1,75 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/analytics"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsHandler_RecordsTranslations(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":  {Type: schema.TypeText},
+		"price": {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	tracker := analytics.NewTracker(nil)
+	translateHandler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithTracker(tracker)
+	statsHandler := NewStatsHandler(tracker)
+
+	for _, query := range []string{"name:foo AND price:>10", "name:bar", "color:red", "name:(", "name:x"} {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: query})
+		req := httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body))
+		translateHandler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Unknown schemas are not tracked
+	body, _ := json.Marshal(TranslateRequest{Schema: "missing", Database: "postgres", Query: "a:b"})
+	translateHandler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+
+	w := httptest.NewRecorder()
+	statsHandler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/stats", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var snapshot analytics.Snapshot
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &snapshot))
+	require.Len(t, snapshot.Schemas, 1)
+
+	summary := snapshot.Schemas["products"]
+	assert.Equal(t, int64(5), summary.Queries)
+	assert.Equal(t, int64(2), summary.Errors)
+	assert.Equal(t, "name", summary.TopFields[0].Name)
+	assert.Equal(t, int64(3), summary.TopFields[0].Count)
+}
+
+func TestStatsHandler_FilterBySchema(t *testing.T) {
+	tracker := analytics.NewTracker(nil)
+	tracker.Record(schema.NewSchema("products", map[string]schema.Field{}, schema.SchemaOptions{}), nil, nil)
+	tracker.Record(schema.NewSchema("orders", map[string]schema.Field{}, schema.SchemaOptions{}), nil, nil)
+	handler := NewStatsHandler(tracker)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/stats?schema=orders", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var snapshot analytics.Snapshot
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &snapshot))
+	assert.Len(t, snapshot.Schemas, 1)
+	assert.Contains(t, snapshot.Schemas, "orders")
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/stats?schema=unknown", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
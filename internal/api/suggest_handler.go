@@ -0,0 +1,107 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// SuggestValuesRequest represents the request body for the suggest values endpoint.
+type SuggestValuesRequest = rsearch.SuggestValuesRequest
+
+// SuggestValuesResponse represents the response body for the suggest values endpoint.
+type SuggestValuesResponse = rsearch.SuggestValuesResponse
+
+// FieldSuggestionsResponse represents the response body for the suggest fields endpoint.
+type FieldSuggestionsResponse = rsearch.FieldSuggestionsResponse
+
+// SuggestHandler generates the queries behind autocompletion in query builders.
+type SuggestHandler struct {
+	schemaRegistry     *schema.Registry
+	translatorRegistry *translator.Registry
+}
+
+// NewSuggestHandler creates a new suggest handler.
+func NewSuggestHandler(schemaRegistry *schema.Registry, translatorRegistry *translator.Registry) *SuggestHandler {
+	return &SuggestHandler{
+		schemaRegistry:     schemaRegistry,
+		translatorRegistry: translatorRegistry,
+	}
+}
+
+// SuggestValues handles POST /api/v1/suggest/values.
+// The response is a query listing distinct values of the field for the caller to run;
+// the schema's default filters always apply and cannot be skipped.
+func (h *SuggestHandler) SuggestValues(w http.ResponseWriter, r *http.Request) {
+	var req SuggestValuesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, http.StatusBadRequest, rsearch.ErrorCodeInvalidRequest, "Invalid request body")
+		return
+	}
+	noteAccess(r.Context(), req.Schema, req.Database)
+	if req.Schema == "" || req.Database == "" || req.Field == "" {
+		RespondError(w, http.StatusBadRequest, rsearch.ErrorCodeInvalidRequest, "Schema, database and field are required")
+		return
+	}
+
+	sch, err := h.schemaRegistry.Get(req.Schema)
+	if err != nil {
+		RespondError(w, http.StatusNotFound, rsearch.ErrorCodeSchemaNotFound, err.Error())
+		return
+	}
+	trans, err := h.translatorRegistry.Get(req.Database)
+	if err != nil {
+		RespondError(w, http.StatusBadRequest, rsearch.ErrorCodeInvalidRequest, fmt.Sprintf("Database type not supported: %s", req.Database))
+		return
+	}
+	if _, _, err := sch.ResolveField(req.Field); err != nil {
+		RespondError(w, http.StatusBadRequest, rsearch.ErrorCodeFieldNotFound, err.Error())
+		return
+	}
+
+	suggestion, err := translator.SuggestValues(trans, sch, req.Field, req.Prefix, req.Limit)
+	if err != nil {
+		RespondError(w, http.StatusBadRequest, rsearch.ErrorCodeInvalidRequest, fmt.Sprintf("Invalid suggestion: %s", err.Error()))
+		return
+	}
+	RespondJSON(w, http.StatusOK, suggestion)
+}
+
+// SuggestFields handles GET /api/v1/suggest/fields?schema=...&q=...&limit=....
+// It ranks the fields a partial or misspelled name may refer to, so query builders can
+// complete field names without downloading the schema.
+func (h *SuggestHandler) SuggestFields(w http.ResponseWriter, r *http.Request) {
+	name, query := r.URL.Query().Get("schema"), r.URL.Query().Get("q")
+	noteAccess(r.Context(), name, "")
+	if name == "" || query == "" {
+		RespondError(w, http.StatusBadRequest, rsearch.ErrorCodeInvalidRequest, "Schema and q are required")
+		return
+	}
+
+	limit := rsearch.DefaultSuggestLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > rsearch.MaxSuggestLimit {
+			RespondError(w, http.StatusBadRequest, rsearch.ErrorCodeInvalidRequest, fmt.Sprintf("limit must be between 1 and %d", rsearch.MaxSuggestLimit))
+			return
+		}
+		limit = n
+	}
+
+	sch, err := h.schemaRegistry.Get(name)
+	if err != nil {
+		RespondError(w, http.StatusNotFound, rsearch.ErrorCodeSchemaNotFound, err.Error())
+		return
+	}
+
+	fields := sch.SuggestFields(query, limit)
+	if fields == nil {
+		fields = []rsearch.FieldSuggestion{}
+	}
+	RespondJSON(w, http.StatusOK, FieldSuggestionsResponse{Schema: sch.Name, Query: query, Fields: fields})
+}
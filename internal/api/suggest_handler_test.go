@@ -0,0 +1,117 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggestHandler_SuggestValues(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":    {Type: schema.TypeText, Column: "product_name"},
+		"deleted": {Type: schema.TypeBoolean},
+		"price":   {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{
+		DefaultFilters: []schema.DefaultFilter{{Name: "not_deleted", Query: "deleted:false"}},
+	})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewSuggestHandler(schemaRegistry, translatorRegistry)
+	send := func(req SuggestValuesRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		handler.SuggestValues(w, httptest.NewRequest("POST", "/api/v1/suggest/values", bytes.NewReader(body)))
+		return w
+	}
+
+	w := send(SuggestValuesRequest{Schema: "products", Database: "postgres", Field: "name", Prefix: "lap", Limit: 5})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response SuggestValuesResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "DISTINCT product_name AS value", response.SelectClause)
+	assert.Equal(t, "(product_name LIKE $1) AND (deleted = $2)", response.WhereClause)
+	assert.Equal(t, []interface{}{"lap%", "false"}, response.Parameters)
+	assert.Equal(t, "value", response.OrderByClause)
+	assert.Equal(t, 5, response.Limit)
+	assert.Equal(t, []string{"not_deleted"}, response.DefaultFilters)
+
+	tests := []struct {
+		name   string
+		req    SuggestValuesRequest
+		status int
+		code   string
+	}{
+		{"missing field", SuggestValuesRequest{Schema: "products", Database: "postgres"}, http.StatusBadRequest, rsearch.ErrorCodeInvalidRequest},
+		{"unknown schema", SuggestValuesRequest{Schema: "orders", Database: "postgres", Field: "name"}, http.StatusNotFound, rsearch.ErrorCodeSchemaNotFound},
+		{"unknown database", SuggestValuesRequest{Schema: "products", Database: "oracle", Field: "name"}, http.StatusBadRequest, rsearch.ErrorCodeInvalidRequest},
+		{"unknown field", SuggestValuesRequest{Schema: "products", Database: "postgres", Field: "color"}, http.StatusBadRequest, rsearch.ErrorCodeFieldNotFound},
+		{"prefix on number", SuggestValuesRequest{Schema: "products", Database: "postgres", Field: "price", Prefix: "1"}, http.StatusBadRequest, rsearch.ErrorCodeInvalidRequest},
+		{"limit too large", SuggestValuesRequest{Schema: "products", Database: "postgres", Field: "name", Limit: 1000}, http.StatusBadRequest, rsearch.ErrorCodeInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := send(tt.req)
+			assert.Equal(t, tt.status, w.Code)
+			var errResp rsearch.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+			assert.Equal(t, tt.code, errResp.Error.Code)
+		})
+	}
+}
+
+func TestSuggestHandler_SuggestFields(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	require.NoError(t, schemaRegistry.Register(schema.NewSchema("products", map[string]schema.Field{
+		"productCode": {Type: schema.TypeText, Description: "Catalogue code"},
+		"productName": {Type: schema.TypeText},
+		"price":       {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{})))
+
+	handler := NewSuggestHandler(schemaRegistry, translator.NewRegistry())
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.SuggestFields(w, httptest.NewRequest("GET", target, nil))
+		return w
+	}
+
+	w := get("/api/v1/suggest/fields?schema=products&q=prodcod")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response FieldSuggestionsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, FieldSuggestionsResponse{
+		Schema: "products",
+		Query:  "prodcod",
+		Fields: []rsearch.FieldSuggestion{{Field: "productCode", Type: "text", Description: "Catalogue code", Match: rsearch.MatchAbbreviation}},
+	}, response)
+
+	w = get("/api/v1/suggest/fields?schema=products&q=product&limit=1")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Fields, 1)
+
+	// No match is an empty list, not an error
+	w = get("/api/v1/suggest/fields?schema=products&q=zzz")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"schema":"products","q":"zzz","fields":[]}`, w.Body.String())
+
+	for target, status := range map[string]int{
+		"/api/v1/suggest/fields?schema=products":                http.StatusBadRequest,
+		"/api/v1/suggest/fields?schema=products&q=p&limit=0":    http.StatusBadRequest,
+		"/api/v1/suggest/fields?schema=products&q=p&limit=lots": http.StatusBadRequest,
+		"/api/v1/suggest/fields?schema=orders&q=p":              http.StatusNotFound,
+	} {
+		assert.Equal(t, status, get(target).Code, target)
+	}
+}
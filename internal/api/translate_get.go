@@ -0,0 +1,107 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// Parameters of GET translate requests; other query parameters, such as cache busters,
+// are ignored
+const (
+	queryParam   = "q"
+	schemaParam  = "schema"
+	dialectParam = "dialect"
+)
+
+// WithGetTranslate sets the limit on the query strings of GET requests, in encoded bytes,
+// and how long caches may keep their translations. A zero limit is unlimited; a zero
+// maxAge makes caches revalidate every use.
+func (h *TranslateHandler) WithGetTranslate(maxQueryStringLength int, maxAge time.Duration) *TranslateHandler {
+	h.maxQueryStringLength = maxQueryStringLength
+	h.cacheMaxAge = maxAge
+	return h
+}
+
+// requestFromURL reads a GET translate request from the q, schema and dialect parameters
+// of its URL, answering the request itself when they are invalid
+func (h *TranslateHandler) requestFromURL(w http.ResponseWriter, r *http.Request) (TranslateRequest, bool) {
+	if h.maxQueryStringLength > 0 && len(r.URL.RawQuery) > h.maxQueryStringLength {
+		RespondError(w, http.StatusRequestURITooLong, rsearch.ErrorCodeQueryTooLong,
+			fmt.Sprintf("Query string exceeds the maximum length of %d bytes; send long queries with POST", h.maxQueryStringLength))
+		return TranslateRequest{}, false
+	}
+
+	// url.ParseQuery reports malformed escapes, such as a lone %, that r.URL.Query drops
+	values, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid query string: %s", err.Error()))
+		return TranslateRequest{}, false
+	}
+	for _, name := range []string{queryParam, schemaParam, dialectParam} {
+		if len(values[name]) > 1 {
+			h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Parameter %s is repeated", name))
+			return TranslateRequest{}, false
+		}
+	}
+
+	return TranslateRequest{
+		Schema:   values.Get(schemaParam),
+		Database: values.Get(dialectParam),
+		Query:    values.Get(queryParam),
+	}, true
+}
+
+// writeCacheable writes the response to a GET translate request with an ETag of its
+// body and the handler's cache lifetime, answering 304 Not Modified to requests that
+// already hold it. Responses to requests with an API key, which quotas and policies may
+// tell apart, are only cached privately.
+func (h *TranslateHandler) writeCacheable(w http.ResponseWriter, r *http.Request, contentType string, response interface{}) {
+	body, err := json.Marshal(response)
+	if err != nil {
+		RespondInternalError(w, fmt.Sprintf("Encoding response failed: %s", err.Error()))
+		return
+	}
+	body = append(body, '\n')
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	visibility := "public"
+	if r.Header.Get("X-API-Key") != "" {
+		visibility = "private"
+	}
+	cacheControl := visibility + ", no-cache"
+	if h.cacheMaxAge > 0 {
+		cacheControl = visibility + ", max-age=" + strconv.Itoa(int(h.cacheMaxAge/time.Second))
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl)
+	if matchesETag(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// matchesETag reports whether an If-None-Match header lists an entity tag, comparing
+// weakly as RFC 9110 requires
+func matchesETag(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
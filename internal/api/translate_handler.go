@@ -1,64 +1,248 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/infiniv/rsearch/internal/analytics"
+	"github.com/infiniv/rsearch/internal/cache"
+	"github.com/infiniv/rsearch/internal/chaos"
+	"github.com/infiniv/rsearch/internal/executor"
+	"github.com/infiniv/rsearch/internal/history"
+	"github.com/infiniv/rsearch/internal/observability"
 	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/policy"
+	"github.com/infiniv/rsearch/internal/ratelimit"
 	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/shadow"
 	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/infiniv/rsearch/pkg/rsearch"
 )
 
 // TranslateRequest represents the request body for the translate endpoint.
-type TranslateRequest struct {
-	Schema   string `json:"schema"`
-	Database string `json:"database"`
-	Query    string `json:"query"`
-}
+type TranslateRequest = rsearch.TranslateRequest
 
 // TranslateResponse represents the response body for the translate endpoint.
-type TranslateResponse struct {
-	Type           string        `json:"type"`
-	WhereClause    string        `json:"whereClause,omitempty"`
-	Parameters     []interface{} `json:"parameters,omitempty"`
-	ParameterTypes []string      `json:"parameterTypes,omitempty"`
-	Filter         interface{}   `json:"filter,omitempty"`
-}
+type TranslateResponse = rsearch.TranslateResponse
 
 // TranslateHandler handles translation requests.
 type TranslateHandler struct {
 	schemaRegistry     *schema.Registry
 	translatorRegistry *translator.Registry
+	policy             *policy.Policy
+	tracker            *analytics.Tracker
+	bypassKeys         []string
+	parseLimits        parser.Limits
 	parseQuery         func(string) (parser.Node, error)
+
+	// Plan cache of translations keyed by query structure; disabled when nil
+	planCache    *cache.Cache
+	cacheMetrics *observability.Metrics
+
+	// Slow translation logging; disabled when slowThreshold is zero
+	logger        *observability.Logger
+	metrics       *observability.Metrics
+	slowThreshold time.Duration
+	queryLogMode  observability.QueryLogMode
+
+	// Audit log of the schema fragments translations apply; disabled when nil
+	auditLogger *observability.Logger
+
+	// Database query plans are read from on request; disabled when nil
+	executor    *executor.Executor
+	planTimeout time.Duration
+	planLogger  *observability.Logger
+
+	// Daily quotas per API key; disabled when nil
+	quotas       *ratelimit.QuotaTracker
+	quotaMetrics *observability.Metrics
+
+	// Recent requests kept for replay; disabled when nil
+	history *history.Store
+
+	// Faults injected at the parse and translate stages; disabled when nil
+	chaos *chaos.Injector
+
+	// Comparison of translations with OpenSearch counts; disabled when nil
+	shadow *shadow.Comparer
+
+	// Limit on the query strings of GET requests, and how long caches keep their responses
+	maxQueryStringLength int
+	cacheMaxAge          time.Duration
+
+	// Limit on the bytes of a translation's WHERE clause or filter; zero is unlimited
+	maxOutputBytes int
 }
 
 // NewTranslateHandler creates a new translate handler.
 func NewTranslateHandler(schemaRegistry *schema.Registry, translatorRegistry *translator.Registry) *TranslateHandler {
-	return &TranslateHandler{
+	h := &TranslateHandler{
 		schemaRegistry:     schemaRegistry,
 		translatorRegistry: translatorRegistry,
-		parseQuery: func(query string) (parser.Node, error) {
-			p := parser.NewParser(query)
-			return p.Parse()
-		},
 	}
+	h.parseQuery = func(query string) (parser.Node, error) {
+		p := parser.NewParserWithLimits(query, h.parseLimits)
+		return p.Parse()
+	}
+	return h
+}
+
+// WithParseLimits bounds the tokens and literal bytes parsed per query; queries over
+// either limit are rejected with QUERY_TOO_COMPLEX. Zero limits are unlimited.
+func (h *TranslateHandler) WithParseLimits(limits parser.Limits) *TranslateHandler {
+	h.parseLimits = limits
+	return h
+}
+
+// WithOutputLimit bounds the bytes of the WHERE clause or filter of a translation;
+// larger translations are rejected with QUERY_TOO_COMPLEX, naming the clause they mostly
+// come from. Zero is unlimited.
+func (h *TranslateHandler) WithOutputLimit(maxBytes int) *TranslateHandler {
+	h.maxOutputBytes = maxBytes
+	return h
+}
+
+// WithPolicy sets the deployment feature policy checked before translation.
+func (h *TranslateHandler) WithPolicy(p *policy.Policy) *TranslateHandler {
+	h.policy = p
+	return h
+}
+
+// WithTracker sets the usage tracker that records every query against a known schema.
+func (h *TranslateHandler) WithTracker(t *analytics.Tracker) *TranslateHandler {
+	h.tracker = t
+	return h
+}
+
+// WithSlowLog logs a warning and increments a counter whenever parsing plus translation
+// takes at least threshold. A zero threshold disables slow logging; metrics may be nil.
+func (h *TranslateHandler) WithSlowLog(logger *observability.Logger, metrics *observability.Metrics, threshold time.Duration) *TranslateHandler {
+	h.logger = logger
+	h.metrics = metrics
+	h.slowThreshold = threshold
+	return h
+}
+
+// WithFragmentAudit logs the schema fragments each successful translation applies,
+// including those of default filters, so that uses of server-side conditions can be
+// traced to requests.
+func (h *TranslateHandler) WithFragmentAudit(logger *observability.Logger) *TranslateHandler {
+	h.auditLogger = logger
+	return h
+}
+
+// WithExecutor lets requests ask for the plan the executor's database would run their
+// translation with, bounded by timeout. Requests for plans are rejected without one.
+// Failed plans are logged to logger, which may be nil, as database errors are not shown
+// to clients.
+func (h *TranslateHandler) WithExecutor(e *executor.Executor, timeout time.Duration, logger *observability.Logger) *TranslateHandler {
+	h.executor = e
+	h.planTimeout = timeout
+	h.planLogger = logger
+	return h
+}
+
+// WithQueryLogging selects how queries appear in the handler's logs: hashed (the
+// default), redacted, in full or not at all.
+func (h *TranslateHandler) WithQueryLogging(mode observability.QueryLogMode) *TranslateHandler {
+	h.queryLogMode = mode
+	return h
+}
+
+// WithQuotas holds requests to the daily quotas of their X-API-Key: keys over quota are
+// rejected with QUOTA_EXCEEDED, and every translation is charged to its key. Requests
+// with an unconfigured key or none share the tracker's anonymous quota. A nil tracker
+// disables quotas; metrics may be nil.
+func (h *TranslateHandler) WithQuotas(tracker *ratelimit.QuotaTracker, metrics *observability.Metrics) *TranslateHandler {
+	h.quotas = tracker
+	h.quotaMetrics = metrics
+	return h
+}
+
+// WithHistory keeps every request for a registered schema in the store, with the status
+// it was answered with. Replayed requests are not kept again. A nil store disables history.
+func (h *TranslateHandler) WithHistory(store *history.Store) *TranslateHandler {
+	h.history = store
+	return h
+}
+
+// WithDefaultFilterBypass sets the API keys allowed to skip schema default filters.
+// Without keys, requests asking to skip default filters are always rejected.
+func (h *TranslateHandler) WithDefaultFilterBypass(keys []string) *TranslateHandler {
+	h.bypassKeys = keys
+	return h
+}
+
+// WithPlanCache caches translations by schema version, dialect and query structure, so
+// queries written alike, such as "a AND b" and "a && b", share one entry. Queries are
+// translated as written whether or not the cache is enabled; operand order and implicit
+// operators are part of the key, as they change the translation. A nil cache disables
+// caching; metrics may be nil.
+func (h *TranslateHandler) WithPlanCache(c *cache.Cache, metrics *observability.Metrics) *TranslateHandler {
+	h.planCache = c
+	h.cacheMetrics = metrics
+	return h
+}
+
+// WithChaos injects the parse and translate stage faults of injector, for resilience
+// testing; see ChaosMiddleware for the other stages. A nil injector injects nothing.
+func (h *TranslateHandler) WithChaos(injector *chaos.Injector) *TranslateHandler {
+	h.chaos = injector
+	return h
+}
+
+// WithShadow compares successful translations with the counts of the OpenSearch cluster
+// they replace, in the background. Requests with asOf are not compared, as OpenSearch
+// has no temporal predicate to match.
+func (h *TranslateHandler) WithShadow(comparer *shadow.Comparer) *TranslateHandler {
+	h.shadow = comparer
+	return h
 }
 
 // ServeHTTP handles HTTP requests.
 func (h *TranslateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Only accept POST requests
-	if r.Method != http.MethodPost {
+	// Accept requests in a POST body, or in the URL of a GET for caches
+	if r.Method != http.MethodPost && r.Method != http.MethodGet {
 		h.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	// Parse request body
+	// Settle the response format before doing any work
+	formatVersion, contentType, err := negotiateFormat(r)
+	if err != nil {
+		h.sendError(w, http.StatusNotAcceptable, err.Error())
+		return
+	}
+
+	// Parse request body, or the URL of a GET
 	var req TranslateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if r.Method == http.MethodGet {
+		var ok bool
+		if req, ok = h.requestFromURL(w, r); !ok {
+			return
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.sendError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	if err := applySelection(r.Context(), &req); err != nil {
+		h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Conflicting selection: %s", err.Error()))
+		return
+	}
+	noteAccess(r.Context(), req.Schema, req.Database)
+
+	// Keep the request for replay, once it is answered
+	if h.history != nil && !isReplay(r.Context()) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		w = ww
+		defer h.remember(r, req, ww)
+	}
 
 	// Validate required fields
 	if req.Schema == "" {
@@ -74,8 +258,34 @@ func (h *TranslateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.TimeoutMs < 0 || req.MaxRows < 0 {
+		h.sendError(w, http.StatusBadRequest, "timeoutMs and maxRows must not be negative")
+		return
+	}
+
+	// Opting out of default filters is a privileged capability
+	apiKey := r.Header.Get("X-API-Key")
+	if len(req.SkipDefaultFilters) > 0 && !h.canBypass(apiKey) {
+		h.sendError(w, http.StatusForbidden, "Skipping default filters requires an authorized API key")
+		return
+	}
+
+	// Keys over their daily quota are turned away before any work is done
+	chargeQuota := h.quotas != nil
+	if chargeQuota {
+		status := h.quotas.Check(apiKey)
+		setQuotaHeaders(w, status)
+		if status.Exceeded() {
+			if h.quotaMetrics != nil {
+				h.quotaMetrics.RecordQuotaRejection()
+			}
+			respondQuotaExceeded(w, status)
+			return
+		}
+	}
+
 	// Lookup schema
-	sch, err := h.schemaRegistry.Get(req.Schema)
+	sch, version, err := h.schemaRegistry.GetVersioned(req.Schema)
 	if err != nil {
 		h.sendError(w, http.StatusNotFound, fmt.Sprintf("Schema not found: %s", req.Schema))
 		return
@@ -88,19 +298,295 @@ func (h *TranslateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Query plans come from the executor's database
+	if req.Plan {
+		if h.executor == nil {
+			RespondError(w, http.StatusBadRequest, rsearch.ErrorCodeFeatureDisabled, "Query plans require the executor, which is not enabled")
+			return
+		}
+		if trans.DatabaseType() != h.executor.Database() {
+			RespondError(w, http.StatusBadRequest, rsearch.ErrorCodeFeatureDisabled, fmt.Sprintf("Query plans are only available for %s translations", h.executor.Database()))
+			return
+		}
+	}
+
+	// Qualify columns for clauses embedded in queries that join other tables
+	if req.TableAlias != "" {
+		if trans.DatabaseType() == "mongodb" {
+			h.sendError(w, http.StatusBadRequest, "Table aliases are only supported for SQL databases")
+			return
+		}
+		sch, err = sch.WithTableAlias(req.TableAlias)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid table alias: %s", err.Error()))
+			return
+		}
+	}
+
+	// Trade fuzzy recall against precision for this request
+	if req.MinSimilarity != 0 {
+		sch, err = sch.WithMinSimilarity(req.MinSimilarity)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid minSimilarity: %s", err.Error()))
+			return
+		}
+	}
+
+	// Match regexes like the caller's source system
+	if req.AnchorRegex != nil {
+		sch = sch.WithAnchorRegex(*req.AnchorRegex)
+	}
+
+	// Join field group values like the caller's source system
+	if req.FieldGroupOperator != "" {
+		sch, err = sch.WithFieldGroupOperator(req.FieldGroupOperator)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid fieldGroupOperator: %s", err.Error()))
+			return
+		}
+	}
+
+	// Tighten the deployment's feature policy for this request
+	featurePolicy := h.policy
+	if len(req.DisableFeatures) > 0 {
+		if featurePolicy == nil {
+			featurePolicy = policy.New(policy.AllFeatures())
+		}
+		featurePolicy, err = featurePolicy.Restrict(req.DisableFeatures)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid disableFeatures: %s", err.Error()))
+			return
+		}
+	}
+
+	switch req.SQLFormat {
+	case "", rsearch.SQLFormatCompact, rsearch.SQLFormatPretty:
+	default:
+		h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid sqlFormat: %q, expected %q or %q", req.SQLFormat, rsearch.SQLFormatCompact, rsearch.SQLFormatPretty))
+		return
+	}
+	switch req.FilterFormat {
+	case "", rsearch.FilterFormatPlain, rsearch.FilterFormatExtendedJSON:
+	default:
+		h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid filterFormat: %q, expected %q or %q", req.FilterFormat, rsearch.FilterFormatPlain, rsearch.FilterFormatExtendedJSON))
+		return
+	}
+
+	if err := h.chaos.Inject(r.Context(), w, chaos.StageParse); err != nil {
+		respondChaos(w, err)
+		return
+	}
+
 	// Parse query
+	start := time.Now()
 	ast, err := h.parseQuery(req.Query)
+	if errors.Is(err, parser.ErrQueryTooComplex) {
+		h.record(sch, nil, err)
+		RespondError(w, http.StatusBadRequest, rsearch.ErrorCodeQueryTooComplex, fmt.Sprintf("Failed to parse query: %s", err.Error()))
+		return
+	}
 	if err != nil {
+		h.record(sch, nil, err)
 		h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse query: %s", err.Error()))
 		return
 	}
 
+	// Enforce deployment-wide and per-request feature restrictions
+	if featurePolicy != nil {
+		if err := featurePolicy.Check(ast); err != nil {
+			h.record(sch, ast, err)
+			var featureErr *policy.FeatureDisabledError
+			if errors.As(err, &featureErr) {
+				RespondJSON(w, http.StatusBadRequest, rsearch.ErrorResponse{
+					Error: rsearch.ErrorDetail{
+						Code:    rsearch.ErrorCodeFeatureDisabled,
+						Message: fmt.Sprintf("Query rejected: %s", err.Error()),
+						Query:   req.Query,
+						Details: []rsearch.ErrorInfo{{
+							Position: featureErr.Pos.Offset,
+							Line:     featureErr.Pos.Line,
+							Column:   featureErr.Pos.Column,
+							Message:  fmt.Sprintf("%s queries are %s", featureErr.Feature, featureErr.Reason()),
+						}},
+						AppliedPolicies: []rsearch.AppliedPolicy{{
+							Policy: rsearch.PolicyFeature,
+							Name:   featureErr.Feature,
+							Action: rsearch.PolicyActionBlocked,
+							Reason: featureErr.Reason(),
+						}},
+						RequestID: requestIDOf(w),
+					},
+				})
+				return
+			}
+			h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Query rejected: %s", err.Error()))
+			return
+		}
+	}
+
+	// AND in the schema's default filters
+	filtered, appliedFilters, err := translator.ApplyDefaultFilters(ast, sch, req.SkipDefaultFilters)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid default filters: %s", err.Error()))
+		return
+	}
+
+	// AND in the temporal predicate selecting the rows valid at asOf
+	filtered, err = translator.ApplyAsOf(filtered, sch, req.AsOf)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid asOf: %s", err.Error()))
+		return
+	}
+
+	if err := h.chaos.Inject(r.Context(), w, chaos.StageTranslate); err != nil {
+		respondChaos(w, err)
+		return
+	}
+
 	// Translate AST
-	output, err := trans.Translate(ast, sch)
+	output, err := h.translate(trans, filtered, sch, version)
+	h.checkSlow(r.Context(), req, ast, time.Since(start))
+	h.record(sch, ast, err)
+	if chargeQuota && err == nil {
+		setQuotaHeaders(w, h.quotas.Charge(apiKey, parser.Count(ast)))
+	}
 	if err != nil {
+		var opErr *translator.OperatorNotAllowedError
+		if errors.As(err, &opErr) {
+			RespondErrorWithDetails(w, http.StatusBadRequest, rsearch.ErrorCodeOperatorNotAllowed,
+				fmt.Sprintf("Translation failed: %s", err.Error()), req.Query, []rsearch.ErrorInfo{{
+					Position: opErr.Pos.Offset,
+					Line:     opErr.Pos.Line,
+					Column:   opErr.Pos.Column,
+					Message:  fmt.Sprintf("field %s allows: %s", opErr.Field, strings.Join(opErr.Allowed, ", ")),
+				}})
+			return
+		}
+		var hashedErr *translator.HashedFieldError
+		if errors.As(err, &hashedErr) {
+			RespondErrorWithDetails(w, http.StatusBadRequest, rsearch.ErrorCodeHashedField,
+				fmt.Sprintf("Translation failed: %s", err.Error()), req.Query, []rsearch.ErrorInfo{{
+					Position: hashedErr.Pos.Offset,
+					Line:     hashedErr.Pos.Line,
+					Column:   hashedErr.Pos.Column,
+					Message:  fmt.Sprintf("field %s is stored hashed; query whole values, e.g. %s:value", hashedErr.Field, hashedErr.Field),
+				}})
+			return
+		}
+		var unknownErr *translator.UnknownValueError
+		if errors.As(err, &unknownErr) {
+			hint := fmt.Sprintf("not in dictionary %s", unknownErr.Dictionary)
+			if len(unknownErr.Suggestions) > 0 {
+				hint = fmt.Sprintf("did you mean %s?", strings.Join(unknownErr.Suggestions, ", "))
+			}
+			RespondErrorWithDetails(w, http.StatusBadRequest, rsearch.ErrorCodeUnknownValue,
+				fmt.Sprintf("Translation failed: %s", err.Error()), req.Query, []rsearch.ErrorInfo{{
+					Position: unknownErr.Pos.Offset,
+					Line:     unknownErr.Pos.Line,
+					Column:   unknownErr.Pos.Column,
+					Message:  hint,
+				}})
+			return
+		}
+		var dictionaryErr *translator.DictionaryUnavailableError
+		if errors.As(err, &dictionaryErr) {
+			RespondError(w, http.StatusServiceUnavailable, rsearch.ErrorCodeServiceUnavailable,
+				fmt.Sprintf("Translation failed: %s", err.Error()))
+			return
+		}
+		var boundsErr *translator.RangeBoundsError
+		if errors.As(err, &boundsErr) {
+			RespondErrorWithDetails(w, http.StatusBadRequest, rsearch.ErrorCodeInvalidRange,
+				fmt.Sprintf("Translation failed: %s", err.Error()), req.Query, []rsearch.ErrorInfo{{
+					Position: boundsErr.Pos.Offset,
+					Line:     boundsErr.Pos.Line,
+					Column:   boundsErr.Pos.Column,
+					Message:  fmt.Sprintf("swap the bounds to [%s TO %s]", boundsErr.End, boundsErr.Start),
+				}})
+			return
+		}
+		var limitErr *translator.ValueLimitError
+		if errors.As(err, &limitErr) {
+			code := rsearch.ErrorCodeTooManyParameters
+			if limitErr.Unit() == "characters" {
+				code = rsearch.ErrorCodeQueryTooLong
+			}
+			RespondErrorWithDetails(w, http.StatusBadRequest, code,
+				fmt.Sprintf("Translation failed: %s", err.Error()), req.Query, []rsearch.ErrorInfo{{
+					Position: limitErr.Pos.Offset,
+					Line:     limitErr.Pos.Line,
+					Column:   limitErr.Pos.Column,
+					Message:  fmt.Sprintf("at most %d %s are allowed", limitErr.Limit, limitErr.Unit()),
+				}})
+			return
+		}
 		h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Translation failed: %s", err.Error()))
 		return
 	}
+	h.auditFragments(r.Context(), req, filtered, sch)
+
+	// Databases reject oversized statements with errors that do not point at the query
+	var sizeErr *translator.OutputSizeError
+	if errors.As(translator.CheckOutputSize(trans, ast, sch, output, h.maxOutputBytes), &sizeErr) {
+		RespondErrorWithDetails(w, http.StatusBadRequest, rsearch.ErrorCodeQueryTooComplex,
+			fmt.Sprintf("Translation failed: %s", sizeErr.Error()), req.Query, []rsearch.ErrorInfo{{
+				Position: sizeErr.Pos.Offset,
+				Line:     sizeErr.Pos.Line,
+				Column:   sizeErr.Pos.Column,
+				Message:  fmt.Sprintf("%s translates to %d bytes; at most %d are allowed", sizeErr.Construct, sizeErr.ConstructSize, sizeErr.Limit),
+			}})
+		return
+	}
+
+	// Strict callers need the query to match exactly as written
+	if req.Strict {
+		var downgradeErr *translator.DowngradeError
+		var coercionErr *translator.CoercionError
+		switch err := translator.CheckStrict(output); {
+		case errors.As(err, &downgradeErr):
+			details := make([]rsearch.ErrorInfo, len(downgradeErr.Downgrades))
+			for i, d := range downgradeErr.Downgrades {
+				details[i] = rsearch.ErrorInfo{Message: fmt.Sprintf("%s would be translated as %s: %s", d.Original, d.Substitute, d.Reason)}
+			}
+			RespondErrorWithDetails(w, http.StatusBadRequest, rsearch.ErrorCodeUnsupportedSyntax,
+				fmt.Sprintf("Strict translation failed: %s", err.Error()), req.Query, details)
+			return
+		case errors.As(err, &coercionErr):
+			details := make([]rsearch.ErrorInfo, len(coercionErr.Coercions))
+			for i, c := range coercionErr.Coercions {
+				details[i] = rsearch.ErrorInfo{Message: fmt.Sprintf("value %s of field %s would be bound as %v: it %s", c.Value, c.Field, c.Bound, c.Reason)}
+			}
+			RespondErrorWithDetails(w, http.StatusBadRequest, rsearch.ErrorCodeTypeMismatch,
+				fmt.Sprintf("Strict translation failed: %s", err.Error()), req.Query, details)
+			return
+		}
+	}
+
+	// Shape the result
+	if err := translator.ApplyProjection(output, trans.DatabaseType(), req.Fields, sch); err != nil {
+		h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid fields: %s", err.Error()))
+		return
+	}
+	if err := translator.ApplySort(output, req.Sort, sch); err != nil {
+		h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid sort: %s", err.Error()))
+		return
+	}
+
+	facets, err := translator.BuildFacets(req.Facets, sch, trans.DatabaseType(), output.Filter)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid facets: %s", err.Error()))
+		return
+	}
+
+	// Hint the schema's execution limits, tightened by the request
+	limits := translator.TightenLimits(sch.Options.Execution, schema.ExecutionLimits{TimeoutMs: req.TimeoutMs, MaxRows: req.MaxRows})
+	translator.ApplyExecutionHints(output, trans.DatabaseType(), limits)
+	hints, _ := output.Metadata["executionHints"].(*translator.ExecutionHints)
+	warnings, _ := output.Metadata["warnings"].([]translator.Warning)
+	minTextScore, _ := output.Metadata["minTextScore"].(float64)
+	downgrades, _ := output.Metadata["downgrades"].([]translator.Downgrade)
+	coercions, _ := output.Metadata["coercions"].([]translator.Coercion)
+	partialIndexes, _ := output.Metadata["partialIndexes"].([]translator.PartialIndexUsage)
 
 	// Build response
 	response := TranslateResponse{
@@ -109,17 +595,253 @@ func (h *TranslateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		Parameters:     output.Parameters,
 		ParameterTypes: output.ParameterTypes,
 		Filter:         output.Filter,
+		SelectClause:   output.SelectClause,
+		Projection:     output.Projection,
+		DefaultFilters: appliedFilters,
+		ExecutionHints: hints,
+		Warnings:       warningMessages(warnings),
+		WarningDetails: warnings,
+		MinTextScore:   minTextScore,
+
+		AppliedPolicies:  appliedPolicies(appliedFilters, req.SkipDefaultFilters),
+		ReferencedFields: referencedFields(output.ReferencedFields),
+		Facets:           facets,
+		Downgrades:       downgrades,
+		Coercions:        coercions,
+		PartialIndexes:   partialIndexes,
+
+		OrderByClause: output.OrderByClause,
+		Sort:          output.Sort,
+	}
+	if req.SQLFormat == rsearch.SQLFormatPretty && response.WhereClause != "" {
+		response.WhereClause = translator.FormatSQL(response.WhereClause)
+	}
+	if req.FilterFormat == rsearch.FilterFormatExtendedJSON && response.Filter != nil {
+		filter, err := translator.ExtendedJSON(response.Filter, sch)
+		if err != nil {
+			RespondInternalError(w, fmt.Sprintf("Encoding filter failed: %s", err.Error()))
+			return
+		}
+		response.Filter = filter
+	}
+	if req.Explain {
+		response.Explain = translator.Explain(ast, sch)
+	}
+	if req.Plan {
+		ctx, cancel := context.WithTimeout(r.Context(), h.planTimeout)
+		plan, err := h.executor.Explain(ctx, sch, output)
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) {
+			RespondTimeout(w, fmt.Sprintf("Query plan timed out after %s", h.planTimeout))
+			return
+		}
+		if err != nil {
+			if h.planLogger != nil {
+				h.planLogger.WithContext(r.Context()).ErrorWithErr(err, "Query plan failed")
+			}
+			RespondServiceUnavailable(w, "Query plan failed")
+			return
+		}
+		response.Plan = plan
+	}
+
+	// Echo the query as translated, without the default filters, which may be private
+	if normalized, err := translator.NormalizedQuery(ast, sch, time.Now()); err == nil {
+		response.NormalizedQuery = normalized
 	}
 
 	// Send response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	w.Header().Add("Vary", "Accept")
+	if r.Method == http.MethodGet {
+		h.writeCacheable(w, r, contentType, formatResponse(response, formatVersion))
+	} else {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(formatResponse(response, formatVersion))
+	}
+
+	h.compare(req, ast, sch, output, appliedFilters)
+}
+
+// warningMessages returns the messages of the warnings listed in the response's warnings
+// field. Downgrades and coercions are left out, as they have fields of their own.
+func warningMessages(warnings []translator.Warning) []string {
+	var messages []string
+	for _, warning := range warnings {
+		if warning.Code != rsearch.WarningDowngraded && warning.Code != rsearch.WarningCoerced {
+			messages = append(messages, warning.Message)
+		}
+	}
+	return messages
+}
+
+// referencedFields converts the fields a translation reads for the response
+func referencedFields(fields []translator.ReferencedField) []rsearch.ReferencedField {
+	if len(fields) == 0 {
+		return nil
+	}
+	referenced := make([]rsearch.ReferencedField, len(fields))
+	for i, field := range fields {
+		referenced[i] = rsearch.ReferencedField{Field: field.Field, Column: field.Column}
+	}
+	return referenced
+}
+
+// appliedPolicies reports the default filters injected into a query and those an
+// authorized caller skipped
+func appliedPolicies(applied, skipped []string) []rsearch.AppliedPolicy {
+	var policies []rsearch.AppliedPolicy
+	for _, name := range applied {
+		policies = append(policies, rsearch.AppliedPolicy{
+			Policy: rsearch.PolicyDefaultFilter,
+			Name:   name,
+			Action: rsearch.PolicyActionInjected,
+		})
+	}
+	for _, name := range skipped {
+		policies = append(policies, rsearch.AppliedPolicy{
+			Policy: rsearch.PolicyDefaultFilter,
+			Name:   name,
+			Action: rsearch.PolicyActionBypassed,
+			Reason: "skipped with an authorized API key",
+		})
+	}
+	return policies
+}
+
+// translate translates the AST, going through the plan cache when it is enabled.
+// Cached outputs are copied so that shaping the response never alters the cache entry.
+func (h *TranslateHandler) translate(trans translator.Translator, ast parser.Node, sch *schema.Schema, version uint64) (*translator.TranslatorOutput, error) {
+	// Date keywords such as today resolve against the current time, so they are not cached
+	if h.planCache == nil || translator.UsesDateKeywords(ast) {
+		return trans.Translate(ast, sch)
+	}
+
+	key := planKey(sch, version, trans.DatabaseType(), ast)
+
+	if cached, ok := h.planCache.Get(key); ok {
+		if h.cacheMetrics != nil {
+			h.cacheMetrics.RecordCacheHit()
+		}
+		// Dictionaries are reloaded without the schema changing, so the values of a
+		// cached plan are checked again
+		if err := translator.CheckDictionaries(ast, sch); err != nil {
+			return nil, err
+		}
+		output := cached.(*cachedPlan).output
+		return &output, nil
+	}
+	if h.cacheMetrics != nil {
+		h.cacheMetrics.RecordCacheMiss()
+	}
+
+	output, err := trans.Translate(ast, sch)
+	if err != nil {
+		return nil, err
+	}
+	h.storePlan(key, sch, version, trans.DatabaseType(), ast, output)
+	return output, nil
+}
+
+// canBypass reports whether the API key may skip default filters
+func (h *TranslateHandler) canBypass(key string) bool {
+	return observability.KeyAllowed(key, h.bypassKeys)
+}
+
+// remember adds an answered request to the history. Requests for schemas that are not
+// registered are left out, so arbitrary schema names do not grow the history.
+func (h *TranslateHandler) remember(r *http.Request, req TranslateRequest, w middleware.WrapResponseWriter) {
+	_, version, err := h.schemaRegistry.GetVersioned(req.Schema)
+	if err != nil {
+		return
+	}
+	status := w.Status()
+	if status == 0 {
+		status = http.StatusOK
+	}
+	h.history.Record(history.Entry{
+		RequestID:     observability.RequestIDFromContext(r.Context()),
+		Time:          time.Now().UTC(),
+		SchemaVersion: version,
+		Status:        status,
+		Request:       req,
+	})
+}
+
+// record adds the query to the usage statistics, if tracking is enabled.
+func (h *TranslateHandler) record(sch *schema.Schema, ast parser.Node, err error) {
+	if h.tracker != nil {
+		h.tracker.Record(sch, ast, err)
+	}
+}
+
+// compare submits a translation to the shadow comparer, with the queries of the default
+// filters ANDed into it
+func (h *TranslateHandler) compare(req TranslateRequest, ast parser.Node, sch *schema.Schema, output *translator.TranslatorOutput, appliedFilters []string) {
+	if h.shadow == nil || req.AsOf != "" {
+		return
+	}
+
+	var filters []string
+	for _, name := range appliedFilters {
+		for _, filter := range sch.Options.DefaultFilters {
+			if filter.Name == name {
+				filters = append(filters, filter.Query)
+			}
+		}
+	}
+	h.shadow.Submit(shadow.Comparison{
+		Schema:    sch,
+		Database:  req.Database,
+		Query:     req.Query,
+		Filters:   filters,
+		Output:    output,
+		LogFields: h.queryLogMode.QueryFields(req.Query, func() string { return parser.Redact(ast) }),
+	})
+}
+
+// auditFragments logs the schema fragments a translated query applies, if auditing is
+// enabled
+func (h *TranslateHandler) auditFragments(ctx context.Context, req TranslateRequest, ast parser.Node, sch *schema.Schema) {
+	if h.auditLogger == nil || len(sch.Fragments) == 0 {
+		return
+	}
+	fragments := translator.ReferencedFragments(ast, sch)
+	if len(fragments) == 0 {
+		return
+	}
+	h.auditLogger.WithContext(ctx).WithFields(map[string]interface{}{
+		"schema":    sch.Name,
+		"dialect":   req.Database,
+		"fragments": fragments,
+	}).Info("Schema fragments applied")
+}
+
+// checkSlow reports a translation that exceeded the slow threshold.
+// The query is logged as the query logging mode allows.
+func (h *TranslateHandler) checkSlow(ctx context.Context, req TranslateRequest, ast parser.Node, elapsed time.Duration) {
+	if h.slowThreshold <= 0 || elapsed < h.slowThreshold {
+		return
+	}
+
+	if h.metrics != nil {
+		h.metrics.RecordSlowTranslation(req.Database)
+	}
+	if h.logger != nil {
+		fields := h.queryLogMode.QueryFields(req.Query, func() string { return parser.Redact(ast) })
+		fields["schema"] = req.Schema
+		fields["dialect"] = req.Database
+		fields["query_length"] = len(req.Query)
+		fields["ast_depth"] = parser.Depth(ast)
+		fields["duration_ms"] = elapsed.Milliseconds()
+		fields["threshold_ms"] = h.slowThreshold.Milliseconds()
+		h.logger.WithContext(ctx).WithFields(fields).Warnf("Slow translation: %dms", elapsed.Milliseconds())
+	}
 }
 
 // sendError sends an error response.
 func (h *TranslateHandler) sendError(w http.ResponseWriter, statusCode int, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(ErrorResponse{Error: message})
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message, RequestID: requestIDOf(w)})
 }
@@ -2,14 +2,30 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/infiniv/rsearch/internal/cache"
+	"github.com/infiniv/rsearch/internal/dictionary"
+	"github.com/infiniv/rsearch/internal/executor"
+	"github.com/infiniv/rsearch/internal/observability"
 	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/policy"
+	"github.com/infiniv/rsearch/internal/ratelimit"
 	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/shadow"
 	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/infiniv/rsearch/pkg/rsearch"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -192,3 +208,1669 @@ func TestTranslateHandler_MissingFields(t *testing.T) {
 		})
 	}
 }
+
+func TestTranslateHandler_PolicyRejectsDisabledFeature(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{
+		EnabledFeatures: schema.EnabledFeatures{Regex: true},
+	})
+	schemaRegistry.Register(testSchema)
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	features := policy.AllFeatures()
+	features.Regex = false
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithPolicy(policy.New(features))
+
+	send := func(query string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: query})
+		req := httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// Regex is enabled on the schema but disabled for the deployment
+	w := send("name:/jo.*/")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response rsearch.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, rsearch.ErrorCodeFeatureDisabled, response.Error.Code)
+	assert.Contains(t, response.Error.Message, "regex queries are disabled")
+	assert.Equal(t, []rsearch.AppliedPolicy{{
+		Policy: rsearch.PolicyFeature,
+		Name:   "regex",
+		Action: rsearch.PolicyActionBlocked,
+		Reason: "disabled on this deployment",
+	}}, response.Error.AppliedPolicies)
+
+	// Other queries are unaffected
+	w = send("name:john")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestTranslateHandler_DisableFeatures(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{
+		EnabledFeatures: schema.EnabledFeatures{Fuzzy: true},
+	})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).
+		WithPolicy(policy.New(policy.AllFeatures())).
+		WithPlanCache(cache.NewCache(100, 0), nil)
+
+	send := func(query string, disable []string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: query, DisableFeatures: disable})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		return w
+	}
+
+	// Fuzzy is enabled on the schema and the deployment, and a cached plan exists
+	w := send("name:john~1", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Input from an untrusted widget turns it off
+	w = send("name:john~1", []string{"fuzzy"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response rsearch.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, rsearch.ErrorCodeFeatureDisabled, response.Error.Code)
+	assert.Contains(t, response.Error.Message, "fuzzy queries are disabled for this request")
+	assert.Equal(t, []rsearch.AppliedPolicy{{
+		Policy: rsearch.PolicyFeature,
+		Name:   "fuzzy",
+		Action: rsearch.PolicyActionBlocked,
+		Reason: "disabled for this request",
+	}}, response.Error.AppliedPolicies)
+
+	w = send("name:john", []string{"fuzzy"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = send("name:john", []string{"everything"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid disableFeatures")
+}
+
+func TestTranslateHandler_ParseLimits(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithParseLimits(parser.Limits{MaxTokens: 20, MaxLiteralBytes: 64})
+
+	send := func(query string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: query})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		return w
+	}
+
+	w := send("name:a OR name:b OR name:c")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	for _, query := range []string{
+		strings.TrimSuffix(strings.Repeat("name:a OR ", 10), " OR "),
+		"name:" + strings.Repeat("x", 100),
+	} {
+		w = send(query)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var response rsearch.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, rsearch.ErrorCodeQueryTooComplex, response.Error.Code)
+		assert.Contains(t, response.Error.Message, "query too complex")
+	}
+}
+
+func TestTranslateHandler_OutputLimit(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText},
+		"status": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithOutputLimit(200)
+
+	send := func(query string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: query})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		return w
+	}
+
+	w := send("status:active AND name:(a, b, c)")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	names := make([]string, 50)
+	for i := range names {
+		names[i] = fmt.Sprintf("n%d", i)
+	}
+	w = send("status:active AND name:(" + strings.Join(names, ", ") + ")")
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	var response rsearch.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, rsearch.ErrorCodeQueryTooComplex, response.Error.Code)
+	require.Len(t, response.Error.Details, 1)
+	assert.Equal(t, 18, response.Error.Details[0].Position)
+	assert.Contains(t, response.Error.Details[0].Message, "name:in(n0, n1")
+}
+
+func TestTranslateHandler_SQLFormat(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText},
+		"status": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+	translatorRegistry.Register("mongodb", translator.NewMongoDBTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithPlanCache(cache.NewCache(100, 0), nil)
+
+	send := func(database, format string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: database, Query: "(name:a OR name:b) AND status:active", SQLFormat: format})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	w, response := send("postgres", "pretty")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "(\n  name = $1\n  OR name = $2\n)\nAND status = $3", response["whereClause"])
+
+	// Compact is the default, and the cached plan is not affected by pretty printing
+	for _, format := range []string{"", "compact"} {
+		_, response = send("postgres", format)
+		assert.Equal(t, "(name = $1 OR name = $2) AND status = $3", response["whereClause"])
+	}
+
+	// MongoDB filters are unaffected
+	w, _ = send("mongodb", "pretty")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, _ = send("postgres", "indented")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestTranslateHandler_NormalizedQuery(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":    {Type: schema.TypeText},
+		"status":  {Type: schema.TypeText, Aliases: []string{"state"}},
+		"deleted": {Type: schema.TypeBoolean},
+	}, schema.SchemaOptions{
+		DefaultFilters: []schema.DefaultFilter{{Name: "not_deleted", Query: "deleted:false"}},
+	})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry)
+
+	body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: "state:active && (name:b || name:a)"})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var response TranslateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "(name:a OR name:b) AND status:active", response.NormalizedQuery, "default filters are not echoed")
+
+	// Version 1 responses do not have it
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate?format=v1", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NotContains(t, w.Body.String(), "normalizedQuery")
+}
+
+func TestTranslateHandler_FilterFormat(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("orders", map[string]schema.Field{
+		"orderId":   {Type: schema.TypeInteger},
+		"createdAt": {Type: schema.TypeDate},
+	}, schema.SchemaOptions{})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+	translatorRegistry.Register("mongodb", translator.NewMongoDBTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithPlanCache(cache.NewCache(100, 0), nil)
+
+	send := func(database, format string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		body, _ := json.Marshal(TranslateRequest{Schema: "orders", Database: database, Query: "orderId:42 AND createdAt:2024-03-01", FilterFormat: format})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	w, response := send("mongodb", "extjson")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	filter, _ := json.Marshal(response["filter"])
+	assert.JSONEq(t, `{"$and": [
+		{"orderId": {"$numberLong": "42"}},
+		{"createdAt": {"$date": {"$numberLong": "1709251200000"}}}
+	]}`, string(filter))
+
+	// Plain is the default, and the cached plan is not affected by the encoding
+	for _, format := range []string{"", "plain"} {
+		_, response = send("mongodb", format)
+		filter, _ = json.Marshal(response["filter"])
+		assert.JSONEq(t, `{"$and": [{"orderId": 42}, {"createdAt": "2024-03-01"}]}`, string(filter))
+	}
+
+	// SQL translations are unaffected
+	w, _ = send("postgres", "extjson")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, _ = send("mongodb", "bson")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestTranslateHandler_OperatorNotAllowed(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"status": {Type: schema.TypeText, Operators: []string{"term", "field_group"}},
+	}, schema.SchemaOptions{})
+	schemaRegistry.Register(testSchema)
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry)
+
+	send := func(query string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: query})
+		req := httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("status:(active OR pending) AND status:act*")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response rsearch.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, rsearch.ErrorCodeOperatorNotAllowed, response.Error.Code)
+	assert.Contains(t, response.Error.Message, "operator wildcard is not allowed on field status")
+	require.Len(t, response.Error.Details, 1)
+	assert.Equal(t, 32, response.Error.Details[0].Column)
+	assert.Equal(t, "field status allows: term, field_group", response.Error.Details[0].Message)
+
+	w = send("status:(active OR pending)")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestTranslateHandler_HashedField(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	hash := &schema.FieldHash{Key: "emails"}
+	testSchema := schema.NewSchema("users", map[string]schema.Field{
+		"email": {Type: schema.TypeText, Column: "email_hash", Hash: hash},
+	}, schema.SchemaOptions{})
+	require.NoError(t, schemaRegistry.RegisterHashKey("emails", []byte("pepper")))
+	require.NoError(t, schemaRegistry.Register(testSchema))
+
+	// Schemas name the key without holding it
+	encoded, err := json.Marshal(testSchema)
+	require.NoError(t, err)
+	assert.NotContains(t, string(encoded), "pepper")
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry)
+
+	send := func(query string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(TranslateRequest{Schema: "users", Database: "postgres", Query: query})
+		req := httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("email:alice*")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var errResponse rsearch.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResponse))
+	assert.Equal(t, rsearch.ErrorCodeHashedField, errResponse.Error.Code)
+	assert.Contains(t, errResponse.Error.Message, "operator wildcard cannot match hashed field email")
+	require.Len(t, errResponse.Error.Details, 1)
+	assert.Equal(t, 1, errResponse.Error.Details[0].Column)
+
+	w = send("email:alice")
+	require.Equal(t, http.StatusOK, w.Code)
+	var response TranslateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	digest, _ := hash.Codec([]byte("pepper")).Encode("alice")
+	assert.Equal(t, "email_hash = $1", response.WhereClause)
+	assert.Equal(t, []interface{}{digest}, response.Parameters)
+}
+
+func TestTranslateHandler_Dictionary(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	path := filepath.Join(t.TempDir(), "currencies.txt")
+	require.NoError(t, os.WriteFile(path, []byte("USD\nEUR\n"), 0o600))
+	require.NoError(t, schemaRegistry.RegisterDictionary("currencies", dictionary.NewFile("currencies", path, 0)))
+	require.NoError(t, schemaRegistry.RegisterDictionary("countries", dictionary.NewFile("countries", filepath.Join(t.TempDir(), "missing.txt"), 0)))
+	testSchema := schema.NewSchema("orders", map[string]schema.Field{
+		"currency": {Type: schema.TypeText, Dictionary: "currencies"},
+		"country":  {Type: schema.TypeText, Dictionary: "countries"},
+	}, schema.SchemaOptions{})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry)
+
+	send := func(query string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(TranslateRequest{Schema: "orders", Database: "postgres", Query: query})
+		req := httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("currency:EUR")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = send("currency:(USD, EUX)")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response rsearch.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, rsearch.ErrorCodeUnknownValue, response.Error.Code)
+	require.Len(t, response.Error.Details, 1)
+	assert.Equal(t, 16, response.Error.Details[0].Column)
+	assert.Equal(t, "did you mean EUR?", response.Error.Details[0].Message)
+
+	w = send("country:US")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, rsearch.ErrorCodeServiceUnavailable, response.Error.Code)
+}
+
+func TestTranslateHandler_DictionaryWithPlanCache(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	var values atomic.Value
+	values.Store([]string{"USD", "EUR"})
+	require.NoError(t, schemaRegistry.RegisterDictionary("currencies", dictionary.NewCached("currencies", func(context.Context) ([]string, error) {
+		return values.Load().([]string), nil
+	}, time.Millisecond, 0)))
+	require.NoError(t, schemaRegistry.Register(schema.NewSchema("orders", map[string]schema.Field{
+		"currency": {Type: schema.TypeText, Dictionary: "currencies"},
+	}, schema.SchemaOptions{})))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithPlanCache(cache.NewCache(100, 0), nil)
+	send := func(query string) int {
+		body, _ := json.Marshal(TranslateRequest{Schema: "orders", Database: "postgres", Query: query})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send("currency:EUR"))
+
+	// Values dropped from the dictionary are rejected although their plan is cached
+	values.Store([]string{"USD"})
+	assert.Eventually(t, func() bool { return send("currency:EUR") == http.StatusBadRequest }, time.Second, time.Millisecond)
+}
+
+func TestTranslateHandler_Plan(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+	for _, name := range []string{"products", "orders"} {
+		require.NoError(t, schemaRegistry.Register(schema.NewSchema(name, map[string]schema.Field{
+			"status": {Type: schema.TypeText},
+		}, schema.SchemaOptions{})))
+	}
+	translatorRegistry.Register("sqlite", translator.NewSQLiteTranslator())
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	path := filepath.Join(t.TempDir(), "shop.db")
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec("CREATE TABLE products (status TEXT)")
+	require.NoError(t, err)
+	queryExecutor, err := executor.Open("sqlite", path, nil)
+	require.NoError(t, err)
+	defer queryExecutor.Close()
+
+	send := func(handler *TranslateHandler, schemaName, database string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(TranslateRequest{Schema: schemaName, Database: database, Query: "status:active", Plan: true})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		return w
+	}
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithExecutor(queryExecutor, 5*time.Second, nil)
+	w := send(handler, "products", "sqlite")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response TranslateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "status = ?", response.WhereClause)
+	assert.JSONEq(t, `[{"id": 2, "parent": 0, "detail": "SCAN products"}]`, string(response.Plan))
+
+	// Plans come from the executor's database only
+	w = send(handler, "products", "postgres")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var errResponse rsearch.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResponse))
+	assert.Equal(t, rsearch.ErrorCodeFeatureDisabled, errResponse.Error.Code)
+	assert.Equal(t, "Query plans are only available for sqlite translations", errResponse.Error.Message)
+
+	w = send(NewTranslateHandler(schemaRegistry, translatorRegistry), "products", "sqlite")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResponse))
+	assert.Equal(t, rsearch.ErrorCodeFeatureDisabled, errResponse.Error.Code)
+
+	// Database errors, here of a missing table, are logged rather than shown to clients
+	w = send(handler, "orders", "sqlite")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResponse))
+	assert.Equal(t, rsearch.ErrorCodeServiceUnavailable, errResponse.Error.Code)
+	assert.Equal(t, "Query plan failed", errResponse.Error.Message)
+}
+
+func TestTranslateHandler_ValueLimits(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"sku": {Type: schema.TypeText, MaxLength: 8, MaxValues: 2},
+	}, schema.SchemaOptions{})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry)
+
+	send := func(query string) rsearch.ErrorResponse {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: query})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		var response rsearch.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	response := send("sku:" + strings.Repeat("A", 9))
+	assert.Equal(t, rsearch.ErrorCodeQueryTooLong, response.Error.Code)
+	assert.Contains(t, response.Error.Message, "value for field sku has 9 characters")
+	require.Len(t, response.Error.Details, 1)
+	assert.Equal(t, "at most 8 characters are allowed", response.Error.Details[0].Message)
+
+	response = send("sku:(a,b,c)")
+	assert.Equal(t, rsearch.ErrorCodeTooManyParameters, response.Error.Code)
+	require.Len(t, response.Error.Details, 1)
+	assert.Equal(t, "at most 2 values are allowed", response.Error.Details[0].Message)
+}
+
+func TestTranslateHandler_ReversedRange(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	rejecting := schema.NewSchema("products", map[string]schema.Field{
+		"price": {Type: schema.TypeInteger},
+	}, schema.SchemaOptions{})
+	swapping := schema.NewSchema("orders", map[string]schema.Field{
+		"price": {Type: schema.TypeInteger},
+	}, schema.SchemaOptions{ReversedRanges: schema.ReversedRangesSwap})
+	require.NoError(t, schemaRegistry.Register(rejecting))
+	require.NoError(t, schemaRegistry.Register(swapping))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry)
+
+	send := func(schemaName string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(TranslateRequest{Schema: schemaName, Database: "postgres", Query: "price:[500 TO 50]"})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		return w
+	}
+
+	w := send("products")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var errResponse rsearch.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResponse))
+	assert.Equal(t, rsearch.ErrorCodeInvalidRange, errResponse.Error.Code)
+	require.Len(t, errResponse.Error.Details, 1)
+	assert.Equal(t, "swap the bounds to [50 TO 500]", errResponse.Error.Details[0].Message)
+
+	w = send("orders")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response TranslateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "price BETWEEN $1 AND $2", response.WhereClause)
+	assert.Len(t, response.Warnings, 1)
+	require.Len(t, response.WarningDetails, 1)
+	assert.Equal(t, rsearch.WarningRangeSwapped, response.WarningDetails[0].Code)
+	assert.Equal(t, response.Warnings[0], response.WarningDetails[0].Message)
+	assert.Equal(t, 1, response.WarningDetails[0].Column)
+}
+
+func TestTranslateHandler_Explain(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"product_code": {Type: schema.TypeText},
+		"price":        {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{NameResolution: []string{schema.ResolveExact, schema.ResolveSnakeCase}})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry)
+
+	send := func(explain bool) TranslateResponse {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: "productCode:a AND price:>1 AND productCode:b", Explain: explain})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response TranslateResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	assert.Nil(t, send(false).Explain)
+
+	explain := send(true).Explain
+	require.NotNil(t, explain)
+	assert.Equal(t, []rsearch.FieldResolution{
+		{
+			Name:   "productCode",
+			Field:  "product_code",
+			Column: "product_code",
+			Steps: []rsearch.ResolutionStep{
+				{Strategy: "exact"},
+				{Strategy: "snake_case", Candidate: "product_code", Matched: true},
+			},
+		},
+		{
+			Name:   "price",
+			Field:  "price",
+			Column: "price",
+			Steps:  []rsearch.ResolutionStep{{Strategy: "exact", Matched: true}},
+		},
+	}, explain.Fields)
+}
+
+func TestTranslateHandler_SelectionHeaders(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+	require.NoError(t, schemaRegistry.Register(schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+	translatorRegistry.Register("mongodb", translator.NewMongoDBTranslator())
+
+	handler := SelectionMiddleware(NewTranslateHandler(schemaRegistry, translatorRegistry))
+
+	send := func(body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/translate", strings.NewReader(body))
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(`{"query": "name:widget"}`, map[string]string{SchemaHeader: "products", DialectHeader: "postgres"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response TranslateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "name = $1", response.WhereClause)
+	assert.Equal(t, []string{SchemaHeader + ", " + DialectHeader, "Accept"}, w.Header().Values("Vary"))
+
+	// Headers complete the body, which may name the same schema or database
+	w = send(`{"schema": "products", "query": "name:widget"}`, map[string]string{SchemaHeader: "products", DialectHeader: "mongodb"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "mongodb", response.Type)
+
+	w = send(`{"schema": "products", "database": "postgres", "query": "name:widget"}`, map[string]string{DialectHeader: "mongodb"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `database \"mongodb\" of the X-RSearch-Dialect header differs from \"postgres\"`)
+
+	w = send(`{"query": "name:widget"}`, map[string]string{DialectHeader: "postgres"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Schema is required")
+}
+
+func TestTranslateHandler_Get(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+	require.NoError(t, schemaRegistry.Register(schema.NewSchema("products", map[string]schema.Field{
+		"name":  {Type: schema.TypeText},
+		"price": {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{})))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := SelectionMiddleware(NewTranslateHandler(schemaRegistry, translatorRegistry).WithGetTranslate(200, time.Minute))
+
+	get := func(target string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/api/v1/translate?schema=products&dialect=postgres&q=name%3A%22blue+widget%22+AND+price%3A%3E10&_=123", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response TranslateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "name = $1 AND price > $2", response.WhereClause)
+	assert.Equal(t, []interface{}{"blue widget", float64(10)}, response.Parameters)
+	assert.Equal(t, "public, max-age=60", w.Header().Get("Cache-Control"))
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// Caches revalidate with the ETag, which only changes with the translation
+	w = get("/api/v1/translate?schema=products&dialect=postgres&q=name%3A%22blue+widget%22+AND+price%3A%3E10", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	w = get("/api/v1/translate?schema=products&dialect=postgres&q=price%3A%3E10", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+
+	// Headers select the schema and database, and API keys keep responses private
+	w = get("/api/v1/translate?q=price:>10", map[string]string{SchemaHeader: "products", DialectHeader: "postgres", "X-API-Key": "key"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "private, max-age=60", w.Header().Get("Cache-Control"))
+
+	w = get("/api/v1/translate?schema=products&dialect=postgres&q=name:%zz", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid query string")
+
+	w = get("/api/v1/translate?schema=products&dialect=postgres&q=a&q=b", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Parameter q is repeated")
+
+	w = get("/api/v1/translate?schema=products&dialect=postgres", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Query is required")
+
+	w = get("/api/v1/translate?schema=products&dialect=postgres&q="+strings.Repeat("a", 200), nil)
+	assert.Equal(t, http.StatusRequestURITooLong, w.Code)
+	var errorResponse rsearch.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errorResponse))
+	assert.Equal(t, rsearch.ErrorCodeQueryTooLong, errorResponse.Error.Code)
+}
+
+func TestMatchesETag(t *testing.T) {
+	assert.True(t, matchesETag(`"abc"`, `"abc"`))
+	assert.True(t, matchesETag(`"x", W/"abc"`, `"abc"`))
+	assert.True(t, matchesETag(`*`, `"abc"`))
+	assert.False(t, matchesETag(`"abcd"`, `"abc"`))
+	assert.False(t, matchesETag("", `"abc"`))
+}
+
+func TestTranslateHandler_MinSimilarity(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{EnabledFeatures: schema.EnabledFeatures{Fuzzy: true}})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithPlanCache(cache.NewCache(100, 0), nil)
+
+	send := func(minSimilarity float64) (*httptest.ResponseRecorder, TranslateResponse) {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: "name:laptop~2", MinSimilarity: minSimilarity})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		var response TranslateResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	w, response := send(0)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "levenshtein(name, $1) <= $2", response.WhereClause)
+
+	// The threshold is not shared with cached plans of other requests
+	w, response = send(0.6)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "similarity(name, $1) >= $2", response.WhereClause)
+	assert.Equal(t, []interface{}{"laptop", 0.6}, response.Parameters)
+
+	w, _ = send(2)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestTranslateHandler_AnchorRegex(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{EnabledFeatures: schema.EnabledFeatures{Regex: true}, AnchorRegex: true})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithPlanCache(cache.NewCache(100, 0), nil)
+
+	send := func(anchor *bool) TranslateResponse {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: "name:/wid.*|gad.*/", AnchorRegex: anchor})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response TranslateResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response
+	}
+
+	// The schema anchors regexes
+	response := send(nil)
+	assert.Equal(t, []interface{}{"^(wid.*|gad.*)$"}, response.Parameters)
+
+	// A request can opt out without sharing the cached plan of the schema
+	unanchored := false
+	response = send(&unanchored)
+	assert.Equal(t, []interface{}{"wid.*|gad.*"}, response.Parameters)
+
+	anchored := true
+	response = send(&anchored)
+	assert.Equal(t, []interface{}{"^(wid.*|gad.*)$"}, response.Parameters)
+}
+
+func TestTranslateHandler_FieldGroupOperator(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("articles", map[string]schema.Field{
+		"tags": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithPlanCache(cache.NewCache(100, 0), nil)
+
+	send := func(operator string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(TranslateRequest{Schema: "articles", Database: "postgres", Query: "tags:(scala functional)", FieldGroupOperator: operator})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		return w
+	}
+	whereClause := func(w *httptest.ResponseRecorder) string {
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response TranslateResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.WhereClause
+	}
+
+	assert.Equal(t, "(tags = $1 OR tags = $2)", whereClause(send("")))
+
+	// A request can require every value without sharing the cached plan of the schema
+	assert.Equal(t, "(tags = $1 AND tags = $2)", whereClause(send("and")))
+	assert.Equal(t, "(tags = $1 OR tags = $2)", whereClause(send("or")))
+
+	assert.Equal(t, http.StatusBadRequest, send("xor").Code)
+}
+
+func TestTranslateHandler_Strict(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{EnabledFeatures: schema.EnabledFeatures{Fuzzy: true}})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("mysql", translator.NewMySQLTranslator())
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithPlanCache(cache.NewCache(100, 0), nil)
+	send := func(database string, strict bool) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: database, Query: "name:laptop~1", Strict: strict})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		return w
+	}
+
+	// Best effort reports the downgrade
+	w := send("mysql", false)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response TranslateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Downgrades, 1)
+	assert.Equal(t, rsearch.SubstituteSoundex, response.Downgrades[0].Substitute)
+	// Downgrades are among the warning details, but not the warning messages
+	assert.Empty(t, response.Warnings)
+	require.Len(t, response.WarningDetails, 1)
+	assert.Equal(t, rsearch.WarningDowngraded, response.WarningDetails[0].Code)
+
+	// Strict mode rejects it, also when the plan is cached
+	w = send("mysql", true)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var errResp rsearch.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+	assert.Equal(t, rsearch.ErrorCodeUnsupportedSyntax, errResp.Error.Code)
+	assert.Equal(t, "Strict translation failed: cannot translate name:laptop~1 exactly for this database", errResp.Error.Message)
+	require.Len(t, errResp.Error.Details, 1)
+	assert.Equal(t, "name:laptop~1 would be translated as soundex: SOUNDEX matches words that sound alike, ignoring the edit distance of 1", errResp.Error.Details[0].Message)
+
+	// Exact translations pass
+	w = send("postgres", true)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestTranslateHandler_StrictCoercions(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"price": {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithPlanCache(cache.NewCache(100, 0), nil)
+	send := func(query string, strict bool) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: query, Strict: strict})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		return w
+	}
+
+	// Best effort binds the rounded value and reports it
+	w := send("price:12345678901234567891", false)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response TranslateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Coercions, 1)
+	assert.Equal(t, "price", response.Coercions[0].Field)
+	assert.Equal(t, "12345678901234567891", response.Coercions[0].Value)
+	assert.Equal(t, 1.2345678901234567e19, response.Coercions[0].Bound)
+
+	// Strict mode rejects it, also when the plan is cached
+	w = send("price:12345678901234567891", true)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var errResp rsearch.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+	assert.Equal(t, rsearch.ErrorCodeTypeMismatch, errResp.Error.Code)
+	assert.Equal(t, "Strict translation failed: cannot bind price:12345678901234567891 exactly", errResp.Error.Message)
+	require.Len(t, errResp.Error.Details, 1)
+	assert.Equal(t, "value 12345678901234567891 of field price would be bound as 1.2345678901234567e+19: it has more digits than a 64-bit float holds", errResp.Error.Details[0].Message)
+
+	// Values a float holds pass
+	w = send("price:0.1", true)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestTranslateHandler_Facets(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("orders", map[string]schema.Field{
+		"status":    {Type: schema.TypeText},
+		"createdAt": {Type: schema.TypeDate, Column: "created_at"},
+	}, schema.SchemaOptions{})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+	translatorRegistry.Register("mongodb", translator.NewMongoDBTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithPlanCache(cache.NewCache(100, 0), nil)
+
+	send := func(database string, facets []rsearch.FacetRequest) (*httptest.ResponseRecorder, TranslateResponse) {
+		body, _ := json.Marshal(TranslateRequest{Schema: "orders", Database: database, Query: "status:paid", Facets: facets})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		var response TranslateResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+	daily := []rsearch.FacetRequest{{Name: "daily", Type: rsearch.FacetDateHistogram, Field: "createdAt", Interval: rsearch.IntervalDay}}
+
+	w, response := send("postgres", daily)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "status = $1", response.WhereClause)
+	assert.Equal(t, []rsearch.Facet{{
+		Name:          "daily",
+		Type:          rsearch.FacetDateHistogram,
+		SelectClause:  "date_trunc('day', created_at) AS bucket, COUNT(*) AS count",
+		GroupByClause: "date_trunc('day', created_at)",
+		OrderByClause: "bucket",
+	}}, response.Facets)
+
+	// MongoDB facets match the translated filter first
+	w, response = send("mongodb", daily)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, response.Facets, 1)
+	assert.Equal(t, map[string]interface{}{"$match": map[string]interface{}{"status": "paid"}}, response.Facets[0].Pipeline[0])
+
+	w, _ = send("postgres", []rsearch.FacetRequest{{Name: "daily", Type: rsearch.FacetDateHistogram, Field: "status", Interval: rsearch.IntervalDay}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestTranslateHandler_SlowTranslationLog(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+	schemaRegistry.Register(testSchema)
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	logPath := filepath.Join(t.TempDir(), "slow.log")
+	logger, err := observability.NewLogger("warn", "json", logPath)
+	require.NoError(t, err)
+
+	delay := 0 * time.Millisecond
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithSlowLog(logger, nil, 20*time.Millisecond)
+	parse := handler.parseQuery
+	handler.parseQuery = func(query string) (parser.Node, error) {
+		time.Sleep(delay)
+		return parse(query)
+	}
+
+	send := func(query string) {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: query})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// Fast translations are not logged
+	send("name:fast")
+	content, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Empty(t, content)
+
+	delay = 30 * time.Millisecond
+	send("name:slow AND name:query")
+
+	content, err = os.ReadFile(logPath)
+	require.NoError(t, err)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(content, &entry))
+	assert.Equal(t, "warn", entry["level"])
+	assert.Equal(t, "postgres", entry["dialect"])
+	assert.Equal(t, "products", entry["schema"])
+	assert.Equal(t, observability.QueryHash("name:slow AND name:query"), entry["query_hash"])
+	assert.Equal(t, float64(len("name:slow AND name:query")), entry["query_length"])
+	assert.Equal(t, float64(2), entry["ast_depth"])
+	assert.GreaterOrEqual(t, entry["duration_ms"], float64(30))
+	assert.NotContains(t, string(content), "name:slow", "query text must not be logged")
+}
+
+func TestTranslateHandler_FragmentAudit(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":     {Type: schema.TypeText},
+		"stockQty": {Type: schema.TypeInteger},
+	}, schema.SchemaOptions{})
+	testSchema.Fragments = map[string]schema.Fragment{"inStock": {SQL: "{stockQty} > 0"}}
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	logPath := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := observability.NewLogger("info", "json", logPath)
+	require.NoError(t, err)
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithFragmentAudit(logger)
+
+	send := func(query string) {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: query})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	// Queries without fragments are not logged
+	send("name:widget")
+	content, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Empty(t, content)
+
+	send("name:widget AND inStock:true")
+	content, err = os.ReadFile(logPath)
+	require.NoError(t, err)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(content, &entry))
+	assert.Equal(t, "Schema fragments applied", entry["message"])
+	assert.Equal(t, "products", entry["schema"])
+	assert.Equal(t, "postgres", entry["dialect"])
+	assert.Equal(t, []interface{}{"inStock"}, entry["fragments"])
+	assert.NotContains(t, string(content), "stockQty", "fragment definitions must not be logged")
+}
+
+func TestTranslateHandler_QueryLogging(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("users", map[string]schema.Field{
+		"email": {Type: schema.TypeText},
+		"age":   {Type: schema.TypeInteger},
+	}, schema.SchemaOptions{})
+	schemaRegistry.Register(testSchema)
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	query := `email:"jane@example.com" AND age:[30 TO 40]`
+
+	tests := []struct {
+		mode     observability.QueryLogMode
+		field    string
+		expected interface{}
+	}{
+		{"", "query_hash", observability.QueryHash(query)},
+		{observability.QueryLogHash, "query_hash", observability.QueryHash(query)},
+		{observability.QueryLogRedacted, "query_redacted", "age:[? TO ?] AND email:?"},
+		{observability.QueryLogFull, "query", query},
+		{observability.QueryLogNone, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			logPath := filepath.Join(t.TempDir(), "slow.log")
+			logger, err := observability.NewLogger("warn", "json", logPath)
+			require.NoError(t, err)
+
+			// Every translation takes at least a nanosecond, so every one is logged
+			handler := NewTranslateHandler(schemaRegistry, translatorRegistry).
+				WithSlowLog(logger, nil, time.Nanosecond).
+				WithQueryLogging(tt.mode)
+
+			body, _ := json.Marshal(TranslateRequest{Schema: "users", Database: "postgres", Query: query})
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+			require.Equal(t, http.StatusOK, w.Code)
+
+			content, err := os.ReadFile(logPath)
+			require.NoError(t, err)
+
+			var entry map[string]interface{}
+			require.NoError(t, json.Unmarshal(content, &entry))
+			for _, field := range []string{"query", "query_redacted", "query_hash"} {
+				if field == tt.field {
+					assert.Equal(t, tt.expected, entry[field])
+				} else {
+					assert.NotContains(t, entry, field)
+				}
+			}
+			if tt.mode != observability.QueryLogFull {
+				assert.NotContains(t, string(content), "jane@example.com")
+			}
+		})
+	}
+}
+
+func TestTranslateHandler_Sort(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("orders", map[string]schema.Field{
+		"id":        {Type: schema.TypeInteger},
+		"status":    {Type: schema.TypeText},
+		"createdAt": {Type: schema.TypeDate, Column: "created_at"},
+	}, schema.SchemaOptions{
+		DefaultSort: []schema.SortField{{Field: "createdAt", Order: rsearch.SortDesc}},
+		TieBreaker:  "id",
+	})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+	translatorRegistry.Register("mongodb", translator.NewMongoDBTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithPlanCache(cache.NewCache(100, 0), nil)
+
+	send := func(database string, sort []rsearch.SortField) (*httptest.ResponseRecorder, TranslateResponse) {
+		body, _ := json.Marshal(TranslateRequest{Schema: "orders", Database: database, Query: "status:paid", Sort: sort})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		var response TranslateResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	w, response := send("postgres", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "created_at DESC, id", response.OrderByClause)
+
+	// Sorting by a non-unique column still ends with the tie-breaker, also from the cached plan
+	w, response = send("postgres", []rsearch.SortField{{Field: "status"}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "status, id", response.OrderByClause)
+
+	w, response = send("mongodb", []rsearch.SortField{{Field: "status", Order: rsearch.SortDesc}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []rsearch.SortKey{{Key: "status", Direction: -1}, {Key: "id", Direction: 1}}, response.Sort)
+	assert.Empty(t, response.OrderByClause)
+
+	w, _ = send("postgres", []rsearch.SortField{{Field: "password"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid sort: field password not found in schema orders")
+}
+
+func TestTranslateHandler_AsOf(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("prices", map[string]schema.Field{
+		"sku":       {Type: schema.TypeText},
+		"validFrom": {Type: schema.TypeDateTime, Column: "valid_from"},
+		"validTo":   {Type: schema.TypeDateTime, Column: "valid_to"},
+	}, schema.SchemaOptions{
+		Temporal: schema.TemporalOptions{ValidFrom: "validFrom", ValidTo: "validTo"},
+	})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithPlanCache(cache.NewCache(100, 0), nil)
+
+	send := func(asOf string) (*httptest.ResponseRecorder, TranslateResponse) {
+		body, _ := json.Marshal(TranslateRequest{Schema: "prices", Database: "postgres", Query: "sku:a", AsOf: asOf})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		var response TranslateResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	w, response := send("")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "sku = $1", response.WhereClause)
+
+	w, response = send("2024-06-01T00:00:00Z")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, response.WhereClause, "sku = ")
+	assert.Contains(t, response.WhereClause, "(NOT valid_to IS NOT NULL OR valid_to > ")
+	assert.ElementsMatch(t, []interface{}{"a", "2024-06-01T00:00:00Z", "2024-06-01T00:00:00Z"}, response.Parameters)
+
+	// Another point in time is another plan
+	w, response = send("2025-01-01T00:00:00Z")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.ElementsMatch(t, []interface{}{"a", "2025-01-01T00:00:00Z", "2025-01-01T00:00:00Z"}, response.Parameters)
+
+	w, _ = send("yesterday-ish")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid asOf: invalid asOf")
+}
+
+func TestTranslateHandler_Quotas(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	tracker := ratelimit.NewQuotaTracker(ratelimit.Quota{Queries: 2, Complexity: 100}, map[string]ratelimit.Quota{"big-tenant": {}}, []string{"tenant-a", "tenant-b"})
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithQuotas(tracker, nil)
+
+	send := func(apiKey, query string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: query})
+		req := httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body))
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("tenant-a", "name:a AND name:b")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "2", w.Header().Get("X-Quota-Queries-Limit"))
+	assert.Equal(t, "1", w.Header().Get("X-Quota-Queries-Remaining"))
+	assert.Equal(t, "97", w.Header().Get("X-Quota-Complexity-Remaining"))
+
+	// Failed translations are not charged
+	w = send("tenant-a", "missing:a")
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Equal(t, "1", w.Header().Get("X-Quota-Queries-Remaining"))
+
+	w = send("tenant-a", "name:c")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "0", w.Header().Get("X-Quota-Queries-Remaining"))
+
+	w = send("tenant-a", "name:d")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	var response rsearch.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, rsearch.ErrorCodeQuotaExceeded, response.Error.Code)
+	require.NotNil(t, response.Error.Quota)
+	assert.Equal(t, 2, response.Error.Quota.QueriesLimit)
+	assert.Equal(t, 2, response.Error.Quota.QueriesUsed)
+	assert.Equal(t, 4, response.Error.Quota.ComplexityUsed)
+	assert.NotEmpty(t, response.Error.Quota.ResetsAt)
+
+	// Other keys are counted separately
+	w = send("tenant-b", "name:e")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Keys without limits are never rejected
+	for range 3 {
+		w = send("big-tenant", "name:e")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	assert.Empty(t, w.Header().Get("X-Quota-Queries-Limit"))
+
+	// Unknown keys and requests without a key share one quota, so rotating keys does not
+	// reset usage
+	for _, apiKey := range []string{"", "rotated-1"} {
+		w = send(apiKey, "name:f")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	w = send("rotated-2", "name:f")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func TestTranslateHandler_Projection(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText},
+		"status": {Type: schema.TypeText, Column: "product_status"},
+	}, schema.SchemaOptions{})
+	schemaRegistry.Register(testSchema)
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+	translatorRegistry.Register("mongodb", translator.NewMongoDBTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry)
+
+	send := func(database string, fields []string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: database, Query: "name:foo", Fields: fields})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	w, response := send("postgres", []string{"name", "status"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `name, product_status AS "status"`, response["selectClause"])
+
+	w, response = send("mongodb", []string{"status"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, map[string]interface{}{"product_status": float64(1)}, response["projection"])
+
+	w, response = send("postgres", []string{"password"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, response["error"], "Invalid fields")
+
+	// Without fields the response carries no projection
+	w, response = send("postgres", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, response, "selectClause")
+}
+
+func TestTranslateHandler_TableAlias(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"productCode": {Type: schema.TypeText},
+		"status":      {Type: schema.TypeText, Column: "product_status"},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+	schemaRegistry.Register(testSchema)
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+	translatorRegistry.Register("mongodb", translator.NewMongoDBTranslator())
+
+	planCache := cache.NewCache(100, 0)
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithPlanCache(planCache, nil)
+
+	send := func(database, alias string, fields []string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: database, Query: "productCode:A1 AND status:active", Fields: fields, TableAlias: alias})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	w, response := send("postgres", "p", []string{"status"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "p.product_code = $1 AND p.product_status = $2", response["whereClause"])
+	assert.Equal(t, `p.product_status AS "status"`, response["selectClause"])
+
+	// Aliased and unaliased plans are cached apart
+	w, response = send("postgres", "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "product_code = $1 AND product_status = $2", response["whereClause"])
+	assert.Equal(t, 2, planCache.Len())
+
+	w, response = send("postgres", "p.q", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, response["error"], "Invalid table alias")
+
+	w, response = send("mongodb", "p", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Table aliases are only supported for SQL databases", response["error"])
+}
+
+func TestTranslateHandler_ExecutionHints(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	limited := schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{Execution: schema.ExecutionLimits{TimeoutMs: 2000, MaxRows: 100}})
+	open := schema.NewSchema("logs", map[string]schema.Field{
+		"level": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+	require.NoError(t, schemaRegistry.Register(limited))
+	require.NoError(t, schemaRegistry.Register(open))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+	translatorRegistry.Register("mongodb", translator.NewMongoDBTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithPlanCache(cache.NewCache(100, 0), nil)
+
+	send := func(req TranslateRequest) (*httptest.ResponseRecorder, TranslateResponse) {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		var response TranslateResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	// Schema limits apply as configured
+	w, response := send(TranslateRequest{Schema: "products", Database: "postgres", Query: "name:a"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NotNil(t, response.ExecutionHints)
+	assert.Equal(t, []string{"SET LOCAL statement_timeout = 2000"}, response.ExecutionHints.Statements)
+	assert.Equal(t, "LIMIT 100", response.ExecutionHints.LimitClause)
+
+	// Requests can tighten limits but not loosen them
+	w, response = send(TranslateRequest{Schema: "products", Database: "postgres", Query: "name:a", TimeoutMs: 500, MaxRows: 1000})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 500, response.ExecutionHints.TimeoutMs)
+	assert.Equal(t, 100, response.ExecutionHints.MaxRows)
+
+	// Tightened hints are not cached for other requests
+	w, response = send(TranslateRequest{Schema: "products", Database: "postgres", Query: "name:a"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2000, response.ExecutionHints.TimeoutMs)
+
+	w, response = send(TranslateRequest{Schema: "logs", Database: "mongodb", Query: "level:error", TimeoutMs: 750})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 750, response.ExecutionHints.MaxTimeMS)
+	assert.Empty(t, response.ExecutionHints.LimitClause)
+
+	// Schemas and requests without limits get no hints
+	w, response = send(TranslateRequest{Schema: "logs", Database: "postgres", Query: "level:error"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, response.ExecutionHints)
+
+	w, _ = send(TranslateRequest{Schema: "logs", Database: "postgres", Query: "level:error", MaxRows: -1})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestTranslateHandler_DefaultFilters(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("users", map[string]schema.Field{
+		"name":       {Type: schema.TypeText},
+		"deleted_at": {Type: schema.TypeDate},
+	}, schema.SchemaOptions{
+		DefaultFilters: []schema.DefaultFilter{
+			{Name: "not_deleted", Query: "NOT _exists_:deleted_at"},
+		},
+	})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithDefaultFilterBypass([]string{"admin-key"})
+
+	send := func(apiKey string, skip []string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		body, _ := json.Marshal(TranslateRequest{Schema: "users", Database: "postgres", Query: "name:foo", SkipDefaultFilters: skip})
+		req := httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body))
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	// Default filters are always applied
+	w, response := send("", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "(name = $1) AND (NOT deleted_at IS NOT NULL)", response["whereClause"])
+	assert.Equal(t, []interface{}{"not_deleted"}, response["defaultFilters"])
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"policy": "default_filter", "name": "not_deleted", "action": "injected",
+	}}, response["appliedPolicies"])
+	// Fields read by default filters are referenced too
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"field": "name", "column": "name"},
+		map[string]interface{}{"field": "deleted_at", "column": "deleted_at"},
+	}, response["referencedFields"])
+
+	// Opting out requires an authorized key
+	w, _ = send("", []string{"not_deleted"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w, _ = send("wrong-key", []string{"not_deleted"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w, response = send("admin-key", []string{"not_deleted"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "name = $1", response["whereClause"])
+	assert.NotContains(t, response, "defaultFilters")
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"policy": "default_filter", "name": "not_deleted", "action": "bypassed",
+		"reason": "skipped with an authorized API key",
+	}}, response["appliedPolicies"])
+
+	// Unknown filter names are rejected rather than ignored
+	w, _ = send("admin-key", []string{"not_deletd"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// shadowCounter records the queries and filters counted on OpenSearch
+type shadowCounter struct {
+	queries chan []string
+}
+
+func (c *shadowCounter) Count(_ context.Context, _ *schema.Schema, query string, filters []string) (int64, error) {
+	c.queries <- append([]string{query}, filters...)
+	return 1, nil
+}
+
+type shadowExecutor struct{}
+
+func (shadowExecutor) Count(context.Context, *schema.Schema, *translator.TranslatorOutput) (int64, error) {
+	return 1, nil
+}
+
+func TestTranslateHandler_Shadow(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	testSchema := schema.NewSchema("users", map[string]schema.Field{
+		"name":       {Type: schema.TypeText},
+		"deleted_at": {Type: schema.TypeDate},
+	}, schema.SchemaOptions{
+		DefaultFilters: []schema.DefaultFilter{
+			{Name: "not_deleted", Query: "NOT _exists_:deleted_at"},
+		},
+	})
+	require.NoError(t, schemaRegistry.Register(testSchema))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+	translatorRegistry.Register("mongodb", translator.NewMongoDBTranslator())
+
+	logger, err := observability.NewLogger("error", "json", "stdout")
+	require.NoError(t, err)
+	counter := &shadowCounter{queries: make(chan []string, 10)}
+	comparer := shadow.NewComparer("postgres", shadowExecutor{}, counter, logger, nil)
+	comparer.Start()
+
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithShadow(comparer)
+	for _, req := range []TranslateRequest{
+		{Schema: "users", Database: "mongodb", Query: "name:bar"},
+		{Schema: "users", Database: "postgres", Query: "name:foo"},
+		{Schema: "users", Database: "postgres", Query: "name:"},
+	} {
+		body, _ := json.Marshal(req)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+	}
+	comparer.Stop()
+
+	// Only successful translations to the compared database are counted, with the
+	// default filters applied to them
+	require.Len(t, counter.queries, 1)
+	assert.Equal(t, []string{"name:foo", "NOT _exists_:deleted_at"}, <-counter.queries)
+}
+
+func TestTranslateHandler_PlanCache(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+
+	newSchema := func(column string) *schema.Schema {
+		return schema.NewSchema("products", map[string]schema.Field{
+			"name":  {Type: schema.TypeText, Column: column},
+			"price": {Type: schema.TypeFloat},
+		}, schema.SchemaOptions{})
+	}
+	require.NoError(t, schemaRegistry.Register(newSchema("name")))
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	planCache := cache.NewCache(100, 0)
+	handler := NewTranslateHandler(schemaRegistry, translatorRegistry).WithPlanCache(planCache, nil)
+
+	send := func(query string, fields []string) map[string]interface{} {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: query, Fields: fields})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response
+	}
+
+	first := send("name:foo AND price:>10", nil)
+	assert.Equal(t, "name = $1 AND price > $2", first["whereClause"])
+	assert.Equal(t, 1, planCache.Len())
+
+	// Queries written alike share the cached plan and get identical responses
+	second := send("name:foo  &&  price:>10", nil)
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, planCache.Len())
+
+	// Reordered operands number parameters differently, so they are translated as written
+	assert.Equal(t, "price > $1 AND name = $2", send("price:>10 AND name:foo", nil)["whereClause"])
+	assert.Equal(t, 2, planCache.Len())
+
+	// Shaping a cached response does not leak into the cache entry
+	projected := send("name:foo AND price:>10", []string{"name"})
+	assert.Equal(t, "name", projected["selectClause"])
+	assert.NotContains(t, send("name:foo AND price:>10", nil), "selectClause")
+
+	// Date keywords resolve against the current time and are never cached
+	require.NoError(t, schemaRegistry.Register(schema.NewSchema("orders", map[string]schema.Field{
+		"createdAt": {Type: schema.TypeDateTime},
+	}, schema.SchemaOptions{})))
+	body, _ := json.Marshal(TranslateRequest{Schema: "orders", Database: "postgres", Query: "createdAt:today"})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "createdAt >= $1 AND createdAt < $2", response["whereClause"])
+	assert.Equal(t, 2, planCache.Len())
+
+	// A schema change invalidates cached plans through the version in the key
+	require.NoError(t, schemaRegistry.Delete("products"))
+	require.NoError(t, schemaRegistry.Register(newSchema("product_name")))
+	assert.Equal(t, "product_name = $1 AND price > $2", send("name:foo AND price:>10", nil)["whereClause"])
+}
+
+func TestTranslateHandler_PlanCacheMatchesUncached(t *testing.T) {
+	schemaRegistry := schema.NewRegistry()
+	require.NoError(t, schemaRegistry.Register(schema.NewSchema("products", map[string]schema.Field{
+		"name":  {Type: schema.TypeText},
+		"price": {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{
+		DefaultField: "name",
+		Analyzer:     schema.AnalyzerOptions{Stopwords: []string{"the"}},
+	})))
+	translatorRegistry := translator.NewRegistry()
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+
+	cached := NewTranslateHandler(schemaRegistry, translatorRegistry).WithPlanCache(cache.NewCache(100, 0), nil)
+	uncached := NewTranslateHandler(schemaRegistry, translatorRegistry)
+
+	send := func(handler *TranslateHandler, query string) (int, string) {
+		body, _ := json.Marshal(TranslateRequest{Schema: "products", Database: "postgres", Query: query})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/translate", bytes.NewReader(body)))
+		return w.Code, w.Body.String()
+	}
+
+	queries := []string{
+		"a -b",
+		"a OR -b",
+		"foo NOT bar",
+		"-b a",
+		"the fox",
+		"price:>10 AND name:foo",
+		"name:foo AND price:>10",
+		"name:foo  AND  price:>10",
+		"price:[20 TO 10]",
+		"name:x AND  price:[20 TO 10]",
+	}
+	// Each query is sent twice to the cached handler, after all the others, so every
+	// response may come from a plan another query cached
+	for round := 0; round < 2; round++ {
+		for _, query := range queries {
+			wantCode, want := send(uncached, query)
+			code, got := send(cached, query)
+			assert.Equal(t, wantCode, code, query)
+			assert.JSONEq(t, want, got, query)
+		}
+	}
+
+	// Negated free text keeps its meaning with the cache enabled
+	_, body := send(cached, "a -b")
+	assert.Contains(t, body, `"whereClause":"name = $1 AND NOT name = $2"`)
+}
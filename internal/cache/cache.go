@@ -131,6 +131,24 @@ func (c *Cache) Len() int {
 	return c.lruList.Len()
 }
 
+// Values returns the values that have not expired, most recently used first, without
+// changing their order
+func (c *Cache) Values() []interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	values := make([]interface{}, 0, c.lruList.Len())
+	for element := c.lruList.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(*entry)
+		if c.ttl > 0 && now.After(entry.expiresAt) {
+			continue
+		}
+		values = append(values, entry.value)
+	}
+	return values
+}
+
 // evictOldest removes the least recently used item from the cache.
 // Must be called with lock held.
 func (c *Cache) evictOldest() {
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -512,3 +513,27 @@ func TestCacheStressTest(t *testing.T) {
 		t.Error("cache should still be functional after stress test")
 	}
 }
+
+// TestCache_Values verifies values are listed most recently used first, skipping expired ones
+func TestCache_Values(t *testing.T) {
+	cache := NewCache(3, 0)
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Set("c", 3)
+	cache.Get("a")
+	cache.Set("d", 4) // evicts b
+
+	for i := 0; i < 2; i++ { // listing values must not reorder them
+		if got := cache.Values(); !reflect.DeepEqual(got, []interface{}{4, 1, 3}) {
+			t.Errorf("Values() = %v, want [4 1 3]", got)
+		}
+	}
+
+	expiring := NewCache(2, 10*time.Millisecond)
+	expiring.Set("a", 1)
+	time.Sleep(20 * time.Millisecond)
+	expiring.Set("b", 2)
+	if got := expiring.Values(); !reflect.DeepEqual(got, []interface{}{2}) {
+		t.Errorf("Values() = %v, want [2]", got)
+	}
+}
@@ -0,0 +1,114 @@
+// Package chaos injects faults into the request pipeline at configured rates, so services
+// calling rsearch can exercise their retry and fallback logic against a real server. It is
+// meant for test environments only and is disabled unless configured.
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Pipeline stages faults can be injected at
+const (
+	StageRequest   = "request"   // before a request reaches its handler
+	StageParse     = "parse"     // before the query is parsed
+	StageTranslate = "translate" // before the parsed query is translated
+	StageResponse  = "response"  // after the handler wrote its response
+)
+
+// Stages lists the pipeline stages, in order
+var Stages = []string{StageRequest, StageParse, StageTranslate, StageResponse}
+
+// Header names the injected faults on responses, such as "parse:delay"
+const Header = "X-Chaos-Injected"
+
+// Fault describes the faults injected at a stage. Rates are probabilities between 0
+// and 1, drawn independently for each request.
+type Fault struct {
+	DelayRate     float64
+	Delay         time.Duration
+	ErrorRate     float64
+	ErrorStatus   int     // status of injected errors; 500 when 0
+	MalformedRate float64 // response stage only: truncate the response body
+}
+
+// Error is an injected failure
+type Error struct {
+	Stage  string
+	Status int
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("injected failure at %s stage", e.Stage)
+}
+
+// Injector injects the faults of each stage. A nil Injector injects nothing.
+type Injector struct {
+	faults map[string]Fault
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// New creates an injector of the faults per stage; the same seed draws the same faults
+// for the same sequence of calls
+func New(faults map[string]Fault, seed int64) *Injector {
+	return &Injector{faults: faults, rand: rand.New(rand.NewSource(seed))}
+}
+
+// Inject delays the stage and fails it at the stage's rates, recording what it injected
+// in the response's Header. The delay ends early when ctx is done.
+func (i *Injector) Inject(ctx context.Context, w http.ResponseWriter, stage string) error {
+	if i == nil {
+		return nil
+	}
+	fault, ok := i.faults[stage]
+	if !ok {
+		return nil
+	}
+
+	if fault.Delay > 0 && i.draw(fault.DelayRate) {
+		w.Header().Add(Header, stage+":delay")
+		timer := time.NewTimer(fault.Delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+
+	if i.draw(fault.ErrorRate) {
+		w.Header().Add(Header, stage+":error")
+		status := fault.ErrorStatus
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		return &Error{Stage: stage, Status: status}
+	}
+	return nil
+}
+
+// Malform reports whether to truncate the response written at the stage, recording it
+// in the response's Header
+func (i *Injector) Malform(w http.ResponseWriter, stage string) bool {
+	if i == nil || !i.draw(i.faults[stage].MalformedRate) {
+		return false
+	}
+	w.Header().Add(Header, stage+":malformed")
+	return true
+}
+
+// draw reports whether an event of the given probability happens
+func (i *Injector) draw(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.rand.Float64() < rate
+}
@@ -0,0 +1,72 @@
+package chaos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInjector_Inject(t *testing.T) {
+	injector := New(map[string]Fault{
+		StageParse:     {ErrorRate: 1, ErrorStatus: http.StatusServiceUnavailable},
+		StageTranslate: {DelayRate: 1, Delay: time.Millisecond},
+	}, 1)
+
+	w := httptest.NewRecorder()
+	err := injector.Inject(context.Background(), w, StageParse)
+	var chaosErr *Error
+	require.ErrorAs(t, err, &chaosErr)
+	assert.Equal(t, http.StatusServiceUnavailable, chaosErr.Status)
+	assert.Equal(t, "injected failure at parse stage", err.Error())
+	assert.Equal(t, []string{"parse:error"}, w.Header().Values(Header))
+
+	w = httptest.NewRecorder()
+	assert.NoError(t, injector.Inject(context.Background(), w, StageTranslate))
+	assert.Equal(t, []string{"translate:delay"}, w.Header().Values(Header))
+
+	w = httptest.NewRecorder()
+	assert.NoError(t, injector.Inject(context.Background(), w, StageRequest))
+	assert.Empty(t, w.Header().Values(Header))
+}
+
+func TestInjector_DelayCanceled(t *testing.T) {
+	injector := New(map[string]Fault{StageRequest: {DelayRate: 1, Delay: time.Hour}}, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := injector.Inject(ctx, httptest.NewRecorder(), StageRequest)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestInjector_Rates(t *testing.T) {
+	// The same seed draws the same faults
+	draws := func() []bool {
+		injector := New(map[string]Fault{StageResponse: {MalformedRate: 0.5}}, 42)
+		var malformed []bool
+		for i := 0; i < 100; i++ {
+			malformed = append(malformed, injector.Malform(httptest.NewRecorder(), StageResponse))
+		}
+		return malformed
+	}
+	first := draws()
+	assert.Equal(t, first, draws())
+
+	count := 0
+	for _, m := range first {
+		if m {
+			count++
+		}
+	}
+	assert.InDelta(t, 50, count, 20)
+}
+
+func TestInjector_Nil(t *testing.T) {
+	var injector *Injector
+	assert.NoError(t, injector.Inject(context.Background(), httptest.NewRecorder(), StageParse))
+	assert.False(t, injector.Malform(httptest.NewRecorder(), StageResponse))
+}
@@ -0,0 +1,190 @@
+// Package cluster propagates schema registry changes between rsearch replicas.
+// Every local registration or deletion is broadcast over a pub/sub transport and
+// applied by the other replicas, so a schema update on one node reaches all nodes
+// behind the load balancer within the transport's delivery latency. Replicas exchange
+// their full state when they start and when they reconnect, catching up on the changes
+// they missed.
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/infiniv/rsearch/internal/observability"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// Transport is a pub/sub channel shared by all replicas
+type Transport interface {
+	// Publish broadcasts a payload to all subscribers, including the publisher itself
+	Publish(payload []byte) error
+
+	// Subscribe registers the handler invoked for every received payload
+	Subscribe(handler func(payload []byte)) error
+
+	// Close releases the transport's resources
+	Close() error
+}
+
+// Reconnector is implemented by transports that can lose messages while disconnected
+// and report when they are connected again
+type Reconnector interface {
+	// OnReconnect registers the function called after every reconnection
+	OnReconnect(fn func())
+}
+
+// syncRequest is the type of the message asking the other replicas for their state
+const syncRequest schema.EventType = "sync"
+
+// Message is the wire format of a schema change broadcast
+type Message struct {
+	Node    string           `json:"node"`             // replica sending the message
+	Origin  string           `json:"origin,omitempty"` // replica that made the version; Node when empty
+	Type    schema.EventType `json:"type"`
+	Name    string           `json:"name,omitempty"`
+	Version uint64           `json:"version,omitempty"`
+	Schema  *schema.Schema   `json:"schema,omitempty"`
+}
+
+// Syncer keeps a schema registry in sync with the other replicas
+type Syncer struct {
+	registry  *schema.Registry
+	transport Transport
+	nodeID    string
+	logger    *observability.Logger
+}
+
+// NewSyncer creates a syncer for the given registry and transport.
+// nodeID must be unique per replica; it is used to ignore our own broadcasts, and
+// orders concurrent changes of the same schema version on different replicas.
+func NewSyncer(registry *schema.Registry, transport Transport, nodeID string, logger *observability.Logger) *Syncer {
+	registry.SetNode(nodeID)
+	return &Syncer{
+		registry:  registry,
+		transport: transport,
+		nodeID:    nodeID,
+		logger:    logger,
+	}
+}
+
+// Start begins broadcasting local changes and applying remote ones, and exchanges state
+// with the other replicas
+func (s *Syncer) Start() error {
+	if err := s.transport.Subscribe(s.handleMessage); err != nil {
+		return fmt.Errorf("failed to subscribe to cluster transport: %w", err)
+	}
+	s.registry.Subscribe(s.handleEvent)
+	if reconnector, ok := s.transport.(Reconnector); ok {
+		reconnector.OnReconnect(s.sync)
+	}
+	s.sync()
+	return nil
+}
+
+// sync broadcasts the local state and asks the other replicas for theirs, so that
+// changes made while this replica was down or disconnected reach both sides
+func (s *Syncer) sync() {
+	s.publishState()
+	s.publish(Message{Node: s.nodeID, Type: syncRequest})
+}
+
+// publishState broadcasts the current version of every schema name
+func (s *Syncer) publishState() {
+	for _, event := range s.registry.State() {
+		s.publish(Message{
+			Node:    s.nodeID,
+			Origin:  event.Node,
+			Type:    event.Type,
+			Name:    event.Name,
+			Version: event.Version,
+			Schema:  event.Schema,
+		})
+	}
+}
+
+// Stop closes the underlying transport
+func (s *Syncer) Stop() error {
+	return s.transport.Close()
+}
+
+// handleEvent broadcasts a local registry change
+func (s *Syncer) handleEvent(event schema.Event) {
+	if event.Remote {
+		return
+	}
+
+	s.publish(Message{
+		Node:    s.nodeID,
+		Origin:  event.Node,
+		Type:    event.Type,
+		Name:    event.Name,
+		Version: event.Version,
+		Schema:  event.Schema,
+	})
+}
+
+// publish broadcasts a message, logging failures
+func (s *Syncer) publish(msg Message) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		s.logger.ErrorWithErr(err, "Failed to encode schema change")
+		return
+	}
+
+	if err := s.transport.Publish(payload); err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"schema":  msg.Name,
+			"version": msg.Version,
+			"type":    msg.Type,
+		}).ErrorWithErr(err, "Failed to broadcast schema change")
+	}
+}
+
+// handleMessage applies a schema change received from another replica
+func (s *Syncer) handleMessage(payload []byte) {
+	var msg Message
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		s.logger.ErrorWithErr(err, "Failed to decode schema change")
+		return
+	}
+
+	if msg.Node == s.nodeID {
+		return
+	}
+	if msg.Type == syncRequest {
+		s.publishState()
+		return
+	}
+	if msg.Origin == "" {
+		msg.Origin = msg.Node
+	}
+
+	if msg.Schema != nil {
+		// Rebuild through the constructor so lookup caches are initialized
+		rebuilt := schema.NewSchema(msg.Schema.Name, msg.Schema.Fields, msg.Schema.Options)
+		rebuilt.Fragments = msg.Schema.Fragments
+		rebuilt.CreatedAt = msg.Schema.CreatedAt
+		msg.Schema = rebuilt
+	}
+
+	applied, err := s.registry.ApplyRemote(schema.Event{
+		Type:    msg.Type,
+		Name:    msg.Name,
+		Version: msg.Version,
+		Node:    msg.Origin,
+		Schema:  msg.Schema,
+	})
+
+	fields := map[string]interface{}{
+		"schema":  msg.Name,
+		"version": msg.Version,
+		"node":    msg.Origin,
+	}
+	if err != nil {
+		s.logger.WithFields(fields).ErrorWithErr(err, "Failed to apply remote schema change")
+		return
+	}
+	if applied {
+		s.logger.WithFields(fields).Infof("Applied remote schema change: %s", msg.Type)
+	}
+}
@@ -0,0 +1,200 @@
+package cluster
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/observability"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryBus is an in-process transport delivering every payload to all subscribers
+type memoryBus struct {
+	mu       sync.Mutex
+	handlers []func([]byte)
+}
+
+func (b *memoryBus) transport() Transport {
+	return &memoryTransport{bus: b}
+}
+
+type memoryTransport struct {
+	bus *memoryBus
+}
+
+func (t *memoryTransport) Publish(payload []byte) error {
+	t.bus.mu.Lock()
+	handlers := append([]func([]byte){}, t.bus.handlers...)
+	t.bus.mu.Unlock()
+
+	for _, h := range handlers {
+		h(payload)
+	}
+	return nil
+}
+
+func (t *memoryTransport) Subscribe(handler func([]byte)) error {
+	t.bus.mu.Lock()
+	defer t.bus.mu.Unlock()
+	t.bus.handlers = append(t.bus.handlers, handler)
+	return nil
+}
+
+func (t *memoryTransport) Close() error { return nil }
+
+func newTestLogger(t *testing.T) *observability.Logger {
+	logger, err := observability.NewLogger("error", "json", "stderr")
+	require.NoError(t, err)
+	return logger
+}
+
+// stateOf describes the version of every name in a registry, with the replica that made
+// it and the schema's fingerprint
+func stateOf(registry *schema.Registry) []string {
+	var state []string
+	for _, event := range registry.State() {
+		fingerprint := ""
+		if event.Schema != nil {
+			fingerprint = event.Schema.Fingerprint()
+		}
+		state = append(state, fmt.Sprintf("%s %s@%d by %s %s", event.Type, event.Name, event.Version, event.Node, fingerprint))
+	}
+	return state
+}
+
+func TestSyncer_PropagatesRegistrationAndDeletion(t *testing.T) {
+	bus := &memoryBus{}
+	logger := newTestLogger(t)
+
+	nodeA := schema.NewRegistry()
+	nodeB := schema.NewRegistry()
+	require.NoError(t, NewSyncer(nodeA, bus.transport(), "a", logger).Start())
+	require.NoError(t, NewSyncer(nodeB, bus.transport(), "b", logger).Start())
+
+	require.NoError(t, nodeA.Register(schema.NewSchema("products", map[string]schema.Field{
+		"productCode": {Type: schema.TypeText},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})))
+
+	replicated, err := nodeB.Get("products")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), nodeB.Version("products"))
+
+	// Replicated schema must be fully usable, including naming resolution
+	column, _, err := replicated.ResolveField("productCode")
+	require.NoError(t, err)
+	assert.Equal(t, "product_code", column)
+
+	require.NoError(t, nodeA.Delete("products"))
+	assert.False(t, nodeB.Exists("products"))
+	assert.Equal(t, uint64(2), nodeB.Version("products"))
+}
+
+func TestSyncer_ReplacementOnOtherNode(t *testing.T) {
+	bus := &memoryBus{}
+	logger := newTestLogger(t)
+
+	nodeA := schema.NewRegistry()
+	nodeB := schema.NewRegistry()
+	require.NoError(t, NewSyncer(nodeA, bus.transport(), "a", logger).Start())
+	require.NoError(t, NewSyncer(nodeB, bus.transport(), "b", logger).Start())
+
+	require.NoError(t, nodeA.Register(schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})))
+	require.NoError(t, nodeB.Delete("products"))
+	require.NoError(t, nodeB.Register(schema.NewSchema("products", map[string]schema.Field{
+		"name":  {Type: schema.TypeText},
+		"price": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})))
+
+	replicated, err := nodeA.Get("products")
+	require.NoError(t, err)
+	assert.Contains(t, replicated.Fields, "price")
+	assert.Equal(t, nodeB.Version("products"), nodeA.Version("products"))
+}
+
+func TestSyncer_IgnoresStaleAndMalformedMessages(t *testing.T) {
+	bus := &memoryBus{}
+	registry := schema.NewRegistry()
+	require.NoError(t, NewSyncer(registry, bus.transport(), "a", newTestLogger(t)).Start())
+	require.NoError(t, registry.Register(schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})))
+	_, err := registry.RegisterOrReplace(schema.NewSchema("products", map[string]schema.Field{
+		"name":  {Type: schema.TypeText},
+		"price": {Type: schema.TypeText},
+	}, schema.SchemaOptions{}))
+	require.NoError(t, err)
+
+	publisher := bus.transport()
+	require.NoError(t, publisher.Publish([]byte(`{"node":"b","type":"registered","name":"products","version":1,"schema":{"name":"products","fields":{"other":{"type":"text"}}}}`)))
+	require.NoError(t, publisher.Publish([]byte(`not json`)))
+
+	current, err := registry.Get("products")
+	require.NoError(t, err)
+	assert.Contains(t, current.Fields, "price", "stale version must not overwrite newer local schema")
+}
+
+func TestSyncer_CatchesUpOnStart(t *testing.T) {
+	bus := &memoryBus{}
+	logger := newTestLogger(t)
+
+	nodeA := schema.NewRegistry()
+	require.NoError(t, NewSyncer(nodeA, bus.transport(), "a", logger).Start())
+	require.NoError(t, nodeA.Register(schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})))
+	require.NoError(t, nodeA.Register(schema.NewSchema("orders", map[string]schema.Field{
+		"total": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})))
+	require.NoError(t, nodeA.Delete("orders"))
+
+	// A replica starting later gets the changes it missed, deletions included
+	nodeB := schema.NewRegistry()
+	require.NoError(t, nodeB.Register(schema.NewSchema("orders", map[string]schema.Field{
+		"total": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})))
+	require.NoError(t, NewSyncer(nodeB, bus.transport(), "b", logger).Start())
+
+	assert.True(t, nodeB.Exists("products"))
+	assert.False(t, nodeB.Exists("orders"))
+	assert.Equal(t, stateOf(nodeA), stateOf(nodeB))
+}
+
+func TestSyncer_ConcurrentChangesConverge(t *testing.T) {
+	logger := newTestLogger(t)
+
+	// Both replicas make version 1 while they cannot reach each other
+	for _, startFirst := range []string{"a", "b"} {
+		t.Run(startFirst+" first", func(t *testing.T) {
+			bus := &memoryBus{}
+			nodes := map[string]*schema.Registry{"a": schema.NewRegistry(), "b": schema.NewRegistry()}
+			syncers := map[string]*Syncer{}
+			for id, registry := range nodes {
+				syncers[id] = NewSyncer(registry, bus.transport(), id, logger)
+				require.NoError(t, registry.Register(schema.NewSchema("products", map[string]schema.Field{
+					"name":       {Type: schema.TypeText},
+					"from_" + id: {Type: schema.TypeText},
+				}, schema.SchemaOptions{})))
+			}
+
+			require.NoError(t, syncers[startFirst].Start())
+			for id, syncer := range syncers {
+				if id != startFirst {
+					require.NoError(t, syncer.Start())
+				}
+			}
+
+			// The version made by the replica ordered last wins everywhere
+			for id, registry := range nodes {
+				current, err := registry.Get("products")
+				require.NoError(t, err)
+				assert.Contains(t, current.Fields, "from_b", "on %s", id)
+			}
+			assert.Equal(t, stateOf(nodes["a"]), stateOf(nodes["b"]))
+		})
+	}
+}
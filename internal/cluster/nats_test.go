@@ -0,0 +1,166 @@
+package cluster
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNATSServer implements enough of the NATS protocol to fan out PUB frames
+type fakeNATSServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	conns    map[net.Conn]bool
+	subs     map[net.Conn]string // conn -> sid
+}
+
+func startFakeNATSServer(t *testing.T) *fakeNATSServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &fakeNATSServer{listener: listener, conns: make(map[net.Conn]bool), subs: make(map[net.Conn]string)}
+	go s.accept()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *fakeNATSServer) addr() string {
+	return "nats://" + s.listener.Addr().String()
+}
+
+func (s *fakeNATSServer) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.serve(conn)
+	}
+}
+
+// drop closes every client connection, as a restarting server would
+func (s *fakeNATSServer) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func (s *fakeNATSServer) serve(conn net.Conn) {
+	s.mu.Lock()
+	s.conns[conn] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		delete(s.subs, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+	io.WriteString(conn, "INFO {\"server_id\":\"fake\",\"max_payload\":1048576}\r\n")
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		parts := strings.Fields(line)
+		if len(parts) == 0 {
+			continue
+		}
+		switch parts[0] {
+		case "PING":
+			s.mu.Lock()
+			io.WriteString(conn, "PONG\r\n")
+			s.mu.Unlock()
+		case "SUB":
+			s.mu.Lock()
+			s.subs[conn] = parts[2]
+			s.mu.Unlock()
+		case "PUB":
+			size, _ := strconv.Atoi(parts[2])
+			buf := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, buf); err != nil {
+				return
+			}
+			s.mu.Lock()
+			for sub, sid := range s.subs {
+				fmt.Fprintf(sub, "MSG %s %s %d\r\n%s\r\n", parts[1], sid, size, buf[:size])
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func TestNATSTransport_PublishSubscribe(t *testing.T) {
+	server := startFakeNATSServer(t)
+
+	subscriber, err := NewNATSTransport(server.addr(), "rsearch.schemas", time.Second)
+	require.NoError(t, err)
+	defer subscriber.Close()
+
+	received := make(chan []byte, 1)
+	require.NoError(t, subscriber.Subscribe(func(payload []byte) { received <- payload }))
+
+	publisher, err := NewNATSTransport(server.addr(), "rsearch.schemas", time.Second)
+	require.NoError(t, err)
+	defer publisher.Close()
+
+	// The subscription is registered asynchronously by the server; retry until delivered
+	deadline := time.After(2 * time.Second)
+	for {
+		require.NoError(t, publisher.Publish([]byte(`{"name":"products"}`)))
+		select {
+		case payload := <-received:
+			assert.Equal(t, `{"name":"products"}`, string(payload))
+			return
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("message was not delivered")
+		}
+	}
+}
+
+func TestNATSTransport_InvalidSubject(t *testing.T) {
+	_, err := NewNATSTransport("nats://127.0.0.1:1", "bad subject", time.Second)
+	assert.Error(t, err)
+}
+
+func TestNATSTransport_PublishAfterClose(t *testing.T) {
+	server := startFakeNATSServer(t)
+
+	transport, err := NewNATSTransport(server.addr(), "rsearch.schemas", time.Second)
+	require.NoError(t, err)
+	require.NoError(t, transport.Close())
+
+	assert.Error(t, transport.Publish([]byte("x")))
+}
+
+func TestNATSTransport_Reconnect(t *testing.T) {
+	server := startFakeNATSServer(t)
+
+	transport, err := NewNATSTransport(server.addr(), "rsearch.schemas", time.Second)
+	require.NoError(t, err)
+	defer transport.Close()
+
+	reconnected := make(chan struct{}, 1)
+	transport.OnReconnect(func() { reconnected <- struct{}{} })
+
+	server.drop()
+	select {
+	case <-reconnected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("transport did not reconnect")
+	}
+}
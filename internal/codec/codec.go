@@ -0,0 +1,90 @@
+// Package codec provides the built-in schema codecs, which encode the values queried on
+// fields whose columns store them encrypted (see schema.Field.Codec).
+package codec
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// Codec types accepted by New
+const (
+	TypeDeterministicAES = "deterministic-aes"
+)
+
+// DeterministicAESKeySize is the size of DeterministicAES keys: a 32-byte MAC key
+// followed by a 32-byte encryption key
+const DeterministicAESKeySize = 64
+
+// New creates a codec of the given type from its key
+func New(codecType string, key []byte) (schema.Codec, error) {
+	switch codecType {
+	case TypeDeterministicAES:
+		return NewDeterministicAES(key)
+	}
+	return nil, fmt.Errorf("unknown codec type %q: must be %s", codecType, TypeDeterministicAES)
+}
+
+// DeterministicAES encrypts values with AES-256-GCM under a synthetic nonce, the
+// HMAC-SHA256 of the value, in the manner of AES-SIV: equal values encrypt to equal
+// ciphertexts, so an encrypted column can be searched by equality, while the database
+// only learns which rows hold equal values. Encodings are base64 (standard, padded) of
+// the nonce followed by the ciphertext.
+//
+// Applications writing the column must encode values with the same codec and key.
+type DeterministicAES struct {
+	macKey []byte
+	aead   cipher.AEAD
+}
+
+// NewDeterministicAES creates a codec from a DeterministicAESKeySize-byte key
+func NewDeterministicAES(key []byte) (*DeterministicAES, error) {
+	if len(key) != DeterministicAESKeySize {
+		return nil, fmt.Errorf("deterministic-aes keys are %d bytes, got %d", DeterministicAESKeySize, len(key))
+	}
+	block, err := aes.NewCipher(key[32:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &DeterministicAES{macKey: append([]byte(nil), key[:32]...), aead: aead}, nil
+}
+
+// Encode encrypts a value
+func (c *DeterministicAES) Encode(value string) (string, error) {
+	nonce := c.nonce([]byte(value))
+	sealed := c.aead.Seal(nonce, nonce, []byte(value), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decode decrypts an encoded value, checking that it was encrypted under the codec's key
+func (c *DeterministicAES) Decode(encoded string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < c.aead.NonceSize()+c.aead.Overhead() {
+		return "", errors.New("invalid deterministic-aes encoding")
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil || subtle.ConstantTimeCompare(nonce, c.nonce(plain)) != 1 {
+		return "", errors.New("invalid deterministic-aes encoding")
+	}
+	return string(plain), nil
+}
+
+// nonce derives the nonce of a value
+func (c *DeterministicAES) nonce(value []byte) []byte {
+	mac := hmac.New(sha256.New, c.macKey)
+	mac.Write(value)
+	return mac.Sum(nil)[:c.aead.NonceSize()]
+}
@@ -0,0 +1,55 @@
+package codec
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, DeterministicAESKeySize)
+}
+
+func TestDeterministicAES(t *testing.T) {
+	c, err := NewDeterministicAES(testKey(1))
+	require.NoError(t, err)
+
+	encoded, err := c.Encode("123-45-6789")
+	require.NoError(t, err)
+	again, err := c.Encode("123-45-6789")
+	require.NoError(t, err)
+	other, err := c.Encode("123-45-6780")
+	require.NoError(t, err)
+
+	assert.Equal(t, encoded, again, "equal values encrypt equally")
+	assert.NotEqual(t, encoded, other)
+	assert.NotContains(t, encoded, "6789")
+
+	decoded, err := c.Decode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, "123-45-6789", decoded)
+
+	// Another key neither encodes equally nor decodes
+	otherKey, err := NewDeterministicAES(testKey(2))
+	require.NoError(t, err)
+	encodedOther, _ := otherKey.Encode("123-45-6789")
+	assert.NotEqual(t, encoded, encodedOther)
+	_, err = otherKey.Decode(encoded)
+	assert.Error(t, err)
+
+	_, err = c.Decode("not base64!")
+	assert.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(TypeDeterministicAES, testKey(1))
+	assert.NoError(t, err)
+
+	_, err = New(TypeDeterministicAES, []byte("short"))
+	assert.EqualError(t, err, "deterministic-aes keys are 64 bytes, got 5")
+
+	_, err = New("rot13", testKey(1))
+	assert.Error(t, err)
+}
@@ -1,25 +1,38 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/infiniv/rsearch/internal/chaos"
+	"github.com/infiniv/rsearch/internal/codec"
+	"github.com/infiniv/rsearch/internal/dictionary"
+	"github.com/infiniv/rsearch/internal/schema"
 	"github.com/spf13/viper"
 )
 
 // Config holds the complete application configuration
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Logging  LoggingConfig  `mapstructure:"logging"`
-	Metrics  MetricsConfig  `mapstructure:"metrics"`
-	CORS     CORSConfig     `mapstructure:"cors"`
-	Schemas  SchemasConfig  `mapstructure:"schemas"`
-	Limits   LimitsConfig   `mapstructure:"limits"`
-	Cache    CacheConfig    `mapstructure:"cache"`
-	Security SecurityConfig `mapstructure:"security"`
-	Features FeaturesConfig `mapstructure:"features"`
-	API      APIConfig      `mapstructure:"api"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Logging   LoggingConfig   `mapstructure:"logging"`
+	Metrics   MetricsConfig   `mapstructure:"metrics"`
+	CORS      CORSConfig      `mapstructure:"cors"`
+	Schemas   SchemasConfig   `mapstructure:"schemas"`
+	Limits    LimitsConfig    `mapstructure:"limits"`
+	Cache     CacheConfig     `mapstructure:"cache"`
+	Security  SecurityConfig  `mapstructure:"security"`
+	Features  FeaturesConfig  `mapstructure:"features"`
+	API       APIConfig       `mapstructure:"api"`
+	Cluster   ClusterConfig   `mapstructure:"cluster"`
+	Analytics AnalyticsConfig `mapstructure:"analytics"`
+	History   HistoryConfig   `mapstructure:"history"`
+	Events    EventsConfig    `mapstructure:"events"`
+	Chaos     ChaosConfig     `mapstructure:"chaos"`
+	Executor  ExecutorConfig  `mapstructure:"executor"`
+	Shadow    ShadowConfig    `mapstructure:"shadow"`
 }
 
 // ServerConfig holds server configuration
@@ -36,13 +49,31 @@ type LoggingConfig struct {
 	Level  string `mapstructure:"level"`
 	Format string `mapstructure:"format"`
 	Output string `mapstructure:"output"`
+
+	// SlowTranslationThreshold logs parse+translate calls taking at least this long; 0 disables
+	SlowTranslationThreshold time.Duration `mapstructure:"slowTranslationThreshold"`
+
+	// Queries selects how query strings are logged: none, hash, redacted (values replaced
+	// by ?) or full. Only use full where logs may hold the personal data queries contain.
+	Queries string `mapstructure:"queries"`
 }
 
 // MetricsConfig holds metrics configuration
 type MetricsConfig struct {
-	Enabled bool   `mapstructure:"enabled"`
-	Port    int    `mapstructure:"port"`
-	Path    string `mapstructure:"path"`
+	Enabled bool        `mapstructure:"enabled"`
+	Port    int         `mapstructure:"port"`
+	Path    string      `mapstructure:"path"`
+	Debug   DebugConfig `mapstructure:"debug"`
+}
+
+// DebugConfig serves pprof profiles and runtime statistics under /debug/ on the metrics
+// server, so production hotspots can be profiled without a special build
+type DebugConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// APIKeys (sent as X-API-Key) may read the endpoints; at least one is required, as
+	// profiles expose the process's internals
+	APIKeys []string `mapstructure:"apiKeys"`
 }
 
 // CORSConfig holds CORS configuration
@@ -56,19 +87,73 @@ type CORSConfig struct {
 type SchemasConfig struct {
 	LoadFromFiles bool   `mapstructure:"loadFromFiles"`
 	Directory     string `mapstructure:"directory"`
+
+	// Dictionaries list the values of schema fields naming them, such as country codes
+	Dictionaries []DictionaryConfig `mapstructure:"dictionaries"`
+}
+
+// DictionaryConfig defines a dictionary schema fields can name in their dictionary option,
+// read from either a file or a URL: a JSON array of strings, or one value per line
+type DictionaryConfig struct {
+	Name    string        `mapstructure:"name"`
+	File    string        `mapstructure:"file"`
+	URL     string        `mapstructure:"url"`
+	Refresh time.Duration `mapstructure:"refresh"` // reload interval; 0 loads once
+	Timeout time.Duration `mapstructure:"timeout"` // bounds each fetch of a URL
+}
+
+// NewDictionary creates the dictionary
+func (c DictionaryConfig) NewDictionary() schema.Dictionary {
+	if c.URL != "" {
+		return dictionary.NewHTTP(c.Name, c.URL, c.Refresh, c.Timeout, nil)
+	}
+	return dictionary.NewFile(c.Name, c.File, c.Refresh)
 }
 
 // LimitsConfig holds various limits
 type LimitsConfig struct {
-	MaxQueryLength     int             `mapstructure:"maxQueryLength"`
-	MaxParameterCount  int             `mapstructure:"maxParameterCount"`
-	MaxParseDepth      int             `mapstructure:"maxParseDepth"`
-	MaxSchemaFields    int             `mapstructure:"maxSchemaFields"`
-	MaxFieldNameLength int             `mapstructure:"maxFieldNameLength"`
-	MaxSchemas         int             `mapstructure:"maxSchemas"`
-	MaxRequestBodySize int64           `mapstructure:"maxRequestBodySize"`
-	RequestTimeout     time.Duration   `mapstructure:"requestTimeout"`
-	RateLimit          RateLimitConfig `mapstructure:"rateLimit"`
+	MaxQueryLength     int               `mapstructure:"maxQueryLength"`
+	MaxParameterCount  int               `mapstructure:"maxParameterCount"`
+	MaxParseDepth      int               `mapstructure:"maxParseDepth"`
+	MaxTokens          int               `mapstructure:"maxTokens"`
+	MaxLiteralBytes    int               `mapstructure:"maxLiteralBytes"`
+	MaxOutputBytes     int               `mapstructure:"maxOutputBytes"`
+	MaxSchemaFields    int               `mapstructure:"maxSchemaFields"`
+	MaxFieldNameLength int               `mapstructure:"maxFieldNameLength"`
+	MaxSchemas         int               `mapstructure:"maxSchemas"`
+	MaxRequestBodySize int64             `mapstructure:"maxRequestBodySize"`
+	RequestTimeout     time.Duration     `mapstructure:"requestTimeout"`
+	RateLimit          RateLimitConfig   `mapstructure:"rateLimit"`
+	Concurrency        ConcurrencyConfig `mapstructure:"concurrency"`
+	Quota              QuotaConfig       `mapstructure:"quota"`
+}
+
+// QuotaConfig sets daily quotas on the queries each API key (sent as X-API-Key)
+// translates and on their cumulative complexity, in AST nodes. Keys over either quota
+// are rejected with 429 until midnight UTC. Only keys listed here or in
+// security.auth.apiKeys are counted on their own; requests with any other key or none
+// share one anonymous quota under the defaults. Zero quotas are unlimited.
+type QuotaConfig struct {
+	Enabled          bool             `mapstructure:"enabled"`
+	QueriesPerDay    int              `mapstructure:"queriesPerDay"`
+	ComplexityPerDay int              `mapstructure:"complexityPerDay"`
+	Keys             []KeyQuotaConfig `mapstructure:"keys"` // quotas replacing the defaults for specific keys
+}
+
+// KeyQuotaConfig sets the quotas of one API key
+type KeyQuotaConfig struct {
+	Key              string `mapstructure:"key"`
+	QueriesPerDay    int    `mapstructure:"queriesPerDay"`
+	ComplexityPerDay int    `mapstructure:"complexityPerDay"`
+}
+
+// ConcurrencyConfig bounds the translations processed at once. Translations over
+// MaxInFlight wait for a free slot, up to MaxQueue of them for at most QueueTimeout,
+// and are rejected with 503 beyond that. A MaxInFlight of 0 disables the limit.
+type ConcurrencyConfig struct {
+	MaxInFlight  int           `mapstructure:"maxInFlight"`
+	MaxQueue     int           `mapstructure:"maxQueue"`
+	QueueTimeout time.Duration `mapstructure:"queueTimeout"`
 }
 
 // RateLimitConfig holds rate limiting configuration
@@ -84,6 +169,18 @@ type CacheConfig struct {
 	Enabled bool `mapstructure:"enabled"`
 	MaxSize int  `mapstructure:"maxSize"`
 	TTL     int  `mapstructure:"ttl"`
+
+	// Persist saves the queries of cached translations to disk, so that a restarted
+	// instance translates them again as their schemas are registered
+	Persist CachePersistConfig `mapstructure:"persist"`
+}
+
+// CachePersistConfig configures the file the plan cache is saved to. The file holds
+// queries as clients sent them, so it should be protected like the logs. Queries are
+// only saved when logging.queries is full, and never those of fields with a codec or hash.
+type CachePersistConfig struct {
+	Path     string        `mapstructure:"path"`     // empty disables persistence
+	Interval time.Duration `mapstructure:"interval"` // between saves; the cache is also saved at shutdown
 }
 
 // SecurityConfig holds security configuration
@@ -91,6 +188,52 @@ type SecurityConfig struct {
 	AllowedSpecialChars string     `mapstructure:"allowedSpecialChars"`
 	BlockSqlKeywords    bool       `mapstructure:"blockSqlKeywords"`
 	Auth                AuthConfig `mapstructure:"auth"`
+
+	// DefaultFilterBypassKeys are API keys (sent as X-API-Key) allowed to skip schema default filters
+	DefaultFilterBypassKeys []string `mapstructure:"defaultFilterBypassKeys"`
+
+	// Codecs encode the values of schema fields naming them, for encrypted columns
+	Codecs []CodecConfig `mapstructure:"codecs"`
+
+	// HashKeys are the secret keys hashed schema fields name, for columns storing HMACs
+	HashKeys []HashKeyConfig `mapstructure:"hashKeys"`
+}
+
+// CodecConfig defines a codec schema fields can name in their codec option
+type CodecConfig struct {
+	Name string `mapstructure:"name"`
+	Type string `mapstructure:"type"` // deterministic-aes
+	Key  string `mapstructure:"key"`  // base64 key; prefer setting it from a secret
+}
+
+// NewCodec creates the codec
+func (c CodecConfig) NewCodec() (schema.Codec, error) {
+	key, err := base64.StdEncoding.DecodeString(c.Key)
+	if err != nil {
+		return nil, fmt.Errorf("key is not valid base64")
+	}
+	return codec.New(c.Type, key)
+}
+
+// HashKeyConfig defines a secret key hashed schema fields can name in their hash option
+type HashKeyConfig struct {
+	Name string `mapstructure:"name"`
+	Key  string `mapstructure:"key"` // base64 key; prefer setting it from a secret
+}
+
+// minHashKeySize is the smallest hash key accepted, in bytes
+const minHashKeySize = 32
+
+// Secret decodes the key
+func (c HashKeyConfig) Secret() ([]byte, error) {
+	secret, err := base64.StdEncoding.DecodeString(c.Key)
+	if err != nil {
+		return nil, fmt.Errorf("key is not valid base64")
+	}
+	if len(secret) < minHashKeySize {
+		return nil, fmt.Errorf("key must be at least %d bytes", minHashKeySize)
+	}
+	return secret, nil
 }
 
 // AuthConfig holds authentication configuration
@@ -102,14 +245,42 @@ type AuthConfig struct {
 
 // FeaturesConfig holds feature flags
 type FeaturesConfig struct {
-	QuerySuggestions bool   `mapstructure:"querySuggestions"`
-	MaxQueryLength   int    `mapstructure:"maxQueryLength"`
-	RequestIDHeader  string `mapstructure:"requestIdHeader"`
+	QuerySuggestions bool                `mapstructure:"querySuggestions"`
+	MaxQueryLength   int                 `mapstructure:"maxQueryLength"`
+	RequestIDHeader  string              `mapstructure:"requestIdHeader"`
+	Query            QueryFeaturesConfig `mapstructure:"query"`
+}
+
+// QueryFeaturesConfig holds deployment-wide query capability switches.
+// A disabled capability is rejected for every schema, regardless of schema flags.
+type QueryFeaturesConfig struct {
+	Regex           bool `mapstructure:"regex"`
+	Fuzzy           bool `mapstructure:"fuzzy"`
+	Proximity       bool `mapstructure:"proximity"`
+	LeadingWildcard bool `mapstructure:"leadingWildcard"`
+	Exists          bool `mapstructure:"exists"`
 }
 
 // APIConfig holds API configuration
 type APIConfig struct {
 	Versions map[string]APIVersionConfig `mapstructure:"versions"`
+
+	// GetTranslate configures GET /api/v1/translate, which takes the query in the URL so
+	// caches and CDNs in front of public, read-only search widgets can keep translations
+	GetTranslate GetTranslateConfig `mapstructure:"getTranslate"`
+}
+
+// GetTranslateConfig configures translation requests sent with GET
+type GetTranslateConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// MaxQueryStringLength caps the URL query string in bytes, as sent, encoded; longer
+	// ones are rejected rather than risking truncation by proxies and CDNs
+	MaxQueryStringLength int `mapstructure:"maxQueryStringLength"`
+
+	// CacheMaxAge is how long caches may keep successful translations; 0 lets them store
+	// translations but revalidate each use with the ETag
+	CacheMaxAge time.Duration `mapstructure:"cacheMaxAge"`
 }
 
 // APIVersionConfig holds configuration for a specific API version
@@ -118,6 +289,104 @@ type APIVersionConfig struct {
 	Deprecated bool `mapstructure:"deprecated"`
 }
 
+// ClusterConfig holds multi-replica schema synchronization configuration
+type ClusterConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	NodeID         string        `mapstructure:"nodeId"` // defaults to a random ID per process
+	NATSURL        string        `mapstructure:"natsUrl"`
+	Subject        string        `mapstructure:"subject"`
+	ConnectTimeout time.Duration `mapstructure:"connectTimeout"`
+}
+
+// AnalyticsConfig holds query usage analytics configuration
+type AnalyticsConfig struct {
+	Enabled          bool          `mapstructure:"enabled"`
+	SnapshotInterval time.Duration `mapstructure:"snapshotInterval"`
+}
+
+// HistoryConfig keeps the last translate requests of each schema in memory, listed and
+// replayed through the /api/v1/admin/history endpoints for debugging
+type HistoryConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	Size    int  `mapstructure:"size"` // requests kept per schema
+
+	// APIKeys (sent as X-API-Key) may use the endpoints; at least one is required, as
+	// the history holds the queries clients sent
+	APIKeys []string `mapstructure:"apiKeys"`
+}
+
+// ChaosConfig injects faults into the request pipeline, for resilience testing of the
+// services calling rsearch. Never enable it in production.
+type ChaosConfig struct {
+	Enabled bool                        `mapstructure:"enabled"`
+	Seed    int64                       `mapstructure:"seed"`   // seeds the fault draws, for repeatable runs
+	Stages  map[string]ChaosFaultConfig `mapstructure:"stages"` // request, parse, translate or response
+}
+
+// ChaosFaultConfig holds the faults injected at a pipeline stage; rates are between 0 and 1
+type ChaosFaultConfig struct {
+	DelayRate     float64       `mapstructure:"delayRate"`
+	Delay         time.Duration `mapstructure:"delay"`
+	ErrorRate     float64       `mapstructure:"errorRate"`
+	ErrorStatus   int           `mapstructure:"errorStatus"`   // 500 when 0
+	MalformedRate float64       `mapstructure:"malformedRate"` // response stage only
+}
+
+// Faults returns the faults per stage, for chaos.New
+func (c ChaosConfig) Faults() map[string]chaos.Fault {
+	faults := make(map[string]chaos.Fault, len(c.Stages))
+	for stage, f := range c.Stages {
+		faults[stage] = chaos.Fault(f)
+	}
+	return faults
+}
+
+// EventsConfig holds schema change notification configuration
+type EventsConfig struct {
+	Enabled     bool            `mapstructure:"enabled"`
+	Webhooks    []WebhookConfig `mapstructure:"webhooks"`
+	NATSSubject string          `mapstructure:"natsSubject"` // publish to cluster.natsUrl when set
+	Timeout     time.Duration   `mapstructure:"timeout"`     // per delivery attempt
+	MaxRetries  int             `mapstructure:"maxRetries"`
+	QueueSize   int             `mapstructure:"queueSize"` // undelivered events kept before dropping
+}
+
+// WebhookConfig is a webhook receiving schema change events
+type WebhookConfig struct {
+	URL    string `mapstructure:"url"`
+	Secret string `mapstructure:"secret"` // optional HMAC-SHA256 signing key
+}
+
+// ExecutorConfig holds the database translations are run on, for the features that need
+// the data itself: shadow comparisons and query plans. rsearch only translates unless it
+// is enabled.
+type ExecutorConfig struct {
+	Enabled  bool              `mapstructure:"enabled"`
+	Database string            `mapstructure:"database"` // dialect run: postgres, mysql, sqlite or mongodb
+	DSN      string            `mapstructure:"dsn"`      // connection string; for mongodb a URI naming the database
+	Tables   map[string]string `mapstructure:"tables"`   // schema name -> table or collection; default the schema name
+	Timeout  time.Duration     `mapstructure:"timeout"`  // bounds each query plan
+}
+
+// ShadowConfig holds the shadow comparison of translations with OpenSearch, for
+// migrations: translations to the executor's database are counted on it and compared
+// with the count OpenSearch returns for the same query
+type ShadowConfig struct {
+	Enabled    bool             `mapstructure:"enabled"`
+	OpenSearch OpenSearchConfig `mapstructure:"opensearch"` // cluster compared with
+	SampleRate float64          `mapstructure:"sampleRate"` // fraction of translations compared, from 0 to 1
+	Timeout    time.Duration    `mapstructure:"timeout"`    // per comparison, both counts included
+	QueueSize  int              `mapstructure:"queueSize"`  // comparisons waiting before new ones are dropped
+}
+
+// OpenSearchConfig is an OpenSearch or Elasticsearch cluster
+type OpenSearchConfig struct {
+	URL      string            `mapstructure:"url"`
+	Indices  map[string]string `mapstructure:"indices"` // schema name -> index or alias; default the schema name
+	Username string            `mapstructure:"username"`
+	Password string            `mapstructure:"password"`
+}
+
 // Load loads configuration from file and environment variables
 func Load(configPath string) (*Config, error) {
 	v := viper.New()
@@ -177,11 +446,15 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("logging.level", "info")
 	v.SetDefault("logging.format", "json")
 	v.SetDefault("logging.output", "stdout")
+	v.SetDefault("logging.slowTranslationThreshold", "100ms")
+	v.SetDefault("logging.queries", "hash")
 
 	// Metrics defaults
 	v.SetDefault("metrics.enabled", false)
 	v.SetDefault("metrics.port", 9090)
 	v.SetDefault("metrics.path", "/metrics")
+	v.SetDefault("metrics.debug.enabled", false)
+	v.SetDefault("metrics.debug.apiKeys", []string{})
 
 	// CORS defaults
 	v.SetDefault("cors.enabled", false)
@@ -191,11 +464,15 @@ func setDefaults(v *viper.Viper) {
 	// Schemas defaults
 	v.SetDefault("schemas.loadFromFiles", false)
 	v.SetDefault("schemas.directory", "./schemas")
+	v.SetDefault("schemas.dictionaries", []DictionaryConfig{})
 
 	// Limits defaults
 	v.SetDefault("limits.maxQueryLength", 10000)
 	v.SetDefault("limits.maxParameterCount", 100)
 	v.SetDefault("limits.maxParseDepth", 50)
+	v.SetDefault("limits.maxTokens", 10000)
+	v.SetDefault("limits.maxLiteralBytes", 65536)
+	v.SetDefault("limits.maxOutputBytes", 1048576)
 	v.SetDefault("limits.maxSchemaFields", 1000)
 	v.SetDefault("limits.maxFieldNameLength", 255)
 	v.SetDefault("limits.maxSchemas", 100)
@@ -205,11 +482,19 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("limits.rateLimit.requestsPerMinute", 100)
 	v.SetDefault("limits.rateLimit.requestsPerHour", 5000)
 	v.SetDefault("limits.rateLimit.burst", 10)
+	v.SetDefault("limits.concurrency.maxInFlight", 0)
+	v.SetDefault("limits.concurrency.maxQueue", 100)
+	v.SetDefault("limits.concurrency.queueTimeout", "1s")
+	v.SetDefault("limits.quota.enabled", false)
+	v.SetDefault("limits.quota.queriesPerDay", 0)
+	v.SetDefault("limits.quota.complexityPerDay", 0)
 
 	// Cache defaults
 	v.SetDefault("cache.enabled", true)
 	v.SetDefault("cache.maxSize", 10000)
 	v.SetDefault("cache.ttl", 3600)
+	v.SetDefault("cache.persist.path", "")
+	v.SetDefault("cache.persist.interval", "5m")
 
 	// Security defaults
 	v.SetDefault("security.allowedSpecialChars", ".-_")
@@ -217,15 +502,63 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("security.auth.enabled", false)
 	v.SetDefault("security.auth.type", "apikey")
 	v.SetDefault("security.auth.apiKeys", []string{})
+	v.SetDefault("security.defaultFilterBypassKeys", []string{})
+	v.SetDefault("security.codecs", []CodecConfig{})
+	v.SetDefault("security.hashKeys", []HashKeyConfig{})
 
 	// Features defaults
 	v.SetDefault("features.querySuggestions", false)
 	v.SetDefault("features.maxQueryLength", 1000)
 	v.SetDefault("features.requestIdHeader", "X-Request-ID")
+	v.SetDefault("features.query.regex", true)
+	v.SetDefault("features.query.fuzzy", true)
+	v.SetDefault("features.query.proximity", true)
+	v.SetDefault("features.query.leadingWildcard", true)
+	v.SetDefault("features.query.exists", true)
 
 	// API defaults
 	v.SetDefault("api.versions.v1.enabled", true)
 	v.SetDefault("api.versions.v1.deprecated", false)
+	v.SetDefault("api.getTranslate.enabled", true)
+	v.SetDefault("api.getTranslate.maxQueryStringLength", 4096)
+	v.SetDefault("api.getTranslate.cacheMaxAge", "60s")
+
+	// Cluster defaults
+	v.SetDefault("cluster.enabled", false)
+	v.SetDefault("cluster.nodeId", "")
+	v.SetDefault("cluster.natsUrl", "nats://localhost:4222")
+	v.SetDefault("cluster.subject", "rsearch.schemas")
+	v.SetDefault("cluster.connectTimeout", "5s")
+
+	// Analytics defaults
+	v.SetDefault("analytics.enabled", true)
+	v.SetDefault("analytics.snapshotInterval", "10s")
+
+	// History defaults
+	v.SetDefault("history.enabled", false)
+	v.SetDefault("history.size", 100)
+	v.SetDefault("history.apiKeys", []string{})
+
+	// Chaos defaults
+	v.SetDefault("chaos.enabled", false)
+	v.SetDefault("chaos.seed", 0)
+
+	// Events defaults
+	v.SetDefault("events.enabled", false)
+	v.SetDefault("events.natsSubject", "")
+	v.SetDefault("events.timeout", "5s")
+	v.SetDefault("events.maxRetries", 3)
+	v.SetDefault("events.queueSize", 1000)
+
+	// Executor defaults
+	v.SetDefault("executor.enabled", false)
+	v.SetDefault("executor.timeout", "5s")
+
+	// Shadow defaults
+	v.SetDefault("shadow.enabled", false)
+	v.SetDefault("shadow.sampleRate", 1.0)
+	v.SetDefault("shadow.timeout", "5s")
+	v.SetDefault("shadow.queueSize", 100)
 }
 
 // validate validates the configuration
@@ -246,6 +579,15 @@ func validate(cfg *Config) error {
 		return fmt.Errorf("invalid log format: %s (must be json or console)", cfg.Logging.Format)
 	}
 
+	if cfg.Logging.SlowTranslationThreshold < 0 {
+		return fmt.Errorf("slowTranslationThreshold cannot be negative")
+	}
+
+	validQueryModes := map[string]bool{"none": true, "hash": true, "redacted": true, "full": true}
+	if !validQueryModes[cfg.Logging.Queries] {
+		return fmt.Errorf("invalid query logging mode: %s (must be none, hash, redacted, or full)", cfg.Logging.Queries)
+	}
+
 	// Metrics validation
 	if cfg.Metrics.Enabled {
 		if cfg.Metrics.Port < 1 || cfg.Metrics.Port > 65535 {
@@ -255,6 +597,24 @@ func validate(cfg *Config) error {
 			return fmt.Errorf("metrics path cannot be empty when metrics are enabled")
 		}
 	}
+	if cfg.Metrics.Debug.Enabled {
+		if !cfg.Metrics.Enabled {
+			return fmt.Errorf("metrics.debug requires metrics to be enabled, as it is served on the metrics server")
+		}
+		if len(cfg.Metrics.Debug.APIKeys) == 0 {
+			return fmt.Errorf("metrics.debug requires at least one API key")
+		}
+	}
+
+	// API validation
+	if cfg.API.GetTranslate.MaxQueryStringLength < 0 || cfg.API.GetTranslate.CacheMaxAge < 0 {
+		return fmt.Errorf("api.getTranslate maxQueryStringLength and cacheMaxAge cannot be negative")
+	}
+
+	// Cache validation
+	if cfg.Cache.Persist.Path != "" && cfg.Cache.Persist.Interval <= 0 {
+		return fmt.Errorf("cache.persist.interval must be positive when a path is set")
+	}
 
 	// Limits validation
 	if cfg.Limits.MaxQueryLength < 0 {
@@ -263,6 +623,193 @@ func validate(cfg *Config) error {
 	if cfg.Limits.MaxParameterCount < 1 {
 		return fmt.Errorf("maxParameterCount must be at least 1")
 	}
+	if cfg.Limits.MaxTokens < 0 {
+		return fmt.Errorf("maxTokens cannot be negative")
+	}
+	if cfg.Limits.MaxLiteralBytes < 0 {
+		return fmt.Errorf("maxLiteralBytes cannot be negative")
+	}
+	if cfg.Limits.MaxOutputBytes < 0 {
+		return fmt.Errorf("maxOutputBytes cannot be negative")
+	}
+	if cfg.Limits.Concurrency.MaxInFlight < 0 || cfg.Limits.Concurrency.MaxQueue < 0 || cfg.Limits.Concurrency.QueueTimeout < 0 {
+		return fmt.Errorf("concurrency maxInFlight, maxQueue and queueTimeout cannot be negative")
+	}
+
+	quota := cfg.Limits.Quota
+	if quota.QueriesPerDay < 0 || quota.ComplexityPerDay < 0 {
+		return fmt.Errorf("quota queriesPerDay and complexityPerDay cannot be negative")
+	}
+	seenKeys := make(map[string]bool, len(quota.Keys))
+	for i, key := range quota.Keys {
+		if key.Key == "" {
+			return fmt.Errorf("quota keys[%d]: key cannot be empty", i)
+		}
+		if seenKeys[key.Key] {
+			return fmt.Errorf("quota keys[%d]: key is listed more than once", i)
+		}
+		seenKeys[key.Key] = true
+		if key.QueriesPerDay < 0 || key.ComplexityPerDay < 0 {
+			return fmt.Errorf("quota keys[%d]: queriesPerDay and complexityPerDay cannot be negative", i)
+		}
+	}
+
+	// Dictionary validation
+	seenDictionaries := make(map[string]bool, len(cfg.Schemas.Dictionaries))
+	for i, d := range cfg.Schemas.Dictionaries {
+		if d.Name == "" {
+			return fmt.Errorf("schemas dictionaries[%d]: name cannot be empty", i)
+		}
+		if seenDictionaries[d.Name] {
+			return fmt.Errorf("schemas dictionaries[%d]: dictionary %q is defined more than once", i, d.Name)
+		}
+		seenDictionaries[d.Name] = true
+		if (d.File == "") == (d.URL == "") {
+			return fmt.Errorf("schemas dictionaries[%d]: set either file or url", i)
+		}
+		if d.URL != "" && !strings.HasPrefix(d.URL, "http://") && !strings.HasPrefix(d.URL, "https://") {
+			return fmt.Errorf("schemas dictionaries[%d]: url must be http or https", i)
+		}
+		if d.Refresh < 0 || d.Timeout < 0 {
+			return fmt.Errorf("schemas dictionaries[%d]: refresh and timeout cannot be negative", i)
+		}
+	}
+
+	// Codec validation
+	seenCodecs := make(map[string]bool, len(cfg.Security.Codecs))
+	for i, c := range cfg.Security.Codecs {
+		if c.Name == "" {
+			return fmt.Errorf("security codecs[%d]: name cannot be empty", i)
+		}
+		if seenCodecs[c.Name] {
+			return fmt.Errorf("security codecs[%d]: codec %q is defined more than once", i, c.Name)
+		}
+		seenCodecs[c.Name] = true
+		if _, err := c.NewCodec(); err != nil {
+			return fmt.Errorf("security codecs[%d]: %w", i, err)
+		}
+	}
+
+	// Hash key validation
+	seenHashKeys := make(map[string]bool, len(cfg.Security.HashKeys))
+	for i, k := range cfg.Security.HashKeys {
+		if k.Name == "" {
+			return fmt.Errorf("security hashKeys[%d]: name cannot be empty", i)
+		}
+		if seenHashKeys[k.Name] {
+			return fmt.Errorf("security hashKeys[%d]: hash key %q is defined more than once", i, k.Name)
+		}
+		seenHashKeys[k.Name] = true
+		if _, err := k.Secret(); err != nil {
+			return fmt.Errorf("security hashKeys[%d]: %w", i, err)
+		}
+	}
+
+	// Analytics validation
+	if cfg.Analytics.Enabled && cfg.Analytics.SnapshotInterval <= 0 {
+		return fmt.Errorf("analytics snapshotInterval must be positive when analytics are enabled")
+	}
+
+	// History validation
+	if cfg.History.Enabled {
+		if cfg.History.Size < 1 {
+			return fmt.Errorf("history size must be positive when history is enabled")
+		}
+		if len(cfg.History.APIKeys) == 0 {
+			return fmt.Errorf("history requires at least one API key")
+		}
+	}
+
+	// Chaos validation
+	if cfg.Chaos.Enabled {
+		for stage, f := range cfg.Chaos.Stages {
+			if !slices.Contains(chaos.Stages, stage) {
+				return fmt.Errorf("chaos stage %q is unknown: must be one of: %s", stage, strings.Join(chaos.Stages, ", "))
+			}
+			for _, rate := range []float64{f.DelayRate, f.ErrorRate, f.MalformedRate} {
+				if rate < 0 || rate > 1 {
+					return fmt.Errorf("chaos stage %s: rates must be between 0 and 1", stage)
+				}
+			}
+			if f.Delay < 0 {
+				return fmt.Errorf("chaos stage %s: delay cannot be negative", stage)
+			}
+			if f.ErrorStatus != 0 && (f.ErrorStatus < 400 || f.ErrorStatus > 599) {
+				return fmt.Errorf("chaos stage %s: errorStatus must be a 4xx or 5xx status", stage)
+			}
+			if f.MalformedRate > 0 && stage != chaos.StageResponse {
+				return fmt.Errorf("chaos stage %s: malformedRate applies to the response stage only", stage)
+			}
+		}
+	}
+
+	// Cluster validation
+	if cfg.Cluster.Enabled {
+		if cfg.Cluster.NATSURL == "" {
+			return fmt.Errorf("cluster natsUrl cannot be empty when cluster sync is enabled")
+		}
+		if cfg.Cluster.Subject == "" {
+			return fmt.Errorf("cluster subject cannot be empty when cluster sync is enabled")
+		}
+	}
+
+	// Events validation
+	if cfg.Events.Enabled {
+		if len(cfg.Events.Webhooks) == 0 && cfg.Events.NATSSubject == "" {
+			return fmt.Errorf("events require at least one webhook or a natsSubject when enabled")
+		}
+		for _, webhook := range cfg.Events.Webhooks {
+			if !strings.HasPrefix(webhook.URL, "http://") && !strings.HasPrefix(webhook.URL, "https://") {
+				return fmt.Errorf("invalid webhook URL %q: must start with http:// or https://", webhook.URL)
+			}
+		}
+		if cfg.Events.NATSSubject != "" && cfg.Cluster.NATSURL == "" {
+			return fmt.Errorf("cluster natsUrl cannot be empty when events natsSubject is set")
+		}
+		if cfg.Events.Timeout <= 0 {
+			return fmt.Errorf("events timeout must be positive when events are enabled")
+		}
+		if cfg.Events.MaxRetries < 0 {
+			return fmt.Errorf("events maxRetries cannot be negative")
+		}
+		if cfg.Events.QueueSize < 1 {
+			return fmt.Errorf("events queueSize must be at least 1")
+		}
+	}
+
+	// Executor validation
+	if cfg.Executor.Enabled {
+		switch cfg.Executor.Database {
+		case "postgres", "mysql", "sqlite", "mongodb":
+		default:
+			return fmt.Errorf("invalid executor database %q: must be one of: postgres, mysql, sqlite, mongodb", cfg.Executor.Database)
+		}
+		if cfg.Executor.DSN == "" {
+			return fmt.Errorf("executor dsn cannot be empty when the executor is enabled")
+		}
+		if cfg.Executor.Timeout <= 0 {
+			return fmt.Errorf("executor timeout must be positive when the executor is enabled")
+		}
+	}
+
+	// Shadow validation
+	if cfg.Shadow.Enabled {
+		if !cfg.Executor.Enabled {
+			return fmt.Errorf("shadow comparison requires the executor to be enabled")
+		}
+		if !strings.HasPrefix(cfg.Shadow.OpenSearch.URL, "http://") && !strings.HasPrefix(cfg.Shadow.OpenSearch.URL, "https://") {
+			return fmt.Errorf("invalid shadow opensearch URL %q: must start with http:// or https://", cfg.Shadow.OpenSearch.URL)
+		}
+		if cfg.Shadow.SampleRate <= 0 || cfg.Shadow.SampleRate > 1 {
+			return fmt.Errorf("shadow sampleRate must be greater than 0 and at most 1")
+		}
+		if cfg.Shadow.Timeout <= 0 {
+			return fmt.Errorf("shadow timeout must be positive when shadow comparison is enabled")
+		}
+		if cfg.Shadow.QueueSize < 1 {
+			return fmt.Errorf("shadow queueSize must be at least 1")
+		}
+	}
 
 	return nil
 }
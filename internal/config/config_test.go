@@ -1,7 +1,9 @@
 package config
 
 import (
+	"encoding/base64"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -37,6 +39,52 @@ func TestLoadDefaultConfig(t *testing.T) {
 	if cfg.Limits.MaxQueryLength != 10000 {
 		t.Errorf("Expected default max query length 10000, got %d", cfg.Limits.MaxQueryLength)
 	}
+
+	if cfg.Limits.MaxOutputBytes != 1048576 {
+		t.Errorf("Expected default max output bytes 1048576, got %d", cfg.Limits.MaxOutputBytes)
+	}
+
+	if got := cfg.Cache.Persist; got.Path != "" || got.Interval != 5*time.Minute {
+		t.Errorf("Expected plan cache persistence disabled with a 5m interval, got %+v", got)
+	}
+
+	if got := cfg.API.GetTranslate; !got.Enabled || got.MaxQueryStringLength != 4096 || got.CacheMaxAge != time.Minute {
+		t.Errorf("Expected GET translate enabled with a 4096 byte limit and 1m max age, got %+v", got)
+	}
+
+	if cfg.Logging.SlowTranslationThreshold != 100*time.Millisecond {
+		t.Errorf("Expected default slow translation threshold 100ms, got %s", cfg.Logging.SlowTranslationThreshold)
+	}
+
+	if cfg.Logging.Queries != "hash" {
+		t.Errorf("Expected default query logging 'hash', got '%s'", cfg.Logging.Queries)
+	}
+
+	if !cfg.Analytics.Enabled || cfg.Analytics.SnapshotInterval != 10*time.Second {
+		t.Errorf("Expected analytics enabled with 10s snapshots by default, got %+v", cfg.Analytics)
+	}
+
+	if cfg.History.Enabled || cfg.History.Size != 100 {
+		t.Errorf("Expected history disabled, keeping 100 requests per schema, by default, got %+v", cfg.History)
+	}
+
+	if cfg.Limits.Concurrency != (ConcurrencyConfig{MaxQueue: 100, QueueTimeout: time.Second}) {
+		t.Errorf("Expected concurrency limit disabled with a 100 request, 1s queue by default, got %+v", cfg.Limits.Concurrency)
+	}
+
+	query := cfg.Features.Query
+	if !query.Regex || !query.Fuzzy || !query.Proximity || !query.LeadingWildcard || !query.Exists {
+		t.Errorf("Expected all query features enabled by default, got %+v", query)
+	}
+
+	if cfg.Executor.Enabled || cfg.Executor.Timeout != 5*time.Second {
+		t.Errorf("Expected executor disabled, with query plans bounded by 5s, by default, got %+v", cfg.Executor)
+	}
+
+	shadow := cfg.Shadow
+	if shadow.Enabled || shadow.SampleRate != 1 || shadow.Timeout != 5*time.Second || shadow.QueueSize != 100 {
+		t.Errorf("Expected shadow comparison disabled, comparing every translation within 5s, by default, got %+v", shadow)
+	}
 }
 
 func TestEnvironmentVariableOverrides(t *testing.T) {
@@ -68,6 +116,9 @@ func TestEnvironmentVariableOverrides(t *testing.T) {
 	}
 }
 
+// testCodecKey is a 64-byte deterministic-aes key
+var testCodecKey = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 64)))
+
 func TestConfigValidation(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -116,6 +167,351 @@ func TestConfigValidation(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "negative max output bytes",
+			modifyConfig: func(c *Config) {
+				c.Limits.MaxOutputBytes = -1
+			},
+			expectError: true,
+		},
+		{
+			name: "persisted cache without interval",
+			modifyConfig: func(c *Config) {
+				c.Cache.Persist = CachePersistConfig{Path: "/var/lib/rsearch/plans.json"}
+			},
+			expectError: true,
+		},
+		{
+			name: "negative GET translate cache max age",
+			modifyConfig: func(c *Config) {
+				c.API.GetTranslate.CacheMaxAge = -time.Second
+			},
+			expectError: true,
+		},
+		{
+			name: "negative concurrency queue",
+			modifyConfig: func(c *Config) {
+				c.Limits.Concurrency = ConcurrencyConfig{MaxInFlight: 8, MaxQueue: -1}
+			},
+			expectError: true,
+		},
+		{
+			name: "redacted query logging",
+			modifyConfig: func(c *Config) {
+				c.Logging.Queries = "redacted"
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid query logging mode",
+			modifyConfig: func(c *Config) {
+				c.Logging.Queries = "masked"
+			},
+			expectError: true,
+		},
+		{
+			name: "quota with a key override",
+			modifyConfig: func(c *Config) {
+				c.Limits.Quota = QuotaConfig{Enabled: true, QueriesPerDay: 1000, Keys: []KeyQuotaConfig{{Key: "big-tenant", QueriesPerDay: 100000}}}
+			},
+			expectError: false,
+		},
+		{
+			name: "negative quota",
+			modifyConfig: func(c *Config) {
+				c.Limits.Quota = QuotaConfig{Enabled: true, ComplexityPerDay: -1}
+			},
+			expectError: true,
+		},
+		{
+			name: "quota key listed twice",
+			modifyConfig: func(c *Config) {
+				c.Limits.Quota = QuotaConfig{Keys: []KeyQuotaConfig{{Key: "a"}, {Key: "a"}}}
+			},
+			expectError: true,
+		},
+		{
+			name: "quota key without a key",
+			modifyConfig: func(c *Config) {
+				c.Limits.Quota = QuotaConfig{Keys: []KeyQuotaConfig{{QueriesPerDay: 5}}}
+			},
+			expectError: true,
+		},
+		{
+			name: "negative slow translation threshold",
+			modifyConfig: func(c *Config) {
+				c.Logging.SlowTranslationThreshold = -time.Second
+			},
+			expectError: true,
+		},
+		{
+			name: "debug endpoints with a key",
+			modifyConfig: func(c *Config) {
+				c.Metrics.Debug = DebugConfig{Enabled: true, APIKeys: []string{"ops-key"}}
+			},
+			expectError: false,
+		},
+		{
+			name: "debug endpoints without keys",
+			modifyConfig: func(c *Config) {
+				c.Metrics.Debug = DebugConfig{Enabled: true}
+			},
+			expectError: true,
+		},
+		{
+			name: "debug endpoints without metrics",
+			modifyConfig: func(c *Config) {
+				c.Metrics.Enabled = false
+				c.Metrics.Debug = DebugConfig{Enabled: true, APIKeys: []string{"ops-key"}}
+			},
+			expectError: true,
+		},
+		{
+			name: "analytics without snapshot interval",
+			modifyConfig: func(c *Config) {
+				c.Analytics.Enabled = true
+				c.Analytics.SnapshotInterval = 0
+			},
+			expectError: true,
+		},
+		{
+			name: "valid history",
+			modifyConfig: func(c *Config) {
+				c.History = HistoryConfig{Enabled: true, Size: 50, APIKeys: []string{"ops-key"}}
+			},
+			expectError: false,
+		},
+		{
+			name: "history without keys",
+			modifyConfig: func(c *Config) {
+				c.History = HistoryConfig{Enabled: true, Size: 50}
+			},
+			expectError: true,
+		},
+		{
+			name: "history without size",
+			modifyConfig: func(c *Config) {
+				c.History = HistoryConfig{Enabled: true, APIKeys: []string{"ops-key"}}
+			},
+			expectError: true,
+		},
+		{
+			name: "valid codec",
+			modifyConfig: func(c *Config) {
+				c.Security.Codecs = []CodecConfig{{Name: "pii", Type: "deterministic-aes", Key: testCodecKey}}
+			},
+			expectError: false,
+		},
+		{
+			name: "codec with a short key",
+			modifyConfig: func(c *Config) {
+				c.Security.Codecs = []CodecConfig{{Name: "pii", Type: "deterministic-aes", Key: "c2hvcnQ="}}
+			},
+			expectError: true,
+		},
+		{
+			name: "codec defined twice",
+			modifyConfig: func(c *Config) {
+				c.Security.Codecs = []CodecConfig{
+					{Name: "pii", Type: "deterministic-aes", Key: testCodecKey},
+					{Name: "pii", Type: "deterministic-aes", Key: testCodecKey},
+				}
+			},
+			expectError: true,
+		},
+		{
+			name: "valid hash key",
+			modifyConfig: func(c *Config) {
+				c.Security.HashKeys = []HashKeyConfig{{Name: "emails", Key: testCodecKey}}
+			},
+			expectError: false,
+		},
+		{
+			name: "hash key too short",
+			modifyConfig: func(c *Config) {
+				c.Security.HashKeys = []HashKeyConfig{{Name: "emails", Key: "c2hvcnQ="}}
+			},
+			expectError: true,
+		},
+		{
+			name: "hash key defined twice",
+			modifyConfig: func(c *Config) {
+				c.Security.HashKeys = []HashKeyConfig{{Name: "emails", Key: testCodecKey}, {Name: "emails", Key: testCodecKey}}
+			},
+			expectError: true,
+		},
+		{
+			name: "valid dictionaries",
+			modifyConfig: func(c *Config) {
+				c.Schemas.Dictionaries = []DictionaryConfig{
+					{Name: "countries", File: "dictionaries/countries.txt"},
+					{Name: "currencies", URL: "https://reference.example.com/currencies.json", Refresh: time.Hour, Timeout: 2 * time.Second},
+				}
+			},
+			expectError: false,
+		},
+		{
+			name: "dictionary with a file and a url",
+			modifyConfig: func(c *Config) {
+				c.Schemas.Dictionaries = []DictionaryConfig{{Name: "countries", File: "countries.txt", URL: "https://reference.example.com/countries"}}
+			},
+			expectError: true,
+		},
+		{
+			name: "dictionary defined twice",
+			modifyConfig: func(c *Config) {
+				c.Schemas.Dictionaries = []DictionaryConfig{
+					{Name: "countries", File: "countries.txt"},
+					{Name: "countries", File: "countries.txt"},
+				}
+			},
+			expectError: true,
+		},
+		{
+			name: "valid chaos",
+			modifyConfig: func(c *Config) {
+				c.Chaos = ChaosConfig{Enabled: true, Stages: map[string]ChaosFaultConfig{
+					"translate": {DelayRate: 0.1, Delay: 200 * time.Millisecond, ErrorRate: 0.05, ErrorStatus: 503},
+					"response":  {MalformedRate: 0.01},
+				}}
+			},
+			expectError: false,
+		},
+		{
+			name: "chaos with an unknown stage",
+			modifyConfig: func(c *Config) {
+				c.Chaos = ChaosConfig{Enabled: true, Stages: map[string]ChaosFaultConfig{"database": {ErrorRate: 1}}}
+			},
+			expectError: true,
+		},
+		{
+			name: "chaos malformed parse output",
+			modifyConfig: func(c *Config) {
+				c.Chaos = ChaosConfig{Enabled: true, Stages: map[string]ChaosFaultConfig{"parse": {MalformedRate: 0.5}}}
+			},
+			expectError: true,
+		},
+		{
+			name: "chaos rate above 1",
+			modifyConfig: func(c *Config) {
+				c.Chaos = ChaosConfig{Enabled: true, Stages: map[string]ChaosFaultConfig{"request": {ErrorRate: 2}}}
+			},
+			expectError: true,
+		},
+		{
+			name: "valid events",
+			modifyConfig: func(c *Config) {
+				c.Events = EventsConfig{
+					Enabled:   true,
+					Webhooks:  []WebhookConfig{{URL: "https://docs.example.com/hooks/rsearch"}},
+					Timeout:   5 * time.Second,
+					QueueSize: 100,
+				}
+			},
+			expectError: false,
+		},
+		{
+			name: "events without destinations",
+			modifyConfig: func(c *Config) {
+				c.Events = EventsConfig{Enabled: true, Timeout: 5 * time.Second, QueueSize: 100}
+			},
+			expectError: true,
+		},
+		{
+			name: "events with invalid webhook URL",
+			modifyConfig: func(c *Config) {
+				c.Events = EventsConfig{
+					Enabled:   true,
+					Webhooks:  []WebhookConfig{{URL: "docs.example.com"}},
+					Timeout:   5 * time.Second,
+					QueueSize: 100,
+				}
+			},
+			expectError: true,
+		},
+		{
+			name: "events on NATS without URL",
+			modifyConfig: func(c *Config) {
+				c.Events = EventsConfig{Enabled: true, NATSSubject: "rsearch.events", Timeout: 5 * time.Second, QueueSize: 100}
+			},
+			expectError: true,
+		},
+		{
+			name: "valid executor",
+			modifyConfig: func(c *Config) {
+				c.Executor = ExecutorConfig{Enabled: true, Database: "mongodb", DSN: "mongodb://db.example.com/shop", Timeout: 5 * time.Second}
+			},
+			expectError: false,
+		},
+		{
+			name: "executor on OpenSearch",
+			modifyConfig: func(c *Config) {
+				c.Executor = ExecutorConfig{Enabled: true, Database: "opensearch", DSN: "https://search.example.com:9200", Timeout: 5 * time.Second}
+			},
+			expectError: true,
+		},
+		{
+			name: "executor without DSN",
+			modifyConfig: func(c *Config) {
+				c.Executor = ExecutorConfig{Enabled: true, Database: "postgres", Timeout: 5 * time.Second}
+			},
+			expectError: true,
+		},
+		{
+			name: "executor without timeout",
+			modifyConfig: func(c *Config) {
+				c.Executor = ExecutorConfig{Enabled: true, Database: "sqlite", DSN: "shop.db"}
+			},
+			expectError: true,
+		},
+		{
+			name: "valid shadow",
+			modifyConfig: func(c *Config) {
+				c.Executor = ExecutorConfig{Enabled: true, Database: "postgres", DSN: "postgres://rsearch@db.example.com/shop", Timeout: 5 * time.Second}
+				c.Shadow = ShadowConfig{
+					Enabled:    true,
+					OpenSearch: OpenSearchConfig{URL: "https://search.example.com:9200"},
+					SampleRate: 0.1,
+					Timeout:    5 * time.Second,
+					QueueSize:  100,
+				}
+			},
+			expectError: false,
+		},
+		{
+			name: "shadow without executor",
+			modifyConfig: func(c *Config) {
+				c.Shadow = ShadowConfig{
+					Enabled:    true,
+					OpenSearch: OpenSearchConfig{URL: "https://search.example.com:9200"},
+					SampleRate: 1,
+					Timeout:    5 * time.Second,
+					QueueSize:  100,
+				}
+			},
+			expectError: true,
+		},
+		{
+			name: "shadow without OpenSearch URL",
+			modifyConfig: func(c *Config) {
+				c.Executor = ExecutorConfig{Enabled: true, Database: "sqlite", DSN: "shop.db", Timeout: 5 * time.Second}
+				c.Shadow = ShadowConfig{Enabled: true, SampleRate: 1, Timeout: 5 * time.Second, QueueSize: 100}
+			},
+			expectError: true,
+		},
+		{
+			name: "shadow sample rate of 0",
+			modifyConfig: func(c *Config) {
+				c.Executor = ExecutorConfig{Enabled: true, Database: "sqlite", DSN: "shop.db", Timeout: 5 * time.Second}
+				c.Shadow = ShadowConfig{
+					Enabled:    true,
+					OpenSearch: OpenSearchConfig{URL: "http://localhost:9200"},
+					Timeout:    5 * time.Second,
+					QueueSize:  100,
+				}
+			},
+			expectError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -129,9 +525,10 @@ func TestConfigValidation(t *testing.T) {
 					ShutdownTimeout: 10 * time.Second,
 				},
 				Logging: LoggingConfig{
-					Level:  "info",
-					Format: "json",
-					Output: "stdout",
+					Level:   "info",
+					Format:  "json",
+					Output:  "stdout",
+					Queries: "hash",
 				},
 				Metrics: MetricsConfig{
 					Enabled: true,
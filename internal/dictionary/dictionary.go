@@ -0,0 +1,233 @@
+// Package dictionary provides the built-in schema dictionaries: lists of the values a field
+// accepts, loaded from a file or an HTTP endpoint and cached (see schema.Field.Dictionary).
+package dictionary
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxSize bounds the dictionaries read from files and HTTP endpoints
+const maxSize = 10 << 20
+
+// DefaultHTTPTimeout bounds the fetches of HTTP dictionaries created without a timeout
+const DefaultHTTPTimeout = 5 * time.Second
+
+// Loader reads the values of a dictionary
+type Loader func(ctx context.Context) ([]string, error)
+
+const (
+	// minRetryDelay is how long a dictionary waits before loading again after a failure;
+	// the delay doubles with each further failure
+	minRetryDelay = time.Second
+
+	// maxRetryDelay bounds the delay between failed loads
+	maxRetryDelay = time.Minute
+)
+
+// Cached is a dictionary loaded on first use and reloaded once it is older than its
+// refresh interval. Reloads happen in the background while the values loaded last stay
+// in use, so translations never wait on a slow source once a load succeeded. Failed
+// loads are retried after a delay growing up to maxRetryDelay, or the refresh interval
+// when it is shorter; until the first load succeeds, lookups fail with its error in
+// between, so an unavailable source is not hit by every translation.
+type Cached struct {
+	name    string
+	load    Loader
+	refresh time.Duration // 0 loads once
+	timeout time.Duration // bounds each load
+	now     func() time.Time
+
+	mu       sync.Mutex
+	values   map[string]struct{}
+	list     []string
+	loadedAt time.Time     // when the values were loaded
+	loading  chan struct{} // closed when the load in flight ends; nil when none is
+	err      error         // why the last load failed, until one succeeds
+	failures int           // consecutive failed loads
+	retryAt  time.Time     // no load starts before, after a failure
+}
+
+// NewCached creates a dictionary reading its values with load
+func NewCached(name string, load Loader, refresh, timeout time.Duration) *Cached {
+	return &Cached{name: name, load: load, refresh: refresh, timeout: timeout, now: time.Now}
+}
+
+// NewFile creates a dictionary reading a file, see Parse for its format
+func NewFile(name, path string, refresh time.Duration) *Cached {
+	return NewCached(name, func(context.Context) ([]string, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return readValues(f)
+	}, refresh, 0)
+}
+
+// NewHTTP creates a dictionary fetching a URL with GET, see Parse for the body's format.
+// Each fetch is bounded by timeout, or DefaultHTTPTimeout when it is 0.
+func NewHTTP(name, url string, refresh, timeout time.Duration, client *http.Client) *Cached {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if timeout <= 0 {
+		timeout = DefaultHTTPTimeout
+	}
+	return NewCached(name, func(ctx context.Context) ([]string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("GET %s returned %s", url, resp.Status)
+		}
+		return readValues(resp.Body)
+	}, refresh, timeout)
+}
+
+// Contains reports whether the dictionary holds the value
+func (c *Cached) Contains(value string) (bool, error) {
+	values, _, err := c.current()
+	if err != nil {
+		return false, err
+	}
+	_, ok := values[value]
+	return ok, nil
+}
+
+// Values returns the values of the dictionary, in the order of its source
+func (c *Cached) Values() ([]string, error) {
+	_, list, err := c.current()
+	return list, err
+}
+
+// current returns the values loaded last, starting a background reload when they are due
+// for a refresh. Before any load succeeded, it waits for a load instead, or returns the
+// error of the last one while its retry delay has not passed.
+func (c *Cached) current() (map[string]struct{}, []string, error) {
+	c.mu.Lock()
+	now := c.now()
+	if c.values != nil {
+		due := c.refresh > 0 && now.Sub(c.loadedAt) >= c.refresh
+		if due && c.loading == nil && !now.Before(c.retryAt) {
+			c.startLoad()
+		}
+		values, list := c.values, c.list
+		c.mu.Unlock()
+		return values, list, nil
+	}
+
+	if c.loading == nil {
+		if now.Before(c.retryAt) {
+			err := c.err
+			c.mu.Unlock()
+			return nil, nil, err
+		}
+		c.startLoad()
+	}
+	loading := c.loading
+	c.mu.Unlock()
+
+	<-loading
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.values == nil {
+		return nil, nil, c.err
+	}
+	return c.values, c.list, nil
+}
+
+// startLoad loads the values on a new goroutine; c.mu must be held
+func (c *Cached) startLoad() {
+	loading := make(chan struct{})
+	c.loading = loading
+	go func() {
+		defer close(loading)
+
+		ctx := context.Background()
+		if c.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+		}
+		list, err := c.load(ctx)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.loading = nil
+		now := c.now()
+		if err != nil {
+			c.failures++
+			c.err = fmt.Errorf("failed to load dictionary %s: %w", c.name, err)
+			c.retryAt = now.Add(c.retryDelay())
+			return
+		}
+
+		values := make(map[string]struct{}, len(list))
+		for _, value := range list {
+			values[value] = struct{}{}
+		}
+		c.values, c.list, c.loadedAt = values, list, now
+		c.err, c.failures, c.retryAt = nil, 0, time.Time{}
+	}()
+}
+
+// retryDelay returns how long to wait after the current run of failed loads
+func (c *Cached) retryDelay() time.Duration {
+	delay := maxRetryDelay
+	if c.failures <= 6 {
+		delay = min(minRetryDelay<<(c.failures-1), maxRetryDelay)
+	}
+	if c.refresh > 0 {
+		delay = min(delay, c.refresh)
+	}
+	return delay
+}
+
+// readValues reads and parses at most maxSize bytes
+func readValues(r io.Reader) ([]string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSize {
+		return nil, fmt.Errorf("dictionary exceeds %d bytes", maxSize)
+	}
+	return Parse(data)
+}
+
+// Parse reads the values of a dictionary: a JSON array of strings, or else one value per
+// line. Lines are trimmed, and blank lines and lines starting with # are skipped.
+func Parse(data []byte) ([]string, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var values []string
+		if err := json.Unmarshal(trimmed, &values); err != nil {
+			return nil, fmt.Errorf("invalid JSON dictionary: %w", err)
+		}
+		return values, nil
+	}
+
+	var values []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		values = append(values, line)
+	}
+	return values, nil
+}
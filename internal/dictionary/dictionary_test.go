@@ -0,0 +1,179 @@
+package dictionary
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	values, err := Parse([]byte("# ISO 4217\nUSD\n\n  EUR  \nJPY"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"USD", "EUR", "JPY"}, values)
+
+	values, err = Parse([]byte(` ["US", "DE"] `))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"US", "DE"}, values)
+
+	_, err = Parse([]byte(`["US", 1]`))
+	assert.Error(t, err)
+}
+
+// testClock is a settable clock, safe for the goroutines loading dictionaries
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestCached_Refresh(t *testing.T) {
+	var loads atomic.Int32
+	var fail atomic.Bool
+	release := make(chan struct{})
+	c := NewCached("currencies", func(context.Context) ([]string, error) {
+		n := loads.Add(1)
+		if fail.Load() {
+			return nil, errors.New("unavailable")
+		}
+		if n == 1 {
+			return []string{"USD"}, nil
+		}
+		<-release
+		return []string{"USD", "EUR"}, nil
+	}, time.Hour, 0)
+	clock := &testClock{now: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)}
+	c.now = clock.Now
+
+	ok, err := c.Contains("EUR")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, _ = c.Contains("USD")
+	assert.True(t, ok)
+	assert.Equal(t, int32(1), loads.Load(), "values are cached")
+
+	// Values due for a refresh stay in use while they are reloaded in the background
+	clock.Advance(time.Hour)
+	ok, err = c.Contains("EUR")
+	require.NoError(t, err)
+	assert.False(t, ok, "the reload does not hold up lookups")
+	close(release)
+	require.Eventually(t, func() bool {
+		ok, _ := c.Contains("EUR")
+		return ok
+	}, time.Second, time.Millisecond, "values are reloaded after the refresh interval")
+	assert.Equal(t, int32(2), loads.Load())
+
+	// A failed reload keeps the previous values, and is retried after a delay
+	fail.Store(true)
+	clock.Advance(time.Hour)
+	values, err := c.Values()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"USD", "EUR"}, values)
+	require.Eventually(t, func() bool { return loads.Load() == 3 }, time.Second, time.Millisecond)
+	waitIdle(t, c)
+	_, _ = c.Values()
+	assert.Equal(t, int32(3), loads.Load(), "no reload before the retry delay")
+
+	clock.Advance(minRetryDelay)
+	values, err = c.Values()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"USD", "EUR"}, values)
+	require.Eventually(t, func() bool { return loads.Load() == 4 }, time.Second, time.Millisecond)
+}
+
+func TestCached_RetryDelay(t *testing.T) {
+	var loads atomic.Int32
+	var fail atomic.Bool
+	fail.Store(true)
+	c := NewCached("currencies", func(context.Context) ([]string, error) {
+		loads.Add(1)
+		if fail.Load() {
+			return nil, errors.New("unavailable")
+		}
+		return []string{"USD"}, nil
+	}, 0, 0)
+	clock := &testClock{now: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)}
+	c.now = clock.Now
+
+	// A dictionary that never loaded fails lookups without loading again until the
+	// retry delay passes, which doubles with each failure
+	for _, step := range []struct {
+		advance time.Duration
+		loads   int32
+	}{{0, 1}, {0, 1}, {time.Second, 2}, {time.Second, 2}, {time.Second, 3}} {
+		clock.Advance(step.advance)
+		_, err := c.Contains("USD")
+		assert.ErrorContains(t, err, "failed to load dictionary currencies: unavailable")
+		assert.Equal(t, step.loads, loads.Load())
+	}
+
+	fail.Store(false)
+	clock.Advance(4 * time.Second)
+	ok, err := c.Contains("USD")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+// waitIdle waits for the load in flight, if any, to end
+func waitIdle(t *testing.T, c *Cached) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.loading == nil
+	}, time.Second, time.Millisecond)
+}
+
+func TestCached_LoadError(t *testing.T) {
+	c := NewFile("countries", filepath.Join(t.TempDir(), "missing.txt"), 0)
+	_, err := c.Contains("US")
+	assert.ErrorContains(t, err, "failed to load dictionary countries")
+}
+
+func TestNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "countries.txt")
+	require.NoError(t, os.WriteFile(path, []byte("US\nDE\n"), 0o600))
+
+	ok, err := NewFile("countries", path, 0).Contains("DE")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestNewHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/countries" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`["US", "DE"]`))
+	}))
+	defer server.Close()
+
+	values, err := NewHTTP("countries", server.URL+"/countries", time.Minute, time.Second, nil).Values()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"US", "DE"}, values)
+
+	_, err = NewHTTP("missing", server.URL+"/missing", time.Minute, time.Second, nil).Values()
+	assert.ErrorContains(t, err, "404 Not Found")
+}
@@ -0,0 +1,241 @@
+// Package events notifies external systems of schema changes.
+// Every local registration, replacement or deletion in the schema registry becomes an
+// Event carrying the schema before and after the change, which is delivered
+// asynchronously to webhooks or a pub/sub subject, so caches, documentation sites
+// and SDK generators can react without polling.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/infiniv/rsearch/internal/observability"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// Type identifies the kind of schema change
+type Type string
+
+const (
+	// SchemaRegistered is emitted when a schema name is registered for the first time,
+	// or again after it was deleted
+	SchemaRegistered Type = "schema.registered"
+	// SchemaUpdated is emitted when a registered schema is replaced by a new definition
+	SchemaUpdated Type = "schema.updated"
+	// SchemaDeleted is emitted when a schema is removed
+	SchemaDeleted Type = "schema.deleted"
+)
+
+// Event is the payload delivered to sinks
+type Event struct {
+	ID        string         `json:"id"` // unique per event, for deduplicating redeliveries
+	Type      Type           `json:"type"`
+	Schema    string         `json:"schema"`
+	Version   uint64         `json:"version"` // registry version after the change
+	Timestamp time.Time      `json:"timestamp"`
+	Before    *schema.Schema `json:"before,omitempty"` // nil for registrations
+	After     *schema.Schema `json:"after,omitempty"`  // nil for deletions
+	Diff      schema.Diff    `json:"diff"`
+}
+
+// Sink delivers encoded events to one destination
+type Sink interface {
+	// Name identifies the sink in logs
+	Name() string
+
+	// Send delivers one event; it is retried when it returns an error
+	Send(ctx context.Context, event Event, payload []byte) error
+}
+
+// Emitter turns registry changes into events and delivers them to sinks.
+// Delivery happens on a background goroutine so that registry writes never wait on
+// slow destinations; events arriving while the queue is full are dropped and logged.
+type Emitter struct {
+	sinks      []Sink
+	logger     *observability.Logger
+	queue      chan Event
+	maxRetries int
+	backoff    time.Duration
+
+	mu      sync.Mutex
+	running bool
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// Retry defaults used by NewEmitter
+const (
+	DefaultMaxRetries = 3
+	DefaultBackoff    = 500 * time.Millisecond
+)
+
+// NewEmitter creates an emitter queueing up to queueSize undelivered events
+func NewEmitter(logger *observability.Logger, queueSize int, sinks ...Sink) *Emitter {
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	return &Emitter{
+		sinks:      sinks,
+		logger:     logger,
+		queue:      make(chan Event, queueSize),
+		maxRetries: DefaultMaxRetries,
+		backoff:    DefaultBackoff,
+	}
+}
+
+// WithRetries sets how often a failed delivery is retried and the initial delay,
+// which doubles with every retry
+func (e *Emitter) WithRetries(maxRetries int, backoff time.Duration) *Emitter {
+	e.maxRetries = maxRetries
+	e.backoff = backoff
+	return e
+}
+
+// Start subscribes to the registry and begins delivering events
+func (e *Emitter) Start(registry *schema.Registry) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.running {
+		return
+	}
+	e.running = true
+	e.done = make(chan struct{})
+
+	e.wg.Add(1)
+	go e.run(e.done)
+
+	registry.Subscribe(e.handleEvent)
+}
+
+// Stop delivers the events still queued, without retrying them, and stops the emitter
+func (e *Emitter) Stop() {
+	e.mu.Lock()
+	if !e.running {
+		e.mu.Unlock()
+		return
+	}
+	e.running = false
+	close(e.done)
+	e.mu.Unlock()
+
+	e.wg.Wait()
+}
+
+// handleEvent queues an event for a local registry change.
+// Changes applied from other replicas are skipped: the replica that made them emits them.
+func (e *Emitter) handleEvent(change schema.Event) {
+	if change.Remote {
+		return
+	}
+
+	event := NewEvent(change)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.running {
+		return
+	}
+
+	select {
+	case e.queue <- event:
+	default:
+		e.logger.WithFields(map[string]interface{}{
+			"schema":  event.Schema,
+			"version": event.Version,
+			"event":   event.Type,
+		}).Warn("Schema event queue is full, dropping event")
+	}
+}
+
+// NewEvent builds the event describing a registry change
+func NewEvent(change schema.Event) Event {
+	event := Event{
+		ID:        uuid.New().String(),
+		Schema:    change.Name,
+		Version:   change.Version,
+		Timestamp: time.Now().UTC(),
+		Before:    change.Previous,
+		After:     change.Schema,
+		Diff:      schema.Compare(change.Previous, change.Schema),
+	}
+
+	switch {
+	case change.Type == schema.EventDeleted:
+		event.Type = SchemaDeleted
+	case change.Previous != nil:
+		event.Type = SchemaUpdated
+	default:
+		event.Type = SchemaRegistered
+	}
+	return event
+}
+
+// run delivers queued events until the emitter is stopped
+func (e *Emitter) run(done chan struct{}) {
+	defer e.wg.Done()
+
+	for {
+		select {
+		case event := <-e.queue:
+			e.deliver(event, done)
+		case <-done:
+			for {
+				select {
+				case event := <-e.queue:
+					e.deliver(event, nil)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver sends an event to every sink, retrying failures until done is closed.
+// A nil done disables retries.
+func (e *Emitter) deliver(event Event, done chan struct{}) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		e.logger.ErrorWithErr(err, "Failed to encode schema event")
+		return
+	}
+
+	for _, sink := range e.sinks {
+		backoff := e.backoff
+		for attempt := 0; ; attempt++ {
+			err := sink.Send(context.Background(), event, payload)
+			if err == nil {
+				break
+			}
+
+			if done == nil || attempt >= e.maxRetries || !wait(backoff, done) {
+				e.logger.WithFields(map[string]interface{}{
+					"sink":     sink.Name(),
+					"schema":   event.Schema,
+					"version":  event.Version,
+					"event":    event.Type,
+					"attempts": attempt + 1,
+				}).ErrorWithErr(err, "Failed to deliver schema event")
+				break
+			}
+			backoff *= 2
+		}
+	}
+}
+
+// wait sleeps for d and reports whether it elapsed before done was closed
+func wait(d time.Duration, done chan struct{}) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-done:
+		return false
+	}
+}
@@ -0,0 +1,183 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/observability"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLogger(t *testing.T) *observability.Logger {
+	t.Helper()
+	logger, err := observability.NewLogger("error", "json", "stderr")
+	require.NoError(t, err)
+	return logger
+}
+
+// recordingSink records delivered events and fails the first failures attempts
+type recordingSink struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	events   chan Event
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Send(_ context.Context, event Event, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attempts++
+	if s.attempts <= s.failures {
+		return errors.New("unavailable")
+	}
+
+	var decoded Event
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		return err
+	}
+	s.events <- decoded
+	return nil
+}
+
+func receive(t *testing.T, events chan Event) Event {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return Event{}
+	}
+}
+
+func TestEmitter(t *testing.T) {
+	registry := schema.NewRegistry()
+	sink := &recordingSink{failures: 2, events: make(chan Event, 10)}
+
+	emitter := NewEmitter(newTestLogger(t), 10, sink).WithRetries(3, time.Millisecond)
+	emitter.Start(registry)
+	defer emitter.Stop()
+
+	products := schema.NewSchema("products", map[string]schema.Field{"name": {Type: schema.TypeText}}, schema.SchemaOptions{})
+	require.NoError(t, registry.Register(products))
+
+	registered := receive(t, sink.events)
+	assert.Equal(t, SchemaRegistered, registered.Type)
+	assert.Equal(t, "products", registered.Schema)
+	assert.Equal(t, uint64(1), registered.Version)
+	assert.NotEmpty(t, registered.ID)
+	assert.Nil(t, registered.Before)
+	require.NotNil(t, registered.After)
+	assert.Equal(t, []string{"name"}, registered.Diff.AddedFields)
+	assert.Equal(t, 3, sink.attempts, "delivery is retried")
+
+	require.NoError(t, registry.Delete("products"))
+
+	deleted := receive(t, sink.events)
+	assert.Equal(t, SchemaDeleted, deleted.Type)
+	assert.Equal(t, uint64(2), deleted.Version)
+	require.NotNil(t, deleted.Before)
+	assert.Nil(t, deleted.After)
+	assert.Equal(t, []string{"name"}, deleted.Diff.RemovedFields)
+
+	// Changes applied from other replicas are emitted by their origin
+	_, err := registry.ApplyRemote(schema.Event{Type: schema.EventRegistered, Name: "products", Version: 3, Schema: products})
+	require.NoError(t, err)
+	select {
+	case event := <-sink.events:
+		t.Fatalf("unexpected event for remote change: %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestNewEvent_Updated(t *testing.T) {
+	before := schema.NewSchema("products", map[string]schema.Field{"name": {Type: schema.TypeText}}, schema.SchemaOptions{})
+	after := schema.NewSchema("products", map[string]schema.Field{"name": {Type: schema.TypeText}, "price": {Type: schema.TypeFloat}}, schema.SchemaOptions{})
+
+	event := NewEvent(schema.Event{Type: schema.EventRegistered, Name: "products", Version: 4, Schema: after, Previous: before})
+	assert.Equal(t, SchemaUpdated, event.Type)
+	assert.Equal(t, []string{"price"}, event.Diff.AddedFields)
+}
+
+func TestEmitter_StopDeliversQueuedEvents(t *testing.T) {
+	registry := schema.NewRegistry()
+	sink := &recordingSink{events: make(chan Event, 10)}
+
+	emitter := NewEmitter(newTestLogger(t), 10, sink)
+	emitter.Start(registry)
+
+	for _, name := range []string{"a", "b", "c"} {
+		require.NoError(t, registry.Register(schema.NewSchema(name, map[string]schema.Field{"f": {Type: schema.TypeText}}, schema.SchemaOptions{})))
+	}
+	emitter.Stop()
+	assert.Len(t, sink.events, 3)
+
+	// Changes after Stop are not emitted
+	require.NoError(t, registry.Delete("a"))
+	emitter.Stop()
+	assert.Len(t, sink.events, 3)
+}
+
+func TestWebhookSink(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	event := Event{ID: "42", Type: SchemaDeleted, Schema: "products"}
+	payload := []byte(`{"id":"42"}`)
+
+	sink := NewWebhookSink(server.URL, "s3cret", time.Second)
+	require.NoError(t, sink.Send(context.Background(), event, payload))
+	assert.Equal(t, http.MethodPost, received.Method)
+	assert.Equal(t, payload, body)
+	assert.Equal(t, "schema.deleted", received.Header.Get(EventHeader))
+	assert.Equal(t, "42", received.Header.Get(DeliveryHeader))
+	assert.Equal(t, Sign([]byte("s3cret"), payload), received.Header.Get(SignatureHeader))
+
+	// Without a secret, requests are not signed
+	require.NoError(t, NewWebhookSink(server.URL, "", time.Second).Send(context.Background(), event, payload))
+	assert.Empty(t, received.Header.Get(SignatureHeader))
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	assert.Error(t, NewWebhookSink(failing.URL, "", time.Second).Send(context.Background(), event, payload))
+}
+
+func TestSign(t *testing.T) {
+	// echo -n 'hello' | openssl dgst -sha256 -hmac key
+	assert.Equal(t, "sha256=9307b3b915efb5171ff14d8cb55fbcc798c6c0ef1456d66ded1a6aa723a58b7b", Sign([]byte("key"), []byte("hello")))
+}
+
+type publisherFunc func([]byte) error
+
+func (f publisherFunc) Publish(payload []byte) error { return f(payload) }
+
+func TestPublisherSink(t *testing.T) {
+	var published []byte
+	sink := NewPublisherSink("nats", publisherFunc(func(payload []byte) error {
+		published = payload
+		return nil
+	}))
+
+	require.NoError(t, sink.Send(context.Background(), Event{}, []byte("payload")))
+	assert.Equal(t, []byte("payload"), published)
+	assert.Equal(t, "nats", sink.Name())
+}
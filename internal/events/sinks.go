@@ -0,0 +1,102 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Webhook request headers
+const (
+	EventHeader     = "X-Rsearch-Event"
+	DeliveryHeader  = "X-Rsearch-Delivery"
+	SignatureHeader = "X-Rsearch-Signature"
+)
+
+// WebhookSink POSTs events as JSON to a URL.
+// When a secret is set, the body is signed with HMAC-SHA256 and the signature sent as
+// "sha256=<hex>" in X-Rsearch-Signature, so receivers can verify the sender.
+type WebhookSink struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhookSink creates a webhook sink; timeout bounds each delivery attempt
+func NewWebhookSink(url, secret string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Name returns the webhook URL
+func (s *WebhookSink) Name() string {
+	return s.url
+}
+
+// Send posts the event; any non-2xx response is an error
+func (s *WebhookSink) Send(ctx context.Context, event Event, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(event.Type))
+	req.Header.Set(DeliveryHeader, event.ID)
+	if len(s.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(s.secret, payload))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the signature header value of a payload
+func Sign(secret, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Publisher broadcasts payloads, such as cluster.NATSTransport
+type Publisher interface {
+	Publish(payload []byte) error
+}
+
+// PublisherSink publishes events to a pub/sub subject
+type PublisherSink struct {
+	name      string
+	publisher Publisher
+}
+
+// NewPublisherSink creates a sink publishing through publisher; name identifies it in logs
+func NewPublisherSink(name string, publisher Publisher) *PublisherSink {
+	return &PublisherSink{name: name, publisher: publisher}
+}
+
+// Name returns the sink name
+func (s *PublisherSink) Name() string {
+	return s.name
+}
+
+// Send publishes the encoded event
+func (s *PublisherSink) Send(_ context.Context, _ Event, payload []byte) error {
+	return s.publisher.Publish(payload)
+}
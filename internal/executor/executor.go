@@ -0,0 +1,249 @@
+// Package executor runs translated queries on the database they were translated for.
+// rsearch otherwise only translates; the executor backs the features that need the data
+// itself, such as shadow comparisons and query plans, and is only opened when configured.
+// It never returns rows: it counts them, or explains how the database would find them.
+package executor
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver/connstring"
+
+	// Drivers of the SQL databases the executor runs on
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// Databases are the dialects an executor runs
+var Databases = []string{"postgres", "mysql", "sqlite", "mongodb"}
+
+// drivers maps the SQL dialects to their database/sql drivers
+var drivers = map[string]string{
+	"postgres": "postgres",
+	"mysql":    "mysql",
+	"sqlite":   "sqlite",
+}
+
+// tableNameRegex matches table and collection names, optionally qualified with a schema
+var tableNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
+// Executor runs the translations to one dialect on one database
+type Executor struct {
+	database string
+	db       *sql.DB         // SQL dialects
+	client   *mongo.Client   // mongodb
+	mongoDB  *mongo.Database // mongodb
+	tables   map[string]string
+}
+
+// Open connects to a database of a dialect. For mongodb, dsn is a URI naming the
+// database in its path. tables maps schema names, matched case-insensitively, to the
+// tables or collections holding their rows; the schema name is used for others.
+// Connections are made when first needed.
+func Open(database, dsn string, tables map[string]string) (*Executor, error) {
+	e := &Executor{database: database, tables: tables}
+	if driver, ok := drivers[database]; ok {
+		db, err := sql.Open(driver, dsn)
+		if err != nil {
+			return nil, err
+		}
+		e.db = db
+		return e, nil
+	}
+	if database != "mongodb" {
+		return nil, fmt.Errorf("unsupported executor database %q: must be one of: %s", database, strings.Join(Databases, ", "))
+	}
+
+	uri, err := connstring.ParseAndValidate(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mongodb URI: %w", err)
+	}
+	if uri.Database == "" {
+		return nil, fmt.Errorf("mongodb URI must name the database, e.g. mongodb://host:27017/shop")
+	}
+	client, err := mongo.Connect(options.Client().ApplyURI(dsn))
+	if err != nil {
+		return nil, err
+	}
+	e.client, e.mongoDB = client, client.Database(uri.Database)
+	return e, nil
+}
+
+// Database returns the dialect the executor runs
+func (e *Executor) Database() string {
+	return e.database
+}
+
+// Close closes the connections to the database
+func (e *Executor) Close() error {
+	if e.client != nil {
+		return e.client.Disconnect(context.Background())
+	}
+	return e.db.Close()
+}
+
+// Count counts the rows of the schema's table, or the documents of its collection,
+// matching a translation. A SQL translation with a table alias counts the table under
+// that alias.
+func (e *Executor) Count(ctx context.Context, s *schema.Schema, output *translator.TranslatorOutput) (int64, error) {
+	table, err := e.table(s)
+	if err != nil {
+		return 0, err
+	}
+	if e.mongoDB != nil {
+		count, err := e.mongoDB.Collection(table).CountDocuments(ctx, output.Filter)
+		if err != nil {
+			return 0, fmt.Errorf("count failed: %w", err)
+		}
+		return count, nil
+	}
+
+	var count int64
+	if err := e.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+from(table, s, output), output.Parameters...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count query failed: %w", err)
+	}
+	return count, nil
+}
+
+// Explain returns the plan the database would run a translation with, as JSON, without
+// running it: EXPLAIN (FORMAT JSON) on postgres, EXPLAIN FORMAT=JSON on mysql, the rows of
+// EXPLAIN QUERY PLAN on sqlite and the queryPlanner verbosity of explain on mongodb.
+// The plan covers the select list or projection and the order of the translation.
+func (e *Executor) Explain(ctx context.Context, s *schema.Schema, output *translator.TranslatorOutput) (json.RawMessage, error) {
+	table, err := e.table(s)
+	if err != nil {
+		return nil, err
+	}
+	if e.mongoDB != nil {
+		return e.explainFind(ctx, table, output)
+	}
+
+	columns := "*"
+	if output.SelectClause != "" {
+		columns = output.SelectClause
+	}
+	query := "SELECT " + columns + " FROM " + from(table, s, output)
+	if output.OrderByClause != "" {
+		query += " ORDER BY " + output.OrderByClause
+	}
+
+	switch e.database {
+	case "sqlite":
+		return e.explainQueryPlan(ctx, query, output.Parameters)
+	case "mysql":
+		query = "EXPLAIN FORMAT=JSON " + query
+	default:
+		query = "EXPLAIN (FORMAT JSON) " + query
+	}
+	var plan string
+	if err := e.db.QueryRowContext(ctx, query, output.Parameters...).Scan(&plan); err != nil {
+		return nil, fmt.Errorf("explain failed: %w", err)
+	}
+	return json.RawMessage(plan), nil
+}
+
+// queryPlanStep is a row of SQLite's EXPLAIN QUERY PLAN
+type queryPlanStep struct {
+	ID     int    `json:"id"`
+	Parent int    `json:"parent"`
+	Detail string `json:"detail"`
+}
+
+// explainQueryPlan lists the steps of SQLite's plan for a query
+func (e *Executor) explainQueryPlan(ctx context.Context, query string, parameters []interface{}) (json.RawMessage, error) {
+	rows, err := e.db.QueryContext(ctx, "EXPLAIN QUERY PLAN "+query, parameters...)
+	if err != nil {
+		return nil, fmt.Errorf("explain failed: %w", err)
+	}
+	defer rows.Close()
+
+	steps := []queryPlanStep{}
+	for rows.Next() {
+		var step queryPlanStep
+		var unused int
+		if err := rows.Scan(&step.ID, &step.Parent, &unused, &step.Detail); err != nil {
+			return nil, fmt.Errorf("explain failed: %w", err)
+		}
+		steps = append(steps, step)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("explain failed: %w", err)
+	}
+	return json.Marshal(steps)
+}
+
+// explainFind explains a find on a collection with the filter, projection and sort of a
+// translation, as relaxed Extended JSON
+func (e *Executor) explainFind(ctx context.Context, collection string, output *translator.TranslatorOutput) (json.RawMessage, error) {
+	find := bson.D{{Key: "find", Value: collection}}
+	if output.Filter != nil {
+		find = append(find, bson.E{Key: "filter", Value: output.Filter})
+	}
+	if len(output.Projection) > 0 {
+		find = append(find, bson.E{Key: "projection", Value: output.Projection})
+	}
+	if len(output.Sort) > 0 {
+		sort := make(bson.D, len(output.Sort))
+		for i, key := range output.Sort {
+			sort[i] = bson.E{Key: key.Key, Value: key.Direction}
+		}
+		find = append(find, bson.E{Key: "sort", Value: sort})
+	}
+
+	raw, err := e.mongoDB.RunCommand(ctx, bson.D{{Key: "explain", Value: find}, {Key: "verbosity", Value: "queryPlanner"}}).Raw()
+	if err != nil {
+		return nil, fmt.Errorf("explain failed: %w", err)
+	}
+	plan, err := bson.MarshalExtJSON(raw, false, false)
+	if err != nil {
+		return nil, fmt.Errorf("explain failed: %w", err)
+	}
+	return plan, nil
+}
+
+// table returns the table or collection holding the rows of a schema
+func (e *Executor) table(s *schema.Schema) (string, error) {
+	table := Lookup(e.tables, s.Name)
+	if !tableNameRegex.MatchString(table) {
+		return "", fmt.Errorf("invalid table name %q for schema %s", table, s.Name)
+	}
+	return table, nil
+}
+
+// from writes the FROM and WHERE clauses of a SQL translation on table.
+// The table name is validated by table and the alias when the schema copy was made.
+func from(table string, s *schema.Schema, output *translator.TranslatorOutput) string {
+	clause := table
+	if alias := s.TableAlias(); alias != "" {
+		clause += " " + alias
+	}
+	if output.WhereClause != "" {
+		clause += " WHERE " + output.WhereClause
+	}
+	return clause
+}
+
+// Lookup returns the value configured for a schema name, or the name itself. Names are
+// matched exactly first, then case-insensitively, as configuration files lowercase keys.
+func Lookup(names map[string]string, schemaName string) string {
+	if name, ok := names[schemaName]; ok {
+		return name
+	}
+	for key, name := range names {
+		if strings.EqualFold(key, schemaName) {
+			return name
+		}
+	}
+	return schemaName
+}
@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openShop opens an executor on a SQLite database whose catalog table holds the rows of
+// the products schema
+func openShop(t *testing.T) (*Executor, *schema.Schema) {
+	t.Helper()
+	e, err := Open("sqlite", filepath.Join(t.TempDir(), "shop.db"), map[string]string{"products": "catalog"})
+	require.NoError(t, err)
+	t.Cleanup(func() { e.Close() })
+
+	for _, statement := range []string{
+		"CREATE TABLE catalog (name TEXT, status TEXT, price REAL)",
+		"INSERT INTO catalog VALUES ('lamp', 'active', 25), ('desk', 'active', 150), ('chair', 'retired', 80)",
+	} {
+		_, err := e.db.Exec(statement)
+		require.NoError(t, err)
+	}
+	return e, schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText},
+		"status": {Type: schema.TypeText},
+		"price":  {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{DefaultField: "name"})
+}
+
+func translate(t *testing.T, query string, s *schema.Schema) *translator.TranslatorOutput {
+	t.Helper()
+	ast, err := parser.NewParser(query).Parse()
+	require.NoError(t, err)
+	output, err := translator.NewSQLiteTranslator().Translate(ast, s)
+	require.NoError(t, err)
+	return output
+}
+
+func TestExecutor_Count(t *testing.T) {
+	e, s := openShop(t)
+	assert.Equal(t, "sqlite", e.Database())
+
+	count, err := e.Count(context.Background(), s, translate(t, "status:active", s))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	count, err = e.Count(context.Background(), s, translate(t, "status:active AND price:>100", s))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// Translations with a table alias count the table under it
+	aliased, err := s.WithTableAlias("p")
+	require.NoError(t, err)
+	count, err = e.Count(context.Background(), aliased, translate(t, "price:<100", aliased))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestExecutor_Explain(t *testing.T) {
+	e, s := openShop(t)
+	_, err := e.db.Exec("CREATE INDEX catalog_status ON catalog (status)")
+	require.NoError(t, err)
+
+	plan, err := e.Explain(context.Background(), s, translate(t, "status:active", s))
+	require.NoError(t, err)
+	var steps []queryPlanStep
+	require.NoError(t, json.Unmarshal(plan, &steps))
+	require.Len(t, steps, 1)
+	assert.Contains(t, steps[0].Detail, "USING INDEX catalog_status")
+
+	// The plan covers the order of the results
+	output := translate(t, "price:>10", s)
+	require.NoError(t, translator.ApplySort(output, []schema.SortField{{Field: "name"}}, s))
+	plan, err = e.Explain(context.Background(), s, output)
+	require.NoError(t, err)
+	assert.Contains(t, string(plan), "USE TEMP B-TREE FOR ORDER BY")
+}
+
+func TestExecutor_InvalidTable(t *testing.T) {
+	e, err := Open("sqlite", filepath.Join(t.TempDir(), "shop.db"), map[string]string{"products": "catalog; DROP TABLE catalog"})
+	require.NoError(t, err)
+	defer e.Close()
+
+	s := schema.NewSchema("products", map[string]schema.Field{"status": {Type: schema.TypeText}}, schema.SchemaOptions{})
+	_, err = e.Count(context.Background(), s, translate(t, "status:active", s))
+	assert.EqualError(t, err, `invalid table name "catalog; DROP TABLE catalog" for schema products`)
+}
+
+func TestOpen(t *testing.T) {
+	_, err := Open("opensearch", "http://localhost:9200", nil)
+	assert.EqualError(t, err, `unsupported executor database "opensearch": must be one of: postgres, mysql, sqlite, mongodb`)
+
+	_, err = Open("mongodb", "mongodb://localhost:27017", nil)
+	assert.EqualError(t, err, "mongodb URI must name the database, e.g. mongodb://host:27017/shop")
+
+	// Connections are made when first needed
+	e, err := Open("mongodb", "mongodb://localhost:27017/shop", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "mongodb", e.Database())
+	assert.NoError(t, e.Close())
+}
+
+func TestLookup(t *testing.T) {
+	tables := map[string]string{"products": "catalog", "orderitems": "order_items"}
+	assert.Equal(t, "catalog", Lookup(tables, "products"))
+	assert.Equal(t, "order_items", Lookup(tables, "orderItems"))
+	assert.Equal(t, "customers", Lookup(tables, "customers"))
+}
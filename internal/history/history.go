@@ -0,0 +1,111 @@
+// Package history keeps the most recent translate requests of each schema, so that the
+// queries behind a production incident can be listed and replayed against a new schema
+// version or another database. History is opt-in: requests hold the query strings
+// clients send, which may contain personal data.
+package history
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// Entry is a translate request as it was received, and how it was answered
+type Entry struct {
+	ID            uint64                   `json:"id"`
+	RequestID     string                   `json:"requestId,omitempty"`
+	Time          time.Time                `json:"time"`
+	SchemaVersion uint64                   `json:"schemaVersion"` // version of the schema the request was translated against
+	Status        int                      `json:"status"`        // HTTP status of the response
+	Request       rsearch.TranslateRequest `json:"request"`
+}
+
+// Store holds the last entries of each schema in a ring buffer. It is safe for
+// concurrent use.
+type Store struct {
+	size int
+
+	mu      sync.Mutex
+	lastID  uint64
+	schemas map[string]*ring
+}
+
+// ring is a fixed-size buffer of entries, overwriting the oldest when full
+type ring struct {
+	entries []Entry
+	next    int // index the next entry is written at once the buffer is full
+}
+
+// NewStore creates a store keeping the last size entries of each schema
+func NewStore(size int) *Store {
+	if size < 1 {
+		size = 1
+	}
+	return &Store{size: size, schemas: make(map[string]*ring)}
+}
+
+// Record adds an entry to the history of its request's schema, and returns it with the
+// ID it was given. IDs increase with every entry, across schemas.
+func (s *Store) Record(entry Entry) Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastID++
+	entry.ID = s.lastID
+
+	r, ok := s.schemas[entry.Request.Schema]
+	if !ok {
+		r = &ring{entries: make([]Entry, 0, s.size)}
+		s.schemas[entry.Request.Schema] = r
+	}
+	if len(r.entries) < s.size {
+		r.entries = append(r.entries, entry)
+	} else {
+		r.entries[r.next] = entry
+		r.next = (r.next + 1) % s.size
+	}
+	return entry
+}
+
+// List returns up to limit entries, newest first, of one schema or, when schemaName is
+// empty, of every schema. A limit of 0 returns every entry kept.
+func (s *Store) List(schemaName string, limit int) []Entry {
+	s.mu.Lock()
+	var entries []Entry
+	for name, r := range s.schemas {
+		if schemaName == "" || name == schemaName {
+			entries = append(entries, r.entries...)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID > entries[j].ID })
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+// Get returns the entry with the given ID, if it is still kept
+func (s *Store) Get(id uint64) (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.schemas {
+		for _, entry := range r.entries {
+			if entry.ID == id {
+				return entry, true
+			}
+		}
+	}
+	return Entry{}, false
+}
+
+// Forget drops the history of a schema, such as one that was deleted
+func (s *Store) Forget(schemaName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.schemas, schemaName)
+}
@@ -0,0 +1,65 @@
+package history
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/infiniv/rsearch/pkg/rsearch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func entry(schemaName, query string) Entry {
+	return Entry{Status: 200, Request: rsearch.TranslateRequest{Schema: schemaName, Database: "postgres", Query: query}}
+}
+
+func queries(entries []Entry) []string {
+	result := make([]string, len(entries))
+	for i, e := range entries {
+		result[i] = e.Request.Query
+	}
+	return result
+}
+
+func TestStore_RingBuffer(t *testing.T) {
+	store := NewStore(3)
+	for i := 1; i <= 5; i++ {
+		recorded := store.Record(entry("products", fmt.Sprintf("id:%d", i)))
+		assert.Equal(t, uint64(i), recorded.ID)
+	}
+	store.Record(entry("orders", "status:open"))
+
+	assert.Equal(t, []string{"id:5", "id:4", "id:3"}, queries(store.List("products", 0)))
+	assert.Equal(t, []string{"status:open", "id:5"}, queries(store.List("", 2)))
+	assert.Empty(t, store.List("customers", 0))
+
+	e, ok := store.Get(4)
+	require.True(t, ok)
+	assert.Equal(t, "id:4", e.Request.Query)
+
+	// Overwritten by newer entries
+	_, ok = store.Get(1)
+	assert.False(t, ok)
+
+	store.Forget("products")
+	assert.Equal(t, []string{"status:open"}, queries(store.List("", 0)))
+}
+
+func TestStore_Concurrent(t *testing.T) {
+	store := NewStore(10)
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			store.Record(entry("products", fmt.Sprintf("id:%d", i)))
+			store.List("products", 5)
+		}()
+	}
+	wg.Wait()
+
+	entries := store.List("products", 0)
+	assert.Len(t, entries, 10)
+	assert.Equal(t, uint64(50), entries[0].ID)
+}
@@ -0,0 +1,93 @@
+package ir
+
+import "reflect"
+
+// Equivalent reports whether two nodes are the same predicate: the same operators on the
+// same columns with the same values, whatever names their fields were queried by and
+// whatever their boosts and groups. Chains of And and Or compare by their clauses, which
+// must be in the same order.
+func Equivalent(a, b Node) bool {
+	a, b = unwrapAll(a), unwrapAll(b)
+	switch x := a.(type) {
+	case *And:
+		_, ok := b.(*And)
+		return ok && equivalentClauses(Conjuncts(x), Conjuncts(b))
+	case *Or:
+		_, ok := b.(*Or)
+		return ok && equivalentClauses(disjuncts(x), disjuncts(b))
+	case *Not:
+		y, ok := b.(*Not)
+		return ok && Equivalent(x.Clause, y.Clause)
+	case *Equal:
+		y, ok := b.(*Equal)
+		return ok && x.Column.Name == y.Column.Name && reflect.DeepEqual(x.Value, y.Value)
+	case *Wildcard:
+		y, ok := b.(*Wildcard)
+		return ok && x.Column.Name == y.Column.Name && x.Pattern == y.Pattern
+	case *Regex:
+		y, ok := b.(*Regex)
+		return ok && x.Column.Name == y.Column.Name && x.Value.Pattern == y.Value.Pattern && x.Value.Flags == y.Value.Flags
+	case *Phrase:
+		y, ok := b.(*Phrase)
+		return ok && x.Column.Name == y.Column.Name && x.Phrase == y.Phrase
+	case *Range:
+		y, ok := b.(*Range)
+		return ok && x.Column.Name == y.Column.Name && reflect.DeepEqual(x.Start, y.Start) && reflect.DeepEqual(x.End, y.End)
+	case *Exists:
+		y, ok := b.(*Exists)
+		return ok && x.Column.Name == y.Column.Name
+	case *Fuzzy:
+		y, ok := b.(*Fuzzy)
+		return ok && x.Column.Name == y.Column.Name && x.Term == y.Term && x.Distance == y.Distance
+	case *Proximity:
+		y, ok := b.(*Proximity)
+		return ok && x.Column.Name == y.Column.Name && x.Phrase == y.Phrase && x.Distance == y.Distance
+	case *In:
+		y, ok := b.(*In)
+		return ok && x.Column.Name == y.Column.Name && x.Exclude == y.Exclude && reflect.DeepEqual(x.Values, y.Values)
+	case *Fragment:
+		y, ok := b.(*Fragment)
+		return ok && x.Name == y.Name && x.Negated == y.Negated
+	}
+	return false
+}
+
+func equivalentClauses(a, b []Node) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !Equivalent(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Conjuncts returns the clauses a node requires: the clauses of a chain of Ands, looking
+// through groups, or the node itself
+func Conjuncts(node Node) []Node {
+	and, ok := unwrapAll(node).(*And)
+	if !ok {
+		return []Node{node}
+	}
+	var clauses []Node
+	for _, clause := range and.Clauses {
+		clauses = append(clauses, Conjuncts(clause)...)
+	}
+	return clauses
+}
+
+// disjuncts returns the clauses of a chain of Ors, looking through groups, or the node
+// itself
+func disjuncts(node Node) []Node {
+	or, ok := unwrapAll(node).(*Or)
+	if !ok {
+		return []Node{node}
+	}
+	var clauses []Node
+	for _, clause := range or.Clauses {
+		clauses = append(clauses, disjuncts(clause)...)
+	}
+	return clauses
+}
@@ -0,0 +1,168 @@
+// Package ir is the dialect-neutral form translators emit queries from. Lowering a
+// parsed query resolves every predicate to its schema column, expands field groups and
+// standalone terms into ordinary predicates and folds required clauses away, so an
+// emitter only handles a handful of node types. Boolean operations and groups keep the
+// shape the query was written in, so translations keep their parentheses.
+package ir
+
+import (
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// Node is a node of the intermediate representation
+type Node interface {
+	irNode()
+}
+
+// Column is the schema field a predicate applies to
+type Column struct {
+	Field string        // field name as queried, or the default field
+	Name  string        // column or document key the field maps to
+	Def   *schema.Field // the field's definition
+}
+
+// And matches when all of its clauses match. Lowering joins the two sides of an AND,
+// or the members of a field group the field group operator joins by AND.
+type And struct {
+	Clauses []Node
+}
+
+// Or matches when any of its clauses matches. Lowering joins the two sides of an OR,
+// or the members of a field group.
+type Or struct {
+	Clauses []Node
+}
+
+// Group matches as its clause does: a parenthesized query, or a field group member
+// joining values, which SQL emitters parenthesize
+type Group struct {
+	Clause Node
+}
+
+// Not matches when its clause does not
+type Not struct {
+	Clause     Node
+	Prohibited bool // written with a leading -, rather than NOT
+}
+
+// Boost matches as its clause does. The boost is scoring metadata, reported alongside
+// the translation rather than emitted.
+type Boost struct {
+	Clause Node
+	Boost  float64
+	Query  parser.Node // the boosted query, as parsed
+}
+
+// Equal compares a column with a value
+type Equal struct {
+	Column Column
+	Value  interface{}
+}
+
+// Wildcard matches a column against a pattern where * is any run of characters and ?
+// any single character
+type Wildcard struct {
+	Column  Column
+	Pattern string
+}
+
+// Regex matches a column against a regular expression
+type Regex struct {
+	Column Column
+	Value  *parser.RegexValue
+}
+
+// Phrase matches a phrase in a column, as the field's phrase match mode decides
+type Phrase struct {
+	Column Column
+	Phrase string
+}
+
+// Bound is one end of a range
+type Bound struct {
+	Value     interface{}
+	Inclusive bool
+}
+
+// Range compares a column with its bounds. A nil bound leaves that end open.
+type Range struct {
+	Column     Column
+	Start, End *Bound
+}
+
+// Exists matches when a column holds a value
+type Exists struct {
+	Column Column
+}
+
+// Fuzzy matches terms within an edit distance of Term
+type Fuzzy struct {
+	Column   Column
+	Term     string
+	Distance int
+	Query    *parser.FuzzyQuery // the query as parsed, reported in downgrades
+}
+
+// Proximity matches the words of Phrase within Distance words of each other
+type Proximity struct {
+	Column   Column
+	Phrase   string
+	Distance int
+	Query    *parser.ProximityQuery // the query as parsed, reported in downgrades
+}
+
+// In matches a column equal to any of its values, or with Exclude to none of them
+type In struct {
+	Column  Column
+	Values  []interface{}
+	Exclude bool
+}
+
+// Fragment applies a schema fragment, or with Negated its negation
+type Fragment struct {
+	Name     string
+	Fragment *schema.Fragment
+	Negated  bool
+}
+
+func (*And) irNode()       {}
+func (*Or) irNode()        {}
+func (*Group) irNode()     {}
+func (*Not) irNode()       {}
+func (*Boost) irNode()     {}
+func (*Equal) irNode()     {}
+func (*Wildcard) irNode()  {}
+func (*Regex) irNode()     {}
+func (*Phrase) irNode()    {}
+func (*Range) irNode()     {}
+func (*Exists) irNode()    {}
+func (*Fuzzy) irNode()     {}
+func (*Proximity) irNode() {}
+func (*In) irNode()        {}
+func (*Fragment) irNode()  {}
+
+// Unwrap returns the clause a node matches as, looking through boosts
+func Unwrap(node Node) Node {
+	for {
+		boost, ok := node.(*Boost)
+		if !ok {
+			return node
+		}
+		node = boost.Clause
+	}
+}
+
+// unwrapAll returns the clause a node matches as, looking through boosts and groups
+func unwrapAll(node Node) Node {
+	for {
+		switch n := node.(type) {
+		case *Boost:
+			node = n.Clause
+		case *Group:
+			node = n.Clause
+		default:
+			return node
+		}
+	}
+}
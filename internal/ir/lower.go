@@ -0,0 +1,334 @@
+package ir
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// Lower lowers a prepared query to the intermediate representation, resolving its
+// fields against the schema. Unknown fields, and standalone terms without a default
+// field, are reported as errors.
+func Lower(node parser.Node, s *schema.Schema) (Node, error) {
+	switch n := node.(type) {
+	case *parser.FieldQuery:
+		return lowerFieldQuery(n, s)
+	case *parser.BinaryOp:
+		return lowerBinaryOp(n, func(child parser.Node) (Node, error) {
+			return Lower(child, s)
+		})
+	case *parser.RangeQuery:
+		column, err := resolve(n.Field, s)
+		if err != nil {
+			return nil, err
+		}
+		return &Range{Column: column, Start: bound(n.Start, n.InclusiveStart), End: bound(n.End, n.InclusiveEnd)}, nil
+	case *parser.UnaryOp:
+		switch n.Op {
+		case "+":
+			return Lower(n.Operand, s)
+		case "-", "NOT":
+			return negate(n.Operand, s)
+		}
+		return nil, fmt.Errorf("unsupported unary operator: %s", n.Op)
+	case *parser.ExistsQuery:
+		column, err := resolve(n.Field, s)
+		if err != nil {
+			return nil, err
+		}
+		return &Exists{Column: column}, nil
+	case *parser.BoostQuery:
+		clause, err := Lower(n.Query, s)
+		if err != nil {
+			return nil, err
+		}
+		return &Boost{Clause: clause, Boost: n.Boost, Query: n.Query}, nil
+	case *parser.GroupQuery:
+		clause, err := Lower(n.Query, s)
+		if err != nil {
+			return nil, err
+		}
+		return &Group{Clause: clause}, nil
+	case *parser.RequiredQuery:
+		return Lower(n.Query, s)
+	case *parser.ProhibitedQuery:
+		node, err := negate(n.Query, s)
+		if not, ok := node.(*Not); ok {
+			not.Prohibited = true
+		}
+		return node, err
+	case *parser.TermQuery:
+		column, err := defaultColumn(s, "term", n.Term)
+		if err != nil {
+			return nil, err
+		}
+		return &Equal{Column: column, Value: n.Term}, nil
+	case *parser.PhraseQuery:
+		column, err := defaultColumn(s, "phrase", n.Phrase)
+		if err != nil {
+			return nil, err
+		}
+		return &Phrase{Column: column, Phrase: n.Phrase}, nil
+	case *parser.WildcardQuery:
+		column, err := defaultColumn(s, "wildcard", n.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		return &Wildcard{Column: column, Pattern: n.Pattern}, nil
+	case *parser.FuzzyQuery:
+		field := n.Field
+		if field == "" {
+			if s.Options.DefaultField == "" {
+				return nil, fmt.Errorf("fuzzy search '%s~%d' requires a field or default field in schema", n.Term, n.Distance)
+			}
+			field = s.Options.DefaultField
+		}
+		column, err := resolve(field, s)
+		if err != nil {
+			return nil, err
+		}
+		return &Fuzzy{Column: column, Term: n.Term, Distance: n.Distance, Query: n}, nil
+	case *parser.ProximityQuery:
+		field := n.Field
+		if field == "" {
+			if s.Options.DefaultField == "" {
+				return nil, fmt.Errorf("proximity search requires a field or default field in schema")
+			}
+			field = s.Options.DefaultField
+		}
+		column, err := resolve(field, s)
+		if err != nil {
+			return nil, err
+		}
+		return &Proximity{Column: column, Phrase: n.Phrase, Distance: n.Distance, Query: n}, nil
+	case *parser.FieldGroupQuery:
+		return lowerFieldGroup(n, s)
+	case *parser.InListQuery:
+		return lowerList(n, s, false)
+	}
+	return nil, fmt.Errorf("unsupported node type: %s", node.Type())
+}
+
+// lowerFieldQuery lowers field:value, or a fragment pseudo-field for names that are
+// not fields
+func lowerFieldQuery(fq *parser.FieldQuery, s *schema.Schema) (Node, error) {
+	column, err := resolve(fq.Field, s)
+	if err != nil {
+		if fragment, ok := s.Fragment(fq.Field); ok {
+			return lowerFragment(fq, fragment)
+		}
+		return nil, err
+	}
+
+	switch v := fq.Value.(type) {
+	case *parser.WildcardValue:
+		return &Wildcard{Column: column, Pattern: v.Pattern}, nil
+	case *parser.RegexValue:
+		return &Regex{Column: column, Value: v}, nil
+	case *parser.PhraseValue:
+		return &Phrase{Column: column, Phrase: v.Phrase}, nil
+	}
+	return &Equal{Column: column, Value: fq.Value.Value()}, nil
+}
+
+// lowerFragment reads the true or false a fragment pseudo-field is queried with:
+// inStock:true applies the fragment and inStock:false negates it
+func lowerFragment(fq *parser.FieldQuery, fragment *schema.Fragment) (Node, error) {
+	if term, ok := fq.Value.(*parser.TermValue); ok {
+		switch strings.ToLower(term.Term) {
+		case "true":
+			return &Fragment{Name: fq.Field, Fragment: fragment}, nil
+		case "false":
+			return &Fragment{Name: fq.Field, Fragment: fragment, Negated: true}, nil
+		}
+	}
+	return nil, fmt.Errorf("fragment %s only accepts true or false", fq.Field)
+}
+
+// lowerBinaryOp lowers both sides of AND or OR with lowerChild and joins them
+func lowerBinaryOp(bo *parser.BinaryOp, lowerChild func(parser.Node) (Node, error)) (Node, error) {
+	left, err := lowerChild(bo.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := lowerChild(bo.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToUpper(bo.Op) {
+	case "AND":
+		return &And{Clauses: []Node{left, right}}, nil
+	case "OR":
+		return &Or{Clauses: []Node{left, right}}, nil
+	}
+	return nil, fmt.Errorf("unsupported binary operator: %s", bo.Op)
+}
+
+// lowerFieldGroup lowers field:(a OR b) to predicates on the field, grouped. Members
+// written next to each other are joined by the schema's field group operator, OR unless
+// set to AND.
+func lowerFieldGroup(fg *parser.FieldGroupQuery, s *schema.Schema) (Node, error) {
+	if len(fg.Queries) == 0 {
+		return nil, fmt.Errorf("empty field group query")
+	}
+
+	column, err := resolve(fg.Field, s)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]Node, 0, len(fg.Queries))
+	for _, query := range fg.Queries {
+		member, err := lowerMember(query, column, s)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	switch {
+	case len(members) == 1:
+		return members[0], nil
+	case s.FieldGroupOperator() == schema.FieldGroupAnd:
+		return &Group{Clause: &And{Clauses: members}}, nil
+	}
+	return &Group{Clause: &Or{Clauses: members}}, nil
+}
+
+// lowerMember lowers a member of a field group. Terms and wildcards match the group's
+// column, and values written next to each other are joined by the field group operator
+// and grouped; other queries lower as they would outside the group.
+func lowerMember(node parser.Node, column Column, s *schema.Schema) (Node, error) {
+	switch n := node.(type) {
+	case *parser.TermQuery:
+		return &Equal{Column: column, Value: n.Term}, nil
+	case *parser.WildcardQuery:
+		return &Wildcard{Column: column, Pattern: n.Pattern}, nil
+	case *parser.BinaryOp:
+		if n.Implicit && s.FieldGroupOperator() == schema.FieldGroupAnd {
+			n = &parser.BinaryOp{Op: "AND", Left: n.Left, Right: n.Right, Pos: n.Pos}
+		}
+		joined, err := lowerBinaryOp(n, func(child parser.Node) (Node, error) {
+			return lowerMember(child, column, s)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &Group{Clause: joined}, nil
+	}
+	return Lower(node, s)
+}
+
+// lowerList lowers an in-list to an In
+func lowerList(iq *parser.InListQuery, s *schema.Schema, exclude bool) (Node, error) {
+	column, err := resolve(iq.Field, s)
+	if err != nil {
+		return nil, err
+	}
+	if len(iq.Values) == 0 {
+		return nil, fmt.Errorf("empty list for field %s", iq.Field)
+	}
+
+	values := make([]interface{}, len(iq.Values))
+	for i, value := range iq.Values {
+		values[i] = value.Value()
+	}
+	return &In{Column: column, Values: values, Exclude: exclude}, nil
+}
+
+// negate lowers a negated query. Negated in-lists, and field groups of terms joined by
+// OR such as -region:(ca us), lower to an In excluding their values.
+func negate(node parser.Node, s *schema.Schema) (Node, error) {
+	if list, ok := exclusionList(node, s); ok {
+		return lowerList(list, s, true)
+	}
+
+	clause, err := Lower(node, s)
+	if err != nil {
+		return nil, err
+	}
+	return &Not{Clause: clause}, nil
+}
+
+// exclusionList returns the list a negated clause excludes: an in-list, or a field group
+// of terms joined by OR. Other clauses, including groups of terms the field group
+// operator joins by AND, are negated as usual.
+func exclusionList(node parser.Node, s *schema.Schema) (*parser.InListQuery, bool) {
+	for {
+		group, ok := node.(*parser.GroupQuery)
+		if !ok {
+			break
+		}
+		node = group.Query
+	}
+
+	switch n := node.(type) {
+	case *parser.InListQuery:
+		return n, true
+	case *parser.FieldGroupQuery:
+		implicitAnd := s.FieldGroupOperator() == schema.FieldGroupAnd
+		if len(n.Queries) > 1 && implicitAnd {
+			return nil, false
+		}
+		var values []parser.ValueNode
+		for _, member := range n.Queries {
+			if !collectGroupTerms(member, implicitAnd, &values) {
+				return nil, false
+			}
+		}
+		if len(values) == 0 {
+			return nil, false
+		}
+		return &parser.InListQuery{Field: n.Field, Values: values, Pos: n.Pos}, true
+	}
+	return nil, false
+}
+
+// collectGroupTerms appends the terms of a field group member, reporting false for
+// members that are not terms joined by OR. With implicitAnd, terms written next to each
+// other are joined by AND.
+func collectGroupTerms(node parser.Node, implicitAnd bool, values *[]parser.ValueNode) bool {
+	switch n := node.(type) {
+	case *parser.TermQuery:
+		*values = append(*values, &parser.TermValue{Term: n.Term, Pos: n.Pos})
+		return true
+	case *parser.BinaryOp:
+		if strings.ToUpper(n.Op) != "OR" || (n.Implicit && implicitAnd) {
+			return false
+		}
+		return collectGroupTerms(n.Left, implicitAnd, values) && collectGroupTerms(n.Right, implicitAnd, values)
+	}
+	return false
+}
+
+// resolve resolves a queried field to its column
+func resolve(field string, s *schema.Schema) (Column, error) {
+	name, def, err := s.ResolveField(field)
+	if err != nil {
+		return Column{}, fmt.Errorf("field %s not found in schema %s", field, s.Name)
+	}
+	return Column{Field: field, Name: name, Def: def}, nil
+}
+
+// defaultColumn resolves the default field a standalone term, phrase or wildcard
+// matches
+func defaultColumn(s *schema.Schema, kind, value string) (Column, error) {
+	if s.Options.DefaultField == "" {
+		return Column{}, fmt.Errorf("standalone %s '%s' requires a default field in schema", kind, value)
+	}
+	column, err := resolve(s.Options.DefaultField, s)
+	if err != nil {
+		return Column{}, fmt.Errorf("default field %s not found in schema %s", s.Options.DefaultField, s.Name)
+	}
+	return column, nil
+}
+
+// bound returns a range bound, or nil for an open end written as *
+func bound(value parser.ValueNode, inclusive bool) *Bound {
+	if value == nil || value.Value() == "*" {
+		return nil
+	}
+	return &Bound{Value: value.Value(), Inclusive: inclusive}
+}
@@ -0,0 +1,302 @@
+package ir
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lower(t *testing.T, query string, s *schema.Schema) (Node, error) {
+	t.Helper()
+	ast, err := parser.NewParser(query).Parse()
+	require.NoError(t, err)
+	return Lower(ast, s)
+}
+
+func column(s *schema.Schema, field string) Column {
+	name, def, _ := s.ResolveField(field)
+	return Column{Field: field, Name: name, Def: def}
+}
+
+func TestLower_KeepsBooleanStructure(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":      {Type: schema.TypeText},
+		"status":    {Type: schema.TypeText},
+		"region":    {Type: schema.TypeText},
+		"createdAt": {Type: schema.TypeDateTime},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+
+	node, err := lower(t, "name:a AND status:b AND (region:c OR name:d)", s)
+	require.NoError(t, err)
+
+	name, status, region := column(s, "name"), column(s, "status"), column(s, "region")
+	assert.Equal(t, &And{Clauses: []Node{
+		&And{Clauses: []Node{
+			&Equal{Column: name, Value: "a"},
+			&Equal{Column: status, Value: "b"},
+		}},
+		&Group{Clause: &Or{Clauses: []Node{
+			&Equal{Column: region, Value: "c"},
+			&Equal{Column: name, Value: "d"},
+		}}},
+	}}, node)
+}
+
+func TestLower_FieldGroups(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":      {Type: schema.TypeText},
+		"status":    {Type: schema.TypeText},
+		"region":    {Type: schema.TypeText},
+		"createdAt": {Type: schema.TypeDateTime},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+
+	status := column(s, "status")
+
+	node, err := lower(t, "status:(active OR pend* OR a AND b)", s)
+	require.NoError(t, err)
+	assert.Equal(t, &Group{Clause: &Or{Clauses: []Node{
+		&Group{Clause: &Or{Clauses: []Node{
+			&Equal{Column: status, Value: "active"},
+			&Wildcard{Column: status, Pattern: "pend*"},
+		}}},
+		&Group{Clause: &And{Clauses: []Node{
+			&Equal{Column: status, Value: "a"},
+			&Equal{Column: status, Value: "b"},
+		}}},
+	}}}, node)
+
+	node, err = lower(t, "status:(active)", s)
+	require.NoError(t, err)
+	assert.Equal(t, &Equal{Column: status, Value: "active"}, node)
+}
+
+func TestLower_FieldGroupOperator(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":      {Type: schema.TypeText},
+		"status":    {Type: schema.TypeText},
+		"region":    {Type: schema.TypeText},
+		"createdAt": {Type: schema.TypeDateTime},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+
+	s, err := testSchema.WithFieldGroupOperator(schema.FieldGroupAnd)
+	require.NoError(t, err)
+	status := column(s, "status")
+
+	// Values written next to each other are all required; written operators are kept
+	node, err := lower(t, "status:(active pend*)", s)
+	require.NoError(t, err)
+	assert.Equal(t, &Group{Clause: &And{Clauses: []Node{
+		&Equal{Column: status, Value: "active"},
+		&Wildcard{Column: status, Pattern: "pend*"},
+	}}}, node)
+
+	node, err = lower(t, "status:(active OR held)", s)
+	require.NoError(t, err)
+	assert.Equal(t, &Group{Clause: &Or{Clauses: []Node{
+		&Equal{Column: status, Value: "active"},
+		&Equal{Column: status, Value: "held"},
+	}}}, node)
+
+	// Negating them excludes rows holding all of the values, not any of them
+	node, err = lower(t, "-status:(active held)", s)
+	require.NoError(t, err)
+	assert.Equal(t, &Not{Clause: &Group{Clause: &And{Clauses: []Node{
+		&Equal{Column: status, Value: "active"},
+		&Equal{Column: status, Value: "held"},
+	}}}, Prohibited: true}, node)
+
+	node, err = lower(t, "-status:(active OR held)", s)
+	require.NoError(t, err)
+	assert.Equal(t, &In{Column: status, Values: []interface{}{"active", "held"}, Exclude: true}, node)
+}
+
+func TestLower_Negation(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":      {Type: schema.TypeText},
+		"status":    {Type: schema.TypeText},
+		"region":    {Type: schema.TypeText},
+		"createdAt": {Type: schema.TypeDateTime},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+
+	region := column(s, "region")
+
+	tests := []struct {
+		query    string
+		expected Node
+	}{
+		{"-region:(ca OR us)", &In{Column: region, Values: []interface{}{"ca", "us"}, Exclude: true}},
+		{"NOT region:in(ca us)", &In{Column: region, Values: []interface{}{"ca", "us"}, Exclude: true}},
+		{"NOT region:ca", &Not{Clause: &Equal{Column: region, Value: "ca"}}},
+		{"-(region:ca AND region:us)", &Not{Clause: &Group{Clause: &And{Clauses: []Node{
+			&Equal{Column: region, Value: "ca"},
+			&Equal{Column: region, Value: "us"},
+		}}}, Prohibited: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			node, err := lower(t, tt.query, s)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, node)
+		})
+	}
+}
+
+func TestLower_DefaultField(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":      {Type: schema.TypeText},
+		"status":    {Type: schema.TypeText},
+		"region":    {Type: schema.TypeText},
+		"createdAt": {Type: schema.TypeDateTime},
+	}, schema.SchemaOptions{NamingConvention: "snake_case", DefaultField: "name"})
+
+	node, err := lower(t, `widget "blue widget" wid*`, s)
+	require.NoError(t, err)
+	name := column(s, "name")
+	assert.Equal(t, &Or{Clauses: []Node{
+		&Or{Clauses: []Node{
+			&Equal{Column: name, Value: "widget"},
+			&Phrase{Column: name, Phrase: "blue widget"},
+		}},
+		&Wildcard{Column: name, Pattern: "wid*"},
+	}}, node)
+
+	s.Options.DefaultField = ""
+	_, err = lower(t, "widget", s)
+	assert.EqualError(t, err, "standalone term 'widget' requires a default field in schema")
+}
+
+func TestLower_Ranges(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":      {Type: schema.TypeText},
+		"status":    {Type: schema.TypeText},
+		"region":    {Type: schema.TypeText},
+		"createdAt": {Type: schema.TypeDateTime},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+
+	node, err := lower(t, "createdAt:[2024-01-01 TO *}", s)
+	require.NoError(t, err)
+	assert.Equal(t, &Range{
+		Column: column(s, "createdAt"),
+		Start:  &Bound{Value: "2024-01-01", Inclusive: true},
+	}, node)
+}
+
+func TestLower_BoostsKeepTheirClause(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":      {Type: schema.TypeText},
+		"status":    {Type: schema.TypeText},
+		"region":    {Type: schema.TypeText},
+		"createdAt": {Type: schema.TypeDateTime},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+
+	node, err := lower(t, "name:a OR (name:b OR name:c)^2", s)
+	require.NoError(t, err)
+
+	or, ok := node.(*Or)
+	require.True(t, ok)
+	require.Len(t, or.Clauses, 2)
+	boost, ok := or.Clauses[1].(*Boost)
+	require.True(t, ok)
+	assert.Equal(t, 2.0, boost.Boost)
+	assert.IsType(t, &Group{}, Unwrap(boost))
+}
+
+func TestLower_Fragments(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":      {Type: schema.TypeText},
+		"status":    {Type: schema.TypeText},
+		"region":    {Type: schema.TypeText},
+		"createdAt": {Type: schema.TypeDateTime},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+	s.Fragments = map[string]schema.Fragment{"recent": {SQL: "{createdAt} > now() - interval '7 days'"}}
+
+	fragment, _ := s.Fragment("recent")
+
+	node, err := lower(t, "recent:false", s)
+	require.NoError(t, err)
+	assert.Equal(t, &Fragment{Name: "recent", Fragment: fragment, Negated: true}, node)
+
+	_, err = lower(t, "recent:maybe", s)
+	assert.EqualError(t, err, "fragment recent only accepts true or false")
+}
+
+func TestLower_Errors(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":      {Type: schema.TypeText},
+		"status":    {Type: schema.TypeText},
+		"region":    {Type: schema.TypeText},
+		"createdAt": {Type: schema.TypeDateTime},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+
+	_, err := lower(t, "name:a AND colour:red", s)
+	assert.EqualError(t, err, "field colour not found in schema products")
+
+	_, err = Lower(&parser.BinaryOp{
+		Op:    "XOR",
+		Left:  &parser.FieldQuery{Field: "name", Value: &parser.TermValue{Term: "a"}},
+		Right: &parser.FieldQuery{Field: "name", Value: &parser.TermValue{Term: "b"}},
+	}, s)
+	assert.EqualError(t, err, "unsupported binary operator: XOR")
+
+	_, err = Lower(&parser.FieldGroupQuery{Field: "name"}, s)
+	assert.EqualError(t, err, "empty field group query")
+}
+
+func TestEquivalent(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":      {Type: schema.TypeText},
+		"status":    {Type: schema.TypeText},
+		"region":    {Type: schema.TypeText},
+		"createdAt": {Type: schema.TypeDateTime},
+	}, schema.SchemaOptions{NamingConvention: "snake_case", DefaultField: "name"})
+
+	s.Fields["deletedAt"] = schema.Field{Type: schema.TypeDateTime, Aliases: []string{"removed"}}
+	s = schema.NewSchema(s.Name, s.Fields, s.Options)
+
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"status:active", "status:active^2", true},
+		{"NOT _exists_:deletedAt", "NOT _exists_:removed", true},
+		{"status:active", "status:inactive", false},
+		{"status:active", "region:active", false},
+		{"status:(a OR b)", "(status:a OR status:b)", true},
+		{"status:a AND region:b AND name:c", "status:a AND (region:b AND name:c)", true},
+		{"status:(a OR b)", "status:(b OR a)", false},
+		{"createdAt:[2024-01-01 TO *]", "createdAt:>=2024-01-01", true},
+		{"createdAt:[2024-01-01 TO *]", "createdAt:>2024-01-01", false},
+		{"_exists_:status", "NOT _exists_:status", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			a, err := lower(t, tt.a, s)
+			require.NoError(t, err)
+			b, err := lower(t, tt.b, s)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, Equivalent(a, b))
+		})
+	}
+}
+
+func TestConjuncts(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":      {Type: schema.TypeText},
+		"status":    {Type: schema.TypeText},
+		"region":    {Type: schema.TypeText},
+		"createdAt": {Type: schema.TypeDateTime},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+
+	node, err := lower(t, "status:a AND (region:b AND name:c)", s)
+	require.NoError(t, err)
+	assert.Len(t, Conjuncts(node), 3)
+
+	node, err = lower(t, "status:a OR region:b", s)
+	require.NoError(t, err)
+	assert.Equal(t, []Node{node}, Conjuncts(node))
+}
@@ -0,0 +1,269 @@
+package migration
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+)
+
+// IndexMapping maps OpenSearch indices to the schema of the table replacing them
+type IndexMapping struct {
+	Pattern string // index name or path.Match glob, e.g. "products-*"
+	Schema  string
+}
+
+// Assessment reports how much of a query corpus taken from OpenSearch logs translates
+type Assessment struct {
+	Database   string             `json:"database"`
+	Queries    int                `json:"queries"`
+	Unreadable int                `json:"unreadable"`
+	Schemas    []SchemaAssessment `json:"schemas"`
+	Unmapped   map[string]int     `json:"unmapped,omitempty"` // index -> queries without a schema
+}
+
+// SchemaAssessment holds the compatibility of the queries of one schema. Queries are
+// counted as often as they were logged; distinct counts count each query text once.
+type SchemaAssessment struct {
+	Schema             string        `json:"schema"`
+	Queries            int           `json:"queries"`
+	Compatible         int           `json:"compatible"`
+	Distinct           int           `json:"distinct"`
+	DistinctCompatible int           `json:"distinctCompatible"`
+	Failures           []FailureRate `json:"failures,omitempty"`
+}
+
+// FailureRate groups the queries failing for the same reason
+type FailureRate struct {
+	Reason  string `json:"reason"`
+	Queries int    `json:"queries"`
+	Example string `json:"example"` // first query failing for the reason
+}
+
+// positionRegex matches the position in parse errors, which differs between queries
+// failing for the same reason
+var positionRegex = regexp.MustCompile(` at line \d+, column \d+`)
+
+// Assess translates every query with the schema its index maps to. Indices without a
+// mapping map to the schema of the same name, if any.
+func Assess(scan LogScan, schemas []*schema.Schema, mappings []IndexMapping, trans translator.Translator) Assessment {
+	assessment := Assessment{
+		Database:   trans.DatabaseType(),
+		Queries:    len(scan.Queries),
+		Unreadable: scan.Unreadable,
+	}
+
+	byName := make(map[string]*schema.Schema, len(schemas))
+	for _, s := range schemas {
+		byName[s.Name] = s
+	}
+
+	type schemaQueries struct {
+		assessment SchemaAssessment
+		outcomes   map[string]string // query -> failure reason, empty when compatible
+		failures   map[string]*FailureRate
+	}
+	results := make(map[string]*schemaQueries)
+
+	// Every query sees the same clock, so date keywords translate alike
+	now := time.Now()
+	for _, query := range scan.Queries {
+		s := resolveIndex(query.Index, byName, mappings)
+		if s == nil {
+			if assessment.Unmapped == nil {
+				assessment.Unmapped = make(map[string]int)
+			}
+			assessment.Unmapped[query.Index]++
+			continue
+		}
+
+		result, ok := results[s.Name]
+		if !ok {
+			result = &schemaQueries{
+				assessment: SchemaAssessment{Schema: s.Name},
+				outcomes:   make(map[string]string),
+				failures:   make(map[string]*FailureRate),
+			}
+			results[s.Name] = result
+		}
+
+		reason, seen := result.outcomes[query.Text]
+		if !seen {
+			if translation := translate(query.Text, s, trans, now); translation.Error != "" {
+				reason = positionRegex.ReplaceAllString(translation.Error, "")
+			}
+			result.outcomes[query.Text] = reason
+			result.assessment.Distinct++
+			if reason == "" {
+				result.assessment.DistinctCompatible++
+			}
+		}
+
+		result.assessment.Queries++
+		if reason == "" {
+			result.assessment.Compatible++
+			continue
+		}
+		failure, ok := result.failures[reason]
+		if !ok {
+			failure = &FailureRate{Reason: reason, Example: query.Text}
+			result.failures[reason] = failure
+		}
+		failure.Queries++
+	}
+
+	for _, result := range results {
+		for _, failure := range result.failures {
+			result.assessment.Failures = append(result.assessment.Failures, *failure)
+		}
+		sort.Slice(result.assessment.Failures, func(i, j int) bool {
+			a, b := result.assessment.Failures[i], result.assessment.Failures[j]
+			if a.Queries != b.Queries {
+				return a.Queries > b.Queries
+			}
+			return a.Reason < b.Reason
+		})
+		assessment.Schemas = append(assessment.Schemas, result.assessment)
+	}
+	sort.Slice(assessment.Schemas, func(i, j int) bool {
+		return assessment.Schemas[i].Schema < assessment.Schemas[j].Schema
+	})
+
+	return assessment
+}
+
+// resolveIndex returns the schema of an index: the first mapping matching it, or the
+// schema of the same name
+func resolveIndex(index string, schemas map[string]*schema.Schema, mappings []IndexMapping) *schema.Schema {
+	for _, mapping := range mappings {
+		if matched, _ := path.Match(mapping.Pattern, index); matched {
+			return schemas[mapping.Schema]
+		}
+	}
+	return schemas[index]
+}
+
+// Compatible returns the fraction of the queries with a schema that translate
+func (a Assessment) Compatible() float64 {
+	compatible, total := a.totals()
+	if total == 0 {
+		return 0
+	}
+	return float64(compatible) / float64(total)
+}
+
+// totals counts the compatible queries and the queries with a schema
+func (a Assessment) totals() (compatible, total int) {
+	for _, s := range a.Schemas {
+		compatible += s.Compatible
+		total += s.Queries
+	}
+	return compatible, total
+}
+
+// WriteText writes a human-readable report with the failure reasons of each schema
+func (a Assessment) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	for _, s := range a.Schemas {
+		fmt.Fprintf(bw, "%s: %d of %d queries compatible (%s), %d of %d distinct\n",
+			s.Schema, s.Compatible, s.Queries, percent(s.Compatible, s.Queries), s.DistinctCompatible, s.Distinct)
+		for _, failure := range s.Failures {
+			fmt.Fprintf(bw, "  %6d  %s\n          e.g. %s\n", failure.Queries, failure.Reason, failure.Example)
+		}
+		fmt.Fprintln(bw)
+	}
+
+	if len(a.Unmapped) > 0 {
+		indices := make([]string, 0, len(a.Unmapped))
+		for index := range a.Unmapped {
+			indices = append(indices, index)
+		}
+		sort.Strings(indices)
+		fmt.Fprint(bw, "No schema for indices:")
+		for i, index := range indices {
+			if i > 0 {
+				fmt.Fprint(bw, ",")
+			}
+			name := index
+			if name == "" {
+				name = "(unknown)"
+			}
+			fmt.Fprintf(bw, " %s (%d)", name, a.Unmapped[index])
+		}
+		fmt.Fprintln(bw)
+	}
+	if a.Unreadable > 0 {
+		fmt.Fprintf(bw, "%d log entries with unreadable request bodies, possibly truncated by index.search.slowlog.source\n", a.Unreadable)
+	}
+
+	compatible, total := a.totals()
+	noun := "queries"
+	if a.Queries == 1 {
+		noun = "query"
+	}
+	fmt.Fprintf(bw, "%d %s (%s): %d of %d assessed compatible (%s)\n", a.Queries, noun, a.Database, compatible, total, percent(compatible, total))
+
+	return bw.Flush()
+}
+
+func percent(part, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(part)/float64(total))
+}
+
+// LoadSchemas reads the schema files, YAML or JSON, of a directory
+func LoadSchemas(dir string) ([]*schema.Schema, error) {
+	var paths []string
+	for _, pattern := range []string{"*.json", "*.yaml", "*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("failed to read schemas: %w", err)
+		}
+		return nil, fmt.Errorf("no schema files in %s", dir)
+	}
+
+	schemas := make([]*schema.Schema, 0, len(paths))
+	names := make(map[string]string, len(paths))
+	for _, path := range paths {
+		s, err := LoadSchema(path)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := names[s.Name]; ok {
+			return nil, fmt.Errorf("schema %s is defined in both %s and %s", s.Name, other, path)
+		}
+		names[s.Name] = path
+		schemas = append(schemas, s)
+	}
+	return schemas, nil
+}
+
+// ParseIndexMapping parses a mapping written pattern=schema
+func ParseIndexMapping(value string) (IndexMapping, error) {
+	pattern, schemaName, ok := strings.Cut(value, "=")
+	if !ok || pattern == "" || schemaName == "" {
+		return IndexMapping{}, fmt.Errorf("invalid index mapping %q: expected pattern=schema", value)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return IndexMapping{}, fmt.Errorf("invalid index mapping %q: %w", value, err)
+	}
+	return IndexMapping{Pattern: pattern, Schema: schemaName}, nil
+}
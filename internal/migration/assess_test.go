@@ -0,0 +1,96 @@
+package migration
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssess(t *testing.T) {
+	products := schema.NewSchema("products", map[string]schema.Field{
+		"name":  {Type: schema.TypeText},
+		"price": {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{DefaultField: "name"})
+	orders := schema.NewSchema("orders", map[string]schema.Field{
+		"status": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+
+	scan := LogScan{
+		Queries: []LogQuery{
+			{Line: 1, Index: "products-2025.01", Text: "name:laptop"},
+			{Line: 2, Index: "products-2025.02", Text: "name:laptop"},
+			{Line: 3, Index: "products-2025.02", Text: "sku:A-1"},
+			{Line: 4, Index: "products-2025.02", Text: "sku:B-2"},
+			{Line: 5, Index: "products-2025.02", Text: "name:(desk"},
+			{Line: 6, Index: "products-2025.02", Text: "price:(10"},
+			{Line: 7, Index: "orders", Text: "status:shipped"},
+			{Line: 8, Index: "logs", Text: "level:error"},
+		},
+		Unreadable: 1,
+	}
+
+	assessment := Assess(scan, []*schema.Schema{products, orders}, []IndexMapping{{Pattern: "products-*", Schema: "products"}}, translator.NewPostgresTranslator())
+	assert.Equal(t, "postgres", assessment.Database)
+	assert.Equal(t, 8, assessment.Queries)
+	assert.Equal(t, map[string]int{"logs": 1}, assessment.Unmapped)
+	require.Len(t, assessment.Schemas, 2)
+
+	assert.Equal(t, SchemaAssessment{Schema: "orders", Queries: 1, Compatible: 1, Distinct: 1, DistinctCompatible: 1}, assessment.Schemas[0])
+
+	// Parse errors at different positions fail for the same reason
+	assert.Equal(t, SchemaAssessment{
+		Schema:             "products",
+		Queries:            6,
+		Compatible:         2,
+		Distinct:           5,
+		DistinctCompatible: 1,
+		Failures: []FailureRate{
+			{Reason: "expected ')'", Queries: 2, Example: "name:(desk"},
+			{Reason: "field sku not found in schema products", Queries: 2, Example: "sku:A-1"},
+		},
+	}, assessment.Schemas[1])
+	assert.InDelta(t, 3.0/7, assessment.Compatible(), 1e-9)
+
+	var out bytes.Buffer
+	require.NoError(t, assessment.WriteText(&out))
+	text := out.String()
+	assert.Contains(t, text, "products: 2 of 6 queries compatible (33.3%), 1 of 5 distinct\n")
+	assert.Contains(t, text, "       2  field sku not found in schema products\n          e.g. sku:A-1\n")
+	assert.Contains(t, text, "No schema for indices: logs (1)\n")
+	assert.Contains(t, text, "1 log entries with unreadable request bodies")
+	assert.Contains(t, text, "8 queries (postgres): 3 of 7 assessed compatible (42.9%)\n")
+}
+
+func TestParseIndexMapping(t *testing.T) {
+	mapping, err := ParseIndexMapping("products-*=products")
+	require.NoError(t, err)
+	assert.Equal(t, IndexMapping{Pattern: "products-*", Schema: "products"}, mapping)
+
+	_, err = ParseIndexMapping("products")
+	assert.EqualError(t, err, `invalid index mapping "products": expected pattern=schema`)
+	_, err = ParseIndexMapping("products-[=products")
+	assert.Error(t, err)
+}
+
+func TestLoadSchemas(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "products.yaml"), []byte("name: products\nfields:\n  name: {type: text}\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "orders.json"), []byte(`{"name":"orders","fields":{"status":{"type":"text"}}}`), 0o644))
+
+	schemas, err := LoadSchemas(dir)
+	require.NoError(t, err)
+	assert.Len(t, schemas, 2)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "copy.yml"), []byte("name: products\nfields:\n  name: {type: text}\n"), 0o644))
+	_, err = LoadSchemas(dir)
+	assert.ErrorContains(t, err, "schema products is defined in both")
+
+	_, err = LoadSchemas(t.TempDir())
+	assert.ErrorContains(t, err, "no schema files")
+}
@@ -0,0 +1,251 @@
+// Package migration predicts how a schema change affects real queries.
+// A corpus of queries is translated under the old and the new schema version and
+// every query whose translation breaks or changes is reported, so field renames,
+// type changes and removed aliases can be checked before rollout.
+package migration
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"go.yaml.in/yaml/v3"
+)
+
+// Status classifies how a query is affected by the schema change
+type Status string
+
+const (
+	StatusUnchanged Status = "unchanged" // same translation under both versions
+	StatusChanged   Status = "changed"   // translates under both versions, differently
+	StatusBroken    Status = "broken"    // translates under the old version only
+	StatusFixed     Status = "fixed"     // translates under the new version only
+	StatusFailing   Status = "failing"   // fails under both versions
+)
+
+// Statuses lists all statuses in report order
+var Statuses = []Status{StatusBroken, StatusChanged, StatusFixed, StatusFailing, StatusUnchanged}
+
+// Query is one query of the corpus
+type Query struct {
+	Line int    `json:"line"`
+	Text string `json:"query"`
+}
+
+// Translation is the outcome of translating a query under one schema version
+type Translation struct {
+	Output         string        `json:"output,omitempty"` // WHERE clause, or the filter as JSON
+	Parameters     []interface{} `json:"parameters,omitempty"`
+	ParameterTypes []string      `json:"parameterTypes,omitempty"`
+	Error          string        `json:"error,omitempty"`
+}
+
+// Result compares the translations of one query
+type Result struct {
+	Query
+	Status Status      `json:"status"`
+	Old    Translation `json:"old"`
+	New    Translation `json:"new"`
+}
+
+// Report is the outcome of comparing a corpus
+type Report struct {
+	Database string         `json:"database"`
+	Summary  map[Status]int `json:"summary"`
+	Results  []Result       `json:"results"`
+}
+
+// Compare translates every query under both schema versions
+func Compare(oldSchema, newSchema *schema.Schema, trans translator.Translator, queries []Query) Report {
+	report := Report{
+		Database: trans.DatabaseType(),
+		Summary:  make(map[Status]int, len(Statuses)),
+		Results:  make([]Result, 0, len(queries)),
+	}
+	for _, status := range Statuses {
+		report.Summary[status] = 0
+	}
+
+	// Both versions see the same clock, so date keywords such as now cannot differ
+	now := time.Now()
+	for _, query := range queries {
+		result := Result{
+			Query: query,
+			Old:   translate(query.Text, oldSchema, trans, now),
+			New:   translate(query.Text, newSchema, trans, now),
+		}
+		result.Status = classify(result.Old, result.New)
+		report.Summary[result.Status]++
+		report.Results = append(report.Results, result)
+	}
+
+	return report
+}
+
+// Count returns the number of queries with any of the given statuses
+func (r Report) Count(statuses ...Status) int {
+	total := 0
+	for _, status := range statuses {
+		total += r.Summary[status]
+	}
+	return total
+}
+
+// WriteText writes a human-readable report of the affected queries and a summary
+func (r Report) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	for _, result := range r.Results {
+		if result.Status == StatusUnchanged {
+			continue
+		}
+		fmt.Fprintf(bw, "%s line %d: %s\n", strings.ToUpper(string(result.Status)), result.Line, result.Query.Text)
+		writeTranslation(bw, "old", result.Old)
+		writeTranslation(bw, "new", result.New)
+		fmt.Fprintln(bw)
+	}
+
+	noun := "queries"
+	if len(r.Results) == 1 {
+		noun = "query"
+	}
+	fmt.Fprintf(bw, "%d %s (%s):", len(r.Results), noun, r.Database)
+	for _, status := range Statuses {
+		fmt.Fprintf(bw, " %d %s", r.Summary[status], status)
+		if status != StatusUnchanged {
+			fmt.Fprint(bw, ",")
+		}
+	}
+	fmt.Fprintln(bw)
+
+	return bw.Flush()
+}
+
+func writeTranslation(w io.Writer, label string, t Translation) {
+	if t.Error != "" {
+		fmt.Fprintf(w, "  %s: error: %s\n", label, t.Error)
+		return
+	}
+	params, _ := json.Marshal(t.Parameters)
+	fmt.Fprintf(w, "  %s: %s  %s\n", label, t.Output, params)
+}
+
+// translate runs the translation pipeline of the translate endpoint, default filters included
+func translate(query string, sch *schema.Schema, trans translator.Translator, now time.Time) Translation {
+	ast, err := parser.NewParser(query).Parse()
+	if err != nil {
+		return Translation{Error: err.Error()}
+	}
+
+	filtered, _, err := translator.ApplyDefaultFilters(ast, sch, nil)
+	if err != nil {
+		return Translation{Error: err.Error()}
+	}
+
+	// Preparing at a fixed time pins date keywords; Translate leaves prepared ASTs unchanged
+	prepared, _, err := translator.Prepare(filtered, sch, now)
+	if err != nil {
+		return Translation{Error: err.Error()}
+	}
+
+	output, err := trans.Translate(prepared, sch)
+	if err != nil {
+		return Translation{Error: err.Error()}
+	}
+
+	result := Translation{
+		Output:         output.WhereClause,
+		Parameters:     output.Parameters,
+		ParameterTypes: output.ParameterTypes,
+	}
+	if output.Filter != nil {
+		filter, err := json.Marshal(output.Filter)
+		if err != nil {
+			return Translation{Error: err.Error()}
+		}
+		result.Output = string(filter)
+	}
+	return result
+}
+
+// classify compares the translations of a query
+func classify(old, new Translation) Status {
+	switch {
+	case old.Error != "" && new.Error != "":
+		return StatusFailing
+	case old.Error != "":
+		return StatusFixed
+	case new.Error != "":
+		return StatusBroken
+	case reflect.DeepEqual(old, new):
+		return StatusUnchanged
+	default:
+		return StatusChanged
+	}
+}
+
+// ReadQueries reads one query per line, skipping blank lines and lines starting with #
+func ReadQueries(r io.Reader) ([]Query, error) {
+	var queries []Query
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		queries = append(queries, Query{Line: line, Text: text})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read queries: %w", err)
+	}
+
+	return queries, nil
+}
+
+// LoadSchema reads and validates a schema definition in YAML or JSON.
+// Keys are the same as in the schema registration API.
+func LoadSchema(path string) (*schema.Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	// YAML is converted to JSON so that the schema's JSON field names apply
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("invalid schema YAML in %s: %w", path, err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("invalid schema YAML in %s: %w", path, err)
+		}
+	}
+
+	var s schema.Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema in %s: %w", path, err)
+	}
+
+	// Build through the constructor so lookup caches are initialized
+	loaded := schema.NewSchema(s.Name, s.Fields, s.Options)
+	loaded.Fragments = s.Fragments
+	if err := schema.ValidateSchema(loaded); err != nil {
+		return nil, fmt.Errorf("invalid schema in %s: %w", path, err)
+	}
+	if err := translator.ValidateDefaultFilters(loaded); err != nil {
+		return nil, fmt.Errorf("invalid schema in %s: %w", path, err)
+	}
+
+	return loaded, nil
+}
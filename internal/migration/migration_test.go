@@ -0,0 +1,131 @@
+package migration
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	oldSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":  {Type: schema.TypeText},
+		"price": {Type: schema.TypeFloat},
+		"sku":   {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+	newSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText, Column: "product_name"},
+		"price":  {Type: schema.TypeFloat},
+		"rating": {Type: schema.TypeInteger},
+	}, schema.SchemaOptions{})
+
+	queries := []Query{
+		{Line: 1, Text: "price:>10"},
+		{Line: 2, Text: "name:laptop"},
+		{Line: 3, Text: "sku:A-1"},
+		{Line: 4, Text: "rating:5"},
+		{Line: 5, Text: "color:red"},
+	}
+
+	report := Compare(oldSchema, newSchema, translator.NewPostgresTranslator(), queries)
+	require.Len(t, report.Results, 5)
+	assert.Equal(t, "postgres", report.Database)
+
+	statuses := make([]Status, len(report.Results))
+	for i, result := range report.Results {
+		statuses[i] = result.Status
+	}
+	assert.Equal(t, []Status{StatusUnchanged, StatusChanged, StatusBroken, StatusFixed, StatusFailing}, statuses)
+
+	changed := report.Results[1]
+	assert.Equal(t, "name = $1", changed.Old.Output)
+	assert.Equal(t, "product_name = $1", changed.New.Output)
+	assert.NotEmpty(t, report.Results[2].New.Error)
+
+	assert.Equal(t, 1, report.Summary[StatusBroken])
+	assert.Equal(t, 2, report.Count(StatusBroken, StatusChanged))
+
+	var out bytes.Buffer
+	require.NoError(t, report.WriteText(&out))
+	text := out.String()
+	assert.Contains(t, text, "CHANGED line 2: name:laptop\n  old: name = $1  [\"laptop\"]\n  new: product_name = $1  [\"laptop\"]\n")
+	assert.Contains(t, text, "BROKEN line 3: sku:A-1\n")
+	assert.NotContains(t, text, "price:>10")
+	assert.Contains(t, text, "5 queries (postgres): 1 broken, 1 changed, 1 fixed, 1 failing, 1 unchanged\n")
+}
+
+func TestCompare_MongoDB(t *testing.T) {
+	oldSchema := schema.NewSchema("products", map[string]schema.Field{"name": {Type: schema.TypeText}}, schema.SchemaOptions{})
+	newSchema := schema.NewSchema("products", map[string]schema.Field{"name": {Type: schema.TypeText, Column: "title"}}, schema.SchemaOptions{})
+
+	report := Compare(oldSchema, newSchema, translator.NewMongoDBTranslator(), []Query{{Line: 1, Text: "name:laptop"}})
+	assert.Equal(t, StatusChanged, report.Results[0].Status)
+	assert.Equal(t, `{"name":"laptop"}`, report.Results[0].Old.Output)
+	assert.Equal(t, `{"title":"laptop"}`, report.Results[0].New.Output)
+}
+
+func TestCompare_DefaultFilters(t *testing.T) {
+	oldSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":       {Type: schema.TypeText},
+		"deleted_at": {Type: schema.TypeDateTime},
+	}, schema.SchemaOptions{})
+	newSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":       {Type: schema.TypeText},
+		"deleted_at": {Type: schema.TypeDateTime},
+	}, schema.SchemaOptions{DefaultFilters: []schema.DefaultFilter{{Name: "not_deleted", Query: "NOT _exists_:deleted_at"}}})
+
+	report := Compare(oldSchema, newSchema, translator.NewPostgresTranslator(), []Query{{Line: 1, Text: "name:laptop"}})
+	assert.Equal(t, StatusChanged, report.Results[0].Status)
+	assert.Equal(t, "(name = $1) AND (NOT deleted_at IS NOT NULL)", report.Results[0].New.Output)
+}
+
+func TestReadQueries(t *testing.T) {
+	queries, err := ReadQueries(strings.NewReader("# corpus\nname:a\n\n  price:>5  \n"))
+	require.NoError(t, err)
+	assert.Equal(t, []Query{{Line: 2, Text: "name:a"}, {Line: 4, Text: "price:>5"}}, queries)
+}
+
+func TestLoadSchema(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, "products.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(`
+name: products
+fields:
+  productName:
+    type: text
+    aliases: [title]
+  price:
+    type: float
+options:
+  namingConvention: snake_case
+  defaultField: productName
+`), 0o644))
+
+	s, err := LoadSchema(yamlPath)
+	require.NoError(t, err)
+	assert.Equal(t, "products", s.Name)
+	assert.Equal(t, "snake_case", s.Options.NamingConvention)
+	column, _, err := s.ResolveField("title")
+	require.NoError(t, err)
+	assert.Equal(t, "product_name", column)
+
+	jsonPath := filepath.Join(dir, "products.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"name":"products","fields":{"price":{"type":"float"}}}`), 0o644))
+	_, err = LoadSchema(jsonPath)
+	require.NoError(t, err)
+
+	invalidPath := filepath.Join(dir, "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalidPath, []byte("name: products\nfields:\n  price:\n    type: money\n"), 0o644))
+	_, err = LoadSchema(invalidPath)
+	assert.Error(t, err)
+
+	_, err = LoadSchema(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
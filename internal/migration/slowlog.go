@@ -0,0 +1,183 @@
+package migration
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// LogQuery is a query_string query found in an OpenSearch or Elasticsearch log
+type LogQuery struct {
+	Line  int    `json:"line"`
+	Index string `json:"index"`
+	Text  string `json:"query"`
+}
+
+// LogScan holds the queries found in a log
+type LogScan struct {
+	Queries []LogQuery
+
+	// Unreadable counts entries whose request body could not be decoded, usually
+	// because the slow log truncated it (index.search.slowlog.source)
+	Unreadable int
+}
+
+// shardRegex matches the "[index][shard]" of slow log entries
+var shardRegex = regexp.MustCompile(`\[([^\[\]\s]+)\]\[\d+\]`)
+
+// Keys holding the request body, index and URI parameters of JSON log entries:
+// slow logs of OpenSearch and Elasticsearch 7, Elasticsearch 8 (ECS), the OpenSearch
+// security audit log and the Elasticsearch audit log
+var (
+	sourceKeys  = []string{"source", "elasticsearch.slowlog.source", "audit_request_body", "request.body"}
+	messageKeys = []string{"message", "elasticsearch.slowlog.message"}
+	indicesKeys = []string{"audit_trace_indices", "audit_trace_resolved_indices", "indices", "elasticsearch.index.name"}
+	paramsKeys  = []string{"audit_rest_request_params"}
+)
+
+// ReadLogQueries extracts the query_string queries of search slow logs and audit logs,
+// in their plain text or JSON formats. Every query_string clause of a request body is
+// a query, as is the q parameter of URI searches; entries without either are skipped.
+func ReadLogQueries(r io.Reader) (LogScan, error) {
+	var scan LogScan
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var index string
+		var queries []string
+		var ok bool
+		if strings.HasPrefix(text, "{") {
+			index, queries, ok = parseJSONEntry(text)
+		} else {
+			index, queries, ok = parseTextEntry(text)
+		}
+		if !ok {
+			scan.Unreadable++
+			continue
+		}
+		for _, query := range queries {
+			scan.Queries = append(scan.Queries, LogQuery{Line: line, Index: index, Text: query})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return LogScan{}, fmt.Errorf("failed to read log: %w", err)
+	}
+
+	return scan, nil
+}
+
+// parseTextEntry reads a plain text slow log entry:
+//
+//	[2025-01-01T12:00:00,000][WARN ][i.s.s.query] [node-1] [products][0] took[1.2s], ..., source[{"query":...}], id[],
+func parseTextEntry(text string) (string, []string, bool) {
+	start := strings.Index(text, "source[")
+	if start < 0 {
+		return "", nil, true
+	}
+	var index string
+	if match := shardRegex.FindStringSubmatch(text[:start]); match != nil {
+		index = match[1]
+	}
+
+	// The body is JSON, which may hold brackets itself, so it ends where decoding stops
+	body := strings.TrimSpace(text[start+len("source["):])
+	if strings.HasPrefix(body, "]") {
+		return index, nil, true
+	}
+	var source interface{}
+	if err := json.NewDecoder(strings.NewReader(body)).Decode(&source); err != nil {
+		return index, nil, false
+	}
+	return index, queryStrings(source, nil), true
+}
+
+// parseJSONEntry reads a JSON slow log or audit log entry
+func parseJSONEntry(text string) (string, []string, bool) {
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &entry); err != nil {
+		return "", nil, false
+	}
+
+	var index string
+	for _, key := range messageKeys {
+		if message, ok := entry[key].(string); ok {
+			if match := shardRegex.FindStringSubmatch(message); match != nil {
+				index = match[1]
+				break
+			}
+		}
+	}
+	for _, key := range indicesKeys {
+		if index != "" {
+			break
+		}
+		switch indices := entry[key].(type) {
+		case string:
+			index = indices
+		case []interface{}:
+			if len(indices) > 0 {
+				index, _ = indices[0].(string)
+			}
+		}
+	}
+
+	var queries []string
+	for _, key := range paramsKeys {
+		if params, ok := entry[key].(map[string]interface{}); ok {
+			if q, ok := params["q"].(string); ok && q != "" {
+				queries = append(queries, q)
+			}
+		}
+	}
+	for _, key := range sourceKeys {
+		body, ok := entry[key].(string)
+		if !ok || body == "" {
+			continue
+		}
+		var source interface{}
+		if err := json.Unmarshal([]byte(body), &source); err != nil {
+			return index, nil, false
+		}
+		queries = queryStrings(source, queries)
+		break
+	}
+	return index, queries, true
+}
+
+// queryStrings appends the query of every query_string clause in a request body
+func queryStrings(node interface{}, queries []string) []string {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		// Keys are sorted so that queries are reported in a stable order
+		keys := make([]string, 0, len(n))
+		for key := range n {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value := n[key]
+			if clause, ok := value.(map[string]interface{}); ok && key == "query_string" {
+				if query, ok := clause["query"].(string); ok && query != "" {
+					queries = append(queries, query)
+				}
+				continue
+			}
+			queries = queryStrings(value, queries)
+		}
+	case []interface{}:
+		for _, value := range n {
+			queries = queryStrings(value, queries)
+		}
+	}
+	return queries
+}
@@ -0,0 +1,44 @@
+package migration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadLogQueries(t *testing.T) {
+	log := strings.Join([]string{
+		// Plain text slow log
+		`[2025-01-01T12:00:00,000][WARN ][i.s.s.query              ] [node-1] [products][0] took[1.2s], took_millis[1200], total_hits[10 hits], types[], stats[], search_type[QUERY_THEN_FETCH], total_shards[1], source[{"query":{"bool":{"must":[{"query_string":{"query":"name:laptop AND price:[100 TO 500]"}}],"filter":[{"query_string":{"query":"status:active"}}]}}}], id[],`,
+		// Truncated by index.search.slowlog.source
+		`[2025-01-01T12:00:01,000][WARN ][i.s.s.query              ] [node-1] [products][1] took[2s], source[{"query":{"query_string":{"query":"name:desk AND pri], id[],`,
+		// Other entries
+		`[2025-01-01T12:00:02,000][INFO ][o.o.n.Node               ] [node-1] started`,
+		`[2025-01-01T12:00:03,000][WARN ][i.s.s.fetch              ] [node-1] [products][0] took[1s], source[{"query":{"match_all":{}}}], id[],`,
+		"",
+		// JSON slow log of OpenSearch and Elasticsearch 7
+		`{"type": "index_search_slowlog", "level": "WARN", "message": "[orders][0]", "took": "1.5s", "source": "{\"query\":{\"query_string\":{\"query\":\"status:shipped\",\"default_field\":\"id\"}}}", "id": ""}`,
+		// Elasticsearch 8 (ECS)
+		`{"@timestamp": "2025-01-01T12:00:00.000Z", "elasticsearch.slowlog.message": "[orders][2]", "elasticsearch.slowlog.source": "{\"query\":{\"query_string\":{\"query\":\"total:>100\"}}}"}`,
+		// OpenSearch audit log with a URI search
+		`{"audit_category": "REST_REQUEST", "audit_rest_request_path": "/users/_search", "audit_rest_request_params": {"q": "email:*@example.com"}, "audit_trace_indices": ["users"]}`,
+		// Elasticsearch audit log
+		`{"event.action": "access_granted", "indices": ["users"], "request.body": "{\"query\":{\"query_string\":{\"query\":\"name:alice\"}}}"}`,
+		`{"broken`,
+	}, "\n")
+
+	scan, err := ReadLogQueries(strings.NewReader(log))
+	require.NoError(t, err)
+
+	assert.Equal(t, []LogQuery{
+		{Line: 1, Index: "products", Text: "status:active"},
+		{Line: 1, Index: "products", Text: "name:laptop AND price:[100 TO 500]"},
+		{Line: 6, Index: "orders", Text: "status:shipped"},
+		{Line: 7, Index: "orders", Text: "total:>100"},
+		{Line: 8, Index: "users", Text: "email:*@example.com"},
+		{Line: 9, Index: "users", Text: "name:alice"},
+	}, scan.Queries)
+	assert.Equal(t, 2, scan.Unreadable)
+}
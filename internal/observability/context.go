@@ -0,0 +1,26 @@
+package observability
+
+import "context"
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a context carrying the ID of the request it serves
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, or "" when there is none
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// WithContext returns a logger adding the request ID carried by ctx to every line,
+// so all lines logged for a request can be correlated
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		return l.WithRequestID(requestID)
+	}
+	return l
+}
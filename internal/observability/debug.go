@@ -0,0 +1,88 @@
+package observability
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+)
+
+// RuntimeStats is a snapshot of the Go runtime served at /debug/runtime
+type RuntimeStats struct {
+	GoVersion    string    `json:"goVersion"`
+	GOMAXPROCS   int       `json:"gomaxprocs"`
+	NumCPU       int       `json:"numCpu"`
+	Goroutines   int       `json:"goroutines"`
+	HeapAlloc    uint64    `json:"heapAllocBytes"`
+	HeapInuse    uint64    `json:"heapInuseBytes"`
+	HeapObjects  uint64    `json:"heapObjects"`
+	TotalAlloc   uint64    `json:"totalAllocBytes"`
+	Sys          uint64    `json:"sysBytes"`
+	NumGC        uint32    `json:"numGc"`
+	PauseTotalNs uint64    `json:"gcPauseTotalNs"`
+	LastGC       time.Time `json:"lastGc,omitempty"`
+}
+
+// ReadRuntimeStats takes a snapshot of the Go runtime. It stops the world briefly to
+// read memory statistics.
+func ReadRuntimeStats() RuntimeStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := RuntimeStats{
+		GoVersion:    runtime.Version(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		NumCPU:       runtime.NumCPU(),
+		Goroutines:   runtime.NumGoroutine(),
+		HeapAlloc:    mem.HeapAlloc,
+		HeapInuse:    mem.HeapInuse,
+		HeapObjects:  mem.HeapObjects,
+		TotalAlloc:   mem.TotalAlloc,
+		Sys:          mem.Sys,
+		NumGC:        mem.NumGC,
+		PauseTotalNs: mem.PauseTotalNs,
+	}
+	if mem.LastGC > 0 {
+		stats.LastGC = time.Unix(0, int64(mem.LastGC)).UTC()
+	}
+	return stats
+}
+
+// DebugHandler serves net/http/pprof under /debug/pprof/ and RuntimeStats as JSON at
+// /debug/runtime. Requests must carry one of apiKeys in the X-API-Key header; with no
+// keys every request is refused.
+func DebugHandler(apiKeys []string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/runtime", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ReadRuntimeStats())
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !KeyAllowed(r.Header.Get("X-API-Key"), apiKeys) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// KeyAllowed reports whether an API key is one of keys, comparing in constant time
+func KeyAllowed(key string, keys []string) bool {
+	if key == "" {
+		return false
+	}
+	for _, allowed := range keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(allowed)) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package observability
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugHandler(t *testing.T) {
+	handler := DebugHandler([]string{"ops-key"})
+
+	get := func(path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// Every endpoint requires a key
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/runtime"} {
+		assert.Equal(t, http.StatusUnauthorized, get(path, "").Code, path)
+		assert.Equal(t, http.StatusUnauthorized, get(path, "wrong-key").Code, path)
+	}
+
+	w := get("/debug/pprof/", "ops-key")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.Contains(w.Body.String(), "goroutine"))
+
+	w = get("/debug/pprof/goroutine?debug=1", "ops-key")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = get("/debug/runtime", "ops-key")
+	require.Equal(t, http.StatusOK, w.Code)
+	var stats RuntimeStats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Positive(t, stats.Goroutines)
+	assert.Positive(t, stats.GOMAXPROCS)
+	assert.NotZero(t, stats.HeapAlloc)
+}
+
+func TestDebugHandler_NoKeys(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/debug/runtime", nil)
+	req.Header.Set("X-API-Key", "")
+	DebugHandler(nil).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -2,6 +2,7 @@ package observability
 
 import (
 	"bytes"
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -131,6 +132,28 @@ func TestLoggerWithRequestID(t *testing.T) {
 	}
 }
 
+func TestLoggerWithContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &Logger{logger: zerolog.New(&buf)}
+
+	ctx := ContextWithRequestID(context.Background(), "req-123")
+	if got := RequestIDFromContext(ctx); got != "req-123" {
+		t.Errorf("expected request ID req-123, got %q", got)
+	}
+
+	logger.WithContext(ctx).Error("translation failed")
+	if !strings.Contains(buf.String(), `"request_id":"req-123"`) {
+		t.Errorf("expected the request ID in the log line, got %s", buf.String())
+	}
+
+	// Without a request ID the logger is unchanged
+	buf.Reset()
+	logger.WithContext(context.Background()).Error("no request")
+	if strings.Contains(buf.String(), "request_id") {
+		t.Errorf("expected no request ID, got %s", buf.String())
+	}
+}
+
 func TestLoggerWithFields(t *testing.T) {
 	logger, err := NewLogger("debug", "json", "stdout")
 	if err != nil {
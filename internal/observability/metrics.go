@@ -13,6 +13,7 @@ import (
 type Metrics struct {
 	RequestsTotal     *prometheus.CounterVec
 	RequestDuration   *prometheus.HistogramVec
+	RouteLatency      *prometheus.SummaryVec
 	ActiveRequests    prometheus.Gauge
 	ErrorsTotal       *prometheus.CounterVec
 	ParseDuration     *prometheus.HistogramVec
@@ -31,6 +32,24 @@ type Metrics struct {
 	ResponseSize     *prometheus.HistogramVec
 	DatabaseTargets  *prometheus.CounterVec
 
+	// Query usage analytics
+	SchemaQueries *prometheus.CounterVec
+	FieldUsage    *prometheus.CounterVec
+	OperatorUsage *prometheus.CounterVec
+
+	SlowTranslations *prometheus.CounterVec
+
+	// Overload protection
+	RateLimitClients     prometheus.Gauge
+	TranslationsInFlight prometheus.Gauge
+	TranslationsQueued   prometheus.Gauge
+	TranslationQueueWait prometheus.Histogram
+	TranslationsShed     *prometheus.CounterVec
+	QuotaRejections      prometheus.Counter
+
+	// Shadow comparisons with OpenSearch
+	ShadowComparisons *prometheus.CounterVec
+
 	// System metrics
 	GoroutineCount prometheus.Gauge
 	MemoryUsage    prometheus.Gauge
@@ -57,6 +76,15 @@ func NewMetrics() *Metrics {
 			},
 			[]string{"endpoint"},
 		),
+		RouteLatency: prometheus.NewSummaryVec(
+			prometheus.SummaryOpts{
+				Name:       "rsearch_route_latency_seconds",
+				Help:       "HTTP request latency in seconds by method and route, with p50, p95 and p99 over the last 10 minutes",
+				Objectives: map[float64]float64{0.5: 0.05, 0.95: 0.01, 0.99: 0.001},
+				MaxAge:     10 * time.Minute,
+			},
+			[]string{"method", "route"},
+		),
 		ActiveRequests: prometheus.NewGauge(
 			prometheus.GaugeOpts{
 				Name: "rsearch_active_requests",
@@ -162,6 +190,79 @@ func NewMetrics() *Metrics {
 			},
 			[]string{"database"},
 		),
+		SchemaQueries: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rsearch_schema_queries_total",
+				Help: "Total number of queries per schema by outcome",
+			},
+			[]string{"schema", "status"},
+		),
+		FieldUsage: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rsearch_field_usage_total",
+				Help: "Total number of times a schema field was referenced in a query",
+			},
+			[]string{"schema", "field"},
+		),
+		OperatorUsage: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rsearch_operator_usage_total",
+				Help: "Total number of times a query operator was used per schema",
+			},
+			[]string{"schema", "operator"},
+		),
+		SlowTranslations: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rsearch_slow_translations_total",
+				Help: "Total number of translations exceeding the slow threshold",
+			},
+			[]string{"database"},
+		),
+		RateLimitClients: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "rsearch_rate_limit_clients",
+				Help: "Number of clients tracked by the rate limiter",
+			},
+		),
+		TranslationsInFlight: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "rsearch_translations_in_flight",
+				Help: "Number of translations being processed",
+			},
+		),
+		TranslationsQueued: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "rsearch_translations_queued",
+				Help: "Number of translations waiting for a free slot",
+			},
+		),
+		TranslationQueueWait: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Name:    "rsearch_translation_queue_wait_seconds",
+				Help:    "Time translations waited for a free slot in seconds",
+				Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+			},
+		),
+		TranslationsShed: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rsearch_translations_shed_total",
+				Help: "Total number of translations rejected by the concurrency limit",
+			},
+			[]string{"reason"},
+		),
+		QuotaRejections: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "rsearch_quota_rejections_total",
+				Help: "Total number of translations rejected because their API key exceeded its daily quota",
+			},
+		),
+		ShadowComparisons: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rsearch_shadow_comparisons_total",
+				Help: "Total number of translations compared with OpenSearch, by result: match, diverged, error or dropped",
+			},
+			[]string{"schema", "result"},
+		),
 		GoroutineCount: prometheus.NewGauge(
 			prometheus.GaugeOpts{
 				Name: "rsearch_goroutines",
@@ -186,6 +287,7 @@ func NewMetrics() *Metrics {
 	// Register all metrics
 	prometheus.MustRegister(m.RequestsTotal)
 	prometheus.MustRegister(m.RequestDuration)
+	prometheus.MustRegister(m.RouteLatency)
 	prometheus.MustRegister(m.ActiveRequests)
 	prometheus.MustRegister(m.ErrorsTotal)
 	prometheus.MustRegister(m.ParseDuration)
@@ -201,6 +303,17 @@ func NewMetrics() *Metrics {
 	prometheus.MustRegister(m.QuerySyntaxUsage)
 	prometheus.MustRegister(m.ResponseSize)
 	prometheus.MustRegister(m.DatabaseTargets)
+	prometheus.MustRegister(m.SchemaQueries)
+	prometheus.MustRegister(m.FieldUsage)
+	prometheus.MustRegister(m.OperatorUsage)
+	prometheus.MustRegister(m.SlowTranslations)
+	prometheus.MustRegister(m.RateLimitClients)
+	prometheus.MustRegister(m.TranslationsInFlight)
+	prometheus.MustRegister(m.TranslationsQueued)
+	prometheus.MustRegister(m.TranslationQueueWait)
+	prometheus.MustRegister(m.TranslationsShed)
+	prometheus.MustRegister(m.QuotaRejections)
+	prometheus.MustRegister(m.ShadowComparisons)
 	prometheus.MustRegister(m.GoroutineCount)
 	prometheus.MustRegister(m.MemoryUsage)
 	prometheus.MustRegister(m.Uptime)
@@ -219,6 +332,11 @@ func (m *Metrics) RecordRequest(endpoint string, status int, duration float64) {
 	m.RequestDuration.WithLabelValues(endpoint).Observe(duration)
 }
 
+// RecordRouteLatency records the latency of a request by its method and route pattern
+func (m *Metrics) RecordRouteLatency(method, route string, duration float64) {
+	m.RouteLatency.WithLabelValues(method, route).Observe(duration)
+}
+
 // IncActiveRequests increments active requests
 func (m *Metrics) IncActiveRequests() {
 	m.ActiveRequests.Inc()
@@ -303,6 +421,61 @@ func (m *Metrics) RecordDatabaseTarget(dbType string) {
 	m.DatabaseTargets.WithLabelValues(dbType).Inc()
 }
 
+// RecordSchemaQuery records a query against a schema
+func (m *Metrics) RecordSchemaQuery(schema string, success bool) {
+	status := "success"
+	if !success {
+		status = "error"
+	}
+	m.SchemaQueries.WithLabelValues(schema, status).Inc()
+}
+
+// RecordFieldUsage records a reference to a schema field
+func (m *Metrics) RecordFieldUsage(schema, field string) {
+	m.FieldUsage.WithLabelValues(schema, field).Inc()
+}
+
+// RecordOperatorUsage records the use of a query operator
+func (m *Metrics) RecordOperatorUsage(schema, operator string) {
+	m.OperatorUsage.WithLabelValues(schema, operator).Inc()
+}
+
+// RecordSlowTranslation records a translation that exceeded the slow threshold
+func (m *Metrics) RecordSlowTranslation(database string) {
+	m.SlowTranslations.WithLabelValues(database).Inc()
+}
+
+// RecordShadowComparison records the result of a shadow comparison with OpenSearch
+func (m *Metrics) RecordShadowComparison(schema, result string) {
+	m.ShadowComparisons.WithLabelValues(schema, result).Inc()
+}
+
+// SetRateLimitClients sets the number of clients tracked by the rate limiter
+func (m *Metrics) SetRateLimitClients(count int) {
+	m.RateLimitClients.Set(float64(count))
+}
+
+// SetTranslationConcurrency sets the number of translations processed and waiting
+func (m *Metrics) SetTranslationConcurrency(inFlight, queued int) {
+	m.TranslationsInFlight.Set(float64(inFlight))
+	m.TranslationsQueued.Set(float64(queued))
+}
+
+// RecordTranslationQueueWait records how long a translation waited for a free slot
+func (m *Metrics) RecordTranslationQueueWait(seconds float64) {
+	m.TranslationQueueWait.Observe(seconds)
+}
+
+// RecordShedTranslation records a translation rejected by the concurrency limit
+func (m *Metrics) RecordShedTranslation(reason string) {
+	m.TranslationsShed.WithLabelValues(reason).Inc()
+}
+
+// RecordQuotaRejection records a translation rejected by an API key's daily quota
+func (m *Metrics) RecordQuotaRejection() {
+	m.QuotaRejections.Inc()
+}
+
 // UpdateSystemMetrics updates system-level metrics
 func (m *Metrics) UpdateSystemMetrics() {
 	// Update goroutine count
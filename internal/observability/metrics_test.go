@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestNewMetrics(t *testing.T) {
@@ -70,6 +71,20 @@ func TestRecordRequest(t *testing.T) {
 	m.RecordRequest("/api/v1/translate", http.StatusBadRequest, 0.010)
 }
 
+func TestRecordRouteLatency(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	m := NewMetrics()
+
+	for i := 1; i <= 100; i++ {
+		m.RecordRouteLatency("POST", "/api/v1/translate", float64(i)/1000)
+	}
+	m.RecordRouteLatency("GET", "/api/v1/schemas/{name}", 0.002)
+
+	if series := testutil.CollectAndCount(m.RouteLatency); series != 2 {
+		t.Errorf("expected a series per method and route, got %d", series)
+	}
+}
+
 func TestActiveRequests(t *testing.T) {
 	prometheus.DefaultRegisterer = prometheus.NewRegistry()
 	m := NewMetrics()
@@ -219,6 +234,47 @@ func TestRecordDatabaseTarget(t *testing.T) {
 	m.RecordDatabaseTarget("sqlite")
 }
 
+func TestRecordQueryUsage(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	m := NewMetrics()
+
+	// Should not panic
+	m.RecordSchemaQuery("products", true)
+	m.RecordSchemaQuery("products", false)
+	m.RecordFieldUsage("products", "name")
+	m.RecordOperatorUsage("products", "and")
+}
+
+func TestRecordSlowTranslation(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	m := NewMetrics()
+
+	// Should not panic
+	m.RecordSlowTranslation("postgres")
+	m.RecordSlowTranslation("mongodb")
+}
+
+func TestRecordShadowComparison(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	m := NewMetrics()
+
+	// Should not panic
+	m.RecordShadowComparison("products", "match")
+	m.RecordShadowComparison("products", "diverged")
+}
+
+func TestOverloadMetrics(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	m := NewMetrics()
+
+	// Should not panic
+	m.SetRateLimitClients(3)
+	m.SetTranslationConcurrency(8, 2)
+	m.RecordTranslationQueueWait(0.02)
+	m.RecordShedTranslation("queue_full")
+	m.RecordQuotaRejection()
+}
+
 func TestUpdateSystemMetrics(t *testing.T) {
 	prometheus.DefaultRegisterer = prometheus.NewRegistry()
 	m := NewMetrics()
@@ -257,6 +313,15 @@ func TestNewMetricsIncludesAllMetrics(t *testing.T) {
 	if m.DatabaseTargets == nil {
 		t.Error("DatabaseTargets not initialized")
 	}
+	if m.SchemaQueries == nil {
+		t.Error("SchemaQueries not initialized")
+	}
+	if m.FieldUsage == nil {
+		t.Error("FieldUsage not initialized")
+	}
+	if m.OperatorUsage == nil {
+		t.Error("OperatorUsage not initialized")
+	}
 	if m.GoroutineCount == nil {
 		t.Error("GoroutineCount not initialized")
 	}
@@ -0,0 +1,42 @@
+package observability
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// QueryLogMode selects how query strings are written to logs. Query values often hold
+// personal data, so the default only logs a hash.
+type QueryLogMode string
+
+// Query log modes
+const (
+	QueryLogNone     QueryLogMode = "none"     // no trace of the query
+	QueryLogHash     QueryLogMode = "hash"     // a short hash identifying repeated queries
+	QueryLogRedacted QueryLogMode = "redacted" // the query's structure, with values replaced by ?
+	QueryLogFull     QueryLogMode = "full"     // the raw query, values included
+)
+
+// QueryFields returns the log fields describing query under the mode: "query",
+// "query_redacted" or "query_hash". redact renders the redacted form; it is only called
+// in redacted mode, and when it is nil, as for queries that failed to parse, the hash is
+// logged instead. An empty mode is QueryLogHash.
+func (m QueryLogMode) QueryFields(query string, redact func() string) map[string]interface{} {
+	switch m {
+	case QueryLogNone:
+		return map[string]interface{}{}
+	case QueryLogFull:
+		return map[string]interface{}{"query": query}
+	case QueryLogRedacted:
+		if redact != nil {
+			return map[string]interface{}{"query_redacted": redact()}
+		}
+	}
+	return map[string]interface{}{"query_hash": QueryHash(query)}
+}
+
+// QueryHash returns a short, stable identifier for a query string
+func QueryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,183 @@
+// Package openapi builds OpenAPI 3 documents from Go types.
+// Component schemas are derived by reflection from the same structs the API encodes,
+// so the published document cannot drift from the wire format.
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Version is the OpenAPI specification version of generated documents
+const Version = "3.0.3"
+
+// Object is a JSON object in an OpenAPI document
+type Object = map[string]interface{}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// Generator derives component schemas from Go types.
+// Named struct types become components referenced with $ref; everything else is inlined.
+type Generator struct {
+	components Object
+	names      map[reflect.Type]string
+	enums      map[reflect.Type][]string
+	required   map[reflect.Type][]string
+}
+
+// NewGenerator creates an empty generator
+func NewGenerator() *Generator {
+	return &Generator{
+		components: make(Object),
+		names:      make(map[reflect.Type]string),
+		enums:      make(map[reflect.Type][]string),
+		required:   make(map[reflect.Type][]string),
+	}
+}
+
+// Enum restricts the values of a named type, such as a string-based enum.
+// It must be called before the type is first referenced.
+func (g *Generator) Enum(v interface{}, values ...string) {
+	g.enums[reflect.TypeOf(v)] = values
+}
+
+// Require marks JSON properties of a struct type as required.
+// It must be called before the type is first referenced.
+func (g *Generator) Require(v interface{}, properties ...string) {
+	g.required[reflect.TypeOf(v)] = properties
+}
+
+// Ref returns a schema for the type of v, registering components as needed
+func (g *Generator) Ref(v interface{}) Object {
+	return g.schema(reflect.TypeOf(v))
+}
+
+// Define registers a hand-written component schema and returns a reference to it
+func (g *Generator) Define(name string, schema Object) Object {
+	g.components[name] = schema
+	return ref(name)
+}
+
+// Components returns all registered component schemas
+func (g *Generator) Components() Object {
+	return g.components
+}
+
+// schema returns the schema of a type
+func (g *Generator) schema(t reflect.Type) Object {
+	if t == nil {
+		return Object{}
+	}
+
+	if values, ok := g.enums[t]; ok {
+		return Object{"type": "string", "enum": values}
+	}
+
+	switch t {
+	case timeType:
+		return Object{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return Object{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := g.schema(t.Elem())
+		if _, isRef := s["$ref"]; isRef {
+			return s
+		}
+		s["nullable"] = true
+		return s
+	case reflect.String:
+		return Object{"type": "string"}
+	case reflect.Bool:
+		return Object{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Object{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Object{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return Object{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return Object{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return g.component(t)
+	default:
+		// interface{} and anything else accepts any JSON value
+		return Object{}
+	}
+}
+
+// component registers a named struct type and returns a reference to it
+func (g *Generator) component(t reflect.Type) Object {
+	if name, ok := g.names[t]; ok {
+		return ref(name)
+	}
+
+	name := t.Name()
+	for _, taken := range g.names {
+		if taken == name {
+			// Same name in another package, e.g. api.ErrorResponse and rsearch.ErrorResponse
+			pkg := t.PkgPath()
+			pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+			name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+			break
+		}
+	}
+
+	// Register before building so recursive types terminate
+	g.names[t] = name
+	g.components[name] = g.object(t)
+	return ref(name)
+}
+
+// object builds the schema of a struct from its exported, JSON-encoded fields
+func (g *Generator) object(t reflect.Type) Object {
+	properties := make(Object)
+	g.addProperties(t, properties)
+
+	s := Object{"type": "object", "properties": properties}
+	if required, ok := g.required[t]; ok {
+		s["required"] = required
+	}
+	return s
+}
+
+// addProperties adds the JSON properties of a struct, flattening embedded structs
+func (g *Generator) addProperties(t reflect.Type, properties Object) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// encoding/json promotes the fields of untagged embedded structs, even unexported ones
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			g.addProperties(field.Type, properties)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = g.schema(field.Type)
+	}
+}
+
+// ref returns a reference to a component schema
+func ref(name string) Object {
+	return Object{"$ref": "#/components/schemas/" + name}
+}
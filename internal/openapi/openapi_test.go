@@ -0,0 +1,67 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type kind string
+
+type base struct {
+	ID string `json:"id"`
+}
+
+type node struct {
+	base
+	Kind     kind                   `json:"kind"`
+	Count    int64                  `json:"count,omitempty"`
+	Score    *float64               `json:"score,omitempty"`
+	Tags     []string               `json:"tags"`
+	Attrs    map[string]interface{} `json:"attrs"`
+	Children []*node                `json:"children,omitempty"`
+	Created  time.Time              `json:"created"`
+	Ignored  string                 `json:"-"`
+	internal string
+}
+
+func TestGenerator(t *testing.T) {
+	g := NewGenerator()
+	g.Enum(kind(""), "leaf", "branch")
+	g.Require(node{}, "id", "kind")
+
+	assert.Equal(t, Object{"$ref": "#/components/schemas/node"}, g.Ref(node{}))
+	assert.Equal(t, Object{"$ref": "#/components/schemas/node"}, g.Ref(&node{}))
+
+	component := g.Components()["node"].(Object)
+	assert.Equal(t, []string{"id", "kind"}, component["required"])
+
+	properties := component["properties"].(Object)
+	assert.Equal(t, Object{"type": "string"}, properties["id"])
+	assert.Equal(t, Object{"type": "string", "enum": []string{"leaf", "branch"}}, properties["kind"])
+	assert.Equal(t, Object{"type": "integer"}, properties["count"])
+	assert.Equal(t, Object{"type": "number", "nullable": true}, properties["score"])
+	assert.Equal(t, Object{"type": "array", "items": Object{"type": "string"}}, properties["tags"])
+	assert.Equal(t, Object{"type": "object", "additionalProperties": Object{}}, properties["attrs"])
+	assert.Equal(t, Object{"type": "array", "items": Object{"$ref": "#/components/schemas/node"}}, properties["children"])
+	assert.Equal(t, Object{"type": "string", "format": "date-time"}, properties["created"])
+	assert.NotContains(t, properties, "Ignored")
+	assert.NotContains(t, properties, "internal")
+	assert.Len(t, properties, 8)
+
+	// The document must be encodable as JSON
+	_, err := json.Marshal(g.Components())
+	require.NoError(t, err)
+}
+
+func TestGenerator_NameCollision(t *testing.T) {
+	g := NewGenerator()
+	assert.Equal(t, Object{"$ref": "#/components/schemas/Location"}, g.Ref(time.Location{}))
+
+	// A second type with the same name gets its package as a prefix
+	type Location struct{}
+	assert.Equal(t, Object{"$ref": "#/components/schemas/OpenapiLocation"}, g.Ref(Location{}))
+}
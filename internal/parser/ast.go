@@ -1,5 +1,7 @@
 package parser
 
+import "strings"
+
 // Node is the interface for all AST nodes
 type Node interface {
 	Type() string
@@ -14,10 +16,11 @@ type ValueNode interface {
 
 // BinaryOp represents a binary operation (AND, OR)
 type BinaryOp struct {
-	Op    string // "AND", "OR"
-	Left  Node
-	Right Node
-	Pos   Position
+	Op       string // "AND", "OR"
+	Left     Node
+	Right    Node
+	Pos      Position
+	Implicit bool // true for an OR the parser inserted between adjacent clauses
 }
 
 func (n *BinaryOp) Type() string       { return "BinaryOp" }
@@ -71,6 +74,18 @@ type FieldGroupQuery struct {
 func (n *FieldGroupQuery) Type() string       { return "FieldGroupQuery" }
 func (n *FieldGroupQuery) Position() Position { return n.Pos }
 
+// InListQuery represents an explicit list of values, field:(a,b,c) or field:in(a b c).
+// Unlike a field group, which may hold any clauses, it only holds values, so it always
+// translates to a single IN.
+type InListQuery struct {
+	Field  string
+	Values []ValueNode // terms, numbers and phrases
+	Pos    Position
+}
+
+func (n *InListQuery) Type() string       { return "InListQuery" }
+func (n *InListQuery) Position() Position { return n.Pos }
+
 // RangeQuery represents a range query [start TO end] or {start TO end}
 type RangeQuery struct {
 	Field          string
@@ -88,7 +103,7 @@ func (n *RangeQuery) Position() Position { return n.Pos }
 type FuzzyQuery struct {
 	Field    string
 	Term     string
-	Distance int // 0 means default (2)
+	Distance int // edits, from 0 to MaxFuzzyDistance
 	Pos      Position
 }
 
@@ -152,23 +167,44 @@ type WildcardValue struct {
 func (n *WildcardValue) Value() interface{} { return n.Pattern }
 func (n *WildcardValue) IsValueNode()       {}
 
-// RegexValue represents a regex pattern /pattern/
+// RegexValue represents a regex pattern /pattern/ with optional trailing flags
 type RegexValue struct {
 	Pattern string
+	Flags   string // RegexFlags written after the closing slash, in canonical order
 	Pos     Position
 }
 
+// RegexFlags are the flags a regex literal may carry: i matches case-insensitively and m
+// lets ^ and $ match at line breaks
+const RegexFlags = "im"
+
+// HasFlag reports whether the regex carries flag
+func (n *RegexValue) HasFlag(flag byte) bool {
+	return strings.IndexByte(n.Flags, flag) >= 0
+}
+
 func (n *RegexValue) Value() interface{} { return n.Pattern }
 func (n *RegexValue) IsValueNode()       {}
 
-// NumberValue represents a numeric value
+// NumberValue represents a numeric value. Number is the literal as written until the
+// translator normalizes it for a numeric field, which sets Parsed to an int64 or float64.
 type NumberValue struct {
 	Number string
+	Parsed interface{} // typed value bound as the parameter, when set
 	Pos    Position
+
+	// Inexact is the value as written when Parsed only approximates it, such as a float
+	// literal with more digits than a float64 holds
+	Inexact string
 }
 
-func (n *NumberValue) Value() interface{} { return n.Number }
-func (n *NumberValue) IsValueNode()       {}
+func (n *NumberValue) Value() interface{} {
+	if n.Parsed != nil {
+		return n.Parsed
+	}
+	return n.Number
+}
+func (n *NumberValue) IsValueNode() {}
 
 // WildcardQuery represents a standalone wildcard query (not in a field context)
 type WildcardQuery struct {
@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParser_Boost checks that every boostable expression takes the same boost syntax
+func TestParser_Boost(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"term^2", "(^2 term)"},
+		{"term^0.5", "(^0.5 term)"},
+		{"term^.5", "(^0.5 term)"},
+		{"term^0", "(^0 term)"},
+		{"name:value^1.25", "(^1.25 name:value)"},
+		{"name:value^.25", "(^0.25 name:value)"},
+		{"(a OR b)^3", "(^3 (OR a b))"},
+		{"(a OR b)^1.5", "(^1.5 (OR a b))"},
+		{"name:(a OR b)^2", "(^2 *parser.FieldGroupQuery)"},
+		{`"big deal"^2.5`, `(^2.5 "big deal")`},
+		{"price:[10 TO 20]^2", "(^2 *parser.RangeQuery)"},
+		{"name:widget~1^2", "(^2 *parser.FuzzyQuery)"},
+		{"a^2 b^0.5", "(or (^2 a) (^0.5 b))"},
+		{"a^2 AND b", "(AND (^2 a) b)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ast, err := NewParser(tt.query).Parse()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sexpr(ast))
+		})
+	}
+}
+
+func TestParser_BoostErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"term^", "expected a boost after '^'"},
+		{"name:value^", "expected a boost after '^'"},
+		{"(a OR b)^)", "expected a boost after '^'"},
+		{"term^-1", "boost must not be negative"},
+		{"name:value^abc", `invalid boost "abc": expected a decimal number`},
+		{"term^1e2", `invalid boost "1e2": expected a decimal number`},
+		{"term^2.5x", `invalid boost "2.5x": expected a decimal number`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := NewParser(tt.query).Parse()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
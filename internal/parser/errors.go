@@ -20,6 +20,7 @@ type ParseError struct {
 	Position Position
 	Line     int
 	Column   int
+	Fix      string // the query corrected, for errors with an obvious correction
 }
 
 // Error implements the error interface
@@ -0,0 +1,58 @@
+package parser
+
+// FreeTextSegment is a run of adjacent clauses joined by implicit ORs, such as
+// "quick brown fox". The clauses came from a single stretch of free text, so rewriting
+// and scoring layers may treat them as a bag of words rather than independent clauses.
+type FreeTextSegment struct {
+	Clauses []Node   // clauses in query order
+	Pos     Position // position of the first clause
+}
+
+// Terms returns the bare terms of the segment in query order.
+// ok is false if any clause is not a plain term.
+func (s FreeTextSegment) Terms() (terms []string, ok bool) {
+	terms = make([]string, 0, len(s.Clauses))
+	for _, clause := range s.Clauses {
+		term, isTerm := clause.(*TermQuery)
+		if !isTerm {
+			return nil, false
+		}
+		terms = append(terms, term.Term)
+	}
+	return terms, true
+}
+
+// FreeTextSegments returns every free-text segment in the AST, outermost first.
+// A chain of implicit ORs forms one segment; explicit ORs never join segments.
+func FreeTextSegments(node Node) []FreeTextSegment {
+	var segments []FreeTextSegment
+
+	Walk(node, func(n Node) bool {
+		bo, ok := n.(*BinaryOp)
+		if !ok || !bo.Implicit {
+			return true
+		}
+
+		var clauses []Node
+		collectImplicit(bo, &clauses)
+		segments = append(segments, FreeTextSegment{Clauses: clauses, Pos: bo.Pos})
+
+		// Clauses may contain groups with segments of their own
+		for _, clause := range clauses {
+			segments = append(segments, FreeTextSegments(clause)...)
+		}
+		return false
+	})
+
+	return segments
+}
+
+// collectImplicit flattens a chain of implicit ORs into its operands
+func collectImplicit(node Node, clauses *[]Node) {
+	if bo, ok := node.(*BinaryOp); ok && bo.Implicit {
+		collectImplicit(bo.Left, clauses)
+		collectImplicit(bo.Right, clauses)
+		return
+	}
+	*clauses = append(*clauses, node)
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser_ImplicitORIsMarked(t *testing.T) {
+	ast, err := NewParser("quick brown").Parse()
+	require.NoError(t, err)
+
+	bin, ok := ast.(*BinaryOp)
+	require.True(t, ok)
+	assert.True(t, bin.Implicit)
+
+	ast, err = NewParser("quick OR brown").Parse()
+	require.NoError(t, err)
+
+	bin, ok = ast.(*BinaryOp)
+	require.True(t, ok)
+	assert.False(t, bin.Implicit)
+}
+
+func TestFreeTextSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected [][]string
+	}{
+		{"single term", "quick", nil},
+		{"explicit OR", "quick OR brown", nil},
+		{"bag of words", "quick brown fox", [][]string{{"quick", "brown", "fox"}}},
+		{"split by AND", "(quick brown) AND (lazy dog)", [][]string{{"quick", "brown"}, {"lazy", "dog"}}},
+		{"inside group", "status:active AND (quick brown)", [][]string{{"quick", "brown"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			var got [][]string
+			for _, segment := range FreeTextSegments(ast) {
+				terms, ok := segment.Terms()
+				require.True(t, ok)
+				got = append(got, terms)
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestFreeTextSegments_MixedClauses(t *testing.T) {
+	ast, err := NewParser(`quick "brown fox" status:active`).Parse()
+	require.NoError(t, err)
+
+	segments := FreeTextSegments(ast)
+	require.Len(t, segments, 1)
+	require.Len(t, segments[0].Clauses, 3)
+	assert.IsType(t, &PhraseQuery{}, segments[0].Clauses[1])
+	assert.IsType(t, &FieldQuery{}, segments[0].Clauses[2])
+	assert.Equal(t, 1, segments[0].Pos.Column)
+
+	_, ok := segments[0].Terms()
+	assert.False(t, ok)
+}
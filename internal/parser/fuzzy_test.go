@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser_FuzzyDistance(t *testing.T) {
+	tests := []struct {
+		query    string
+		field    string
+		distance int
+	}{
+		{"roam~", "", DefaultFuzzyDistance},
+		{"roam~0", "", 0},
+		{"roam~1", "", 1},
+		{"roam~2", "", 2},
+		{"name:roam~", "name", DefaultFuzzyDistance},
+		{"name:roam~1", "name", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ast, err := NewParser(tt.query).Parse()
+			require.NoError(t, err)
+			fuzzy, ok := ast.(*FuzzyQuery)
+			require.True(t, ok, "expected FuzzyQuery, got %T", ast)
+			assert.Equal(t, "roam", fuzzy.Term)
+			assert.Equal(t, tt.field, fuzzy.Field)
+			assert.Equal(t, tt.distance, fuzzy.Distance)
+		})
+	}
+}
+
+func TestParser_ProximityDistance(t *testing.T) {
+	tests := []struct {
+		query    string
+		field    string
+		distance int
+	}{
+		{`"quick fox"~`, "", DefaultFuzzyDistance},
+		{`"quick fox"~5`, "", 5},
+		{`title:"quick fox"~5`, "title", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ast, err := NewParser(tt.query).Parse()
+			require.NoError(t, err)
+			prox, ok := ast.(*ProximityQuery)
+			require.True(t, ok, "expected ProximityQuery, got %T", ast)
+			assert.Equal(t, "quick fox", prox.Phrase)
+			assert.Equal(t, tt.field, prox.Field)
+			assert.Equal(t, tt.distance, prox.Distance)
+		})
+	}
+}
+
+// TestParser_DistanceSeparate checks that a number after whitespace starts the next
+// clause rather than giving the distance
+func TestParser_DistanceSeparate(t *testing.T) {
+	ast, err := NewParser("roam~ 1").Parse()
+	require.NoError(t, err)
+	op, ok := ast.(*BinaryOp)
+	require.True(t, ok, "expected BinaryOp, got %T", ast)
+	assert.Equal(t, &FuzzyQuery{Term: "roam", Distance: DefaultFuzzyDistance, Pos: op.Left.Position()}, op.Left)
+}
+
+func TestParser_DistanceErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"roam~1.5", `invalid fuzzy distance "1.5": expected a whole number`},
+		{"name:roam~0.8", `invalid fuzzy distance "0.8": expected a whole number`},
+		{"roam~3", "fuzzy distance 3 exceeds the maximum of 2 edits"},
+		{"name:roam~10", "fuzzy distance 10 exceeds the maximum of 2 edits"},
+		{"roam~-1", "fuzzy distance must not be negative"},
+		{"roam~abc", `invalid fuzzy distance "abc": expected a whole number`},
+		{`"quick fox"~2.5`, `invalid proximity distance "2.5": expected a whole number`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := NewParser(tt.query).Parse()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser_InListQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		values []interface{}
+	}{
+		{"comma separated", "region:(ca,us,mx)", []interface{}{"ca", "us", "mx"}},
+		{"spaces around commas", "region:( ca , us )", []interface{}{"ca", "us"}},
+		{"in form", "region:in(ca us mx)", []interface{}{"ca", "us", "mx"}},
+		{"in form with commas", "region:IN(ca, us)", []interface{}{"ca", "us"}},
+		{"single value in form", "region:in(ca)", []interface{}{"ca"}},
+		{"numbers and phrases", `code:(1, 2.5, "new york")`, []interface{}{"1", "2.5", "new york"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, ok := parse(t, tt.input).(*InListQuery)
+			require.True(t, ok, "expected InListQuery")
+
+			values := make([]interface{}, len(list.Values))
+			for i, value := range list.Values {
+				values[i] = value.Value()
+			}
+			assert.Equal(t, tt.values, values)
+		})
+	}
+}
+
+func TestParser_InListQuery_Combined(t *testing.T) {
+	op, ok := parse(t, "region:(ca,us) AND status:active").(*BinaryOp)
+	require.True(t, ok)
+	assert.Equal(t, "AND", op.Op)
+	assert.IsType(t, &InListQuery{}, op.Left)
+	assert.IsType(t, &FieldQuery{}, op.Right)
+
+	// Groups without commas and "in" followed by a space keep their meaning
+	assert.IsType(t, &FieldGroupQuery{}, parse(t, "region:(ca OR us)"))
+	assert.IsType(t, &FieldGroupQuery{}, parse(t, "region:(ca)"))
+	assert.IsType(t, &BinaryOp{}, parse(t, "region:in (ca us)"))
+}
+
+func TestParser_InListQuery_Errors(t *testing.T) {
+	for _, input := range []string{
+		"region:(ca,)",
+		"region:(ca, us mx)",
+		"region:(ca, us",
+		"region:in()",
+		"region:in(ca*, us)",
+		"region:(ca, [1 TO 2])",
+		"region:ca,us",
+		"price:1.234,56",
+		"price:1,5 AND stock:2",
+	} {
+		t.Run(input, func(t *testing.T) {
+			_, err := NewParser(input).Parse()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParser_ProhibitedFieldQuery(t *testing.T) {
+	prohibited, ok := parse(t, "-region:(ca us) AND status:open").(*BinaryOp)
+	require.True(t, ok)
+	query, ok := prohibited.Left.(*ProhibitedQuery)
+	require.True(t, ok)
+	assert.IsType(t, &FieldGroupQuery{}, query.Query)
+
+	required, ok := parse(t, "+region:ca").(*RequiredQuery)
+	require.True(t, ok)
+	assert.IsType(t, &FieldQuery{}, required.Query)
+}
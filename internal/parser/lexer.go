@@ -34,6 +34,7 @@ const (
 	MINUS    // -
 	CARET    // ^
 	TILDE    // ~
+	COMMA    // , (separates in-list values)
 
 	// Boolean operators
 	AND // AND, &&
@@ -99,6 +100,8 @@ func (tt TokenType) String() string {
 		return "CARET"
 	case TILDE:
 		return "TILDE"
+	case COMMA:
+		return "COMMA"
 	case AND:
 		return "AND"
 	case OR:
@@ -130,6 +133,13 @@ type Lexer struct {
 	ch           byte // current char under examination
 	line         int  // current line number (1-indexed)
 	column       int  // current column number (1-indexed)
+
+	prev TokenType // type of the last token, which tells a sign from the prohibited operator
+
+	limits       Limits
+	tokens       int   // tokens read so far
+	literalBytes int   // bytes of the literals read so far
+	err          error // set once a limit is exceeded, after which only EOF is returned
 }
 
 // NewLexer creates a new lexer for the given input
@@ -188,6 +198,31 @@ func (l *Lexer) currentPosition() Position {
 
 // NextToken returns the next token from the input
 func (l *Lexer) NextToken() Token {
+	if l.err != nil {
+		return Token{Type: EOF, Position: l.currentPosition()}
+	}
+	tok := l.nextToken()
+	if tok.Type != EOF {
+		if l.err = l.count(tok); l.err != nil {
+			return Token{Type: EOF, Position: tok.Position}
+		}
+	}
+	l.prev = tok.Type
+	return tok
+}
+
+// signAllowed reports whether the last token puts the lexer in value position, where a
+// '-' before a digit is the sign of a number, as in temperature:[-10 TO 40], rather than
+// the prohibited operator
+func (l *Lexer) signAllowed() bool {
+	switch l.prev {
+	case COLON, LBRACKET, LBRACE, TO, GT, GTE, LT, LTE:
+		return true
+	}
+	return false
+}
+
+func (l *Lexer) nextToken() Token {
 	var tok Token
 
 	l.skipWhitespace()
@@ -239,6 +274,16 @@ func (l *Lexer) NextToken() Token {
 			l.readChar()
 			return tok
 		}
+		if l.signAllowed() && isDigit(l.peekChar()) {
+			l.readChar()
+			tok.Literal = "-" + l.readNumberOrString()
+			if containsLetters(tok.Literal) {
+				tok.Type = STRING
+			} else {
+				tok.Type = NUMBER
+			}
+			return tok
+		}
 		tok.Type = MINUS
 		tok.Literal = string(l.ch)
 		l.readChar()
@@ -250,6 +295,10 @@ func (l *Lexer) NextToken() Token {
 		tok.Type = TILDE
 		tok.Literal = string(l.ch)
 		l.readChar()
+	case ',':
+		tok.Type = COMMA
+		tok.Literal = string(l.ch)
+		l.readChar()
 	case '>':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -329,6 +378,12 @@ func (l *Lexer) NextToken() Token {
 			return tok
 		} else if isDigit(l.ch) {
 			tok.Literal = l.readNumberOrString()
+			// A wildcard continues the value, so 123* is one pattern rather than 123 and *
+			if l.ch == '*' || l.ch == '?' {
+				tok.Literal += l.readStringOrWildcard()
+				tok.Type = WILDCARD
+				return tok
+			}
 			// Check if it's a pure number or mixed alphanumeric
 			if containsLetters(tok.Literal) {
 				tok.Type = STRING
@@ -336,6 +391,15 @@ func (l *Lexer) NextToken() Token {
 				tok.Type = NUMBER
 			}
 			return tok
+		} else if l.ch == '.' && l.prev == CARET && isDigit(l.peekChar()) {
+			// A boost may leave out the leading zero, as in term^.5
+			tok.Literal = l.readNumberOrString()
+			if containsLetters(tok.Literal) {
+				tok.Type = STRING
+			} else {
+				tok.Type = NUMBER
+			}
+			return tok
 		} else {
 			tok.Type = ILLEGAL
 			tok.Literal = string(l.ch)
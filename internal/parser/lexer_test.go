@@ -90,6 +90,26 @@ func TestLexer_BasicTokens(t *testing.T) {
 			input:    "name:widget~2",
 			expected: []TokenType{STRING, COLON, STRING, TILDE, NUMBER, EOF},
 		},
+		{
+			name:     "in-list",
+			input:    "region:(ca,us)",
+			expected: []TokenType{STRING, COLON, LPAREN, STRING, COMMA, STRING, RPAREN, EOF},
+		},
+		{
+			name:     "negative range bounds",
+			input:    "temperature:[-10 TO -2.5}",
+			expected: []TokenType{STRING, COLON, LBRACKET, NUMBER, TO, NUMBER, RBRACE, EOF},
+		},
+		{
+			name:     "negative values after colon and comparison",
+			input:    "a:-5 AND b:>=-1e3",
+			expected: []TokenType{STRING, COLON, NUMBER, AND, STRING, COLON, GTE, STRING, EOF},
+		},
+		{
+			name:     "prohibited operator outside value position",
+			input:    "a:1 -5 -b",
+			expected: []TokenType{STRING, COLON, NUMBER, MINUS, NUMBER, MINUS, STRING, EOF},
+		},
 		{
 			name:     "parentheses",
 			input:    "(a OR b)",
@@ -573,6 +593,16 @@ func TestLexer_WildcardPatterns(t *testing.T) {
 			input:    "?",
 			expected: "?",
 		},
+		{
+			name:     "number prefix",
+			input:    "123*",
+			expected: "123*",
+		},
+		{
+			name:     "alphanumeric with wildcards",
+			input:    "13w4?x*",
+			expected: "13w4?x*",
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,30 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrQueryTooComplex is returned by Parse when a query exceeds the parser's limits
+var ErrQueryTooComplex = errors.New("query too complex")
+
+// Limits bound the memory a parse may use, so generated mega-queries are rejected before
+// their AST is built. Zero fields are unlimited.
+type Limits struct {
+	MaxTokens       int // tokens read from the query
+	MaxLiteralBytes int // total bytes of the tokens' literals
+}
+
+// count records a token against the limits, returning an error wrapping
+// ErrQueryTooComplex once one is exceeded
+func (l *Lexer) count(tok Token) error {
+	l.tokens++
+	l.literalBytes += len(tok.Literal)
+	if l.limits.MaxTokens > 0 && l.tokens > l.limits.MaxTokens {
+		return fmt.Errorf("%w: more than %d tokens at %s", ErrQueryTooComplex, l.limits.MaxTokens, tok.Position)
+	}
+	if l.limits.MaxLiteralBytes > 0 && l.literalBytes > l.limits.MaxLiteralBytes {
+		return fmt.Errorf("%w: more than %d bytes of terms at %s", ErrQueryTooComplex, l.limits.MaxLiteralBytes, tok.Position)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser_Limits(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		limits  Limits
+		wantErr string
+	}{
+		{name: "unlimited", input: strings.Repeat("a OR ", 5000) + "a"},
+		{name: "tokens at the limit", input: "name:john AND age:30", limits: Limits{MaxTokens: 7}},
+		{
+			name:    "too many tokens",
+			input:   "name:john AND age:30 OR x",
+			limits:  Limits{MaxTokens: 7},
+			wantErr: "query too complex: more than 7 tokens at line 1, column 22",
+		},
+		{
+			name:    "too many tokens in an unfinished range",
+			input:   "price:[1 TO 2",
+			limits:  Limits{MaxTokens: 4},
+			wantErr: "query too complex: more than 4 tokens at line 1, column 10",
+		},
+		{name: "literal bytes at the limit", input: `title:"abcdef"`, limits: Limits{MaxLiteralBytes: 12}},
+		{
+			name:    "too many literal bytes",
+			input:   `title:"abcdef" OR body:abcdef`,
+			limits:  Limits{MaxLiteralBytes: 12},
+			wantErr: "query too complex: more than 12 bytes of terms at line 1, column 16",
+		},
+		{
+			name:    "generated mega-query",
+			input:   strings.Repeat("(a:b OR ", 100000),
+			limits:  Limits{MaxTokens: 1000, MaxLiteralBytes: 1 << 16},
+			wantErr: "query too complex: more than 1000 tokens at line 1, column 1601",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := NewParserWithLimits(tt.input, tt.limits).Parse()
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				assert.NotNil(t, ast)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, ErrQueryTooComplex))
+			assert.EqualError(t, err, tt.wantErr)
+			assert.Nil(t, ast)
+		})
+	}
+}
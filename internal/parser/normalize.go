@@ -0,0 +1,335 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Normalize returns a canonical copy of the AST, so that equivalent queries such as
+// "a AND b" and "b && a" normalize to the same tree. The input is not modified.
+//
+// Normalization:
+//   - flattens chains of the same AND/OR operator, including through groups, then sorts
+//     and deduplicates their operands
+//   - sorts and deduplicates the values of in-lists
+//   - uppercases operators and treats implicit ORs as explicit ones, except between the
+//     values of field groups, which schemas can join by AND
+//   - trims surrounding whitespace from values
+//   - drops groups, whose grouping the tree keeps, except within field groups, where
+//     groups leave the field
+//   - clears source positions
+func Normalize(node Node) Node {
+	return normalize(node)
+}
+
+// Canonical renders the normalized form of the AST in query syntax.
+// Equivalent queries render to the same string.
+func Canonical(node Node) string {
+	return render(Normalize(node))
+}
+
+// Hash returns a stable hash of the normalized AST, suitable as a cache or deduplication key.
+func Hash(node Node) string {
+	sum := sha256.Sum256([]byte(Canonical(node)))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalize returns the canonical copy of a node
+func normalize(node Node) Node {
+	switch n := node.(type) {
+	case nil:
+		return nil
+	case *BinaryOp:
+		op := strings.ToUpper(n.Op)
+		var operands []Node
+		collectOperands(n, op, &operands)
+		return buildChain(op, false, operands)
+	case *UnaryOp:
+		return &UnaryOp{Op: strings.ToUpper(n.Op), Operand: normalize(n.Operand)}
+	case *RequiredQuery:
+		return &RequiredQuery{Query: normalize(n.Query)}
+	case *ProhibitedQuery:
+		return &ProhibitedQuery{Query: normalize(n.Query)}
+	case *BoostQuery:
+		return &BoostQuery{Query: normalize(n.Query), Boost: n.Boost}
+	case *GroupQuery:
+		// The tree keeps the grouping, which render writes back as parentheses
+		return normalize(n.Query)
+	case *FieldQuery:
+		return &FieldQuery{Field: n.Field, Value: normalizeValue(n.Value)}
+	case *FieldGroupQuery:
+		queries := make([]Node, 0, len(n.Queries))
+		for _, q := range n.Queries {
+			queries = append(queries, normalizeMember(q))
+		}
+		return &FieldGroupQuery{Field: n.Field, Queries: sortUnique(queries)}
+	case *InListQuery:
+		return &InListQuery{Field: n.Field, Values: sortUniqueValues(n.Values)}
+	case *RangeQuery:
+		return &RangeQuery{
+			Field:          n.Field,
+			Start:          normalizeValue(n.Start),
+			End:            normalizeValue(n.End),
+			InclusiveStart: n.InclusiveStart,
+			InclusiveEnd:   n.InclusiveEnd,
+		}
+	case *FuzzyQuery:
+		return &FuzzyQuery{Field: n.Field, Term: strings.TrimSpace(n.Term), Distance: n.Distance}
+	case *ProximityQuery:
+		return &ProximityQuery{Field: n.Field, Phrase: strings.TrimSpace(n.Phrase), Distance: n.Distance}
+	case *ExistsQuery:
+		return &ExistsQuery{Field: n.Field}
+	case *TermQuery:
+		return &TermQuery{Term: strings.TrimSpace(n.Term)}
+	case *PhraseQuery:
+		return &PhraseQuery{Phrase: strings.TrimSpace(n.Phrase)}
+	case *WildcardQuery:
+		return &WildcardQuery{Pattern: strings.TrimSpace(n.Pattern)}
+	default:
+		return node
+	}
+}
+
+// collectOperands gathers the normalized operands of a chain of op, looking through groups
+func collectOperands(node Node, op string, operands *[]Node) {
+	switch n := node.(type) {
+	case *BinaryOp:
+		if strings.ToUpper(n.Op) == op {
+			collectOperands(n.Left, op, operands)
+			collectOperands(n.Right, op, operands)
+			return
+		}
+	case *GroupQuery:
+		if inner, ok := unwrapGroup(n).(*BinaryOp); ok && strings.ToUpper(inner.Op) == op {
+			collectOperands(inner, op, operands)
+			return
+		}
+	}
+	*operands = append(*operands, normalize(node))
+}
+
+// normalizeMember returns the canonical copy of a field group member. Values written next
+// to each other form chains apart from values joined by OR.
+// Groups within a field group leave the field, so they are kept.
+func normalizeMember(node Node) Node {
+	switch n := node.(type) {
+	case *BinaryOp:
+		op := strings.ToUpper(n.Op)
+		var operands []Node
+		collectMembers(n, op, n.Implicit, &operands)
+		return buildMemberChain(op, n.Implicit, operands)
+	case *GroupQuery:
+		return &GroupQuery{Query: normalize(n.Query)}
+	default:
+		return normalize(node)
+	}
+}
+
+// collectMembers gathers the normalized operands of a chain of op within a field group
+func collectMembers(node Node, op string, implicit bool, operands *[]Node) {
+	if bo, ok := node.(*BinaryOp); ok && strings.ToUpper(bo.Op) == op && bo.Implicit == implicit {
+		collectMembers(bo.Left, op, implicit, operands)
+		collectMembers(bo.Right, op, implicit, operands)
+		return
+	}
+	*operands = append(*operands, normalizeMember(node))
+}
+
+// buildChain joins sorted, deduplicated operands into a left-associative chain
+func buildChain(op string, implicit bool, operands []Node) Node {
+	return joinChain(op, implicit, sortUnique(operands))
+}
+
+// buildMemberChain joins the operands of a chain within a field group like buildChain, but
+// puts first an operand that binds no tighter than the chain: field groups cannot group
+// it, so it can only be written as the leftmost operand
+func buildMemberChain(op string, implicit bool, operands []Node) Node {
+	operands = sortUnique(operands)
+	precedence := binaryPrecedence(&BinaryOp{Op: op, Implicit: implicit})
+	for i, operand := range operands {
+		if bo, ok := operand.(*BinaryOp); ok && binaryPrecedence(bo) <= precedence {
+			operands = append(append([]Node{operand}, operands[:i]...), operands[i+1:]...)
+			break
+		}
+	}
+	return joinChain(op, implicit, operands)
+}
+
+// joinChain joins operands into a left-associative chain
+func joinChain(op string, implicit bool, operands []Node) Node {
+	chain := operands[0]
+	for _, operand := range operands[1:] {
+		chain = &BinaryOp{Op: op, Left: chain, Right: operand, Implicit: implicit}
+	}
+	return chain
+}
+
+// sortUnique orders nodes by their rendered form and drops duplicates
+func sortUnique(nodes []Node) []Node {
+	rendered := make(map[Node]string, len(nodes))
+	for _, n := range nodes {
+		rendered[n] = renderOperand(n, "")
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return rendered[nodes[i]] < rendered[nodes[j]]
+	})
+
+	unique := nodes[:0]
+	for i, n := range nodes {
+		if i > 0 && rendered[n] == rendered[nodes[i-1]] {
+			continue
+		}
+		unique = append(unique, n)
+	}
+	return unique
+}
+
+// sortUniqueValues returns the normalized values ordered by their rendered form, without duplicates
+func sortUniqueValues(values []ValueNode) []ValueNode {
+	rendered := make(map[string]ValueNode, len(values))
+	keys := make([]string, 0, len(values))
+	for _, value := range values {
+		normalized := normalizeValue(value)
+		key := renderExactValue(normalized)
+		if _, seen := rendered[key]; !seen {
+			rendered[key] = normalized
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	unique := make([]ValueNode, len(keys))
+	for i, key := range keys {
+		unique[i] = rendered[key]
+	}
+	return unique
+}
+
+// unwrapGroup removes any number of enclosing groups
+func unwrapGroup(node Node) Node {
+	for {
+		group, ok := node.(*GroupQuery)
+		if !ok {
+			return node
+		}
+		node = group.Query
+	}
+}
+
+// normalizeValue returns the canonical copy of a value
+func normalizeValue(value ValueNode) ValueNode {
+	switch v := value.(type) {
+	case *TermValue:
+		return &TermValue{Term: strings.TrimSpace(v.Term)}
+	case *PhraseValue:
+		return &PhraseValue{Phrase: strings.TrimSpace(v.Phrase)}
+	case *WildcardValue:
+		return &WildcardValue{Pattern: strings.TrimSpace(v.Pattern)}
+	case *RegexValue:
+		// Whitespace is significant in a regex
+		return &RegexValue{Pattern: v.Pattern, Flags: v.Flags}
+	case *NumberValue:
+		return &NumberValue{Number: strings.TrimSpace(v.Number)}
+	default:
+		return value
+	}
+}
+
+// render writes a normalized node in query syntax that parses back to the same query,
+// quoting terms the lexer would split rather than escaping them
+func render(node Node) string {
+	switch n := node.(type) {
+	case nil:
+		return ""
+	case *BinaryOp:
+		if n.Implicit {
+			return renderOperand(n.Left, "") + " " + renderOperand(n.Right, "")
+		}
+		return renderOperand(n.Left, n.Op) + " " + n.Op + " " + renderOperand(n.Right, n.Op)
+	case *UnaryOp:
+		return n.Op + " " + renderOperand(n.Operand, "")
+	case *RequiredQuery:
+		return "+" + renderOperand(n.Query, "")
+	case *ProhibitedQuery:
+		operand := renderOperand(n.Query, "")
+		if strings.HasPrefix(operand, "-") {
+			// "--" is rejected as a SQL comment
+			return "- " + operand
+		}
+		return "-" + operand
+	case *BoostQuery:
+		operand := renderOperand(n.Query, "")
+		switch n.Query.(type) {
+		case *UnaryOp, *RequiredQuery, *ProhibitedQuery:
+			operand = "(" + operand + ")"
+		}
+		return operand + "^" + strconv.FormatFloat(n.Boost, 'f', -1, 64)
+	case *GroupQuery:
+		return "(" + render(n.Query) + ")"
+	case *FieldQuery:
+		return n.Field + ":" + renderFieldValue(n.Value)
+	case *FieldGroupQuery:
+		parts := make([]string, len(n.Queries))
+		for i, q := range n.Queries {
+			parts[i] = renderMember(q)
+		}
+		return n.Field + ":(" + strings.Join(parts, " OR ") + ")"
+	case *InListQuery:
+		values := make([]string, len(n.Values))
+		for i, value := range n.Values {
+			values[i] = renderExactValue(value)
+		}
+		return n.Field + ":in(" + strings.Join(values, ", ") + ")"
+	case *RangeQuery:
+		return renderRange(n)
+	case *FuzzyQuery:
+		return fieldPrefix(n.Field) + renderTerm(n.Term, n.Field != "", true) + "~" + strconv.Itoa(n.Distance)
+	case *ProximityQuery:
+		return fieldPrefix(n.Field) + quote(n.Phrase) + "~" + strconv.Itoa(n.Distance)
+	case *ExistsQuery:
+		return "_exists_:" + n.Field
+	case *TermQuery:
+		return renderTerm(n.Term, false, true)
+	case *PhraseQuery:
+		return quote(n.Phrase)
+	case *WildcardQuery:
+		return n.Pattern
+	case *placeholder:
+		return n.String()
+	default:
+		return fmt.Sprintf("<%s>", node.Type())
+	}
+}
+
+// renderOperand renders a child node, parenthesizing binary operations other than parentOp
+func renderOperand(node Node, parentOp string) string {
+	if bo, ok := node.(*BinaryOp); ok && (bo.Op != parentOp || bo.Implicit) {
+		return "(" + render(bo) + ")"
+	}
+	return render(node)
+}
+
+// renderMember writes a field group member. Groups within a field group leave the field,
+// so chains are written without parentheses, in the order the parser binds them.
+func renderMember(node Node) string {
+	bo, ok := node.(*BinaryOp)
+	if !ok {
+		return render(node)
+	}
+	if bo.Implicit {
+		return renderMember(bo.Left) + " " + renderMember(bo.Right)
+	}
+	return renderMember(bo.Left) + " " + bo.Op + " " + renderMember(bo.Right)
+}
+
+// fieldPrefix returns "field:" or an empty string for default-field queries
+func fieldPrefix(field string) string {
+	if field == "" {
+		return ""
+	}
+	return field + ":"
+}
@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parse(t *testing.T, query string) Node {
+	t.Helper()
+
+	ast, err := NewParser(query).Parse()
+	require.NoError(t, err)
+	return ast
+}
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"b AND a", "a AND b"},
+		{"c OR a OR b", "a OR b OR c"},
+		{"quick brown", "brown OR quick"},
+		{"a AND (c AND b)", "a AND b AND c"},
+		{"(a OR b) AND c", "(a OR b) AND c"},
+		{"a OR b AND c", "(b AND c) OR a"},
+		{"((status:open))", "status:open"},
+		{"a AND a", "a"},
+		{`name:"  blue widget "`, `name:"blue widget"`},
+		{"NOT (b OR a)", "NOT (a OR b)"},
+		{"tags:(z OR x)", "tags:(x OR z)"},
+		{"tags:(z x)", "tags:(x z)"},
+		{"tags:(z x OR y)", "tags:(x z OR y)"},
+		{"tags:(y OR z x)", "tags:(y OR z x)"},
+		{"region:(us, ca, us)", "region:in(ca, us)"},
+		{`region:in("new york" ca)`, `region:in("new york", ca)`},
+		{"price:[10 TO 20}", "price:[10 TO 20}"},
+		{"price:>=10", "price:>=10"},
+		{"name:foo-bar", "name:foo-bar"},
+		{"created:[now-1d TO *]", "created:[now-1d TO *]"},
+		{`name:/a\/b/`, `name:/a\/b/`},
+		{`name:"say \"hi\""`, `name:"say \"hi\""`},
+		{"- -a", "- -a"},
+		{"name:/ab c/", "name:/ab c/"},
+		{"title:jo*", "title:jo*"},
+		{"(a OR b)^2", "(a OR b)^2"},
+		{"_exists_:email", "_exists_:email"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Canonical(parse(t, tt.query)))
+		})
+	}
+}
+
+func TestCanonical_ParsesBack(t *testing.T) {
+	queries := []string{
+		"name:foo-bar",
+		"created:[now-1d TO *]",
+		`name:/a\/b/i`,
+		`name:"say \"hi\" \\ bye"`,
+		`"café \t"`,
+		"price:<=10 OR price:{* TO 5]",
+		"temperature:[-10 TO 40]",
+		"name:jo* OR jo?n",
+		"name:foo~1 brwn~2",
+		`title:"quick fox"~3`,
+		"tags:(x y OR z)",
+		`region:in("new york" ca 5)`,
+		"NOT (a OR -b)^2",
+		"+a -(b c)",
+	}
+
+	// Random queries combine values the lexer reads specially under every operator
+	values := []string{"a", "foo-bar", "-3", "1.5", "now-1d", `"x \"y\""`, "jo*", "/a\\/b/", "AND", `"OR"`, "2024-01-01T00:00:00Z"}
+	fields := []string{"", "name:", "price:"}
+	random := rand.New(rand.NewSource(1))
+	var clause func(depth int) string
+	clause = func(depth int) string {
+		pick := func(options []string) string { return options[random.Intn(len(options))] }
+		switch n := random.Intn(9); {
+		case depth > 2 || n < 3:
+			value := pick(values)
+			if strings.HasPrefix(value, "/") {
+				return "name:" + value
+			}
+			return pick(fields) + value
+		case n == 3:
+			return "NOT " + clause(depth+1)
+		case n == 4:
+			return pick([]string{"+", "-"}) + "(" + clause(depth+1) + ")"
+		case n == 5:
+			return "(" + clause(depth+1) + ")^2"
+		case n == 6:
+			return "name:[" + pick([]string{"a", "*", "now-1d", "-10"}) + " TO " + pick([]string{"z", "*", "now"}) + "}"
+		default:
+			return clause(depth+1) + pick([]string{" AND ", " OR ", " ", " && "}) + clause(depth+1)
+		}
+	}
+	for i := 0; i < 300; i++ {
+		query := clause(0)
+		if _, err := NewParser(query).Parse(); err == nil {
+			queries = append(queries, query)
+		}
+	}
+
+	for _, query := range queries {
+		normalized := Normalize(parse(t, query))
+		canonical := Canonical(normalized)
+		reparsed, err := NewParser(canonical).Parse()
+		if assert.NoError(t, err, "%s rendered as %s", query, canonical) {
+			assert.Equal(t, Fingerprint(normalized), Fingerprint(Normalize(reparsed)), "%s rendered as %s", query, canonical)
+			assert.Equal(t, canonical, Canonical(reparsed))
+		}
+	}
+}
+
+func TestHash_EquivalentQueries(t *testing.T) {
+	equivalent := [][]string{
+		{"a AND b", "b AND a", "(a AND b)", "b && a"},
+		{"x OR (y OR z)", "z y x", "(z OR y) OR x"},
+		{"a OR (b AND c)", "(c AND b) OR a", "a OR b AND c"},
+	}
+
+	for _, group := range equivalent {
+		want := Hash(parse(t, group[0]))
+		for _, query := range group[1:] {
+			assert.Equal(t, want, Hash(parse(t, query)), "%q should hash like %q", query, group[0])
+		}
+	}
+}
+
+func TestHash_DistinctQueries(t *testing.T) {
+	distinct := []string{
+		"a AND b",
+		"a OR b",
+		"(a OR b) AND c",
+		"a OR (b AND c)",
+		"name:a",
+		`name:"a"`,
+		"name:a*",
+		"NOT a",
+		"price:[1 TO 2]",
+		"price:{1 TO 2}",
+		"tags:(a OR b)",
+		"tags:(a b)",
+	}
+
+	seen := make(map[string]string)
+	for _, query := range distinct {
+		hash := Hash(parse(t, query))
+		if other, exists := seen[hash]; exists {
+			t.Errorf("%q and %q hash the same", query, other)
+		}
+		seen[hash] = query
+	}
+}
+
+func TestNormalize_DoesNotModifyInput(t *testing.T) {
+	ast := parse(t, "b AND a")
+	before := Canonical(ast)
+
+	normalized := Normalize(ast)
+
+	bin := ast.(*BinaryOp)
+	assert.Equal(t, "b", bin.Left.(*TermQuery).Term)
+	assert.Equal(t, 1, bin.Pos.Line)
+	assert.Equal(t, Position{}, normalized.Position())
+	assert.Equal(t, before, Canonical(ast))
+}
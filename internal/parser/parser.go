@@ -1,6 +1,11 @@
 package parser
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
 
 // Parser parses OpenSearch query strings into AST
 type Parser struct {
@@ -8,11 +13,26 @@ type Parser struct {
 	current Token
 	peek    Token
 	errors  *ParseErrors
+
+	reserved []reservedWord // reserved words written where a value was expected
+}
+
+// reservedWord is a reserved word reported as an error, and that error
+type reservedWord struct {
+	token Token
+	err   *ParseError
 }
 
 // NewParser creates a new parser for the given input
 func NewParser(input string) *Parser {
+	return NewParserWithLimits(input, Limits{})
+}
+
+// NewParserWithLimits creates a parser that aborts with ErrQueryTooComplex once the
+// input exceeds limits
+func NewParserWithLimits(input string, limits Limits) *Parser {
 	lexer := NewLexer(input)
+	lexer.limits = limits
 	p := &Parser{
 		lexer:  lexer,
 		errors: &ParseErrors{},
@@ -31,20 +51,50 @@ func (p *Parser) nextToken() {
 
 // Parse parses the query and returns the root AST node
 func (p *Parser) Parse() (Node, error) {
+	if p.lexer.err != nil {
+		return nil, p.lexer.err
+	}
 	if p.current.Type == EOF {
 		return nil, nil
 	}
 
 	expr := p.parseExpression(LOWEST)
 
+	// Whatever was parsed before a limit was hit is incomplete
+	if p.lexer.err != nil {
+		return nil, p.lexer.err
+	}
+
+	// The query ends early at a number written with separators, as in price:1.234,56 or
+	// price:1'234; the rest would be ignored and 1.234 or 1 searched
+	if p.separatedNumber() {
+		p.addError(fmt.Sprintf(`unexpected %q after a number: quote numbers written with separators, e.g. price:"1.234,56"`, p.current.Literal), p.current.Position)
+	} else if p.current.Type == COMMA {
+		p.addError(`unexpected "," outside a list: put lists in parentheses, e.g. field:(a, b)`, p.current.Position)
+	}
+
 	if p.errors.HasErrors() {
+		p.fixReservedWords()
 		return expr, p.errors
 	}
 
 	return expr, nil
 }
 
-// Operator precedence (lowest to highest)
+// separatedNumber reports whether the current token is a separator of numbers directly
+// following a digit, which the lexer does not read as part of the number
+func (p *Parser) separatedNumber() bool {
+	if p.current.Type != COMMA && p.current.Type != ILLEGAL {
+		return false
+	}
+	offset := p.current.Position.Offset
+	separator := p.current.Literal == "," || p.current.Literal == "." || p.current.Literal == "'"
+	return separator && offset > 0 && isDigit(p.lexer.input[offset-1])
+}
+
+// Operator precedence (lowest to highest). As in Lucene, NOT binds tighter than AND,
+// which binds tighter than OR: "NOT a:x AND b:y OR c:z" is ((NOT a:x) AND b:y) OR c:z.
+// Adjacent clauses are joined by an implicit OR at OR precedence.
 const (
 	LOWEST        int = iota
 	OR_PREC           // OR, ||
@@ -124,6 +174,13 @@ func (p *Parser) parseExpression(precedence int) Node {
 	case QUOTED_STRING:
 		left = p.parsePhraseExpression()
 	default:
+		if p.isReservedWord() {
+			// Parse on as if it were quoted, so later errors are reported as well
+			p.addReservedWordError()
+			p.current.Type = STRING
+			left = p.parsePrimaryExpression()
+			break
+		}
 		p.addError(fmt.Sprintf("unexpected token: %s", p.current.Type), p.current.Position)
 		p.nextToken()
 		return nil
@@ -169,10 +226,11 @@ func (p *Parser) parseExpression(precedence int) Node {
 			right := p.parseExpression(OR_PREC)
 			if right != nil {
 				left = &BinaryOp{
-					Op:    "OR",
-					Left:  left,
-					Right: right,
-					Pos:   left.Position(),
+					Op:       "OR",
+					Left:     left,
+					Right:    right,
+					Pos:      left.Position(),
+					Implicit: true,
 				}
 			} else {
 				return left
@@ -251,7 +309,7 @@ func (p *Parser) parseRequiredExpression() Node {
 	pos := p.current.Position
 	p.nextToken()
 
-	query := p.parseExpression(PREFIX)
+	query := p.parseExpression(REQUIRED_PREC)
 
 	return &RequiredQuery{
 		Query: query,
@@ -264,7 +322,7 @@ func (p *Parser) parseProhibitedExpression() Node {
 	pos := p.current.Position
 	p.nextToken()
 
-	query := p.parseExpression(PREFIX)
+	query := p.parseExpression(REQUIRED_PREC)
 
 	return &ProhibitedQuery{
 		Query: query,
@@ -319,6 +377,20 @@ func (p *Parser) parseFieldQuery(field string, pos Position) Node {
 		return p.parseComparisonQuery(field, pos)
 	}
 
+	// Check for an in-list: field:in(a b c), with "in" directly before the parenthesis
+	if p.current.Type == STRING && strings.EqualFold(p.current.Literal, "in") &&
+		p.peek.Type == LPAREN && p.peek.Position.Offset == p.current.Position.Offset+len(p.current.Literal) {
+		p.nextToken() // consume 'in'
+		p.nextToken() // consume '('
+		return p.parseInList(field, pos, false)
+	}
+
+	// A reserved word directly after the colon is the value, as in code:TO, since no
+	// operator can be there
+	if p.isReservedWord() && p.current.Position.Offset > 0 && p.lexer.input[p.current.Position.Offset-1] == ':' {
+		p.current.Type = STRING
+	}
+
 	// Regular field:value
 	value := p.parseValue()
 
@@ -328,14 +400,17 @@ func (p *Parser) parseFieldQuery(field string, pos Position) Node {
 		Pos:   pos,
 	}
 
-	// Check for fuzzy ~N
+	// Check for fuzzy ~N, or proximity for a phrase
 	if p.current.Type == TILDE {
-		p.nextToken()
-		distance := 2 // default fuzzy distance
-		if p.current.Type == NUMBER {
-			fmt.Sscanf(p.current.Literal, "%d", &distance)
-			p.nextToken()
+		if phrase, ok := value.(*PhraseValue); ok {
+			return &ProximityQuery{
+				Field:    field,
+				Phrase:   phrase.Phrase,
+				Distance: p.parseDistance(false),
+				Pos:      pos,
+			}
 		}
+		distance := p.parseDistance(true)
 		return &FuzzyQuery{
 			Field:    field,
 			Term:     value.Value().(string),
@@ -346,17 +421,7 @@ func (p *Parser) parseFieldQuery(field string, pos Position) Node {
 
 	// Check for boost ^N
 	if p.current.Type == CARET {
-		p.nextToken()
-		boost := 1.0
-		if p.current.Type == NUMBER {
-			fmt.Sscanf(p.current.Literal, "%f", &boost)
-			p.nextToken()
-		}
-		return &BoostQuery{
-			Query: node,
-			Boost: boost,
-			Pos:   pos,
-		}
+		return p.parseBoostExpression(node)
 	}
 
 	return node
@@ -366,6 +431,11 @@ func (p *Parser) parseFieldQuery(field string, pos Position) Node {
 func (p *Parser) parseFieldGroupQuery(field string, pos Position) Node {
 	p.nextToken() // consume '('
 
+	// A comma after the first value makes the group an in-list: field:(a,b,c)
+	if (isListValue(p.current.Type) || p.isReservedWord()) && p.peek.Type == COMMA {
+		return p.parseInList(field, pos, true)
+	}
+
 	var queries []Node
 	for p.current.Type != RPAREN && p.current.Type != EOF {
 		expr := p.parseExpression(LOWEST)
@@ -392,6 +462,39 @@ func (p *Parser) parseFieldGroupQuery(field string, pos Position) Node {
 	}
 }
 
+// parseInList parses the values of an in-list up to and including the closing ')'.
+// Values are separated by commas, which are optional in the in(...) form.
+func (p *Parser) parseInList(field string, pos Position, requireCommas bool) Node {
+	var values []ValueNode
+	for {
+		if p.isReservedWord() {
+			p.addReservedWordError()
+			p.current.Type = STRING
+		}
+		if !isListValue(p.current.Type) {
+			p.addError(fmt.Sprintf("expected a term, number or phrase in list, got %s", p.current.Type), p.current.Position)
+			return nil
+		}
+		values = append(values, p.parseValue())
+
+		switch {
+		case p.current.Type == RPAREN:
+			p.nextToken() // consume ')'
+			return &InListQuery{Field: field, Values: values, Pos: pos}
+		case p.current.Type == COMMA:
+			p.nextToken() // consume ','
+		case requireCommas:
+			p.addError("expected ',' or ')' in list", p.current.Position)
+			return nil
+		}
+	}
+}
+
+// isListValue reports whether a token can be an in-list value
+func isListValue(tt TokenType) bool {
+	return tt == STRING || tt == NUMBER || tt == QUOTED_STRING
+}
+
 // parseFieldRangeQuery parses field:[start TO end] or field:{start TO end}
 func (p *Parser) parseFieldRangeQuery(field string, pos Position) Node {
 	inclusive := p.current.Type == LBRACKET
@@ -479,6 +582,12 @@ func (p *Parser) parseValue() ValueNode {
 	pos := p.current.Position
 	var value ValueNode
 
+	if p.isReservedWord() {
+		// Parse on as if it were quoted, so later errors are reported as well
+		p.addReservedWordError()
+		p.current.Type = STRING
+	}
+
 	switch p.current.Type {
 	case STRING:
 		value = &TermValue{Term: p.current.Literal, Pos: pos}
@@ -487,7 +596,13 @@ func (p *Parser) parseValue() ValueNode {
 	case WILDCARD:
 		value = &WildcardValue{Pattern: p.current.Literal, Pos: pos}
 	case REGEX:
-		value = &RegexValue{Pattern: p.current.Literal, Pos: pos}
+		regex := &RegexValue{Pattern: p.current.Literal, Pos: pos}
+		// Flags follow the closing slash directly, as in /widget/i
+		if flags := p.peek; flags.Type == STRING && flags.Position.Offset == pos.Offset+len(regex.Pattern)+2 {
+			p.nextToken()
+			regex.Flags = p.parseRegexFlags(regex.Pattern, flags)
+		}
+		value = regex
 	case NUMBER:
 		value = &NumberValue{Number: p.current.Literal, Pos: pos}
 	default:
@@ -499,6 +614,24 @@ func (p *Parser) parseValue() ValueNode {
 	return value
 }
 
+// parseRegexFlags validates the flags of /pattern/flags and returns them in the order of
+// RegexFlags
+func (p *Parser) parseRegexFlags(pattern string, tok Token) string {
+	for _, flag := range tok.Literal {
+		if !strings.ContainsRune(RegexFlags, flag) {
+			p.addError(fmt.Sprintf("unsupported regex flag %q in /%s/%s: expected i or m", flag, pattern, tok.Literal), tok.Position)
+			return ""
+		}
+	}
+	var flags strings.Builder
+	for _, flag := range RegexFlags {
+		if strings.ContainsRune(tok.Literal, flag) {
+			flags.WriteRune(flag)
+		}
+	}
+	return flags.String()
+}
+
 // parseRangeExpression parses standalone range [50 TO 500]
 func (p *Parser) parseRangeExpression() Node {
 	pos := p.current.Position
@@ -538,16 +671,38 @@ func (p *Parser) parseRangeExpression() Node {
 	}
 }
 
-// parseBoostExpression parses expr^boost
+// parseBoostExpression parses expr^boost for terms, field queries, phrases, ranges and
+// groups alike. The boost is a non-negative decimal such as 2, 1.25 or .5; anything else
+// after '^' is an error rather than a silent boost of 1.
 func (p *Parser) parseBoostExpression(expr Node) Node {
 	pos := p.current.Position
-	p.nextToken()
+	p.nextToken() // consume '^'
+
+	switch p.current.Type {
+	case NUMBER:
+	case MINUS:
+		p.addError("boost must not be negative", p.current.Position)
+		p.nextToken()
+		if p.current.Type == NUMBER {
+			p.nextToken()
+		}
+		return expr
+	case STRING:
+		p.addError(fmt.Sprintf("invalid boost %q: expected a decimal number", p.current.Literal), p.current.Position)
+		p.nextToken()
+		return expr
+	default:
+		p.addError("expected a boost after '^'", p.current.Position)
+		return expr
+	}
 
-	boost := 1.0
-	if p.current.Type == NUMBER {
-		fmt.Sscanf(p.current.Literal, "%f", &boost)
+	boost, err := strconv.ParseFloat(p.current.Literal, 64)
+	if err != nil || math.IsInf(boost, 0) {
+		p.addError(fmt.Sprintf("invalid boost %q: expected a decimal number", p.current.Literal), p.current.Position)
 		p.nextToken()
+		return expr
 	}
+	p.nextToken()
 
 	return &BoostQuery{
 		Query: expr,
@@ -556,22 +711,71 @@ func (p *Parser) parseBoostExpression(expr Node) Node {
 	}
 }
 
-// parseFuzzyOrProximityExpression parses term~distance or "phrase"~distance
-func (p *Parser) parseFuzzyOrProximityExpression(expr Node) Node {
-	pos := p.current.Position
-	p.nextToken()
+// DefaultFuzzyDistance is the distance of a '~' written without one, as in roam~
+const DefaultFuzzyDistance = 2
+
+// MaxFuzzyDistance is the largest edit distance of a fuzzy query, as in Lucene
+const MaxFuzzyDistance = 2
+
+// parseDistance parses the '~' of a fuzzy or proximity query and the distance written
+// directly after it. A bare '~' means DefaultFuzzyDistance edits or, for a phrase, a
+// distance of DefaultFuzzyDistance words. Distances are whole numbers, and fuzzy ones are
+// at most MaxFuzzyDistance; the similarity fractions of old Lucene versions, as in
+// roam~0.8, are rejected.
+func (p *Parser) parseDistance(fuzzy bool) int {
+	tilde := p.current
+	p.nextToken() // consume '~'
+
+	kind := "proximity"
+	if fuzzy {
+		kind = "fuzzy"
+	}
+
+	// A number after whitespace is the next clause, as in roam~ 5
+	if p.current.Position.Offset != tilde.Position.Offset+len(tilde.Literal) {
+		return DefaultFuzzyDistance
+	}
 
-	distance := 2 // default
-	if p.current.Type == NUMBER {
-		fmt.Sscanf(p.current.Literal, "%d", &distance)
+	switch p.current.Type {
+	case NUMBER:
+	case MINUS:
+		p.addError(fmt.Sprintf("%s distance must not be negative", kind), p.current.Position)
 		p.nextToken()
+		if p.current.Type == NUMBER {
+			p.nextToken()
+		}
+		return DefaultFuzzyDistance
+	case STRING:
+		p.addError(fmt.Sprintf("invalid %s distance %q: expected a whole number", kind, p.current.Literal), p.current.Position)
+		p.nextToken()
+		return DefaultFuzzyDistance
+	default:
+		return DefaultFuzzyDistance
+	}
+
+	literal := p.current.Literal
+	p.nextToken()
+	distance, err := strconv.Atoi(literal)
+	if err != nil {
+		p.addError(fmt.Sprintf("invalid %s distance %q: expected a whole number", kind, literal), tilde.Position)
+		return DefaultFuzzyDistance
+	}
+	if fuzzy && distance > MaxFuzzyDistance {
+		p.addError(fmt.Sprintf("fuzzy distance %d exceeds the maximum of %d edits", distance, MaxFuzzyDistance), tilde.Position)
+		return MaxFuzzyDistance
 	}
+	return distance
+}
+
+// parseFuzzyOrProximityExpression parses term~distance or "phrase"~distance
+func (p *Parser) parseFuzzyOrProximityExpression(expr Node) Node {
+	pos := p.current.Position
 
 	// Check if expr is a phrase or term
 	if phrase, ok := expr.(*PhraseQuery); ok {
 		return &ProximityQuery{
 			Phrase:   phrase.Phrase,
-			Distance: distance,
+			Distance: p.parseDistance(false),
 			Pos:      pos,
 		}
 	}
@@ -580,11 +784,12 @@ func (p *Parser) parseFuzzyOrProximityExpression(expr Node) Node {
 		return &FuzzyQuery{
 			Field:    "",
 			Term:     term.Term,
-			Distance: distance,
+			Distance: p.parseDistance(true),
 			Pos:      pos,
 		}
 	}
 
+	p.parseDistance(false)
 	return expr
 }
 
@@ -619,6 +824,47 @@ func (p *Parser) addError(message string, pos Position) {
 	p.errors.Add(NewParseError(message, pos))
 }
 
+// isReservedWord reports whether the current token is one of the words AND, OR, NOT and
+// TO, as opposed to the operators &&, || and !
+func (p *Parser) isReservedWord() bool {
+	switch p.current.Type {
+	case AND, OR, NOT, TO:
+		return p.current.Literal == p.current.Type.String()
+	}
+	return false
+}
+
+// addReservedWordError reports a reserved word where a value or query was expected,
+// which fixReservedWords completes once the query is parsed
+func (p *Parser) addReservedWordError() {
+	err := NewParseError(p.current.Literal+" is a reserved word", p.current.Position)
+	p.errors.Add(err)
+	p.reserved = append(p.reserved, reservedWord{token: p.current, err: err})
+}
+
+// fixReservedWords suggests quoting the reserved words written where values were
+// expected: every one of their errors has the query with all of them quoted as its fix
+func (p *Parser) fixReservedWords() {
+	if len(p.reserved) == 0 {
+		return
+	}
+
+	var fix strings.Builder
+	input := p.lexer.input
+	last := 0
+	for _, word := range p.reserved {
+		fix.WriteString(input[last:word.token.Position.Offset])
+		fix.WriteString(strconv.Quote(word.token.Literal))
+		last = word.token.Position.Offset + len(word.token.Literal)
+	}
+	fix.WriteString(input[last:])
+
+	for _, word := range p.reserved {
+		word.err.Message = fmt.Sprintf("%s is a reserved word; quote it to search for it, as in: %s", word.token.Literal, fix.String())
+		word.err.Fix = fix.String()
+	}
+}
+
 // precedenceOfType returns the precedence of a token type
 func precedenceOfType(tt TokenType) int {
 	switch tt {
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -731,3 +732,152 @@ func TestParser_ImplicitOR(t *testing.T) {
 		t.Errorf("expected OR operator, got %q", bin.Op)
 	}
 }
+
+func TestParser_RegexFlags(t *testing.T) {
+	tests := []struct {
+		query string
+		flags string
+	}{
+		{"name:/wid.*/", ""},
+		{"name:/wid.*/i", "i"},
+		{"name:/wid.*/m", "m"},
+		{"name:/wid.*/mi", "im"},
+		{"name:/wid.*/ii", "i"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			node, err := NewParser(tt.query).Parse()
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+			fq, ok := node.(*FieldQuery)
+			if !ok {
+				t.Fatalf("expected FieldQuery, got %T", node)
+			}
+			regex, ok := fq.Value.(*RegexValue)
+			if !ok {
+				t.Fatalf("expected RegexValue, got %T", fq.Value)
+			}
+			if regex.Pattern != "wid.*" || regex.Flags != tt.flags {
+				t.Errorf("expected /wid.*/%s, got /%s/%s", tt.flags, regex.Pattern, regex.Flags)
+			}
+		})
+	}
+
+	// A term after whitespace is a separate clause, not flags
+	node, err := NewParser("name:/wid.*/ i").Parse()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if _, ok := node.(*BinaryOp); !ok {
+		t.Errorf("expected BinaryOp, got %T", node)
+	}
+
+	_, err = NewParser("name:/wid.*/x").Parse()
+	if err == nil || !strings.Contains(err.Error(), `unsupported regex flag 'x' in /wid.*/x: expected i or m`) {
+		t.Errorf("expected an unsupported flag error, got %v", err)
+	}
+}
+
+func TestParser_ReservedWordValues(t *testing.T) {
+	// Directly after the colon, reserved words are values
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"code:TO", "code:TO"},
+		{"code:AND", "code:AND"},
+		{"code:OR OR x:1", "code:OR OR x:1"},
+		{"NOT code:NOT", "NOT code:NOT"},
+		{"a:1 AND code:TO^2", "a:1 AND code:TO^2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			node, err := NewParser(tt.query).Parse()
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+			if got := Canonical(node); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	node, err := NewParser("code:TO").Parse()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if fq, ok := node.(*FieldQuery); !ok || fq.Value.Value() != "TO" {
+		t.Errorf("expected the term TO, got %#v", node)
+	}
+}
+
+func TestParser_ReservedWordErrors(t *testing.T) {
+	// Elsewhere, they must be quoted; every error suggests quoting all of them
+	tests := []struct {
+		query  string
+		errors []string
+		fix    string
+	}{
+		{
+			query:  "code: TO",
+			errors: []string{`TO is a reserved word; quote it to search for it, as in: code: "TO"`},
+			fix:    `code: "TO"`,
+		},
+		{
+			query:  "code:[a TO TO]",
+			errors: []string{`TO is a reserved word; quote it to search for it, as in: code:[a TO "TO"]`},
+			fix:    `code:[a TO "TO"]`,
+		},
+		{
+			query: "code:(TO OR AND)",
+			errors: []string{
+				`TO is a reserved word; quote it to search for it, as in: code:("TO" OR "AND")`,
+				`AND is a reserved word; quote it to search for it, as in: code:("TO" OR "AND")`,
+			},
+			fix: `code:("TO" OR "AND")`,
+		},
+		{
+			query:  "code:(TO, x)",
+			errors: []string{`TO is a reserved word; quote it to search for it, as in: code:("TO", x)`},
+			fix:    `code:("TO", x)`,
+		},
+		{
+			query:  "AND b",
+			errors: []string{`AND is a reserved word; quote it to search for it, as in: "AND" b`},
+			fix:    `"AND" b`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := NewParser(tt.query).Parse()
+			parseErrs, ok := err.(*ParseErrors)
+			if !ok {
+				t.Fatalf("expected ParseErrors, got %v", err)
+			}
+			if len(parseErrs.Errors) != len(tt.errors) {
+				t.Fatalf("expected %d errors, got %v", len(tt.errors), parseErrs)
+			}
+			for i, parseErr := range parseErrs.Errors {
+				if parseErr.Message != tt.errors[i] {
+					t.Errorf("expected %q, got %q", tt.errors[i], parseErr.Message)
+				}
+				if parseErr.Fix != tt.fix {
+					t.Errorf("expected fix %q, got %q", tt.fix, parseErr.Fix)
+				}
+			}
+			if _, err := NewParser(tt.fix).Parse(); err != nil {
+				t.Errorf("fix %q does not parse: %v", tt.fix, err)
+			}
+		})
+	}
+
+	// The operators written as symbols are not words to quote
+	_, err := NewParser("code:&&").Parse()
+	if err == nil || !strings.Contains(err.Error(), "unexpected value type: AND") {
+		t.Errorf("expected an unexpected value error, got %v", err)
+	}
+}
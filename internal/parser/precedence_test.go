@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sexpr renders an AST with every operator's operands spelled out, so grouping is explicit
+func sexpr(node Node) string {
+	switch n := node.(type) {
+	case *BinaryOp:
+		op := n.Op
+		if n.Implicit {
+			op = "or"
+		}
+		return fmt.Sprintf("(%s %s %s)", op, sexpr(n.Left), sexpr(n.Right))
+	case *UnaryOp:
+		return fmt.Sprintf("(NOT %s)", sexpr(n.Operand))
+	case *RequiredQuery:
+		return fmt.Sprintf("(+ %s)", sexpr(n.Query))
+	case *ProhibitedQuery:
+		return fmt.Sprintf("(- %s)", sexpr(n.Query))
+	case *GroupQuery:
+		return sexpr(n.Query)
+	case *BoostQuery:
+		return fmt.Sprintf("(^%v %s)", n.Boost, sexpr(n.Query))
+	case *FieldQuery:
+		return fmt.Sprintf("%s:%v", n.Field, n.Value.Value())
+	case *TermQuery:
+		return n.Term
+	case *PhraseQuery:
+		return fmt.Sprintf("%q", n.Phrase)
+	}
+	return fmt.Sprintf("%T", node)
+}
+
+// TestParser_Precedence pins how operators group, following OpenSearch's query_string
+// parser: NOT and the +/- prefixes apply to the next clause only, AND binds tighter
+// than OR, and field binding and boosts bind tighter than any operator
+func TestParser_Precedence(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"NOT status:active AND region:ca", "(AND (NOT status:active) region:ca)"},
+		{"!status:active && region:ca", "(AND (NOT status:active) region:ca)"},
+		{"NOT status:active OR region:ca", "(OR (NOT status:active) region:ca)"},
+		{"region:ca AND NOT status:active", "(AND region:ca (NOT status:active))"},
+		{"NOT a:x AND b:y AND c:z", "(AND (AND (NOT a:x) b:y) c:z)"},
+		{"NOT (a:x AND b:y)", "(NOT (AND a:x b:y))"},
+		{"NOT NOT a", "(NOT (NOT a))"},
+		{"NOT a:x^2", "(NOT (^2 a:x))"},
+		{"a AND NOT b OR c", "(OR (AND a (NOT b)) c)"},
+		{"a OR b AND c", "(OR a (AND b c))"},
+		{"a AND b OR c AND d", "(OR (AND a b) (AND c d))"},
+		{"-a AND b", "(AND (- a) b)"},
+		{"a NOT b", "(or a (NOT b))"},
+		{"NOT a b", "(or (NOT a) b)"},
+		{"+a -b c", "(or (or (+ a) (- b)) c)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ast, err := NewParser(tt.query).Parse()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sexpr(ast))
+		})
+	}
+}
@@ -149,6 +149,17 @@ func TestRangeQueryParsing(t *testing.T) {
 			wantInclusiveEnd:   true,
 			wantError:          false,
 		},
+		{
+			name:               "Negative numbers - unquoted",
+			input:              "temperature:[-10 TO -2.5]",
+			wantType:           "RangeQuery",
+			wantField:          "temperature",
+			wantStart:          "-10",
+			wantEnd:            "-2.5",
+			wantInclusiveStart: true,
+			wantInclusiveEnd:   true,
+			wantError:          false,
+		},
 		{
 			name:               "String range - alphabetical",
 			input:              "name:[alice TO zoe]",
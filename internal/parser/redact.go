@@ -0,0 +1,58 @@
+package parser
+
+import "strconv"
+
+// Redact renders the normalized form of the AST with every value replaced by "?". Fields,
+// operators, range brackets, open bounds and fuzzy, proximity and boost modifiers are
+// kept, so the structure of a query can be logged without the values it searched for.
+func Redact(node Node) string {
+	return render(redact(Normalize(node)))
+}
+
+// redact replaces the values of a normalized AST with placeholders
+func redact(node Node) Node {
+	return Transform(node, func(n Node) Node {
+		switch q := n.(type) {
+		case *FieldQuery:
+			return &FieldQuery{Field: q.Field, Value: redactValue(q.Value)}
+		case *InListQuery:
+			values := make([]ValueNode, len(q.Values))
+			for i, value := range q.Values {
+				values[i] = redactValue(value)
+			}
+			return &InListQuery{Field: q.Field, Values: values}
+		case *RangeQuery:
+			redacted := *q
+			redacted.Start = redactValue(q.Start)
+			redacted.End = redactValue(q.End)
+			return &redacted
+		case *FuzzyQuery:
+			return &placeholder{field: q.Field, suffix: "~" + strconv.Itoa(q.Distance)}
+		case *ProximityQuery:
+			return &placeholder{field: q.Field, suffix: "~" + strconv.Itoa(q.Distance)}
+		case *TermQuery, *PhraseQuery, *WildcardQuery:
+			return &placeholder{}
+		}
+		return n
+	})
+}
+
+// redactValue replaces a value with a placeholder, keeping open range bounds
+func redactValue(value ValueNode) ValueNode {
+	if value == nil || value.Value() == "*" {
+		return value
+	}
+	return &placeholder{}
+}
+
+// placeholder stands for a redacted value, or a redacted clause made of a single value
+type placeholder struct {
+	field  string // field of a redacted clause
+	suffix string // modifier of a redacted clause, such as a fuzzy distance
+}
+
+func (n *placeholder) Type() string       { return "Placeholder" }
+func (n *placeholder) Position() Position { return Position{} }
+func (n *placeholder) Value() interface{} { return "?" }
+func (n *placeholder) IsValueNode()       {}
+func (n *placeholder) String() string     { return fieldPrefix(n.field) + "?" + n.suffix }
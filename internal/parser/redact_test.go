@@ -0,0 +1,45 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{`email:"jane@example.com"`, "email:?"},
+		{`name:"Jane Doe" AND age:42`, "age:? AND name:?"},
+		{"ssn:123-45-6789 OR ssn:987-65-4321", "ssn:? OR ssn:?"},
+		{"region:(us, ca)", "region:in(?, ?)"},
+		{"tags:(vip OR staff)", "tags:(? OR ?)"},
+		{"dob:[1980-01-01 TO 1990-01-01}", "dob:[? TO ?}"},
+		{"price:>=10", "price:>=?"},
+		{"name:jo*", "name:?"},
+		{"name:/jo.*n/i", "name:?"},
+		{"name:jon~1", "name:?~1"},
+		{`bio:"lives in paris"~3`, "bio:?~3"},
+		{"jane AND _exists_:phone", "_exists_:phone AND ?"},
+		{`"jane doe"`, "?"},
+		{"-status:banned^2", "-status:?^2"},
+		{"NOT (a OR b)", "NOT (? OR ?)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Redact(parse(t, tt.query)))
+		})
+	}
+}
+
+func TestRedact_DoesNotModifyInput(t *testing.T) {
+	ast := parse(t, `email:"jane@example.com" AND region:(us, ca)`)
+	before := Canonical(ast)
+
+	Redact(ast)
+
+	assert.Equal(t, before, Canonical(ast))
+}
@@ -0,0 +1,253 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Render writes the AST in query syntax as it is, without normalizing it: parsing the
+// result gives back the same tree, positions aside. Trees built by hand may come back in
+// the form the parser builds, such as a term the lexer would split coming back as a
+// phrase, or a nested operation coming back grouped.
+func Render(node Node) string {
+	switch n := node.(type) {
+	case nil:
+		return ""
+	case *BinaryOp:
+		precedence := binaryPrecedence(n)
+		// Chains are left-associative, so a right operand of the same precedence is grouped
+		left, right := renderAt(n.Left, precedence), renderAt(n.Right, precedence+1)
+		if n.Implicit {
+			return left + " " + right
+		}
+		return left + " " + n.Op + " " + right
+	case *UnaryOp:
+		return n.Op + " " + renderAt(n.Operand, NOT_PREC)
+	case *RequiredQuery:
+		return "+" + renderAt(n.Query, NOT_PREC)
+	case *ProhibitedQuery:
+		operand := renderAt(n.Query, NOT_PREC)
+		if strings.HasPrefix(operand, "-") {
+			// "--" is rejected as a SQL comment
+			return "- " + operand
+		}
+		return "-" + operand
+	case *BoostQuery:
+		operand := Render(n.Query)
+		switch n.Query.(type) {
+		case *BinaryOp, *UnaryOp, *RequiredQuery, *ProhibitedQuery:
+			operand = "(" + operand + ")"
+		}
+		return operand + "^" + strconv.FormatFloat(n.Boost, 'f', -1, 64)
+	case *GroupQuery:
+		return "(" + Render(n.Query) + ")"
+	case *FieldQuery:
+		return n.Field + ":" + renderFieldValue(n.Value)
+	case *FieldGroupQuery:
+		parts := make([]string, len(n.Queries))
+		for i, q := range n.Queries {
+			parts[i] = Render(q)
+		}
+		return n.Field + ":(" + strings.Join(parts, " ") + ")"
+	case *InListQuery:
+		values := make([]string, len(n.Values))
+		for i, value := range n.Values {
+			values[i] = renderExactValue(value)
+		}
+		return n.Field + ":in(" + strings.Join(values, ", ") + ")"
+	case *RangeQuery:
+		return renderRange(n)
+	case *FuzzyQuery:
+		return fieldPrefix(n.Field) + renderTerm(n.Term, n.Field != "", true) + "~" + strconv.Itoa(n.Distance)
+	case *ProximityQuery:
+		return fieldPrefix(n.Field) + quote(n.Phrase) + "~" + strconv.Itoa(n.Distance)
+	case *ExistsQuery:
+		return "_exists_:" + n.Field
+	case *TermQuery:
+		return renderTerm(n.Term, false, true)
+	case *PhraseQuery:
+		return quote(n.Phrase)
+	case *WildcardQuery:
+		return n.Pattern
+	case *placeholder:
+		return n.String()
+	default:
+		return fmt.Sprintf("<%s>", node.Type())
+	}
+}
+
+// renderAt renders an operand where the parser stops at operators below precedence,
+// grouping binary operations that bind more loosely
+func renderAt(node Node, precedence int) string {
+	if bo, ok := node.(*BinaryOp); ok && binaryPrecedence(bo) < precedence {
+		return "(" + Render(bo) + ")"
+	}
+	return Render(node)
+}
+
+// binaryPrecedence returns the precedence the parser gives a boolean operator. Implicit
+// ORs bind like explicit ones.
+func binaryPrecedence(bo *BinaryOp) int {
+	if !bo.Implicit && strings.EqualFold(bo.Op, "AND") {
+		return AND_PREC
+	}
+	return OR_PREC
+}
+
+// renderRange writes a range, in the comparison syntax it was parsed from when its open
+// bound is the term the parser puts there for comparisons
+func renderRange(n *RangeQuery) string {
+	open := func(value ValueNode) bool {
+		term, ok := value.(*TermValue)
+		return ok && term.Term == "*"
+	}
+	if n.Field != "" {
+		switch {
+		case open(n.End) && !n.InclusiveEnd && !open(n.Start):
+			if n.InclusiveStart {
+				return n.Field + ":>=" + renderExactValue(n.Start)
+			}
+			return n.Field + ":>" + renderExactValue(n.Start)
+		case open(n.Start) && !n.InclusiveStart && !open(n.End):
+			if n.InclusiveEnd {
+				return n.Field + ":<=" + renderExactValue(n.End)
+			}
+			return n.Field + ":<" + renderExactValue(n.End)
+		}
+	}
+
+	brackets := map[bool]string{true: "[", false: "{"}
+	rng := brackets[n.InclusiveStart] + renderExactValue(n.Start) + " TO " + renderExactValue(n.End)
+	if n.InclusiveEnd {
+		rng += "]"
+	} else {
+		rng += "}"
+	}
+	return fieldPrefix(n.Field) + rng
+}
+
+// renderFieldValue writes the value of a field query, where the parser reads reserved
+// words right after the colon as terms
+func renderFieldValue(value ValueNode) string {
+	if term, ok := value.(*TermValue); ok {
+		switch term.Term {
+		case AND.String(), OR.String(), NOT.String(), TO.String():
+			return term.Term
+		}
+	}
+	return renderExactValue(value)
+}
+
+// renderExactValue writes a field value so that it parses back to a value of its kind
+func renderExactValue(value ValueNode) string {
+	switch v := value.(type) {
+	case *TermValue:
+		if v.Term == "*" {
+			return "*"
+		}
+		return renderTerm(v.Term, true, false)
+	case *PhraseValue:
+		return quote(v.Phrase)
+	case *WildcardValue:
+		return v.Pattern
+	case *RegexValue:
+		return "/" + escapeSlashes(v.Pattern) + "/" + v.Flags
+	case *NumberValue:
+		return v.Number
+	case *placeholder:
+		return v.String()
+	case nil:
+		return ""
+	default:
+		return fmt.Sprintf("%v", value.Value())
+	}
+}
+
+// renderTerm writes a term as it is when the lexer reads it back as one term, or as a
+// number where number says the parser takes one for a term, and quoted otherwise.
+// Values follow a colon, where a leading - is a sign.
+func renderTerm(term string, value, number bool) string {
+	l := NewLexer(term)
+	if value {
+		l.prev = COLON
+	}
+	tok := l.NextToken()
+	plain := tok.Type == STRING || (number && tok.Type == NUMBER)
+	if plain && tok.Literal == term && l.NextToken().Type == EOF {
+		return term
+	}
+	return quote(term)
+}
+
+// quote writes a phrase, escaping the characters the lexer reads escaped in phrases
+func quote(phrase string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(phrase) + `"`
+}
+
+// escapeSlashes escapes the slashes of a regex pattern that are not escaped already,
+// which would end the pattern
+func escapeSlashes(pattern string) string {
+	var b strings.Builder
+	escaped := false
+	for _, ch := range pattern {
+		if ch == '/' && !escaped {
+			b.WriteRune('\\')
+		}
+		escaped = ch == '\\' && !escaped
+		b.WriteRune(ch)
+	}
+	return b.String()
+}
+
+// Fingerprint returns a hash of the AST as it is, positions aside. Unlike Hash, it tells
+// apart queries that normalize alike but translate differently, such as "a -b" and
+// "a OR -b", or "a AND b" and "b AND a", whose parameters are numbered in another order.
+func Fingerprint(node Node) string {
+	var b strings.Builder
+	writeStructure(&b, reflect.ValueOf(node))
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// positionType is left out of fingerprints
+var positionType = reflect.TypeOf(Position{})
+
+// writeStructure writes the types and values of an AST, leaving out positions
+func writeStructure(b *strings.Builder, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Invalid:
+		b.WriteString("nil")
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			b.WriteString("nil")
+			return
+		}
+		writeStructure(b, v.Elem())
+	case reflect.Struct:
+		b.WriteString(v.Type().String())
+		b.WriteByte('{')
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).Type == positionType {
+				continue
+			}
+			writeStructure(b, v.Field(i))
+			b.WriteByte(',')
+		}
+		b.WriteByte('}')
+	case reflect.Slice:
+		b.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			writeStructure(b, v.Index(i))
+			b.WriteByte(',')
+		}
+		b.WriteByte(']')
+	case reflect.String:
+		b.WriteString(strconv.Quote(v.String()))
+	default:
+		fmt.Fprintf(b, "%s(%v)", v.Type(), v)
+	}
+}
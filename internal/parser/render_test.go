@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender_RoundTrips(t *testing.T) {
+	queries := []string{
+		"a -b",
+		"a OR -b",
+		"foo NOT bar",
+		"price:>10 AND name:foo",
+		"a b AND c",
+		"a AND b OR c",
+		"(a OR b) AND c",
+		"a AND (b AND c)",
+		"NOT (a OR b)",
+		"- -a",
+		"+a^2 -b",
+		"(a OR b)^1.5",
+		"name:foo-bar",
+		"created:[now-1d TO *]",
+		"price:{10 TO *}",
+		"price:<=10",
+		"price:[* TO 10]",
+		"temperature:[-10 TO 40]",
+		`name:/a\/b/i`,
+		`name:"say \"hi\" \\ bye"`,
+		"name:jo* OR jo?n",
+		"tags:(x y OR z)",
+		"region:(us, ca)",
+		`region:in("new york" ca 5)`,
+		"name:foo~1 brwn~2",
+		`title:"quick fox"~3`,
+		"_exists_:email",
+		"42 name:foo^3",
+		"name:AND OR name:TO",
+	}
+
+	for _, query := range queries {
+		t.Run(query, func(t *testing.T) {
+			ast := parse(t, query)
+			rendered := Render(ast)
+			assert.Equal(t, Fingerprint(ast), Fingerprint(parse(t, rendered)), "rendered as %s", rendered)
+		})
+	}
+}
+
+func TestRender_HandBuiltTrees(t *testing.T) {
+	// Operands that bind more loosely than their operator are grouped
+	ast := &BinaryOp{Op: "AND", Left: &BinaryOp{Op: "OR", Left: &TermQuery{Term: "a"}, Right: &TermQuery{Term: "b"}}, Right: &TermQuery{Term: "c"}}
+	assert.Equal(t, "(a OR b) AND c", Render(ast))
+
+	// Terms the lexer would split are quoted
+	assert.Equal(t, `created:"2024-01-01T00:00:00Z"`, Render(&FieldQuery{Field: "created", Value: &TermValue{Term: "2024-01-01T00:00:00Z"}}))
+	assert.Equal(t, `"AND"`, Render(&TermQuery{Term: "AND"}))
+}
+
+func TestFingerprint(t *testing.T) {
+	same := [][]string{
+		{"a AND b", "a && b", "a  AND\tb"},
+		{"NOT a", "!a"},
+	}
+	for _, queries := range same {
+		for _, query := range queries[1:] {
+			assert.Equal(t, Fingerprint(parse(t, queries[0])), Fingerprint(parse(t, query)), "%s and %s", queries[0], query)
+		}
+	}
+
+	different := [][2]string{
+		{"a -b", "a OR -b"},
+		{"a AND b", "b AND a"},
+		{"a AND b", "(a AND b)"},
+		{"name:10", `name:"10"`},
+	}
+	for _, pair := range different {
+		assert.NotEqual(t, Fingerprint(parse(t, pair[0])), Fingerprint(parse(t, pair[1])), "%s and %s", pair[0], pair[1])
+	}
+}
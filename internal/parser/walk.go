@@ -0,0 +1,187 @@
+package parser
+
+// Walk traverses the AST depth-first, calling fn for every node.
+// If fn returns false, the children of that node are not visited.
+func Walk(node Node, fn func(Node) bool) {
+	if node == nil || !fn(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *BinaryOp:
+		Walk(n.Left, fn)
+		Walk(n.Right, fn)
+	case *UnaryOp:
+		Walk(n.Operand, fn)
+	case *RequiredQuery:
+		Walk(n.Query, fn)
+	case *ProhibitedQuery:
+		Walk(n.Query, fn)
+	case *BoostQuery:
+		Walk(n.Query, fn)
+	case *GroupQuery:
+		Walk(n.Query, fn)
+	case *FieldGroupQuery:
+		for _, q := range n.Queries {
+			Walk(q, fn)
+		}
+	}
+}
+
+// Depth returns the number of nodes on the longest path from the root to a leaf.
+// A nil AST has depth 0.
+func Depth(node Node) int {
+	if node == nil {
+		return 0
+	}
+
+	deepest := 0
+	switch n := node.(type) {
+	case *BinaryOp:
+		deepest = max(Depth(n.Left), Depth(n.Right))
+	case *UnaryOp:
+		deepest = Depth(n.Operand)
+	case *RequiredQuery:
+		deepest = Depth(n.Query)
+	case *ProhibitedQuery:
+		deepest = Depth(n.Query)
+	case *BoostQuery:
+		deepest = Depth(n.Query)
+	case *GroupQuery:
+		deepest = Depth(n.Query)
+	case *FieldGroupQuery:
+		for _, q := range n.Queries {
+			deepest = max(deepest, Depth(q))
+		}
+	}
+
+	return deepest + 1
+}
+
+// Count returns the number of nodes in the AST, a measure of the work translating it takes.
+// A nil AST has 0 nodes.
+func Count(node Node) int {
+	count := 0
+	Walk(node, func(Node) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// Transform returns a copy of the AST in which every node is replaced by fn(node). Nodes
+// are transformed bottom-up, so fn sees nodes whose children are already transformed.
+// The input is not modified, and fn must return new nodes rather than modify its argument.
+func Transform(node Node, fn func(Node) Node) Node {
+	switch n := node.(type) {
+	case nil:
+		return nil
+	case *BinaryOp:
+		node = &BinaryOp{Op: n.Op, Left: Transform(n.Left, fn), Right: Transform(n.Right, fn), Pos: n.Pos, Implicit: n.Implicit}
+	case *UnaryOp:
+		node = &UnaryOp{Op: n.Op, Operand: Transform(n.Operand, fn), Pos: n.Pos}
+	case *RequiredQuery:
+		node = &RequiredQuery{Query: Transform(n.Query, fn), Pos: n.Pos}
+	case *ProhibitedQuery:
+		node = &ProhibitedQuery{Query: Transform(n.Query, fn), Pos: n.Pos}
+	case *BoostQuery:
+		node = &BoostQuery{Query: Transform(n.Query, fn), Boost: n.Boost, Pos: n.Pos}
+	case *GroupQuery:
+		node = &GroupQuery{Query: Transform(n.Query, fn), Pos: n.Pos}
+	case *FieldGroupQuery:
+		queries := make([]Node, len(n.Queries))
+		for i, q := range n.Queries {
+			queries[i] = Transform(q, fn)
+		}
+		node = &FieldGroupQuery{Field: n.Field, Queries: queries, Pos: n.Pos}
+	}
+	return fn(node)
+}
+
+// MapValues returns a copy of the AST in which every field value is replaced by the
+// result of fn, called with the field the value is compared against. Terms inside
+// field:(...) groups are passed as TermValues and stay terms, whatever fn returns;
+// open range bounds (*) are not passed to fn.
+// The input is not modified; the first error returned by fn aborts the mapping.
+func MapValues(node Node, fn func(field string, value ValueNode) (ValueNode, error)) (Node, error) {
+	var err error
+	mapValue := func(field string, value ValueNode) ValueNode {
+		if err != nil || value == nil || value.Value() == "*" {
+			return value
+		}
+		var mapped ValueNode
+		if mapped, err = fn(field, value); err != nil {
+			return value
+		}
+		return mapped
+	}
+
+	var mapNode func(node Node) Node
+	mapNode = func(node Node) Node {
+		switch n := node.(type) {
+		case *BinaryOp:
+			return &BinaryOp{Op: n.Op, Left: mapNode(n.Left), Right: mapNode(n.Right), Pos: n.Pos, Implicit: n.Implicit}
+		case *UnaryOp:
+			return &UnaryOp{Op: n.Op, Operand: mapNode(n.Operand), Pos: n.Pos}
+		case *RequiredQuery:
+			return &RequiredQuery{Query: mapNode(n.Query), Pos: n.Pos}
+		case *ProhibitedQuery:
+			return &ProhibitedQuery{Query: mapNode(n.Query), Pos: n.Pos}
+		case *BoostQuery:
+			return &BoostQuery{Query: mapNode(n.Query), Boost: n.Boost, Pos: n.Pos}
+		case *GroupQuery:
+			return &GroupQuery{Query: mapNode(n.Query), Pos: n.Pos}
+		case *FieldQuery:
+			return &FieldQuery{Field: n.Field, Value: mapValue(n.Field, n.Value), Pos: n.Pos}
+		case *RangeQuery:
+			mapped := *n
+			mapped.Start = mapValue(n.Field, n.Start)
+			mapped.End = mapValue(n.Field, n.End)
+			return &mapped
+		case *FieldGroupQuery:
+			queries := make([]Node, len(n.Queries))
+			for i, q := range n.Queries {
+				queries[i] = mapGroupMember(n.Field, q, mapNode, mapValue)
+			}
+			return &FieldGroupQuery{Field: n.Field, Queries: queries, Pos: n.Pos}
+		case *InListQuery:
+			values := make([]ValueNode, len(n.Values))
+			for i, value := range n.Values {
+				values[i] = mapValue(n.Field, value)
+			}
+			return &InListQuery{Field: n.Field, Values: values, Pos: n.Pos}
+		}
+		return node
+	}
+
+	mapped := mapNode(node)
+	if err != nil {
+		return nil, err
+	}
+	return mapped, nil
+}
+
+// mapGroupMember maps the values of a member of a field:(...) group
+func mapGroupMember(field string, node Node, mapNode func(Node) Node, mapValue func(string, ValueNode) ValueNode) Node {
+	switch n := node.(type) {
+	case *TermQuery:
+		switch mapped := mapValue(field, &TermValue{Term: n.Term, Pos: n.Pos}).(type) {
+		case *NumberValue:
+			return &TermQuery{Term: mapped.Number, Pos: n.Pos}
+		default:
+			if term, ok := mapped.Value().(string); ok {
+				return &TermQuery{Term: term, Pos: n.Pos}
+			}
+		}
+		return n
+	case *BinaryOp:
+		return &BinaryOp{
+			Op:       n.Op,
+			Left:     mapGroupMember(field, n.Left, mapNode, mapValue),
+			Right:    mapGroupMember(field, n.Right, mapNode, mapValue),
+			Pos:      n.Pos,
+			Implicit: n.Implicit,
+		}
+	}
+	return mapNode(node)
+}
@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalk_VisitsAllNodes(t *testing.T) {
+	ast, err := NewParser(`-draft AND (name:foo OR name:bar) AND tags:(a OR b)^2 AND NOT _exists_:deleted`).Parse()
+	require.NoError(t, err)
+
+	var types []string
+	Walk(ast, func(n Node) bool {
+		types = append(types, n.Type())
+		return true
+	})
+
+	assert.Contains(t, types, "FieldQuery")
+	assert.Contains(t, types, "ProhibitedQuery")
+	assert.Contains(t, types, "FieldGroupQuery")
+	assert.Contains(t, types, "BoostQuery")
+	assert.Contains(t, types, "ExistsQuery")
+	assert.Contains(t, types, "TermQuery")
+}
+
+func TestWalk_SkipsChildren(t *testing.T) {
+	ast, err := NewParser(`name:foo AND (status:open OR status:closed)`).Parse()
+	require.NoError(t, err)
+
+	count := 0
+	Walk(ast, func(n Node) bool {
+		count++
+		_, isGroup := n.(*GroupQuery)
+		return !isGroup
+	})
+
+	// BinaryOp, FieldQuery and the GroupQuery itself
+	assert.Equal(t, 3, count)
+}
+
+func TestWalk_NilNode(t *testing.T) {
+	called := false
+	Walk(nil, func(Node) bool {
+		called = true
+		return true
+	})
+	assert.False(t, called)
+}
+
+func TestDepth(t *testing.T) {
+	tests := []struct {
+		query string
+		depth int
+	}{
+		{`name:foo`, 1},
+		{`name:foo AND bar`, 2},
+		{`a AND (b OR c)`, 4},
+		{`tags:(a OR b)`, 3},
+		{`NOT (a AND b)^2`, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ast, err := NewParser(tt.query).Parse()
+			require.NoError(t, err)
+			assert.Equal(t, tt.depth, Depth(ast))
+		})
+	}
+
+	assert.Equal(t, 0, Depth(nil))
+}
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		query string
+		count int
+	}{
+		{`name:foo`, 1},
+		{`name:foo AND bar`, 3},
+		{`a AND (b OR c)`, 6},
+		{`tags:(a OR b)`, 4},
+		{`region:(us, ca, mx)`, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ast, err := NewParser(tt.query).Parse()
+			require.NoError(t, err)
+			assert.Equal(t, tt.count, Count(ast))
+		})
+	}
+
+	assert.Equal(t, 0, Count(nil))
+}
+
+func TestMapValues(t *testing.T) {
+	ast, err := NewParser(`size:>10 AND NOT (size:[1 TO 5] OR tags:(a OR b)) AND name:"x"`).Parse()
+	require.NoError(t, err)
+	before := render(ast)
+
+	var fields []string
+	mapped, err := MapValues(ast, func(field string, value ValueNode) (ValueNode, error) {
+		fields = append(fields, field)
+		switch v := value.(type) {
+		case *NumberValue:
+			return &NumberValue{Number: v.Number + "0"}, nil
+		case *TermValue:
+			return &TermValue{Term: v.Term + "x"}, nil
+		}
+		return value, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"size", "size", "size", "tags", "tags", "name"}, fields)
+	assert.Equal(t, `size:>100 AND NOT (size:[10 TO 50] OR tags:(ax OR bx)) AND name:"x"`, render(mapped))
+	assert.Equal(t, before, render(ast), "input is not modified")
+
+	_, err = MapValues(ast, func(string, ValueNode) (ValueNode, error) {
+		return nil, assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestTransform(t *testing.T) {
+	ast, err := NewParser(`a AND NOT (b OR tags:(c OR d))`).Parse()
+	require.NoError(t, err)
+	before := render(ast)
+
+	transformed := Transform(ast, func(node Node) Node {
+		if term, ok := node.(*TermQuery); ok {
+			return &TermQuery{Term: strings.ToUpper(term.Term), Pos: term.Pos}
+		}
+		return node
+	})
+	assert.Equal(t, `A AND NOT (B OR tags:(C OR D))`, render(transformed))
+	assert.Equal(t, before, render(ast), "input is not modified")
+	assert.Nil(t, Transform(nil, func(node Node) Node { return node }))
+}
@@ -0,0 +1,230 @@
+// Package patterns is a curated library of parameterized query patterns, the idioms
+// teams should reach for when writing common filters, such as the recent days of a date
+// field or a numeric range. Patterns render to query syntax from parameter values that
+// are validated first, so a rendered query cannot be altered by the values it was given.
+package patterns
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+)
+
+// Kinds of parameter values
+const (
+	KindField   = "field"   // a field name
+	KindInteger = "integer" // a positive whole number
+	KindNumber  = "number"  // an integer or decimal number, possibly negative
+	KindValues  = "values"  // a comma-separated list of values
+)
+
+// FieldParam is the name of the parameter every pattern takes for the field it filters
+const FieldParam = "field"
+
+// maxValues bounds the values of a values parameter
+const maxValues = 100
+
+var (
+	fieldName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+	number    = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+	// word is a value wildcard patterns can hold as written. It leaves out _, which LIKE
+	// reads as a wildcard.
+	word = regexp.MustCompile(`^[A-Za-z0-9]+([.-][A-Za-z0-9]+)*$`)
+)
+
+// Param is a parameter of a pattern
+type Param struct {
+	Name        string
+	Kind        string
+	Description string
+	Example     string
+}
+
+// Pattern is a parameterized query. Template shows the query with each parameter as
+// {name}; a values parameter stands for its whole list.
+type Pattern struct {
+	Name        string
+	Description string
+	Template    string
+	Params      []Param
+	FieldTypes  []schema.FieldType // types of the fields the pattern applies to, preferred first
+	render      func(args map[string]string) (string, error)
+}
+
+// catalog holds the patterns by name
+var catalog = map[string]Pattern{
+	"recent_days": {
+		Name:        "recent_days",
+		Description: "Dates within the last N calendar days, today included",
+		Template:    "{field}:last_{days}_days",
+		Params: []Param{
+			{Name: FieldParam, Kind: KindField, Description: "Date or datetime field", Example: "createdAt"},
+			{Name: "days", Kind: KindInteger, Description: fmt.Sprintf("Number of days, at most %d", translator.MaxRecentDays), Example: "7"},
+		},
+		FieldTypes: []schema.FieldType{schema.TypeDateTime, schema.TypeDate},
+		render: func(args map[string]string) (string, error) {
+			days, err := strconv.Atoi(args["days"])
+			if err != nil || days < 1 || days > translator.MaxRecentDays {
+				return "", fmt.Errorf("days must be a whole number from 1 to %d", translator.MaxRecentDays)
+			}
+			return fmt.Sprintf("%s:last_%d_days", args[FieldParam], days), nil
+		},
+	},
+	"numeric_between": {
+		Name:        "numeric_between",
+		Description: "Numbers from min to max, both included",
+		Template:    "{field}:[{min} TO {max}]",
+		Params: []Param{
+			{Name: FieldParam, Kind: KindField, Description: "Integer or float field", Example: "price"},
+			{Name: "min", Kind: KindNumber, Description: "Lower bound", Example: "10"},
+			{Name: "max", Kind: KindNumber, Description: "Upper bound", Example: "99.99"},
+		},
+		FieldTypes: []schema.FieldType{schema.TypeFloat, schema.TypeInteger},
+		render: func(args map[string]string) (string, error) {
+			min, max := args["min"], args["max"]
+			if !number.MatchString(min) || !number.MatchString(max) {
+				return "", fmt.Errorf("min and max must be numbers")
+			}
+			low, _ := strconv.ParseFloat(min, 64)
+			high, _ := strconv.ParseFloat(max, 64)
+			if low > high {
+				return "", fmt.Errorf("min must not be greater than max")
+			}
+			return fmt.Sprintf("%s:[%s TO %s]", args[FieldParam], min, max), nil
+		},
+	},
+	"contains_any": {
+		Name:        "contains_any",
+		Description: "Text containing any of the values",
+		Template:    "{field}:(*{values}* OR ...)",
+		Params: []Param{
+			{Name: FieldParam, Kind: KindField, Description: "Text field", Example: "name"},
+			{Name: "values", Kind: KindValues, Description: "Values of letters and digits, possibly joined by . or -", Example: "usb-c,hdmi"},
+		},
+		FieldTypes: []schema.FieldType{schema.TypeText},
+		render: func(args map[string]string) (string, error) {
+			values, err := splitValues(args["values"])
+			if err != nil {
+				return "", err
+			}
+			patterns := make([]string, len(values))
+			for i, value := range values {
+				if !word.MatchString(value) {
+					return "", fmt.Errorf("value %q may only hold letters and digits, joined by . or -", value)
+				}
+				patterns[i] = "*" + value + "*"
+			}
+			return fmt.Sprintf("%s:(%s)", args[FieldParam], strings.Join(patterns, " OR ")), nil
+		},
+	},
+	"one_of": {
+		Name:        "one_of",
+		Description: "Values equal to any of a list",
+		Template:    "{field}:({values})",
+		Params: []Param{
+			{Name: FieldParam, Kind: KindField, Description: "Field", Example: "status"},
+			{Name: "values", Kind: KindValues, Description: "Values to match", Example: "shipped,delivered"},
+		},
+		FieldTypes: []schema.FieldType{schema.TypeText, schema.TypeInteger, schema.TypeFloat},
+		render: func(args map[string]string) (string, error) {
+			values, err := splitValues(args["values"])
+			if err != nil {
+				return "", err
+			}
+			rendered := make([]string, len(values))
+			for i, value := range values {
+				rendered[i] = quoteValue(value)
+			}
+			return fmt.Sprintf("%s:(%s)", args[FieldParam], strings.Join(rendered, ", ")), nil
+		},
+	},
+}
+
+// All returns the patterns sorted by name
+func All() []Pattern {
+	patterns := make([]Pattern, 0, len(catalog))
+	for _, pattern := range catalog {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		return patterns[i].Name < patterns[j].Name
+	})
+	return patterns
+}
+
+// Get returns the pattern with a name
+func Get(name string) (Pattern, bool) {
+	pattern, ok := catalog[name]
+	return pattern, ok
+}
+
+// Render returns the query of a pattern for the parameter values in args, keyed by
+// parameter name. Every parameter is required.
+func (p Pattern) Render(args map[string]string) (string, error) {
+	for _, param := range p.Params {
+		if strings.TrimSpace(args[param.Name]) == "" {
+			return "", fmt.Errorf("parameter %s is required", param.Name)
+		}
+	}
+	if field := args[FieldParam]; !fieldName.MatchString(field) {
+		return "", fmt.Errorf("invalid field name %q", field)
+	}
+	return p.render(args)
+}
+
+// ExampleArgs returns the example value of every parameter
+func (p Pattern) ExampleArgs() map[string]string {
+	args := make(map[string]string, len(p.Params))
+	for _, param := range p.Params {
+		args[param.Name] = param.Example
+	}
+	return args
+}
+
+// AppliesTo reports whether the pattern applies to fields of a type
+func (p Pattern) AppliesTo(fieldType schema.FieldType) bool {
+	for _, t := range p.FieldTypes {
+		if t == fieldType {
+			return true
+		}
+	}
+	return false
+}
+
+// splitValues splits a comma-separated list, trimming spaces around values
+func splitValues(list string) ([]string, error) {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("values must list at least one value")
+	}
+	if len(values) > maxValues {
+		return nil, fmt.Errorf("values must list at most %d values", maxValues)
+	}
+	return values, nil
+}
+
+// quoteValue writes a value as a term when it is a plain word or number, and as a phrase
+// otherwise, so that operators and special characters in it are matched literally
+func quoteValue(value string) string {
+	if number.MatchString(value) {
+		return value
+	}
+	switch strings.ToUpper(value) {
+	case "AND", "OR", "NOT", "TO":
+		return strconv.Quote(value)
+	}
+	if word.MatchString(value) {
+		return value
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
@@ -0,0 +1,95 @@
+package patterns
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		pattern string
+		args    map[string]string
+		want    string
+	}{
+		{"recent_days", map[string]string{"field": "createdAt", "days": "30"}, "createdAt:last_30_days"},
+		{"numeric_between", map[string]string{"field": "price", "min": "-5", "max": "99.99"}, "price:[-5 TO 99.99]"},
+		{"contains_any", map[string]string{"field": "name", "values": "usb-c, hdmi,,v2.1"}, "name:(*usb-c* OR *hdmi* OR *v2.1*)"},
+		{"one_of", map[string]string{"field": "status", "values": `shipped,OR,new york,say "hi",42`},
+			`status:(shipped, "OR", "new york", "say \"hi\"", 42)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			pattern, ok := Get(tt.pattern)
+			if !ok {
+				t.Fatalf("pattern %s not found", tt.pattern)
+			}
+			query, err := pattern.Render(tt.args)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if query != tt.want {
+				t.Errorf("Render() = %q, want %q", query, tt.want)
+			}
+			if _, err := parser.NewParser(query).Parse(); err != nil {
+				t.Errorf("rendered query %q does not parse: %v", query, err)
+			}
+		})
+	}
+}
+
+func TestRender_Invalid(t *testing.T) {
+	tests := []struct {
+		pattern string
+		args    map[string]string
+	}{
+		{"recent_days", map[string]string{"field": "createdAt"}},
+		{"recent_days", map[string]string{"field": "createdAt", "days": "0"}},
+		{"recent_days", map[string]string{"field": "createdAt", "days": "7 OR x"}},
+		{"recent_days", map[string]string{"field": "created At", "days": "7"}},
+		{"recent_days", map[string]string{"field": "a:b", "days": "7"}},
+		{"numeric_between", map[string]string{"field": "price", "min": "1e3", "max": "5"}},
+		{"numeric_between", map[string]string{"field": "price", "min": "10", "max": "5"}},
+		{"numeric_between", map[string]string{"field": "price", "min": "1", "max": "5] OR secret:[*"}},
+		{"contains_any", map[string]string{"field": "name", "values": "a b"}},
+		{"contains_any", map[string]string{"field": "name", "values": "100%"}},
+		{"contains_any", map[string]string{"field": "name", "values": "snake_case"}},
+		{"contains_any", map[string]string{"field": "name", "values": " , "}},
+		{"one_of", map[string]string{"field": "status"}},
+	}
+
+	for _, tt := range tests {
+		pattern, _ := Get(tt.pattern)
+		if query, err := pattern.Render(tt.args); err == nil {
+			t.Errorf("%s.Render(%v) = %q, want an error", tt.pattern, tt.args, query)
+		}
+	}
+}
+
+func TestAll(t *testing.T) {
+	all := All()
+	if len(all) != len(catalog) {
+		t.Fatalf("All() returned %d patterns, want %d", len(all), len(catalog))
+	}
+	for i, pattern := range all {
+		if i > 0 && all[i-1].Name >= pattern.Name {
+			t.Errorf("patterns not sorted: %s before %s", all[i-1].Name, pattern.Name)
+		}
+		if len(pattern.FieldTypes) == 0 {
+			t.Errorf("pattern %s applies to no field type", pattern.Name)
+		}
+		if pattern.Params[0].Name != FieldParam {
+			t.Errorf("pattern %s does not take the field first", pattern.Name)
+		}
+		if _, err := pattern.Render(pattern.ExampleArgs()); err != nil {
+			t.Errorf("pattern %s does not render its examples: %v", pattern.Name, err)
+		}
+	}
+
+	numeric, _ := Get("numeric_between")
+	if !numeric.AppliesTo(schema.TypeInteger) || numeric.AppliesTo(schema.TypeText) {
+		t.Error("numeric_between should apply to integer fields only among integer and text")
+	}
+}
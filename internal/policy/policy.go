@@ -0,0 +1,177 @@
+// Package policy enforces deployment-wide restrictions on query capabilities.
+// Schema feature flags describe what a table supports; the policy describes what
+// the operator of a deployment allows, and is checked before any translation.
+// Requests can restrict the policy further, but never relax it.
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/infiniv/rsearch/internal/parser"
+)
+
+// Feature names reported in errors
+const (
+	FeatureRegex           = "regex"
+	FeatureFuzzy           = "fuzzy"
+	FeatureProximity       = "proximity"
+	FeatureLeadingWildcard = "leading wildcard"
+	FeatureExists          = "_exists_"
+)
+
+// RequestFeatures lists the names requests disable features by, see Restrict
+var RequestFeatures = []string{"regex", "fuzzy", "proximity", "leadingWildcard", "exists"}
+
+// Features lists the query capabilities a deployment allows
+type Features struct {
+	Regex           bool
+	Fuzzy           bool
+	Proximity       bool
+	LeadingWildcard bool
+	Exists          bool
+}
+
+// AllFeatures returns a feature set with every capability enabled
+func AllFeatures() Features {
+	return Features{
+		Regex:           true,
+		Fuzzy:           true,
+		Proximity:       true,
+		LeadingWildcard: true,
+		Exists:          true,
+	}
+}
+
+// FeatureDisabledError is returned when a query uses a capability the deployment, or the
+// request, disallows
+type FeatureDisabledError struct {
+	Feature string
+	Pos     parser.Position
+	Request bool // disabled by the request rather than the deployment
+}
+
+func (e *FeatureDisabledError) Error() string {
+	return fmt.Sprintf("%s queries are %s (at line %d, column %d)", e.Feature, e.Reason(), e.Pos.Line, e.Pos.Column)
+}
+
+// Reason describes who disabled the feature
+func (e *FeatureDisabledError) Reason() string {
+	if e.Request {
+		return "disabled for this request"
+	}
+	return "disabled on this deployment"
+}
+
+// Policy checks parsed queries against the allowed features
+type Policy struct {
+	features   Features
+	restricted map[string]bool // features the deployment allows but a request disabled
+}
+
+// New creates a policy allowing the given features
+func New(features Features) *Policy {
+	return &Policy{features: features}
+}
+
+// Features returns the features allowed by the policy
+func (p *Policy) Features() Features {
+	return p.features
+}
+
+// Restrict returns a copy of the policy that also disallows the named features, one of
+// RequestFeatures each, so a request can tighten what the deployment allows, e.g. turn
+// off fuzzy queries for input from untrusted widgets. Features the deployment already
+// disallows stay disallowed; there is no way to allow them.
+func (p *Policy) Restrict(names []string) (*Policy, error) {
+	restricted := &Policy{features: p.features, restricted: make(map[string]bool, len(names))}
+	for feature := range p.restricted {
+		restricted.restricted[feature] = true
+	}
+
+	for _, name := range names {
+		var allowed *bool
+		var feature string
+		switch name {
+		case "regex":
+			allowed, feature = &restricted.features.Regex, FeatureRegex
+		case "fuzzy":
+			allowed, feature = &restricted.features.Fuzzy, FeatureFuzzy
+		case "proximity":
+			allowed, feature = &restricted.features.Proximity, FeatureProximity
+		case "leadingWildcard":
+			allowed, feature = &restricted.features.LeadingWildcard, FeatureLeadingWildcard
+		case "exists":
+			allowed, feature = &restricted.features.Exists, FeatureExists
+		default:
+			return nil, fmt.Errorf("unknown feature %q: must be one of: %s", name, strings.Join(RequestFeatures, ", "))
+		}
+		if *allowed {
+			*allowed = false
+			restricted.restricted[feature] = true
+		}
+	}
+	return restricted, nil
+}
+
+// Check returns a *FeatureDisabledError for the first disallowed capability in the AST
+func (p *Policy) Check(ast parser.Node) error {
+	var violation error
+
+	parser.Walk(ast, func(node parser.Node) bool {
+		if violation != nil {
+			return false
+		}
+		if feature := p.disallowed(node); feature != "" {
+			violation = &FeatureDisabledError{Feature: feature, Pos: node.Position(), Request: p.restricted[feature]}
+			return false
+		}
+		return true
+	})
+
+	return violation
+}
+
+// disallowed returns the name of the disallowed feature used by the node, if any
+func (p *Policy) disallowed(node parser.Node) string {
+	switch n := node.(type) {
+	case *parser.FieldQuery:
+		switch v := n.Value.(type) {
+		case *parser.RegexValue:
+			if !p.features.Regex {
+				return FeatureRegex
+			}
+		case *parser.WildcardValue:
+			// field:* translates to an existence check
+			if !p.features.Exists && v.Pattern == "*" {
+				return FeatureExists
+			}
+			if !p.features.LeadingWildcard && isLeadingWildcard(v.Pattern) {
+				return FeatureLeadingWildcard
+			}
+		}
+	case *parser.WildcardQuery:
+		if !p.features.LeadingWildcard && isLeadingWildcard(n.Pattern) {
+			return FeatureLeadingWildcard
+		}
+	case *parser.FuzzyQuery:
+		if !p.features.Fuzzy {
+			return FeatureFuzzy
+		}
+	case *parser.ProximityQuery:
+		if !p.features.Proximity {
+			return FeatureProximity
+		}
+	case *parser.ExistsQuery:
+		if !p.features.Exists {
+			return FeatureExists
+		}
+	}
+	return ""
+}
+
+// isLeadingWildcard reports whether a pattern starts with a wildcard.
+// A lone "*" matches everything and does not need a scan of every value, so it is allowed.
+func isLeadingWildcard(pattern string) bool {
+	return len(pattern) > 1 && (pattern[0] == '*' || pattern[0] == '?')
+}
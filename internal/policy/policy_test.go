@@ -0,0 +1,120 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parse(t *testing.T, query string) parser.Node {
+	t.Helper()
+	ast, err := parser.NewParser(query).Parse()
+	require.NoError(t, err)
+	return ast
+}
+
+func TestPolicy_AllFeaturesAllowEverything(t *testing.T) {
+	p := New(AllFeatures())
+
+	queries := []string{
+		`name:/jo.*/`,
+		`name:john~2`,
+		`"quick fox"~3`,
+		`name:*son`,
+		`*son`,
+		`_exists_:email`,
+	}
+	for _, q := range queries {
+		assert.NoError(t, p.Check(parse(t, q)), q)
+	}
+}
+
+func TestPolicy_DisabledFeatures(t *testing.T) {
+	tests := []struct {
+		name     string
+		features func(*Features)
+		query    string
+		feature  string
+	}{
+		{"regex", func(f *Features) { f.Regex = false }, `name:/jo.*/`, FeatureRegex},
+		{"fuzzy", func(f *Features) { f.Fuzzy = false }, `name:john~2`, FeatureFuzzy},
+		{"proximity", func(f *Features) { f.Proximity = false }, `"quick fox"~3`, FeatureProximity},
+		{"leading wildcard value", func(f *Features) { f.LeadingWildcard = false }, `name:*son`, FeatureLeadingWildcard},
+		{"leading wildcard term", func(f *Features) { f.LeadingWildcard = false }, `?ohn`, FeatureLeadingWildcard},
+		{"exists", func(f *Features) { f.Exists = false }, `_exists_:email`, FeatureExists},
+		{"nested", func(f *Features) { f.Regex = false }, `status:active AND (tags:a OR NOT name:/x/)`, FeatureRegex},
+		{"inside field group", func(f *Features) { f.LeadingWildcard = false }, `name:(john OR *son)`, FeatureLeadingWildcard},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			features := AllFeatures()
+			tt.features(&features)
+
+			err := New(features).Check(parse(t, tt.query))
+			require.Error(t, err)
+
+			var disabled *FeatureDisabledError
+			require.ErrorAs(t, err, &disabled)
+			assert.Equal(t, tt.feature, disabled.Feature)
+			assert.Contains(t, err.Error(), "disabled on this deployment")
+		})
+	}
+}
+
+func TestPolicy_Restrict(t *testing.T) {
+	features := AllFeatures()
+	features.Regex = false
+	deployment := New(features)
+
+	p, err := deployment.Restrict([]string{"fuzzy", "regex", "leadingWildcard"})
+	require.NoError(t, err)
+
+	var disabled *FeatureDisabledError
+	require.ErrorAs(t, p.Check(parse(t, `name:john~2`)), &disabled)
+	assert.Equal(t, FeatureFuzzy, disabled.Feature)
+	assert.True(t, disabled.Request)
+	assert.Contains(t, disabled.Error(), "fuzzy queries are disabled for this request")
+
+	// The deployment disabled regexes before the request did
+	require.ErrorAs(t, p.Check(parse(t, `name:/jo.*/`)), &disabled)
+	assert.False(t, disabled.Request)
+
+	require.ErrorAs(t, p.Check(parse(t, `name:*son`)), &disabled)
+	assert.Equal(t, FeatureLeadingWildcard, disabled.Feature)
+	assert.NoError(t, p.Check(parse(t, `"quick fox"~3 AND _exists_:email`)))
+
+	// The deployment's policy is unchanged
+	assert.NoError(t, deployment.Check(parse(t, `name:john~2`)))
+
+	_, err = deployment.Restrict([]string{"wildcards"})
+	assert.EqualError(t, err, `unknown feature "wildcards": must be one of: regex, fuzzy, proximity, leadingWildcard, exists`)
+}
+
+func TestPolicy_TrailingWildcardAllowed(t *testing.T) {
+	features := AllFeatures()
+	features.LeadingWildcard = false
+	p := New(features)
+
+	assert.NoError(t, p.Check(parse(t, `name:jo*`)))
+	assert.NoError(t, p.Check(parse(t, `name:j?hn`)))
+	assert.NoError(t, p.Check(parse(t, `name:*`)))
+}
+
+func TestPolicy_WildcardOnlyIsExists(t *testing.T) {
+	features := AllFeatures()
+	features.Exists = false
+	p := New(features)
+
+	var disabled *FeatureDisabledError
+	require.ErrorAs(t, p.Check(parse(t, `name:*`)), &disabled)
+	assert.Equal(t, FeatureExists, disabled.Feature)
+	assert.NoError(t, p.Check(parse(t, `name:jo*`)))
+}
+
+func TestPolicy_NilAST(t *testing.T) {
+	p := New(Features{})
+	assert.NoError(t, p.Check(nil))
+}
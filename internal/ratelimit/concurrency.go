@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// Errors returned by ConcurrencyLimiter.Acquire when a request is shed
+var (
+	ErrQueueFull    = errors.New("too many requests waiting")
+	ErrQueueTimeout = errors.New("timed out waiting for a free slot")
+)
+
+// ConcurrencyLimiter bounds the number of requests processed at once. Requests over the
+// limit wait in a bounded queue for a free slot, and are shed when the queue is full or
+// they have waited too long, so overload turns into fast rejections instead of every
+// request slowing down.
+type ConcurrencyLimiter struct {
+	slots        chan struct{}
+	maxQueue     int64
+	queueTimeout time.Duration
+
+	queued atomic.Int64
+	shed   atomic.Uint64
+}
+
+// ConcurrencyStats is a snapshot of a concurrency limiter
+type ConcurrencyStats struct {
+	InFlight int    // requests holding a slot
+	Queued   int    // requests waiting for a slot
+	Shed     uint64 // requests rejected since start
+}
+
+// NewConcurrencyLimiter creates a limiter of maxInFlight concurrent requests, with up to
+// maxQueue more waiting at most queueTimeout each. Without a timeout requests wait as
+// long as their context allows.
+func NewConcurrencyLimiter(maxInFlight, maxQueue int, queueTimeout time.Duration) *ConcurrencyLimiter {
+	if maxInFlight < 1 {
+		maxInFlight = 1
+	}
+	if maxQueue < 0 {
+		maxQueue = 0
+	}
+	return &ConcurrencyLimiter{
+		slots:        make(chan struct{}, maxInFlight),
+		maxQueue:     int64(maxQueue),
+		queueTimeout: queueTimeout,
+	}
+}
+
+// Acquire takes a slot, waiting in the queue if none is free, and returns the function
+// releasing it. It fails with ErrQueueFull, ErrQueueTimeout or the context's error.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) (func(), error) {
+	release := func() { <-l.slots }
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	if l.queued.Add(1) > l.maxQueue {
+		l.queued.Add(-1)
+		l.shed.Add(1)
+		return nil, ErrQueueFull
+	}
+	defer l.queued.Add(-1)
+
+	var timeout <-chan time.Time
+	if l.queueTimeout > 0 {
+		timer := time.NewTimer(l.queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	case <-timeout:
+		l.shed.Add(1)
+		return nil, ErrQueueTimeout
+	case <-ctx.Done():
+		l.shed.Add(1)
+		return nil, ctx.Err()
+	}
+}
+
+// Stats returns the limiter's current occupancy
+func (l *ConcurrencyLimiter) Stats() ConcurrencyStats {
+	return ConcurrencyStats{
+		InFlight: len(l.slots),
+		Queued:   int(l.queued.Load()),
+		Shed:     l.shed.Load(),
+	}
+}
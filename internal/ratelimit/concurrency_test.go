@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimiter_QueuesUntilReleased(t *testing.T) {
+	limiter := NewConcurrencyLimiter(1, 1, time.Second)
+
+	release, err := limiter.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("first request: unexpected error %v", err)
+	}
+
+	acquired := make(chan error)
+	go func() {
+		releaseQueued, err := limiter.Acquire(context.Background())
+		if err == nil {
+			releaseQueued()
+		}
+		acquired <- err
+	}()
+
+	// Wait for the second request to queue
+	for limiter.Stats().Queued != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	if stats := limiter.Stats(); stats.InFlight != 1 {
+		t.Errorf("expected 1 request in flight, got %+v", stats)
+	}
+
+	// The queue is full
+	if _, err := limiter.Acquire(context.Background()); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("expected ErrQueueFull, got %v", err)
+	}
+
+	release()
+	if err := <-acquired; err != nil {
+		t.Errorf("queued request: unexpected error %v", err)
+	}
+	if stats := limiter.Stats(); stats != (ConcurrencyStats{Shed: 1}) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestConcurrencyLimiter_QueueTimeout(t *testing.T) {
+	limiter := NewConcurrencyLimiter(1, 5, 10*time.Millisecond)
+
+	release, err := limiter.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("first request: unexpected error %v", err)
+	}
+	defer release()
+
+	if _, err := limiter.Acquire(context.Background()); !errors.Is(err, ErrQueueTimeout) {
+		t.Errorf("expected ErrQueueTimeout, got %v", err)
+	}
+}
+
+func TestConcurrencyLimiter_ContextCanceled(t *testing.T) {
+	limiter := NewConcurrencyLimiter(1, 5, 0)
+
+	release, err := limiter.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("first request: unexpected error %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if queued := limiter.Stats().Queued; queued != 0 {
+		t.Errorf("expected an empty queue, got %d", queued)
+	}
+}
@@ -3,7 +3,9 @@
 package ratelimit
 
 import (
+	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,6 +26,24 @@ type RateLimiter struct {
 	staleThreshold    time.Duration
 	stopCleanup       chan struct{}
 	cleanupWg         sync.WaitGroup
+
+	clients  atomic.Int64  // buckets tracked
+	allowed  atomic.Uint64 // requests allowed since start
+	rejected atomic.Uint64 // requests rejected since start
+}
+
+// Decision is the outcome of a rate limit check
+type Decision struct {
+	Allowed    bool
+	Remaining  int           // whole requests the client can still make right away
+	RetryAfter time.Duration // until the next request would be allowed; zero when allowed
+}
+
+// Stats is a snapshot of the limiter's occupancy
+type Stats struct {
+	Clients  int    // clients with a bucket, i.e. seen within the stale threshold
+	Allowed  uint64 // requests allowed since start
+	Rejected uint64 // requests rejected since start
 }
 
 // NewRateLimiter creates a new rate limiter with specified requests per minute and burst size
@@ -51,16 +71,25 @@ func NewRateLimiterWithCleanup(requestsPerMinute int, burst int, cleanupInterval
 
 // Allow checks if a request from the given IP is allowed
 func (rl *RateLimiter) Allow(ip string) bool {
+	return rl.Take(ip).Allowed
+}
+
+// Take checks if a request from the given IP is allowed and, if so, consumes a token.
+// A rejected request reports how long until a token is available.
+func (rl *RateLimiter) Take(ip string) Decision {
 	// Load or create bucket for this IP
 	// Start with 1 token to allow the first request even with zero burst
-	initialTokens := rl.burst
-	if initialTokens < 1.0 {
-		initialTokens = 1.0
+	maxTokens := rl.burst
+	if maxTokens < 1.0 {
+		maxTokens = 1.0
 	}
-	value, _ := rl.buckets.LoadOrStore(ip, &tokenBucket{
-		tokens:         initialTokens,
+	value, loaded := rl.buckets.LoadOrStore(ip, &tokenBucket{
+		tokens:         maxTokens,
 		lastRefillTime: time.Now(),
 	})
+	if !loaded {
+		rl.clients.Add(1)
+	}
 	bucket := value.(*tokenBucket)
 
 	bucket.mu.Lock()
@@ -69,15 +98,9 @@ func (rl *RateLimiter) Allow(ip string) bool {
 	now := time.Now()
 	elapsed := now.Sub(bucket.lastRefillTime).Seconds()
 
-	// Refill tokens based on elapsed time
+	// Refill tokens based on elapsed time, capped at burst but allowing at least 1 token
 	if elapsed > 0 {
-		tokensToAdd := elapsed * rl.tokensPerSecond
-		bucket.tokens += tokensToAdd
-		// Cap at burst, but allow at least 1 token to accumulate
-		maxTokens := rl.burst
-		if maxTokens < 1.0 {
-			maxTokens = 1.0
-		}
+		bucket.tokens += elapsed * rl.tokensPerSecond
 		if bucket.tokens > maxTokens {
 			bucket.tokens = maxTokens
 		}
@@ -87,10 +110,30 @@ func (rl *RateLimiter) Allow(ip string) bool {
 	// Check if we have at least one token
 	if bucket.tokens >= 1.0 {
 		bucket.tokens -= 1.0
-		return true
+		rl.allowed.Add(1)
+		return Decision{Allowed: true, Remaining: int(bucket.tokens)}
+	}
+
+	rl.rejected.Add(1)
+	decision := Decision{RetryAfter: time.Duration(math.MaxInt64)}
+	if rl.tokensPerSecond > 0 {
+		decision.RetryAfter = time.Duration((1.0 - bucket.tokens) / rl.tokensPerSecond * float64(time.Second))
+	}
+	return decision
+}
+
+// Stats returns the limiter's current occupancy and request counts
+func (rl *RateLimiter) Stats() Stats {
+	return Stats{
+		Clients:  int(rl.clients.Load()),
+		Allowed:  rl.allowed.Load(),
+		Rejected: rl.rejected.Load(),
 	}
+}
 
-	return false
+// Limit returns the number of requests a client can make in a burst
+func (rl *RateLimiter) Limit() int {
+	return int(math.Max(rl.burst, 1))
 }
 
 // cleanupRoutine periodically removes stale bucket entries
@@ -132,7 +175,9 @@ func (rl *RateLimiter) cleanup() {
 
 	// Delete stale entries
 	for _, ip := range staleIPs {
-		rl.buckets.Delete(ip)
+		if _, deleted := rl.buckets.LoadAndDelete(ip); deleted {
+			rl.clients.Add(-1)
+		}
 	}
 }
 
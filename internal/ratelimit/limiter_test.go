@@ -265,3 +265,43 @@ func TestRateLimiter_ZeroBurst(t *testing.T) {
 		t.Error("request after refill should be allowed")
 	}
 }
+
+func TestRateLimiter_Take(t *testing.T) {
+	// 6 requests per minute refill a token every 10 seconds
+	limiter := NewRateLimiter(6, 2)
+	defer limiter.Stop()
+
+	first := limiter.Take("10.0.0.1")
+	if !first.Allowed || first.Remaining != 1 || first.RetryAfter != 0 {
+		t.Errorf("first request: got %+v, want allowed with 1 remaining", first)
+	}
+	limiter.Take("10.0.0.1")
+
+	rejected := limiter.Take("10.0.0.1")
+	if rejected.Allowed || rejected.Remaining != 0 {
+		t.Errorf("third request: got %+v, want rejected", rejected)
+	}
+	if rejected.RetryAfter < 9*time.Second || rejected.RetryAfter > 10*time.Second {
+		t.Errorf("third request: expected to retry after about 10s, got %s", rejected.RetryAfter)
+	}
+
+	limiter.Take("10.0.0.2")
+	stats := limiter.Stats()
+	if stats != (Stats{Clients: 2, Allowed: 3, Rejected: 1}) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestRateLimiter_CleanupUpdatesClients(t *testing.T) {
+	limiter := NewRateLimiterWithCleanup(60, 5, time.Hour, 0)
+	defer limiter.Stop()
+
+	limiter.Allow("10.0.0.1")
+	limiter.Allow("10.0.0.2")
+	time.Sleep(time.Millisecond)
+	limiter.cleanup()
+
+	if clients := limiter.Stats().Clients; clients != 0 {
+		t.Errorf("expected no clients after cleanup, got %d", clients)
+	}
+}
@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Quota bounds what an API key may translate per day: the number of queries and their
+// cumulative complexity, measured in AST nodes. A zero limit is unlimited.
+type Quota struct {
+	Queries    int
+	Complexity int
+}
+
+// QuotaStatus is a key's quota and its usage in the current day
+type QuotaStatus struct {
+	Quota
+	Queries    int       // queries translated today
+	Complexity int       // cumulative complexity of today's queries
+	Reset      time.Time // when usage resets, at the next midnight UTC
+}
+
+// Exceeded reports whether the key has used up either limit
+func (s QuotaStatus) Exceeded() bool {
+	return (s.Quota.Queries > 0 && s.Queries >= s.Quota.Queries) ||
+		(s.Quota.Complexity > 0 && s.Complexity >= s.Quota.Complexity)
+}
+
+// QuotaTracker counts the queries each API key translates per UTC day against its
+// quota. Quotas are soft: a query is admitted while the key is under both limits and
+// charged after translation, so the query crossing a limit completes and the next ones
+// are rejected until the day ends. Usage is kept in memory, per instance.
+//
+// Only configured keys are counted on their own. Any other key, and requests without
+// one, share a single anonymous record under the defaults, so clients cannot get around
+// their quota by changing keys and unknown keys do not grow the tracker.
+type QuotaTracker struct {
+	defaults  Quota
+	overrides map[string]Quota
+	keys      map[string]bool
+	now       func() time.Time
+
+	mu    sync.Mutex
+	day   time.Time // start of the day usage is counted for
+	usage map[string]*QuotaStatus
+}
+
+// anonymousKey is the record shared by keys that are not configured
+const anonymousKey = ""
+
+// NewQuotaTracker creates a tracker applying defaults to every key without an override.
+// Keys with an override and the keys listed are counted on their own; others share the
+// anonymous record.
+func NewQuotaTracker(defaults Quota, overrides map[string]Quota, keys []string) *QuotaTracker {
+	known := make(map[string]bool, len(overrides)+len(keys))
+	for key := range overrides {
+		known[key] = true
+	}
+	for _, key := range keys {
+		known[key] = true
+	}
+	delete(known, anonymousKey)
+	return &QuotaTracker{
+		defaults:  defaults,
+		overrides: overrides,
+		keys:      known,
+		now:       time.Now,
+		usage:     make(map[string]*QuotaStatus),
+	}
+}
+
+// Check returns the key's status without charging it
+func (t *QuotaTracker) Check(key string) QuotaStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return *t.status(key, false)
+}
+
+// Charge counts a query of the given complexity against the key and returns its new status
+func (t *QuotaTracker) Charge(key string, complexity int) QuotaStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status := t.status(key, true)
+	status.Queries++
+	status.Complexity += complexity
+	return *status
+}
+
+// status returns the key's usage record, starting a new day's records when the day has
+// ended. Keys that are not configured use the anonymous record. Records of keys without
+// usage are only kept when track is set. The caller holds the lock.
+func (t *QuotaTracker) status(key string, track bool) *QuotaStatus {
+	if !t.keys[key] {
+		key = anonymousKey
+	}
+
+	now := t.now().UTC()
+	if day := now.Truncate(24 * time.Hour); !day.Equal(t.day) {
+		t.day = day
+		clear(t.usage)
+	}
+
+	status, ok := t.usage[key]
+	if !ok {
+		quota, overridden := t.overrides[key]
+		if !overridden {
+			quota = t.defaults
+		}
+		status = &QuotaStatus{Quota: quota, Reset: t.day.Add(24 * time.Hour)}
+		if track {
+			t.usage[key] = status
+		}
+	}
+	return status
+}
@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuotaTracker_SoftLimits(t *testing.T) {
+	tracker := NewQuotaTracker(Quota{Queries: 2, Complexity: 10}, nil, []string{"tenant-a", "tenant-b"})
+
+	if status := tracker.Check("tenant-a"); status.Exceeded() || status.Queries != 0 {
+		t.Fatalf("expected an unused quota, got %+v", status)
+	}
+
+	// The query crossing the complexity limit is still charged in full
+	tracker.Charge("tenant-a", 4)
+	status := tracker.Charge("tenant-a", 8)
+	if status.Queries != 2 || status.Complexity != 12 {
+		t.Errorf("expected 2 queries of complexity 12, got %+v", status)
+	}
+	if !tracker.Check("tenant-a").Exceeded() {
+		t.Error("expected tenant-a to be over quota")
+	}
+
+	// Keys are counted separately
+	if tracker.Check("tenant-b").Exceeded() {
+		t.Error("expected tenant-b to be under quota")
+	}
+}
+
+func TestQuotaTracker_Limits(t *testing.T) {
+	tracker := NewQuotaTracker(Quota{Queries: 1}, map[string]Quota{"big-tenant": {Queries: 3}, "unlimited": {}}, []string{"small-tenant"})
+
+	for range 3 {
+		tracker.Charge("big-tenant", 1)
+		tracker.Charge("unlimited", 1000)
+	}
+	tracker.Charge("small-tenant", 1)
+
+	if !tracker.Check("big-tenant").Exceeded() {
+		t.Error("expected big-tenant to be over its override of 3 queries")
+	}
+	if tracker.Check("unlimited").Exceeded() {
+		t.Error("expected a zero quota to be unlimited")
+	}
+	if !tracker.Check("small-tenant").Exceeded() {
+		t.Error("expected small-tenant to be over the default of 1 query")
+	}
+}
+
+func TestQuotaTracker_ResetsDaily(t *testing.T) {
+	now := time.Date(2025, 3, 1, 23, 59, 0, 0, time.UTC)
+	tracker := NewQuotaTracker(Quota{Queries: 1}, nil, []string{"tenant-a"})
+	tracker.now = func() time.Time { return now }
+
+	status := tracker.Charge("tenant-a", 1)
+	if !status.Exceeded() {
+		t.Fatalf("expected tenant-a to be over quota, got %+v", status)
+	}
+	if want := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC); !status.Reset.Equal(want) {
+		t.Errorf("expected usage to reset at %s, got %s", want, status.Reset)
+	}
+
+	now = now.Add(time.Minute)
+	if status := tracker.Check("tenant-a"); status.Exceeded() || status.Queries != 0 {
+		t.Errorf("expected usage to reset at midnight UTC, got %+v", status)
+	}
+}
+
+func TestQuotaTracker_CheckDoesNotTrack(t *testing.T) {
+	tracker := NewQuotaTracker(Quota{Queries: 1}, nil, []string{"a", "b", "c"})
+	for _, key := range []string{"a", "b", "c"} {
+		tracker.Check(key)
+	}
+	if len(tracker.usage) != 0 {
+		t.Errorf("expected checks not to track keys, got %d", len(tracker.usage))
+	}
+}
+
+func TestQuotaTracker_UnknownKeysShareAnonymousQuota(t *testing.T) {
+	tracker := NewQuotaTracker(Quota{Queries: 2}, map[string]Quota{"big-tenant": {Queries: 5}}, []string{"tenant-a"})
+
+	// Rotating unknown keys, or sending none, does not reset usage
+	tracker.Charge("rotated-1", 1)
+	tracker.Charge("", 1)
+	if status := tracker.Check("rotated-2"); !status.Exceeded() || status.Queries != 2 {
+		t.Errorf("expected unknown keys to share an exceeded quota, got %+v", status)
+	}
+	if len(tracker.usage) != 1 {
+		t.Errorf("expected unknown keys to share one record, got %d", len(tracker.usage))
+	}
+
+	// Configured keys keep their own usage
+	if tracker.Check("tenant-a").Exceeded() || tracker.Check("big-tenant").Exceeded() {
+		t.Error("expected configured keys to be under quota")
+	}
+}
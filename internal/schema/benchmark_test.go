@@ -1,6 +1,7 @@
 package schema
 
 import (
+	"fmt"
 	"testing"
 )
 
@@ -312,3 +313,72 @@ func BenchmarkRegistryOperations(b *testing.B) {
 		}
 	})
 }
+
+// BenchmarkRegistryParallelGet benchmarks lookups from many goroutines, as under load,
+// with and without a schema being re-registered concurrently. Lookups take no lock, so
+// their cost should not grow with -cpu.
+func BenchmarkRegistryParallelGet(b *testing.B) {
+	fields := map[string]Field{
+		"field1": {Type: TypeText},
+		"field2": {Type: TypeInteger},
+	}
+	options := SchemaOptions{}
+
+	newRegistry := func(b *testing.B) *Registry {
+		registry := NewRegistry()
+		for i := 0; i < 50; i++ {
+			if err := registry.Register(NewSchema(fmt.Sprintf("schema%d", i), fields, options)); err != nil {
+				b.Fatal(err)
+			}
+		}
+		return registry
+	}
+
+	b.Run("ReadOnly", func(b *testing.B) {
+		registry := newRegistry(b)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, _, err := registry.GetVersioned("schema7"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+
+	b.Run("DuringWrites", func(b *testing.B) {
+		registry := newRegistry(b)
+
+		done := make(chan struct{})
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					registry.RegisterOrReplace(NewSchema("schema42", fields, options))
+				}
+			}
+		}()
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, _, err := registry.GetVersioned("schema7"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.StopTimer()
+		close(done)
+		<-stopped
+	})
+}
@@ -0,0 +1,42 @@
+package schema
+
+import "fmt"
+
+// Codec encodes the values queried on a field before they are bound, for columns that
+// store values encrypted or hashed rather than in plain text. Encodings must be
+// deterministic, the same value always encoding to the same stored value, or equality
+// could not match.
+type Codec interface {
+	Encode(value string) (string, error)
+}
+
+// CodecFunc adapts a function to a Codec
+type CodecFunc func(value string) (string, error)
+
+// Encode calls f(value)
+func (f CodecFunc) Encode(value string) (string, error) {
+	return f(value)
+}
+
+// exactOperators are the operators allowed on fields with a codec: only whole values can
+// be encoded and compared, so patterns, ranges and similarity cannot match
+var exactOperators = []string{OperatorTerm, OperatorPhrase, OperatorFieldGroup, OperatorIn, OperatorExists}
+
+// Codec returns a codec registered with the schema's registry
+func (s *Schema) Codec(name string) (Codec, bool) {
+	codec, ok := s.codecs[name]
+	return codec, ok
+}
+
+// checkCodecs returns an error for fields whose codec is not among the given codecs
+func (s *Schema) checkCodecs(codecs map[string]Codec) error {
+	for fieldName, field := range s.Fields {
+		if field.Codec == "" {
+			continue
+		}
+		if _, ok := codecs[field.Codec]; !ok {
+			return fmt.Errorf("unknown codec %q for field %q", field.Codec, fieldName)
+		}
+	}
+	return nil
+}
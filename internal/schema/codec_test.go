@@ -0,0 +1,151 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_Codec(t *testing.T) {
+	registry := NewRegistry()
+	reverse := CodecFunc(func(value string) (string, error) {
+		runes := []rune(value)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes), nil
+	})
+	if err := registry.RegisterCodec("reverse", reverse); err != nil {
+		t.Fatalf("RegisterCodec() unexpected error = %v", err)
+	}
+	if err := registry.RegisterCodec("reverse", reverse); err == nil {
+		t.Error("RegisterCodec() expected error for a duplicate, got nil")
+	}
+
+	s := NewSchema("users", map[string]Field{"ssn": {Type: TypeText, Codec: "reverse"}}, SchemaOptions{})
+	if err := registry.Register(s); err != nil {
+		t.Fatalf("Register() unexpected error = %v", err)
+	}
+	codec, ok := s.Codec("reverse")
+	if !ok {
+		t.Fatal("Codec(\"reverse\") not found after registration")
+	}
+	if encoded, _ := codec.Encode("abc"); encoded != "cba" {
+		t.Errorf("Encode(\"abc\") = %q, want cba", encoded)
+	}
+
+	unknown := NewSchema("orders", map[string]Field{"card": {Type: TypeText, Codec: "vault"}}, SchemaOptions{})
+	if err := registry.Register(unknown); err == nil || !strings.Contains(err.Error(), `unknown codec "vault"`) {
+		t.Errorf("Register() error = %v, want unknown codec", err)
+	}
+}
+
+func TestValidateSchema_Codec(t *testing.T) {
+	tests := []struct {
+		name  string
+		field Field
+		opts  SchemaOptions
+		err   string
+	}{
+		{"text field", Field{Type: TypeText, Codec: "pii"}, SchemaOptions{}, ""},
+		{"exact phrases", Field{Type: TypeText, Codec: "pii", PhraseMatch: PhraseExact}, SchemaOptions{}, ""},
+		{"not text", Field{Type: TypeInteger, Codec: "pii"}, SchemaOptions{}, "only supported on text fields"},
+		{"hash", Field{Type: TypeText, Hash: &FieldHash{Key: "k"}}, SchemaOptions{}, ""},
+		{"hash without key", Field{Type: TypeText, Hash: &FieldHash{}}, SchemaOptions{}, "needs a key"},
+		{"hash algorithm", Field{Type: TypeText, Hash: &FieldHash{Algorithm: "md5", Key: "k"}}, SchemaOptions{}, "invalid hash algorithm"},
+		{"codec and hash", Field{Type: TypeText, Codec: "pii", Hash: &FieldHash{Key: "k"}}, SchemaOptions{}, "both a codec and a hash"},
+		{"hashed integer", Field{Type: TypeInteger, Hash: &FieldHash{Key: "k"}}, SchemaOptions{}, "only supported on text fields"},
+		{"collation", Field{Type: TypeText, Codec: "pii", Collation: "C"}, SchemaOptions{}, "is encoded and cannot set"},
+		{"contains phrases", Field{Type: TypeText, Codec: "pii", PhraseMatch: PhraseContains}, SchemaOptions{}, "is encoded and cannot set"},
+		{"default field", Field{Type: TypeText, Codec: "pii"}, SchemaOptions{DefaultField: "ssn"}, "cannot be encoded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSchema(NewSchema("users", map[string]Field{"ssn": tt.field}, tt.opts))
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("ValidateSchema() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ValidateSchema() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestField_AllowedOperators(t *testing.T) {
+	tests := []struct {
+		name  string
+		field Field
+		want  []string
+	}{
+		{"unrestricted", Field{Type: TypeText}, nil},
+		{"whitelist", Field{Type: TypeText, Operators: []string{OperatorTerm, OperatorRange}}, []string{OperatorTerm, OperatorRange}},
+		{"codec", Field{Type: TypeText, Codec: "pii"}, exactOperators},
+		{"hash", Field{Type: TypeText, Hash: &FieldHash{Key: "k"}}, exactOperators},
+		{"codec narrowing a whitelist", Field{Type: TypeText, Codec: "pii", Operators: []string{OperatorIn, OperatorRange}}, []string{OperatorIn}},
+		{"codec without exact operators", Field{Type: TypeText, Codec: "pii", Operators: []string{OperatorRange}}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.field.AllowedOperators(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AllowedOperators() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	field := Field{Type: TypeText, Codec: "pii"}
+	if field.AllowsOperator(OperatorWildcard) || !field.AllowsOperator(OperatorIn) {
+		t.Error("AllowsOperator() should only allow exact matches on fields with a codec")
+	}
+}
+
+func TestFieldHash_Codec(t *testing.T) {
+	tests := []struct {
+		name string
+		hash FieldHash
+		want string
+	}{
+		// HMAC-SHA256 and HMAC-SHA512 of "abc" under the key "pepper"
+		{"sha256 by default", FieldHash{Key: "k"}, "95ac8d48c4886bae06029b433c46c419019bf03a65a842069d84b7755b1d08c4"},
+		{"sha512", FieldHash{Algorithm: HashSHA512, Key: "k"}, "e02cdec1ff25f024ef54057b5b181861efa2ef9c0dc6db7d3f12936ebc9afba19321e8d2357ed18b64dabccc2fda0e45994e254eba6b6415742be2adeb765d71"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.hash.Codec([]byte("pepper")).Encode("abc")
+			if err != nil || got != tt.want {
+				t.Errorf("Encode() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry_HashKey(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.RegisterHashKey("emails", []byte("pepper")); err != nil {
+		t.Fatalf("RegisterHashKey() unexpected error = %v", err)
+	}
+	if err := registry.RegisterHashKey("emails", []byte("pepper")); err == nil {
+		t.Error("RegisterHashKey() expected error for a duplicate, got nil")
+	}
+
+	field := Field{Type: TypeText, Hash: &FieldHash{Key: "emails"}}
+	s := NewSchema("users", map[string]Field{"email": field}, SchemaOptions{})
+	if err := registry.Register(s); err != nil {
+		t.Fatalf("Register() unexpected error = %v", err)
+	}
+	codec, ok := s.ValueCodec(&field)
+	if !ok || codec == nil {
+		t.Fatal("ValueCodec() found no codec after registration")
+	}
+	if encoded, _ := codec.Encode("abc"); encoded != "95ac8d48c4886bae06029b433c46c419019bf03a65a842069d84b7755b1d08c4" {
+		t.Errorf("Encode(\"abc\") = %q, want the HMAC under the registered key", encoded)
+	}
+
+	unknown := NewSchema("orders", map[string]Field{"card": {Type: TypeText, Hash: &FieldHash{Key: "cards"}}}, SchemaOptions{})
+	if err := registry.Register(unknown); err == nil || !strings.Contains(err.Error(), `unknown hash key "cards"`) {
+		t.Errorf("Register() error = %v, want unknown hash key", err)
+	}
+}
@@ -0,0 +1,72 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Dictionary is an external list of the values a field accepts, such as ISO country or
+// currency codes, registered with Registry.RegisterDictionary and named by Field.Dictionary.
+// Implementations are typically backed by a file or an HTTP endpoint and cache the list.
+type Dictionary interface {
+	// Contains reports whether the dictionary holds the value, compared as written
+	Contains(value string) (bool, error)
+	// Values returns every value of the dictionary, to suggest the closest ones
+	Values() ([]string, error)
+}
+
+// Dictionary returns a dictionary registered with the schema's registry
+func (s *Schema) Dictionary(name string) (Dictionary, bool) {
+	dictionary, ok := s.dictionaries[name]
+	return dictionary, ok
+}
+
+// checkDictionaries returns an error for fields whose dictionary is not among the given
+// dictionaries
+func (s *Schema) checkDictionaries(dictionaries map[string]Dictionary) error {
+	for fieldName, field := range s.Fields {
+		if field.Dictionary == "" {
+			continue
+		}
+		if _, ok := dictionaries[field.Dictionary]; !ok {
+			return fmt.Errorf("unknown dictionary %q for field %q", field.Dictionary, fieldName)
+		}
+	}
+	return nil
+}
+
+// ClosestValues returns up to limit values within a few edits of value, ignoring case,
+// closest first, to suggest for a value a dictionary does not hold: one edit below six
+// characters, so codes such as UDS suggest USD, and two from six
+func ClosestValues(value string, values []string, limit int) []string {
+	type candidate struct {
+		value    string
+		distance int
+	}
+	query := strings.ToLower(value)
+	maxEdits := 1
+	if len([]rune(query)) >= 6 {
+		maxEdits = 2
+	}
+	var candidates []candidate
+	for _, v := range values {
+		if distance := editDistance(query, strings.ToLower(v)); distance <= maxEdits {
+			candidates = append(candidates, candidate{v, distance})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].value < candidates[j].value
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	var closest []string
+	for _, c := range candidates {
+		closest = append(closest, c.value)
+	}
+	return closest
+}
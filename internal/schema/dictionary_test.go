@@ -0,0 +1,72 @@
+package schema
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// staticDictionary is a Dictionary of fixed values
+type staticDictionary []string
+
+func (d staticDictionary) Contains(value string) (bool, error) {
+	return slices.Contains(d, value), nil
+}
+
+func (d staticDictionary) Values() ([]string, error) {
+	return d, nil
+}
+
+func TestRegistry_Dictionary(t *testing.T) {
+	registry := NewRegistry()
+	countries := staticDictionary{"US", "DE"}
+	if err := registry.RegisterDictionary("countries", countries); err != nil {
+		t.Fatalf("RegisterDictionary() unexpected error = %v", err)
+	}
+	if err := registry.RegisterDictionary("countries", countries); err == nil {
+		t.Error("RegisterDictionary() expected error for a duplicate, got nil")
+	}
+	if err := registry.RegisterDictionary("", countries); err == nil {
+		t.Error("RegisterDictionary() expected error for an empty name, got nil")
+	}
+
+	s := NewSchema("orders", map[string]Field{"country": {Type: TypeText, Dictionary: "countries"}}, SchemaOptions{})
+	if err := registry.Register(s); err != nil {
+		t.Fatalf("Register() unexpected error = %v", err)
+	}
+	if _, ok := s.Dictionary("countries"); !ok {
+		t.Error("Dictionary(\"countries\") not found after registration")
+	}
+
+	unknown := NewSchema("users", map[string]Field{"currency": {Type: TypeText, Dictionary: "currencies"}}, SchemaOptions{})
+	if err := registry.Register(unknown); err == nil || !strings.Contains(err.Error(), `unknown dictionary "currencies"`) {
+		t.Errorf("Register() error = %v, want unknown dictionary", err)
+	}
+
+	dated := NewSchema("events", map[string]Field{"day": {Type: TypeDate, Dictionary: "countries"}}, SchemaOptions{})
+	if err := ValidateSchema(dated); err == nil || !strings.Contains(err.Error(), "only supported on text and integer fields") {
+		t.Errorf("ValidateSchema() error = %v, want text and integer fields only", err)
+	}
+}
+
+func TestClosestValues(t *testing.T) {
+	values := []string{"USD", "EUR", "GBP", "JPY", "UAH"}
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"usd", []string{"USD"}},
+		{"UDS", []string{"USD"}},
+		{"GPB", []string{"GBP"}},
+		{"EUX", []string{"EUR"}},
+		{"XXXXXX", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := ClosestValues(tt.value, values, 3); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ClosestValues(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
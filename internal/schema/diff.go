@@ -0,0 +1,86 @@
+package schema
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Diff summarizes the differences between two versions of a schema
+type Diff struct {
+	AddedFields    []string `json:"addedFields,omitempty"`
+	RemovedFields  []string `json:"removedFields,omitempty"`
+	ChangedFields  []string `json:"changedFields,omitempty"` // fields whose definition differs
+	OptionsChanged bool     `json:"optionsChanged,omitempty"`
+
+	AddedFragments   []string `json:"addedFragments,omitempty"`
+	RemovedFragments []string `json:"removedFragments,omitempty"`
+	ChangedFragments []string `json:"changedFragments,omitempty"` // fragments whose definition differs
+}
+
+// Empty reports whether the two versions are equivalent
+func (d Diff) Empty() bool {
+	return len(d.AddedFields) == 0 && len(d.RemovedFields) == 0 && len(d.ChangedFields) == 0 && !d.OptionsChanged &&
+		len(d.AddedFragments) == 0 && len(d.RemovedFragments) == 0 && len(d.ChangedFragments) == 0
+}
+
+// Compare returns the differences from before to after. A nil schema has no fields or
+// fragments, so comparing against nil lists every one as added or removed. Names are sorted.
+func Compare(before, after *Schema) Diff {
+	var diff Diff
+	var beforeFields, afterFields map[string]Field
+	if before != nil {
+		beforeFields = before.Fields
+	}
+	if after != nil {
+		afterFields = after.Fields
+	}
+
+	for name, field := range afterFields {
+		previous, exists := beforeFields[name]
+		switch {
+		case !exists:
+			diff.AddedFields = append(diff.AddedFields, name)
+		case !reflect.DeepEqual(previous, field):
+			diff.ChangedFields = append(diff.ChangedFields, name)
+		}
+	}
+	for name := range beforeFields {
+		if _, exists := afterFields[name]; !exists {
+			diff.RemovedFields = append(diff.RemovedFields, name)
+		}
+	}
+
+	if before != nil && after != nil {
+		diff.OptionsChanged = !reflect.DeepEqual(before.Options, after.Options)
+	}
+
+	var beforeFragments, afterFragments map[string]Fragment
+	if before != nil {
+		beforeFragments = before.Fragments
+	}
+	if after != nil {
+		afterFragments = after.Fragments
+	}
+	for name, fragment := range afterFragments {
+		previous, exists := beforeFragments[name]
+		switch {
+		case !exists:
+			diff.AddedFragments = append(diff.AddedFragments, name)
+		case !reflect.DeepEqual(previous, fragment):
+			diff.ChangedFragments = append(diff.ChangedFragments, name)
+		}
+	}
+	for name := range beforeFragments {
+		if _, exists := afterFragments[name]; !exists {
+			diff.RemovedFragments = append(diff.RemovedFragments, name)
+		}
+	}
+
+	sort.Strings(diff.AddedFields)
+	sort.Strings(diff.RemovedFields)
+	sort.Strings(diff.ChangedFields)
+	sort.Strings(diff.AddedFragments)
+	sort.Strings(diff.RemovedFragments)
+	sort.Strings(diff.ChangedFragments)
+	return diff
+}
@@ -0,0 +1,60 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	before := NewSchema("products", map[string]Field{
+		"name":  {Type: TypeText},
+		"price": {Type: TypeFloat},
+		"sku":   {Type: TypeText},
+	}, SchemaOptions{DefaultField: "name"})
+	after := NewSchema("products", map[string]Field{
+		"name":   {Type: TypeText},
+		"price":  {Type: TypeFloat, Unit: "USD"},
+		"rating": {Type: TypeInteger},
+		"brand":  {Type: TypeText},
+	}, SchemaOptions{DefaultField: "name"})
+
+	diff := Compare(before, after)
+	assert.Equal(t, []string{"brand", "rating"}, diff.AddedFields)
+	assert.Equal(t, []string{"sku"}, diff.RemovedFields)
+	assert.Equal(t, []string{"price"}, diff.ChangedFields)
+	assert.False(t, diff.OptionsChanged)
+	assert.False(t, diff.Empty())
+
+	after.Options.DefaultField = "brand"
+	assert.True(t, Compare(before, after).OptionsChanged)
+
+	assert.True(t, Compare(before, before).Empty())
+	assert.Equal(t, []string{"name", "price", "sku"}, Compare(before, nil).RemovedFields)
+	assert.Equal(t, []string{"name", "price", "sku"}, Compare(nil, before).AddedFields)
+	assert.True(t, Compare(nil, nil).Empty())
+}
+
+func TestCompare_Fragments(t *testing.T) {
+	fields := map[string]Field{"stock": {Type: TypeInteger}}
+	before := NewSchema("products", fields, SchemaOptions{})
+	before.Fragments = map[string]Fragment{
+		"inStock":   {SQL: "{stock} > 0"},
+		"clearance": {SQL: "{stock} < 5"},
+	}
+	after := NewSchema("products", fields, SchemaOptions{})
+	after.Fragments = map[string]Fragment{
+		"inStock":  {SQL: "{stock} > 1"},
+		"lowStock": {SQL: "{stock} < 10"},
+	}
+
+	diff := Compare(before, after)
+	assert.Equal(t, []string{"lowStock"}, diff.AddedFragments)
+	assert.Equal(t, []string{"clearance"}, diff.RemovedFragments)
+	assert.Equal(t, []string{"inStock"}, diff.ChangedFragments)
+	assert.Empty(t, diff.ChangedFields)
+	assert.False(t, diff.Empty())
+
+	assert.True(t, Compare(after, after).Empty())
+	assert.Equal(t, []string{"inStock", "lowStock"}, Compare(nil, after).AddedFragments)
+}
@@ -0,0 +1,206 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Fragment is a named filter condition kept in the schema, so that business rules such as
+// "in stock" are written once by the schema owner and used in queries as a pseudo-field:
+// inStock:true applies the condition and inStock:false negates it. Clients never submit
+// SQL; they can only name the fragments a schema defines, and definitions are vetted when
+// the schema is validated.
+type Fragment struct {
+	// SQL condition used by the postgres, mysql and sqlite translators, such as
+	// "{stockQty} > 0 AND discontinued = false". Fields referenced as {fieldName} resolve
+	// to their columns, including any table alias; bare identifiers are used as written.
+	SQL string `json:"sql,omitempty"`
+
+	// MongoDB filter document used by the mongodb translator
+	MongoDB map[string]interface{} `json:"mongodb,omitempty"`
+
+	Description string `json:"description,omitempty"` // Human-readable explanation of the filter
+}
+
+var (
+	// fragmentReferenceRegex matches {fieldName} references in fragment SQL
+	fragmentReferenceRegex = regexp.MustCompile(`\{([^{}]*)\}`)
+
+	// fragmentForbiddenWords are SQL keywords and functions that have no place in a
+	// boolean condition: statements, subqueries and deliberate delays
+	fragmentForbiddenWords = map[string]bool{
+		"SELECT": true, "INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true,
+		"DROP": true, "ALTER": true, "CREATE": true, "TRUNCATE": true, "GRANT": true,
+		"REVOKE": true, "UNION": true, "INTO": true, "EXEC": true, "EXECUTE": true,
+		"CALL": true, "COPY": true, "ATTACH": true, "PRAGMA": true, "LOAD_FILE": true,
+		"SLEEP": true, "PG_SLEEP": true, "BENCHMARK": true,
+	}
+
+	// fragmentForbiddenMongoOperators run server-side JavaScript
+	fragmentForbiddenMongoOperators = map[string]bool{
+		"$where":       true,
+		"$function":    true,
+		"$accumulator": true,
+	}
+)
+
+// Fragment looks up a fragment by name, case-insensitively unless field names are strict
+func (s *Schema) Fragment(name string) (*Fragment, bool) {
+	if f, exists := s.Fragments[name]; exists {
+		return &f, true
+	}
+	if !s.Options.StrictFieldNames {
+		for fragmentName, f := range s.Fragments {
+			if strings.EqualFold(fragmentName, name) {
+				return &f, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// ExpandSQL returns the fragment's SQL condition with field references replaced by the
+// columns they resolve to in the schema
+func (f *Fragment) ExpandSQL(s *Schema) (string, error) {
+	var err error
+	expanded := fragmentReferenceRegex.ReplaceAllStringFunc(f.SQL, func(reference string) string {
+		column, _, resolveErr := s.ResolveField(strings.TrimSpace(reference[1 : len(reference)-1]))
+		if resolveErr != nil && err == nil {
+			err = fmt.Errorf("fragment references unknown field %s", reference)
+		}
+		return column
+	})
+	if err != nil {
+		return "", err
+	}
+	return expanded, nil
+}
+
+// validateFragment vets a fragment definition. SQL must be a single condition: no
+// statement separators, comments, parameter placeholders, subqueries or statements,
+// with balanced quotes and parentheses, and field references must resolve.
+func validateFragment(s *Schema, name string, f Fragment) error {
+	if !columnNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid fragment name %q: must contain only alphanumeric characters and underscores", name)
+	}
+	if _, _, err := s.ResolveField(name); err == nil {
+		return fmt.Errorf("fragment %q conflicts with an existing field or alias", name)
+	}
+	if strings.TrimSpace(f.SQL) == "" && len(f.MongoDB) == 0 {
+		return fmt.Errorf("fragment %q must define sql or mongodb", name)
+	}
+
+	if f.SQL != "" {
+		if err := vetFragmentSQL(f.SQL); err != nil {
+			return fmt.Errorf("invalid sql for fragment %q: %w", name, err)
+		}
+		if _, err := f.ExpandSQL(s); err != nil {
+			return fmt.Errorf("invalid sql for fragment %q: %w", name, err)
+		}
+	}
+	if err := vetFragmentMongoDB(f.MongoDB); err != nil {
+		return fmt.Errorf("invalid mongodb filter for fragment %q: %w", name, err)
+	}
+	return nil
+}
+
+// vetFragmentSQL checks the text of a fragment's SQL outside of quoted literals and identifiers
+func vetFragmentSQL(sql string) error {
+	var quote byte
+	depth := 0
+	var word strings.Builder
+
+	checkWord := func() error {
+		if fragmentForbiddenWords[strings.ToUpper(word.String())] {
+			return fmt.Errorf("%s is not allowed", strings.ToUpper(word.String()))
+		}
+		word.Reset()
+		return nil
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		if c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			word.WriteByte(c)
+			continue
+		}
+		if err := checkWord(); err != nil {
+			return err
+		}
+
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth < 0 {
+				return errors.New("unbalanced parentheses")
+			}
+		case c == ';':
+			return errors.New("statement separators are not allowed")
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-', c == '/' && i+1 < len(sql) && sql[i+1] == '*', c == '#':
+			return errors.New("comments are not allowed")
+		case c == '$' || c == '?' || c == '\\':
+			return fmt.Errorf("%q is not allowed outside of quotes", c)
+		}
+	}
+
+	if err := checkWord(); err != nil {
+		return err
+	}
+	if quote != 0 {
+		return errors.New("unterminated quote")
+	}
+	if depth != 0 {
+		return errors.New("unbalanced parentheses")
+	}
+	return nil
+}
+
+// vetFragmentMongoDB rejects operators that run JavaScript anywhere in a filter document
+func vetFragmentMongoDB(value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if fragmentForbiddenMongoOperators[key] {
+				return fmt.Errorf("%s is not allowed", key)
+			}
+			if err := vetFragmentMongoDB(nested); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, nested := range v {
+			if err := vetFragmentMongoDB(nested); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WithoutFragmentDefinitions returns a copy of the schema whose fragments keep only their
+// descriptions, for showing the schema to clients: definitions stay server-side. The
+// copy shares everything else with the schema.
+func (s *Schema) WithoutFragmentDefinitions() *Schema {
+	if len(s.Fragments) == 0 {
+		return s
+	}
+	copied := *s
+	copied.Fragments = make(map[string]Fragment, len(s.Fragments))
+	for name, fragment := range s.Fragments {
+		copied.Fragments[name] = Fragment{Description: fragment.Description}
+	}
+	return &copied
+}
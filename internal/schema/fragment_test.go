@@ -0,0 +1,87 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSchema_Fragments(t *testing.T) {
+	tests := []struct {
+		name     string
+		fragment Fragment
+		fragName string
+		wantErr  string
+	}{
+		{name: "sql with references", fragment: Fragment{SQL: "({stockQty} > 0 AND {discontinued} = false)"}},
+		{name: "bare columns and literals", fragment: Fragment{SQL: "status IN ('a;b', 'c--d') AND \"Col\" <> 'x'"}},
+		{name: "mongodb only", fragment: Fragment{MongoDB: map[string]interface{}{"stock_qty": map[string]interface{}{"$gt": 0}}}},
+		{name: "empty", fragment: Fragment{Description: "nothing"}, wantErr: "must define sql or mongodb"},
+		{name: "invalid name", fragName: "in-stock", fragment: Fragment{SQL: "a = 1"}, wantErr: "invalid fragment name"},
+		{name: "shadows field", fragName: "stockQty", fragment: Fragment{SQL: "a = 1"}, wantErr: "conflicts with an existing field"},
+		{name: "shadows alias", fragName: "retired", fragment: Fragment{SQL: "a = 1"}, wantErr: "conflicts with an existing field"},
+		{name: "statement separator", fragment: Fragment{SQL: "a = 1; DROP TABLE products"}, wantErr: "statement separators are not allowed"},
+		{name: "line comment", fragment: Fragment{SQL: "a = 1 -- x"}, wantErr: "comments are not allowed"},
+		{name: "block comment", fragment: Fragment{SQL: "a = 1 /* x */"}, wantErr: "comments are not allowed"},
+		{name: "subquery", fragment: Fragment{SQL: "id IN (select id FROM other)"}, wantErr: "SELECT is not allowed"},
+		{name: "union", fragment: Fragment{SQL: "a = 1 UNION ALL b"}, wantErr: "UNION is not allowed"},
+		{name: "sleep", fragment: Fragment{SQL: "pg_sleep(10) IS NULL"}, wantErr: "PG_SLEEP is not allowed"},
+		{name: "placeholder", fragment: Fragment{SQL: "a = $1"}, wantErr: "'$' is not allowed outside of quotes"},
+		{name: "question mark", fragment: Fragment{SQL: "a = ?"}, wantErr: "'?' is not allowed outside of quotes"},
+		{name: "unterminated quote", fragment: Fragment{SQL: "a = 'x"}, wantErr: "unterminated quote"},
+		{name: "unbalanced parentheses", fragment: Fragment{SQL: "(a = 1"}, wantErr: "unbalanced parentheses"},
+		{name: "closing parenthesis first", fragment: Fragment{SQL: "a = 1) OR (b = 2"}, wantErr: "unbalanced parentheses"},
+		{name: "unknown reference", fragment: Fragment{SQL: "{missing} > 0"}, wantErr: "unknown field {missing}"},
+		{name: "mongodb javascript", fragment: Fragment{MongoDB: map[string]interface{}{"$or": []interface{}{map[string]interface{}{"$where": "sleep(100)"}}}}, wantErr: "$where is not allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := tt.fragName
+			if name == "" {
+				name = "inStock"
+			}
+			s := NewSchema("products", map[string]Field{
+				"stockQty":     {Type: TypeInteger},
+				"discontinued": {Type: TypeBoolean, Aliases: []string{"retired"}},
+			}, SchemaOptions{NamingConvention: "snake_case"})
+			s.Fragments = map[string]Fragment{name: tt.fragment}
+
+			err := ValidateSchema(s)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestSchema_Fragment(t *testing.T) {
+	s := NewSchema("products", map[string]Field{
+		"stockQty":     {Type: TypeInteger},
+		"discontinued": {Type: TypeBoolean, Aliases: []string{"retired"}},
+	}, SchemaOptions{NamingConvention: "snake_case"})
+	s.Fragments = map[string]Fragment{"inStock": {SQL: "{stockQty} > 0 AND {discontinued} = false"}}
+
+	fragment, ok := s.Fragment("instock")
+	require.True(t, ok)
+	sql, err := fragment.ExpandSQL(s)
+	require.NoError(t, err)
+	assert.Equal(t, "stock_qty > 0 AND discontinued = false", sql)
+
+	aliased, err := s.WithTableAlias("p")
+	require.NoError(t, err)
+	sql, err = fragment.ExpandSQL(aliased)
+	require.NoError(t, err)
+	assert.Equal(t, "p.stock_qty > 0 AND p.discontinued = false", sql)
+
+	_, ok = s.Fragment("outOfStock")
+	assert.False(t, ok)
+
+	s.Options.StrictFieldNames = true
+	_, ok = s.Fragment("instock")
+	assert.False(t, ok)
+}
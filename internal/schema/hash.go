@@ -0,0 +1,75 @@
+package schema
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"slices"
+)
+
+// Hash algorithms of hashed fields
+const (
+	HashSHA256 = "sha256"
+	HashSHA512 = "sha512"
+)
+
+// FieldHash describes a field stored as keyed hashes of its values, such that rows can be
+// looked up by a value without the database holding it. Values are stored as the
+// lowercase hex HMAC of the value under a secret key. The key is registered with
+// Registry.RegisterHashKey from the deployment's configuration; schemas only name it, so
+// reading a schema never reveals it.
+type FieldHash struct {
+	Algorithm string `json:"algorithm,omitempty"` // "sha256" (default) or "sha512"
+	Key       string `json:"key"`                 // name of the registered secret key
+}
+
+// Codec returns the codec hashing values under the secret key
+func (h *FieldHash) Codec(secret []byte) Codec {
+	newHash := sha256.New
+	if h.Algorithm == HashSHA512 {
+		newHash = sha512.New
+	}
+	return CodecFunc(func(value string) (string, error) {
+		mac := hmac.New(newHash, secret)
+		mac.Write([]byte(value))
+		return hex.EncodeToString(mac.Sum(nil)), nil
+	})
+}
+
+// IsExactOperator reports whether an operator matches whole values, the only kind of
+// match possible on fields with a codec or a hash
+func IsExactOperator(operator string) bool {
+	return slices.Contains(exactOperators, operator)
+}
+
+// ValueCodec returns the codec encoding the field's values: its hash under the key it
+// names, or the registered codec it names. ok is false for fields queried in plain text,
+// and codec is nil when the named codec or key is not registered.
+func (s *Schema) ValueCodec(field *Field) (codec Codec, ok bool) {
+	if field.Hash != nil {
+		secret, registered := s.hashKeys[field.Hash.Key]
+		if !registered {
+			return nil, true
+		}
+		return field.Hash.Codec(secret), true
+	}
+	if field.Codec == "" {
+		return nil, false
+	}
+	return s.codecs[field.Codec], true
+}
+
+// checkHashKeys returns an error for hashed fields whose key is not among the given keys
+func (s *Schema) checkHashKeys(keys map[string][]byte) error {
+	for fieldName, field := range s.Fields {
+		if field.Hash == nil {
+			continue
+		}
+		if _, ok := keys[field.Hash.Key]; !ok {
+			return fmt.Errorf("unknown hash key %q for field %q", field.Hash.Key, fieldName)
+		}
+	}
+	return nil
+}
@@ -135,3 +135,9 @@ func ToPascalCase(s string) string {
 
 	return result.String()
 }
+
+// ToKebabCase converts a string to kebab-case.
+// Handles camelCase, PascalCase and snake_case strings.
+func ToKebabCase(s string) string {
+	return strings.ReplaceAll(ToSnakeCase(s), "_", "-")
+}
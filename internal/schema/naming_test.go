@@ -84,3 +84,22 @@ func TestToPascalCase(t *testing.T) {
 		})
 	}
 }
+
+func TestToKebabCase(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"userName", "user-name"},
+		{"ProductCode", "product-code"},
+		{"ship_date", "ship-date"},
+		{"HTTPServer", "http-server"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if result := ToKebabCase(tt.input); result != tt.expected {
+			t.Errorf("ToKebabCase(%q) = %q, want %q", tt.input, result, tt.expected)
+		}
+	}
+}
@@ -2,58 +2,350 @@ package schema
 
 import (
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 )
 
-// Registry is a thread-safe in-memory storage for schemas
+// EventType identifies the kind of registry change
+type EventType string
+
+const (
+	// EventRegistered is emitted when a schema is stored in the registry
+	EventRegistered EventType = "registered"
+	// EventDeleted is emitted when a schema is removed from the registry
+	EventDeleted EventType = "deleted"
+)
+
+// Event describes a change to a schema in the registry
+type Event struct {
+	Type     EventType
+	Name     string
+	Version  uint64
+	Node     string  // replica that made the version, see SetNode; empty outside clusters
+	Schema   *Schema // nil for deletions
+	Previous *Schema // the schema replaced or deleted by the change; nil when there was none
+	Remote   bool    // true when the change was applied from another replica
+}
+
+// Registry is a thread-safe in-memory storage for schemas.
+// Lookups read an immutable snapshot of the schemas without locking, so they never
+// contend under load; changes are serialized and publish a new snapshot.
 type Registry struct {
-	schemas map[string]*Schema
-	mu      sync.RWMutex
+	snapshot     atomic.Pointer[snapshot]
+	listeners    []func(Event)
+	conversions  map[string]NameConversion // custom name resolution strategies
+	codecs       map[string]Codec          // value codecs fields can name, see Field.Codec
+	hashKeys     map[string][]byte         // secret keys hashed fields can name, see Field.Hash
+	dictionaries map[string]Dictionary     // value dictionaries fields can name, see Field.Dictionary
+	node         string                    // replica the registry runs on, see SetNode
+	mu           sync.Mutex                // serializes changes
+}
+
+// snapshot holds the registered schemas and the versions of all names ever registered.
+// It is never modified once published; changes copy it.
+type snapshot struct {
+	schemas  map[string]*Schema
+	versions map[string]uint64
+	nodes    map[string]string // replica that made each version
+}
+
+// with returns a copy of the snapshot storing schema under name, or deleting name when
+// schema is nil, at version made by node
+func (s *snapshot) with(name string, schema *Schema, version uint64, node string) *snapshot {
+	next := &snapshot{
+		schemas:  make(map[string]*Schema, len(s.schemas)+1),
+		versions: make(map[string]uint64, len(s.versions)+1),
+		nodes:    make(map[string]string, len(s.nodes)+1),
+	}
+	for key, value := range s.schemas {
+		next.schemas[key] = value
+	}
+	for key, value := range s.versions {
+		next.versions[key] = value
+	}
+	for key, value := range s.nodes {
+		next.nodes[key] = value
+	}
+
+	if schema != nil {
+		next.schemas[name] = schema
+	} else {
+		delete(next.schemas, name)
+	}
+	next.versions[name] = version
+	next.nodes[name] = node
+	return next
+}
+
+// supersededBy reports whether a change replaces the current version of its name.
+// Replicas changing a schema concurrently can make the same version; such changes are
+// ordered by the replica that made them, and then by content, so that whichever order
+// they arrive in, every replica keeps the same one.
+func (s *snapshot) supersededBy(event Event) bool {
+	version, node := s.versions[event.Name], s.nodes[event.Name]
+	if event.Version != version {
+		return event.Version > version
+	}
+	if event.Node != node {
+		return event.Node > node
+	}
+	return contentKey(event.Schema) > contentKey(s.schemas[event.Name])
+}
+
+// contentKey orders the contents of schemas for supersededBy, deletions first
+func contentKey(schema *Schema) string {
+	if schema == nil {
+		return ""
+	}
+	return schema.Fingerprint()
 }
 
 // NewRegistry creates a new schema registry
 func NewRegistry() *Registry {
-	return &Registry{
-		schemas: make(map[string]*Schema),
+	r := &Registry{}
+	r.snapshot.Store(&snapshot{
+		schemas:  make(map[string]*Schema),
+		versions: make(map[string]uint64),
+		nodes:    make(map[string]string),
+	})
+	return r
+}
+
+// SetNode names the replica the registry runs on, which the versions it makes carry to
+// break ties with concurrent changes on other replicas. Versions made before it is set
+// are attributed to the replica too.
+func (r *Registry) SetNode(node string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.node = node
+	current := r.snapshot.Load()
+	next := &snapshot{schemas: current.schemas, versions: current.versions, nodes: make(map[string]string, len(current.versions))}
+	for name := range current.versions {
+		next.nodes[name] = current.nodes[name]
+		if next.nodes[name] == "" {
+			next.nodes[name] = node
+		}
+	}
+	r.snapshot.Store(next)
+}
+
+// Subscribe registers a listener that is called after every registry change.
+// Listeners are invoked synchronously, outside the registry lock.
+func (r *Registry) Subscribe(listener func(Event)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.listeners = append(r.listeners, listener)
+}
+
+// RegisterNameConversion adds a custom name resolution strategy that schemas can list in
+// their nameResolution option, such as one stripping a legacy column prefix. Conversions
+// must be registered before the schemas using them.
+func (r *Registry) RegisterNameConversion(name string, conversion NameConversion) error {
+	if name == "" || conversion == nil {
+		return fmt.Errorf("name conversion needs a name and a function")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.conversions[name]; exists || isBuiltinStrategy(name) {
+		return fmt.Errorf("name resolution strategy %q already exists", name)
+	}
+	if r.conversions == nil {
+		r.conversions = make(map[string]NameConversion)
+	}
+	r.conversions[name] = conversion
+	return nil
+}
+
+// RegisterCodec adds a codec that fields can name in their codec option, to query columns
+// storing values encrypted. Codecs must be registered before the schemas using them.
+func (r *Registry) RegisterCodec(name string, codec Codec) error {
+	if name == "" || codec == nil {
+		return fmt.Errorf("codec needs a name and an implementation")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.codecs[name]; exists {
+		return fmt.Errorf("codec %q already exists", name)
+	}
+	if r.codecs == nil {
+		r.codecs = make(map[string]Codec)
+	}
+	r.codecs[name] = codec
+	return nil
+}
+
+// RegisterHashKey adds a secret key that hashed fields can name in their hash option.
+// Keys must be registered before the schemas using them.
+func (r *Registry) RegisterHashKey(name string, secret []byte) error {
+	if name == "" || len(secret) == 0 {
+		return fmt.Errorf("hash key needs a name and a secret")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.hashKeys[name]; exists {
+		return fmt.Errorf("hash key %q already exists", name)
+	}
+	if r.hashKeys == nil {
+		r.hashKeys = make(map[string][]byte)
+	}
+	r.hashKeys[name] = secret
+	return nil
+}
+
+// RegisterDictionary adds a dictionary that fields can name in their dictionary option, to
+// accept only its values. Dictionaries must be registered before the schemas using them.
+func (r *Registry) RegisterDictionary(name string, dictionary Dictionary) error {
+	if name == "" || dictionary == nil {
+		return fmt.Errorf("dictionary needs a name and an implementation")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.dictionaries[name]; exists {
+		return fmt.Errorf("dictionary %q already exists", name)
+	}
+	if r.dictionaries == nil {
+		r.dictionaries = make(map[string]Dictionary)
 	}
+	r.dictionaries[name] = dictionary
+	return nil
+}
+
+// VersionConflictError is returned by Update when the schema changed since the
+// version the caller read
+type VersionConflictError struct {
+	Name     string
+	Expected uint64
+	Current  uint64
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("schema %q is at version %d, not %d", e.Name, e.Current, e.Expected)
 }
 
+// registerMode selects what register does when a schema of the same name exists
+type registerMode int
+
+const (
+	registerNew     registerMode = iota // fail
+	registerReplace                     // replace it
+	registerAbsent                      // keep it
+	registerUpdate                      // replace it if it is at the expected version
+)
+
 // Register adds a schema to the registry after validation
 // Returns an error if the schema is invalid or already exists
 func (r *Registry) Register(schema *Schema) error {
+	_, _, err := r.register(schema, registerNew, 0)
+	return err
+}
+
+// RegisterOrReplace adds a schema to the registry after validation, replacing the
+// schema of the same name if there is one. The registration event carries the replaced
+// schema as Previous. Returns whether a schema was replaced.
+func (r *Registry) RegisterOrReplace(schema *Schema) (bool, error) {
+	previous, _, err := r.register(schema, registerReplace, 0)
+	return previous != nil, err
+}
+
+// RegisterIfAbsent adds a schema to the registry after validation unless a schema of
+// the same name exists, which is kept, without an event. Returns whether the schema was
+// registered; loading schemas at boot with it never overwrites schemas registered at
+// runtime in the meantime.
+func (r *Registry) RegisterIfAbsent(schema *Schema) (bool, error) {
+	_, stored, err := r.register(schema, registerAbsent, 0)
+	return stored, err
+}
+
+// Update replaces a registered schema after validation, provided it is still at version,
+// the version the caller read it at with GetVersioned. Concurrent read-modify-write
+// cycles thus cannot overwrite each other: the later one fails with a
+// *VersionConflictError and can read the schema again.
+func (r *Registry) Update(schema *Schema, version uint64) error {
+	_, _, err := r.register(schema, registerUpdate, version)
+	return err
+}
+
+// register validates and stores a schema as mode says, returning the schema it replaced
+// and whether it was stored
+func (r *Registry) register(schema *Schema, mode registerMode, version uint64) (*Schema, bool, error) {
 	if schema == nil {
-		return fmt.Errorf("schema is nil")
+		return nil, false, fmt.Errorf("schema is nil")
 	}
 
 	// Validate schema before registration
 	if err := ValidateSchema(schema); err != nil {
-		return fmt.Errorf("invalid schema: %w", err)
+		return nil, false, fmt.Errorf("invalid schema: %w", err)
 	}
 
 	r.mu.Lock()
-	defer r.mu.Unlock()
+	current := r.snapshot.Load()
 
 	// Check for duplicate
-	if _, exists := r.schemas[schema.Name]; exists {
-		return fmt.Errorf("schema %q already exists", schema.Name)
+	previous, exists := current.schemas[schema.Name]
+	switch {
+	case mode == registerNew && exists:
+		r.mu.Unlock()
+		return nil, false, fmt.Errorf("schema %q already exists", schema.Name)
+	case mode == registerAbsent && exists:
+		r.mu.Unlock()
+		return nil, false, nil
+	case mode == registerUpdate && !exists:
+		r.mu.Unlock()
+		return nil, false, fmt.Errorf("schema %q not found", schema.Name)
+	case mode == registerUpdate && current.versions[schema.Name] != version:
+		r.mu.Unlock()
+		return nil, false, &VersionConflictError{Name: schema.Name, Expected: version, Current: current.versions[schema.Name]}
+	}
+	if err := schema.checkNameResolution(r.conversions); err != nil {
+		r.mu.Unlock()
+		return nil, false, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := schema.checkCodecs(r.codecs); err != nil {
+		r.mu.Unlock()
+		return nil, false, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := schema.checkHashKeys(r.hashKeys); err != nil {
+		r.mu.Unlock()
+		return nil, false, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := schema.checkDictionaries(r.dictionaries); err != nil {
+		r.mu.Unlock()
+		return nil, false, fmt.Errorf("invalid schema: %w", err)
 	}
 
 	// Pre-compute field mappings for fast lookups
 	schema.buildLookupCache()
+	schema.conversions = r.conversions
+	schema.codecs = r.codecs
+	schema.hashKeys = r.hashKeys
+	schema.dictionaries = r.dictionaries
 
 	// Store schema
-	r.schemas[schema.Name] = schema
+	event := Event{Type: EventRegistered, Name: schema.Name, Version: current.versions[schema.Name] + 1, Node: r.node, Schema: schema, Previous: previous}
+	r.snapshot.Store(current.with(schema.Name, schema, event.Version, event.Node))
+	listeners := r.listeners
 
-	return nil
+	r.mu.Unlock()
+
+	notify(listeners, event)
+	return previous, true, nil
 }
 
 // Get retrieves a schema by name
 // Returns an error if the schema does not exist
 func (r *Registry) Get(name string) (*Schema, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	schema, exists := r.schemas[name]
+	schema, exists := r.snapshot.Load().schemas[name]
 	if !exists {
 		return nil, fmt.Errorf("schema %q not found", name)
 	}
@@ -61,28 +353,143 @@ func (r *Registry) Get(name string) (*Schema, error) {
 	return schema, nil
 }
 
+// GetVersioned retrieves a schema together with its current version, read atomically
+// so that the version always describes the returned schema
+func (r *Registry) GetVersioned(name string) (*Schema, uint64, error) {
+	current := r.snapshot.Load()
+
+	schema, exists := current.schemas[name]
+	if !exists {
+		return nil, 0, fmt.Errorf("schema %q not found", name)
+	}
+
+	return schema, current.versions[name], nil
+}
+
 // Delete removes a schema from the registry
 // Returns an error if the schema does not exist
 func (r *Registry) Delete(name string) error {
 	r.mu.Lock()
-	defer r.mu.Unlock()
+	current := r.snapshot.Load()
 
-	if _, exists := r.schemas[name]; !exists {
+	previous, exists := current.schemas[name]
+	if !exists {
+		r.mu.Unlock()
 		return fmt.Errorf("schema %q not found", name)
 	}
 
-	delete(r.schemas, name)
+	event := Event{Type: EventDeleted, Name: name, Version: current.versions[name] + 1, Node: r.node, Previous: previous}
+	r.snapshot.Store(current.with(name, nil, event.Version, event.Node))
+	listeners := r.listeners
+
+	r.mu.Unlock()
+
+	notify(listeners, event)
 	return nil
 }
 
+// Version returns the current version of a schema name.
+// Versions start at zero and are bumped on every registration or deletion,
+// so a deleted schema keeps a version that later registrations build on.
+func (r *Registry) Version(name string) uint64 {
+	return r.snapshot.Load().versions[name]
+}
+
+// ApplyRemote applies a change received from another replica.
+// The change is ignored (and false returned) unless it supersedes the local version:
+// unless its version is newer, or the same version made concurrently by a replica
+// ordered after the local one's. Redelivered or reordered messages are thus harmless,
+// and replicas converge on the same schema whatever order they apply changes in.
+func (r *Registry) ApplyRemote(event Event) (bool, error) {
+	if event.Type == EventRegistered {
+		if event.Schema == nil {
+			return false, fmt.Errorf("remote registration of %q carries no schema", event.Name)
+		}
+		if err := ValidateSchema(event.Schema); err != nil {
+			return false, fmt.Errorf("invalid remote schema: %w", err)
+		}
+	}
+
+	r.mu.Lock()
+	current := r.snapshot.Load()
+
+	if !current.supersededBy(event) {
+		r.mu.Unlock()
+		return false, nil
+	}
+
+	event.Previous = current.schemas[event.Name]
+	switch event.Type {
+	case EventRegistered:
+		if err := event.Schema.checkNameResolution(r.conversions); err != nil {
+			r.mu.Unlock()
+			return false, fmt.Errorf("invalid remote schema: %w", err)
+		}
+		if err := event.Schema.checkCodecs(r.codecs); err != nil {
+			r.mu.Unlock()
+			return false, fmt.Errorf("invalid remote schema: %w", err)
+		}
+		if err := event.Schema.checkHashKeys(r.hashKeys); err != nil {
+			r.mu.Unlock()
+			return false, fmt.Errorf("invalid remote schema: %w", err)
+		}
+		if err := event.Schema.checkDictionaries(r.dictionaries); err != nil {
+			r.mu.Unlock()
+			return false, fmt.Errorf("invalid remote schema: %w", err)
+		}
+		event.Schema.buildLookupCache()
+		event.Schema.conversions = r.conversions
+		event.Schema.codecs = r.codecs
+		event.Schema.hashKeys = r.hashKeys
+		event.Schema.dictionaries = r.dictionaries
+		r.snapshot.Store(current.with(event.Name, event.Schema, event.Version, event.Node))
+	case EventDeleted:
+		r.snapshot.Store(current.with(event.Name, nil, event.Version, event.Node))
+	default:
+		r.mu.Unlock()
+		return false, fmt.Errorf("unknown event type %q", event.Type)
+	}
+	event.Remote = true
+	listeners := r.listeners
+
+	r.mu.Unlock()
+
+	notify(listeners, event)
+	return true, nil
+}
+
+// State returns the current version of every name: a registration event for each
+// registered schema and a deletion event for each deleted name, sorted by name, for a
+// replica catching up to apply with ApplyRemote
+func (r *Registry) State() []Event {
+	current := r.snapshot.Load()
+
+	events := make([]Event, 0, len(current.versions))
+	for name, version := range current.versions {
+		event := Event{Type: EventDeleted, Name: name, Version: version, Node: current.nodes[name]}
+		if schema, ok := current.schemas[name]; ok {
+			event.Type, event.Schema = EventRegistered, schema
+		}
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Name < events[j].Name })
+	return events
+}
+
+// notify calls each listener with the event
+func notify(listeners []func(Event), event Event) {
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
 // List returns all registered schemas
 // Returns a copy of the schema list to prevent external modification
 func (r *Registry) List() []*Schema {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	current := r.snapshot.Load()
 
-	schemas := make([]*Schema, 0, len(r.schemas))
-	for _, schema := range r.schemas {
+	schemas := make([]*Schema, 0, len(current.schemas))
+	for _, schema := range current.schemas {
 		schemas = append(schemas, schema)
 	}
 
@@ -91,17 +498,11 @@ func (r *Registry) List() []*Schema {
 
 // Count returns the number of registered schemas
 func (r *Registry) Count() int {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	return len(r.schemas)
+	return len(r.snapshot.Load().schemas)
 }
 
 // Exists checks if a schema with the given name exists
 func (r *Registry) Exists(name string) bool {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	_, exists := r.schemas[name]
+	_, exists := r.snapshot.Load().schemas[name]
 	return exists
 }
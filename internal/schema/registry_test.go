@@ -266,3 +266,232 @@ func TestRegistry_ConcurrentWrites(t *testing.T) {
 		t.Errorf("List() length = %v, want %v", len(schemas), numGoroutines)
 	}
 }
+
+func TestRegistry_VersionsAndEvents(t *testing.T) {
+	registry := NewRegistry()
+
+	var events []Event
+	registry.Subscribe(func(e Event) { events = append(events, e) })
+
+	s := NewSchema("users", map[string]Field{"userName": {Type: TypeText}}, SchemaOptions{})
+	if err := registry.Register(s); err != nil {
+		t.Fatalf("Register() unexpected error = %v", err)
+	}
+	if err := registry.Delete("users"); err != nil {
+		t.Fatalf("Delete() unexpected error = %v", err)
+	}
+
+	if got := registry.Version("users"); got != 2 {
+		t.Errorf("Version() = %d, want 2", got)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if events[0].Type != EventRegistered || events[0].Version != 1 || events[0].Schema != s || events[0].Previous != nil {
+		t.Errorf("unexpected registration event: %+v", events[0])
+	}
+	if events[1].Type != EventDeleted || events[1].Version != 2 || events[1].Schema != nil || events[1].Previous != s {
+		t.Errorf("unexpected deletion event: %+v", events[1])
+	}
+}
+
+func TestRegistry_ApplyRemote(t *testing.T) {
+	registry := NewRegistry()
+
+	var remote []Event
+	registry.Subscribe(func(e Event) {
+		if e.Remote {
+			remote = append(remote, e)
+		}
+	})
+
+	s := NewSchema("users", map[string]Field{"userName": {Type: TypeText}}, SchemaOptions{})
+	applied, err := registry.ApplyRemote(Event{Type: EventRegistered, Name: "users", Version: 3, Schema: s})
+	if err != nil || !applied {
+		t.Fatalf("ApplyRemote() = %v, %v; want true, nil", applied, err)
+	}
+	if !registry.Exists("users") || registry.Version("users") != 3 {
+		t.Errorf("remote registration not applied")
+	}
+
+	// Older or equal versions are ignored
+	applied, err = registry.ApplyRemote(Event{Type: EventDeleted, Name: "users", Version: 3})
+	if err != nil || applied {
+		t.Errorf("ApplyRemote() stale = %v, %v; want false, nil", applied, err)
+	}
+
+	// Invalid remote schemas are rejected
+	if _, err := registry.ApplyRemote(Event{Type: EventRegistered, Name: "bad", Version: 1, Schema: &Schema{Name: "bad"}}); err == nil {
+		t.Errorf("ApplyRemote() expected error for invalid schema")
+	}
+
+	if len(remote) != 1 || !remote[0].Remote {
+		t.Errorf("expected one remote event, got %+v", remote)
+	}
+}
+
+func TestRegistry_ApplyRemote_ConcurrentVersions(t *testing.T) {
+	registry := NewRegistry()
+	registry.SetNode("b")
+	local := NewSchema("users", map[string]Field{"userName": {Type: TypeText}}, SchemaOptions{})
+	if err := registry.Register(local); err != nil {
+		t.Fatalf("Register() unexpected error = %v", err)
+	}
+
+	// The same version made by a replica ordered before the local one is ignored
+	other := NewSchema("users", map[string]Field{"email": {Type: TypeText}}, SchemaOptions{})
+	if applied, err := registry.ApplyRemote(Event{Type: EventRegistered, Name: "users", Version: 1, Node: "a", Schema: other}); err != nil || applied {
+		t.Errorf("ApplyRemote() from a = %v, %v; want false, nil", applied, err)
+	}
+
+	// and the one made by a replica ordered after it replaces it
+	if applied, err := registry.ApplyRemote(Event{Type: EventRegistered, Name: "users", Version: 1, Node: "c", Schema: other}); err != nil || !applied {
+		t.Errorf("ApplyRemote() from c = %v, %v; want true, nil", applied, err)
+	}
+	state := registry.State()
+	if len(state) != 1 || state[0].Node != "c" || state[0].Schema != other {
+		t.Errorf("State() = %+v, want the version of c", state)
+	}
+}
+
+func TestRegistry_RegisterOrReplace(t *testing.T) {
+	registry := NewRegistry()
+
+	var events []Event
+	registry.Subscribe(func(e Event) { events = append(events, e) })
+
+	v1 := NewSchema("users", map[string]Field{"userName": {Type: TypeText}}, SchemaOptions{})
+	v2 := NewSchema("users", map[string]Field{"userAge": {Type: TypeInteger}}, SchemaOptions{})
+
+	replaced, err := registry.RegisterOrReplace(v1)
+	if err != nil || replaced {
+		t.Fatalf("RegisterOrReplace() = %v, %v, want false, nil", replaced, err)
+	}
+	replaced, err = registry.RegisterOrReplace(v2)
+	if err != nil || !replaced {
+		t.Fatalf("RegisterOrReplace() = %v, %v, want true, nil", replaced, err)
+	}
+
+	if got, _ := registry.Get("users"); got != v2 {
+		t.Errorf("Get() returned the replaced schema")
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if events[0].Previous != nil || events[1].Type != EventRegistered || events[1].Version != 2 || events[1].Schema != v2 || events[1].Previous != v1 {
+		t.Errorf("unexpected replacement event: %+v", events[1])
+	}
+
+	invalid := &Schema{Name: "users", Fields: map[string]Field{}}
+	if _, err := registry.RegisterOrReplace(invalid); err == nil {
+		t.Error("RegisterOrReplace() expected error for invalid schema, got nil")
+	}
+	if got, _ := registry.Get("users"); got != v2 {
+		t.Errorf("an invalid schema replaced the registered one")
+	}
+}
+
+func TestRegistry_RegisterIfAbsent(t *testing.T) {
+	registry := NewRegistry()
+
+	var events []Event
+	registry.Subscribe(func(e Event) { events = append(events, e) })
+
+	v1 := NewSchema("users", map[string]Field{"userName": {Type: TypeText}}, SchemaOptions{})
+	v2 := NewSchema("users", map[string]Field{"userAge": {Type: TypeInteger}}, SchemaOptions{})
+
+	registered, err := registry.RegisterIfAbsent(v1)
+	if err != nil || !registered {
+		t.Fatalf("RegisterIfAbsent() = %v, %v, want true, nil", registered, err)
+	}
+	registered, err = registry.RegisterIfAbsent(v2)
+	if err != nil || registered {
+		t.Fatalf("RegisterIfAbsent() = %v, %v, want false, nil", registered, err)
+	}
+
+	if got, _ := registry.Get("users"); got != v1 {
+		t.Errorf("RegisterIfAbsent() replaced the registered schema")
+	}
+	if got := registry.Version("users"); got != 1 {
+		t.Errorf("Version() = %d, want 1", got)
+	}
+	if len(events) != 1 {
+		t.Errorf("got %d events, want 1", len(events))
+	}
+}
+
+func TestRegistry_Update(t *testing.T) {
+	registry := NewRegistry()
+
+	var events []Event
+	registry.Subscribe(func(e Event) { events = append(events, e) })
+
+	v1 := NewSchema("users", map[string]Field{"userName": {Type: TypeText}}, SchemaOptions{})
+	v2 := NewSchema("users", map[string]Field{"userAge": {Type: TypeInteger}}, SchemaOptions{})
+	v3 := NewSchema("users", map[string]Field{"email": {Type: TypeText}}, SchemaOptions{})
+
+	if err := registry.Update(v1, 0); err == nil {
+		t.Error("Update() expected error for unregistered schema, got nil")
+	}
+	if err := registry.Register(v1); err != nil {
+		t.Fatalf("Register() unexpected error = %v", err)
+	}
+
+	_, version, _ := registry.GetVersioned("users")
+	if err := registry.Update(v2, version); err != nil {
+		t.Fatalf("Update() unexpected error = %v", err)
+	}
+
+	// A second writer that read the same version loses
+	err := registry.Update(v3, version)
+	conflict, ok := err.(*VersionConflictError)
+	if !ok {
+		t.Fatalf("Update() error = %v, want *VersionConflictError", err)
+	}
+	if conflict.Name != "users" || conflict.Expected != 1 || conflict.Current != 2 {
+		t.Errorf("unexpected conflict: %+v", conflict)
+	}
+	if err.Error() != `schema "users" is at version 2, not 1` {
+		t.Errorf("Error() = %q", err.Error())
+	}
+
+	if got, _ := registry.Get("users"); got != v2 {
+		t.Errorf("Get() did not return the updated schema")
+	}
+	if len(events) != 2 || events[1].Previous != v1 || events[1].Schema != v2 {
+		t.Errorf("unexpected events: %+v", events)
+	}
+}
+
+func TestRegistry_ConcurrentUpdates(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(NewSchema("users", map[string]Field{"userName": {Type: TypeText}}, SchemaOptions{})); err != nil {
+		t.Fatalf("Register() unexpected error = %v", err)
+	}
+	_, version, _ := registry.GetVersioned("users")
+
+	// Of the writers that read the same version, exactly one wins
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	wins := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s := NewSchema("users", map[string]Field{fmt.Sprintf("field%d", i): {Type: TypeText}}, SchemaOptions{})
+			if err := registry.Update(s, version); err == nil {
+				mu.Lock()
+				wins++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if wins != 1 {
+		t.Errorf("%d concurrent updates succeeded, want 1", wins)
+	}
+	if got := registry.Version("users"); got != version+1 {
+		t.Errorf("Version() = %d, want %d", got, version+1)
+	}
+}
@@ -0,0 +1,155 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// Name resolution strategies, tried in the order listed in SchemaOptions.NameResolution
+const (
+	ResolveExact            = "exact"             // the field name as written
+	ResolveCaseInsensitive  = "case_insensitive"  // ignoring case, unless strictFieldNames is set
+	ResolveAlias            = "alias"             // field aliases and the schema's fieldAliases
+	ResolveNamingConvention = "naming_convention" // converted with the schema's namingConvention
+	ResolveSnakeCase        = "snake_case"        // productCode -> product_code
+	ResolveCamelCase        = "camel_case"        // product_code -> productCode
+	ResolvePascalCase       = "pascal_case"       // product_code -> ProductCode
+	ResolveKebabCase        = "kebab_case"        // productCode -> product-code
+)
+
+// defaultNameResolution is the order used when a schema does not set one
+var defaultNameResolution = []string{ResolveExact, ResolveCaseInsensitive, ResolveAlias, ResolveNamingConvention}
+
+// NameConversion converts a field name as written in a query into a candidate field
+// name, which then matches a schema field exactly, or ignoring case unless
+// strictFieldNames is set
+type NameConversion func(name string) string
+
+// builtinConversions are the conversions every schema can use
+var builtinConversions = map[string]NameConversion{
+	ResolveSnakeCase:  ToSnakeCase,
+	ResolveCamelCase:  ToCamelCase,
+	ResolvePascalCase: ToPascalCase,
+	ResolveKebabCase:  ToKebabCase,
+}
+
+// ResolutionStep is one name resolution strategy tried for a field name
+type ResolutionStep = rsearch.ResolutionStep
+
+// FieldResolution traces how a field name was resolved
+type FieldResolution = rsearch.FieldResolution
+
+// isBuiltinStrategy reports whether a strategy name is built in
+func isBuiltinStrategy(name string) bool {
+	switch name {
+	case ResolveExact, ResolveCaseInsensitive, ResolveAlias, ResolveNamingConvention:
+		return true
+	}
+	_, ok := builtinConversions[name]
+	return ok
+}
+
+// checkNameResolution returns an error for strategies that are neither built in nor
+// among the given conversions
+func (s *Schema) checkNameResolution(conversions map[string]NameConversion) error {
+	for _, strategy := range s.Options.NameResolution {
+		if _, ok := conversions[strategy]; !ok && !isBuiltinStrategy(strategy) {
+			return fmt.Errorf("unknown name resolution strategy %q", strategy)
+		}
+	}
+	return nil
+}
+
+// TraceResolution resolves a field name like ResolveField, recording every strategy
+// tried up to the first that matches
+func (s *Schema) TraceResolution(queryField string) FieldResolution {
+	trace := FieldResolution{Name: queryField, Steps: []ResolutionStep{}}
+	fieldName, ok := s.resolveName(queryField, func(step ResolutionStep) {
+		trace.Steps = append(trace.Steps, step)
+	})
+	if ok {
+		f := s.Fields[fieldName]
+		trace.Field = fieldName
+		trace.Column = s.getColumnName(fieldName, &f)
+	}
+	return trace
+}
+
+// resolveName finds the schema field a query field name refers to, trying the schema's
+// strategies in order. record, when not nil, is called with each strategy tried.
+func (s *Schema) resolveName(queryField string, record func(ResolutionStep)) (string, bool) {
+	strategies := s.Options.NameResolution
+	if len(strategies) == 0 {
+		strategies = defaultNameResolution
+	}
+
+	for _, strategy := range strategies {
+		fieldName, candidate, ok := s.applyStrategy(strategy, queryField)
+		if record != nil {
+			record(ResolutionStep{Strategy: strategy, Candidate: candidate, Matched: ok})
+		}
+		if ok {
+			return fieldName, true
+		}
+	}
+	return "", false
+}
+
+// applyStrategy resolves a field name with one strategy, returning the candidate it
+// looked up for conversions
+func (s *Schema) applyStrategy(strategy, queryField string) (fieldName, candidate string, ok bool) {
+	switch strategy {
+	case ResolveExact:
+		_, ok = s.Fields[queryField]
+		return queryField, "", ok
+	case ResolveCaseInsensitive:
+		if s.Options.StrictFieldNames {
+			return "", "", false
+		}
+		fieldName, ok = s.lowerFieldMap[strings.ToLower(queryField)]
+		return fieldName, "", ok
+	case ResolveAlias:
+		lookupKey := queryField
+		if !s.Options.StrictFieldNames {
+			lookupKey = strings.ToLower(queryField)
+		}
+		fieldName, ok = s.aliasMap[lookupKey]
+		return fieldName, "", ok
+	case ResolveNamingConvention:
+		if s.Options.NamingConvention == "" || s.Options.NamingConvention == "none" {
+			return "", "", false
+		}
+		candidate = s.transformFieldName(queryField)
+	default:
+		conversion, found := builtinConversions[strategy]
+		if !found {
+			conversion, found = s.conversions[strategy]
+		}
+		if !found {
+			return "", "", false
+		}
+		candidate = conversion(queryField)
+	}
+
+	if candidate == queryField {
+		return "", candidate, false
+	}
+	fieldName, ok = s.lookupCandidate(candidate)
+	return fieldName, candidate, ok
+}
+
+// lookupCandidate matches a converted name against the schema's fields exactly, or
+// ignoring case unless field names are strict
+func (s *Schema) lookupCandidate(candidate string) (string, bool) {
+	if _, exists := s.Fields[candidate]; exists {
+		return candidate, true
+	}
+	if !s.Options.StrictFieldNames {
+		if fieldName, exists := s.lowerFieldMap[strings.ToLower(candidate)]; exists {
+			return fieldName, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,145 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveField_Strategies(t *testing.T) {
+	schema := NewSchema("legacy", map[string]Field{
+		"product_code": {Type: TypeText},
+		"unitPrice":    {Type: TypeFloat},
+		"ship-date":    {Type: TypeDate},
+	}, SchemaOptions{
+		NameResolution: []string{ResolveExact, ResolveSnakeCase, ResolveCamelCase, ResolveKebabCase, ResolveAlias},
+		FieldAliases:   map[string]string{"sku": "product_code"},
+	})
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"product_code", "product_code"},
+		{"productCode", "product_code"},
+		{"unit_price", "unitPrice"},
+		{"shipDate", "ship-date"},
+		{"SKU", "product_code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			column, _, err := schema.ResolveField(tt.query)
+			if err != nil {
+				t.Fatalf("ResolveField(%q) unexpected error = %v", tt.query, err)
+			}
+			if column != tt.want {
+				t.Errorf("ResolveField(%q) column = %q, want %q", tt.query, column, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveField_StrictDisablesCaseInsensitive(t *testing.T) {
+	schema := NewSchema("test", map[string]Field{
+		"status": {Type: TypeText},
+	}, SchemaOptions{StrictFieldNames: true, NameResolution: []string{ResolveCaseInsensitive}})
+
+	if _, _, err := schema.ResolveField("Status"); err == nil {
+		t.Error("ResolveField(\"Status\") expected error with strictFieldNames, got nil")
+	}
+}
+
+func TestTraceResolution(t *testing.T) {
+	schema := NewSchema("products", map[string]Field{
+		"product_code": {Type: TypeText},
+	}, SchemaOptions{NamingConvention: "snake_case"})
+
+	trace := schema.TraceResolution("productCode")
+	want := FieldResolution{
+		Name:   "productCode",
+		Field:  "product_code",
+		Column: "product_code",
+		Steps: []ResolutionStep{
+			{Strategy: ResolveExact},
+			{Strategy: ResolveCaseInsensitive},
+			{Strategy: ResolveAlias},
+			{Strategy: ResolveNamingConvention, Candidate: "product_code", Matched: true},
+		},
+	}
+	if !reflect.DeepEqual(trace, want) {
+		t.Errorf("TraceResolution() = %+v, want %+v", trace, want)
+	}
+
+	trace = schema.TraceResolution("PRODUCT_CODE")
+	if trace.Field != "product_code" || len(trace.Steps) != 2 || !trace.Steps[1].Matched {
+		t.Errorf("TraceResolution() = %+v, want a case-insensitive match", trace)
+	}
+
+	trace = schema.TraceResolution("missing")
+	if trace.Field != "" || len(trace.Steps) != 4 {
+		t.Errorf("TraceResolution() = %+v, want no match after every strategy", trace)
+	}
+}
+
+func TestRegistry_NameConversion(t *testing.T) {
+	registry := NewRegistry()
+	stripPrefix := func(name string) string { return "col_" + name }
+	if err := registry.RegisterNameConversion("col_prefix", stripPrefix); err != nil {
+		t.Fatalf("RegisterNameConversion() unexpected error = %v", err)
+	}
+	if err := registry.RegisterNameConversion("col_prefix", stripPrefix); err == nil {
+		t.Error("RegisterNameConversion() expected error for a duplicate, got nil")
+	}
+	if err := registry.RegisterNameConversion(ResolveSnakeCase, stripPrefix); err == nil {
+		t.Error("RegisterNameConversion() expected error for a built-in name, got nil")
+	}
+
+	schema := &Schema{
+		Name:    "legacy",
+		Fields:  map[string]Field{"col_status": {Type: TypeText}},
+		Options: SchemaOptions{NameResolution: []string{ResolveExact, "col_prefix"}},
+	}
+	if err := registry.Register(schema); err != nil {
+		t.Fatalf("Register() unexpected error = %v", err)
+	}
+	column, _, err := schema.ResolveField("status")
+	if err != nil || column != "col_status" {
+		t.Errorf("ResolveField(\"status\") = %q, %v, want col_status", column, err)
+	}
+
+	unknown := &Schema{
+		Name:    "other",
+		Fields:  map[string]Field{"status": {Type: TypeText}},
+		Options: SchemaOptions{NameResolution: []string{"reverse"}},
+	}
+	err = registry.Register(unknown)
+	if err == nil || !strings.Contains(err.Error(), `unknown name resolution strategy "reverse"`) {
+		t.Errorf("Register() error = %v, want unknown strategy", err)
+	}
+}
+
+func TestValidateSchema_NameResolution(t *testing.T) {
+	tests := []struct {
+		name    string
+		options SchemaOptions
+	}{
+		{"duplicate strategy", SchemaOptions{NameResolution: []string{ResolveExact, ResolveExact}}},
+		{"empty strategy", SchemaOptions{NameResolution: []string{""}}},
+		{"alias of unknown field", SchemaOptions{FieldAliases: map[string]string{"sku": "missing"}}},
+		{"empty alias", SchemaOptions{FieldAliases: map[string]string{"": "field1"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &Schema{
+				Name:    "test",
+				Fields:  map[string]Field{"field1": {Type: TypeText}},
+				Options: tt.options,
+			}
+			if err := ValidateSchema(schema); err == nil {
+				t.Error("ValidateSchema() expected error, got nil")
+			}
+		})
+	}
+}
@@ -1,10 +1,17 @@
 package schema
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/infiniv/rsearch/pkg/rsearch"
 )
 
 // FieldType represents the data type of a field
@@ -22,12 +29,148 @@ const (
 	TypeArray    FieldType = "array"
 )
 
+// Phrase match modes for text fields
+const (
+	PhraseExact    = "exact"    // Full-value equality (default)
+	PhraseContains = "contains" // Substring match of the whole phrase
+	PhraseFulltext = "fulltext" // Full-text phrase search (requires a full-text index)
+)
+
+// Full-text parsers of MySQL FULLTEXT indexes
+const (
+	FulltextParserNgram = "ngram" // splits text into n-grams rather than at spaces
+)
+
+// DefaultTextSearchConfig is the PostgreSQL text search configuration of fields that
+// do not set a full-text language
+const DefaultTextSearchConfig = "english"
+
+// Handling of ranges whose start is after their end, such as price:[500 TO 50]
+const (
+	ReversedRangesReject = "reject" // Reject the query (default)
+	ReversedRangesSwap   = "swap"   // Swap the bounds and warn
+)
+
+// Handling of clauses on fields the schema does not have, such as host:web1
+const (
+	UnknownFieldsError        = "error"         // Reject the query (default)
+	UnknownFieldsDrop         = "drop"          // Drop the clause and warn
+	UnknownFieldsDefaultField = "default_field" // Search the default field instead and warn
+)
+
+// Operators joining the values of a field group written next to each other, such as
+// tags:(scala functional). Operators written in the group are kept.
+const (
+	FieldGroupOr  = "or"  // Any of the values (default)
+	FieldGroupAnd = "and" // All of the values, as OpenSearch with default_operator AND
+)
+
+// NumberDecimalAuto reads either "." or "," as the decimal separator of each number, the
+// other one grouping its digits, see NumberFormat
+const NumberDecimalAuto = "auto"
+
+// Query operator names, used to restrict the operators a field supports
+const (
+	OperatorTerm       = "term"
+	OperatorPhrase     = "phrase"
+	OperatorWildcard   = "wildcard"
+	OperatorRegex      = "regex"
+	OperatorRange      = "range"
+	OperatorFuzzy      = "fuzzy"
+	OperatorProximity  = "proximity"
+	OperatorExists     = "exists"
+	OperatorFieldGroup = "field_group"
+	OperatorIn         = "in"
+)
+
 // Field represents a schema field definition
 type Field struct {
 	Type    FieldType `json:"type"`
 	Column  string    `json:"column,omitempty"`  // Optional: explicit column name override
 	Indexed bool      `json:"indexed"`           // Hint for translators
 	Aliases []string  `json:"aliases,omitempty"` // Alternative field names
+
+	// Collation and Unaccent control text matching; both apply to text fields only
+	Collation string `json:"collation,omitempty"` // Optional: collation used for equality and pattern matches
+	Unaccent  bool   `json:"unaccent,omitempty"`  // Accent-insensitive matching ("jose" matches "José")
+
+	PhraseMatch string `json:"phraseMatch,omitempty"` // How quoted phrases match: "exact", "contains" or "fulltext"
+
+	// Fulltext configures how full-text searches on the field, such as fulltext phrases
+	// and proximity searches, analyze text; it must match the column's full-text index
+	Fulltext FulltextOptions `json:"fulltext,omitzero"`
+
+	// PrefixRange translates prefix wildcards, such as code:abc*, to the range
+	// code >= 'abc' AND code < 'abd', which a B-tree index on the column serves. Set it on
+	// keyword-like text fields whose column collation orders by code point; translators
+	// keep LIKE when the field's collation could order the range differently.
+	PrefixRange bool `json:"prefixRange,omitempty"`
+
+	// Operators restricts the query operators allowed on the field, e.g. ["term", "in"]
+	// for equality and IN only. Empty allows every operator the field type supports.
+	Operators []string `json:"operators,omitempty"`
+
+	// Documentation for query builders; not used in translation
+	Description string   `json:"description,omitempty"` // Human-readable explanation of the field
+	Examples    []string `json:"examples,omitempty"`    // Example query values
+
+	// Unit of measure, e.g. "USD" or "ms". On numeric fields, a byte, duration or distance
+	// unit is also the base unit that suffixed values convert to: "10mb" binds 10000000 when Unit is "b".
+	Unit string `json:"unit,omitempty"`
+
+	// MinSimilarity, between 0 and 1, makes fuzzy queries on the field match by trigram
+	// similarity at or above it instead of by edit distance. 0 keeps edit distance.
+	MinSimilarity float64 `json:"minSimilarity,omitempty"`
+
+	// MaxLength caps the characters of each value queried on the field, overriding the
+	// schema's MaxValueLength. MaxValues caps the values of its in-lists and field groups,
+	// overriding the schema's MaxListSize. 0 keeps the schema's limits.
+	MaxLength int `json:"maxLength,omitempty"`
+	MaxValues int `json:"maxValues,omitempty"`
+
+	// Codec names a codec registered with Registry.RegisterCodec that encodes values
+	// before they are bound, for columns storing values encrypted, such that the database
+	// never sees the plain text queried. Only exact matches can be queried on the field.
+	Codec string `json:"codec,omitempty"`
+
+	// Hash marks a field stored as keyed hashes of its values; values are hashed before
+	// they are bound, and like codec fields only exact matches can be queried.
+	Hash *FieldHash `json:"hash,omitempty"`
+
+	// Dictionary names a dictionary registered with Registry.RegisterDictionary holding the
+	// values the field accepts; queries matching other values are rejected with suggestions.
+	Dictionary string `json:"dictionary,omitempty"`
+
+	// ValueAliases maps values users write to the values stored, such as {"california": "ca"},
+	// so region:california binds "ca". Aliases are looked up ignoring case; other values
+	// are bound as written.
+	ValueAliases map[string]string `json:"valueAliases,omitempty"`
+
+	// PartialIndexes are indexes on the field's column that only hold the rows matching a
+	// condition; translations report whether their queries can be served by them
+	PartialIndexes []PartialIndex `json:"partialIndexes,omitempty"`
+}
+
+// FulltextOptions configure the full-text searches of a text field
+type FulltextOptions struct {
+	// Language is the text search configuration PostgreSQL analyzes the column and the
+	// query with, such as "english", "german" or "simple", which does not stem, and the
+	// language of MongoDB $text searches, overriding the text index's default language.
+	// Empty uses "english" in PostgreSQL and the text index's language in MongoDB.
+	Language string `json:"language,omitempty"`
+
+	// Parser is the parser of the column's MySQL FULLTEXT index: empty for the built-in
+	// parser, or "ngram" for languages written without spaces between words, such as
+	// Chinese and Japanese
+	Parser string `json:"parser,omitempty"`
+}
+
+// PartialIndex is an index holding only the rows that match its predicate, such as
+// CREATE INDEX orders_status ON orders (status) WHERE deleted_at IS NULL. A database
+// only serves a query from it when the query's conditions imply the predicate.
+type PartialIndex struct {
+	Name  string `json:"name"`
+	Where string `json:"where"` // the predicate in query syntax, e.g. "NOT _exists_:deleted_at"
 }
 
 // EnabledFeatures contains flags for optional database features
@@ -39,23 +182,143 @@ type EnabledFeatures struct {
 
 // SchemaOptions contains configuration options for a schema
 type SchemaOptions struct {
-	NamingConvention string          `json:"namingConvention"` // "snake_case", "camelCase", "PascalCase", "none"
-	StrictOperators  bool            `json:"strictOperators"`  // case-sensitive AND/OR/NOT
-	StrictFieldNames bool            `json:"strictFieldNames"` // case-sensitive field names
-	DefaultField     string          `json:"defaultField"`     // field for queries without field specifier
-	EnabledFeatures  EnabledFeatures `json:"enabledFeatures"`  // Optional database features
+	NamingConvention string          `json:"namingConvention"`         // "snake_case", "camelCase", "PascalCase", "none"
+	StrictOperators  bool            `json:"strictOperators"`          // case-sensitive AND/OR/NOT
+	StrictFieldNames bool            `json:"strictFieldNames"`         // case-sensitive field names
+	DefaultField     string          `json:"defaultField"`             // field for queries without field specifier
+	EnabledFeatures  EnabledFeatures `json:"enabledFeatures"`          // Optional database features
+	DefaultFilters   []DefaultFilter `json:"defaultFilters,omitempty"` // filters ANDed into every query
+	Timezone         string          `json:"timezone,omitempty"`       // IANA time zone for date keywords such as "today"; default UTC
+	Analyzer         AnalyzerOptions `json:"analyzer,omitzero"`        // free-text term filtering
+	Execution        ExecutionLimits `json:"execution,omitzero"`       // limits returned as execution hints
+	MaxListSize      int             `json:"maxListSize,omitempty"`    // maximum values in an in-list such as region:(ca,us); 0 for no limit
+	MaxValueLength   int             `json:"maxValueLength,omitempty"` // maximum characters of a value; 0 for no limit
+	// ExclusionsMatchNull makes excluded lists, such as NOT region:in(ca us), also match
+	// records where the field is null or missing. By default they never do, in every dialect.
+	ExclusionsMatchNull bool `json:"exclusionsMatchNull,omitempty"`
+
+	// ReversedRanges is how ranges with a start after their end are handled: "reject" or
+	// "swap". Only numeric, date and time bounds are compared, as text order depends on
+	// the database collation.
+	ReversedRanges string `json:"reversedRanges,omitempty"`
+
+	// UnknownFields is how clauses on names that are neither fields nor fragments are
+	// handled: "error", "drop" or "default_field". Exploratory searches, such as over
+	// logs, can tolerate them; queries that must match exactly, such as for billing,
+	// should keep the default.
+	UnknownFields string `json:"unknownFields,omitempty"`
+
+	// NameResolution lists the strategies that resolve field names written in queries,
+	// tried in order: "exact", "case_insensitive", "alias", "naming_convention",
+	// "snake_case", "camel_case", "pascal_case", "kebab_case" or a conversion registered
+	// with the registry. Empty uses exact, case_insensitive, alias, naming_convention.
+	NameResolution []string `json:"nameResolution,omitempty"`
+
+	// FieldAliases maps alternative names to fields, e.g. {"sku": "productCode"}, for
+	// aliases shared by a schema instead of declared on each field
+	FieldAliases map[string]string `json:"fieldAliases,omitempty"`
+
+	// LegacyNegation keeps negated clauses of free text as optional alternatives, so
+	// "a NOT b" matches a OR NOT b, as before negations were bound like Lucene binds them
+	LegacyNegation bool `json:"legacyNegation,omitempty"`
+
+	// LegacyWildcardOnly keeps values that are only a wildcard, as in status:*, wildcard
+	// patterns matching any value (LIKE '%' or a match-all regex), as before they were
+	// existence checks like _exists_:status
+	LegacyWildcardOnly bool `json:"legacyWildcardOnly,omitempty"`
+
+	// AnchorRegex makes regex values match the whole value, as OpenSearch regexp queries
+	// do, by wrapping them in ^(...)$. Without it they match anywhere in the value, as
+	// the databases' regex operators do.
+	AnchorRegex bool `json:"anchorRegex,omitempty"`
+
+	// FieldGroupOperator joins the values of a field group written next to each other:
+	// "or" (default) or "and", so tags:(scala functional) needs both tags
+	FieldGroupOperator string `json:"fieldGroupOperator,omitempty"`
+
+	// DefaultSort orders the results of requests that do not sort. TieBreaker names a
+	// field with unique values, usually the primary key, appended to every sort that
+	// does not already include it, so rows with equal sort values keep a stable order
+	// across pages.
+	DefaultSort []SortField `json:"defaultSort,omitempty"`
+	TieBreaker  string      `json:"tieBreaker,omitempty"`
+
+	// NumberFormat is how numeric values are written in queries, for locales that write
+	// 1.234,56 rather than 1234.56
+	NumberFormat NumberFormat `json:"numberFormat,omitzero"`
+
+	// Temporal names the validity columns of a schema of versioned rows, so requests can
+	// ask for the rows valid at a point in time with asOf
+	Temporal TemporalOptions `json:"temporal,omitzero"`
+}
+
+// TemporalOptions names the date or datetime fields bounding the period a row is valid:
+// from ValidFrom, inclusive, to ValidTo, exclusive. A null ValidTo is valid indefinitely.
+type TemporalOptions struct {
+	ValidFrom string `json:"validFrom"`
+	ValidTo   string `json:"validTo"`
+}
+
+// NumberFormat sets the separators of numeric values written in queries. Localized values
+// are quoted, as in price:"1.234,56", since commas separate in-list values and a number
+// token ends at its second dot.
+// With Decimal "auto", a separator followed by exactly three digits, as in 1,000, could be
+// either one, and the value is rejected.
+type NumberFormat struct {
+	Decimal  string `json:"decimal,omitempty"`  // ".", "," or "auto"; default "."
+	Grouping string `json:"grouping,omitempty"` // digit grouping separator: ",", ".", " " or "'"; default none
+}
+
+// SortField orders results by a field, see rsearch.SortField
+type SortField = rsearch.SortField
+
+// ExecutionLimits bound the execution of translated queries. rsearch does not run queries;
+// the limits are returned with every translation as hints for the executor to enforce.
+type ExecutionLimits struct {
+	TimeoutMs int `json:"timeoutMs,omitempty"` // statement timeout in milliseconds; 0 sets none
+	MaxRows   int `json:"maxRows,omitempty"`   // row limit; 0 sets none
+}
+
+// AnalyzerOptions filters the bare terms of free text ("quick brown fox") before
+// translation, so that noise words do not each add a clause to the query. Synonyms
+// expand terms into their alternatives, weighted so exact matches can rank first.
+type AnalyzerOptions struct {
+	Stopwords      []string   `json:"stopwords,omitempty"`      // terms dropped from free text, matched case-insensitively
+	MinTermLength  int        `json:"minTermLength,omitempty"`  // terms with fewer characters are dropped; 0 keeps all
+	Synonyms       [][]string `json:"synonyms,omitempty"`       // groups of interchangeable terms, e.g. ["laptop", "notebook"]
+	OriginalWeight float64    `json:"originalWeight,omitempty"` // boost of a term as written when it has synonyms; default 1
+	SynonymWeight  float64    `json:"synonymWeight,omitempty"`  // boost of the synonyms it expands to; default 1
+}
+
+// DefaultFilter is an implicit filter ANDed into every query on a schema,
+// such as excluding soft-deleted rows. Filters are written in query syntax,
+// so they are validated and parameterized like any client query.
+type DefaultFilter struct {
+	Name  string `json:"name"`  // identifier used to opt out of the filter
+	Query string `json:"query"` // e.g. "NOT _exists_:deleted_at" or "is_active:true"
 }
 
 // Schema represents a schema definition
 type Schema struct {
-	Name      string           `json:"name"`
-	Fields    map[string]Field `json:"fields"`
-	Options   SchemaOptions    `json:"options"`
-	CreatedAt time.Time        `json:"createdAt"`
+	Name      string              `json:"name"`
+	Fields    map[string]Field    `json:"fields"`
+	Options   SchemaOptions       `json:"options"`
+	Fragments map[string]Fragment `json:"fragments,omitempty"` // named filters usable as pseudo-fields
+	CreatedAt time.Time           `json:"createdAt"`
 
 	// Internal cache for fast lookups
-	lowerFieldMap map[string]string // lowercase field name -> actual field name
-	aliasMap      map[string]string // alias (normalized) -> field name
+	lowerFieldMap map[string]string         // lowercase field name -> actual field name
+	aliasMap      map[string]string         // alias (normalized) -> field name
+	location      *time.Location            // loaded Options.Timezone
+	conversions   map[string]NameConversion // custom name conversions of the registry
+	codecs        map[string]Codec          // codecs of the registry, see Field.Codec
+	hashKeys      map[string][]byte         // hash keys of the registry, see Field.Hash
+	dictionaries  map[string]Dictionary     // dictionaries of the registry, see Field.Dictionary
+	tableAlias    string                    // qualifies resolved column names, see WithTableAlias
+	minSimilarity float64                   // overrides the fields' MinSimilarity, see WithMinSimilarity
+	anchorRegex   *bool                     // overrides Options.AnchorRegex, see WithAnchorRegex
+	groupOperator string                    // overrides Options.FieldGroupOperator, see WithFieldGroupOperator
+	suggestKeys   []suggestKey              // field names and aliases, see SuggestFields
 }
 
 // NewSchema creates a new schema with the given name and fields
@@ -88,75 +351,178 @@ func (s *Schema) buildLookupCache() {
 			s.aliasMap[normalizedAlias] = fieldName
 		}
 	}
+	for alias, fieldName := range s.Options.FieldAliases {
+		if !s.Options.StrictFieldNames {
+			alias = strings.ToLower(alias)
+		}
+		s.aliasMap[alias] = fieldName
+	}
+
+	s.location = loadLocation(s.Options.Timezone)
+	s.buildSuggestIndex()
+}
+
+// WithTableAlias returns a copy of the schema whose resolved column names are qualified
+// with a table alias, as in p.product_code, so translated clauses can be embedded in
+// queries that join other tables. The copy shares its field definitions with the schema.
+func (s *Schema) WithTableAlias(alias string) (*Schema, error) {
+	if !columnNameRegex.MatchString(alias) {
+		return nil, fmt.Errorf("invalid table alias %q: must contain only alphanumeric characters and underscores", alias)
+	}
+	aliased := *s
+	aliased.tableAlias = alias
+	return &aliased, nil
 }
 
-// ResolveField resolves a query field name to its actual column name and field definition
-// Resolution order:
-// 1. Exact match
-// 2. Case-insensitive match (if strictFieldNames: false)
-// 3. Alias lookup
-// 4. Transform via naming convention and match
-func (s *Schema) ResolveField(queryField string) (columnName string, field *Field, err error) {
-	if queryField == "" {
-		return "", nil, errors.New("empty field name")
+// WithMinSimilarity returns a copy of the schema whose fuzzy queries match by a minimum
+// similarity between 0 and 1, overriding the fields' MinSimilarity for one request.
+// The copy shares its field definitions with the schema.
+func (s *Schema) WithMinSimilarity(minSimilarity float64) (*Schema, error) {
+	if minSimilarity <= 0 || minSimilarity > 1 {
+		return nil, fmt.Errorf("invalid minimum similarity %v: must be greater than 0 and at most 1", minSimilarity)
 	}
+	copied := *s
+	copied.minSimilarity = minSimilarity
+	return &copied, nil
+}
 
-	// Stage 1: Exact match
-	if f, exists := s.Fields[queryField]; exists {
-		return s.getColumnName(queryField, &f), &f, nil
+// MinSimilarity returns the minimum similarity fuzzy queries on the field match by,
+// or 0 when they match by edit distance
+func (s *Schema) MinSimilarity(field *Field) float64 {
+	if s.minSimilarity > 0 {
+		return s.minSimilarity
 	}
+	return field.MinSimilarity
+}
 
-	// Stage 2: Case-insensitive match (if enabled)
-	if !s.Options.StrictFieldNames {
-		if actualField, exists := s.lowerFieldMap[strings.ToLower(queryField)]; exists {
-			f := s.Fields[actualField]
-			return s.getColumnName(actualField, &f), &f, nil
-		}
+// WithAnchorRegex returns a copy of the schema whose regex values are anchored, or not,
+// regardless of Options.AnchorRegex. The copy shares its field definitions with the schema.
+func (s *Schema) WithAnchorRegex(anchor bool) *Schema {
+	copied := *s
+	copied.anchorRegex = &anchor
+	return &copied
+}
+
+// AnchorRegex reports whether regex values must match the whole value
+func (s *Schema) AnchorRegex() bool {
+	if s.anchorRegex != nil {
+		return *s.anchorRegex
 	}
+	return s.Options.AnchorRegex
+}
 
-	// Stage 3: Alias lookup
-	lookupKey := queryField
-	if !s.Options.StrictFieldNames {
-		lookupKey = strings.ToLower(queryField)
+// WithFieldGroupOperator returns a copy of the schema joining the values of field groups
+// with FieldGroupOr or FieldGroupAnd, regardless of Options.FieldGroupOperator. The copy
+// shares its field definitions with the schema.
+func (s *Schema) WithFieldGroupOperator(operator string) (*Schema, error) {
+	if operator != FieldGroupOr && operator != FieldGroupAnd {
+		return nil, fmt.Errorf("invalid field group operator %q: must be one of: or, and", operator)
 	}
-	if actualField, exists := s.aliasMap[lookupKey]; exists {
-		f := s.Fields[actualField]
-		return s.getColumnName(actualField, &f), &f, nil
+	copied := *s
+	copied.groupOperator = operator
+	return &copied, nil
+}
+
+// FieldGroupOperator returns the operator joining the values of field groups written
+// next to each other, FieldGroupOr or FieldGroupAnd
+func (s *Schema) FieldGroupOperator() string {
+	if s.groupOperator != "" {
+		return s.groupOperator
+	}
+	if s.Options.FieldGroupOperator == FieldGroupAnd {
+		return FieldGroupAnd
 	}
+	return FieldGroupOr
+}
 
-	// Stage 4: Transform via naming convention and match
-	if s.Options.NamingConvention != "" && s.Options.NamingConvention != "none" {
-		transformed := s.transformFieldName(queryField)
-		if transformed != queryField {
-			// Try exact match with transformed name
-			if f, exists := s.Fields[transformed]; exists {
-				return s.getColumnName(transformed, &f), &f, nil
-			}
-			// Try case-insensitive match with transformed name
-			if !s.Options.StrictFieldNames {
-				if actualField, exists := s.lowerFieldMap[strings.ToLower(transformed)]; exists {
-					f := s.Fields[actualField]
-					return s.getColumnName(actualField, &f), &f, nil
-				}
-			}
-		}
+// Fingerprint identifies the definition of a schema: its fields, options and fragments.
+// Unlike registry versions, which count registrations in one process, it is the same for
+// the same definition across restarts and replicas, so state saved for a schema can be
+// told stale after the schema changed.
+func (s *Schema) Fingerprint() string {
+	definition := Schema{Name: s.Name, Fields: s.Fields, Options: s.Options, Fragments: s.Fragments}
+	encoded, err := json.Marshal(definition)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:16])
+}
+
+// Overrides describes the per-request settings of a schema copy made by WithTableAlias,
+// WithMinSimilarity, WithAnchorRegex or WithFieldGroupOperator, so copies that translate
+// differently can be told apart, e.g. in cache keys. It is empty for registered schemas.
+func (s *Schema) Overrides() string {
+	if s.tableAlias == "" && s.minSimilarity == 0 && s.anchorRegex == nil && s.groupOperator == "" {
+		return ""
 	}
+	anchor := ""
+	if s.anchorRegex != nil {
+		anchor = strconv.FormatBool(*s.anchorRegex)
+	}
+	return fmt.Sprintf("alias=%s,minSimilarity=%v,anchorRegex=%s,fieldGroupOperator=%s", s.tableAlias, s.minSimilarity, anchor, s.groupOperator)
+}
 
-	return "", nil, fmt.Errorf("field %q not found in schema %q", queryField, s.Name)
+// TableAlias returns the alias column names are qualified with, if any
+func (s *Schema) TableAlias() string {
+	return s.tableAlias
 }
 
-// getColumnName returns the column name for a field (using explicit column or field name)
-func (s *Schema) getColumnName(fieldName string, field *Field) string {
-	if field.Column != "" {
-		return field.Column
+// Location returns the schema's time zone, UTC when none or an invalid one is set
+func (s *Schema) Location() *time.Location {
+	if s.location != nil {
+		return s.location
 	}
+	return loadLocation(s.Options.Timezone)
+}
 
-	// Apply naming convention transformation if needed
-	if s.Options.NamingConvention == "" || s.Options.NamingConvention == "none" {
-		return fieldName
+// loadLocation loads an IANA time zone, falling back to UTC
+func loadLocation(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// ResolveField resolves a query field name to its actual column name and field definition.
+// Strategies are tried in the order of Options.NameResolution; by default:
+// 1. Exact match
+// 2. Case-insensitive match (if strictFieldNames: false)
+// 3. Alias lookup
+// 4. Transform via naming convention and match
+func (s *Schema) ResolveField(queryField string) (columnName string, field *Field, err error) {
+	if queryField == "" {
+		return "", nil, errors.New("empty field name")
+	}
+
+	fieldName, ok := s.resolveName(queryField, nil)
+	if !ok {
+		return "", nil, &FieldNotFoundError{Field: queryField, Schema: s.Name, schema: s}
+	}
+	f := s.Fields[fieldName]
+	return s.getColumnName(fieldName, &f), &f, nil
+}
+
+// getColumnName returns the column name for a field (using explicit column or field name),
+// qualified with the table alias when one is set
+func (s *Schema) getColumnName(fieldName string, field *Field) string {
+	column := field.Column
+	if column == "" {
+		column = fieldName
+		// Apply naming convention transformation if needed
+		if s.Options.NamingConvention != "" && s.Options.NamingConvention != "none" {
+			column = s.transformFieldName(fieldName)
+		}
 	}
 
-	return s.transformFieldName(fieldName)
+	if s.tableAlias != "" {
+		return s.tableAlias + "." + column
+	}
+	return column
 }
 
 // transformFieldName applies the schema's naming convention to transform a field name
@@ -198,3 +564,45 @@ func IsValidFieldType(ft FieldType) bool {
 		return false
 	}
 }
+
+// AllowsOperator reports whether the field's operator whitelist permits the operator
+func (f *Field) AllowsOperator(operator string) bool {
+	allowed := f.AllowedOperators()
+	return allowed == nil || slices.Contains(allowed, operator)
+}
+
+// AllowedOperators returns the operators the field is restricted to: its whitelist,
+// narrowed to exact matches when it has a codec or a hash. It returns nil when the field
+// allows every operator its type supports.
+func (f *Field) AllowedOperators() []string {
+	if f.Codec == "" && f.Hash == nil {
+		if len(f.Operators) == 0 {
+			return nil
+		}
+		return f.Operators
+	}
+	if len(f.Operators) == 0 {
+		return exactOperators
+	}
+	allowed := []string{}
+	for _, operator := range exactOperators {
+		if slices.Contains(f.Operators, operator) {
+			allowed = append(allowed, operator)
+		}
+	}
+	return allowed
+}
+
+// StoredValue returns the value stored for a value written in a query, when the value is
+// one of the field's value aliases, ignoring case
+func (f *Field) StoredValue(value string) (string, bool) {
+	if stored, ok := f.ValueAliases[value]; ok {
+		return stored, true
+	}
+	for alias, stored := range f.ValueAliases {
+		if strings.EqualFold(alias, value) {
+			return stored, true
+		}
+	}
+	return "", false
+}
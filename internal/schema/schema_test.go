@@ -2,6 +2,7 @@ package schema
 
 import (
 	"testing"
+	"time"
 )
 
 func TestResolveField_ExactMatch(t *testing.T) {
@@ -332,3 +333,63 @@ func TestIsValidFieldType(t *testing.T) {
 		})
 	}
 }
+
+func TestWithTableAlias(t *testing.T) {
+	fields := map[string]Field{
+		"productCode": {Type: TypeText},
+		"status":      {Type: TypeText, Column: "product_status"},
+	}
+	schema := NewSchema("products", fields, SchemaOptions{NamingConvention: "snake_case"})
+
+	aliased, err := schema.WithTableAlias("p")
+	if err != nil {
+		t.Fatalf("WithTableAlias() unexpected error = %v", err)
+	}
+	if aliased.TableAlias() != "p" {
+		t.Errorf("TableAlias() = %v, want p", aliased.TableAlias())
+	}
+
+	for field, want := range map[string]string{"productCode": "p.product_code", "status": "p.product_status", "PRODUCTCODE": "p.product_code"} {
+		column, _, err := aliased.ResolveField(field)
+		if err != nil {
+			t.Fatalf("ResolveField(%q) unexpected error = %v", field, err)
+		}
+		if column != want {
+			t.Errorf("ResolveField(%q) column = %v, want %v", field, column, want)
+		}
+	}
+
+	// The original schema is left unqualified
+	if column, _, _ := schema.ResolveField("productCode"); column != "product_code" {
+		t.Errorf("ResolveField() on original schema column = %v, want product_code", column)
+	}
+
+	for _, alias := range []string{"", "p.q", "1p", "p; DROP TABLE x"} {
+		if _, err := schema.WithTableAlias(alias); err == nil {
+			t.Errorf("WithTableAlias(%q) expected error", alias)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	fields := map[string]Field{"name": {Type: TypeText}, "price": {Type: TypeFloat}}
+	a := NewSchema("products", fields, SchemaOptions{DefaultField: "name"})
+	b := NewSchema("products", map[string]Field{"price": {Type: TypeFloat}, "name": {Type: TypeText}}, SchemaOptions{DefaultField: "name"})
+	b.CreatedAt = a.CreatedAt.Add(time.Hour)
+
+	if a.Fingerprint() == "" || a.Fingerprint() != b.Fingerprint() {
+		t.Errorf("same definitions registered at different times should share a fingerprint: %q, %q", a.Fingerprint(), b.Fingerprint())
+	}
+	aliased, err := a.WithTableAlias("p")
+	if err != nil {
+		t.Fatalf("WithTableAlias() error = %v", err)
+	}
+	if a.Fingerprint() != aliased.Fingerprint() {
+		t.Error("per-request overrides should not change the fingerprint")
+	}
+
+	changed := NewSchema("products", map[string]Field{"name": {Type: TypeText, Column: "product_name"}, "price": {Type: TypeFloat}}, SchemaOptions{DefaultField: "name"})
+	if a.Fingerprint() == changed.Fingerprint() {
+		t.Error("a changed column should change the fingerprint")
+	}
+}
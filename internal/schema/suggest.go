@@ -0,0 +1,233 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// FieldSuggestion is a schema field a partial or misspelled field name may refer to
+type FieldSuggestion = rsearch.FieldSuggestion
+
+// FieldNotFoundError is returned by ResolveField for names matching no field. Its
+// message suggests the closest field, if any.
+type FieldNotFoundError struct {
+	Field  string
+	Schema string
+
+	schema *Schema
+}
+
+func (e *FieldNotFoundError) Error() string {
+	msg := fmt.Sprintf("field %q not found in schema %q", e.Field, e.Schema)
+	if suggestions := e.schema.SuggestFields(e.Field, 1); len(suggestions) > 0 {
+		msg += fmt.Sprintf(", did you mean %s?", suggestions[0].Field)
+	}
+	return msg
+}
+
+// suggestKey is a name a field is suggested for: the field name or one of its aliases
+type suggestKey struct {
+	field string
+	name  string   // as written in the schema
+	key   string   // lowercase, without separators
+	words []string // lowercase words of the name, split at separators and case changes
+}
+
+// fieldMatch is how well a partial name matched a suggestKey
+type fieldMatch struct {
+	match    string
+	rank     int // position of match in suggestion order
+	distance int
+	key      suggestKey
+}
+
+// better reports whether m is a closer match than o
+func (m fieldMatch) better(o fieldMatch) bool {
+	if m.rank != o.rank {
+		return m.rank < o.rank
+	}
+	if m.distance != o.distance {
+		return m.distance < o.distance
+	}
+	return len(m.key.key) < len(o.key.key)
+}
+
+// buildSuggestIndex indexes the field names and aliases by their words, for
+// SuggestFields and the suggestions of FieldNotFoundError
+func (s *Schema) buildSuggestIndex() {
+	keys := make([]suggestKey, 0, len(s.Fields))
+	for fieldName, field := range s.Fields {
+		keys = append(keys, newSuggestKey(fieldName, fieldName))
+		for _, alias := range field.Aliases {
+			keys = append(keys, newSuggestKey(fieldName, alias))
+		}
+	}
+	for alias, fieldName := range s.Options.FieldAliases {
+		if _, ok := s.Fields[fieldName]; ok {
+			keys = append(keys, newSuggestKey(fieldName, alias))
+		}
+	}
+	s.suggestKeys = keys
+}
+
+// newSuggestKey indexes one name of a field
+func newSuggestKey(field, name string) suggestKey {
+	words := strings.FieldsFunc(ToSnakeCase(name), isNameSeparator)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return suggestKey{field: field, name: name, key: strings.Join(words, ""), words: words}
+}
+
+// isNameSeparator reports whether r separates the words of a field name
+func isNameSeparator(r rune) bool {
+	return r == '_' || r == '-' || r == '.' || unicode.IsSpace(r)
+}
+
+// SuggestFields returns up to limit schema fields a partial or misspelled field name
+// may refer to, best match first. Field names and aliases are compared ignoring case
+// and separators, so productCode, product_code and product-code are the same name.
+// Fields match, from best to worst, on the whole name, on its start (productc), on the
+// starts of consecutive words (prodcod), or within a few edits of the name or its start
+// (prodcut): one edit from three letters, two from six.
+func (s *Schema) SuggestFields(partial string, limit int) []FieldSuggestion {
+	query := strings.ToLower(strings.Join(strings.FieldsFunc(partial, isNameSeparator), ""))
+	if query == "" || limit <= 0 {
+		return nil
+	}
+
+	best := make(map[string]fieldMatch)
+	for _, key := range s.suggestKeys {
+		m, ok := matchFieldName(query, key)
+		if !ok {
+			continue
+		}
+		if current, seen := best[key.field]; !seen || m.better(current) {
+			best[key.field] = m
+		}
+	}
+
+	matches := make([]fieldMatch, 0, len(best))
+	for _, m := range best {
+		matches = append(matches, m)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].better(matches[j]) != matches[j].better(matches[i]) {
+			return matches[i].better(matches[j])
+		}
+		return matches[i].key.field < matches[j].key.field
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	suggestions := make([]FieldSuggestion, len(matches))
+	for i, m := range matches {
+		field := s.Fields[m.key.field]
+		suggestions[i] = FieldSuggestion{
+			Field:       m.key.field,
+			Type:        string(field.Type),
+			Description: field.Description,
+			Match:       m.match,
+			Distance:    m.distance,
+		}
+		if m.key.name != m.key.field {
+			suggestions[i].Alias = m.key.name
+		}
+	}
+	return suggestions
+}
+
+// matchFieldName matches a normalized partial name against one name of a field
+func matchFieldName(query string, key suggestKey) (fieldMatch, bool) {
+	switch {
+	case query == key.key:
+		return fieldMatch{match: rsearch.MatchExact, rank: 0, key: key}, true
+	case strings.HasPrefix(key.key, query):
+		return fieldMatch{match: rsearch.MatchPrefix, rank: 1, key: key}, true
+	case abbreviates(query, key.words):
+		return fieldMatch{match: rsearch.MatchAbbreviation, rank: 2, key: key}, true
+	}
+
+	maxEdits := 0
+	switch n := len([]rune(query)); {
+	case n >= 6:
+		maxEdits = 2
+	case n >= 3:
+		maxEdits = 1
+	}
+	if maxEdits == 0 {
+		return fieldMatch{}, false
+	}
+
+	distance := editDistance(query, key.key)
+	if start := []rune(key.key); len(start) > len([]rune(query)) {
+		if d := editDistance(query, string(start[:len([]rune(query))])); d < distance {
+			distance = d
+		}
+	}
+	if distance > maxEdits {
+		return fieldMatch{}, false
+	}
+	return fieldMatch{match: rsearch.MatchFuzzy, rank: 3, distance: distance, key: key}, true
+}
+
+// abbreviates reports whether query is made of the starts of consecutive words, from
+// any word on, such as prodcod for product code or code for product code
+func abbreviates(query string, words []string) bool {
+	for start := range words {
+		if abbreviatesFrom(query, words[start:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// abbreviatesFrom reports whether query is made of the starts of words, in order from
+// the first word
+func abbreviatesFrom(query string, words []string) bool {
+	if query == "" {
+		return true
+	}
+	if len(words) == 0 {
+		return false
+	}
+	word := words[0]
+	for n := min(len(word), len(query)); n > 0; n-- {
+		if query[:n] == word[:n] && abbreviatesFrom(query[n:], words[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// editDistance counts the insertions, deletions, substitutions and transpositions of
+// adjacent letters turning a into b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	rows := make([][]int, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(rb)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(ra)][len(rb)]
+}
@@ -0,0 +1,95 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/pkg/rsearch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema_SuggestFields(t *testing.T) {
+	s := NewSchema("products", map[string]Field{
+		"productCode":  {Type: TypeText, Description: "Catalogue code"},
+		"productName":  {Type: TypeText, Aliases: []string{"title"}},
+		"product_type": {Type: TypeText},
+		"price":        {Type: TypeFloat},
+		"createdAt":    {Type: TypeDateTime},
+	}, SchemaOptions{FieldAliases: map[string]string{"cost": "price"}})
+
+	tests := []struct {
+		partial string
+		want    []FieldSuggestion
+	}{
+		{"product_code", []FieldSuggestion{{Field: "productCode", Type: "text", Description: "Catalogue code", Match: rsearch.MatchExact}}},
+		{"productc", []FieldSuggestion{{Field: "productCode", Type: "text", Description: "Catalogue code", Match: rsearch.MatchPrefix}}},
+		{"prodcod", []FieldSuggestion{{Field: "productCode", Type: "text", Description: "Catalogue code", Match: rsearch.MatchAbbreviation}}},
+		{"created", []FieldSuggestion{{Field: "createdAt", Type: "datetime", Match: rsearch.MatchPrefix}}},
+		{"prodcutCode", []FieldSuggestion{{Field: "productCode", Type: "text", Description: "Catalogue code", Match: rsearch.MatchFuzzy, Distance: 1}}},
+		{"prcie", []FieldSuggestion{{Field: "price", Type: "float", Match: rsearch.MatchFuzzy, Distance: 1}}},
+		{"tit", []FieldSuggestion{{Field: "productName", Type: "text", Match: rsearch.MatchPrefix, Alias: "title"}}},
+		{"COST", []FieldSuggestion{{Field: "price", Type: "float", Match: rsearch.MatchExact, Alias: "cost"}}},
+		{"xyz", []FieldSuggestion{}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.partial, func(t *testing.T) {
+			got := s.SuggestFields(tt.partial, 1)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSchema_SuggestFieldsRanking(t *testing.T) {
+	s := NewSchema("products", map[string]Field{
+		"productCode":  {Type: TypeText, Description: "Catalogue code"},
+		"productName":  {Type: TypeText, Aliases: []string{"title"}},
+		"product_type": {Type: TypeText},
+		"price":        {Type: TypeFloat},
+		"createdAt":    {Type: TypeDateTime},
+	}, SchemaOptions{FieldAliases: map[string]string{"cost": "price"}})
+
+	// Prefix matches come before abbreviations, then the shorter name first
+	var names []string
+	for _, suggestion := range s.SuggestFields("prod", 10) {
+		names = append(names, suggestion.Field)
+	}
+	assert.Equal(t, []string{"productCode", "productName", "product_type"}, names)
+
+	// Abbreviations may start at any word
+	names = nil
+	for _, suggestion := range s.SuggestFields("name", 10) {
+		names = append(names, suggestion.Field)
+	}
+	assert.Equal(t, []string{"productName"}, names)
+
+	assert.Len(t, s.SuggestFields("p", 2), 2)
+}
+
+func TestSchema_ResolveFieldDidYouMean(t *testing.T) {
+	s := NewSchema("products", map[string]Field{
+		"productCode":  {Type: TypeText, Description: "Catalogue code"},
+		"productName":  {Type: TypeText, Aliases: []string{"title"}},
+		"product_type": {Type: TypeText},
+		"price":        {Type: TypeFloat},
+		"createdAt":    {Type: TypeDateTime},
+	}, SchemaOptions{FieldAliases: map[string]string{"cost": "price"}})
+
+	_, _, err := s.ResolveField("prodcutCode")
+	var notFound *FieldNotFoundError
+	require.ErrorAs(t, err, &notFound)
+	assert.Equal(t, "prodcutCode", notFound.Field)
+	assert.EqualError(t, err, `field "prodcutCode" not found in schema "products", did you mean productCode?`)
+
+	_, _, err = s.ResolveField("xyz")
+	assert.EqualError(t, err, `field "xyz" not found in schema "products"`)
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("price", "price"))
+	assert.Equal(t, 1, editDistance("prcie", "price"))
+	assert.Equal(t, 1, editDistance("pric", "price"))
+	assert.Equal(t, 2, editDistance("prxcx", "price"))
+	assert.Equal(t, 3, editDistance("", "abc"))
+}
@@ -4,13 +4,24 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/infiniv/rsearch/pkg/rsearch"
 )
 
 var (
 	// columnNameRegex validates column names: alphanumeric and underscores only
 	columnNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
 
+	// collationNameRegex validates collation names such as utf8mb4_0900_ai_ci or und-x-icu
+	collationNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.@-]*$`)
+
+	// fulltextLanguageRegex validates full-text languages, PostgreSQL text search
+	// configurations such as german or public.german_unaccent, quoted into SQL as literals
+	fulltextLanguageRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
 	// Valid naming conventions
 	validNamingConventions = map[string]bool{
 		"snake_case": true,
@@ -19,6 +30,28 @@ var (
 		"none":       true,
 		"":           true, // Empty is treated as "none"
 	}
+
+	// Valid phrase match modes
+	validPhraseMatchModes = map[string]bool{
+		PhraseExact:    true,
+		PhraseContains: true,
+		PhraseFulltext: true,
+		"":             true, // Empty is treated as "exact"
+	}
+
+	// Valid operator names for field operator whitelists
+	validOperators = map[string]bool{
+		OperatorTerm:       true,
+		OperatorPhrase:     true,
+		OperatorWildcard:   true,
+		OperatorRegex:      true,
+		OperatorRange:      true,
+		OperatorFuzzy:      true,
+		OperatorProximity:  true,
+		OperatorExists:     true,
+		OperatorFieldGroup: true,
+		OperatorIn:         true,
+	}
 )
 
 // ValidateSchema validates a schema for correctness
@@ -64,6 +97,133 @@ func ValidateSchema(s *Schema) error {
 			}
 		}
 
+		// Validate text matching options
+		if field.Collation != "" || field.Unaccent {
+			if field.Type != TypeText {
+				return fmt.Errorf("collation and unaccent are only supported on text fields, field %q is %s", fieldName, field.Type)
+			}
+			if field.Collation != "" && !collationNameRegex.MatchString(field.Collation) {
+				return fmt.Errorf("invalid collation %q for field %q", field.Collation, fieldName)
+			}
+		}
+
+		if field.PrefixRange && field.Type != TypeText {
+			return fmt.Errorf("prefixRange is only supported on text fields, field %q is %s", fieldName, field.Type)
+		}
+
+		// Validate phrase match mode
+		if !validPhraseMatchModes[field.PhraseMatch] {
+			return fmt.Errorf("invalid phrase match mode %q for field %q: must be one of: exact, contains, fulltext", field.PhraseMatch, fieldName)
+		}
+		if field.PhraseMatch != "" && field.PhraseMatch != PhraseExact && field.Type != TypeText {
+			return fmt.Errorf("phrase match mode %q is only supported on text fields, field %q is %s", field.PhraseMatch, fieldName, field.Type)
+		}
+
+		// Validate full-text options
+		if field.Fulltext != (FulltextOptions{}) && field.Type != TypeText {
+			return fmt.Errorf("fulltext options are only supported on text fields, field %q is %s", fieldName, field.Type)
+		}
+		if field.Fulltext.Language != "" && !fulltextLanguageRegex.MatchString(field.Fulltext.Language) {
+			return fmt.Errorf("invalid fulltext language %q for field %q", field.Fulltext.Language, fieldName)
+		}
+		if field.Fulltext.Parser != "" && field.Fulltext.Parser != FulltextParserNgram {
+			return fmt.Errorf("invalid fulltext parser %q for field %q: must be ngram or empty for the built-in parser", field.Fulltext.Parser, fieldName)
+		}
+
+		// Validate fuzzy similarity threshold
+		if field.MinSimilarity < 0 || field.MinSimilarity > 1 {
+			return fmt.Errorf("invalid minSimilarity %v for field %q: must be between 0 and 1", field.MinSimilarity, fieldName)
+		}
+		if field.MinSimilarity > 0 && field.Type != TypeText {
+			return fmt.Errorf("minSimilarity is only supported on text fields, field %q is %s", fieldName, field.Type)
+		}
+
+		// Validate codec and hash; encoded values only match whole values
+		if field.Hash != nil {
+			if field.Codec != "" {
+				return fmt.Errorf("field %q cannot have both a codec and a hash", fieldName)
+			}
+			if field.Hash.Algorithm != "" && field.Hash.Algorithm != HashSHA256 && field.Hash.Algorithm != HashSHA512 {
+				return fmt.Errorf("invalid hash algorithm %q for field %q: must be one of: sha256, sha512", field.Hash.Algorithm, fieldName)
+			}
+			if field.Hash.Key == "" {
+				return fmt.Errorf("hashed field %q needs a key", fieldName)
+			}
+		}
+		if field.Codec != "" || field.Hash != nil {
+			if field.Type != TypeText {
+				return fmt.Errorf("codecs and hashes are only supported on text fields, field %q is %s", fieldName, field.Type)
+			}
+			if field.Collation != "" || field.Unaccent || field.PhraseMatch != "" && field.PhraseMatch != PhraseExact || field.MinSimilarity > 0 {
+				return fmt.Errorf("field %q is encoded and cannot set collation, unaccent, minSimilarity or a phrase match mode other than exact", fieldName)
+			}
+			if fieldName == s.Options.DefaultField {
+				return fmt.Errorf("default field %q cannot be encoded, as free text is not matched exactly", fieldName)
+			}
+		}
+
+		// Validate partial indexes; predicates are parsed by translator.ValidatePartialIndexes
+		seenIndexes := make(map[string]bool)
+		for _, index := range field.PartialIndexes {
+			if index.Name == "" {
+				return fmt.Errorf("partial index of field %q needs a name", fieldName)
+			}
+			if seenIndexes[index.Name] {
+				return fmt.Errorf("duplicate partial index %q on field %q", index.Name, fieldName)
+			}
+			seenIndexes[index.Name] = true
+			if strings.TrimSpace(index.Where) == "" {
+				return fmt.Errorf("partial index %q of field %q has an empty predicate", index.Name, fieldName)
+			}
+		}
+
+		// Validate dictionary; values are compared as written
+		if field.Dictionary != "" && field.Type != TypeText && field.Type != TypeInteger {
+			return fmt.Errorf("dictionaries are only supported on text and integer fields, field %q is %s", fieldName, field.Type)
+		}
+
+		// Validate value aliases; a stored value may not be the alias of another, so
+		// resolving aliases twice binds the same values as resolving them once
+		if len(field.ValueAliases) > 0 && field.Type != TypeText && field.Type != TypeInteger {
+			return fmt.Errorf("value aliases are only supported on text and integer fields, field %q is %s", fieldName, field.Type)
+		}
+		seenValueAliases := make(map[string]string)
+		for alias, stored := range field.ValueAliases {
+			if alias == "" || stored == "" {
+				return fmt.Errorf("value aliases of field %q cannot be empty", fieldName)
+			}
+			if strings.ContainsAny(stored, "*?") {
+				return fmt.Errorf("value %q stored for alias %q of field %q cannot contain wildcards", stored, alias, fieldName)
+			}
+			if field.Type == TypeInteger {
+				if _, err := strconv.ParseInt(stored, 10, 64); err != nil {
+					return fmt.Errorf("value %q stored for alias %q of integer field %q is not an integer", stored, alias, fieldName)
+				}
+			}
+			normalizedAlias := strings.ToLower(alias)
+			if existing, exists := seenValueAliases[normalizedAlias]; exists {
+				return fmt.Errorf("duplicate value alias %q found on field %q, also written %q", alias, fieldName, existing)
+			}
+			seenValueAliases[normalizedAlias] = alias
+		}
+		for alias, stored := range field.ValueAliases {
+			if next, ok := field.StoredValue(stored); ok && next != stored {
+				return fmt.Errorf("value %q stored for alias %q of field %q is itself an alias of %q", stored, alias, fieldName, next)
+			}
+		}
+
+		// Validate value limits
+		if field.MaxLength < 0 || field.MaxValues < 0 {
+			return fmt.Errorf("maxLength and maxValues of field %q must not be negative", fieldName)
+		}
+
+		// Validate operator whitelist
+		for _, operator := range field.Operators {
+			if !validOperators[operator] {
+				return fmt.Errorf("invalid operator %q for field %q: must be one of: term, phrase, wildcard, regex, range, fuzzy, proximity, exists, field_group, in", operator, fieldName)
+			}
+		}
+
 		// Validate aliases
 		for _, alias := range field.Aliases {
 			if alias == "" {
@@ -84,6 +244,13 @@ func ValidateSchema(s *Schema) error {
 		}
 	}
 
+	// Validate fragments; names must not shadow fields, which resolve first
+	for name, fragment := range s.Fragments {
+		if err := validateFragment(s, name, fragment); err != nil {
+			return err
+		}
+	}
+
 	// Validate naming convention
 	if !validNamingConventions[s.Options.NamingConvention] {
 		return fmt.Errorf("invalid naming convention %q: must be one of: snake_case, camelCase, PascalCase, none", s.Options.NamingConvention)
@@ -96,5 +263,181 @@ func ValidateSchema(s *Schema) error {
 		}
 	}
 
+	// Validate time zone
+	if s.Options.Timezone != "" {
+		if _, err := time.LoadLocation(s.Options.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %q: must be an IANA time zone such as Europe/Berlin", s.Options.Timezone)
+		}
+	}
+
+	// Validate analyzer
+	if s.Options.Analyzer.MinTermLength < 0 {
+		return fmt.Errorf("invalid analyzer minTermLength %d: must not be negative", s.Options.Analyzer.MinTermLength)
+	}
+	for _, stopword := range s.Options.Analyzer.Stopwords {
+		if strings.TrimSpace(stopword) == "" {
+			return errors.New("analyzer stopwords cannot be empty")
+		}
+	}
+	seenSynonyms := make(map[string]bool)
+	for _, group := range s.Options.Analyzer.Synonyms {
+		if len(group) < 2 {
+			return fmt.Errorf("analyzer synonym group %v must list at least two terms", group)
+		}
+		for _, term := range group {
+			if strings.TrimSpace(term) == "" {
+				return errors.New("analyzer synonyms cannot be empty")
+			}
+			if seenSynonyms[strings.ToLower(term)] {
+				return fmt.Errorf("analyzer synonym %q appears in more than one group", term)
+			}
+			seenSynonyms[strings.ToLower(term)] = true
+		}
+	}
+	if s.Options.Analyzer.OriginalWeight < 0 || s.Options.Analyzer.SynonymWeight < 0 {
+		return errors.New("analyzer synonym weights must not be negative")
+	}
+
+	// Validate execution limits
+	if s.Options.Execution.TimeoutMs < 0 || s.Options.Execution.MaxRows < 0 {
+		return errors.New("execution timeoutMs and maxRows must not be negative")
+	}
+
+	// Validate name resolution
+	seenStrategies := make(map[string]bool)
+	for _, strategy := range s.Options.NameResolution {
+		if strings.TrimSpace(strategy) == "" {
+			return errors.New("name resolution strategies cannot be empty")
+		}
+		if seenStrategies[strategy] {
+			return fmt.Errorf("name resolution strategy %q is listed more than once", strategy)
+		}
+		seenStrategies[strategy] = true
+	}
+	for alias, fieldName := range s.Options.FieldAliases {
+		if strings.TrimSpace(alias) == "" {
+			return errors.New("field aliases cannot be empty")
+		}
+		if _, exists := s.Fields[fieldName]; !exists {
+			return fmt.Errorf("field alias %q refers to unknown field %q", alias, fieldName)
+		}
+	}
+
+	switch s.Options.ReversedRanges {
+	case "", ReversedRangesReject, ReversedRangesSwap:
+	default:
+		return fmt.Errorf("invalid reversedRanges %q: must be one of: reject, swap", s.Options.ReversedRanges)
+	}
+
+	switch s.Options.UnknownFields {
+	case "", UnknownFieldsError, UnknownFieldsDrop:
+	case UnknownFieldsDefaultField:
+		if s.Options.DefaultField == "" {
+			return fmt.Errorf("unknownFields %q requires a defaultField", s.Options.UnknownFields)
+		}
+	default:
+		return fmt.Errorf("invalid unknownFields %q: must be one of: error, drop, default_field", s.Options.UnknownFields)
+	}
+
+	switch s.Options.FieldGroupOperator {
+	case "", FieldGroupOr, FieldGroupAnd:
+	default:
+		return fmt.Errorf("invalid fieldGroupOperator %q: must be one of: or, and", s.Options.FieldGroupOperator)
+	}
+
+	if err := s.Options.NumberFormat.validate(); err != nil {
+		return err
+	}
+
+	if s.Options.MaxListSize < 0 {
+		return errors.New("maxListSize must not be negative")
+	}
+	if s.Options.MaxValueLength < 0 {
+		return errors.New("maxValueLength must not be negative")
+	}
+
+	// Validate sorting
+	seenSort := make(map[string]bool)
+	for _, sort := range s.Options.DefaultSort {
+		if _, exists := s.Fields[sort.Field]; !exists {
+			return fmt.Errorf("default sort field %q does not exist in schema", sort.Field)
+		}
+		if seenSort[sort.Field] {
+			return fmt.Errorf("default sort field %q is listed more than once", sort.Field)
+		}
+		seenSort[sort.Field] = true
+		switch sort.Order {
+		case "", rsearch.SortAsc, rsearch.SortDesc:
+		default:
+			return fmt.Errorf("invalid default sort order %q for field %q: must be one of: asc, desc", sort.Order, sort.Field)
+		}
+	}
+	if s.Options.TieBreaker != "" {
+		if _, exists := s.Fields[s.Options.TieBreaker]; !exists {
+			return fmt.Errorf("tie-breaker field %q does not exist in schema", s.Options.TieBreaker)
+		}
+	}
+
+	// Validate temporal fields
+	if s.Options.Temporal != (TemporalOptions{}) {
+		for _, name := range []string{s.Options.Temporal.ValidFrom, s.Options.Temporal.ValidTo} {
+			field, exists := s.Fields[name]
+			if !exists {
+				return fmt.Errorf("temporal field %q does not exist in schema", name)
+			}
+			if field.Type != TypeDate && field.Type != TypeDateTime {
+				return fmt.Errorf("temporal field %q must be of type date or datetime, got %s", name, field.Type)
+			}
+		}
+		if s.Options.Temporal.ValidFrom == s.Options.Temporal.ValidTo {
+			return fmt.Errorf("temporal fields validFrom and validTo must differ, both are %q", s.Options.Temporal.ValidFrom)
+		}
+	}
+
+	// Validate default filters
+	seenFilters := make(map[string]bool)
+	for _, filter := range s.Options.DefaultFilters {
+		if filter.Name == "" {
+			return errors.New("default filter name cannot be empty")
+		}
+		if seenFilters[filter.Name] {
+			return fmt.Errorf("duplicate default filter %q", filter.Name)
+		}
+		seenFilters[filter.Name] = true
+
+		// Query syntax is checked by translator.ValidateDefaultFilters, as the schema package
+		// cannot depend on the parser
+		if strings.TrimSpace(filter.Query) == "" {
+			return fmt.Errorf("default filter %q has an empty query", filter.Name)
+		}
+	}
+
+	return nil
+}
+
+// validate checks the separators of a number format
+func (f NumberFormat) validate() error {
+	switch f.Decimal {
+	case "", ".", ",":
+	case NumberDecimalAuto:
+		if f.Grouping == "." || f.Grouping == "," {
+			return fmt.Errorf("invalid numberFormat grouping %q: decimal auto already reads . and , as grouping", f.Grouping)
+		}
+	default:
+		return fmt.Errorf(`invalid numberFormat decimal %q: must be ".", "," or "auto"`, f.Decimal)
+	}
+
+	switch f.Grouping {
+	case "", ",", ".", " ", "'":
+	default:
+		return fmt.Errorf(`invalid numberFormat grouping %q: must be ",", ".", " " or "'"`, f.Grouping)
+	}
+	decimal := f.Decimal
+	if decimal == "" {
+		decimal = "."
+	}
+	if f.Grouping == decimal {
+		return fmt.Errorf("numberFormat grouping %q must differ from the decimal separator", f.Grouping)
+	}
 	return nil
 }
@@ -1,6 +1,7 @@
 package schema
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -210,6 +211,46 @@ func TestValidateSchema_InvalidColumnName(t *testing.T) {
 	}
 }
 
+func TestValidateSchema_TextMatchingOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   Field
+		wantErr bool
+	}{
+		{"unaccent text", Field{Type: TypeText, Unaccent: true}, false},
+		{"mysql collation", Field{Type: TypeText, Collation: "utf8mb4_0900_ai_ci"}, false},
+		{"icu collation", Field{Type: TypeText, Collation: "und-x-icu"}, false},
+		{"collation with quote", Field{Type: TypeText, Collation: `x" = 1 --`}, true},
+		{"collation with space", Field{Type: TypeText, Collation: "en US"}, true},
+		{"unaccent on integer", Field{Type: TypeInteger, Unaccent: true}, true},
+		{"collation on date", Field{Type: TypeDate, Collation: "NOCASE"}, true},
+		{"contains phrases", Field{Type: TypeText, PhraseMatch: PhraseContains}, false},
+		{"fulltext phrases", Field{Type: TypeText, PhraseMatch: PhraseFulltext}, false},
+		{"exact phrases on integer", Field{Type: TypeInteger, PhraseMatch: PhraseExact}, false},
+		{"unknown phrase mode", Field{Type: TypeText, PhraseMatch: "fuzzy"}, true},
+		{"contains phrases on integer", Field{Type: TypeInteger, PhraseMatch: PhraseContains}, true},
+		{"prefix range", Field{Type: TypeText, PrefixRange: true}, false},
+		{"prefix range on integer", Field{Type: TypeInteger, PrefixRange: true}, true},
+		{"operator whitelist", Field{Type: TypeText, Operators: []string{OperatorTerm, OperatorFieldGroup}}, false},
+		{"unknown operator", Field{Type: TypeText, Operators: []string{"between"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &Schema{
+				Name:    "test",
+				Fields:  map[string]Field{"field1": tt.field},
+				Options: SchemaOptions{},
+			}
+
+			err := ValidateSchema(schema)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSchema() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidateSchema_InvalidNamingConvention(t *testing.T) {
 	schema := &Schema{
 		Name: "test",
@@ -227,6 +268,276 @@ func TestValidateSchema_InvalidNamingConvention(t *testing.T) {
 	}
 }
 
+func TestValidateSchema_NegativeExecutionLimits(t *testing.T) {
+	for _, limits := range []ExecutionLimits{{TimeoutMs: -1}, {MaxRows: -1}} {
+		schema := &Schema{
+			Name:    "test",
+			Fields:  map[string]Field{"field1": {Type: TypeText}},
+			Options: SchemaOptions{Execution: limits},
+		}
+
+		if err := ValidateSchema(schema); err == nil {
+			t.Errorf("ValidateSchema() expected error for execution limits %+v, got nil", limits)
+		}
+	}
+}
+
+func TestValidateSchema_NegativeMaxListSize(t *testing.T) {
+	schema := &Schema{
+		Name:    "test",
+		Fields:  map[string]Field{"field1": {Type: TypeText}},
+		Options: SchemaOptions{MaxListSize: -1},
+	}
+
+	if err := ValidateSchema(schema); err == nil {
+		t.Error("ValidateSchema() expected error for negative maxListSize, got nil")
+	}
+}
+
+func TestValidateSchema_NegativeValueLimits(t *testing.T) {
+	schemas := []*Schema{
+		{Name: "test", Fields: map[string]Field{"field1": {Type: TypeText}}, Options: SchemaOptions{MaxValueLength: -1}},
+		{Name: "test", Fields: map[string]Field{"field1": {Type: TypeText, MaxLength: -1}}},
+		{Name: "test", Fields: map[string]Field{"field1": {Type: TypeText, MaxValues: -1}}},
+	}
+
+	for _, schema := range schemas {
+		if err := ValidateSchema(schema); err == nil {
+			t.Errorf("ValidateSchema() expected error for negative value limits, got nil")
+		}
+	}
+}
+
+func TestValidateSchema_ReversedRanges(t *testing.T) {
+	for _, value := range []string{"", ReversedRangesReject, ReversedRangesSwap} {
+		schema := &Schema{
+			Name:    "test",
+			Fields:  map[string]Field{"field1": {Type: TypeText}},
+			Options: SchemaOptions{ReversedRanges: value},
+		}
+
+		if err := ValidateSchema(schema); err != nil {
+			t.Errorf("ValidateSchema() unexpected error for reversedRanges %q: %v", value, err)
+		}
+	}
+
+	schema := &Schema{
+		Name:    "test",
+		Fields:  map[string]Field{"field1": {Type: TypeText}},
+		Options: SchemaOptions{ReversedRanges: "ignore"},
+	}
+	if err := ValidateSchema(schema); err == nil {
+		t.Error("ValidateSchema() expected error for reversedRanges \"ignore\", got nil")
+	}
+}
+
+func TestValidateSchema_UnknownFields(t *testing.T) {
+	for _, value := range []string{"", UnknownFieldsError, UnknownFieldsDrop, UnknownFieldsDefaultField} {
+		schema := &Schema{
+			Name:    "test",
+			Fields:  map[string]Field{"field1": {Type: TypeText}},
+			Options: SchemaOptions{UnknownFields: value, DefaultField: "field1"},
+		}
+
+		if err := ValidateSchema(schema); err != nil {
+			t.Errorf("ValidateSchema() unexpected error for unknownFields %q: %v", value, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		options SchemaOptions
+	}{
+		{"unknown policy", SchemaOptions{UnknownFields: "ignore"}},
+		{"default field missing", SchemaOptions{UnknownFields: UnknownFieldsDefaultField}},
+	}
+	for _, tt := range tests {
+		schema := &Schema{
+			Name:    "test",
+			Fields:  map[string]Field{"field1": {Type: TypeText}},
+			Options: tt.options,
+		}
+		if err := ValidateSchema(schema); err == nil {
+			t.Errorf("ValidateSchema() expected error for %s, got nil", tt.name)
+		}
+	}
+}
+
+func TestValidateSchema_FieldGroupOperator(t *testing.T) {
+	for _, value := range []string{"", FieldGroupOr, FieldGroupAnd} {
+		schema := &Schema{
+			Name:    "test",
+			Fields:  map[string]Field{"field1": {Type: TypeText}},
+			Options: SchemaOptions{FieldGroupOperator: value},
+		}
+
+		if err := ValidateSchema(schema); err != nil {
+			t.Errorf("ValidateSchema() unexpected error for fieldGroupOperator %q: %v", value, err)
+		}
+	}
+
+	schema := &Schema{
+		Name:    "test",
+		Fields:  map[string]Field{"field1": {Type: TypeText}},
+		Options: SchemaOptions{FieldGroupOperator: "AND"},
+	}
+	if err := ValidateSchema(schema); err == nil {
+		t.Error("ValidateSchema() expected error for fieldGroupOperator \"AND\", got nil")
+	}
+}
+
+func TestValidateSchema_NumberFormat(t *testing.T) {
+	valid := []NumberFormat{
+		{},
+		{Decimal: ",", Grouping: "."},
+		{Decimal: ".", Grouping: ","},
+		{Grouping: "'"},
+		{Decimal: NumberDecimalAuto},
+		{Decimal: NumberDecimalAuto, Grouping: " "},
+	}
+	for _, format := range valid {
+		schema := &Schema{
+			Name:    "test",
+			Fields:  map[string]Field{"field1": {Type: TypeFloat}},
+			Options: SchemaOptions{NumberFormat: format},
+		}
+		if err := ValidateSchema(schema); err != nil {
+			t.Errorf("ValidateSchema() unexpected error for numberFormat %+v: %v", format, err)
+		}
+	}
+
+	invalid := []NumberFormat{
+		{Decimal: ";"},
+		{Grouping: "_"},
+		{Grouping: "."},
+		{Decimal: ",", Grouping: ","},
+		{Decimal: NumberDecimalAuto, Grouping: ","},
+	}
+	for _, format := range invalid {
+		schema := &Schema{
+			Name:    "test",
+			Fields:  map[string]Field{"field1": {Type: TypeFloat}},
+			Options: SchemaOptions{NumberFormat: format},
+		}
+		if err := ValidateSchema(schema); err == nil {
+			t.Errorf("ValidateSchema() expected error for numberFormat %+v, got nil", format)
+		}
+	}
+}
+
+func TestValidateSchema_MinSimilarity(t *testing.T) {
+	tests := []struct {
+		name  string
+		field Field
+	}{
+		{"above one", Field{Type: TypeText, MinSimilarity: 1.5}},
+		{"negative", Field{Type: TypeText, MinSimilarity: -0.5}},
+		{"not text", Field{Type: TypeInteger, MinSimilarity: 0.5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &Schema{Name: "test", Fields: map[string]Field{"field1": tt.field}}
+			if err := ValidateSchema(schema); err == nil {
+				t.Error("ValidateSchema() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestValidateSchema_PartialIndexes(t *testing.T) {
+	tests := []struct {
+		name    string
+		indexes []PartialIndex
+		wantErr string
+	}{
+		{"valid", []PartialIndex{{Name: "live", Where: "NOT _exists_:deleted"}, {Name: "open", Where: "status:open"}}, ""},
+		{"no name", []PartialIndex{{Where: "status:open"}}, "needs a name"},
+		{"duplicate", []PartialIndex{{Name: "live", Where: "status:a"}, {Name: "live", Where: "status:b"}}, "duplicate partial index"},
+		{"no predicate", []PartialIndex{{Name: "live", Where: " "}}, "empty predicate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &Schema{Name: "test", Fields: map[string]Field{
+				"status":  {Type: TypeText, PartialIndexes: tt.indexes},
+				"deleted": {Type: TypeDateTime},
+			}}
+			err := ValidateSchema(schema)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateSchema() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateSchema() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSchema_Fulltext(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   Field
+		wantErr string
+	}{
+		{"valid", Field{Type: TypeText, Fulltext: FulltextOptions{Language: "german", Parser: FulltextParserNgram}}, ""},
+		{"schema qualified", Field{Type: TypeText, Fulltext: FulltextOptions{Language: "public.german_unaccent"}}, ""},
+		{"invalid language", Field{Type: TypeText, Fulltext: FulltextOptions{Language: "english'); DROP TABLE x; --"}}, "invalid fulltext language"},
+		{"invalid parser", Field{Type: TypeText, Fulltext: FulltextOptions{Parser: "mecab"}}, "invalid fulltext parser"},
+		{"not text", Field{Type: TypeInteger, Fulltext: FulltextOptions{Language: "german"}}, "only supported on text fields"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSchema(&Schema{Name: "test", Fields: map[string]Field{"body": tt.field}})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateSchema() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateSchema() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSchema_ValueAliases(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   Field
+		wantErr string
+	}{
+		{"valid", Field{Type: TypeText, ValueAliases: map[string]string{"california": "ca", "CA": "ca"}}, ""},
+		{"integer", Field{Type: TypeInteger, ValueAliases: map[string]string{"active": "1"}}, ""},
+		{"not an integer", Field{Type: TypeInteger, ValueAliases: map[string]string{"active": "yes"}}, "is not an integer"},
+		{"unsupported type", Field{Type: TypeBoolean, ValueAliases: map[string]string{"yes": "true"}}, "only supported on text and integer fields"},
+		{"empty", Field{Type: TypeText, ValueAliases: map[string]string{"california": ""}}, "cannot be empty"},
+		{"wildcard", Field{Type: TypeText, ValueAliases: map[string]string{"any": "*"}}, "cannot contain wildcards"},
+		{"duplicate", Field{Type: TypeText, ValueAliases: map[string]string{"Canada": "can", "canada": "ca"}}, "duplicate value alias"},
+		{"chained", Field{Type: TypeText, ValueAliases: map[string]string{"california": "cal", "cal": "ca"}}, "is itself an alias"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSchema(&Schema{Name: "test", Fields: map[string]Field{"region": tt.field}})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateSchema() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateSchema() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidateSchema_DefaultFieldNotFound(t *testing.T) {
 	schema := &Schema{
 		Name: "test",
@@ -243,3 +554,166 @@ func TestValidateSchema_DefaultFieldNotFound(t *testing.T) {
 		t.Error("ValidateSchema() expected error for non-existent default field, got nil")
 	}
 }
+
+func TestValidateSchema_Timezone(t *testing.T) {
+	for timezone, wantErr := range map[string]bool{
+		"":              false,
+		"UTC":           false,
+		"Europe/Berlin": false,
+		"Mars/Olympus":  true,
+	} {
+		schema := &Schema{
+			Name:    "test",
+			Fields:  map[string]Field{"created_at": {Type: TypeDateTime}},
+			Options: SchemaOptions{Timezone: timezone},
+		}
+		if err := ValidateSchema(schema); (err != nil) != wantErr {
+			t.Errorf("ValidateSchema() with timezone %q error = %v, wantErr %v", timezone, err, wantErr)
+		}
+	}
+}
+
+func TestValidateSchema_Analyzer(t *testing.T) {
+	tests := []struct {
+		name     string
+		analyzer AnalyzerOptions
+		wantErr  bool
+	}{
+		{"stopwords and min length", AnalyzerOptions{Stopwords: []string{"the", "of"}, MinTermLength: 2}, false},
+		{"empty stopword", AnalyzerOptions{Stopwords: []string{"the", " "}}, true},
+		{"negative min length", AnalyzerOptions{MinTermLength: -1}, true},
+		{"synonyms with weights", AnalyzerOptions{Synonyms: [][]string{{"laptop", "notebook"}}, OriginalWeight: 2, SynonymWeight: 0.5}, false},
+		{"single term synonym group", AnalyzerOptions{Synonyms: [][]string{{"laptop"}}}, true},
+		{"empty synonym", AnalyzerOptions{Synonyms: [][]string{{"laptop", ""}}}, true},
+		{"synonym in two groups", AnalyzerOptions{Synonyms: [][]string{{"laptop", "notebook"}, {"Notebook", "journal"}}}, true},
+		{"negative synonym weight", AnalyzerOptions{Synonyms: [][]string{{"laptop", "notebook"}}, SynonymWeight: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &Schema{
+				Name:    "test",
+				Fields:  map[string]Field{"body": {Type: TypeText}},
+				Options: SchemaOptions{Analyzer: tt.analyzer},
+			}
+			if err := ValidateSchema(schema); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSchema() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSchema_Sort(t *testing.T) {
+	tests := []struct {
+		name    string
+		options SchemaOptions
+		wantErr bool
+	}{
+		{"default sort and tie-breaker", SchemaOptions{DefaultSort: []SortField{{Field: "createdAt", Order: "desc"}, {Field: "title"}}, TieBreaker: "id"}, false},
+		{"unknown default sort field", SchemaOptions{DefaultSort: []SortField{{Field: "missing"}}}, true},
+		{"default sort field listed twice", SchemaOptions{DefaultSort: []SortField{{Field: "title"}, {Field: "title", Order: "desc"}}}, true},
+		{"invalid default sort order", SchemaOptions{DefaultSort: []SortField{{Field: "title", Order: "DESC"}}}, true},
+		{"unknown tie-breaker", SchemaOptions{TieBreaker: "missing"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &Schema{
+				Name: "test",
+				Fields: map[string]Field{
+					"id":        {Type: TypeInteger},
+					"title":     {Type: TypeText},
+					"createdAt": {Type: TypeDate},
+				},
+				Options: tt.options,
+			}
+			if err := ValidateSchema(schema); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSchema() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSchema_Temporal(t *testing.T) {
+	tests := []struct {
+		name     string
+		temporal TemporalOptions
+		wantErr  bool
+	}{
+		{"date and datetime fields", TemporalOptions{ValidFrom: "validFrom", ValidTo: "validTo"}, false},
+		{"unknown field", TemporalOptions{ValidFrom: "validFrom", ValidTo: "missing"}, true},
+		{"missing validTo", TemporalOptions{ValidFrom: "validFrom"}, true},
+		{"text field", TemporalOptions{ValidFrom: "title", ValidTo: "validTo"}, true},
+		{"same field twice", TemporalOptions{ValidFrom: "validTo", ValidTo: "validTo"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &Schema{
+				Name: "test",
+				Fields: map[string]Field{
+					"title":     {Type: TypeText},
+					"validFrom": {Type: TypeDate},
+					"validTo":   {Type: TypeDateTime},
+				},
+				Options: SchemaOptions{Temporal: tt.temporal},
+			}
+			if err := ValidateSchema(schema); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSchema() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSchema_DefaultFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters []DefaultFilter
+		wantErr bool
+	}{
+		{
+			name: "valid filters",
+			filters: []DefaultFilter{
+				{Name: "not_deleted", Query: "NOT _exists_:deleted_at"},
+				{Name: "active", Query: "is_active:true"},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "empty name",
+			filters: []DefaultFilter{{Query: "is_active:true"}},
+			wantErr: true,
+		},
+		{
+			name: "duplicate name",
+			filters: []DefaultFilter{
+				{Name: "active", Query: "is_active:true"},
+				{Name: "active", Query: "is_active:false"},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "empty query",
+			filters: []DefaultFilter{{Name: "active", Query: "  "}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &Schema{
+				Name: "test",
+				Fields: map[string]Field{
+					"is_active":  {Type: TypeBoolean},
+					"deleted_at": {Type: TypeDate},
+				},
+				Options: SchemaOptions{DefaultFilters: tt.filters},
+			}
+
+			err := ValidateSchema(schema)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSchema() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
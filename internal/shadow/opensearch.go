@@ -0,0 +1,107 @@
+package shadow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/infiniv/rsearch/internal/executor"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// OpenSearch counts the documents matching a query string with the _count API of an
+// OpenSearch or Elasticsearch cluster
+type OpenSearch struct {
+	url      string
+	indices  map[string]string // schema name -> index; the schema name by default
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewOpenSearch creates a counter for the cluster at baseURL. indices maps schema names,
+// matched case-insensitively, to the indices or aliases holding their documents.
+func NewOpenSearch(baseURL string, indices map[string]string, client *http.Client) *OpenSearch {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &OpenSearch{
+		url:     strings.TrimSuffix(baseURL, "/"),
+		indices: indices,
+		client:  client,
+	}
+}
+
+// WithBasicAuth authenticates requests to the cluster
+func (o *OpenSearch) WithBasicAuth(username, password string) *OpenSearch {
+	o.username = username
+	o.password = password
+	return o
+}
+
+// Count runs the query string, with the filters ANDed in as filter clauses, and returns
+// the number of matching documents. The schema's default field is the query's default
+// field.
+func (o *OpenSearch) Count(ctx context.Context, s *schema.Schema, query string, filters []string) (int64, error) {
+	must := map[string]interface{}{"query": query}
+	if s.Options.DefaultField != "" {
+		must["default_field"] = s.Options.DefaultField
+	}
+	clauses := make([]interface{}, len(filters))
+	for i, filter := range filters {
+		clauses[i] = map[string]interface{}{"query_string": map[string]interface{}{"query": filter}}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must":   []interface{}{map[string]interface{}{"query_string": must}},
+				"filter": clauses,
+			},
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode count request: %w", err)
+	}
+
+	endpoint := o.url + "/" + url.PathEscape(o.index(s.Name)) + "/_count"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create count request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.username != "" {
+		req.SetBasicAuth(o.username, o.password)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("count request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return 0, fmt.Errorf("count responded with status %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+
+	var result struct {
+		Count *int64 `json:"count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("failed to decode count response: %w", err)
+	}
+	if result.Count == nil {
+		return 0, fmt.Errorf("count response has no count")
+	}
+	return *result.Count, nil
+}
+
+// index returns the index of a schema
+func (o *OpenSearch) index(schemaName string) string {
+	return executor.Lookup(o.indices, schemaName)
+}
@@ -0,0 +1,237 @@
+// Package shadow compares translations with the OpenSearch cluster they replace.
+// In shadow mode every translation is counted on the database by the executor, and the
+// query as the caller wrote it is counted on OpenSearch, in the background; queries whose
+// counts diverge are logged and counted in metrics, so teams migrating off OpenSearch
+// can build confidence in the translations before cutting over.
+package shadow
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/observability"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+)
+
+// Executor counts the rows a translation matches, as *executor.Executor does
+type Executor interface {
+	Count(ctx context.Context, s *schema.Schema, output *translator.TranslatorOutput) (int64, error)
+}
+
+// Counter counts the documents a query string matches, with filters ANDed in
+type Counter interface {
+	Count(ctx context.Context, s *schema.Schema, query string, filters []string) (int64, error)
+}
+
+// Outcomes of comparisons, the result label of rsearch_shadow_comparisons_total
+const (
+	ResultMatch    = "match"    // both counts are equal
+	ResultDiverged = "diverged" // the counts differ
+	ResultError    = "error"    // either count failed
+	ResultDropped  = "dropped"  // the queue was full
+)
+
+// Defaults used by NewComparer
+const (
+	DefaultTimeout   = 5 * time.Second
+	DefaultQueueSize = 100
+)
+
+// Comparison is a translated query to compare
+type Comparison struct {
+	Schema   *schema.Schema
+	Database string   // dialect of the translation
+	Query    string   // the query as the caller wrote it
+	Filters  []string // queries of the default filters ANDed into the translation
+	Output   *translator.TranslatorOutput
+
+	// LogFields are logged with divergences, holding the query as the query logging
+	// mode allows
+	LogFields map[string]interface{}
+}
+
+// Result holds the counts of a comparison
+type Result struct {
+	SQLCount        int64
+	OpenSearchCount int64
+}
+
+// Diverged reports whether the counts differ
+func (r Result) Diverged() bool {
+	return r.SQLCount != r.OpenSearchCount
+}
+
+// Comparer runs comparisons on a background goroutine, so translations never wait on
+// either count; comparisons arriving while the queue is full are dropped.
+type Comparer struct {
+	database   string
+	executor   Executor
+	counter    Counter
+	logger     *observability.Logger
+	metrics    *observability.Metrics
+	sampleRate float64
+	timeout    time.Duration
+	queue      chan Comparison
+
+	mu      sync.Mutex
+	running bool
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewComparer creates a comparer of the translations to database, counted by executor,
+// with the counts of counter. metrics may be nil.
+func NewComparer(database string, executor Executor, counter Counter, logger *observability.Logger, metrics *observability.Metrics) *Comparer {
+	return &Comparer{
+		database:   database,
+		executor:   executor,
+		counter:    counter,
+		logger:     logger,
+		metrics:    metrics,
+		sampleRate: 1,
+		timeout:    DefaultTimeout,
+		queue:      make(chan Comparison, DefaultQueueSize),
+	}
+}
+
+// WithSampling compares only a fraction, between 0 and 1, of the translations
+func (c *Comparer) WithSampling(rate float64) *Comparer {
+	c.sampleRate = rate
+	return c
+}
+
+// WithTimeout bounds each comparison, both counts included
+func (c *Comparer) WithTimeout(timeout time.Duration) *Comparer {
+	c.timeout = timeout
+	return c
+}
+
+// WithQueueSize sets how many comparisons wait before new ones are dropped
+func (c *Comparer) WithQueueSize(size int) *Comparer {
+	if size < 1 {
+		size = 1
+	}
+	c.queue = make(chan Comparison, size)
+	return c
+}
+
+// Start begins running submitted comparisons
+func (c *Comparer) Start() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return
+	}
+	c.running = true
+	c.done = make(chan struct{})
+
+	c.wg.Add(1)
+	go c.run(c.done)
+}
+
+// Stop runs the comparisons still queued and stops the comparer
+func (c *Comparer) Stop() {
+	c.mu.Lock()
+	if !c.running {
+		c.mu.Unlock()
+		return
+	}
+	c.running = false
+	close(c.done)
+	c.mu.Unlock()
+
+	c.wg.Wait()
+}
+
+// Submit queues a comparison of a translation to the comparer's database, as sampled.
+// Translations to other databases are ignored.
+func (c *Comparer) Submit(comparison Comparison) {
+	if comparison.Database != c.database || comparison.Output == nil {
+		return
+	}
+	if c.sampleRate < 1 && rand.Float64() >= c.sampleRate {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.running {
+		return
+	}
+
+	select {
+	case c.queue <- comparison:
+	default:
+		c.record(comparison.Schema.Name, ResultDropped)
+	}
+}
+
+// Compare counts a translation on the database and its query on OpenSearch
+func (c *Comparer) Compare(ctx context.Context, comparison Comparison) (Result, error) {
+	var result Result
+	var err error
+	if result.SQLCount, err = c.executor.Count(ctx, comparison.Schema, comparison.Output); err != nil {
+		return result, err
+	}
+	result.OpenSearchCount, err = c.counter.Count(ctx, comparison.Schema, comparison.Query, comparison.Filters)
+	return result, err
+}
+
+// run runs queued comparisons until the comparer is stopped
+func (c *Comparer) run(done chan struct{}) {
+	defer c.wg.Done()
+
+	for {
+		select {
+		case comparison := <-c.queue:
+			c.compare(comparison)
+		case <-done:
+			for {
+				select {
+				case comparison := <-c.queue:
+					c.compare(comparison)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// compare runs one comparison, logging divergences and failures
+func (c *Comparer) compare(comparison Comparison) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	fields := make(map[string]interface{}, len(comparison.LogFields)+4)
+	for key, value := range comparison.LogFields {
+		fields[key] = value
+	}
+	fields["schema"] = comparison.Schema.Name
+	fields["dialect"] = comparison.Database
+
+	result, err := c.Compare(ctx, comparison)
+	switch {
+	case err != nil:
+		c.record(comparison.Schema.Name, ResultError)
+		c.logger.WithFields(fields).ErrorWithErr(err, "Shadow comparison failed")
+	case result.Diverged():
+		c.record(comparison.Schema.Name, ResultDiverged)
+		fields["sql_count"] = result.SQLCount
+		fields["opensearch_count"] = result.OpenSearchCount
+		c.logger.WithFields(fields).Warnf("Shadow comparison diverged: %d rows, %d documents", result.SQLCount, result.OpenSearchCount)
+	default:
+		c.record(comparison.Schema.Name, ResultMatch)
+	}
+}
+
+// record counts the outcome of a comparison, if metrics are enabled
+func (c *Comparer) record(schemaName, result string) {
+	if c.metrics != nil {
+		c.metrics.RecordShadowComparison(schemaName, result)
+	}
+}
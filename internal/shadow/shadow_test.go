@@ -0,0 +1,214 @@
+package shadow
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/executor"
+	"github.com/infiniv/rsearch/internal/observability"
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLogger(t *testing.T) *observability.Logger {
+	t.Helper()
+	logger, err := observability.NewLogger("error", "json", "stderr")
+	require.NoError(t, err)
+	return logger
+}
+
+// fixedCounter counts every query as count, recording the comparisons it ran
+type fixedCounter struct {
+	mu      sync.Mutex
+	count   int64
+	err     error
+	queries chan string
+}
+
+func (c *fixedCounter) Count(_ context.Context, _ *schema.Schema, query string, _ []string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.queries != nil {
+		c.queries <- query
+	}
+	return c.count, c.err
+}
+
+type fixedExecutor int64
+
+func (e fixedExecutor) Count(context.Context, *schema.Schema, *translator.TranslatorOutput) (int64, error) {
+	return int64(e), nil
+}
+
+func translate(t *testing.T, query string, s *schema.Schema) *translator.TranslatorOutput {
+	t.Helper()
+	ast, err := parser.NewParser(query).Parse()
+	require.NoError(t, err)
+	output, err := translator.NewSQLiteTranslator().Translate(ast, s)
+	require.NoError(t, err)
+	return output
+}
+
+func TestComparer_Compare(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText},
+		"status": {Type: schema.TypeText},
+		"price":  {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{DefaultField: "name"})
+
+	comparer := NewComparer("sqlite", fixedExecutor(3), &fixedCounter{count: 4}, newTestLogger(t), nil)
+
+	result, err := comparer.Compare(context.Background(), Comparison{Schema: s, Database: "sqlite", Query: "status:active", Output: &translator.TranslatorOutput{}})
+	require.NoError(t, err)
+	assert.Equal(t, Result{SQLCount: 3, OpenSearchCount: 4}, result)
+	assert.True(t, result.Diverged())
+
+	comparer = NewComparer("sqlite", fixedExecutor(3), &fixedCounter{err: errors.New("unavailable")}, newTestLogger(t), nil)
+	_, err = comparer.Compare(context.Background(), Comparison{Schema: s, Database: "sqlite", Query: "status:active", Output: &translator.TranslatorOutput{}})
+	assert.EqualError(t, err, "unavailable")
+}
+
+func TestComparer_Submit(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText},
+		"status": {Type: schema.TypeText},
+		"price":  {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{DefaultField: "name"})
+
+	counter := &fixedCounter{count: 3, queries: make(chan string, 10)}
+	comparer := NewComparer("sqlite", fixedExecutor(3), counter, newTestLogger(t), nil)
+
+	// Comparisons before Start are not run
+	comparer.Submit(Comparison{Schema: s, Database: "sqlite", Query: "status:pending", Output: &translator.TranslatorOutput{}})
+
+	comparer.Start()
+	comparer.Submit(Comparison{Schema: s, Database: "mongodb", Query: "status:draft", Output: &translator.TranslatorOutput{}})
+	comparer.Submit(Comparison{Schema: s, Database: "sqlite", Query: "status:active", Output: &translator.TranslatorOutput{}})
+	comparer.Stop()
+
+	// Stop runs the queued comparisons, and only those to the comparer's database
+	require.Len(t, counter.queries, 1)
+	assert.Equal(t, "status:active", <-counter.queries)
+}
+
+func TestComparer_Sampling(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText},
+		"status": {Type: schema.TypeText},
+		"price":  {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{DefaultField: "name"})
+
+	counter := &fixedCounter{queries: make(chan string, 100)}
+	comparer := NewComparer("sqlite", fixedExecutor(0), counter, newTestLogger(t), nil).
+		WithSampling(0.000001).
+		WithQueueSize(100)
+
+	comparer.Start()
+	for i := 0; i < 100; i++ {
+		comparer.Submit(Comparison{Schema: s, Database: "sqlite", Query: "status:active", Output: &translator.TranslatorOutput{}})
+	}
+	comparer.Stop()
+
+	assert.Less(t, len(counter.queries), 5)
+}
+
+func TestOpenSearch_Count(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText},
+		"status": {Type: schema.TypeText},
+		"price":  {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{DefaultField: "name"})
+
+	var path, username string
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		username, _, _ = r.BasicAuth()
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Write([]byte(`{"count":42,"_shards":{"total":1,"successful":1,"skipped":0,"failed":0}}`))
+	}))
+	defer server.Close()
+
+	counter := NewOpenSearch(server.URL+"/", map[string]string{"Products": "products-v2"}, nil).WithBasicAuth("rsearch", "secret")
+	count, err := counter.Count(context.Background(), testSchema, "status:active AND price:>10", []string{"NOT _exists_:deleted_at"})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(42), count)
+	assert.Equal(t, "/products-v2/_count", path)
+	assert.Equal(t, "rsearch", username)
+	assert.JSONEq(t, `{
+		"query": {
+			"bool": {
+				"must": [{"query_string": {"query": "status:active AND price:>10", "default_field": "name"}}],
+				"filter": [{"query_string": {"query": "NOT _exists_:deleted_at"}}]
+			}
+		}
+	}`, mustMarshal(t, body))
+}
+
+func TestOpenSearch_CountError(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText},
+		"status": {Type: schema.TypeText},
+		"price":  {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{DefaultField: "name"})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":{"type":"index_not_found_exception"},"status":404}`))
+	}))
+	defer server.Close()
+
+	_, err := NewOpenSearch(server.URL, nil, nil).Count(context.Background(), testSchema, "status:active", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "count responded with status 404")
+	assert.Contains(t, err.Error(), "index_not_found_exception")
+}
+
+func mustMarshal(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestComparer_CountsOnExecutor(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText},
+		"status": {Type: schema.TypeText},
+		"price":  {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{DefaultField: "name"})
+
+	path := filepath.Join(t.TempDir(), "shop.db")
+	e, err := executor.Open("sqlite", path, map[string]string{"products": "catalog"})
+	require.NoError(t, err)
+	defer e.Close()
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+	for _, statement := range []string{
+		"CREATE TABLE catalog (name TEXT, status TEXT, price REAL)",
+		"INSERT INTO catalog VALUES ('lamp', 'active', 25), ('desk', 'active', 150), ('chair', 'retired', 80)",
+	} {
+		_, err := db.Exec(statement)
+		require.NoError(t, err)
+	}
+
+	// The count matches OpenSearch, so nothing diverges
+	comparer := NewComparer("sqlite", e, &fixedCounter{count: 1}, newTestLogger(t), nil)
+	result, err := comparer.Compare(context.Background(), Comparison{Schema: s, Database: "sqlite", Query: "status:active AND price:>100", Output: translate(t, "status:active AND price:>100", s)})
+	require.NoError(t, err)
+	assert.Equal(t, Result{SQLCount: 1, OpenSearchCount: 1}, result)
+	assert.False(t, result.Diverged())
+}
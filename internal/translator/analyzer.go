@@ -0,0 +1,85 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// AnalyzeFreeText returns a copy of the AST without the stopwords and too-short terms
+// configured in the schema's analyzer. Only bare terms of free text, chains of implicit
+// ORs such as "the quick fox", are dropped; fielded clauses, phrases and terms joined by
+// an explicit OR are kept. Free text left without any term is an error.
+func AnalyzeFreeText(ast parser.Node, s *schema.Schema) (parser.Node, error) {
+	options := s.Options.Analyzer
+	if len(options.Stopwords) == 0 && options.MinTermLength <= 0 {
+		return ast, nil
+	}
+
+	stopwords := make(map[string]bool, len(options.Stopwords))
+	for _, stopword := range options.Stopwords {
+		stopwords[strings.ToLower(stopword)] = true
+	}
+	dropped := func(node parser.Node) bool {
+		term, ok := node.(*parser.TermQuery)
+		return ok && (stopwords[strings.ToLower(term.Term)] || utf8.RuneCountInString(term.Term) < options.MinTermLength)
+	}
+
+	var err error
+	var analyze func(node parser.Node) parser.Node
+	analyze = func(node parser.Node) parser.Node {
+		switch n := node.(type) {
+		case *parser.BinaryOp:
+			if !n.Implicit {
+				return &parser.BinaryOp{Op: n.Op, Left: analyze(n.Left), Right: analyze(n.Right), Pos: n.Pos}
+			}
+			var kept parser.Node
+			for _, clause := range implicitClauses(n) {
+				if dropped(clause) {
+					continue
+				}
+				if kept == nil {
+					kept = analyze(clause)
+					continue
+				}
+				kept = &parser.BinaryOp{Op: n.Op, Left: kept, Right: analyze(clause), Pos: n.Pos, Implicit: true}
+			}
+			if kept == nil {
+				if err == nil {
+					err = fmt.Errorf("free text at line %d, column %d only contains stopwords or too short terms", n.Pos.Line, n.Pos.Column)
+				}
+				return n
+			}
+			return kept
+		case *parser.UnaryOp:
+			return &parser.UnaryOp{Op: n.Op, Operand: analyze(n.Operand), Pos: n.Pos}
+		case *parser.RequiredQuery:
+			return &parser.RequiredQuery{Query: analyze(n.Query), Pos: n.Pos}
+		case *parser.ProhibitedQuery:
+			return &parser.ProhibitedQuery{Query: analyze(n.Query), Pos: n.Pos}
+		case *parser.BoostQuery:
+			return &parser.BoostQuery{Query: analyze(n.Query), Boost: n.Boost, Pos: n.Pos}
+		case *parser.GroupQuery:
+			return &parser.GroupQuery{Query: analyze(n.Query), Pos: n.Pos}
+		}
+		// Field groups hold values of their field, not free text
+		return node
+	}
+
+	analyzed := analyze(ast)
+	if err != nil {
+		return nil, err
+	}
+	return analyzed, nil
+}
+
+// implicitClauses flattens a chain of implicit ORs into its operands
+func implicitClauses(node parser.Node) []parser.Node {
+	if bo, ok := node.(*parser.BinaryOp); ok && bo.Implicit {
+		return append(implicitClauses(bo.Left), implicitClauses(bo.Right)...)
+	}
+	return []parser.Node{node}
+}
@@ -0,0 +1,64 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeFreeText(t *testing.T) {
+	s := schema.NewSchema("articles", map[string]schema.Field{
+		"body":  {Type: schema.TypeText},
+		"title": {Type: schema.TypeText},
+	}, schema.SchemaOptions{
+		DefaultField: "body",
+		Analyzer:     schema.AnalyzerOptions{Stopwords: []string{"the", "of", "A"}, MinTermLength: 2},
+	})
+
+	tests := []struct {
+		query   string
+		where   string
+		params  []interface{}
+		wantErr string
+	}{
+		{query: "the history of rome", where: "body = $1 OR body = $2", params: []interface{}{"history", "rome"}},
+		{query: "The x Rome", where: "body = $1", params: []interface{}{"Rome"}},
+		{query: "title:the a rome", where: "title = $1 OR body = $2", params: []interface{}{"the", "rome"}},
+		{query: `"the end" of rome`, where: "body = $1 OR body = $2", params: []interface{}{"the end", "rome"}},
+		{query: "title:x AND (the rome)", where: "title = $1 AND (body = $2)", params: []interface{}{"x", "rome"}},
+		{query: "the OR rome", where: "body = $1 OR body = $2", params: []interface{}{"the", "rome"}},
+		{query: "the", where: "body = $1", params: []interface{}{"the"}},
+		{query: "the a of", wantErr: "free text at line 1, column 1 only contains stopwords or too short terms"},
+		{query: "title:x AND (the of)", wantErr: "free text at line 1, column 14 only contains stopwords or too short terms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			output, err := NewPostgresTranslator().Translate(ast, s)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.where, output.WhereClause)
+			assert.Equal(t, tt.params, output.Parameters)
+		})
+	}
+}
+
+func TestAnalyzeFreeText_Disabled(t *testing.T) {
+	s := schema.NewSchema("articles", map[string]schema.Field{"body": {Type: schema.TypeText}}, schema.SchemaOptions{DefaultField: "body"})
+
+	ast, err := parser.NewParser("the a of").Parse()
+	require.NoError(t, err)
+
+	analyzed, err := AnalyzeFreeText(ast, s)
+	require.NoError(t, err)
+	assert.Same(t, ast, analyzed)
+}
@@ -331,3 +331,24 @@ func BenchmarkTranslateBoost(b *testing.B) {
 		}
 	}
 }
+
+// BenchmarkRegistryParallelGet benchmarks translator lookups from many goroutines, as
+// under load. Lookups take no lock, so their cost should not grow with -cpu.
+func BenchmarkRegistryParallelGet(b *testing.B) {
+	registry := NewRegistry()
+	registry.Register("postgres", NewPostgresTranslator())
+	registry.Register("mysql", NewMySQLTranslator())
+	registry.Register("sqlite", NewSQLiteTranslator())
+	registry.Register("mongodb", NewMongoDBTranslator())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := registry.Get("postgres"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package translator
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// RangeBoundsError is returned for a range whose start is after its end, such as
+// price:[500 TO 50], which would otherwise translate to a condition that never matches
+type RangeBoundsError struct {
+	Field string // field as named in the query
+	Start string
+	End   string
+	Pos   parser.Position
+}
+
+func (e *RangeBoundsError) Error() string {
+	return fmt.Sprintf("range on field %s starts at %s, after its end %s, at line %d, column %d",
+		e.Field, e.Start, e.End, e.Pos.Line, e.Pos.Column)
+}
+
+// boundLayouts are the formats date and time bounds are ordered by
+var boundLayouts = map[schema.FieldType][]string{
+	schema.TypeDate:     {"2006-01-02", time.RFC3339},
+	schema.TypeDateTime: {time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"},
+	schema.TypeTime:     {"15:04:05", "15:04"},
+}
+
+// CheckRangeBounds handles ranges whose start is after their end according to the
+// schema's ReversedRanges option: by default it returns a *RangeBoundsError, and with
+// "swap" it returns a copy of the AST with the bounds swapped, along with a warning for
+// each swapped range. Bounds that cannot be ordered, such as text, are left alone.
+func CheckRangeBounds(ast parser.Node, s *schema.Schema) (parser.Node, []Warning, error) {
+	var reversed []*parser.RangeQuery
+	parser.Walk(ast, func(node parser.Node) bool {
+		if rq, ok := node.(*parser.RangeQuery); ok && boundsReversed(rq, s) {
+			reversed = append(reversed, rq)
+		}
+		return true
+	})
+	if len(reversed) == 0 {
+		return ast, nil, nil
+	}
+
+	if s.Options.ReversedRanges != schema.ReversedRangesSwap {
+		rq := reversed[0]
+		return nil, nil, &RangeBoundsError{Field: rq.Field, Start: boundString(rq.Start), End: boundString(rq.End), Pos: rq.Pos}
+	}
+
+	warnings := make([]Warning, 0, len(reversed))
+	for _, rq := range reversed {
+		// sqllint:trusted a warning for the response, not SQL
+		warnings = append(warnings, newWarning(rsearch.WarningRangeSwapped, rq.Pos, fmt.Sprintf("swapped the bounds of the range on field %s, which started at %s after its end %s",
+			rq.Field, boundString(rq.Start), boundString(rq.End))))
+	}
+	swapped := parser.Transform(ast, func(node parser.Node) parser.Node {
+		rq, ok := node.(*parser.RangeQuery)
+		if !ok || !boundsReversed(rq, s) {
+			return node
+		}
+		return &parser.RangeQuery{
+			Field:          rq.Field,
+			Start:          rq.End,
+			End:            rq.Start,
+			InclusiveStart: rq.InclusiveEnd,
+			InclusiveEnd:   rq.InclusiveStart,
+			Pos:            rq.Pos,
+		}
+	})
+	return swapped, warnings, nil
+}
+
+// boundsReversed reports whether a range on a numeric, date or time field starts after its end
+func boundsReversed(rq *parser.RangeQuery, s *schema.Schema) bool {
+	_, field, err := s.ResolveField(rq.Field)
+	if err != nil {
+		return false
+	}
+	start, end := boundString(rq.Start), boundString(rq.End)
+	if start == "*" || end == "*" {
+		return false
+	}
+
+	switch field.Type {
+	case schema.TypeInteger, schema.TypeFloat:
+		startNumber, startErr := strconv.ParseFloat(start, 64)
+		endNumber, endErr := strconv.ParseFloat(end, 64)
+		return startErr == nil && endErr == nil && startNumber > endNumber
+	}
+
+	layouts, ok := boundLayouts[field.Type]
+	if !ok {
+		return false
+	}
+	startTime, startOk := parseBound(start, layouts)
+	endTime, endOk := parseBound(end, layouts)
+	return startOk && endOk && startTime.After(endTime)
+}
+
+// parseBound parses a date or time bound with the first layout that fits
+func parseBound(value string, layouts []string) (time.Time, bool) {
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// boundString returns the text of a range bound
+func boundString(value parser.ValueNode) string {
+	if value == nil {
+		return "*"
+	}
+	if number, ok := value.(*parser.NumberValue); ok {
+		return number.Number
+	}
+	str, _ := value.Value().(string)
+	return str
+}
@@ -0,0 +1,102 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRangeBounds_Reject(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"price":   {Type: schema.TypeFloat},
+		"created": {Type: schema.TypeDate},
+		"opens":   {Type: schema.TypeTime},
+		"name":    {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+
+	tests := []struct {
+		query string
+		start string
+		end   string
+	}{
+		{"price:[500 TO 50]", "500", "50"},
+		{"name:foo AND price:{10.5 TO 2}", "10.5", "2"},
+		{"created:[2026-10-15 TO 2026-01-01]", "2026-10-15", "2026-01-01"},
+		{`opens:["18:00" TO "09:30"]`, "18:00", "09:30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			_, err = NewPostgresTranslator().Translate(ast, testSchema)
+			var boundsErr *RangeBoundsError
+			require.ErrorAs(t, err, &boundsErr)
+			assert.Equal(t, tt.start, boundsErr.Start)
+			assert.Equal(t, tt.end, boundsErr.End)
+			assert.Positive(t, boundsErr.Pos.Column)
+		})
+	}
+}
+
+func TestCheckRangeBounds_Swap(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"price":   {Type: schema.TypeFloat},
+		"created": {Type: schema.TypeDate},
+		"opens":   {Type: schema.TypeTime},
+		"name":    {Type: schema.TypeText},
+	}, schema.SchemaOptions{ReversedRanges: schema.ReversedRangesSwap})
+
+	ast, err := parser.NewParser("name:x AND price:[500 TO 50}").Parse()
+	require.NoError(t, err)
+
+	output, err := NewPostgresTranslator().Translate(ast, testSchema)
+	require.NoError(t, err)
+	assert.Equal(t, "name = $1 AND price > $2 AND price <= $3", output.WhereClause)
+	assert.Equal(t, []interface{}{"x", 50.0, 500.0}, output.Parameters)
+	assert.Equal(t, []Warning{{
+		Code:     rsearch.WarningRangeSwapped,
+		Message:  "swapped the bounds of the range on field price, which started at 500 after its end 50",
+		Position: 11,
+		Line:     1,
+		Column:   12,
+	}}, output.Metadata["warnings"])
+
+	output, err = NewMongoDBTranslator().Translate(ast, testSchema)
+	require.NoError(t, err)
+	assert.Len(t, output.Metadata["warnings"], 1)
+}
+
+func TestCheckRangeBounds_Unaffected(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"price":   {Type: schema.TypeFloat},
+		"created": {Type: schema.TypeDate},
+		"opens":   {Type: schema.TypeTime},
+		"name":    {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+
+	queries := []string{
+		"price:[50 TO 500]",
+		"price:[50 TO 50]",
+		"price:[500 TO *]",
+		"name:[zebra TO apple]",
+		"created:[2026-01-01 TO 2026-10-15]",
+	}
+
+	for _, query := range queries {
+		t.Run(query, func(t *testing.T) {
+			ast, err := parser.NewParser(query).Parse()
+			require.NoError(t, err)
+
+			checked, warnings, err := CheckRangeBounds(ast, testSchema)
+			require.NoError(t, err)
+			assert.Empty(t, warnings)
+			assert.Same(t, ast, checked)
+		})
+	}
+}
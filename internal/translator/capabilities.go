@@ -0,0 +1,106 @@
+package translator
+
+import (
+	"strings"
+
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// Operator names, matching those reported in usage statistics
+const (
+	OperatorTerm       = schema.OperatorTerm
+	OperatorPhrase     = schema.OperatorPhrase
+	OperatorWildcard   = schema.OperatorWildcard
+	OperatorRegex      = schema.OperatorRegex
+	OperatorRange      = schema.OperatorRange
+	OperatorFuzzy      = schema.OperatorFuzzy
+	OperatorProximity  = schema.OperatorProximity
+	OperatorExists     = schema.OperatorExists
+	OperatorFieldGroup = schema.OperatorFieldGroup
+	OperatorIn         = schema.OperatorIn
+)
+
+// FieldOperators lists the query operators a field supports in the given dialect, taking
+// the field type, its operator whitelist and the schema's enabled features into account. dbType is a translator's
+// DatabaseType(); unknown dialects only get the operators every translator implements.
+func FieldOperators(dbType string, field schema.Field, options schema.SchemaOptions) []string {
+	operators := []string{OperatorTerm, OperatorPhrase, OperatorFieldGroup, OperatorIn, OperatorExists}
+
+	switch field.Type {
+	case schema.TypeText:
+		operators = append(operators, OperatorWildcard, OperatorRange)
+		if options.EnabledFeatures.Regex {
+			operators = append(operators, OperatorRegex)
+		}
+		// SQLite has no fuzzy matching
+		if options.EnabledFeatures.Fuzzy && isKnownDialect(dbType) && dbType != "sqlite" {
+			operators = append(operators, OperatorFuzzy)
+		}
+		if options.EnabledFeatures.Proximity && isKnownDialect(dbType) {
+			operators = append(operators, OperatorProximity)
+		}
+	case schema.TypeInteger, schema.TypeFloat, schema.TypeDate, schema.TypeDateTime, schema.TypeTime:
+		operators = append(operators, OperatorRange)
+	}
+
+	if field.AllowedOperators() == nil {
+		return operators
+	}
+	allowed := operators[:0]
+	for _, operator := range operators {
+		if field.AllowsOperator(operator) {
+			allowed = append(allowed, operator)
+		}
+	}
+	return allowed
+}
+
+// CaseSensitive reports whether equality matches on a text field are case-sensitive in
+// the given dialect. It returns nil for non-text fields and when the answer depends on
+// database configuration, such as a column's default collation.
+func CaseSensitive(dbType string, field schema.Field) *bool {
+	if field.Type != schema.TypeText {
+		return nil
+	}
+
+	collation := strings.ToLower(field.Collation)
+	switch dbType {
+	case "postgres":
+		if collation != "" {
+			return nil
+		}
+		return boolPtr(true)
+	case "mysql":
+		switch {
+		case collation == "" && field.Unaccent, strings.HasSuffix(collation, "_ci"):
+			return boolPtr(false)
+		case strings.HasSuffix(collation, "_cs"), strings.HasSuffix(collation, "_bin"):
+			return boolPtr(true)
+		}
+		return nil
+	case "sqlite":
+		if collation == "nocase" || (collation == "" && field.Unaccent) {
+			return boolPtr(false)
+		}
+		if collation == "" || collation == "binary" {
+			return boolPtr(true)
+		}
+		return nil
+	case "mongodb":
+		return boolPtr(!field.Unaccent)
+	}
+	return nil
+}
+
+// isKnownDialect reports whether dbType is one of the built-in translators
+func isKnownDialect(dbType string) bool {
+	switch dbType {
+	case "postgres", "mysql", "sqlite", "mongodb":
+		return true
+	}
+	return false
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
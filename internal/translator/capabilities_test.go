@@ -0,0 +1,57 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldOperators(t *testing.T) {
+	text := schema.Field{Type: schema.TypeText}
+	all := schema.SchemaOptions{EnabledFeatures: schema.EnabledFeatures{Fuzzy: true, Proximity: true, Regex: true}}
+
+	assert.ElementsMatch(t,
+		[]string{"term", "phrase", "field_group", "in", "exists", "wildcard", "range", "regex", "fuzzy", "proximity"},
+		FieldOperators("postgres", text, all))
+	assert.NotContains(t, FieldOperators("sqlite", text, all), "fuzzy")
+	assert.NotContains(t, FieldOperators("postgres", text, schema.SchemaOptions{}), "regex")
+	assert.NotContains(t, FieldOperators("custom", text, all), "proximity")
+
+	assert.ElementsMatch(t,
+		[]string{"term", "phrase", "field_group", "in", "exists", "range"},
+		FieldOperators("mysql", schema.Field{Type: schema.TypeDate}, all))
+	assert.ElementsMatch(t,
+		[]string{"term", "phrase", "field_group", "in", "exists"},
+		FieldOperators("mysql", schema.Field{Type: schema.TypeBoolean}, all))
+
+	// Whitelists narrow the supported operators but never add unsupported ones
+	assert.Equal(t,
+		[]string{"term", "field_group"},
+		FieldOperators("postgres", schema.Field{Type: schema.TypeText, Operators: []string{"term", "field_group", "fuzzy"}}, schema.SchemaOptions{}))
+}
+
+func TestCaseSensitive(t *testing.T) {
+	tests := []struct {
+		name     string
+		dbType   string
+		field    schema.Field
+		expected *bool
+	}{
+		{"postgres default", "postgres", schema.Field{Type: schema.TypeText}, boolPtr(true)},
+		{"postgres collation", "postgres", schema.Field{Type: schema.TypeText, Collation: "und-x-icu"}, nil},
+		{"mysql default", "mysql", schema.Field{Type: schema.TypeText}, nil},
+		{"mysql unaccent", "mysql", schema.Field{Type: schema.TypeText, Unaccent: true}, boolPtr(false)},
+		{"mysql binary", "mysql", schema.Field{Type: schema.TypeText, Collation: "utf8mb4_bin"}, boolPtr(true)},
+		{"sqlite default", "sqlite", schema.Field{Type: schema.TypeText}, boolPtr(true)},
+		{"sqlite nocase", "sqlite", schema.Field{Type: schema.TypeText, Collation: "NOCASE"}, boolPtr(false)},
+		{"mongodb unaccent", "mongodb", schema.Field{Type: schema.TypeText, Unaccent: true}, boolPtr(false)},
+		{"non-text", "postgres", schema.Field{Type: schema.TypeInteger}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, CaseSensitive(tt.dbType, tt.field))
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package translator
+
+import (
+	"fmt"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// EncodeValues returns a copy of the AST in which the values of fields with a codec or a
+// hash are replaced by their encoding, so that "ssn:123-45-6789" binds the value as stored
+// in an encrypted or hashed column and the plain text never reaches the database.
+// Translators run it after Prepare, whose operator checks only let exact matches through
+// on such fields.
+func EncodeValues(ast parser.Node, s *schema.Schema) (parser.Node, error) {
+	return parser.MapValues(ast, func(fieldName string, value parser.ValueNode) (parser.ValueNode, error) {
+		_, field, err := s.ResolveField(fieldName)
+		if err != nil {
+			return value, nil
+		}
+		codec, ok := s.ValueCodec(field)
+		if !ok {
+			return value, nil
+		}
+		if codec == nil && field.Hash != nil {
+			return nil, fmt.Errorf("hash key %q of field %s is not registered", field.Hash.Key, fieldName)
+		}
+		if codec == nil {
+			return nil, fmt.Errorf("codec %q of field %s is not registered", field.Codec, fieldName)
+		}
+
+		var plain string
+		var pos parser.Position
+		switch v := value.(type) {
+		case *parser.TermValue:
+			plain, pos = v.Term, v.Pos
+		case *parser.PhraseValue:
+			plain, pos = v.Phrase, v.Pos
+		case *parser.NumberValue:
+			plain, pos = v.Number, v.Pos
+		default:
+			return nil, fmt.Errorf("field %s is encoded and only supports exact matches", fieldName)
+		}
+
+		encoded, err := codec.Encode(plain)
+		if err != nil {
+			// The value is left out of the error, as it is the text the codec protects
+			return nil, fmt.Errorf("failed to encode a value of field %s: %w", fieldName, err)
+		}
+		if _, ok := value.(*parser.PhraseValue); ok {
+			return &parser.PhraseValue{Phrase: encoded, Pos: pos}, nil
+		}
+		return &parser.TermValue{Term: encoded, Pos: pos}, nil
+	})
+}
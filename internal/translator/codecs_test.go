@@ -0,0 +1,145 @@
+package translator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeValues_Postgres(t *testing.T) {
+	// The ssn field is encoded by prefixing values with "enc:"
+	registry := schema.NewRegistry()
+	require.NoError(t, registry.RegisterCodec("pii", schema.CodecFunc(func(value string) (string, error) {
+		return "enc:" + value, nil
+	})))
+	testSchema := schema.NewSchema("users", map[string]schema.Field{
+		"ssn":  {Type: schema.TypeText, Codec: "pii", Column: "ssn_encrypted"},
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{DefaultField: "name"})
+	require.NoError(t, registry.Register(testSchema))
+
+	tests := []struct {
+		name   string
+		query  string
+		where  string
+		params []interface{}
+	}{
+		{"term", "ssn:123-45-6789", "ssn_encrypted = $1", []interface{}{"enc:123-45-6789"}},
+		{"phrase", `ssn:"123 45 6789"`, "ssn_encrypted = $1", []interface{}{"enc:123 45 6789"}},
+		{"number", "ssn:123456789", "ssn_encrypted = $1", []interface{}{"enc:123456789"}},
+		{"in-list", "ssn:(1,2)", "ssn_encrypted IN ($1, $2)", []interface{}{"enc:1", "enc:2"}},
+		{"field group", "ssn:(1 OR 2)", "(ssn_encrypted = $1 OR ssn_encrypted = $2)", []interface{}{"enc:1", "enc:2"}},
+		{"other fields untouched", "ssn:1 AND name:bob", "ssn_encrypted = $1 AND name = $2", []interface{}{"enc:1", "bob"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			output, err := NewPostgresTranslator().Translate(ast, testSchema)
+			require.NoError(t, err)
+			assert.Equal(t, tt.where, output.WhereClause)
+			assert.Equal(t, tt.params, output.Parameters)
+		})
+	}
+}
+
+func TestEncodeValues_MongoDB(t *testing.T) {
+	registry := schema.NewRegistry()
+	require.NoError(t, registry.RegisterCodec("pii", schema.CodecFunc(func(value string) (string, error) {
+		return "enc:" + value, nil
+	})))
+	testSchema := schema.NewSchema("users", map[string]schema.Field{
+		"ssn":  {Type: schema.TypeText, Codec: "pii", Column: "ssn_encrypted"},
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{DefaultField: "name"})
+	require.NoError(t, registry.Register(testSchema))
+
+	ast, err := parser.NewParser("ssn:123-45-6789").Parse()
+	require.NoError(t, err)
+
+	output, err := NewMongoDBTranslator().Translate(ast, testSchema)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"ssn_encrypted": "enc:123-45-6789"}, output.Filter)
+}
+
+func TestEncodeValues_OnlyExactMatches(t *testing.T) {
+	registry := schema.NewRegistry()
+	require.NoError(t, registry.RegisterCodec("pii", schema.CodecFunc(func(value string) (string, error) {
+		return "enc:" + value, nil
+	})))
+	testSchema := schema.NewSchema("users", map[string]schema.Field{
+		"ssn":  {Type: schema.TypeText, Codec: "pii", Column: "ssn_encrypted"},
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{DefaultField: "name"})
+	require.NoError(t, registry.Register(testSchema))
+
+	for _, query := range []string{"ssn:abc*", "ssn:[1 TO 5]", "ssn:/12.*/", "ssn:(1 OR 2*)"} {
+		t.Run(query, func(t *testing.T) {
+			ast, err := parser.NewParser(query).Parse()
+			require.NoError(t, err)
+
+			_, err = NewPostgresTranslator().Translate(ast, testSchema)
+			var opErr *OperatorNotAllowedError
+			require.ErrorAs(t, err, &opErr)
+			assert.Equal(t, []string{"term", "phrase", "field_group", "in", "exists"}, opErr.Allowed)
+		})
+	}
+}
+
+func TestEncodeValues_PrepareLeavesPlainText(t *testing.T) {
+	registry := schema.NewRegistry()
+	require.NoError(t, registry.RegisterCodec("pii", schema.CodecFunc(func(value string) (string, error) {
+		return "enc:" + value, nil
+	})))
+	s := schema.NewSchema("users", map[string]schema.Field{
+		"ssn":  {Type: schema.TypeText, Codec: "pii", Column: "ssn_encrypted"},
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{DefaultField: "name"})
+	require.NoError(t, registry.Register(s))
+
+	ast, err := parser.NewParser("ssn:1").Parse()
+	require.NoError(t, err)
+
+	// Translating a prepared AST encodes once
+	prepared, _, err := Prepare(ast, s, time.Now())
+	require.NoError(t, err)
+	output, err := NewPostgresTranslator().Translate(prepared, s)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"enc:1"}, output.Parameters)
+}
+
+func TestEncodeValues_HashedField(t *testing.T) {
+	hash := &schema.FieldHash{Algorithm: schema.HashSHA512, Key: "emails"}
+	s := schema.NewSchema("users", map[string]schema.Field{
+		"email": {Type: schema.TypeText, Hash: hash},
+	}, schema.SchemaOptions{})
+	registry := schema.NewRegistry()
+	require.NoError(t, registry.RegisterHashKey("emails", []byte("pepper")))
+	require.NoError(t, registry.Register(s))
+	alice, _ := hash.Codec([]byte("pepper")).Encode("alice")
+	bob, _ := hash.Codec([]byte("pepper")).Encode("bob")
+
+	ast, err := parser.NewParser("email:(alice OR bob)").Parse()
+	require.NoError(t, err)
+	output, err := NewMySQLTranslator().Translate(ast, s)
+	require.NoError(t, err)
+	assert.Equal(t, "(email = ? OR email = ?)", output.WhereClause)
+	assert.Equal(t, []interface{}{alice, bob}, output.Parameters)
+
+	for _, query := range []string{"email:alice*", "email:[a TO b]", "email:alice~1"} {
+		t.Run(query, func(t *testing.T) {
+			ast, err := parser.NewParser(query).Parse()
+			require.NoError(t, err)
+
+			_, err = NewMySQLTranslator().Translate(ast, s)
+			var hashedErr *HashedFieldError
+			require.ErrorAs(t, err, &hashedErr)
+			assert.Equal(t, "email", hashedErr.Field)
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package translator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// Text matching options (schema.Field.Collation and schema.Field.Unaccent) change how
+// equality and pattern comparisons are written for a field. Each dialect has its own
+// mechanism, so the comparison is built by a per-translator compare method.
+
+// defaultMySQLUnaccentCollation is used for unaccent fields without an explicit collation.
+// It is accent- and case-insensitive and available from MySQL 8.0.
+const defaultMySQLUnaccentCollation = "utf8mb4_0900_ai_ci"
+
+// compare builds "column op $N" for the current parameter.
+// Unaccent fields compare unaccent() of both sides (requires the unaccent extension);
+// a collation is applied to the column side and may be any collation known to the server,
+// including nondeterministic ICU collations.
+func (p *PostgresTranslator) compare(columnName, op string, field *schema.Field) string {
+	left := columnName
+	right := fmt.Sprintf("$%d", p.paramCount)
+
+	if field.Unaccent {
+		left = fmt.Sprintf("unaccent(%s)", left)
+		right = fmt.Sprintf("unaccent(%s)", right)
+	}
+	if field.Collation != "" {
+		left = fmt.Sprintf("%s COLLATE %q", left, field.Collation)
+	}
+
+	return fmt.Sprintf("%s %s %s", left, op, right)
+}
+
+// in builds "column op ($N, ...)" for the last count parameters, matching like compare;
+// op is IN or NOT IN
+func (p *PostgresTranslator) in(columnName, op string, count int, field *schema.Field) string {
+	left := columnName
+	placeholders := make([]string, count)
+	for i := range placeholders {
+		placeholders[i] = fmt.Sprintf("$%d", p.paramCount-count+1+i)
+		if field.Unaccent {
+			placeholders[i] = fmt.Sprintf("unaccent(%s)", placeholders[i])
+		}
+	}
+
+	if field.Unaccent {
+		left = fmt.Sprintf("unaccent(%s)", left)
+	}
+	if field.Collation != "" {
+		left = fmt.Sprintf("%s COLLATE %q", left, field.Collation)
+	}
+
+	return fmt.Sprintf("%s %s (%s)", left, op, strings.Join(placeholders, ", "))
+}
+
+// compare builds "column op ?" for the current parameter.
+// The collation is attached to the parameter, which MySQL then uses for the comparison.
+func (m *MySQLTranslator) compare(columnName, op string, field *schema.Field) string {
+	collation := field.Collation
+	if collation == "" && field.Unaccent {
+		collation = defaultMySQLUnaccentCollation
+	}
+
+	if collation == "" {
+		return fmt.Sprintf("%s %s ?", columnName, op)
+	}
+	return fmt.Sprintf("%s %s ? COLLATE %s", columnName, op, collation)
+}
+
+// in builds "column op (?, ...)" for count parameters, each carrying the collation
+func (m *MySQLTranslator) in(columnName, op string, count int, field *schema.Field) string {
+	collation := field.Collation
+	if collation == "" && field.Unaccent {
+		collation = defaultMySQLUnaccentCollation
+	}
+
+	placeholder := "?"
+	if collation != "" {
+		placeholder = "? COLLATE " + collation
+	}
+	return fmt.Sprintf("%s %s (%s)", columnName, op, strings.TrimSuffix(strings.Repeat(placeholder+", ", count), ", "))
+}
+
+// compare builds "column op ?" for the current parameter.
+// SQLite has no built-in accent folding, so unaccent fields fall back to NOCASE, which only
+// folds ASCII case; register a custom collation and name it explicitly for full folding.
+// LIKE ignores collations in SQLite and is already ASCII case-insensitive, so pattern
+// matches are left unchanged.
+func (s *SQLiteTranslator) compare(columnName, op string, field *schema.Field) string {
+	collation := field.Collation
+	if collation == "" && field.Unaccent {
+		collation = "NOCASE"
+	}
+
+	if collation == "" || op == "LIKE" {
+		return fmt.Sprintf("%s %s ?", columnName, op)
+	}
+	return fmt.Sprintf("%s %s ? COLLATE %s", columnName, op, collation)
+}
+
+// in builds "column op (?, ...)" for count parameters. SQLite compares x IN (...) with
+// the collation of x, so the collation is applied to the column.
+func (s *SQLiteTranslator) in(columnName, op string, count int, field *schema.Field) string {
+	collation := field.Collation
+	if collation == "" && field.Unaccent {
+		collation = "NOCASE"
+	}
+
+	left := columnName
+	if collation != "" {
+		left = fmt.Sprintf("%s COLLATE %s", columnName, collation)
+	}
+	return fmt.Sprintf("%s %s (%s)", left, op, strings.TrimSuffix(strings.Repeat("?, ", count), ", "))
+}
+
+// equals builds the filter value for an equality match.
+// Unaccent string values become an anchored, case-insensitive regex with accent folding,
+// since MongoDB collations apply to a whole operation rather than to a single field.
+func (m *MongoDBTranslator) equals(field *schema.Field, value interface{}) interface{} {
+	str, ok := value.(string)
+	if !ok || !field.Unaccent {
+		return literal(value)
+	}
+
+	return map[string]interface{}{
+		"$regex":   "^" + foldRegex(regexp.QuoteMeta(str)) + "$",
+		"$options": "i",
+	}
+}
+
+// matches builds the filter value for a wildcard pattern match.
+func (m *MongoDBTranslator) matches(field *schema.Field, pattern string) map[string]interface{} {
+	regex := wildcardRegex(pattern)
+	if !field.Unaccent {
+		return map[string]interface{}{"$regex": regex, "$options": "s"}
+	}
+
+	return map[string]interface{}{
+		"$regex":   foldRegex(regex),
+		"$options": "is",
+	}
+}
+
+// accentClasses maps base letters to a character class of their accented forms
+var accentClasses = map[rune]string{
+	'a': "aàáâãäåā",
+	'c': "cçćč",
+	'e': "eèéêëēėę",
+	'i': "iìíîïī",
+	'n': "nñń",
+	'o': "oòóôõöøō",
+	's': "sśš",
+	'u': "uùúûüū",
+	'y': "yýÿ",
+	'z': "zźżž",
+}
+
+// accentBase maps each accented letter back to its base letter
+var accentBase = func() map[rune]rune {
+	base := make(map[rune]rune)
+	for letter, class := range accentClasses {
+		for _, r := range class {
+			base[r] = letter
+		}
+	}
+	return base
+}()
+
+// foldRegex replaces letters in a regex with classes matching their accented forms.
+// The input must not contain character classes; escaped characters are kept as they are.
+func foldRegex(pattern string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range pattern {
+		if escaped {
+			b.WriteRune(r)
+			escaped = false
+			continue
+		}
+		if r == '\\' {
+			b.WriteRune(r)
+			escaped = true
+			continue
+		}
+
+		lower := unicode.ToLower(r)
+		if base, ok := accentBase[lower]; ok {
+			b.WriteString("[" + accentClasses[base] + "]")
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,138 @@
+package translator
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func translateWith(t *testing.T, tr Translator, query string) *TranslatorOutput {
+	testSchema := schema.NewSchema("people", map[string]schema.Field{
+		"name":  {Type: schema.TypeText, Unaccent: true},
+		"city":  {Type: schema.TypeText, Collation: "und-x-icu"},
+		"email": {Type: schema.TypeText},
+		"age":   {Type: schema.TypeInteger},
+	}, schema.SchemaOptions{DefaultField: "name"})
+
+	t.Helper()
+
+	ast, err := parser.NewParser(query).Parse()
+	require.NoError(t, err)
+	output, err := tr.Translate(ast, testSchema)
+	require.NoError(t, err)
+	return output
+}
+
+func TestCollation_Postgres(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"name:jose", "unaccent(name) = unaccent($1)"},
+		{`name:"José María"`, "unaccent(name) = unaccent($1)"},
+		{"name:jos*", "unaccent(name) LIKE unaccent($1)"},
+		{"jose", "unaccent(name) = unaccent($1)"},
+		{"name:(jose OR maria)", "(unaccent(name) = unaccent($1) OR unaccent(name) = unaccent($2))"},
+		{"city:zurich", `city COLLATE "und-x-icu" = $1`},
+		{"email:a@b.c", "email = $1"},
+		{"age:30", "age = $1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			output := translateWith(t, NewPostgresTranslator(), tt.query)
+			assert.Equal(t, tt.expected, output.WhereClause)
+		})
+	}
+}
+
+func TestCollation_MySQL(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"name:jose", "name = ? COLLATE utf8mb4_0900_ai_ci"},
+		{"name:jos*", "name LIKE ? COLLATE utf8mb4_0900_ai_ci"},
+		{"city:zurich", "city = ? COLLATE und-x-icu"},
+		{"email:a@b.c", "email = ?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			output := translateWith(t, NewMySQLTranslator(), tt.query)
+			assert.Equal(t, tt.expected, output.WhereClause)
+		})
+	}
+}
+
+func TestCollation_SQLite(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"name:jose", "name = ? COLLATE NOCASE"},
+		{"name:jos*", "name LIKE ?"},
+		{"email:a@b.c", "email = ?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			output := translateWith(t, NewSQLiteTranslator(), tt.query)
+			assert.Equal(t, tt.expected, output.WhereClause)
+		})
+	}
+}
+
+func TestCollation_MongoDB(t *testing.T) {
+	t.Run("equality folds accents", func(t *testing.T) {
+		output := translateWith(t, NewMongoDBTranslator(), "name:jose")
+		filter := output.Filter.(map[string]interface{})["name"].(map[string]interface{})
+
+		assert.Equal(t, "i", filter["$options"])
+		re := regexp.MustCompile("(?i)" + filter["$regex"].(string))
+		assert.True(t, re.MatchString("José"))
+		assert.True(t, re.MatchString("JOSE"))
+		assert.False(t, re.MatchString("Josefina"))
+	})
+
+	t.Run("accented input matches plain value", func(t *testing.T) {
+		output := translateWith(t, NewMongoDBTranslator(), `name:"José"`)
+		filter := output.Filter.(map[string]interface{})["name"].(map[string]interface{})
+
+		re := regexp.MustCompile("(?i)" + filter["$regex"].(string))
+		assert.True(t, re.MatchString("jose"))
+	})
+
+	t.Run("metacharacters stay literal", func(t *testing.T) {
+		output := translateWith(t, NewMongoDBTranslator(), `name:"a.b (c)"`)
+		filter := output.Filter.(map[string]interface{})["name"].(map[string]interface{})
+
+		re := regexp.MustCompile("(?i)" + filter["$regex"].(string))
+		assert.True(t, re.MatchString("a.b (c)"))
+		assert.False(t, re.MatchString("axb (c)"))
+	})
+
+	t.Run("wildcard folds accents", func(t *testing.T) {
+		output := translateWith(t, NewMongoDBTranslator(), "name:jos*")
+		filter := output.Filter.(map[string]interface{})["name"].(map[string]interface{})
+
+		assert.Equal(t, "is", filter["$options"])
+		re := regexp.MustCompile("(?is)" + filter["$regex"].(string))
+		assert.True(t, re.MatchString("Josémaría"))
+	})
+
+	t.Run("plain fields are unchanged", func(t *testing.T) {
+		output := translateWith(t, NewMongoDBTranslator(), "email:jose")
+		assert.Equal(t, map[string]interface{}{"email": "jose"}, output.Filter)
+	})
+}
+
+func TestFoldRegex(t *testing.T) {
+	assert.Equal(t, "[aàáâãäåā]b", foldRegex("Áb"))
+	assert.Equal(t, `\.[nñń]`, foldRegex(`\.ñ`))
+	assert.Equal(t, `x\[`, foldRegex(`x\[`))
+}
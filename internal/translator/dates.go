@@ -0,0 +1,298 @@
+package translator
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// Date keywords accepted as values of date and datetime fields
+const (
+	DateNow       = "now"
+	DateToday     = "today"
+	DateYesterday = "yesterday"
+	DateTomorrow  = "tomorrow"
+	DateThisWeek  = "this_week"
+	DateLastWeek  = "last_week"
+	DateThisMonth = "this_month"
+	DateLastMonth = "last_month"
+	DateThisYear  = "this_year"
+	DateLastYear  = "last_year"
+)
+
+// Keywords last_<n>_days denote the n calendar days ending today, today included, for n
+// up to MaxRecentDays
+const (
+	recentDaysPrefix = "last_"
+	recentDaysSuffix = "_days"
+	MaxRecentDays    = 3660
+)
+
+// dateLayouts are the formats concrete boundaries are written in, by field type
+var dateLayouts = map[schema.FieldType]string{
+	schema.TypeDate:     "2006-01-02",
+	schema.TypeDateTime: time.RFC3339,
+}
+
+// datePeriod returns the half-open interval [start, end) a keyword denotes at now.
+// Weeks start on Monday. For "now", start and end are both the current instant.
+func datePeriod(keyword string, now time.Time) (start, end time.Time, ok bool) {
+	year, month, day := now.Date()
+	midnight := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	monday := midnight.AddDate(0, 0, -(int(now.Weekday())+6)%7)
+	firstOfMonth := time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+	firstOfYear := time.Date(year, time.January, 1, 0, 0, 0, 0, now.Location())
+
+	switch keyword {
+	case DateNow:
+		return now, now, true
+	case DateToday:
+		return midnight, midnight.AddDate(0, 0, 1), true
+	case DateYesterday:
+		return midnight.AddDate(0, 0, -1), midnight, true
+	case DateTomorrow:
+		return midnight.AddDate(0, 0, 1), midnight.AddDate(0, 0, 2), true
+	case DateThisWeek:
+		return monday, monday.AddDate(0, 0, 7), true
+	case DateLastWeek:
+		return monday.AddDate(0, 0, -7), monday, true
+	case DateThisMonth:
+		return firstOfMonth, firstOfMonth.AddDate(0, 1, 0), true
+	case DateLastMonth:
+		return firstOfMonth.AddDate(0, -1, 0), firstOfMonth, true
+	case DateThisYear:
+		return firstOfYear, firstOfYear.AddDate(1, 0, 0), true
+	case DateLastYear:
+		return firstOfYear.AddDate(-1, 0, 0), firstOfYear, true
+	}
+	if days, ok := recentDays(keyword); ok {
+		return midnight.AddDate(0, 0, 1-days), midnight.AddDate(0, 0, 1), true
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// recentDays returns the number of days of a last_<n>_days keyword
+func recentDays(keyword string) (int, bool) {
+	if !strings.HasPrefix(keyword, recentDaysPrefix) || !strings.HasSuffix(keyword, recentDaysSuffix) {
+		return 0, false
+	}
+	digits := strings.TrimSuffix(strings.TrimPrefix(keyword, recentDaysPrefix), recentDaysSuffix)
+	if digits == "" || digits[0] == '0' || digits[0] == '+' {
+		return 0, false
+	}
+	days, err := strconv.Atoi(digits)
+	if err != nil || days < 1 || days > MaxRecentDays {
+		return 0, false
+	}
+	return days, true
+}
+
+// dateKeyword returns the lowercased keyword of a value, if it is one
+func dateKeyword(value string) (string, bool) {
+	keyword := strings.ToLower(strings.TrimSpace(value))
+	_, _, ok := datePeriod(keyword, time.Time{})
+	return keyword, ok
+}
+
+// UsesDateKeywords reports whether any value in the AST is a date keyword. It does not
+// consult a schema, so it may report keywords used on fields that are not dates.
+func UsesDateKeywords(ast parser.Node) bool {
+	found := false
+	parser.Walk(ast, func(node parser.Node) bool {
+		var values []parser.ValueNode
+		switch n := node.(type) {
+		case *parser.FieldQuery:
+			values = []parser.ValueNode{n.Value}
+		case *parser.RangeQuery:
+			values = []parser.ValueNode{n.Start, n.End}
+		case *parser.TermQuery:
+			values = []parser.ValueNode{&parser.TermValue{Term: n.Term}}
+		case *parser.InListQuery:
+			values = n.Values
+		}
+		for _, value := range values {
+			if term, ok := value.(*parser.TermValue); ok {
+				if _, ok := dateKeyword(term.Term); ok {
+					found = true
+				}
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// ResolveDateKeywords returns a copy of the AST in which date keywords on date and
+// datetime fields are replaced by concrete boundaries, computed from now in the schema's
+// timezone. A period keyword matches the whole period, so createdAt:today becomes
+// createdAt:[<midnight> TO <next midnight>}, and range bounds cover or exclude the
+// period according to their inclusivity: createdAt:<=today ends before tomorrow.
+func ResolveDateKeywords(ast parser.Node, s *schema.Schema, now time.Time) parser.Node {
+	now = now.In(s.Location()).Truncate(time.Second)
+
+	return parser.Transform(ast, func(node parser.Node) parser.Node {
+		switch n := node.(type) {
+		case *parser.FieldQuery:
+			if layout, ok := dateLayout(s, n.Field); ok {
+				return resolveFieldQuery(n, layout, now)
+			}
+		case *parser.RangeQuery:
+			if layout, ok := dateLayout(s, n.Field); ok {
+				return resolveRangeQuery(n, layout, now)
+			}
+		case *parser.FieldGroupQuery:
+			if layout, ok := dateLayout(s, n.Field); ok {
+				return resolveFieldGroup(n, layout, now)
+			}
+		case *parser.InListQuery:
+			if layout, ok := dateLayout(s, n.Field); ok {
+				return resolveInList(n, layout, now)
+			}
+		}
+		return node
+	})
+}
+
+// dateLayout returns the boundary format of a date or datetime field
+func dateLayout(s *schema.Schema, fieldName string) (string, bool) {
+	_, field, err := s.ResolveField(fieldName)
+	if err != nil {
+		return "", false
+	}
+	layout, ok := dateLayouts[field.Type]
+	return layout, ok
+}
+
+// resolveFieldQuery expands field:keyword into the keyword's period
+func resolveFieldQuery(fq *parser.FieldQuery, layout string, now time.Time) parser.Node {
+	term, ok := fq.Value.(*parser.TermValue)
+	if !ok {
+		return fq
+	}
+	keyword, ok := dateKeyword(term.Term)
+	if !ok {
+		return fq
+	}
+
+	start, end, _ := datePeriod(keyword, now)
+	if keyword == DateNow {
+		return &parser.FieldQuery{Field: fq.Field, Value: boundary(start, layout, term.Pos), Pos: fq.Pos}
+	}
+	return &parser.RangeQuery{
+		Field:          fq.Field,
+		Start:          boundary(start, layout, term.Pos),
+		End:            boundary(end, layout, term.Pos),
+		InclusiveStart: true,
+		InclusiveEnd:   false,
+		Pos:            fq.Pos,
+	}
+}
+
+// resolveRangeQuery replaces keyword bounds. An inclusive start includes the period and
+// an exclusive one starts after it; an inclusive end includes the period and an exclusive
+// one ends before it.
+func resolveRangeQuery(rq *parser.RangeQuery, layout string, now time.Time) parser.Node {
+	resolved := *rq
+
+	if term, ok := rq.Start.(*parser.TermValue); ok {
+		if keyword, ok := dateKeyword(term.Term); ok {
+			start, end, _ := datePeriod(keyword, now)
+			if keyword == DateNow || rq.InclusiveStart {
+				resolved.Start = boundary(start, layout, term.Pos)
+			} else {
+				resolved.Start = boundary(end, layout, term.Pos)
+				resolved.InclusiveStart = true
+			}
+		}
+	}
+
+	if term, ok := rq.End.(*parser.TermValue); ok {
+		if keyword, ok := dateKeyword(term.Term); ok {
+			start, end, _ := datePeriod(keyword, now)
+			if keyword == DateNow {
+				resolved.End = boundary(end, layout, term.Pos)
+			} else if rq.InclusiveEnd {
+				resolved.End = boundary(end, layout, term.Pos)
+				resolved.InclusiveEnd = false
+			} else {
+				resolved.End = boundary(start, layout, term.Pos)
+			}
+		}
+	}
+
+	return &resolved
+}
+
+// resolveFieldGroup rewrites field:(a OR today) into (field:a OR field:[...}) when the
+// group contains a keyword, as a period cannot be expressed as a group member
+func resolveFieldGroup(fgq *parser.FieldGroupQuery, layout string, now time.Time) parser.Node {
+	hasKeyword := false
+	for _, q := range fgq.Queries {
+		if UsesDateKeywords(q) {
+			hasKeyword = true
+		}
+	}
+	if !hasKeyword {
+		return fgq
+	}
+
+	var expand func(node parser.Node) parser.Node
+	expand = func(node parser.Node) parser.Node {
+		switch n := node.(type) {
+		case *parser.TermQuery:
+			fq := &parser.FieldQuery{Field: fgq.Field, Value: &parser.TermValue{Term: n.Term, Pos: n.Pos}, Pos: n.Pos}
+			return resolveFieldQuery(fq, layout, now)
+		case *parser.WildcardQuery:
+			return &parser.FieldQuery{Field: fgq.Field, Value: &parser.WildcardValue{Pattern: n.Pattern, Pos: n.Pos}, Pos: n.Pos}
+		case *parser.BinaryOp:
+			return &parser.BinaryOp{Op: n.Op, Left: expand(n.Left), Right: expand(n.Right), Pos: n.Pos, Implicit: n.Implicit}
+		}
+		return node
+	}
+
+	var combined parser.Node
+	for _, q := range fgq.Queries {
+		member := expand(q)
+		if combined == nil {
+			combined = member
+			continue
+		}
+		combined = &parser.BinaryOp{Op: "OR", Left: combined, Right: member, Pos: fgq.Pos}
+	}
+	return &parser.GroupQuery{Query: combined, Pos: fgq.Pos}
+}
+
+// boundary renders a time as a range bound or value
+func boundary(t time.Time, layout string, pos parser.Position) parser.ValueNode {
+	return &parser.TermValue{Term: t.Format(layout), Pos: pos}
+}
+
+// resolveInList rewrites field:(a,today) into (field:a OR field:[...}) when the list
+// contains a keyword, as a period cannot be expressed as a list value
+func resolveInList(iq *parser.InListQuery, layout string, now time.Time) parser.Node {
+	hasKeyword := false
+	for _, value := range iq.Values {
+		if term, ok := value.(*parser.TermValue); ok {
+			if _, ok := dateKeyword(term.Term); ok {
+				hasKeyword = true
+			}
+		}
+	}
+	if !hasKeyword {
+		return iq
+	}
+
+	var combined parser.Node
+	for _, value := range iq.Values {
+		member := resolveFieldQuery(&parser.FieldQuery{Field: iq.Field, Value: value, Pos: iq.Pos}, layout, now)
+		if combined == nil {
+			combined = member
+			continue
+		}
+		combined = &parser.BinaryOp{Op: "OR", Left: combined, Right: member, Pos: iq.Pos}
+	}
+	return &parser.GroupQuery{Query: combined, Pos: iq.Pos}
+}
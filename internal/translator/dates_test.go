@@ -0,0 +1,93 @@
+package translator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveDateKeywords(t *testing.T) {
+	s := schema.NewSchema("orders", map[string]schema.Field{
+		"createdAt": {Type: schema.TypeDateTime, Column: "created_at"},
+		"shipDate":  {Type: schema.TypeDate, Column: "ship_date"},
+		"status":    {Type: schema.TypeText},
+	}, schema.SchemaOptions{Timezone: "Europe/Berlin"})
+
+	// Thursday, 22:30 in Berlin
+	now := time.Date(2026, time.October, 15, 20, 30, 45, 500, time.UTC)
+
+	tests := []struct {
+		query  string
+		where  string
+		params []interface{}
+	}{
+		{"createdAt:today", "created_at >= $1 AND created_at < $2",
+			[]interface{}{"2026-10-15T00:00:00+02:00", "2026-10-16T00:00:00+02:00"}},
+		{"createdAt:now", "created_at = $1", []interface{}{"2026-10-15T22:30:45+02:00"}},
+		{"createdAt:[this_month TO now]", "created_at BETWEEN $1 AND $2",
+			[]interface{}{"2026-10-01T00:00:00+02:00", "2026-10-15T22:30:45+02:00"}},
+		{"createdAt:>yesterday", "created_at >= $1", []interface{}{"2026-10-15T00:00:00+02:00"}},
+		{"createdAt:<=today", "created_at < $1", []interface{}{"2026-10-16T00:00:00+02:00"}},
+		{"createdAt:<this_week", "created_at < $1", []interface{}{"2026-10-12T00:00:00+02:00"}},
+		{"createdAt:last_week", "created_at >= $1 AND created_at < $2",
+			[]interface{}{"2026-10-05T00:00:00+02:00", "2026-10-12T00:00:00+02:00"}},
+		{"shipDate:[last_month TO last_month]", "ship_date >= $1 AND ship_date < $2",
+			[]interface{}{"2026-09-01", "2026-10-01"}},
+		{"shipDate:this_year", "ship_date >= $1 AND ship_date < $2", []interface{}{"2026-01-01", "2027-01-01"}},
+		{"shipDate:TOMORROW", "ship_date >= $1 AND ship_date < $2", []interface{}{"2026-10-16", "2026-10-17"}},
+		{"shipDate:(2026-01-01 OR today)", "(ship_date = $1 OR ship_date >= $2 AND ship_date < $3)",
+			[]interface{}{"2026-01-01", "2026-10-15", "2026-10-16"}},
+		{"createdAt:last_7_days", "created_at >= $1 AND created_at < $2",
+			[]interface{}{"2026-10-09T00:00:00+02:00", "2026-10-16T00:00:00+02:00"}},
+		{"shipDate:last_1_days", "ship_date >= $1 AND ship_date < $2", []interface{}{"2026-10-15", "2026-10-16"}},
+		{"shipDate:last_07_days", "ship_date = $1", []interface{}{"last_07_days"}},
+		{"shipDate:last_0_days", "ship_date = $1", []interface{}{"last_0_days"}},
+		{"status:today", "status = $1", []interface{}{"today"}},
+		{"shipDate:2026-10-01", "ship_date = $1", []interface{}{"2026-10-01"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			output, err := NewPostgresTranslator().Translate(ResolveDateKeywords(ast, s, now), s)
+			require.NoError(t, err)
+			assert.Equal(t, tt.where, output.WhereClause)
+			assert.Equal(t, tt.params, output.Parameters)
+		})
+	}
+}
+
+func TestResolveDateKeywords_DefaultsToUTC(t *testing.T) {
+	s := schema.NewSchema("orders", map[string]schema.Field{
+		"createdAt": {Type: schema.TypeDateTime},
+	}, schema.SchemaOptions{})
+
+	ast, err := parser.NewParser("createdAt:today").Parse()
+	require.NoError(t, err)
+
+	now := time.Date(2026, time.October, 15, 23, 0, 0, 0, time.FixedZone("UTC-5", -5*3600))
+	rq, ok := ResolveDateKeywords(ast, s, now).(*parser.RangeQuery)
+	require.True(t, ok)
+	assert.Equal(t, "2026-10-16T00:00:00Z", rq.Start.Value())
+	assert.Equal(t, "2026-10-17T00:00:00Z", rq.End.Value())
+}
+
+func TestUsesDateKeywords(t *testing.T) {
+	for query, want := range map[string]bool{
+		"createdAt:today":               true,
+		"createdAt:[2026-01-01 TO now]": true,
+		"tags:(a OR this_week)":         true,
+		"createdAt:2026-01-01":          false,
+		`name:"today"`:                  false,
+	} {
+		ast, err := parser.NewParser(query).Parse()
+		require.NoError(t, err)
+		assert.Equal(t, want, UsesDateKeywords(ast), query)
+	}
+}
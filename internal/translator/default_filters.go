@@ -0,0 +1,91 @@
+package translator
+
+import (
+	"fmt"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// ValidateDefaultFilters checks that every default filter of the schema is a valid query
+// on fields of the schema that respects their operator whitelists. A filter on a field
+// the schema lacks would only fail at translation, or be dropped with the schema's
+// unknown field policy, so it is rejected up front.
+func ValidateDefaultFilters(s *schema.Schema) error {
+	// The schema may come straight from JSON, without the lookups ResolveField needs
+	resolver := schema.NewSchema(s.Name, s.Fields, s.Options)
+	resolver.Fragments = s.Fragments
+
+	for _, filter := range s.Options.DefaultFilters {
+		ast, err := parser.NewParser(filter.Query).Parse()
+		if err != nil {
+			return fmt.Errorf("default filter %q is not a valid query: %w", filter.Name, err)
+		}
+		var unknown string
+		parser.Walk(ast, func(node parser.Node) bool {
+			if field := nodeField(node); unknown == "" && field != "" && unknownField(field, resolver) {
+				unknown = field
+			}
+			return unknown == ""
+		})
+		if unknown != "" {
+			return fmt.Errorf("default filter %q queries field %s, which is not in the schema", filter.Name, unknown)
+		}
+		if err := CheckOperators(ast, s); err != nil {
+			return fmt.Errorf("default filter %q: %w", filter.Name, err)
+		}
+	}
+	return nil
+}
+
+// ApplyDefaultFilters ANDs the schema's default filters into the query, skipping the
+// filters named in skip. It returns the combined AST and the names of the applied filters.
+// Naming a filter the schema does not define is an error, so typos cannot silently
+// leave a filter in place that the caller believes was removed.
+func ApplyDefaultFilters(ast parser.Node, s *schema.Schema, skip []string) (parser.Node, []string, error) {
+	skipped := make(map[string]bool, len(skip))
+	for _, name := range skip {
+		skipped[name] = true
+	}
+
+	defined := make(map[string]bool, len(s.Options.DefaultFilters))
+	for _, filter := range s.Options.DefaultFilters {
+		defined[filter.Name] = true
+	}
+	for _, name := range skip {
+		if !defined[name] {
+			return nil, nil, fmt.Errorf("default filter %s not found in schema %s", name, s.Name)
+		}
+	}
+
+	var applied []string
+	combined := ast
+	for _, filter := range s.Options.DefaultFilters {
+		if skipped[filter.Name] {
+			continue
+		}
+
+		filterAST, err := parser.NewParser(filter.Query).Parse()
+		if err != nil {
+			return nil, nil, fmt.Errorf("default filter %s: %w", filter.Name, err)
+		}
+
+		// Group both sides so neither can change the other's precedence
+		combined = &parser.BinaryOp{
+			Op:    "AND",
+			Left:  group(combined),
+			Right: group(filterAST),
+		}
+		applied = append(applied, filter.Name)
+	}
+
+	return combined, applied, nil
+}
+
+// group wraps a node in parentheses unless it already is a single group
+func group(node parser.Node) parser.Node {
+	if _, ok := node.(*parser.GroupQuery); ok {
+		return node
+	}
+	return &parser.GroupQuery{Query: node}
+}
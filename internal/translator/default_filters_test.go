@@ -0,0 +1,159 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyDefaultFilters_Postgres(t *testing.T) {
+	s := schema.NewSchema("users", map[string]schema.Field{
+		"name":       {Type: schema.TypeText},
+		"is_active":  {Type: schema.TypeBoolean},
+		"deleted_at": {Type: schema.TypeDate},
+	}, schema.SchemaOptions{
+		DefaultFilters: []schema.DefaultFilter{
+			{Name: "not_deleted", Query: "NOT _exists_:deleted_at"},
+			{Name: "active", Query: "is_active:true"},
+		},
+	})
+
+	ast, err := parser.NewParser("name:foo OR name:bar").Parse()
+	require.NoError(t, err)
+
+	combined, applied, err := ApplyDefaultFilters(ast, s, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"not_deleted", "active"}, applied)
+
+	output, err := NewPostgresTranslator().Translate(combined, s)
+	require.NoError(t, err)
+
+	// The client's OR stays grouped, so it cannot escape the default filters
+	assert.Equal(t, "((name = $1 OR name = $2) AND (NOT deleted_at IS NOT NULL)) AND (is_active = $3)", output.WhereClause)
+	assert.Equal(t, []interface{}{"foo", "bar", "true"}, output.Parameters)
+}
+
+func TestApplyDefaultFilters_Skip(t *testing.T) {
+	s := schema.NewSchema("users", map[string]schema.Field{
+		"name":       {Type: schema.TypeText},
+		"is_active":  {Type: schema.TypeBoolean},
+		"deleted_at": {Type: schema.TypeDate},
+	}, schema.SchemaOptions{
+		DefaultFilters: []schema.DefaultFilter{
+			{Name: "not_deleted", Query: "NOT _exists_:deleted_at"},
+			{Name: "active", Query: "is_active:true"},
+		},
+	})
+
+	ast, err := parser.NewParser("name:foo").Parse()
+	require.NoError(t, err)
+
+	combined, applied, err := ApplyDefaultFilters(ast, s, []string{"not_deleted"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"active"}, applied)
+
+	output, err := NewPostgresTranslator().Translate(combined, s)
+	require.NoError(t, err)
+	assert.NotContains(t, output.WhereClause, "deleted_at")
+}
+
+func TestApplyDefaultFilters_SkipUnknown(t *testing.T) {
+	testSchema := schema.NewSchema("users", map[string]schema.Field{
+		"name":       {Type: schema.TypeText},
+		"is_active":  {Type: schema.TypeBoolean},
+		"deleted_at": {Type: schema.TypeDate},
+	}, schema.SchemaOptions{
+		DefaultFilters: []schema.DefaultFilter{
+			{Name: "not_deleted", Query: "NOT _exists_:deleted_at"},
+			{Name: "active", Query: "is_active:true"},
+		},
+	})
+
+	ast, err := parser.NewParser("name:foo").Parse()
+	require.NoError(t, err)
+
+	_, _, err = ApplyDefaultFilters(ast, testSchema, []string{"not_deletd"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "default filter not_deletd not found")
+}
+
+func TestApplyDefaultFilters_NoFilters(t *testing.T) {
+	s := schema.NewSchema("plain", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+	ast, err := parser.NewParser("name:foo").Parse()
+	require.NoError(t, err)
+
+	combined, applied, err := ApplyDefaultFilters(ast, s, nil)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+	assert.Same(t, ast, combined)
+}
+
+func TestApplyDefaultFilters_MongoDB(t *testing.T) {
+	s := schema.NewSchema("users", map[string]schema.Field{
+		"name":       {Type: schema.TypeText},
+		"is_active":  {Type: schema.TypeBoolean},
+		"deleted_at": {Type: schema.TypeDate},
+	}, schema.SchemaOptions{
+		DefaultFilters: []schema.DefaultFilter{
+			{Name: "not_deleted", Query: "NOT _exists_:deleted_at"},
+			{Name: "active", Query: "is_active:true"},
+		},
+	})
+
+	ast, err := parser.NewParser("name:foo").Parse()
+	require.NoError(t, err)
+
+	combined, _, err := ApplyDefaultFilters(ast, s, []string{"not_deleted"})
+	require.NoError(t, err)
+
+	output, err := NewMongoDBTranslator().Translate(combined, s)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"$and": []interface{}{
+			map[string]interface{}{"name": "foo"},
+			map[string]interface{}{"is_active": "true"},
+		},
+	}, output.Filter)
+}
+
+func TestValidateDefaultFilters(t *testing.T) {
+	testSchema := schema.NewSchema("users", map[string]schema.Field{
+		"name":       {Type: schema.TypeText},
+		"is_active":  {Type: schema.TypeBoolean},
+		"deleted_at": {Type: schema.TypeDate},
+	}, schema.SchemaOptions{
+		DefaultFilters: []schema.DefaultFilter{
+			{Name: "not_deleted", Query: "NOT _exists_:deleted_at"},
+			{Name: "active", Query: "is_active:true"},
+		},
+	})
+
+	assert.NoError(t, ValidateDefaultFilters(testSchema))
+
+	invalid := schema.NewSchema("users", map[string]schema.Field{
+		"is_active": {Type: schema.TypeBoolean},
+	}, schema.SchemaOptions{
+		DefaultFilters: []schema.DefaultFilter{{Name: "active", Query: "(is_active:true"}},
+	})
+	err := ValidateDefaultFilters(invalid)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `default filter "active" is not a valid query`)
+
+	unknown := schema.NewSchema("users", map[string]schema.Field{
+		"is_active": {Type: schema.TypeBoolean, Aliases: []string{"active"}},
+	}, schema.SchemaOptions{
+		DefaultFilters: []schema.DefaultFilter{{Name: "tenant", Query: "active:true AND tenantId:42"}},
+	})
+	assert.EqualError(t, ValidateDefaultFilters(unknown), `default filter "tenant" queries field tenantId, which is not in the schema`)
+
+	// Aliases resolve even when the schema comes straight from JSON
+	decoded := &schema.Schema{Name: "users", Fields: unknown.Fields, Options: schema.SchemaOptions{
+		DefaultFilters: []schema.DefaultFilter{{Name: "active", Query: "active:true"}},
+	}}
+	assert.NoError(t, ValidateDefaultFilters(decoded))
+}
@@ -0,0 +1,135 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// maxValueSuggestions bounds the suggestions of an UnknownValueError
+const maxValueSuggestions = 3
+
+// UnknownValueError is returned for a value missing from its field's dictionary
+type UnknownValueError struct {
+	Field       string   // field as named in the query
+	Value       string   // value as written
+	Dictionary  string   // dictionary of the field
+	Suggestions []string // closest values of the dictionary, best first
+	Pos         parser.Position
+}
+
+func (e *UnknownValueError) Error() string {
+	msg := fmt.Sprintf("value %q of field %s is not in dictionary %s at line %d, column %d",
+		e.Value, e.Field, e.Dictionary, e.Pos.Line, e.Pos.Column)
+	if len(e.Suggestions) > 0 {
+		msg += fmt.Sprintf(", did you mean %s?", strings.Join(e.Suggestions, ", "))
+	}
+	return msg
+}
+
+// DictionaryUnavailableError is returned when a dictionary cannot be loaded, so the
+// values of its fields cannot be checked
+type DictionaryUnavailableError struct {
+	Dictionary string
+	Err        error
+}
+
+func (e *DictionaryUnavailableError) Error() string {
+	return fmt.Sprintf("dictionary %s is unavailable: %v", e.Dictionary, e.Err)
+}
+
+func (e *DictionaryUnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// CheckDictionaries returns an *UnknownValueError for the first value of a field with a
+// dictionary that the dictionary does not hold. Terms, phrases and numbers are checked,
+// in field queries, in-lists and field groups, and free text against the default field;
+// patterns and range bounds are not, as they are not values of the field.
+func CheckDictionaries(ast parser.Node, s *schema.Schema) error {
+	return checkDictionaries(ast, s, s.Options.DefaultField)
+}
+
+// checkDictionaries checks a node whose standalone terms query termField
+func checkDictionaries(node parser.Node, s *schema.Schema, termField string) error {
+	switch n := node.(type) {
+	case *parser.BinaryOp:
+		if err := checkDictionaries(n.Left, s, termField); err != nil {
+			return err
+		}
+		return checkDictionaries(n.Right, s, termField)
+	case *parser.UnaryOp:
+		return checkDictionaries(n.Operand, s, termField)
+	case *parser.GroupQuery:
+		return checkDictionaries(n.Query, s, termField)
+	case *parser.RequiredQuery:
+		return checkDictionaries(n.Query, s, termField)
+	case *parser.ProhibitedQuery:
+		return checkDictionaries(n.Query, s, termField)
+	case *parser.BoostQuery:
+		return checkDictionaries(n.Query, s, termField)
+	case *parser.FieldQuery:
+		return checkDictionaryValue(s, n.Field, n.Value)
+	case *parser.InListQuery:
+		for _, value := range n.Values {
+			if err := checkDictionaryValue(s, n.Field, value); err != nil {
+				return err
+			}
+		}
+	case *parser.FieldGroupQuery:
+		for _, member := range n.Queries {
+			if err := checkDictionaries(member, s, n.Field); err != nil {
+				return err
+			}
+		}
+	case *parser.TermQuery:
+		return checkDictionaryValue(s, termField, &parser.TermValue{Term: n.Term, Pos: n.Pos})
+	case *parser.PhraseQuery:
+		return checkDictionaryValue(s, s.Options.DefaultField, &parser.PhraseValue{Phrase: n.Phrase, Pos: n.Pos})
+	}
+	return nil
+}
+
+// checkDictionaryValue checks a single value against its field's dictionary
+func checkDictionaryValue(s *schema.Schema, fieldName string, value parser.ValueNode) error {
+	if fieldName == "" {
+		return nil
+	}
+	_, field, err := s.ResolveField(fieldName)
+	if err != nil || field.Dictionary == "" {
+		return nil
+	}
+
+	var text string
+	var pos parser.Position
+	switch v := value.(type) {
+	case *parser.TermValue:
+		text, pos = v.Term, v.Pos
+	case *parser.PhraseValue:
+		text, pos = v.Phrase, v.Pos
+	case *parser.NumberValue:
+		text, pos = v.Number, v.Pos
+	default:
+		return nil
+	}
+
+	dictionary, ok := s.Dictionary(field.Dictionary)
+	if !ok {
+		return &DictionaryUnavailableError{Dictionary: field.Dictionary, Err: fmt.Errorf("not registered")}
+	}
+	found, err := dictionary.Contains(text)
+	if err != nil {
+		return &DictionaryUnavailableError{Dictionary: field.Dictionary, Err: err}
+	}
+	if found {
+		return nil
+	}
+
+	unknown := &UnknownValueError{Field: fieldName, Value: text, Dictionary: field.Dictionary, Pos: pos}
+	if values, err := dictionary.Values(); err == nil {
+		unknown.Suggestions = schema.ClosestValues(text, values, maxValueSuggestions)
+	}
+	return unknown
+}
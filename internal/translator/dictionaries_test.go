@@ -0,0 +1,100 @@
+package translator
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listDictionary is a Dictionary of fixed values, or failing with err when set
+type listDictionary struct {
+	values []string
+	err    error
+}
+
+func (d *listDictionary) Contains(value string) (bool, error) {
+	return slices.Contains(d.values, value), d.err
+}
+
+func (d *listDictionary) Values() ([]string, error) {
+	return d.values, d.err
+}
+
+func TestCheckDictionaries(t *testing.T) {
+	registry := schema.NewRegistry()
+	require.NoError(t, registry.RegisterDictionary("currencies", &listDictionary{values: []string{"USD", "EUR", "GBP"}}))
+	s := schema.NewSchema("orders", map[string]schema.Field{
+		"currency": {Type: schema.TypeText, Dictionary: "currencies"},
+		"note":     {Type: schema.TypeText},
+	}, schema.SchemaOptions{DefaultField: "note"})
+	require.NoError(t, registry.Register(s))
+
+	tests := []struct {
+		query       string
+		value       string
+		suggestions []string
+	}{
+		{query: "currency:USD AND note:UDS"},
+		{query: "currency:(USD OR EUR) currency:(GBP, EUR)"},
+		{query: "currency:U* OR currency:[A TO Z]"},
+		{query: "currency:UDS", value: "UDS", suggestions: []string{"USD"}},
+		{query: "currency:(USD, GPB)", value: "GPB", suggestions: []string{"GBP"}},
+		{query: "currency:(EUR OR XYZ)", value: "XYZ"},
+		{query: `NOT currency:"usd"`, value: "usd", suggestions: []string{"USD"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			err = CheckDictionaries(ast, s)
+			if tt.value == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var unknownErr *UnknownValueError
+			require.ErrorAs(t, err, &unknownErr)
+			assert.Equal(t, "currency", unknownErr.Field)
+			assert.Equal(t, tt.value, unknownErr.Value)
+			assert.Equal(t, tt.suggestions, unknownErr.Suggestions)
+		})
+	}
+}
+
+func TestCheckDictionaries_Translate(t *testing.T) {
+	registry := schema.NewRegistry()
+	require.NoError(t, registry.RegisterDictionary("currencies", &listDictionary{values: []string{"USD", "EUR"}}))
+	s := schema.NewSchema("orders", map[string]schema.Field{
+		"currency": {Type: schema.TypeText, Dictionary: "currencies"},
+		"note":     {Type: schema.TypeText},
+	}, schema.SchemaOptions{DefaultField: "note"})
+	require.NoError(t, registry.Register(s))
+	ast, err := parser.NewParser("currency:UDS").Parse()
+	require.NoError(t, err)
+
+	_, err = NewPostgresTranslator().Translate(ast, s)
+	assert.EqualError(t, err, `value "UDS" of field currency is not in dictionary currencies at line 1, column 10, did you mean USD?`)
+}
+
+func TestCheckDictionaries_Unavailable(t *testing.T) {
+	registry := schema.NewRegistry()
+	require.NoError(t, registry.RegisterDictionary("currencies", &listDictionary{err: errors.New("connection refused")}))
+	s := schema.NewSchema("orders", map[string]schema.Field{
+		"currency": {Type: schema.TypeText, Dictionary: "currencies"},
+		"note":     {Type: schema.TypeText},
+	}, schema.SchemaOptions{DefaultField: "note"})
+	require.NoError(t, registry.Register(s))
+	ast, err := parser.NewParser("currency:USD").Parse()
+	require.NoError(t, err)
+
+	err = CheckDictionaries(ast, s)
+	var unavailableErr *DictionaryUnavailableError
+	require.ErrorAs(t, err, &unavailableErr)
+	assert.Equal(t, "currencies", unavailableErr.Dictionary)
+	assert.EqualError(t, err, "dictionary currencies is unavailable: connection refused")
+}
@@ -0,0 +1,149 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslate_Downgrades(t *testing.T) {
+	sch := schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{
+		DefaultField:    "name",
+		EnabledFeatures: schema.EnabledFeatures{Fuzzy: true, Proximity: true},
+	})
+
+	tests := []struct {
+		name       string
+		translator Translator
+		query      string
+		want       []Downgrade
+	}{
+		{"postgres single-word proximity", NewPostgresTranslator(), `name:"laptop"~3`, []Downgrade{{
+			Original: `name:"laptop"~3`, Feature: "proximity", Substitute: rsearch.SubstituteEquality,
+			Reason: "a single word has no distance to other words, so it is compared with the whole value",
+		}}},
+		{"postgres proximity", NewPostgresTranslator(), `"fast car"~3`, []Downgrade{{
+			Original: `"fast car"~3`, Feature: "proximity", Substitute: rsearch.SubstitutePhrase,
+			Reason: "phraseto_tsquery matches the words next to each other, not within 3 words",
+		}}},
+		{"postgres fuzzy", NewPostgresTranslator(), "name:laptop~1", nil},
+		{"mysql fuzzy", NewMySQLTranslator(), "name:laptop~1", []Downgrade{{
+			Original: "name:laptop~1", Feature: "fuzzy", Substitute: rsearch.SubstituteSoundex,
+			Reason: "SOUNDEX matches words that sound alike, ignoring the edit distance of 1",
+		}}},
+		{"mysql proximity", NewMySQLTranslator(), `name:"fast car"~2`, []Downgrade{{
+			Original: `name:"fast car"~2`, Feature: "proximity", Substitute: rsearch.SubstituteAnyWord,
+			Reason: "MATCH ... AGAINST in boolean mode matches any of the words, not all of them within 2 words",
+		}}},
+		{"mongodb fuzzy", NewMongoDBTranslator(), "laptop~2", []Downgrade{{
+			Original: "laptop~2", Feature: "fuzzy", Substitute: rsearch.SubstituteTextSearch,
+			Reason: "$text matches the stemmed word in every field of the text index, ignoring the edit distance of 2 and the field name",
+		}}},
+		{"mongodb proximity", NewMongoDBTranslator(), `name:"fast car"~4`, []Downgrade{{
+			Original: `name:"fast car"~4`, Feature: "proximity", Substitute: rsearch.SubstituteTextSearch,
+			Reason: "$text matches the exact phrase in every field of the text index, not the words within 4 words in name",
+		}}},
+		{"sqlite proximity", NewSQLiteTranslator(), `name:"fast car"~4`, nil},
+		{"no fallback", NewMySQLTranslator(), "name:laptop", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+			output, err := tt.translator.Translate(ast, sch)
+			require.NoError(t, err)
+
+			downgrades, ok := output.Metadata["downgrades"].([]Downgrade)
+			assert.Equal(t, tt.want != nil, ok)
+			assert.Equal(t, tt.want, downgrades)
+		})
+	}
+}
+
+func TestTranslate_DowngradesReset(t *testing.T) {
+	sch := schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{EnabledFeatures: schema.EnabledFeatures{Fuzzy: true}})
+	trans := NewMySQLTranslator()
+
+	ast, err := parser.NewParser("name:laptop~1").Parse()
+	require.NoError(t, err)
+	_, err = trans.Translate(ast, sch)
+	require.NoError(t, err)
+
+	// Downgrades of one translation do not leak into the next
+	ast, err = parser.NewParser("name:laptop").Parse()
+	require.NoError(t, err)
+	output, err := trans.Translate(ast, sch)
+	require.NoError(t, err)
+	assert.NotContains(t, output.Metadata, "downgrades")
+}
+
+func TestCheckStrict(t *testing.T) {
+	sch := schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{EnabledFeatures: schema.EnabledFeatures{Fuzzy: true}})
+
+	translate := func(query string) *TranslatorOutput {
+		ast, err := parser.NewParser(query).Parse()
+		require.NoError(t, err)
+		output, err := NewMySQLTranslator().Translate(ast, sch)
+		require.NoError(t, err)
+		return output
+	}
+
+	assert.NoError(t, CheckStrict(translate("name:laptop")))
+
+	err := CheckStrict(translate("name:laptop~1 OR name:tablet~2"))
+	var downgradeErr *DowngradeError
+	require.ErrorAs(t, err, &downgradeErr)
+	assert.Len(t, downgradeErr.Downgrades, 2)
+	assert.EqualError(t, err, "cannot translate name:laptop~1, name:tablet~2 exactly for this database")
+}
+
+func TestTranslate_Warnings(t *testing.T) {
+	sch := schema.NewSchema("products", map[string]schema.Field{
+		"name":  {Type: schema.TypeText},
+		"price": {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{
+		EnabledFeatures: schema.EnabledFeatures{Fuzzy: true},
+		ReversedRanges:  schema.ReversedRangesSwap,
+	})
+
+	ast, err := parser.NewParser("name:laptop~1 AND price:1e-400 OR price:[9 TO 1]").Parse()
+	require.NoError(t, err)
+	output, err := NewMySQLTranslator().Translate(ast, sch)
+	require.NoError(t, err)
+
+	// Warnings from Prepare come first, then downgrades and coercions
+	assert.Equal(t, []Warning{
+		{
+			Code:     rsearch.WarningRangeSwapped,
+			Message:  "swapped the bounds of the range on field price, which started at 9 after its end 1",
+			Position: 34, Line: 1, Column: 35,
+		},
+		{
+			Code:     rsearch.WarningDowngraded,
+			Message:  "translated name:laptop~1 as soundex: SOUNDEX matches words that sound alike, ignoring the edit distance of 1",
+			Position: 0, Line: 1, Column: 1,
+		},
+		{
+			Code:     rsearch.WarningCoerced,
+			Message:  "bound value 1e-400 of field price as 0, since it is too small for a 64-bit float",
+			Position: 24, Line: 1, Column: 25,
+		},
+	}, output.Metadata["warnings"])
+
+	ast, err = parser.NewParser("name:laptop").Parse()
+	require.NoError(t, err)
+	output, err = NewMySQLTranslator().Translate(ast, sch)
+	require.NoError(t, err)
+	assert.NotContains(t, output.Metadata, "warnings")
+}
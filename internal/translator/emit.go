@@ -0,0 +1,89 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/infiniv/rsearch/internal/ir"
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// Translators lower the prepared AST with ir.Lower and emit their dialect from the
+// lowered nodes, so field resolution, default fields, field groups and negated lists
+// are handled once for every database. The helpers below are shared by the emitters.
+
+// joinClauses joins the SQL of the clauses of an ir.And or ir.Or with AND or OR,
+// parenthesizing clauses that are themselves joined. Groups bring their own parentheses.
+func joinClauses(nodes []ir.Node, clauses []string, operator string) string {
+	for i, clause := range clauses {
+		switch ir.Unwrap(nodes[i]).(type) {
+		case *ir.And, *ir.Or:
+			clauses[i] = fmt.Sprintf("(%s)", clause)
+		}
+	}
+	return strings.Join(clauses, " "+operator+" ")
+}
+
+// negateClause negates the SQL of a clause, parenthesizing joined clauses and SQL that
+// spans several conditions, such as the exists check of a JSON column. Clauses
+// prohibited with - are negated as they are.
+func negateClause(not *ir.Not, operand string) string {
+	if not.Prohibited {
+		return fmt.Sprintf("NOT %s", operand)
+	}
+	switch ir.Unwrap(not.Clause).(type) {
+	case *ir.And, *ir.Or:
+		return fmt.Sprintf("NOT (%s)", operand)
+	}
+	if strings.Contains(operand, " AND ") || strings.Contains(operand, " OR ") {
+		return fmt.Sprintf("NOT (%s)", operand)
+	}
+	return fmt.Sprintf("NOT %s", operand)
+}
+
+// likePattern converts a wildcard pattern to a LIKE pattern
+func likePattern(pattern string) string {
+	pattern = strings.ReplaceAll(pattern, "*", "%")
+	return strings.ReplaceAll(pattern, "?", "_")
+}
+
+// boostMetadata returns the metadata recorded for a boost: the snake_case type of the
+// boosted query and its boost, and for a boosted single-term query its field and term,
+// so scorers can weight matches of the term, such as a synonym, on their own
+func boostMetadata(boost *ir.Boost, s *schema.Schema) map[string]interface{} {
+	boostInfo := map[string]interface{}{
+		"query": toSnakeCase(boost.Query.Type()),
+		"boost": boost.Boost,
+	}
+
+	switch n := boost.Query.(type) {
+	case *parser.TermQuery:
+		if s.Options.DefaultField != "" {
+			boostInfo["field"] = s.Options.DefaultField
+			boostInfo["term"] = n.Term
+		}
+	case *parser.FieldQuery:
+		if term, ok := n.Value.(*parser.TermValue); ok {
+			boostInfo["field"] = n.Field
+			boostInfo["term"] = term.Term
+		}
+	}
+	return boostInfo
+}
+
+// toSnakeCase converts CamelCase to snake_case
+func toSnakeCase(s string) string {
+	var result strings.Builder
+	for i, r := range s {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			result.WriteRune('_')
+		}
+		if r >= 'A' && r <= 'Z' {
+			result.WriteRune(r - 'A' + 'a')
+		} else {
+			result.WriteRune(r)
+		}
+	}
+	return result.String()
+}
@@ -0,0 +1,47 @@
+package translator
+
+import (
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// ResolveExistenceWildcards returns a copy of the AST in which a value that is only a
+// wildcard, as in status:* or status:(* OR unknown), checks that the field exists, as
+// OpenSearch reads it: status:* becomes _exists_:status, which matches non-null values
+// in every dialect, where LIKE '%' and the match-all regex differ on empty strings.
+// Schemas with legacyWildcardOnly keep the wildcard.
+func ResolveExistenceWildcards(ast parser.Node, s *schema.Schema) parser.Node {
+	if s.Options.LegacyWildcardOnly {
+		return ast
+	}
+
+	return parser.Transform(ast, func(node parser.Node) parser.Node {
+		switch n := node.(type) {
+		case *parser.FieldQuery:
+			if wildcard, ok := n.Value.(*parser.WildcardValue); ok && wildcard.Pattern == "*" {
+				return &parser.ExistsQuery{Field: n.Field, Pos: n.Pos}
+			}
+		case *parser.FieldGroupQuery:
+			queries := make([]parser.Node, len(n.Queries))
+			for i, q := range n.Queries {
+				queries[i] = existenceMember(q, n.Field)
+			}
+			return &parser.FieldGroupQuery{Field: n.Field, Queries: queries, Pos: n.Pos}
+		}
+		return node
+	})
+}
+
+// existenceMember rewrites the wildcard-only members of a field group, including those
+// joined to other members, to existence checks on the group's field
+func existenceMember(node parser.Node, field string) parser.Node {
+	switch n := node.(type) {
+	case *parser.WildcardQuery:
+		if n.Pattern == "*" {
+			return &parser.ExistsQuery{Field: field, Pos: n.Pos}
+		}
+	case *parser.BinaryOp:
+		return &parser.BinaryOp{Op: n.Op, Left: existenceMember(n.Left, field), Right: existenceMember(n.Right, field), Pos: n.Pos, Implicit: n.Implicit}
+	}
+	return node
+}
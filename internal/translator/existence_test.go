@@ -0,0 +1,99 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveExistenceWildcards(t *testing.T) {
+	fields := map[string]schema.Field{
+		"name":   {Type: schema.TypeText},
+		"status": {Type: schema.TypeText},
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		want   string
+		legacy string // translation with legacyWildcardOnly
+	}{
+		{
+			name:   "field value",
+			query:  "status:*",
+			want:   "status IS NOT NULL",
+			legacy: "status LIKE $1",
+		},
+		{
+			name:   "negated",
+			query:  "NOT status:*",
+			want:   "NOT status IS NOT NULL",
+			legacy: "NOT status LIKE $1",
+		},
+		{
+			name:   "field group member",
+			query:  "status:(* OR draft)",
+			want:   "(status IS NOT NULL OR status = $1)",
+			legacy: "(status LIKE $1 OR status = $2)",
+		},
+		{
+			name:   "patterns are kept",
+			query:  "status:act*",
+			want:   "status LIKE $1",
+			legacy: "status LIKE $1",
+		},
+		{
+			name:   "standalone wildcard is kept",
+			query:  "*",
+			want:   "name LIKE $1",
+			legacy: "name LIKE $1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			s := schema.NewSchema("products", fields, schema.SchemaOptions{DefaultField: "name"})
+			output, err := NewPostgresTranslator().Translate(ast, s)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, output.WhereClause)
+
+			legacy := schema.NewSchema("products", fields, schema.SchemaOptions{DefaultField: "name", LegacyWildcardOnly: true})
+			output, err = NewPostgresTranslator().Translate(ast, legacy)
+			require.NoError(t, err)
+			assert.Equal(t, tt.legacy, output.WhereClause)
+		})
+	}
+}
+
+func TestResolveExistenceWildcards_MongoDB(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{"status": {Type: schema.TypeText}}, schema.SchemaOptions{})
+	ast, err := parser.NewParser("status:*").Parse()
+	require.NoError(t, err)
+
+	output, err := NewMongoDBTranslator().Translate(ast, s)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"status": map[string]interface{}{"$exists": true, "$ne": nil}}, output.Filter)
+}
+
+func TestResolveExistenceWildcards_Operators(t *testing.T) {
+	// The check counts as the exists operator, so fields need not allow wildcards
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"status": {Type: schema.TypeText, Operators: []string{"term", "exists"}},
+	}, schema.SchemaOptions{})
+	ast, err := parser.NewParser("status:*").Parse()
+	require.NoError(t, err)
+
+	_, err = NewPostgresTranslator().Translate(ast, s)
+	assert.NoError(t, err)
+
+	legacy := schema.NewSchema("products", s.Fields, schema.SchemaOptions{LegacyWildcardOnly: true})
+	_, err = NewPostgresTranslator().Translate(ast, legacy)
+	var notAllowed *OperatorNotAllowedError
+	assert.ErrorAs(t, err, &notAllowed)
+}
@@ -0,0 +1,47 @@
+package translator
+
+import (
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// Explanation describes how a query was interpreted
+type Explanation = rsearch.Explanation
+
+// Explain traces the resolution of every field named in the query, in the order the
+// fields first appear, and lists the values written as value aliases
+func Explain(ast parser.Node, s *schema.Schema) *Explanation {
+	explanation := &Explanation{Fields: []schema.FieldResolution{}, Values: ValueAliases(ast, s)}
+	seen := make(map[string]bool)
+	parser.Walk(ast, func(node parser.Node) bool {
+		name := nodeField(node)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			explanation.Fields = append(explanation.Fields, s.TraceResolution(name))
+		}
+		return true
+	})
+	return explanation
+}
+
+// nodeField returns the field a node queries, if any
+func nodeField(node parser.Node) string {
+	switch n := node.(type) {
+	case *parser.FieldQuery:
+		return n.Field
+	case *parser.FieldGroupQuery:
+		return n.Field
+	case *parser.InListQuery:
+		return n.Field
+	case *parser.RangeQuery:
+		return n.Field
+	case *parser.FuzzyQuery:
+		return n.Field
+	case *parser.ProximityQuery:
+		return n.Field
+	case *parser.ExistsQuery:
+		return n.Field
+	}
+	return ""
+}
@@ -0,0 +1,168 @@
+package translator
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/schema"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// The filters of MongoDB translations hold values as the query wrote them: dates are
+// strings and integers are JSON numbers, which clients decode as float64, losing
+// precision beyond 2^53. BSONFilter types the values by the schema fields they are
+// compared with, for use with the official driver, and ExtendedJSON encodes them as
+// canonical Extended JSON, with $date and $numberLong, so the types survive the JSON
+// transport of the API.
+
+// Operators whose values are never typed by the field: patterns, options and searches
+var untypedOperators = map[string]bool{
+	"$regex":   true,
+	"$options": true,
+	"$text":    true,
+	"$exists":  true,
+}
+
+// BSONFilter converts a MongoDB filter to a bson.D. Values of integer fields become
+// int64, of float fields float64, of boolean fields bool, and of date and datetime
+// fields bson.DateTime, with dates at midnight in the schema's time zone. Values that
+// do not parse as the field's type are left as they are. Keys are sorted, so the same
+// filter always converts to the same document.
+func BSONFilter(filter interface{}, s *schema.Schema) bson.D {
+	document, _ := filter.(map[string]interface{})
+	return bsonDocument(document, nil, columnFields(s), s.Location())
+}
+
+// ExtendedJSON encodes a MongoDB filter as the canonical Extended JSON of its BSONFilter
+func ExtendedJSON(filter interface{}, s *schema.Schema) (json.RawMessage, error) {
+	encoded, err := bson.MarshalExtJSON(BSONFilter(filter, s), true, false)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(encoded), nil
+}
+
+// columnFields maps the document keys of a schema's fields to the fields
+func columnFields(s *schema.Schema) map[string]*schema.Field {
+	fields := make(map[string]*schema.Field, len(s.Fields))
+	for name := range s.Fields {
+		if column, field, err := s.ResolveField(name); err == nil {
+			fields[column] = field
+		}
+	}
+	return fields
+}
+
+// bsonDocument converts a document. Keys that are not operators name the field their
+// value is compared with; operators compare with the field of the enclosing key.
+func bsonDocument(document map[string]interface{}, field *schema.Field, fields map[string]*schema.Field, location *time.Location) bson.D {
+	keys := make([]string, 0, len(document))
+	for key := range document {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	converted := make(bson.D, 0, len(keys))
+	for _, key := range keys {
+		value := document[key]
+		switch {
+		case untypedOperators[key]:
+			value = bsonValue(value, nil, fields, location)
+		case strings.HasPrefix(key, "$"):
+			value = bsonValue(value, field, fields, location)
+		default:
+			value = bsonValue(value, fields[key], fields, location)
+		}
+		converted = append(converted, bson.E{Key: key, Value: value})
+	}
+	return converted
+}
+
+// bsonValue converts a value compared with field, or a nested document or array
+func bsonValue(value interface{}, field *schema.Field, fields map[string]*schema.Field, location *time.Location) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return bsonDocument(v, field, fields, location)
+	case []interface{}:
+		converted := make(bson.A, len(v))
+		for i, element := range v {
+			converted[i] = bsonValue(element, field, fields, location)
+		}
+		return converted
+	}
+	if field == nil {
+		return value
+	}
+
+	switch field.Type {
+	case schema.TypeInteger:
+		return bsonInteger(value)
+	case schema.TypeFloat:
+		return bsonFloat(value)
+	case schema.TypeBoolean:
+		if str, ok := value.(string); ok {
+			if b, err := strconv.ParseBool(str); err == nil {
+				return b
+			}
+		}
+	case schema.TypeDateTime, schema.TypeDate:
+		if str, ok := value.(string); ok {
+			if t, ok := parseBSONDate(str, location); ok {
+				return bson.NewDateTimeFromTime(t)
+			}
+		}
+	}
+	return value
+}
+
+// bsonInteger converts an integer value to int64
+func bsonInteger(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<63 {
+			return int64(v)
+		}
+	case string:
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i
+		}
+	}
+	return value
+}
+
+// bsonFloat converts a float value to float64
+func bsonFloat(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return value
+}
+
+// parseBSONDate parses an RFC 3339 timestamp, or a date or timestamp without a zone in
+// the schema's time zone
+func parseBSONDate(value string, location *time.Location) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, true
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
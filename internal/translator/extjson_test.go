@@ -0,0 +1,87 @@
+package translator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func translateMongo(t *testing.T, query string, s *schema.Schema) interface{} {
+	t.Helper()
+	ast, err := parser.NewParser(query).Parse()
+	require.NoError(t, err)
+	output, err := NewMongoDBTranslator().Translate(ast, s)
+	require.NoError(t, err)
+	return output.Filter
+}
+
+func TestBSONFilter_TypesValuesByField(t *testing.T) {
+	s := schema.NewSchema("orders", map[string]schema.Field{
+		"orderId":   {Type: schema.TypeInteger},
+		"total":     {Type: schema.TypeFloat},
+		"paid":      {Type: schema.TypeBoolean},
+		"createdAt": {Type: schema.TypeDateTime},
+		"status":    {Type: schema.TypeText},
+	}, schema.SchemaOptions{NamingConvention: "snake_case", Timezone: "Europe/Paris"})
+
+	filter := translateMongo(t, "orderId:9007199254740993 AND paid:true AND createdAt:[2024-01-01 TO \"2024-02-01T12:00:00Z\"} AND status:(open OR held)", s)
+
+	paris, _ := time.LoadLocation("Europe/Paris")
+	assert.Equal(t, bson.D{{Key: "$and", Value: bson.A{
+		bson.D{{Key: "order_id", Value: int64(9007199254740993)}},
+		bson.D{{Key: "paid", Value: true}},
+		bson.D{{Key: "created_at", Value: bson.D{
+			{Key: "$gte", Value: bson.NewDateTimeFromTime(time.Date(2024, 1, 1, 0, 0, 0, 0, paris))},
+			{Key: "$lt", Value: bson.NewDateTimeFromTime(time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC))},
+		}}},
+		bson.D{{Key: "$or", Value: bson.A{
+			bson.D{{Key: "status", Value: "open"}},
+			bson.D{{Key: "status", Value: "held"}},
+		}}},
+	}}}, BSONFilter(filter, s))
+}
+
+func TestBSONFilter_LeavesPatternsAndUnparseableValues(t *testing.T) {
+	s := schema.NewSchema("orders", map[string]schema.Field{
+		"orderId":   {Type: schema.TypeInteger},
+		"total":     {Type: schema.TypeFloat},
+		"paid":      {Type: schema.TypeBoolean},
+		"createdAt": {Type: schema.TypeDateTime},
+		"status":    {Type: schema.TypeText},
+	}, schema.SchemaOptions{NamingConvention: "snake_case", Timezone: "Europe/Paris"})
+
+	assert.Equal(t, bson.D{{Key: "created_at", Value: "yesterday-ish"}},
+		BSONFilter(map[string]interface{}{"created_at": "yesterday-ish"}, s))
+	assert.Equal(t, bson.D{{Key: "order_id", Value: bson.D{{Key: "$exists", Value: true}}}},
+		BSONFilter(map[string]interface{}{"order_id": map[string]interface{}{"$exists": true}}, s))
+	assert.Equal(t, bson.D{{Key: "total", Value: bson.D{{Key: "$in", Value: bson.A{1.5, 2.0}}}}},
+		BSONFilter(map[string]interface{}{"total": map[string]interface{}{"$in": []interface{}{"1.5", 2}}}, s))
+}
+
+func TestExtendedJSON(t *testing.T) {
+	s := schema.NewSchema("orders", map[string]schema.Field{
+		"orderId":   {Type: schema.TypeInteger},
+		"total":     {Type: schema.TypeFloat},
+		"paid":      {Type: schema.TypeBoolean},
+		"createdAt": {Type: schema.TypeDateTime},
+		"status":    {Type: schema.TypeText},
+	}, schema.SchemaOptions{NamingConvention: "snake_case", Timezone: "Europe/Paris"})
+
+	filter := translateMongo(t, "orderId:42 AND createdAt:\"2024-03-01T10:00:00Z\"", s)
+
+	encoded, err := ExtendedJSON(filter, s)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"$and": [
+		{"order_id": {"$numberLong": "42"}},
+		{"created_at": {"$date": {"$numberLong": "1709287200000"}}}
+	]}`, string(encoded))
+
+	var decoded bson.D
+	require.NoError(t, bson.UnmarshalExtJSON(encoded, true, &decoded))
+	assert.Equal(t, BSONFilter(filter, s), decoded)
+}
@@ -0,0 +1,258 @@
+package translator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// FacetRequest asks for counts of the matching rows per bucket of a field
+type FacetRequest = rsearch.FacetRequest
+
+// Facet is a facet translated for one database
+type Facet = rsearch.Facet
+
+// timezoneRegex matches IANA time zone names, which are inlined into SQL as literals
+var timezoneRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_+\-/]*$`)
+
+// havingOperators maps the comparisons a facet's having conditions may use to MongoDB
+// query operators
+var havingOperators = map[string]string{
+	"=":  "$eq",
+	"!=": "$ne",
+	">":  "$gt",
+	">=": "$gte",
+	"<":  "$lt",
+	"<=": "$lte",
+}
+
+// BuildFacets translates facet requests for the database an output was translated
+// for. SQL facets reuse the output's WHERE clause and parameters; MongoDB facets are
+// pipelines that start by matching filter. Requests are validated against the schema:
+// names must be unique, date histograms need a date field and a known interval, and
+// having conditions need a known operator and a count that is not negative.
+func BuildFacets(requests []FacetRequest, s *schema.Schema, dbType string, filter interface{}) ([]Facet, error) {
+	if len(requests) == 0 {
+		return nil, nil
+	}
+
+	facets := make([]Facet, 0, len(requests))
+	names := make(map[string]bool, len(requests))
+	for _, req := range requests {
+		if req.Name == "" {
+			return nil, fmt.Errorf("facet on %s has no name", req.Field)
+		}
+		if names[req.Name] {
+			return nil, fmt.Errorf("duplicate facet name %s", req.Name)
+		}
+		names[req.Name] = true
+
+		facet, err := buildFacet(req, s, dbType, filter)
+		if err != nil {
+			return nil, fmt.Errorf("facet %s: %w", req.Name, err)
+		}
+		facets = append(facets, facet)
+	}
+	return facets, nil
+}
+
+// buildFacet translates one facet request: its bucket expression, counted per bucket,
+// filtered by the having conditions and ordered by bucket for histograms or by count,
+// most frequent first, for terms
+func buildFacet(req FacetRequest, s *schema.Schema, dbType string, filter interface{}) (Facet, error) {
+	var bucket interface{}
+	var err error
+	switch req.Type {
+	case rsearch.FacetDateHistogram:
+		bucket, err = dateHistogram(req, s, dbType)
+	case rsearch.FacetTerms:
+		bucket, err = terms(req, s, dbType)
+	default:
+		err = fmt.Errorf("unknown type %q, expected %q or %q", req.Type, rsearch.FacetDateHistogram, rsearch.FacetTerms)
+	}
+	if err != nil {
+		return Facet{}, err
+	}
+	if req.Size < 0 {
+		return Facet{}, fmt.Errorf("size must not be negative, got %d", req.Size)
+	}
+	if req.Size > 0 && req.Type != rsearch.FacetTerms {
+		return Facet{}, fmt.Errorf("size only applies to %s facets", rsearch.FacetTerms)
+	}
+	for _, cond := range req.Having {
+		if _, ok := havingOperators[cond.Op]; !ok {
+			return Facet{}, fmt.Errorf("unknown having operator %q, expected =, !=, >, >=, < or <=", cond.Op)
+		}
+		if cond.Count < 0 {
+			return Facet{}, fmt.Errorf("having count must not be negative, got %d", cond.Count)
+		}
+	}
+
+	facet := Facet{Name: req.Name, Type: req.Type}
+	if dbType == "mongodb" {
+		facet.Pipeline = []map[string]interface{}{
+			{"$match": filter},
+			{"$group": map[string]interface{}{
+				"_id":   bucket,
+				"count": map[string]interface{}{"$sum": 1},
+			}},
+		}
+		if len(req.Having) > 0 {
+			facet.Pipeline = append(facet.Pipeline, map[string]interface{}{"$match": mongoHaving(req.Having)})
+		}
+		// Pipelines travel as JSON objects, whose keys lose their order, so ties in
+		// count are not broken by bucket as in SQL
+		sort := map[string]interface{}{"_id": 1}
+		if req.Type == rsearch.FacetTerms {
+			sort = map[string]interface{}{"count": -1}
+		}
+		facet.Pipeline = append(facet.Pipeline, map[string]interface{}{"$sort": sort})
+		if req.Size > 0 {
+			facet.Pipeline = append(facet.Pipeline, map[string]interface{}{"$limit": req.Size})
+		}
+		return facet, nil
+	}
+
+	expr := bucket.(string)
+	facet.SelectClause = expr + " AS bucket, COUNT(*) AS count"
+	facet.GroupByClause = expr
+	facet.HavingClause = sqlHaving(req.Having)
+	facet.OrderByClause = "bucket"
+	if req.Type == rsearch.FacetTerms {
+		facet.OrderByClause = "count DESC, bucket"
+	}
+	facet.Limit = req.Size
+	return facet, nil
+}
+
+// sqlHaving joins having conditions into a HAVING clause. Counts are integers, so they
+// are inlined rather than bound as parameters, which keeps the query's parameters
+// valid for the facet.
+func sqlHaving(conditions []rsearch.FacetHaving) string {
+	clauses := make([]string, len(conditions))
+	for i, cond := range conditions {
+		// sqllint:trusted cond.Op is one of the havingOperators, checked by buildFacet
+		clauses[i] = fmt.Sprintf("COUNT(*) %s %d", cond.Op, cond.Count)
+	}
+	return strings.Join(clauses, " AND ")
+}
+
+// mongoHaving builds the $match stage that filters grouped buckets by count
+func mongoHaving(conditions []rsearch.FacetHaving) map[string]interface{} {
+	clauses := make([]interface{}, len(conditions))
+	for i, cond := range conditions {
+		clauses[i] = map[string]interface{}{
+			"count": map[string]interface{}{havingOperators[cond.Op]: cond.Count},
+		}
+	}
+	if len(clauses) == 1 {
+		return clauses[0].(map[string]interface{})
+	}
+	return map[string]interface{}{"$and": clauses}
+}
+
+// terms buckets a field by its distinct values
+func terms(req FacetRequest, s *schema.Schema, dbType string) (interface{}, error) {
+	column, _, err := s.ResolveField(req.Field)
+	if err != nil {
+		return nil, err
+	}
+	if req.Interval != "" || req.Timezone != "" {
+		return nil, fmt.Errorf("interval and timezone only apply to %s facets", rsearch.FacetDateHistogram)
+	}
+	switch dbType {
+	case "postgres", "mysql", "sqlite":
+		return column, nil
+	case "mongodb":
+		return "$" + column, nil
+	default:
+		return nil, fmt.Errorf("facets are not supported for database %s", dbType)
+	}
+}
+
+// dateHistogram buckets a date field by calendar interval, starting buckets at midnight
+// in the facet's time zone. Date columns are taken to hold UTC instants (timestamptz in
+// PostgreSQL). It returns the bucket expression for SQL or the group key for MongoDB.
+func dateHistogram(req FacetRequest, s *schema.Schema, dbType string) (interface{}, error) {
+	column, field, err := s.ResolveField(req.Field)
+	if err != nil {
+		return nil, err
+	}
+	if field.Type != schema.TypeDate {
+		return nil, fmt.Errorf("date histograms need a date field, %s is %s", req.Field, field.Type)
+	}
+
+	switch req.Interval {
+	case rsearch.IntervalDay, rsearch.IntervalWeek, rsearch.IntervalMonth:
+	default:
+		return nil, fmt.Errorf("unknown interval %q, expected day, week or month", req.Interval)
+	}
+
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = s.Options.Timezone
+	}
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil || !timezoneRegex.MatchString(timezone) {
+		return nil, fmt.Errorf("unknown time zone %q", timezone)
+	}
+	utc := timezone == "UTC"
+
+	var bucket string
+	// sqllint:trusted the interval is day, week or month, the time zone matches
+	// timezoneRegex and date is the column, converted to the time zone
+	switch dbType {
+	case "postgres":
+		date := column
+		if !utc {
+			date = fmt.Sprintf("%s AT TIME ZONE '%s'", column, timezone)
+		}
+		bucket = fmt.Sprintf("date_trunc('%s', %s)", req.Interval, date)
+	case "mysql":
+		date := column
+		if !utc {
+			// Named zones need MySQL's time zone tables to be loaded
+			date = fmt.Sprintf("CONVERT_TZ(%s, 'UTC', '%s')", column, timezone)
+		}
+		switch req.Interval {
+		case rsearch.IntervalDay:
+			bucket = fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m-%%d')", date)
+		case rsearch.IntervalWeek:
+			bucket = fmt.Sprintf("DATE_FORMAT(DATE_SUB(%s, INTERVAL WEEKDAY(%s) DAY), '%%Y-%%m-%%d')", date, date)
+		case rsearch.IntervalMonth:
+			bucket = fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m-01')", date)
+		}
+	case "sqlite":
+		if !utc {
+			return nil, fmt.Errorf("time zone %s is not supported in SQLite, which only buckets in UTC", timezone)
+		}
+		switch req.Interval {
+		case rsearch.IntervalDay:
+			bucket = fmt.Sprintf("strftime('%%Y-%%m-%%d', %s)", column)
+		case rsearch.IntervalWeek:
+			// The Sunday ending the week, less six days
+			bucket = fmt.Sprintf("strftime('%%Y-%%m-%%d', %s, 'weekday 0', '-6 days')", column)
+		case rsearch.IntervalMonth:
+			bucket = fmt.Sprintf("strftime('%%Y-%%m-01', %s)", column)
+		}
+	case "mongodb":
+		trunc := map[string]interface{}{
+			"date":     "$" + column,
+			"unit":     req.Interval,
+			"timezone": timezone,
+		}
+		if req.Interval == rsearch.IntervalWeek {
+			trunc["startOfWeek"] = "monday"
+		}
+		return map[string]interface{}{"$dateTrunc": trunc}, nil
+	default:
+		return nil, fmt.Errorf("facets are not supported for database %s", dbType)
+	}
+	return bucket, nil
+}
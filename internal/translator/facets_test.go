@@ -0,0 +1,201 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildFacets_DateHistogramSQL(t *testing.T) {
+	testSchema := schema.NewSchema("orders", map[string]schema.Field{
+		"createdAt": {Type: schema.TypeDate, Column: "created_at"},
+		"status":    {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+
+	tests := []struct {
+		name     string
+		dbType   string
+		interval string
+		timezone string
+		bucket   string
+	}{
+		{"postgres day", "postgres", "day", "", "date_trunc('day', created_at)"},
+		{"postgres week in zone", "postgres", "week", "Europe/Berlin", "date_trunc('week', created_at AT TIME ZONE 'Europe/Berlin')"},
+		{"postgres month", "postgres", "month", "UTC", "date_trunc('month', created_at)"},
+		{"mysql day", "mysql", "day", "", "DATE_FORMAT(created_at, '%Y-%m-%d')"},
+		{"mysql week", "mysql", "week", "", "DATE_FORMAT(DATE_SUB(created_at, INTERVAL WEEKDAY(created_at) DAY), '%Y-%m-%d')"},
+		{"mysql month in zone", "mysql", "month", "America/New_York", "DATE_FORMAT(CONVERT_TZ(created_at, 'UTC', 'America/New_York'), '%Y-%m-01')"},
+		{"sqlite day", "sqlite", "day", "", "strftime('%Y-%m-%d', created_at)"},
+		{"sqlite week", "sqlite", "week", "", "strftime('%Y-%m-%d', created_at, 'weekday 0', '-6 days')"},
+		{"sqlite month", "sqlite", "month", "", "strftime('%Y-%m-01', created_at)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			facets, err := BuildFacets([]FacetRequest{{
+				Name: "orders_over_time", Type: rsearch.FacetDateHistogram, Field: "createdAt", Interval: tt.interval, Timezone: tt.timezone,
+			}}, testSchema, tt.dbType, nil)
+			require.NoError(t, err)
+			require.Len(t, facets, 1)
+			assert.Equal(t, Facet{
+				Name:          "orders_over_time",
+				Type:          rsearch.FacetDateHistogram,
+				SelectClause:  tt.bucket + " AS bucket, COUNT(*) AS count",
+				GroupByClause: tt.bucket,
+				OrderByClause: "bucket",
+			}, facets[0])
+		})
+	}
+}
+
+func TestBuildFacets_DateHistogramMongoDB(t *testing.T) {
+	testSchema := schema.NewSchema("orders", map[string]schema.Field{
+		"createdAt": {Type: schema.TypeDate, Column: "created_at"},
+		"status":    {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+
+	filter := map[string]interface{}{"status": "paid"}
+	facets, err := BuildFacets([]FacetRequest{{
+		Name: "weekly", Type: rsearch.FacetDateHistogram, Field: "createdAt", Interval: "week", Timezone: "Europe/Berlin",
+	}}, testSchema, "mongodb", filter)
+	require.NoError(t, err)
+	require.Len(t, facets, 1)
+	assert.Equal(t, []map[string]interface{}{
+		{"$match": filter},
+		{"$group": map[string]interface{}{
+			"_id": map[string]interface{}{"$dateTrunc": map[string]interface{}{
+				"date": "$created_at", "unit": "week", "timezone": "Europe/Berlin", "startOfWeek": "monday",
+			}},
+			"count": map[string]interface{}{"$sum": 1},
+		}},
+		{"$sort": map[string]interface{}{"_id": 1}},
+	}, facets[0].Pipeline)
+}
+
+func TestBuildFacets_TermsSQL(t *testing.T) {
+	testSchema := schema.NewSchema("orders", map[string]schema.Field{
+		"createdAt": {Type: schema.TypeDate, Column: "created_at"},
+		"status":    {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+
+	for _, dbType := range []string{"postgres", "mysql", "sqlite"} {
+		t.Run(dbType, func(t *testing.T) {
+			facets, err := BuildFacets([]FacetRequest{{
+				Name: "popular", Type: rsearch.FacetTerms, Field: "status", Size: 5,
+				Having: []rsearch.FacetHaving{{Op: ">", Count: 10}, {Op: "<=", Count: 1000}},
+			}}, testSchema, dbType, nil)
+			require.NoError(t, err)
+			assert.Equal(t, []Facet{{
+				Name:          "popular",
+				Type:          rsearch.FacetTerms,
+				SelectClause:  "status AS bucket, COUNT(*) AS count",
+				GroupByClause: "status",
+				HavingClause:  "COUNT(*) > 10 AND COUNT(*) <= 1000",
+				OrderByClause: "count DESC, bucket",
+				Limit:         5,
+			}}, facets)
+		})
+	}
+}
+
+func TestBuildFacets_TermsMongoDB(t *testing.T) {
+	testSchema := schema.NewSchema("orders", map[string]schema.Field{
+		"createdAt": {Type: schema.TypeDate, Column: "created_at"},
+		"status":    {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+
+	filter := map[string]interface{}{"status": "paid"}
+	facets, err := BuildFacets([]FacetRequest{{
+		Name: "popular", Type: rsearch.FacetTerms, Field: "status", Size: 5,
+		Having: []rsearch.FacetHaving{{Op: ">", Count: 10}},
+	}}, testSchema, "mongodb", filter)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"$match": filter},
+		{"$group": map[string]interface{}{
+			"_id":   "$status",
+			"count": map[string]interface{}{"$sum": 1},
+		}},
+		{"$match": map[string]interface{}{"count": map[string]interface{}{"$gt": int64(10)}}},
+		{"$sort": map[string]interface{}{"count": -1}},
+		{"$limit": 5},
+	}, facets[0].Pipeline)
+}
+
+func TestBuildFacets_HistogramHaving(t *testing.T) {
+	testSchema := schema.NewSchema("orders", map[string]schema.Field{
+		"createdAt": {Type: schema.TypeDate, Column: "created_at"},
+		"status":    {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+
+	having := []rsearch.FacetHaving{{Op: ">=", Count: 1}, {Op: "!=", Count: 7}}
+
+	facets, err := BuildFacets([]FacetRequest{{Name: "daily", Type: rsearch.FacetDateHistogram, Field: "createdAt", Interval: "day", Having: having}}, testSchema, "postgres", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "COUNT(*) >= 1 AND COUNT(*) != 7", facets[0].HavingClause)
+	assert.Equal(t, "bucket", facets[0].OrderByClause)
+
+	// Several conditions on the count are joined with $and, as they may repeat operators
+	facets, err = BuildFacets([]FacetRequest{{Name: "daily", Type: rsearch.FacetDateHistogram, Field: "createdAt", Interval: "day", Having: having}}, testSchema, "mongodb", nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"$match": map[string]interface{}{"$and": []interface{}{
+		map[string]interface{}{"count": map[string]interface{}{"$gte": int64(1)}},
+		map[string]interface{}{"count": map[string]interface{}{"$ne": int64(7)}},
+	}}}, facets[0].Pipeline[2])
+}
+
+func TestBuildFacets_SchemaTimezone(t *testing.T) {
+	sch := schema.NewSchema("orders", map[string]schema.Field{
+		"createdAt": {Type: schema.TypeDate, Column: "created_at"},
+	}, schema.SchemaOptions{Timezone: "Asia/Tokyo"})
+
+	facets, err := BuildFacets([]FacetRequest{{Name: "daily", Type: rsearch.FacetDateHistogram, Field: "createdAt", Interval: "day"}}, sch, "postgres", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "date_trunc('day', created_at AT TIME ZONE 'Asia/Tokyo')", facets[0].GroupByClause)
+}
+
+func TestBuildFacets_Errors(t *testing.T) {
+	testSchema := schema.NewSchema("orders", map[string]schema.Field{
+		"createdAt": {Type: schema.TypeDate, Column: "created_at"},
+		"status":    {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+
+	histogram := func(modify func(*FacetRequest)) []FacetRequest {
+		req := FacetRequest{Name: "daily", Type: rsearch.FacetDateHistogram, Field: "createdAt", Interval: "day"}
+		modify(&req)
+		return []FacetRequest{req}
+	}
+
+	tests := []struct {
+		name     string
+		requests []FacetRequest
+		dbType   string
+		want     string
+	}{
+		{"no name", histogram(func(r *FacetRequest) { r.Name = "" }), "postgres", "facet on createdAt has no name"},
+		{"duplicate name", append(histogram(func(*FacetRequest) {}), histogram(func(*FacetRequest) {})...), "postgres", "duplicate facet name daily"},
+		{"unknown type", histogram(func(r *FacetRequest) { r.Type = "histogram" }), "postgres", `facet daily: unknown type "histogram", expected "date_histogram" or "terms"`},
+		{"unknown field", histogram(func(r *FacetRequest) { r.Field = "shippedAt" }), "postgres", `facet daily: field "shippedAt" not found in schema "orders"`},
+		{"text field", histogram(func(r *FacetRequest) { r.Field = "status" }), "postgres", "facet daily: date histograms need a date field, status is text"},
+		{"unknown interval", histogram(func(r *FacetRequest) { r.Interval = "fortnight" }), "postgres", `facet daily: unknown interval "fortnight", expected day, week or month`},
+		{"unknown time zone", histogram(func(r *FacetRequest) { r.Timezone = "Mars/Olympus" }), "postgres", `facet daily: unknown time zone "Mars/Olympus"`},
+		{"injected time zone", histogram(func(r *FacetRequest) { r.Timezone = "UTC'; DROP TABLE orders; --" }), "postgres", "facet daily: unknown time zone"},
+		{"unknown having operator", histogram(func(r *FacetRequest) { r.Having = []rsearch.FacetHaving{{Op: "=>", Count: 1}} }), "postgres", `facet daily: unknown having operator "=>", expected =, !=, >, >=, < or <=`},
+		{"negative having count", histogram(func(r *FacetRequest) { r.Having = []rsearch.FacetHaving{{Op: ">", Count: -1}} }), "postgres", "facet daily: having count must not be negative, got -1"},
+		{"histogram size", histogram(func(r *FacetRequest) { r.Size = 10 }), "postgres", "facet daily: size only applies to terms facets"},
+		{"negative size", []FacetRequest{{Name: "popular", Type: rsearch.FacetTerms, Field: "status", Size: -1}}, "postgres", "facet popular: size must not be negative, got -1"},
+		{"terms interval", []FacetRequest{{Name: "popular", Type: rsearch.FacetTerms, Field: "status", Interval: "day"}}, "postgres", "facet popular: interval and timezone only apply to date_histogram facets"},
+		{"sqlite time zone", histogram(func(r *FacetRequest) { r.Timezone = "Europe/Berlin" }), "sqlite", "facet daily: time zone Europe/Berlin is not supported in SQLite, which only buckets in UTC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := BuildFacets(tt.requests, testSchema, tt.dbType, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package translator
+
+import "strings"
+
+// sqlIndent indents each level of nesting in FormatSQL
+const sqlIndent = "  "
+
+// FormatSQL lays out a WHERE clause over several lines for reading: every AND or OR
+// joining clauses starts a line, and a parenthesized group of clauses is broken out and
+// indented one level deeper. Groups without boolean operators, such as IN lists and
+// function calls, stay on one line, as does BETWEEN ... AND. Quoted strings and
+// identifiers are left untouched.
+func FormatSQL(where string) string {
+	var b strings.Builder
+	formatClauses(&b, strings.TrimSpace(where), "")
+	return b.String()
+}
+
+// formatClauses writes the clauses of a boolean expression, one per line at indent
+func formatClauses(b *strings.Builder, expr, indent string) {
+	for i, clause := range splitClauses(expr) {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(indent)
+		b.WriteString(clause.op)
+		formatClause(b, clause.text, indent)
+	}
+}
+
+// formatClause writes one clause, breaking out a parenthesized group of clauses it
+// wraps, optionally behind NOT
+func formatClause(b *strings.Builder, clause, indent string) {
+	prefix, inner := "", clause
+	if strings.HasPrefix(clause, "NOT (") {
+		prefix, inner = "NOT ", clause[len("NOT "):]
+	}
+	if !wrapped(inner) || len(splitClauses(inner[1:len(inner)-1])) < 2 {
+		b.WriteString(clause)
+		return
+	}
+
+	b.WriteString(prefix)
+	b.WriteString("(\n")
+	formatClauses(b, strings.TrimSpace(inner[1:len(inner)-1]), indent+sqlIndent)
+	b.WriteString("\n")
+	b.WriteString(indent)
+	b.WriteString(")")
+}
+
+// sqlClause is a clause of a boolean expression with the operator joining it to the
+// previous one
+type sqlClause struct {
+	op   string // "", "AND " or "OR "
+	text string
+}
+
+// splitClauses splits an expression at the AND and OR operators outside parentheses
+// and quotes
+func splitClauses(expr string) []sqlClause {
+	var clauses []sqlClause
+	op, start, depth := "", 0, 0
+	var quote byte
+	between := false
+
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && c == ' ':
+			word := keywordAt(expr, i+1)
+			switch word {
+			case "BETWEEN":
+				between = true
+			case "AND":
+				if between {
+					between = false
+					continue
+				}
+				fallthrough
+			case "OR":
+				clauses = append(clauses, sqlClause{op: op, text: strings.TrimSpace(expr[start:i])})
+				op = word + " "
+				i += len(word) + 1
+				start = i + 1
+			}
+		}
+	}
+	return append(clauses, sqlClause{op: op, text: strings.TrimSpace(expr[start:])})
+}
+
+// keywordAt returns the AND, OR or BETWEEN keyword that starts at i and is followed by a
+// space, or ""
+func keywordAt(expr string, i int) string {
+	for _, word := range []string{"AND", "OR", "BETWEEN"} {
+		if strings.HasPrefix(expr[i:], word+" ") {
+			return word
+		}
+	}
+	return ""
+}
+
+// wrapped reports whether the whole clause is one parenthesized group
+func wrapped(clause string) bool {
+	if !strings.HasPrefix(clause, "(") || !strings.HasSuffix(clause, ")") {
+		return false
+	}
+	depth := 0
+	var quote byte
+	for i := 0; i < len(clause); i++ {
+		c := clause[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 && i < len(clause)-1 {
+				return false
+			}
+		}
+	}
+	return true
+}
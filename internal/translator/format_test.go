@@ -0,0 +1,73 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatSQL(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "single clause", input: "name = $1", want: "name = $1"},
+		{name: "flat", input: "name = $1 AND price > $2", want: "name = $1\nAND price > $2"},
+		{
+			name:  "nested groups",
+			input: "(name = $1 OR name = $2) AND (NOT deleted_at IS NOT NULL)",
+			want:  "(\n  name = $1\n  OR name = $2\n)\nAND (NOT deleted_at IS NOT NULL)",
+		},
+		{
+			name:  "negated group",
+			input: "status = $1 AND NOT (a = $2 OR (b = $3 AND c = $4))",
+			want:  "status = $1\nAND NOT (\n  a = $2\n  OR (\n    b = $3\n    AND c = $4\n  )\n)",
+		},
+		{
+			name:  "between stays on one line",
+			input: "price BETWEEN $1 AND $2 OR name = $3",
+			want:  "price BETWEEN $1 AND $2\nOR name = $3",
+		},
+		{
+			name:  "lists and calls stay on one line",
+			input: "status IN ($1, $2) AND similarity(name, $3) >= $4",
+			want:  "status IN ($1, $2)\nAND similarity(name, $3) >= $4",
+		},
+		{
+			name:  "quoted text is left alone",
+			input: `"order AND (x" = $1 OR note = 'a OR (b'`,
+			want:  "\"order AND (x\" = $1\nOR note = 'a OR (b'",
+		},
+		{
+			name:  "separate groups",
+			input: "(a = $1) AND (b = $2)",
+			want:  "(a = $1)\nAND (b = $2)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FormatSQL(tt.input))
+		})
+	}
+}
+
+func TestFormatSQL_Translated(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText},
+		"status": {Type: schema.TypeText},
+		"price":  {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{})
+
+	ast, err := parser.NewParser("status:active AND (name:a OR name:b) AND price:[10 TO 20]").Parse()
+	require.NoError(t, err)
+	output, err := NewPostgresTranslator().Translate(ast, s)
+	require.NoError(t, err)
+
+	assert.Equal(t, "(status = $1 AND (name = $2 OR name = $3)) AND price BETWEEN $4 AND $5", output.WhereClause)
+	assert.Equal(t, "(\n  status = $1\n  AND (\n    name = $2\n    OR name = $3\n  )\n)\nAND price BETWEEN $4 AND $5", FormatSQL(output.WhereClause))
+}
@@ -0,0 +1,62 @@
+package translator
+
+import (
+	"fmt"
+
+	"github.com/infiniv/rsearch/internal/ir"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// Schema fragments (schema.Fragment) are named conditions queried as pseudo-fields:
+// inStock:true applies the fragment and inStock:false negates it. Field names resolve
+// first, so ir.Lower only looks up a fragment for names that are not fields.
+
+// fragmentSQL returns the parenthesized SQL condition of a fragment pseudo-field query
+func fragmentSQL(fragment *ir.Fragment, s *schema.Schema) (string, error) {
+	if fragment.Fragment.SQL == "" {
+		return "", fmt.Errorf("fragment %s has no SQL definition", fragment.Name)
+	}
+
+	condition, err := fragment.Fragment.ExpandSQL(s)
+	if err != nil {
+		return "", fmt.Errorf("fragment %s: %w", fragment.Name, err)
+	}
+	// sqllint:trusted the condition is the fragment's SQL, written by the schema's author
+	if fragment.Negated {
+		return fmt.Sprintf("NOT (%s)", condition), nil
+	}
+	return fmt.Sprintf("(%s)", condition), nil // sqllint:trusted as above
+}
+
+// fragmentFilter returns the MongoDB filter of a fragment pseudo-field query. The
+// filter is copied, so translations never share documents with the schema.
+func fragmentFilter(fragment *ir.Fragment) (interface{}, error) {
+	if len(fragment.Fragment.MongoDB) == 0 {
+		return nil, fmt.Errorf("fragment %s has no MongoDB definition", fragment.Name)
+	}
+
+	filter := copyDocument(fragment.Fragment.MongoDB)
+	if fragment.Negated {
+		return map[string]interface{}{"$nor": []interface{}{filter}}, nil
+	}
+	return filter, nil
+}
+
+// copyDocument deep-copies the maps and slices of a decoded JSON document
+func copyDocument(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, nested := range v {
+			copied[key] = copyDocument(nested)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, nested := range v {
+			copied[i] = copyDocument(nested)
+		}
+		return copied
+	}
+	return value
+}
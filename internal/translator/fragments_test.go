@@ -0,0 +1,139 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslate_Fragments(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":         {Type: schema.TypeText},
+		"stockQty":     {Type: schema.TypeInteger},
+		"discontinued": {Type: schema.TypeBoolean},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+	s.Fragments = map[string]schema.Fragment{
+		"inStock": {
+			SQL:     "{stockQty} > 0 AND {discontinued} = false",
+			MongoDB: map[string]interface{}{"stock_qty": map[string]interface{}{"$gt": float64(0)}, "discontinued": false},
+		},
+		"sqlOnly": {SQL: "{stockQty} < 5"},
+	}
+
+	tests := []struct {
+		query  string
+		trans  Translator
+		where  string
+		params []interface{}
+	}{
+		{"name:widget AND inStock:true", NewPostgresTranslator(), "name = $1 AND (stock_qty > 0 AND discontinued = false)", []interface{}{"widget"}},
+		{"inStock:false OR name:widget", NewPostgresTranslator(), "NOT (stock_qty > 0 AND discontinued = false) OR name = $1", []interface{}{"widget"}},
+		{"INSTOCK:TRUE", NewMySQLTranslator(), "(stock_qty > 0 AND discontinued = false)", []interface{}{}},
+		{"NOT inStock:true", NewSQLiteTranslator(), "NOT ((stock_qty > 0 AND discontinued = false))", []interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.trans.DatabaseType()+" "+tt.query, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			output, err := tt.trans.Translate(ast, s)
+			require.NoError(t, err)
+			assert.Equal(t, tt.where, output.WhereClause)
+			assert.Equal(t, tt.params, output.Parameters)
+		})
+	}
+}
+
+func TestTranslate_FragmentsWithTableAlias(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":         {Type: schema.TypeText},
+		"stockQty":     {Type: schema.TypeInteger},
+		"discontinued": {Type: schema.TypeBoolean},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+	s.Fragments = map[string]schema.Fragment{
+		"inStock": {
+			SQL:     "{stockQty} > 0 AND {discontinued} = false",
+			MongoDB: map[string]interface{}{"stock_qty": map[string]interface{}{"$gt": float64(0)}, "discontinued": false},
+		},
+		"sqlOnly": {SQL: "{stockQty} < 5"},
+	}
+	aliased, err := s.WithTableAlias("p")
+	require.NoError(t, err)
+
+	ast, err := parser.NewParser("inStock:true").Parse()
+	require.NoError(t, err)
+
+	output, err := NewPostgresTranslator().Translate(ast, aliased)
+	require.NoError(t, err)
+	assert.Equal(t, "(p.stock_qty > 0 AND p.discontinued = false)", output.WhereClause)
+}
+
+func TestTranslate_FragmentsMongoDB(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":         {Type: schema.TypeText},
+		"stockQty":     {Type: schema.TypeInteger},
+		"discontinued": {Type: schema.TypeBoolean},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+	s.Fragments = map[string]schema.Fragment{
+		"inStock": {
+			SQL:     "{stockQty} > 0 AND {discontinued} = false",
+			MongoDB: map[string]interface{}{"stock_qty": map[string]interface{}{"$gt": float64(0)}, "discontinued": false},
+		},
+		"sqlOnly": {SQL: "{stockQty} < 5"},
+	}
+	translate := func(query string) (*TranslatorOutput, error) {
+		ast, err := parser.NewParser(query).Parse()
+		require.NoError(t, err)
+		return NewMongoDBTranslator().Translate(ast, s)
+	}
+
+	output, err := translate("inStock:true")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"stock_qty": map[string]interface{}{"$gt": float64(0)}, "discontinued": false}, output.Filter)
+
+	// The translated filter does not share documents with the schema
+	output.Filter.(map[string]interface{})["stock_qty"].(map[string]interface{})["$gt"] = float64(100)
+	assert.Equal(t, float64(0), s.Fragments["inStock"].MongoDB["stock_qty"].(map[string]interface{})["$gt"])
+
+	output, err = translate("inStock:false")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"$nor": []interface{}{
+		map[string]interface{}{"stock_qty": map[string]interface{}{"$gt": float64(0)}, "discontinued": false},
+	}}, output.Filter)
+
+	_, err = translate("sqlOnly:true")
+	assert.EqualError(t, err, "fragment sqlOnly has no MongoDB definition")
+}
+
+func TestTranslate_FragmentErrors(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":         {Type: schema.TypeText},
+		"stockQty":     {Type: schema.TypeInteger},
+		"discontinued": {Type: schema.TypeBoolean},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+	s.Fragments = map[string]schema.Fragment{
+		"inStock": {
+			SQL:     "{stockQty} > 0 AND {discontinued} = false",
+			MongoDB: map[string]interface{}{"stock_qty": map[string]interface{}{"$gt": float64(0)}, "discontinued": false},
+		},
+		"sqlOnly": {SQL: "{stockQty} < 5"},
+	}
+
+	for query, wantErr := range map[string]string{
+		"inStock:yes":     "fragment inStock only accepts true or false",
+		"inStock:1":       "fragment inStock only accepts true or false",
+		"outOfStock:true": "field outOfStock not found in schema products",
+	} {
+		t.Run(query, func(t *testing.T) {
+			ast, err := parser.NewParser(query).Parse()
+			require.NoError(t, err)
+
+			_, err = NewPostgresTranslator().Translate(ast, s)
+			assert.EqualError(t, err, wantErr)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package translator
+
+import (
+	"fmt"
+
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// ExecutionHints are the limits an executor should enforce on a translated query.
+type ExecutionHints = rsearch.ExecutionHints
+
+// TightenLimits combines the schema's execution limits with limits requested for one
+// query. A request can lower a limit or set one the schema leaves open, but never raise it.
+func TightenLimits(configured, requested schema.ExecutionLimits) schema.ExecutionLimits {
+	return schema.ExecutionLimits{
+		TimeoutMs: tighter(configured.TimeoutMs, requested.TimeoutMs),
+		MaxRows:   tighter(configured.MaxRows, requested.MaxRows),
+	}
+}
+
+// tighter returns the smaller of two limits, where 0 means no limit
+func tighter(a, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// ApplyExecutionHints stores hints for the limits in the output's "executionHints"
+// metadata, written for the database the output was translated for: SET LOCAL
+// statement_timeout for PostgreSQL, a MAX_EXECUTION_TIME optimizer hint for MySQL and
+// maxTimeMS for MongoDB. SQLite has no statement timeout, so only its row limit is hinted.
+// Outputs without limits are left unchanged.
+func ApplyExecutionHints(output *TranslatorOutput, dbType string, limits schema.ExecutionLimits) {
+	if limits.TimeoutMs <= 0 && limits.MaxRows <= 0 {
+		return
+	}
+
+	hints := &ExecutionHints{TimeoutMs: limits.TimeoutMs, MaxRows: limits.MaxRows}
+	if limits.MaxRows > 0 && dbType != "mongodb" {
+		hints.LimitClause = fmt.Sprintf("LIMIT %d", limits.MaxRows)
+	}
+	if limits.TimeoutMs > 0 {
+		switch dbType {
+		case "postgres":
+			hints.Statements = []string{fmt.Sprintf("SET LOCAL statement_timeout = %d", limits.TimeoutMs)}
+		case "mysql":
+			hints.OptimizerHint = fmt.Sprintf("/*+ MAX_EXECUTION_TIME(%d) */", limits.TimeoutMs)
+		case "mongodb":
+			hints.MaxTimeMS = limits.TimeoutMs
+		}
+	}
+
+	// Copy the metadata, which a cached output may share
+	metadata := make(map[string]interface{}, len(output.Metadata)+1)
+	for key, value := range output.Metadata {
+		metadata[key] = value
+	}
+	metadata["executionHints"] = hints
+	output.Metadata = metadata
+}
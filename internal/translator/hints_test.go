@@ -0,0 +1,69 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTightenLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured schema.ExecutionLimits
+		requested  schema.ExecutionLimits
+		expected   schema.ExecutionLimits
+	}{
+		{"no limits", schema.ExecutionLimits{}, schema.ExecutionLimits{}, schema.ExecutionLimits{}},
+		{"schema only", schema.ExecutionLimits{TimeoutMs: 500, MaxRows: 100}, schema.ExecutionLimits{}, schema.ExecutionLimits{TimeoutMs: 500, MaxRows: 100}},
+		{"request only", schema.ExecutionLimits{}, schema.ExecutionLimits{TimeoutMs: 200}, schema.ExecutionLimits{TimeoutMs: 200}},
+		{"request lowers", schema.ExecutionLimits{TimeoutMs: 500, MaxRows: 100}, schema.ExecutionLimits{TimeoutMs: 200, MaxRows: 10}, schema.ExecutionLimits{TimeoutMs: 200, MaxRows: 10}},
+		{"request cannot raise", schema.ExecutionLimits{TimeoutMs: 500, MaxRows: 100}, schema.ExecutionLimits{TimeoutMs: 9000, MaxRows: 1000}, schema.ExecutionLimits{TimeoutMs: 500, MaxRows: 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, TightenLimits(tt.configured, tt.requested))
+		})
+	}
+}
+
+func TestApplyExecutionHints(t *testing.T) {
+	limits := schema.ExecutionLimits{TimeoutMs: 1500, MaxRows: 50}
+
+	tests := []struct {
+		dbType   string
+		expected *ExecutionHints
+	}{
+		{"postgres", &ExecutionHints{TimeoutMs: 1500, MaxRows: 50, Statements: []string{"SET LOCAL statement_timeout = 1500"}, LimitClause: "LIMIT 50"}},
+		{"mysql", &ExecutionHints{TimeoutMs: 1500, MaxRows: 50, OptimizerHint: "/*+ MAX_EXECUTION_TIME(1500) */", LimitClause: "LIMIT 50"}},
+		{"sqlite", &ExecutionHints{TimeoutMs: 1500, MaxRows: 50, LimitClause: "LIMIT 50"}},
+		{"mongodb", &ExecutionHints{TimeoutMs: 1500, MaxRows: 50, MaxTimeMS: 1500}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dbType, func(t *testing.T) {
+			output := NewSQLOutput("x = $1", []interface{}{"a"}, []string{"text"})
+			ApplyExecutionHints(output, tt.dbType, limits)
+			assert.Equal(t, tt.expected, output.Metadata["executionHints"])
+		})
+	}
+}
+
+func TestApplyExecutionHints_NoLimits(t *testing.T) {
+	output := NewSQLOutput("x = $1", []interface{}{"a"}, []string{"text"})
+	ApplyExecutionHints(output, "postgres", schema.ExecutionLimits{})
+	assert.NotContains(t, output.Metadata, "executionHints")
+}
+
+func TestApplyExecutionHints_CopiesMetadata(t *testing.T) {
+	output := NewSQLOutput("x = $1", []interface{}{"a"}, []string{"text"})
+	output.Metadata = map[string]interface{}{"boosts": 1}
+	shared := output.Metadata
+
+	ApplyExecutionHints(output, "postgres", schema.ExecutionLimits{MaxRows: 10})
+	require.Contains(t, output.Metadata, "executionHints")
+	assert.Equal(t, 1, output.Metadata["boosts"])
+	assert.NotContains(t, shared, "executionHints")
+}
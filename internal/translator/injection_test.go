@@ -0,0 +1,213 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// injectionPayloads are classic SQL/NoSQL injection and regex DoS strings.
+// Each must only ever reach the database as a bound parameter value.
+var injectionPayloads = []string{
+	`' OR '1'='1`,
+	`'; DROP TABLE users; --`,
+	`1; DELETE FROM products`,
+	`) OR 1=1 --`,
+	`')--`,
+	`" OR ""="`,
+	`admin'--`,
+	`' UNION SELECT password FROM users --`,
+	`1' AND SLEEP(5)#`,
+	`'; SELECT pg_sleep(10); --`,
+	`/* comment */ OR 1=1`,
+	`%' OR name LIKE '%`,
+	`\'; EXEC xp_cmdshell('dir'); --`,
+	`$where`,
+	`{"$gt": ""}`,
+	`this.password.match(/.*/)`,
+	`(a+)+$`,
+	`^(([a-z])+.)+[A-Z]([a-z])+$`,
+}
+
+// quote renders a payload as a quoted query string literal
+func quote(payload string) string {
+	escaped := strings.ReplaceAll(payload, `\`, `\\`)
+	escaped = strings.ReplaceAll(escaped, `"`, `\"`)
+	return `"` + escaped + `"`
+}
+
+// injectionQueries places the payload in every value position of the grammar
+func injectionQueries(payload string) map[string]string {
+	q := quote(payload)
+	queries := map[string]string{
+		"field phrase":      "name:" + q,
+		"standalone phrase": q,
+		"field group":       "name:(" + q + " OR safe)",
+		"range start":       "price:[" + q + " TO 100]",
+		"range end":         "price:{1 TO " + q + "}",
+		"comparison":        "price:>=" + q,
+		"negated":           "NOT name:" + q,
+		"prohibited":        "-name:" + q,
+		"boosted":           "name:" + q + "^2",
+		"proximity":         q + "~3",
+		"nested boolean":    "(name:safe OR name:" + q + ") AND price:[1 TO 2]",
+		"unquoted":          "name:" + payload,
+	}
+	if !strings.Contains(payload, "/") {
+		queries["regex"] = "name:/" + payload + "/"
+	}
+	return queries
+}
+
+func injectionTranslators() map[string]Translator {
+	return map[string]Translator{
+		"postgres": NewPostgresTranslator(),
+		"mysql":    NewMySQLTranslator(),
+		"sqlite":   NewSQLiteTranslator(),
+		"mongodb":  NewMongoDBTranslator(),
+	}
+}
+
+// TestInjection_PayloadsOnlyBoundAsParameters is a security regression suite: for every
+// dialect and every value position, an injection payload must either be rejected or appear
+// solely in the bound parameters (SQL) or as a filter value (MongoDB), never in query text
+// or as a filter key.
+func TestInjection_PayloadsOnlyBoundAsParameters(t *testing.T) {
+	// Every optional feature is enabled so all code paths are reachable
+	s := schema.NewSchema("injection", map[string]schema.Field{
+		"name":  {Type: schema.TypeText},
+		"price": {Type: schema.TypeFloat},
+		"tags":  {Type: schema.TypeArray},
+	}, schema.SchemaOptions{
+		DefaultField: "name",
+		EnabledFeatures: schema.EnabledFeatures{
+			Fuzzy:     true,
+			Proximity: true,
+			Regex:     true,
+		},
+	})
+
+	for dbType, tr := range injectionTranslators() {
+		translated := 0
+		for _, payload := range injectionPayloads {
+			for position, query := range injectionQueries(payload) {
+				name := fmt.Sprintf("%s/%s/%s", dbType, position, payload)
+				t.Run(name, func(t *testing.T) {
+					ast, err := parser.NewParser(query).Parse()
+					if err != nil || ast == nil {
+						// Rejected by the parser: nothing reaches the database
+						return
+					}
+
+					output, err := tr.Translate(ast, s)
+					if err != nil {
+						// Rejected by the translator: nothing reaches the database
+						return
+					}
+
+					translated++
+					if output.Type == "sql" {
+						assertSQLSafe(t, output, payload)
+					} else {
+						assertFilterKeysSafe(t, output.Filter, payload)
+					}
+				})
+			}
+		}
+		// Guard against the suite silently degrading into "everything was rejected"
+		assert.Greater(t, translated, len(injectionPayloads)*5, "%s translated too few payload queries", dbType)
+	}
+}
+
+// assertSQLSafe checks that no payload fragment leaked into the SQL text
+func assertSQLSafe(t *testing.T, output *TranslatorOutput, payload string) {
+	t.Helper()
+
+	assert.NotContains(t, output.WhereClause, payload, "payload leaked into SQL text")
+	for _, marker := range []string{";", "--", "/*", "#", "DROP", "UNION", "DELETE", "EXEC", "SLEEP", "pg_sleep"} {
+		if strings.Contains(payload, marker) {
+			assert.NotContains(t, output.WhereClause, marker, "payload fragment %q leaked into SQL text", marker)
+		}
+	}
+	// Quotes are only acceptable as part of dialect constants the translator writes itself
+	stripped := strings.NewReplacer("'english'", "", "'null'::jsonb", "", "'NULL'", "").Replace(output.WhereClause)
+	assert.NotContains(t, stripped, "'", "unexpected string literal in SQL text")
+
+	require.Equal(t, len(output.Parameters), len(output.ParameterTypes))
+	assert.Equal(t, countPlaceholders(output.WhereClause), len(output.Parameters),
+		"every parameter must have exactly one placeholder: %s", output.WhereClause)
+}
+
+// countPlaceholders counts ? and $N placeholders in SQL text
+func countPlaceholders(sql string) int {
+	count := strings.Count(sql, "?")
+	for i := 0; i < len(sql)-1; i++ {
+		if sql[i] == '$' && sql[i+1] >= '0' && sql[i+1] <= '9' {
+			count++
+		}
+	}
+	return count
+}
+
+// assertFilterKeysSafe checks that user input never became a MongoDB document key
+func assertFilterKeysSafe(t *testing.T, filter interface{}, payload string) {
+	t.Helper()
+
+	switch f := filter.(type) {
+	case map[string]interface{}:
+		for key, value := range f {
+			assert.NotEqual(t, payload, key, "payload became a filter key")
+			if strings.HasPrefix(key, "$") {
+				assert.Contains(t, allowedMongoOperators, key, "unexpected operator %q in filter", key)
+			} else {
+				assert.Contains(t, []string{"name", "price", "tags"}, key, "unexpected field %q in filter", key)
+			}
+			assertFilterKeysSafe(t, value, payload)
+		}
+	case []interface{}:
+		for _, item := range f {
+			assertFilterKeysSafe(t, item, payload)
+		}
+	}
+}
+
+// allowedMongoOperators are the operators the MongoDB translator may emit
+var allowedMongoOperators = []string{
+	"$and", "$or", "$nor", "$ne", "$eq", "$gt", "$gte", "$lt", "$lte",
+	"$regex", "$options", "$exists", "$text", "$search", "$in", "$nin",
+}
+
+func TestInjection_BuiltASTOperators(t *testing.T) {
+	s := schema.NewSchema("injection", map[string]schema.Field{
+		"name":  {Type: schema.TypeText},
+		"price": {Type: schema.TypeFloat},
+		"tags":  {Type: schema.TypeArray},
+	}, schema.SchemaOptions{
+		DefaultField: "name",
+		EnabledFeatures: schema.EnabledFeatures{
+			Fuzzy:     true,
+			Proximity: true,
+			Regex:     true,
+		},
+	})
+
+	left := &parser.FieldQuery{Field: "name", Value: &parser.TermValue{Term: "a"}}
+	right := &parser.FieldQuery{Field: "name", Value: &parser.TermValue{Term: "b"}}
+	group := &parser.FieldGroupQuery{Field: "name", Queries: []parser.Node{
+		&parser.BinaryOp{Op: "OR 1=1 --", Left: &parser.TermQuery{Term: "a"}, Right: &parser.TermQuery{Term: "b"}},
+	}}
+
+	for dbType, tr := range injectionTranslators() {
+		// ASTs built by callers rather than the parser can hold any operator
+		_, err := tr.Translate(&parser.BinaryOp{Op: "OR 1=1 --", Left: left, Right: right}, s)
+		assert.EqualError(t, err, "unsupported binary operator: OR 1=1 --", dbType)
+
+		_, err = tr.Translate(group, s)
+		assert.Error(t, err, dbType)
+	}
+}
@@ -0,0 +1,22 @@
+package translator
+
+import (
+	"fmt"
+
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// In-lists (parser.InListQuery), written region:(ca,us,mx) or region:in(ca us mx),
+// translate to a single IN or $in rather than a chain of ORs, and their size can be
+// capped by the schema's MaxListSize or the field's MaxValues, see CheckListSizes.
+// Negated, as NOT region:in(ca us) or -region:(ca us), they lower to an excluding ir.In
+// and translate to NOT IN or $nin.
+
+// excludeList completes a NOT IN clause. NOT IN never matches rows where the column is
+// NULL, so with the schema's ExclusionsMatchNull set they are matched explicitly.
+func excludeList(columnName, clause string, s *schema.Schema) string {
+	if !s.Options.ExclusionsMatchNull {
+		return clause
+	}
+	return fmt.Sprintf("(%s IS NULL OR %s)", columnName, clause)
+}
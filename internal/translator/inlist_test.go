@@ -0,0 +1,235 @@
+package translator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInList_SQL(t *testing.T) {
+	testSchema := schema.NewSchema("customers", map[string]schema.Field{
+		"region": {Type: schema.TypeText},
+		"city":   {Type: schema.TypeText, Unaccent: true},
+		"age":    {Type: schema.TypeInteger},
+		"joined": {Type: schema.TypeDate},
+	}, schema.SchemaOptions{MaxListSize: 3})
+
+	tests := []struct {
+		name      string
+		translate func(parser.Node, *schema.Schema) (*TranslatorOutput, error)
+		query     string
+		where     string
+		params    []interface{}
+	}{
+		{"postgres", NewPostgresTranslator().Translate, "region:(ca,us,mx)", "region IN ($1, $2, $3)", []interface{}{"ca", "us", "mx"}},
+		{"postgres in form", NewPostgresTranslator().Translate, "region:in(ca us) AND age:>18", "region IN ($1, $2) AND age > $3", []interface{}{"ca", "us", int64(18)}},
+		{"postgres unaccent", NewPostgresTranslator().Translate, "city:(sao,jose)", "unaccent(city) IN (unaccent($1), unaccent($2))", []interface{}{"sao", "jose"}},
+		{"postgres single value", NewPostgresTranslator().Translate, "region:in(ca)", "region IN ($1)", []interface{}{"ca"}},
+		{"mysql", NewMySQLTranslator().Translate, "region:(ca,us) OR age:(1,2)", "region IN (?, ?) OR age IN (?, ?)", []interface{}{"ca", "us", int64(1), int64(2)}},
+		{"mysql unaccent", NewMySQLTranslator().Translate, "city:(sao,jose)", "city IN (? COLLATE utf8mb4_0900_ai_ci, ? COLLATE utf8mb4_0900_ai_ci)", []interface{}{"sao", "jose"}},
+		{"sqlite", NewSQLiteTranslator().Translate, "region:(ca,us)", "region IN (?, ?)", []interface{}{"ca", "us"}},
+		{"sqlite unaccent", NewSQLiteTranslator().Translate, "city:(sao,jose)", "city COLLATE NOCASE IN (?, ?)", []interface{}{"sao", "jose"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			output, err := tt.translate(ast, testSchema)
+			require.NoError(t, err)
+			assert.Equal(t, tt.where, output.WhereClause)
+			assert.Equal(t, tt.params, output.Parameters)
+			assert.Len(t, output.ParameterTypes, len(tt.params))
+		})
+	}
+}
+
+func TestInList_MongoDB(t *testing.T) {
+	testSchema := schema.NewSchema("customers", map[string]schema.Field{
+		"region": {Type: schema.TypeText},
+		"city":   {Type: schema.TypeText, Unaccent: true},
+		"age":    {Type: schema.TypeInteger},
+		"joined": {Type: schema.TypeDate},
+	}, schema.SchemaOptions{MaxListSize: 3})
+
+	ast, err := parser.NewParser(`region:(ca,us,"$where")`).Parse()
+	require.NoError(t, err)
+
+	output, err := NewMongoDBTranslator().Translate(ast, testSchema)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"region": map[string]interface{}{"$in": []interface{}{"ca", "us", "$where"}},
+	}, output.Filter)
+
+	ast, err = parser.NewParser("city:(sao,jose)").Parse()
+	require.NoError(t, err)
+
+	output, err = NewMongoDBTranslator().Translate(ast, testSchema)
+	require.NoError(t, err)
+	conditions := output.Filter.(map[string]interface{})["$or"]
+	assert.Len(t, conditions, 2)
+}
+
+func TestInList_MaxListSize(t *testing.T) {
+	testSchema := schema.NewSchema("customers", map[string]schema.Field{
+		"region": {Type: schema.TypeText},
+		"city":   {Type: schema.TypeText, Unaccent: true},
+		"age":    {Type: schema.TypeInteger},
+		"joined": {Type: schema.TypeDate},
+	}, schema.SchemaOptions{MaxListSize: 3})
+
+	ast, err := parser.NewParser("age:>1 AND region:(a,b,c,d)").Parse()
+	require.NoError(t, err)
+
+	_, err = NewPostgresTranslator().Translate(ast, testSchema)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "list for field region has 4 values, at most 3 are allowed")
+
+	// Field groups are not lists and are not capped
+	ast, err = parser.NewParser("region:(a OR b OR c OR d)").Parse()
+	require.NoError(t, err)
+	_, err = NewPostgresTranslator().Translate(ast, testSchema)
+	assert.NoError(t, err)
+}
+
+func TestInList_DateKeywords(t *testing.T) {
+	testSchema := schema.NewSchema("customers", map[string]schema.Field{
+		"region": {Type: schema.TypeText},
+		"city":   {Type: schema.TypeText, Unaccent: true},
+		"age":    {Type: schema.TypeInteger},
+		"joined": {Type: schema.TypeDate},
+	}, schema.SchemaOptions{MaxListSize: 3})
+
+	ast, err := parser.NewParser("joined:(2026-01-01,today)").Parse()
+	require.NoError(t, err)
+	assert.True(t, UsesDateKeywords(ast))
+
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	prepared, _, err := Prepare(ast, testSchema, now)
+	require.NoError(t, err)
+	assert.Equal(t, `joined:2026-01-01 OR joined:["2026-10-15" TO "2026-10-16"}`, parser.Canonical(prepared))
+}
+
+func TestInList_UnknownField(t *testing.T) {
+	testSchema := schema.NewSchema("customers", map[string]schema.Field{
+		"region": {Type: schema.TypeText},
+		"city":   {Type: schema.TypeText, Unaccent: true},
+		"age":    {Type: schema.TypeInteger},
+		"joined": {Type: schema.TypeDate},
+	}, schema.SchemaOptions{MaxListSize: 3})
+
+	ast, err := parser.NewParser("country:(ca,us)").Parse()
+	require.NoError(t, err)
+
+	_, err = NewPostgresTranslator().Translate(ast, testSchema)
+	assert.EqualError(t, err, "field country not found in schema customers")
+}
+
+func TestExclusionList_SQL(t *testing.T) {
+	testSchema := schema.NewSchema("customers", map[string]schema.Field{
+		"region": {Type: schema.TypeText},
+		"city":   {Type: schema.TypeText, Unaccent: true},
+		"age":    {Type: schema.TypeInteger},
+		"joined": {Type: schema.TypeDate},
+	}, schema.SchemaOptions{MaxListSize: 3})
+
+	matchNull := schema.NewSchema("customers", map[string]schema.Field{
+		"region": {Type: schema.TypeText},
+		"city":   {Type: schema.TypeText, Unaccent: true},
+		"age":    {Type: schema.TypeInteger},
+	}, schema.SchemaOptions{ExclusionsMatchNull: true})
+
+	tests := []struct {
+		name      string
+		translate func(parser.Node, *schema.Schema) (*TranslatorOutput, error)
+		schema    *schema.Schema
+		query     string
+		where     string
+		params    []interface{}
+	}{
+		{"not in-list", NewPostgresTranslator().Translate, testSchema, "NOT region:in(ca us)", "region NOT IN ($1, $2)", []interface{}{"ca", "us"}},
+		{"prohibited field group", NewPostgresTranslator().Translate, testSchema, "-region:(ca us)", "region NOT IN ($1, $2)", []interface{}{"ca", "us"}},
+		{"negated group of list", NewPostgresTranslator().Translate, testSchema, "NOT (region:(ca,us))", "region NOT IN ($1, $2)", []interface{}{"ca", "us"}},
+		{"with other predicates", NewPostgresTranslator().Translate, testSchema, "age:>18 AND NOT region:(ca,us) OR region:mx", "(age > $1 AND region NOT IN ($2, $3)) OR region = $4", []interface{}{int64(18), "ca", "us", "mx"}},
+		{"include and exclude", NewPostgresTranslator().Translate, testSchema, "region:(ca,us,mx) AND -region:(us)", "region IN ($1, $2, $3) AND region NOT IN ($4)", []interface{}{"ca", "us", "mx", "us"}},
+		{"matching null", NewPostgresTranslator().Translate, matchNull, "-region:(ca us) AND age:1", "(region IS NULL OR region NOT IN ($1, $2)) AND age = $3", []interface{}{"ca", "us", int64(1)}},
+		{"unaccent", NewPostgresTranslator().Translate, testSchema, "NOT city:(sao,jose)", "unaccent(city) NOT IN (unaccent($1), unaccent($2))", []interface{}{"sao", "jose"}},
+		{"group with wildcard stays negated", NewPostgresTranslator().Translate, testSchema, "NOT region:(ca OR u*)", "NOT ((region = $1 OR region LIKE $2))", []interface{}{"ca", "u%"}},
+		{"group with AND stays negated", NewPostgresTranslator().Translate, testSchema, "-region:(ca AND us)", "NOT (region = $1 AND region = $2)", []interface{}{"ca", "us"}},
+		{"mysql", NewMySQLTranslator().Translate, matchNull, "NOT region:in(ca us)", "(region IS NULL OR region NOT IN (?, ?))", []interface{}{"ca", "us"}},
+		{"sqlite", NewSQLiteTranslator().Translate, testSchema, "-region:(ca us)", "region NOT IN (?, ?)", []interface{}{"ca", "us"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			output, err := tt.translate(ast, tt.schema)
+			require.NoError(t, err)
+			assert.Equal(t, tt.where, output.WhereClause)
+			assert.Equal(t, tt.params, output.Parameters)
+		})
+	}
+}
+
+func TestExclusionList_MongoDB(t *testing.T) {
+	testSchema := schema.NewSchema("customers", map[string]schema.Field{
+		"region": {Type: schema.TypeText},
+		"city":   {Type: schema.TypeText, Unaccent: true},
+		"age":    {Type: schema.TypeInteger},
+		"joined": {Type: schema.TypeDate},
+	}, schema.SchemaOptions{MaxListSize: 3})
+
+	matchNull := schema.NewSchema("customers", map[string]schema.Field{
+		"region": {Type: schema.TypeText},
+		"city":   {Type: schema.TypeText, Unaccent: true},
+		"age":    {Type: schema.TypeInteger},
+	}, schema.SchemaOptions{ExclusionsMatchNull: true})
+
+	tests := []struct {
+		name   string
+		schema *schema.Schema
+		query  string
+		filter interface{}
+	}{
+		{
+			name:   "nulls excluded by default",
+			schema: testSchema,
+			query:  "NOT region:in(ca us)",
+			filter: map[string]interface{}{"region": map[string]interface{}{"$nin": []interface{}{"ca", "us"}, "$ne": nil}},
+		},
+		{
+			name:   "matching null",
+			schema: matchNull,
+			query:  "-region:(ca us)",
+			filter: map[string]interface{}{"region": map[string]interface{}{"$nin": []interface{}{"ca", "us"}}},
+		},
+		{
+			name:   "unaccent",
+			schema: testSchema,
+			query:  "NOT city:(sao,jose)",
+			filter: map[string]interface{}{"$nor": []interface{}{
+				map[string]interface{}{"city": map[string]interface{}{"$regex": "^[sśš][aàáâãäåā][oòóôõöøō]$", "$options": "i"}},
+				map[string]interface{}{"city": map[string]interface{}{"$regex": "^j[oòóôõöøō][sśš][eèéêëēėę]$", "$options": "i"}},
+				map[string]interface{}{"city": nil},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			output, err := NewMongoDBTranslator().Translate(ast, tt.schema)
+			require.NoError(t, err)
+			assert.Equal(t, tt.filter, output.Filter)
+		})
+	}
+}
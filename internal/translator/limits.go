@@ -0,0 +1,184 @@
+package translator
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// Schemas bound the values a single query can bind, so a client cannot send a string of
+// megabytes or a list of thousands of values: a field's MaxLength, or the schema's
+// MaxValueLength, caps the characters of each value, and a field's MaxValues caps the
+// values of its in-lists and field groups, in place of the schema's MaxListSize.
+
+// ValueLimitError is returned for a value longer, or a list or field group with more
+// values, than the schema allows
+type ValueLimitError struct {
+	Clause string // "value", "list" or "field group"
+	Field  string // field as named in the query; empty for free text without a default field
+	Size   int    // characters of the value, or number of values
+	Limit  int
+	Pos    parser.Position
+}
+
+// Unit returns what the limit counts: "characters" for values, "values" for lists and
+// field groups
+func (e *ValueLimitError) Unit() string {
+	if e.Clause == "value" {
+		return "characters"
+	}
+	return "values"
+}
+
+func (e *ValueLimitError) Error() string {
+	clause := e.Clause
+	if e.Field != "" {
+		clause += " for field " + e.Field
+	}
+	return fmt.Sprintf("%s has %d %s, at most %d are allowed at line %d, column %d",
+		clause, e.Size, e.Unit(), e.Limit, e.Pos.Line, e.Pos.Column)
+}
+
+// CheckListSizes returns a *ValueLimitError for the first in-list with more values than
+// its field's MaxValues, or else the schema's MaxListSize, allows, or the first field
+// group with more values than its field's MaxValues allows
+func CheckListSizes(ast parser.Node, s *schema.Schema) error {
+	var err error
+	parser.Walk(ast, func(node parser.Node) bool {
+		switch n := node.(type) {
+		case *parser.InListQuery:
+			limit := fieldLimit(s, n.Field, func(f *schema.Field) int { return f.MaxValues }, s.Options.MaxListSize)
+			if limit > 0 && len(n.Values) > limit {
+				err = &ValueLimitError{Clause: "list", Field: n.Field, Size: len(n.Values), Limit: limit, Pos: n.Pos}
+			}
+		case *parser.FieldGroupQuery:
+			limit := fieldLimit(s, n.Field, func(f *schema.Field) int { return f.MaxValues }, 0)
+			if size := groupSize(n); limit > 0 && size > limit {
+				err = &ValueLimitError{Clause: "field group", Field: n.Field, Size: size, Limit: limit, Pos: n.Pos}
+			}
+		}
+		return err == nil
+	})
+	return err
+}
+
+// groupSize counts the clauses of a field group, such as the 3 terms of region:(a OR b c)
+func groupSize(group *parser.FieldGroupQuery) int {
+	size := 0
+	for _, member := range group.Queries {
+		parser.Walk(member, func(node parser.Node) bool {
+			switch node.(type) {
+			case *parser.BinaryOp, *parser.UnaryOp, *parser.GroupQuery,
+				*parser.RequiredQuery, *parser.ProhibitedQuery, *parser.BoostQuery:
+			default:
+				size++
+			}
+			return true
+		})
+	}
+	return size
+}
+
+// CheckValueLengths returns a *ValueLimitError for the first value with more characters
+// than its field's MaxLength, or else the schema's MaxValueLength, allows. Free text is
+// checked against the default field.
+func CheckValueLengths(ast parser.Node, s *schema.Schema) error {
+	return checkValueLengths(ast, s, s.Options.DefaultField)
+}
+
+// checkValueLengths checks a node whose standalone terms query defaultField
+func checkValueLengths(node parser.Node, s *schema.Schema, defaultField string) error {
+	switch n := node.(type) {
+	case *parser.BinaryOp:
+		if err := checkValueLengths(n.Left, s, defaultField); err != nil {
+			return err
+		}
+		return checkValueLengths(n.Right, s, defaultField)
+	case *parser.UnaryOp:
+		return checkValueLengths(n.Operand, s, defaultField)
+	case *parser.GroupQuery:
+		return checkValueLengths(n.Query, s, defaultField)
+	case *parser.RequiredQuery:
+		return checkValueLengths(n.Query, s, defaultField)
+	case *parser.ProhibitedQuery:
+		return checkValueLengths(n.Query, s, defaultField)
+	case *parser.BoostQuery:
+		return checkValueLengths(n.Query, s, defaultField)
+	case *parser.FieldQuery:
+		return checkValueLength(s, n.Field, n.Value, n.Pos)
+	case *parser.FieldGroupQuery:
+		// Terms of a group query the group's field
+		for _, member := range n.Queries {
+			if err := checkValueLengths(member, s, n.Field); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *parser.InListQuery:
+		for _, value := range n.Values {
+			if err := checkValueLength(s, n.Field, value, n.Pos); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *parser.RangeQuery:
+		if err := checkValueLength(s, n.Field, n.Start, n.Pos); err != nil {
+			return err
+		}
+		return checkValueLength(s, n.Field, n.End, n.Pos)
+	case *parser.FuzzyQuery:
+		return checkLength(s, fieldOrDefault(n.Field, defaultField), n.Term, n.Pos)
+	case *parser.ProximityQuery:
+		return checkLength(s, fieldOrDefault(n.Field, defaultField), n.Phrase, n.Pos)
+	case *parser.TermQuery:
+		return checkLength(s, defaultField, n.Term, n.Pos)
+	case *parser.PhraseQuery:
+		return checkLength(s, defaultField, n.Phrase, n.Pos)
+	case *parser.WildcardQuery:
+		return checkLength(s, defaultField, n.Pattern, n.Pos)
+	}
+	return nil
+}
+
+// checkValueLength checks the length of a value node, as written in the query
+func checkValueLength(s *schema.Schema, fieldName string, value parser.ValueNode, pos parser.Position) error {
+	switch v := value.(type) {
+	case *parser.TermValue:
+		return checkLength(s, fieldName, v.Term, pos)
+	case *parser.PhraseValue:
+		return checkLength(s, fieldName, v.Phrase, pos)
+	case *parser.WildcardValue:
+		return checkLength(s, fieldName, v.Pattern, pos)
+	case *parser.RegexValue:
+		return checkLength(s, fieldName, v.Pattern, pos)
+	case *parser.NumberValue:
+		return checkLength(s, fieldName, v.Number, pos)
+	}
+	return nil
+}
+
+// checkLength checks a single value against the length limit of its field
+func checkLength(s *schema.Schema, fieldName, value string, pos parser.Position) error {
+	limit := fieldLimit(s, fieldName, func(f *schema.Field) int { return f.MaxLength }, s.Options.MaxValueLength)
+	if limit <= 0 || len(value) <= limit {
+		return nil
+	}
+	if size := utf8.RuneCountInString(value); size > limit {
+		return &ValueLimitError{Clause: "value", Field: fieldName, Size: size, Limit: limit, Pos: pos}
+	}
+	return nil
+}
+
+// fieldLimit returns the limit a field sets, or fallback for fields setting none and
+// names that are not schema fields
+func fieldLimit(s *schema.Schema, fieldName string, limit func(*schema.Field) int, fallback int) int {
+	if fieldName == "" {
+		return fallback
+	}
+	if _, field, err := s.ResolveField(fieldName); err == nil && limit(field) > 0 {
+		return limit(field)
+	}
+	return fallback
+}
@@ -0,0 +1,118 @@
+package translator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckValueLengths(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"sku":         {Type: schema.TypeText, MaxLength: 8, MaxValues: 2, Aliases: []string{"code"}},
+		"name":        {Type: schema.TypeText},
+		"description": {Type: schema.TypeText, MaxLength: 100},
+		"price":       {Type: schema.TypeFloat},
+		"region":      {Type: schema.TypeText, MaxValues: 5},
+	}, schema.SchemaOptions{DefaultField: "name", MaxValueLength: 20, MaxListSize: 3})
+
+	long := strings.Repeat("x", 21)
+	tests := []struct {
+		name  string
+		query string
+		err   string
+	}{
+		{"within limits", "sku:ABCD1234 AND name:" + strings.Repeat("x", 20), ""},
+		{"field limit", "sku:ABCD12345", "value for field sku has 9 characters, at most 8 are allowed at line 1, column 1"},
+		{"field limit through alias", "code:ABCD12345", "value for field code has 9 characters, at most 8 are allowed"},
+		{"field limit above schema limit", "description:" + strings.Repeat("x", 50), ""},
+		{"schema limit", "name:" + long, "value for field name has 21 characters"},
+		{"phrase", `name:"` + long + `"`, "value for field name has 21 characters"},
+		{"number", "price:" + strings.Repeat("9", 21), "value for field price has 21 characters"},
+		{"range bound", "sku:[A TO ABCDEFGHI]", "value for field sku has 9 characters"},
+		{"in-list value", "sku:(A,ABCDEFGHI)", "value for field sku has 9 characters"},
+		{"field group term", "sku:(A OR ABCDEFGHI)", "value for field sku has 9 characters"},
+		{"free text", "shoes " + long, "value for field name has 21 characters"},
+		{"fuzzy", "sku:ABCDEFGHI~1", "value for field sku has 9 characters"},
+		{"characters, not bytes", "sku:ÀÉÎÕÜÇÑÅ", ""},
+		{"unknown field", "color:" + long, "value for field color has 21 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			err = CheckValueLengths(ast, testSchema)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var limitErr *ValueLimitError
+			require.ErrorAs(t, err, &limitErr)
+			assert.Equal(t, "characters", limitErr.Unit())
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestCheckListSizes_FieldLimits(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"sku":         {Type: schema.TypeText, MaxLength: 8, MaxValues: 2, Aliases: []string{"code"}},
+		"name":        {Type: schema.TypeText},
+		"description": {Type: schema.TypeText, MaxLength: 100},
+		"price":       {Type: schema.TypeFloat},
+		"region":      {Type: schema.TypeText, MaxValues: 5},
+	}, schema.SchemaOptions{DefaultField: "name", MaxValueLength: 20, MaxListSize: 3})
+
+	tests := []struct {
+		name  string
+		query string
+		err   string
+	}{
+		{"schema list limit", "name:(a,b,c,d)", "list for field name has 4 values, at most 3 are allowed"},
+		{"field list limit below schema limit", "sku:(a,b,c)", "list for field sku has 3 values, at most 2 are allowed"},
+		{"field list limit above schema limit", "region:(a,b,c,d,e)", ""},
+		{"field group limit", "sku:(a OR b OR c)", "field group for field sku has 3 values, at most 2 are allowed"},
+		{"field group within limit", "region:(a b c d e)", ""},
+		{"field group without field limit", "name:(a OR b OR c OR d)", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			err = CheckListSizes(ast, testSchema)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var limitErr *ValueLimitError
+			require.ErrorAs(t, err, &limitErr)
+			assert.Equal(t, "values", limitErr.Unit())
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestValueLimits_Translate(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"sku":         {Type: schema.TypeText, MaxLength: 8, MaxValues: 2, Aliases: []string{"code"}},
+		"name":        {Type: schema.TypeText},
+		"description": {Type: schema.TypeText, MaxLength: 100},
+		"price":       {Type: schema.TypeFloat},
+		"region":      {Type: schema.TypeText, MaxValues: 5},
+	}, schema.SchemaOptions{DefaultField: "name", MaxValueLength: 20, MaxListSize: 3})
+
+	ast, err := parser.NewParser("price:>1 AND sku:" + strings.Repeat("A", 1000)).Parse()
+	require.NoError(t, err)
+
+	for _, trans := range []Translator{NewPostgresTranslator(), NewMongoDBTranslator()} {
+		_, err = trans.Translate(ast, testSchema)
+		assert.EqualError(t, err, "value for field sku has 1000 characters, at most 8 are allowed at line 1, column 14", trans.DatabaseType())
+	}
+}
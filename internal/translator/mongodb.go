@@ -4,14 +4,17 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/infiniv/rsearch/internal/ir"
 	"github.com/infiniv/rsearch/internal/parser"
 	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
 )
 
 // MongoDBTranslator translates AST nodes to MongoDB query filters.
 type MongoDBTranslator struct {
-	boosts   []map[string]interface{}
-	metadata map[string]interface{}
+	boosts     []map[string]interface{}
+	metadata   map[string]interface{}
+	downgrades []downgrade
 }
 
 // NewMongoDBTranslator creates a new MongoDB translator.
@@ -26,11 +29,25 @@ func (m *MongoDBTranslator) DatabaseType() string {
 
 // Translate converts an AST node to a MongoDB query filter.
 func (m *MongoDBTranslator) Translate(ast parser.Node, schema *schema.Schema) (*TranslatorOutput, error) {
-	// Reset state for new translation
-	m.boosts = make([]map[string]interface{}, 0)
-	m.metadata = make(map[string]interface{})
+	// Apply the schema's analyzer, operator whitelists and value rewrites
+	ast, warnings, err := prepareForTranslation(ast, schema)
+	if err != nil {
+		return nil, err
+	}
 
-	filter, err := m.translateNode(ast, schema)
+	// Collect state on a translator of this call, so that concurrent calls through a
+	// translator shared by the registry do not mix their boosts and metadata
+	m = &MongoDBTranslator{
+		boosts:   make([]map[string]interface{}, 0),
+		metadata: make(map[string]interface{}),
+	}
+
+	query, err := ir.Lower(ast, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := m.translateNode(query, schema)
 	if err != nil {
 		return nil, err
 	}
@@ -53,442 +70,327 @@ func (m *MongoDBTranslator) Translate(ast parser.Node, schema *schema.Schema) (*
 		output.Metadata[k] = v
 	}
 
+	output.ReferencedFields = ReferencedFields(ast, schema)
+	addPartialIndexes(output, PartialIndexes(ast, schema, output.ReferencedFields))
+	addWarnings(output, warnings)
+	addDowngrades(output, m.downgrades)
+	addCoercions(output, ast)
 	return output, nil
 }
 
-// translateNode recursively translates AST nodes to MongoDB filters.
-func (m *MongoDBTranslator) translateNode(node parser.Node, schema *schema.Schema) (interface{}, error) {
+// translateNode recursively translates IR nodes to MongoDB filters.
+func (m *MongoDBTranslator) translateNode(node ir.Node, schema *schema.Schema) (interface{}, error) {
 	switch n := node.(type) {
-	case *parser.FieldQuery:
-		return m.translateFieldQuery(n, schema)
-	case *parser.BinaryOp:
-		return m.translateBinaryOp(n, schema)
-	case *parser.RangeQuery:
-		return m.translateRangeQuery(n, schema)
-	case *parser.UnaryOp:
-		return m.translateUnaryOp(n, schema)
-	case *parser.ExistsQuery:
-		return m.translateExistsQuery(n, schema)
-	case *parser.BoostQuery:
-		return m.translateBoostQuery(n, schema)
-	case *parser.GroupQuery:
-		return m.translateGroupQuery(n, schema)
-	case *parser.RequiredQuery:
-		return m.translateRequiredQuery(n, schema)
-	case *parser.ProhibitedQuery:
-		return m.translateProhibitedQuery(n, schema)
-	case *parser.TermQuery:
-		return m.translateTermQuery(n, schema)
-	case *parser.PhraseQuery:
-		return m.translatePhraseQuery(n, schema)
-	case *parser.WildcardQuery:
-		return m.translateWildcardQuery(n, schema)
-	case *parser.FuzzyQuery:
-		return m.translateFuzzyQuery(n, schema)
-	case *parser.ProximityQuery:
-		return m.translateProximityQuery(n, schema)
-	case *parser.FieldGroupQuery:
-		return m.translateFieldGroupQuery(n, schema)
-	default:
-		return nil, fmt.Errorf("unsupported node type: %s", node.Type())
-	}
-}
-
-// translateFieldQuery translates a simple field:value query.
-func (m *MongoDBTranslator) translateFieldQuery(fq *parser.FieldQuery, schema *schema.Schema) (interface{}, error) {
-	// Validate field exists in schema
-	columnName, _, err := schema.ResolveField(fq.Field)
-	if err != nil {
-		return nil, fmt.Errorf("field %s not found in schema %s", fq.Field, schema.Name)
-	}
-
-	// Handle different value types
-	switch v := fq.Value.(type) {
-	case *parser.WildcardValue:
+	case *ir.And:
+		return m.translateClauses(n.Clauses, "$and", schema)
+	case *ir.Or:
+		return m.translateClauses(n.Clauses, "$or", schema)
+	case *ir.Group:
+		return m.translateNode(n.Clause, schema)
+	case *ir.Not:
+		filter, err := m.translateNode(n.Clause, schema)
+		if err != nil {
+			return nil, err
+		}
+		return negateFilter(filter), nil
+	case *ir.Boost:
+		// The boost is stored in metadata; the filter is the same as the boosted clause
+		filter, err := m.translateNode(n.Clause, schema)
+		if err != nil {
+			return nil, err
+		}
+		m.boosts = append(m.boosts, boostMetadata(n, schema))
+		return filter, nil
+	case *ir.Equal:
+		return fieldFilter(n.Column, m.equals(n.Column.Def, n.Value))
+	case *ir.Wildcard:
 		// Convert wildcard pattern to regex pattern
-		pattern := m.wildcardToRegex(v.Pattern)
-		return map[string]interface{}{
-			columnName: map[string]interface{}{
-				"$regex": pattern,
-			},
-		}, nil
-
-	case *parser.RegexValue:
+		return fieldFilter(n.Column, m.matches(n.Column.Def, n.Pattern))
+	case *ir.Regex:
 		// Use MongoDB regex operator
-		return map[string]interface{}{
-			columnName: map[string]interface{}{
-				"$regex":   v.Pattern,
-				"$options": "",
-			},
-		}, nil
-
-	case *parser.PhraseValue:
-		// Phrase is exact match
-		return map[string]interface{}{
-			columnName: v.Phrase,
-		}, nil
-
+		return fieldFilter(n.Column, map[string]interface{}{
+			"$regex":   regexPattern(n.Value, schema),
+			"$options": n.Value.Flags,
+		})
+	case *ir.Phrase:
+		// Phrase matching depends on the field's phrase match mode
+		key, err := documentKey(n.Column)
+		if err != nil {
+			return nil, err
+		}
+		return m.phrase(key, n.Column.Def, n.Phrase), nil
+	case *ir.Range:
+		return fieldFilter(n.Column, rangeFilter(n))
+	case *ir.Exists:
+		// Field exists and is not null
+		return fieldFilter(n.Column, map[string]interface{}{
+			"$exists": true,
+			"$ne":     nil,
+		})
+	case *ir.Fuzzy:
+		return m.translateFuzzy(n, schema)
+	case *ir.Proximity:
+		return m.translateProximity(n, schema)
+	case *ir.In:
+		return m.translateIn(n, schema)
+	case *ir.Fragment:
+		return fragmentFilter(n)
 	default:
-		// Simple equality
-		value := fq.Value.Value()
-		return map[string]interface{}{
-			columnName: value,
-		}, nil
+		return nil, fmt.Errorf("unsupported node type: %T", node)
 	}
 }
 
-// wildcardToRegex converts wildcard pattern to regex pattern.
-func (m *MongoDBTranslator) wildcardToRegex(pattern string) string {
-	// Escape special regex characters except * and ?
-	pattern = strings.ReplaceAll(pattern, ".", "\\.")
-	pattern = strings.ReplaceAll(pattern, "+", "\\+")
-	pattern = strings.ReplaceAll(pattern, "^", "\\^")
-	pattern = strings.ReplaceAll(pattern, "$", "\\$")
-	pattern = strings.ReplaceAll(pattern, "(", "\\(")
-	pattern = strings.ReplaceAll(pattern, ")", "\\)")
-	pattern = strings.ReplaceAll(pattern, "[", "\\[")
-	pattern = strings.ReplaceAll(pattern, "]", "\\]")
-	pattern = strings.ReplaceAll(pattern, "{", "\\{")
-	pattern = strings.ReplaceAll(pattern, "}", "\\}")
-	pattern = strings.ReplaceAll(pattern, "|", "\\|")
-	pattern = strings.ReplaceAll(pattern, "\\", "\\\\")
-
-	// Convert wildcards
-	pattern = strings.ReplaceAll(pattern, "*", ".*")
-	pattern = strings.ReplaceAll(pattern, "?", ".")
-
-	// Anchor the pattern
-	return "^" + pattern + "$"
-}
-
-// translateBinaryOp translates AND/OR operations.
-func (m *MongoDBTranslator) translateBinaryOp(bo *parser.BinaryOp, schema *schema.Schema) (interface{}, error) {
-	left, err := m.translateNode(bo.Left, schema)
-	if err != nil {
-		return nil, err
-	}
-
-	right, err := m.translateNode(bo.Right, schema)
-	if err != nil {
-		return nil, err
+// translateClauses translates the clauses of AND or OR into the array of $and or $or.
+// Clauses that translate to the same operator, such as boosted groups or field groups
+// joined with it, are spliced into the array, so long chains stay one level deep.
+// Conditions of an AND on the same field are merged into one document, so
+// price:>10 AND price:<20 is {price: {$gt: 10, $lt: 20}}; an AND left with a single
+// filter is that filter.
+func (m *MongoDBTranslator) translateClauses(nodes []ir.Node, operator string, schema *schema.Schema) (interface{}, error) {
+	nodes = chainClauses(nodes, operator)
+	filters := make([]interface{}, 0, len(nodes))
+	merged := make(map[string]map[string]interface{})
+	for _, node := range nodes {
+		filter, err := m.translateNode(node, schema)
+		if err != nil {
+			return nil, err
+		}
+		if operator == "$and" && mergeable(node) {
+			if key, condition, ok := fieldCondition(filter); ok {
+				if into, ok := merged[key]; ok && !sharesOperator(into, condition) {
+					for op, value := range condition {
+						into[op] = value
+					}
+					continue
+				}
+				// Copy the condition, as merging into it must not alter other filters
+				copied := make(map[string]interface{}, len(condition))
+				for op, value := range condition {
+					copied[op] = value
+				}
+				if _, ok := merged[key]; !ok {
+					merged[key] = copied
+				}
+				filter = map[string]interface{}{key: copied}
+			}
+		}
+		filters = append(filters, spliceOperands(filter, operator)...)
 	}
-
-	operator := strings.ToLower(bo.Op)
-	if operator == "and" {
-		return map[string]interface{}{
-			"$and": []interface{}{left, right},
-		}, nil
-	} else if operator == "or" {
-		return map[string]interface{}{
-			"$or": []interface{}{left, right},
-		}, nil
+	if operator == "$and" && len(filters) == 1 {
+		return filters[0], nil
 	}
-
-	return nil, fmt.Errorf("unsupported binary operator: %s", bo.Op)
+	return map[string]interface{}{operator: filters}, nil
 }
 
-// translateRangeQuery translates range queries like field:[start TO end].
-func (m *MongoDBTranslator) translateRangeQuery(rq *parser.RangeQuery, schema *schema.Schema) (interface{}, error) {
-	// Validate field exists in schema
-	columnName, _, err := schema.ResolveField(rq.Field)
-	if err != nil {
-		return nil, fmt.Errorf("field %s not found in schema %s", rq.Field, schema.Name)
-	}
-
-	// Check for wildcard boundaries
-	startIsWildcard := m.isWildcard(rq.Start)
-	endIsWildcard := m.isWildcard(rq.End)
-
-	rangeFilter := make(map[string]interface{})
-
-	// Start condition (if not wildcard)
-	if !startIsWildcard {
-		if rq.InclusiveStart {
-			rangeFilter["$gte"] = rq.Start.Value()
-		} else {
-			rangeFilter["$gt"] = rq.Start.Value()
+// chainClauses returns the clauses of a chain of the operator, splicing in the clauses of
+// nested ANDs or ORs of the operator, grouped or not, so their conditions can be merged
+func chainClauses(nodes []ir.Node, operator string) []ir.Node {
+	var clauses []ir.Node
+	for _, node := range nodes {
+		inner := node
+		for {
+			group, ok := inner.(*ir.Group)
+			if !ok {
+				break
+			}
+			inner = group.Clause
 		}
-	}
-
-	// End condition (if not wildcard)
-	if !endIsWildcard {
-		if rq.InclusiveEnd {
-			rangeFilter["$lte"] = rq.End.Value()
-		} else {
-			rangeFilter["$lt"] = rq.End.Value()
+		switch n := inner.(type) {
+		case *ir.And:
+			if operator == "$and" {
+				clauses = append(clauses, chainClauses(n.Clauses, operator)...)
+				continue
+			}
+		case *ir.Or:
+			if operator == "$or" {
+				clauses = append(clauses, chainClauses(n.Clauses, operator)...)
+				continue
+			}
 		}
+		clauses = append(clauses, node)
 	}
-
-	return map[string]interface{}{
-		columnName: rangeFilter,
-	}, nil
+	return clauses
 }
 
-// isWildcard checks if a ValueNode represents a wildcard (*).
-func (m *MongoDBTranslator) isWildcard(v parser.ValueNode) bool {
-	if v == nil {
+// mergeable reports whether the condition of a clause on its field may be merged with
+// other conditions on the field. Conditions on arrays and JSON documents are not, as
+// separate conditions may match different elements where a merged one must match the
+// same element.
+func mergeable(node ir.Node) bool {
+	var column ir.Column
+	switch n := node.(type) {
+	case *ir.Not:
+		return mergeable(n.Clause)
+	case *ir.Boost:
+		return mergeable(n.Clause)
+	case *ir.Group:
+		return mergeable(n.Clause)
+	case *ir.Equal:
+		column = n.Column
+	case *ir.Wildcard:
+		column = n.Column
+	case *ir.Regex:
+		column = n.Column
+	case *ir.Range:
+		column = n.Column
+	case *ir.Exists:
+		column = n.Column
+	case *ir.In:
+		column = n.Column
+	default:
 		return false
 	}
-	val := v.Value()
-	if strVal, ok := val.(string); ok {
-		return strVal == "*"
-	}
-	return false
+	return column.Def != nil && column.Def.Type != schema.TypeArray && column.Def.Type != schema.TypeJSON
 }
 
-// translateUnaryOp translates unary operations (+, -, NOT).
-func (m *MongoDBTranslator) translateUnaryOp(uo *parser.UnaryOp, schema *schema.Schema) (interface{}, error) {
-	operand, err := m.translateNode(uo.Operand, schema)
-	if err != nil {
-		return nil, err
-	}
-
-	// Handle different operators
-	switch uo.Op {
-	case "+":
-		// Required operator - just pass through
-		return operand, nil
-	case "-", "NOT":
-		// Prohibited or NOT operator
-		// For simple field queries, use $ne
-		if operandMap, ok := operand.(map[string]interface{}); ok {
-			// Check if it's a simple field equality
-			if len(operandMap) == 1 {
-				for field, value := range operandMap {
-					// Skip if field starts with $ (operator)
-					if !strings.HasPrefix(field, "$") {
-						// Simple field equality - use $ne
-						if _, ok := value.(map[string]interface{}); ok {
-							// Already has operators, wrap in $nor
-							return map[string]interface{}{
-								"$nor": []interface{}{operand},
-							}, nil
-						}
-						// Simple value - use $ne
-						return map[string]interface{}{
-							field: map[string]interface{}{
-								"$ne": value,
-							},
-						}, nil
-					}
-				}
+// fieldCondition returns the document key and operator document of a filter that is a
+// single {key: {$op: value, ...}} condition
+func fieldCondition(filter interface{}) (string, map[string]interface{}, bool) {
+	filterMap, ok := filter.(map[string]interface{})
+	if !ok || len(filterMap) != 1 {
+		return "", nil, false
+	}
+	for key, value := range filterMap {
+		condition, ok := value.(map[string]interface{})
+		if !ok || strings.HasPrefix(key, "$") {
+			return "", nil, false
+		}
+		for op := range condition {
+			if !strings.HasPrefix(op, "$") {
+				return "", nil, false
 			}
 		}
-		// Complex expressions - use $nor
-		return map[string]interface{}{
-			"$nor": []interface{}{operand},
-		}, nil
-	default:
-		return nil, fmt.Errorf("unsupported unary operator: %s", uo.Op)
-	}
-}
-
-// translateExistsQuery translates existence checks (_exists_:field).
-func (m *MongoDBTranslator) translateExistsQuery(eq *parser.ExistsQuery, schema *schema.Schema) (interface{}, error) {
-	// Validate field exists in schema
-	columnName, _, err := schema.ResolveField(eq.Field)
-	if err != nil {
-		return nil, fmt.Errorf("field %s not found in schema %s", eq.Field, schema.Name)
+		return key, condition, true
 	}
-
-	// MongoDB exists check - field exists and is not null
-	return map[string]interface{}{
-		columnName: map[string]interface{}{
-			"$exists": true,
-			"$ne":     nil,
-		},
-	}, nil
+	return "", nil, false
 }
 
-// translateBoostQuery translates boost queries (query^boost).
-// For MongoDB, boost is stored in metadata; the filter is the same as the wrapped query.
-func (m *MongoDBTranslator) translateBoostQuery(bq *parser.BoostQuery, schema *schema.Schema) (interface{}, error) {
-	// Translate the wrapped query
-	filter, err := m.translateNode(bq.Query, schema)
-	if err != nil {
-		return nil, err
-	}
-
-	// Store boost metadata with snake_case query type
-	queryType := m.toSnakeCase(bq.Query.Type())
-	boostInfo := map[string]interface{}{
-		"query": queryType,
-		"boost": bq.Boost,
+// sharesOperator reports whether two operator documents use an operator in common
+func sharesOperator(a, b map[string]interface{}) bool {
+	for op := range b {
+		if _, ok := a[op]; ok {
+			return true
+		}
 	}
-	m.boosts = append(m.boosts, boostInfo)
-
-	return filter, nil
+	return false
 }
 
-// toSnakeCase converts CamelCase to snake_case
-func (m *MongoDBTranslator) toSnakeCase(s string) string {
-	var result strings.Builder
-	for i, r := range s {
-		if i > 0 && r >= 'A' && r <= 'Z' {
-			result.WriteRune('_')
-		}
-		if r >= 'A' && r <= 'Z' {
-			result.WriteRune(r - 'A' + 'a')
-		} else {
-			result.WriteRune(r)
+// spliceOperands returns the operands of a filter that is only an $and or $or array of
+// the given operator, or the filter itself otherwise
+func spliceOperands(filter interface{}, operator string) []interface{} {
+	if filterMap, ok := filter.(map[string]interface{}); ok && len(filterMap) == 1 {
+		if operands, ok := filterMap[operator].([]interface{}); ok {
+			return operands
 		}
 	}
-	return result.String()
+	return []interface{}{filter}
 }
 
-// translateGroupQuery translates parenthesized expressions.
-func (m *MongoDBTranslator) translateGroupQuery(gq *parser.GroupQuery, schema *schema.Schema) (interface{}, error) {
-	// Group queries in MongoDB just pass through the inner query
-	return m.translateNode(gq.Query, schema)
-}
-
-// translateRequiredQuery translates +term (required term).
-func (m *MongoDBTranslator) translateRequiredQuery(rq *parser.RequiredQuery, schema *schema.Schema) (interface{}, error) {
-	// Required terms pass through - they must match
-	return m.translateNode(rq.Query, schema)
-}
-
-// translateProhibitedQuery translates -term (prohibited term).
-func (m *MongoDBTranslator) translateProhibitedQuery(pq *parser.ProhibitedQuery, schema *schema.Schema) (interface{}, error) {
-	inner, err := m.translateNode(pq.Query, schema)
+// fieldFilter returns the filter {key: condition} on a column's document key.
+func fieldFilter(column ir.Column, condition interface{}) (interface{}, error) {
+	key, err := documentKey(column)
 	if err != nil {
 		return nil, err
 	}
-
-	// For simple field queries, use $ne
-	if innerMap, ok := inner.(map[string]interface{}); ok {
-		if len(innerMap) == 1 {
-			for field, value := range innerMap {
-				if !strings.HasPrefix(field, "$") {
-					// Simple field equality - use $ne
-					if _, ok := value.(map[string]interface{}); ok {
-						// Already has operators, wrap in $nor
-						return map[string]interface{}{
-							"$nor": []interface{}{inner},
-						}, nil
-					}
-					return map[string]interface{}{
-						field: map[string]interface{}{
-							"$ne": value,
-						},
-					}, nil
-				}
-			}
-		}
-	}
-
-	// Complex expressions - use $nor
-	return map[string]interface{}{
-		"$nor": []interface{}{inner},
-	}, nil
+	return map[string]interface{}{key: condition}, nil
 }
 
-// translateTermQuery translates standalone terms (uses default field).
-func (m *MongoDBTranslator) translateTermQuery(tq *parser.TermQuery, schema *schema.Schema) (interface{}, error) {
-	// Use default field from schema options
-	if schema.Options.DefaultField == "" {
-		return nil, fmt.Errorf("standalone term '%s' requires a default field in schema", tq.Term)
-	}
-
-	columnName, _, err := schema.ResolveField(schema.Options.DefaultField)
-	if err != nil {
-		return nil, fmt.Errorf("default field %s not found in schema %s", schema.Options.DefaultField, schema.Name)
+// literal encodes a user value for an equality position.
+// MongoDB treats a bare value as an implicit equality, but a string that looks like an
+// operator ("$where", "$gt") could be reinterpreted by drivers or tooling that rebuild
+// filters from JSON. Such values are wrapped in an explicit $eq so they can only ever be
+// compared as data.
+func literal(value interface{}) interface{} {
+	if str, ok := value.(string); ok && strings.HasPrefix(str, "$") {
+		return map[string]interface{}{"$eq": str}
 	}
-
-	return map[string]interface{}{
-		columnName: tq.Term,
-	}, nil
+	return value
 }
 
-// translatePhraseQuery translates standalone phrases (uses default field).
-func (m *MongoDBTranslator) translatePhraseQuery(pq *parser.PhraseQuery, schema *schema.Schema) (interface{}, error) {
-	// Use default field from schema options
-	if schema.Options.DefaultField == "" {
-		return nil, fmt.Errorf("standalone phrase '%s' requires a default field in schema", pq.Phrase)
-	}
-
-	columnName, _, err := schema.ResolveField(schema.Options.DefaultField)
-	if err != nil {
-		return nil, fmt.Errorf("default field %s not found in schema %s", schema.Options.DefaultField, schema.Name)
+// documentKey returns the document key a column is filtered by.
+// Keys must never be interpreted as operators, so column names starting with $ or
+// containing empty path segments or NUL bytes are rejected.
+func documentKey(column ir.Column) (string, error) {
+	key := column.Name
+	if key == "" || strings.HasPrefix(key, "$") || strings.ContainsRune(key, 0) {
+		return "", fmt.Errorf("field %s maps to unsafe MongoDB key %q", column.Field, key)
+	}
+	for _, segment := range strings.Split(key, ".") {
+		if segment == "" || strings.HasPrefix(segment, "$") {
+			return "", fmt.Errorf("field %s maps to unsafe MongoDB key %q", column.Field, key)
+		}
 	}
-
-	return map[string]interface{}{
-		columnName: pq.Phrase,
-	}, nil
+	return key, nil
 }
 
-// translateWildcardQuery translates standalone wildcards (uses default field).
-func (m *MongoDBTranslator) translateWildcardQuery(wq *parser.WildcardQuery, schema *schema.Schema) (interface{}, error) {
-	// Use default field from schema options
-	if schema.Options.DefaultField == "" {
-		return nil, fmt.Errorf("standalone wildcard '%s' requires a default field in schema", wq.Pattern)
-	}
-
-	columnName, _, err := schema.ResolveField(schema.Options.DefaultField)
-	if err != nil {
-		return nil, fmt.Errorf("default field %s not found in schema %s", schema.Options.DefaultField, schema.Name)
+// negateFilter negates a filter. A field compared with a plain value is negated with
+// $ne; any other filter, including fields with operators, is wrapped in $nor.
+func negateFilter(filter interface{}) interface{} {
+	if filterMap, ok := filter.(map[string]interface{}); ok && len(filterMap) == 1 {
+		for field, value := range filterMap {
+			if strings.HasPrefix(field, "$") {
+				break
+			}
+			if _, ok := value.(map[string]interface{}); ok {
+				break
+			}
+			return map[string]interface{}{
+				field: map[string]interface{}{"$ne": value},
+			}
+		}
 	}
-
-	// Convert wildcard pattern to regex pattern
-	pattern := m.wildcardToRegex(wq.Pattern)
-
 	return map[string]interface{}{
-		columnName: map[string]interface{}{
-			"$regex": pattern,
-		},
-	}, nil
+		"$nor": []interface{}{filter},
+	}
 }
 
-// translateFuzzyQuery translates fuzzy search queries (term~distance).
-func (m *MongoDBTranslator) translateFuzzyQuery(fq *parser.FuzzyQuery, schema *schema.Schema) (interface{}, error) {
-	// Determine field - use provided field or default
-	fieldName := fq.Field
-	if fieldName == "" {
-		if schema.Options.DefaultField == "" {
-			return nil, fmt.Errorf("fuzzy search '%s~%d' requires a field or default field in schema", fq.Term, fq.Distance)
+// rangeFilter returns the condition of a range query like field:[start TO end].
+func rangeFilter(r *ir.Range) map[string]interface{} {
+	condition := make(map[string]interface{})
+	if r.Start != nil {
+		if r.Start.Inclusive {
+			condition["$gte"] = r.Start.Value
+		} else {
+			condition["$gt"] = r.Start.Value
 		}
-		fieldName = schema.Options.DefaultField
 	}
-
-	_, _, err := schema.ResolveField(fieldName)
-	if err != nil {
-		return nil, fmt.Errorf("field %s not found in schema %s", fieldName, schema.Name)
+	if r.End != nil {
+		if r.End.Inclusive {
+			condition["$lte"] = r.End.Value
+		} else {
+			condition["$lt"] = r.End.Value
+		}
 	}
+	return condition
+}
 
+// translateFuzzy translates fuzzy search queries (term~distance).
+func (m *MongoDBTranslator) translateFuzzy(f *ir.Fuzzy, schema *schema.Schema) (interface{}, error) {
 	// Check if fuzzy search is enabled
 	if !schema.Options.EnabledFeatures.Fuzzy {
 		return nil, fmt.Errorf("fuzzy search requires text index. Enable in schema or use wildcards instead")
 	}
 
-	// MongoDB with text index: use $text search
-	filter := map[string]interface{}{
-		"$text": map[string]interface{}{
-			"$search": fq.Term,
-		},
+	// A filter cannot compare the text score, so the threshold is left to the executor,
+	// e.g. as {$match: {$expr: {$gte: [{$meta: "textScore"}, min]}}} in an aggregation
+	if minSimilarity := schema.MinSimilarity(f.Column.Def); minSimilarity > 0 {
+		m.metadata["minTextScore"] = minSimilarity
 	}
 
+	// MongoDB with text index: use $text search
+	// sqllint:trusted a downgrade reason, not SQL
+	m.downgrades = append(m.downgrades, newDowngrade(f.Query, "fuzzy", rsearch.SubstituteTextSearch,
+		fmt.Sprintf("$text matches the stemmed word in every field of the text index, ignoring the edit distance of %d and the field %s", f.Distance, f.Column.Field)))
+	filter := textSearch(f.Term, f.Column.Def)
+
 	// Store fuzzy distance in metadata for reference
-	m.metadata["fuzzy_distance"] = fq.Distance
+	m.metadata["fuzzy_distance"] = f.Distance
 
 	return filter, nil
 }
 
-// translateProximityQuery translates proximity search queries ("phrase"~distance).
-func (m *MongoDBTranslator) translateProximityQuery(pq *parser.ProximityQuery, schema *schema.Schema) (interface{}, error) {
-	// Determine field - use provided field or default
-	fieldName := pq.Field
-	if fieldName == "" {
-		if schema.Options.DefaultField == "" {
-			return nil, fmt.Errorf("proximity search requires a field or default field in schema")
-		}
-		fieldName = schema.Options.DefaultField
-	}
-
-	_, _, err := schema.ResolveField(fieldName)
-	if err != nil {
-		return nil, fmt.Errorf("field %s not found in schema %s", fieldName, schema.Name)
-	}
-
+// translateProximity translates proximity search queries ("phrase"~distance).
+func (m *MongoDBTranslator) translateProximity(px *ir.Proximity, schema *schema.Schema) (interface{}, error) {
 	// Check if proximity search is enabled
 	if !schema.Options.EnabledFeatures.Proximity {
 		return nil, fmt.Errorf("proximity search requires text index. Enable in schema or use phrase match instead")
@@ -496,141 +398,54 @@ func (m *MongoDBTranslator) translateProximityQuery(pq *parser.ProximityQuery, s
 
 	// MongoDB with text index: use $text search with phrase
 	// Wrap phrase in quotes for exact phrase matching
-	searchPhrase := fmt.Sprintf("\"%s\"", pq.Phrase)
+	searchPhrase := fmt.Sprintf("\"%s\"", px.Phrase) // sqllint:trusted a $text search value, not SQL
+	// sqllint:trusted a downgrade reason, not SQL
+	m.downgrades = append(m.downgrades, newDowngrade(px.Query, "proximity", rsearch.SubstituteTextSearch,
+		fmt.Sprintf("$text matches the exact phrase in every field of the text index, not the words within %d words in %s", px.Distance, px.Column.Field)))
 
-	filter := map[string]interface{}{
-		"$text": map[string]interface{}{
-			"$search": searchPhrase,
-		},
-	}
+	filter := textSearch(searchPhrase, px.Column.Def)
 
 	// Store proximity distance in metadata for reference
-	m.metadata["proximity_distance"] = pq.Distance
+	m.metadata["proximity_distance"] = px.Distance
 
 	return filter, nil
 }
 
-// translateFieldGroupQuery translates field:(value1 OR value2) queries.
-func (m *MongoDBTranslator) translateFieldGroupQuery(fgq *parser.FieldGroupQuery, schema *schema.Schema) (interface{}, error) {
-	if len(fgq.Queries) == 0 {
-		return nil, fmt.Errorf("empty field group query")
-	}
-
-	// Validate field exists in schema
-	columnName, _, err := schema.ResolveField(fgq.Field)
+// translateIn translates an in-list to $in, or to $nin when the list is excluded. $nin
+// matches documents where the field is null or missing, so unless the schema's
+// ExclusionsMatchNull is set they are excluded with $ne, as NOT IN does in SQL.
+// Unaccent fields match each value with a folded regex instead, as $in cannot carry
+// regex documents.
+func (m *MongoDBTranslator) translateIn(in *ir.In, schema *schema.Schema) (interface{}, error) {
+	columnName, err := documentKey(in.Column)
 	if err != nil {
-		return nil, fmt.Errorf("field %s not found in schema %s", fgq.Field, schema.Name)
-	}
-
-	// Translate each inner query, wrapping terms as field queries
-	var filters []interface{}
-	for _, q := range fgq.Queries {
-		var filter interface{}
-		var err error
-
-		switch inner := q.(type) {
-		case *parser.TermQuery:
-			// Convert term to field query
-			filter = map[string]interface{}{
-				columnName: inner.Term,
-			}
-		case *parser.WildcardQuery:
-			pattern := m.wildcardToRegex(inner.Pattern)
-			filter = map[string]interface{}{
-				columnName: map[string]interface{}{
-					"$regex": pattern,
-				},
-			}
-		case *parser.BinaryOp:
-			// Recursively translate the binary operation
-			filter, err = m.translateFieldGroupBinaryOp(inner, columnName, schema)
-			if err != nil {
-				return nil, err
-			}
-		default:
-			filter, err = m.translateNode(q, schema)
-			if err != nil {
-				return nil, err
-			}
-		}
-		filters = append(filters, filter)
-	}
-
-	if len(filters) == 1 {
-		return filters[0], nil
-	}
-
-	// Join with OR (default for field groups)
-	return map[string]interface{}{
-		"$or": filters,
-	}, nil
-}
-
-// translateFieldGroupBinaryOp handles binary operations within field groups.
-func (m *MongoDBTranslator) translateFieldGroupBinaryOp(bo *parser.BinaryOp, columnName string, schema *schema.Schema) (interface{}, error) {
-	var leftFilter, rightFilter interface{}
-	var err error
-
-	// Handle left side
-	switch left := bo.Left.(type) {
-	case *parser.TermQuery:
-		leftFilter = map[string]interface{}{
-			columnName: left.Term,
-		}
-	case *parser.WildcardQuery:
-		pattern := m.wildcardToRegex(left.Pattern)
-		leftFilter = map[string]interface{}{
-			columnName: map[string]interface{}{
-				"$regex": pattern,
-			},
-		}
-	case *parser.BinaryOp:
-		leftFilter, err = m.translateFieldGroupBinaryOp(left, columnName, schema)
-		if err != nil {
-			return nil, err
-		}
-	default:
-		leftFilter, err = m.translateNode(bo.Left, schema)
-		if err != nil {
-			return nil, err
-		}
+		return nil, err
 	}
+	field, values := in.Column.Def, in.Values
+	excludeNull := in.Exclude && !schema.Options.ExclusionsMatchNull
 
-	// Handle right side
-	switch right := bo.Right.(type) {
-	case *parser.TermQuery:
-		rightFilter = map[string]interface{}{
-			columnName: right.Term,
-		}
-	case *parser.WildcardQuery:
-		pattern := m.wildcardToRegex(right.Pattern)
-		rightFilter = map[string]interface{}{
-			columnName: map[string]interface{}{
-				"$regex": pattern,
-			},
+	if field.Unaccent {
+		conditions := make([]interface{}, len(values), len(values)+1)
+		for i, value := range values {
+			conditions[i] = map[string]interface{}{columnName: m.equals(field, value)}
 		}
-	case *parser.BinaryOp:
-		rightFilter, err = m.translateFieldGroupBinaryOp(right, columnName, schema)
-		if err != nil {
-			return nil, err
+		if !in.Exclude {
+			return map[string]interface{}{"$or": conditions}, nil
 		}
-	default:
-		rightFilter, err = m.translateNode(bo.Right, schema)
-		if err != nil {
-			return nil, err
+		if excludeNull {
+			conditions = append(conditions, map[string]interface{}{columnName: nil})
 		}
+		return map[string]interface{}{"$nor": conditions}, nil
 	}
 
-	operator := strings.ToLower(bo.Op)
-	if operator == "and" {
-		return map[string]interface{}{
-			"$and": []interface{}{leftFilter, rightFilter},
-		}, nil
-	} else if operator == "or" {
+	if !in.Exclude {
 		return map[string]interface{}{
-			"$or": []interface{}{leftFilter, rightFilter},
+			columnName: map[string]interface{}{"$in": values},
 		}, nil
 	}
-
-	return nil, fmt.Errorf("unsupported binary operator in field group: %s", bo.Op)
+	condition := map[string]interface{}{"$nin": values}
+	if excludeNull {
+		condition["$ne"] = nil
+	}
+	return map[string]interface{}{columnName: condition}, nil
 }
@@ -54,7 +54,7 @@ func TestMongoDBTranslator_NumberFieldQuery(t *testing.T) {
 
 	filter, ok := output.Filter.(map[string]interface{})
 	require.True(t, ok)
-	assert.Equal(t, "100", filter["rod_length"])
+	assert.Equal(t, int64(100), filter["rod_length"])
 }
 
 func TestMongoDBTranslator_PhraseValue(t *testing.T) {
@@ -237,8 +237,8 @@ func TestMongoDBTranslator_RangeQueryInclusive(t *testing.T) {
 
 	priceFilter, ok := filter["price"].(map[string]interface{})
 	require.True(t, ok)
-	assert.Equal(t, "50", priceFilter["$gte"])
-	assert.Equal(t, "500", priceFilter["$lte"])
+	assert.Equal(t, float64(50), priceFilter["$gte"])
+	assert.Equal(t, float64(500), priceFilter["$lte"])
 }
 
 func TestMongoDBTranslator_RangeQueryExclusive(t *testing.T) {
@@ -264,8 +264,8 @@ func TestMongoDBTranslator_RangeQueryExclusive(t *testing.T) {
 
 	priceFilter, ok := filter["price"].(map[string]interface{})
 	require.True(t, ok)
-	assert.Equal(t, "50", priceFilter["$gt"])
-	assert.Equal(t, "500", priceFilter["$lt"])
+	assert.Equal(t, float64(50), priceFilter["$gt"])
+	assert.Equal(t, float64(500), priceFilter["$lt"])
 }
 
 func TestMongoDBTranslator_RangeQueryMixed(t *testing.T) {
@@ -291,8 +291,8 @@ func TestMongoDBTranslator_RangeQueryMixed(t *testing.T) {
 
 	priceFilter, ok := filter["price"].(map[string]interface{})
 	require.True(t, ok)
-	assert.Equal(t, "50", priceFilter["$gte"])
-	assert.Equal(t, "500", priceFilter["$lt"])
+	assert.Equal(t, float64(50), priceFilter["$gte"])
+	assert.Equal(t, float64(500), priceFilter["$lt"])
 }
 
 func TestMongoDBTranslator_RangeQueryUnboundedStart(t *testing.T) {
@@ -318,7 +318,7 @@ func TestMongoDBTranslator_RangeQueryUnboundedStart(t *testing.T) {
 
 	priceFilter, ok := filter["price"].(map[string]interface{})
 	require.True(t, ok)
-	assert.Equal(t, "500", priceFilter["$lte"])
+	assert.Equal(t, float64(500), priceFilter["$lte"])
 	assert.NotContains(t, priceFilter, "$gte")
 }
 
@@ -345,7 +345,7 @@ func TestMongoDBTranslator_RangeQueryUnboundedEnd(t *testing.T) {
 
 	priceFilter, ok := filter["price"].(map[string]interface{})
 	require.True(t, ok)
-	assert.Equal(t, "50", priceFilter["$gte"])
+	assert.Equal(t, float64(50), priceFilter["$gte"])
 	assert.NotContains(t, priceFilter, "$lte")
 }
 
@@ -369,7 +369,7 @@ func TestMongoDBTranslator_WildcardValue(t *testing.T) {
 
 	productFilter, ok := filter["product_code"].(map[string]interface{})
 	require.True(t, ok)
-	assert.Equal(t, "^13.*$", productFilter["$regex"])
+	assert.Equal(t, `^13.*\z`, productFilter["$regex"])
 }
 
 func TestMongoDBTranslator_WildcardValueWithQuestionMark(t *testing.T) {
@@ -392,7 +392,7 @@ func TestMongoDBTranslator_WildcardValueWithQuestionMark(t *testing.T) {
 
 	productFilter, ok := filter["product_code"].(map[string]interface{})
 	require.True(t, ok)
-	assert.Equal(t, "^1.w42$", productFilter["$regex"])
+	assert.Equal(t, `^1.w42\z`, productFilter["$regex"])
 }
 
 func TestMongoDBTranslator_RegexValue(t *testing.T) {
@@ -472,6 +472,106 @@ func TestMongoDBTranslator_BoostQuery(t *testing.T) {
 	assert.Equal(t, 2.5, boosts[0]["boost"])
 }
 
+func TestMongoDBTranslator_FlattensChains(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"a": {Type: schema.TypeText},
+		"b": {Type: schema.TypeText},
+		"c": {Type: schema.TypeText},
+		"d": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+
+	tests := []struct {
+		query string
+		want  map[string]interface{}
+	}{
+		{
+			query: "a:1 AND (b:2 AND (c:3 AND d:4))",
+			want:  map[string]interface{}{"$and": []interface{}{map[string]interface{}{"a": "1"}, map[string]interface{}{"b": "2"}, map[string]interface{}{"c": "3"}, map[string]interface{}{"d": "4"}}},
+		},
+		{
+			query: "(a:1 OR b:2)^2 OR (c:3 OR d:4)^3",
+			want:  map[string]interface{}{"$or": []interface{}{map[string]interface{}{"a": "1"}, map[string]interface{}{"b": "2"}, map[string]interface{}{"c": "3"}, map[string]interface{}{"d": "4"}}},
+		},
+		{
+			query: "(a:1 AND b:2)^2 OR c:3",
+			want: map[string]interface{}{"$or": []interface{}{
+				map[string]interface{}{"$and": []interface{}{map[string]interface{}{"a": "1"}, map[string]interface{}{"b": "2"}}},
+				map[string]interface{}{"c": "3"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			output, err := NewMongoDBTranslator().Translate(ast, testSchema)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, output.Filter)
+		})
+	}
+}
+
+func TestMongoDBTranslator_MergesFieldConditions(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"price":  {Type: schema.TypeInteger},
+		"status": {Type: schema.TypeText},
+		"tags":   {Type: schema.TypeArray},
+	}, schema.SchemaOptions{})
+
+	tests := []struct {
+		query string
+		want  map[string]interface{}
+	}{
+		{
+			query: "price:>10 AND price:<20",
+			want:  map[string]interface{}{"price": map[string]interface{}{"$gt": int64(10), "$lt": int64(20)}},
+		},
+		{
+			query: "price:>10 AND status:active AND price:<20 AND NOT price:15",
+			want: map[string]interface{}{"$and": []interface{}{
+				map[string]interface{}{"price": map[string]interface{}{"$gt": int64(10), "$lt": int64(20), "$ne": int64(15)}},
+				map[string]interface{}{"status": "active"},
+			}},
+		},
+		{
+			// Conditions using the same operator stay apart
+			query: "price:>10 AND price:>12",
+			want: map[string]interface{}{"$and": []interface{}{
+				map[string]interface{}{"price": map[string]interface{}{"$gt": int64(10)}},
+				map[string]interface{}{"price": map[string]interface{}{"$gt": int64(12)}},
+			}},
+		},
+		{
+			// Either condition may match a different element of an array
+			query: "tags:>a AND tags:<c",
+			want: map[string]interface{}{"$and": []interface{}{
+				map[string]interface{}{"tags": map[string]interface{}{"$gt": "a"}},
+				map[string]interface{}{"tags": map[string]interface{}{"$lt": "c"}},
+			}},
+		},
+		{
+			query: "price:>10 OR price:<5",
+			want: map[string]interface{}{"$or": []interface{}{
+				map[string]interface{}{"price": map[string]interface{}{"$gt": int64(10)}},
+				map[string]interface{}{"price": map[string]interface{}{"$lt": int64(5)}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			output, err := NewMongoDBTranslator().Translate(ast, testSchema)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, output.Filter)
+		})
+	}
+}
+
 func TestMongoDBTranslator_GroupQuery(t *testing.T) {
 	translator := NewMongoDBTranslator()
 
@@ -615,7 +715,7 @@ func TestMongoDBTranslator_WildcardQuery(t *testing.T) {
 
 	descFilter, ok := filter["description"].(map[string]interface{})
 	require.True(t, ok)
-	assert.Equal(t, "^lap.*$", descFilter["$regex"])
+	assert.Equal(t, `^lap.*\z`, descFilter["$regex"])
 }
 
 func TestMongoDBTranslator_FuzzyQuery(t *testing.T) {
@@ -787,7 +887,7 @@ func TestMongoDBTranslator_FieldGroupQueryWithWildcard(t *testing.T) {
 	require.True(t, ok)
 	statusFilter, ok := second["status"].(map[string]interface{})
 	require.True(t, ok)
-	assert.Equal(t, "^pend.*$", statusFilter["$regex"])
+	assert.Equal(t, `^pend.*\z`, statusFilter["$regex"])
 }
 
 func TestMongoDBTranslator_ComplexNestedQuery(t *testing.T) {
@@ -831,9 +931,10 @@ func TestMongoDBTranslator_ComplexNestedQuery(t *testing.T) {
 	filter, ok := output.Filter.(map[string]interface{})
 	require.True(t, ok)
 
+	// The nested AND is flattened into the outer one
 	andArray, ok := filter["$and"].([]interface{})
 	require.True(t, ok)
-	require.Len(t, andArray, 2)
+	require.Len(t, andArray, 3)
 
 	leftFilter, ok := andArray[0].(map[string]interface{})
 	require.True(t, ok)
@@ -841,11 +942,8 @@ func TestMongoDBTranslator_ComplexNestedQuery(t *testing.T) {
 	require.True(t, ok)
 	require.Len(t, orArray, 2)
 
-	rightFilter, ok := andArray[1].(map[string]interface{})
-	require.True(t, ok)
-	rightAndArray, ok := rightFilter["$and"].([]interface{})
-	require.True(t, ok)
-	require.Len(t, rightAndArray, 2)
+	assert.Equal(t, map[string]interface{}{"region": "ca"}, andArray[1])
+	assert.Contains(t, andArray[2], "product_code")
 }
 
 func TestMongoDBTranslator_NoDefaultField(t *testing.T) {
@@ -880,3 +978,112 @@ func TestMongoDBTranslator_InvalidFieldName(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "not found in schema")
 }
+
+func TestMongoDBTranslator_OperatorLikeValuesAreLiterals(t *testing.T) {
+	translator := NewMongoDBTranslator()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{DefaultField: "name"})
+
+	tests := []struct {
+		name     string
+		ast      parser.Node
+		expected interface{}
+	}{
+		{
+			name:     "field term",
+			ast:      &parser.FieldQuery{Field: "name", Value: &parser.TermValue{Term: "$where"}},
+			expected: map[string]interface{}{"name": map[string]interface{}{"$eq": "$where"}},
+		},
+		{
+			name:     "field phrase",
+			ast:      &parser.FieldQuery{Field: "name", Value: &parser.PhraseValue{Phrase: "$gt"}},
+			expected: map[string]interface{}{"name": map[string]interface{}{"$eq": "$gt"}},
+		},
+		{
+			name:     "standalone term",
+			ast:      &parser.TermQuery{Term: "$ne"},
+			expected: map[string]interface{}{"name": map[string]interface{}{"$eq": "$ne"}},
+		},
+		{
+			name: "field group",
+			ast: &parser.FieldGroupQuery{Field: "name", Queries: []parser.Node{
+				&parser.TermQuery{Term: "$in"},
+			}},
+			expected: map[string]interface{}{"name": map[string]interface{}{"$eq": "$in"}},
+		},
+		{
+			name:     "dotted value stays plain",
+			ast:      &parser.FieldQuery{Field: "name", Value: &parser.TermValue{Term: "a.b"}},
+			expected: map[string]interface{}{"name": "a.b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := translator.Translate(tt.ast, testSchema)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, output.Filter)
+		})
+	}
+}
+
+func TestMongoDBTranslator_NegatedOperatorLikeValue(t *testing.T) {
+	translator := NewMongoDBTranslator()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+
+	ast := &parser.UnaryOp{
+		Op:      "NOT",
+		Operand: &parser.FieldQuery{Field: "name", Value: &parser.TermValue{Term: "$where"}},
+	}
+
+	output, err := translator.Translate(ast, testSchema)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"$nor": []interface{}{
+			map[string]interface{}{"name": map[string]interface{}{"$eq": "$where"}},
+		},
+	}, output.Filter)
+}
+
+func TestMongoDBTranslator_UnsafeColumnKey(t *testing.T) {
+	translator := NewMongoDBTranslator()
+
+	for _, column := range []string{"$where", "profile.$secret", "profile..name"} {
+		t.Run(column, func(t *testing.T) {
+			testSchema := schema.NewSchema("products", map[string]schema.Field{
+				"name": {Type: schema.TypeText, Column: column},
+			}, schema.SchemaOptions{})
+
+			ast := &parser.FieldQuery{
+				Field: "name",
+				Value: &parser.TermValue{Term: "test"},
+			}
+
+			_, err := translator.Translate(ast, testSchema)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "unsafe MongoDB key")
+		})
+	}
+}
+
+func TestMongoDBTranslator_NestedColumnKey(t *testing.T) {
+	translator := NewMongoDBTranslator()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"city": {Type: schema.TypeText, Column: "address.city"},
+	}, schema.SchemaOptions{})
+
+	ast := &parser.FieldQuery{
+		Field: "city",
+		Value: &parser.TermValue{Term: "Paris"},
+	}
+
+	output, err := translator.Translate(ast, testSchema)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"address.city": "Paris"}, output.Filter)
+}
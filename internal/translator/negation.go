@@ -0,0 +1,66 @@
+package translator
+
+import (
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// BindNegations returns a copy of the AST in which the negated clauses of free text
+// must not match, as in Lucene and OpenSearch: "a NOT b" and "a -b" mean a AND NOT b
+// rather than a OR NOT b, and "a b -c" means (a OR b) AND NOT c. Negations joined by an
+// explicit OR are left alone. Schemas with legacyNegation keep the implicit OR.
+func BindNegations(ast parser.Node, s *schema.Schema) parser.Node {
+	if s.Options.LegacyNegation {
+		return ast
+	}
+
+	var bind func(node parser.Node) parser.Node
+	bind = func(node parser.Node) parser.Node {
+		switch n := node.(type) {
+		case *parser.BinaryOp:
+			if !n.Implicit {
+				return &parser.BinaryOp{Op: n.Op, Left: bind(n.Left), Right: bind(n.Right), Pos: n.Pos}
+			}
+			var kept, negated []parser.Node
+			for _, clause := range implicitClauses(n) {
+				clause = bind(clause)
+				switch clause.(type) {
+				case *parser.UnaryOp, *parser.ProhibitedQuery:
+					negated = append(negated, clause)
+				default:
+					kept = append(kept, clause)
+				}
+			}
+
+			var bound parser.Node
+			for _, clause := range kept {
+				if bound == nil {
+					bound = clause
+					continue
+				}
+				bound = &parser.BinaryOp{Op: n.Op, Left: bound, Right: clause, Pos: n.Pos, Implicit: true}
+			}
+			for _, clause := range negated {
+				if bound == nil {
+					bound = clause
+					continue
+				}
+				bound = &parser.BinaryOp{Op: "AND", Left: bound, Right: clause, Pos: n.Pos}
+			}
+			return bound
+		case *parser.UnaryOp:
+			return &parser.UnaryOp{Op: n.Op, Operand: bind(n.Operand), Pos: n.Pos}
+		case *parser.RequiredQuery:
+			return &parser.RequiredQuery{Query: bind(n.Query), Pos: n.Pos}
+		case *parser.ProhibitedQuery:
+			return &parser.ProhibitedQuery{Query: bind(n.Query), Pos: n.Pos}
+		case *parser.BoostQuery:
+			return &parser.BoostQuery{Query: bind(n.Query), Boost: n.Boost, Pos: n.Pos}
+		case *parser.GroupQuery:
+			return &parser.GroupQuery{Query: bind(n.Query), Pos: n.Pos}
+		}
+		// Field groups hold values of their field, not clauses
+		return node
+	}
+	return bind(ast)
+}
@@ -0,0 +1,101 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindNegations(t *testing.T) {
+	fields := map[string]schema.Field{
+		"name":   {Type: schema.TypeText},
+		"status": {Type: schema.TypeText},
+		"region": {Type: schema.TypeText},
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		want   string
+		legacy string // translation with legacyNegation
+	}{
+		{
+			name:   "adjacent NOT",
+			query:  "name:a NOT status:b",
+			want:   "name = $1 AND NOT status = $2",
+			legacy: "name = $1 OR NOT status = $2",
+		},
+		{
+			name:   "adjacent prohibited clause",
+			query:  "name:a -status:b",
+			want:   "name = $1 AND NOT status = $2",
+			legacy: "name = $1 OR NOT status = $2",
+		},
+		{
+			name:   "optional clauses stay alternatives",
+			query:  "name:a region:c -status:b",
+			want:   "(name = $1 OR region = $2) AND NOT status = $3",
+			legacy: "(name = $1 OR region = $2) OR NOT status = $3",
+		},
+		{
+			name:   "leading negation",
+			query:  "-status:b name:a",
+			want:   "name = $1 AND NOT status = $2",
+			legacy: "NOT status = $1 OR name = $2",
+		},
+		{
+			name:   "only negations",
+			query:  "-status:b -region:c",
+			want:   "NOT status = $1 AND NOT region = $2",
+			legacy: "NOT status = $1 OR NOT region = $2",
+		},
+		{
+			name:   "inside a group",
+			query:  "region:c AND (name:a NOT status:b)",
+			want:   "region = $1 AND (name = $2 AND NOT status = $3)",
+			legacy: "region = $1 AND (name = $2 OR NOT status = $3)",
+		},
+		{
+			name:   "explicit OR is kept",
+			query:  "name:a OR NOT status:b",
+			want:   "name = $1 OR NOT status = $2",
+			legacy: "name = $1 OR NOT status = $2",
+		},
+		{
+			name:   "NOT binds tighter than AND",
+			query:  "NOT status:b AND region:c",
+			want:   "NOT status = $1 AND region = $2",
+			legacy: "NOT status = $1 AND region = $2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			s := schema.NewSchema("products", fields, schema.SchemaOptions{})
+			output, err := NewPostgresTranslator().Translate(ast, s)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, output.WhereClause)
+
+			legacy := schema.NewSchema("products", fields, schema.SchemaOptions{LegacyNegation: true})
+			output, err = NewPostgresTranslator().Translate(ast, legacy)
+			require.NoError(t, err)
+			assert.Equal(t, tt.legacy, output.WhereClause)
+		})
+	}
+}
+
+func TestBindNegations_Idempotent(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{"name": {Type: schema.TypeText}}, schema.SchemaOptions{})
+	ast, err := parser.NewParser("name:a name:b -name:c NOT name:d").Parse()
+	require.NoError(t, err)
+
+	once := BindNegations(ast, s)
+	assert.Equal(t, parser.Canonical(once), parser.Canonical(BindNegations(once, s)))
+	assert.Equal(t, "(name:a OR name:b) AND -name:c AND NOT name:d", parser.Canonical(once))
+}
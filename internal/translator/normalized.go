@@ -0,0 +1,67 @@
+package translator
+
+import (
+	"time"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// NormalizedQuery renders the query a translation executes in canonical query syntax,
+// for clients to show what was searched for: the AST is prepared as for translation at
+// now, so value aliases, synonyms, units and date keywords appear as they were bound,
+// and field names are written as the schema fields they resolve to. Values stay plain,
+// even for fields whose values are encoded when bound.
+func NormalizedQuery(ast parser.Node, s *schema.Schema, now time.Time) (string, error) {
+	prepared, _, err := Prepare(ast, s, now)
+	if err != nil {
+		return "", err
+	}
+	return parser.Canonical(resolveFieldNames(prepared, s)), nil
+}
+
+// resolveFieldNames returns a copy of the AST with field names replaced by the names of
+// the schema fields they resolve to. Names that resolve to no field, such as fragment
+// pseudo-fields, are kept as written.
+func resolveFieldNames(ast parser.Node, s *schema.Schema) parser.Node {
+	return renameFields(ast, func(name string) string {
+		if field := s.TraceResolution(name).Field; field != "" {
+			return field
+		}
+		return name
+	})
+}
+
+// renameFields returns a copy of the AST in which the field of every query on a field is
+// replaced by the result of rename
+func renameFields(ast parser.Node, rename func(name string) string) parser.Node {
+	return parser.Transform(ast, func(node parser.Node) parser.Node {
+		switch n := node.(type) {
+		case *parser.FieldQuery:
+			return &parser.FieldQuery{Field: rename(n.Field), Value: n.Value, Pos: n.Pos}
+		case *parser.FieldGroupQuery:
+			return &parser.FieldGroupQuery{Field: rename(n.Field), Queries: n.Queries, Pos: n.Pos}
+		case *parser.InListQuery:
+			renamed := *n
+			renamed.Field = rename(n.Field)
+			return &renamed
+		case *parser.RangeQuery:
+			renamed := *n
+			renamed.Field = rename(n.Field)
+			return &renamed
+		case *parser.FuzzyQuery:
+			renamed := *n
+			renamed.Field = rename(n.Field)
+			return &renamed
+		case *parser.ProximityQuery:
+			renamed := *n
+			renamed.Field = rename(n.Field)
+			return &renamed
+		case *parser.ExistsQuery:
+			renamed := *n
+			renamed.Field = rename(n.Field)
+			return &renamed
+		}
+		return node
+	})
+}
@@ -0,0 +1,40 @@
+package translator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizedQuery(t *testing.T) {
+	s := schema.NewSchema("stores", map[string]schema.Field{
+		"region":   {Type: schema.TypeText, Aliases: []string{"state"}, ValueAliases: map[string]string{"california": "ca"}},
+		"priority": {Type: schema.TypeInteger},
+		"name":     {Type: schema.TypeText},
+	}, schema.SchemaOptions{DefaultField: "name"})
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "state:California", want: "region:ca"},
+		{query: "Region:ca && priority:[1 TO 3]", want: "priority:[1 TO 3] AND region:ca"},
+		{query: "(state:california OR region:ca) && name:shop", want: "name:shop AND region:ca"},
+		{query: "state:(california, ny)", want: "region:in(ca, ny)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			normalized, err := NormalizedQuery(ast, s, time.Now())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, normalized)
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package translator
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// numberLiteralRegex matches the numeric literals accepted for numeric fields: an
+// optional sign, digits that may be grouped with underscores (1_000_000), an optional
+// fraction and an exponent of at most three digits, so 1e999999999 cannot allocate a
+// huge number
+var numberLiteralRegex = regexp.MustCompile(`^[+-]?[0-9](?:_?[0-9])*(?:\.[0-9](?:_?[0-9])*)?(?:[eE][+-]?[0-9]{1,3})?$`)
+
+// NormalizeNumbers returns a copy of the AST in which numeric literals compared against
+// integer and float fields are parsed, so 1e6, 1_000_000 and 0001000000 all bind the
+// same parameter: an int64 for integer fields and a float64 for float fields. Values
+// that are not numeric literals, such as wildcards, are left as is. Float literals a
+// float64 cannot hold exactly keep the value as written in Inexact, see Coercions.
+func NormalizeNumbers(ast parser.Node, s *schema.Schema) (parser.Node, error) {
+	return parser.MapValues(ast, func(fieldName string, value parser.ValueNode) (parser.ValueNode, error) {
+		var literal string
+		var pos parser.Position
+		switch v := value.(type) {
+		case *parser.TermValue:
+			literal, pos = v.Term, v.Pos
+		case *parser.NumberValue:
+			if v.Parsed != nil { // already normalized
+				return value, nil
+			}
+			literal, pos = v.Number, v.Pos
+		default:
+			return value, nil
+		}
+
+		_, field, err := s.ResolveField(fieldName)
+		if err != nil || (field.Type != schema.TypeInteger && field.Type != schema.TypeFloat) {
+			return value, nil
+		}
+		if !numberLiteralRegex.MatchString(literal) {
+			return value, nil
+		}
+
+		number, ok := new(big.Rat).SetString(strings.ReplaceAll(literal, "_", ""))
+		if !ok {
+			return value, nil
+		}
+
+		if field.Type == schema.TypeInteger {
+			if !number.IsInt() {
+				return nil, fmt.Errorf("value %s is not a whole number for integer field %s", literal, fieldName)
+			}
+			if !number.Num().IsInt64() {
+				return nil, fmt.Errorf("value %s is out of range for integer field %s", literal, fieldName)
+			}
+			integer := number.Num().Int64()
+			return &parser.NumberValue{Number: strconv.FormatInt(integer, 10), Parsed: integer, Pos: pos}, nil
+		}
+
+		float, _ := number.Float64()
+		if math.IsInf(float, 0) {
+			return nil, fmt.Errorf("value %s is out of range for float field %s", literal, fieldName)
+		}
+		normalized := &parser.NumberValue{Number: strconv.FormatFloat(float, 'f', -1, 64), Parsed: float, Pos: pos}
+		// 0.1 is bound as written even though a float64 only approximates it, since it is
+		// the shortest decimal of that float; more digits than that cannot be bound
+		if shortest, _ := new(big.Rat).SetString(strconv.FormatFloat(float, 'g', -1, 64)); shortest.Cmp(number) != 0 {
+			normalized.Inexact = literal
+		}
+		return normalized, nil
+	})
+}
+
+// Coercions lists the values of a prepared AST bound differently than written: float
+// literals rounded to the precision of a float64, or to 0 when they are too small for one
+func Coercions(ast parser.Node) []Coercion {
+	coercions, _ := coercionsOf(ast)
+	return coercions
+}
+
+// coercionsOf returns the Coercions of a prepared AST, and a warning for each
+func coercionsOf(ast parser.Node) ([]Coercion, []Warning) {
+	var coercions []Coercion
+	var warnings []Warning
+	parser.MapValues(ast, func(fieldName string, value parser.ValueNode) (parser.ValueNode, error) {
+		number, ok := value.(*parser.NumberValue)
+		if !ok || number.Inexact == "" {
+			return value, nil
+		}
+		reason := "has more digits than a 64-bit float holds"
+		if number.Parsed == 0.0 {
+			reason = "is too small for a 64-bit float"
+		}
+		coercions = append(coercions, Coercion{Field: fieldName, Value: number.Inexact, Bound: number.Parsed, Reason: reason})
+		// sqllint:trusted a warning for the response, not SQL
+		warnings = append(warnings, newWarning(rsearch.WarningCoerced, number.Pos, fmt.Sprintf("bound value %s of field %s as %s, since it %s",
+			number.Inexact, fieldName, number.Number, reason)))
+		return value, nil
+	})
+	return coercions, warnings
+}
+
+// localNumberRegex matches values that may be written with the separators of a schema's
+// NumberFormat: an optional sign, digits separated by at most one of ".", ",", " " or "'"
+// at a time, and an optional unit suffix
+var localNumberRegex = regexp.MustCompile(`^([+-]?)([0-9](?:[.,' ]?[0-9])*)(\s*[a-zA-Zµ]+)?$`)
+
+// LocalizeNumbers returns a copy of the AST in which values of integer and float fields
+// written with the schema's NumberFormat are rewritten as numeric literals, so with a
+// "," decimal and "." grouping, price:"1.234,56" becomes price:1234.56 before units are
+// converted and numbers normalized. Values whose separators do not fit the format, or
+// that could be read either way, are rejected. Without a NumberFormat, the AST is
+// returned as is.
+func LocalizeNumbers(ast parser.Node, s *schema.Schema) (parser.Node, error) {
+	format := s.Options.NumberFormat
+	if format == (schema.NumberFormat{}) {
+		return ast, nil
+	}
+	return parser.MapValues(ast, func(fieldName string, value parser.ValueNode) (parser.ValueNode, error) {
+		var literal string
+		var pos parser.Position
+		switch v := value.(type) {
+		case *parser.TermValue:
+			literal, pos = v.Term, v.Pos
+		case *parser.PhraseValue:
+			literal, pos = v.Phrase, v.Pos
+		case *parser.NumberValue:
+			if v.Parsed != nil { // already normalized
+				return value, nil
+			}
+			literal, pos = v.Number, v.Pos
+		default:
+			return value, nil
+		}
+
+		_, field, err := s.ResolveField(fieldName)
+		if err != nil || (field.Type != schema.TypeInteger && field.Type != schema.TypeFloat) {
+			return value, nil
+		}
+		match := localNumberRegex.FindStringSubmatch(strings.TrimSpace(literal))
+		if match == nil || !strings.ContainsAny(match[2], ".,' ") {
+			return value, nil
+		}
+		unit := strings.TrimSpace(match[3])
+		if _, _, ok := baseUnit(field.Unit); unit != "" && !ok {
+			return value, nil
+		}
+
+		number, err := localizeNumber(match[2], format)
+		if err != nil {
+			return nil, fmt.Errorf("value %s for field %s %v", literal, fieldName, err)
+		}
+		if unit != "" {
+			return &parser.TermValue{Term: match[1] + number + unit, Pos: pos}, nil
+		}
+		return &parser.NumberValue{Number: match[1] + number, Pos: pos}, nil
+	})
+}
+
+// localizeNumber rewrites unsigned digits written with the format's separators as a
+// numeric literal, or returns why they do not fit the format
+func localizeNumber(digits string, format schema.NumberFormat) (string, error) {
+	decimal, grouping := format.Decimal, format.Grouping
+	if decimal == "" {
+		decimal = "."
+	}
+	if decimal == schema.NumberDecimalAuto {
+		var err error
+		if decimal, grouping, err = detectSeparators(digits, grouping); err != nil {
+			return "", err
+		}
+	}
+
+	integer, fraction, hasFraction := strings.Cut(digits, decimal)
+	if strings.Contains(fraction, decimal) {
+		return "", fmt.Errorf("has more than one decimal separator %q", decimal)
+	}
+	if i := strings.IndexAny(fraction, ".,' "); i >= 0 {
+		return "", fmt.Errorf("has a misplaced separator %q after the decimal separator", fraction[i:i+1])
+	}
+	if grouping != "" && strings.Contains(integer, grouping) {
+		groups := strings.Split(integer, grouping)
+		for i, group := range groups {
+			if len(group) != 3 && (i > 0 || len(group) > 3) {
+				return "", fmt.Errorf("has a misplaced grouping separator %q", grouping)
+			}
+		}
+		integer = strings.Join(groups, "")
+	}
+	if i := strings.IndexAny(integer, ".,' "); i >= 0 {
+		return "", fmt.Errorf("uses %q, which is not a separator of numbers on this schema", integer[i:i+1])
+	}
+
+	if hasFraction {
+		return integer + "." + fraction, nil
+	}
+	return integer, nil
+}
+
+// detectSeparators tells the decimal and grouping separators of digits written with an
+// "auto" decimal: the configured grouping when the digits use it, else the last of "."
+// and "," when both appear, the other grouping, and a separator appearing more than once
+// groups digits. A single separator followed by exactly three digits, as in 1,000, could
+// be either and is ambiguous.
+func detectSeparators(digits, grouping string) (string, string, error) {
+	dots, commas := strings.Count(digits, "."), strings.Count(digits, ",")
+	switch {
+	case grouping != "" && strings.Contains(digits, grouping), dots+commas == 0:
+		if commas > 0 {
+			return ",", grouping, nil
+		}
+		return ".", grouping, nil
+	case dots > 0 && commas > 0:
+		last := digits[strings.LastIndexAny(digits, ".,")]
+		if last == ',' {
+			return ",", ".", nil
+		}
+		return ".", ",", nil
+	}
+
+	separator, other := ".", ","
+	if commas > 0 {
+		separator, other = ",", "."
+	}
+	if dots+commas > 1 {
+		return other, separator, nil
+	}
+	integer, fraction, _ := strings.Cut(digits, separator)
+	if len(fraction) == 3 && len(integer) <= 3 && integer[0] != '0' {
+		return "", "", fmt.Errorf("is ambiguous: %q may separate decimals or thousands", separator)
+	}
+	return separator, other, nil
+}
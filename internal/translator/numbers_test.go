@@ -0,0 +1,212 @@
+package translator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeNumbers(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"stock": {Type: schema.TypeInteger},
+		"price": {Type: schema.TypeFloat},
+		"sku":   {Type: schema.TypeText},
+	}, schema.SchemaOptions{})
+
+	tests := []struct {
+		query   string
+		want    []interface{}
+		wantErr string
+	}{
+		{query: "stock:1e6", want: []interface{}{int64(1000000)}},
+		{query: "stock:1_000_000", want: []interface{}{int64(1000000)}},
+		{query: "stock:0042", want: []interface{}{int64(42)}},
+		{query: "stock:1.5e3", want: []interface{}{int64(1500)}},
+		{query: "stock:[1e3 TO 2_000]", want: []interface{}{int64(1000), int64(2000)}},
+		{query: "stock:(1e2,0100)", want: []interface{}{int64(100), int64(100)}},
+		{query: "price:2.50", want: []interface{}{2.5}},
+		{query: "price:1e-3", want: []interface{}{0.001}},
+		{query: "price:>1_000.25", want: []interface{}{1000.25}},
+		{query: "stock:[-10 TO 40]", want: []interface{}{int64(-10), int64(40)}},
+		{query: "stock:-1_000", want: []interface{}{int64(-1000)}},
+		{query: "price:<-2.5e1", want: []interface{}{-25.0}},
+		{query: "sku:0042", want: []interface{}{"0042"}},
+		{query: "stock:1__000", want: []interface{}{"1__000"}},
+		{query: "stock:1.5", wantErr: "value 1.5 is not a whole number for integer field stock"},
+		{query: "stock:1e19", wantErr: "value 1e19 is out of range for integer field stock"},
+		{query: "price:1e999", wantErr: "value 1e999 is out of range for float field price"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			output, err := NewPostgresTranslator().Translate(ast, s)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, output.Parameters)
+		})
+	}
+}
+
+func TestNormalizeNumbers_FieldGroupTerms(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"stock": {Type: schema.TypeInteger},
+	}, schema.SchemaOptions{})
+
+	ast, err := parser.NewParser("stock:(1e3 OR 0050)").Parse()
+	require.NoError(t, err)
+
+	normalized, err := NormalizeNumbers(ast, s)
+	require.NoError(t, err)
+	assert.Equal(t, "stock:(1000 OR 50)", parser.Canonical(normalized))
+}
+
+func TestLocalizeNumbers(t *testing.T) {
+	fields := map[string]schema.Field{
+		"stock": {Type: schema.TypeInteger},
+		"price": {Type: schema.TypeFloat},
+		"size":  {Type: schema.TypeInteger, Unit: "b"},
+		"sku":   {Type: schema.TypeText},
+	}
+
+	tests := []struct {
+		name    string
+		format  schema.NumberFormat
+		query   string
+		want    []interface{}
+		wantErr string
+	}{
+		{name: "decimal comma", format: schema.NumberFormat{Decimal: ",", Grouping: "."}, query: `price:"1.234,56"`, want: []interface{}{1234.56}},
+		{name: "grouped integer", format: schema.NumberFormat{Decimal: ",", Grouping: "."}, query: `stock:"1.234.567"`, want: []interface{}{int64(1234567)}},
+		{name: "negative", format: schema.NumberFormat{Decimal: ",", Grouping: "."}, query: `price:"-0,5"`, want: []interface{}{-0.5}},
+		{name: "range", format: schema.NumberFormat{Decimal: ",", Grouping: "."}, query: `price:["1.000,5" TO "2.000"]`, want: []interface{}{1000.5, 2000.0}},
+		{name: "in-list", format: schema.NumberFormat{Decimal: ","}, query: `price:("1,5","2,25")`, want: []interface{}{1.5, 2.25}},
+		{name: "unit", format: schema.NumberFormat{Decimal: ","}, query: `size:"1,5kb"`, want: []interface{}{int64(1500)}},
+		{name: "grouping comma", format: schema.NumberFormat{Grouping: ","}, query: `stock:"1,000"`, want: []interface{}{int64(1000)}},
+		{name: "apostrophe", format: schema.NumberFormat{Grouping: "'"}, query: `price:"1'234.5"`, want: []interface{}{1234.5}},
+		{name: "space", format: schema.NumberFormat{Decimal: ",", Grouping: " "}, query: `price:"12 345,5"`, want: []interface{}{12345.5}},
+		{name: "plain literals", format: schema.NumberFormat{Decimal: ",", Grouping: "."}, query: "stock:1e3 AND price:42", want: []interface{}{int64(1000), 42.0}},
+		{name: "text fields", format: schema.NumberFormat{Decimal: ",", Grouping: "."}, query: `sku:"1,5"`, want: []interface{}{"1,5"}},
+		{name: "auto both", format: schema.NumberFormat{Decimal: schema.NumberDecimalAuto}, query: `price:"1,234.5" OR price:"1.234,5"`, want: []interface{}{1234.5, 1234.5}},
+		{name: "auto repeated", format: schema.NumberFormat{Decimal: schema.NumberDecimalAuto}, query: `stock:"1.234.567"`, want: []interface{}{int64(1234567)}},
+		{name: "auto decimal", format: schema.NumberFormat{Decimal: schema.NumberDecimalAuto}, query: `price:"2,5" OR price:"0,500" OR price:"1234,567"`, want: []interface{}{2.5, 0.5, 1234.567}},
+		{name: "auto grouping", format: schema.NumberFormat{Decimal: schema.NumberDecimalAuto, Grouping: " "}, query: `price:"1 000,5"`, want: []interface{}{1000.5}},
+		{name: "auto ambiguous", format: schema.NumberFormat{Decimal: schema.NumberDecimalAuto}, query: `price:"1,000"`, wantErr: `value 1,000 for field price is ambiguous: "," may separate decimals or thousands`},
+		{name: "misplaced grouping", format: schema.NumberFormat{Decimal: ",", Grouping: "."}, query: "price:2.50", wantErr: `value 2.50 for field price has a misplaced grouping separator "."`},
+		{name: "two decimals", format: schema.NumberFormat{Decimal: ","}, query: `price:"1,2,3"`, wantErr: `value 1,2,3 for field price has more than one decimal separator ","`},
+		{name: "grouped fraction", format: schema.NumberFormat{Decimal: ",", Grouping: "."}, query: `price:"1,234.5"`, wantErr: `value 1,234.5 for field price has a misplaced separator "." after the decimal separator`},
+		{name: "foreign separator", format: schema.NumberFormat{Decimal: ","}, query: "price:1.5", wantErr: `value 1.5 for field price uses ".", which is not a separator of numbers on this schema`},
+		{name: "whole number", format: schema.NumberFormat{Decimal: ","}, query: `stock:"1,5"`, wantErr: "value 1.5 is not a whole number for integer field stock"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := schema.NewSchema("products", fields, schema.SchemaOptions{NumberFormat: tt.format})
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			output, err := NewPostgresTranslator().Translate(ast, s)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, output.Parameters)
+		})
+	}
+}
+
+func TestLocalizeNumbers_Unquoted(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"price": {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{NumberFormat: schema.NumberFormat{Decimal: ",", Grouping: "."}})
+
+	// Unquoted, the query would end at the second separator and bind 1234
+	for _, query := range []string{"price:1.234,56", "price:1,5 AND price:2", "price:1.234.567", "price:1'234"} {
+		_, err := parser.NewParser(query).Parse()
+		assert.ErrorContains(t, err, "after a number: quote numbers written with separators", query)
+	}
+
+	// A single grouping separator reads as a number
+	ast, err := parser.NewParser("price:1.234").Parse()
+	require.NoError(t, err)
+	output, err := NewPostgresTranslator().Translate(ast, s)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{1234.0}, output.Parameters)
+}
+
+func TestLocalizeNumbers_Idempotent(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"price": {Type: schema.TypeFloat},
+		"size":  {Type: schema.TypeInteger, Unit: "b"},
+	}, schema.SchemaOptions{NumberFormat: schema.NumberFormat{Decimal: ",", Grouping: "."}})
+
+	ast, err := parser.NewParser(`price:"1.234,5" AND size:"1,5kb"`).Parse()
+	require.NoError(t, err)
+
+	prepared, _, err := Prepare(ast, s, time.Now())
+	require.NoError(t, err)
+	again, _, err := Prepare(prepared, s, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, prepared, again)
+}
+
+func TestCoercions(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"stock": {Type: schema.TypeInteger},
+		"price": {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{})
+
+	tests := []struct {
+		query string
+		want  []Coercion
+	}{
+		{query: "price:0.1 AND price:2.5e3 AND stock:1.0"},
+		{query: "price:12345678901234567891", want: []Coercion{
+			{Field: "price", Value: "12345678901234567891", Bound: 1.2345678901234567e19, Reason: "has more digits than a 64-bit float holds"},
+		}},
+		{query: "price:[1e-400 TO 0.1000000000000000055511151231257827]", want: []Coercion{
+			{Field: "price", Value: "1e-400", Bound: 0.0, Reason: "is too small for a 64-bit float"},
+			{Field: "price", Value: "0.1000000000000000055511151231257827", Bound: 0.1, Reason: "has more digits than a 64-bit float holds"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			for _, translator := range []Translator{NewPostgresTranslator(), NewMySQLTranslator(), NewSQLiteTranslator(), NewMongoDBTranslator()} {
+				output, err := translator.Translate(ast, s)
+				require.NoError(t, err)
+				coercions, _ := output.Metadata["coercions"].([]Coercion)
+				assert.Equal(t, tt.want, coercions, translator.DatabaseType())
+			}
+		})
+	}
+}
+
+func TestCheckStrict_Coercions(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"price": {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{})
+
+	ast, err := parser.NewParser("price:1e-400").Parse()
+	require.NoError(t, err)
+	output, err := NewPostgresTranslator().Translate(ast, s)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{0.0}, output.Parameters)
+
+	var coercionErr *CoercionError
+	require.ErrorAs(t, CheckStrict(output), &coercionErr)
+	assert.EqualError(t, coercionErr, "cannot bind price:1e-400 exactly")
+}
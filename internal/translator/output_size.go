@@ -0,0 +1,119 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// maxConstructLength bounds the length of the construct quoted in an OutputSizeError
+const maxConstructLength = 100
+
+// OutputSizeError is returned for a translation larger than the configured limit, which
+// databases may reject with errors that do not point back at the query. Construct is
+// the clause most of the translation comes from, such as a long in-list or a field
+// group expanded by synonyms, or the whole query when no clause stands out.
+type OutputSizeError struct {
+	Size          int // bytes of the translation
+	Limit         int
+	Construct     string // the clause in query syntax, shortened when long
+	ConstructSize int    // bytes of the clause's own translation
+	Pos           parser.Position
+}
+
+func (e *OutputSizeError) Error() string {
+	return fmt.Sprintf("translation of %d bytes exceeds the limit of %d bytes; %s translates to %d bytes",
+		e.Size, e.Limit, e.Construct, e.ConstructSize)
+}
+
+// OutputSize returns the size of a translation in bytes: the length of its WHERE clause,
+// or of its filter encoded as JSON
+func OutputSize(output *TranslatorOutput) int {
+	if output.Type == "sql" {
+		return len(output.WhereClause)
+	}
+	encoded, err := json.Marshal(output.Filter)
+	if err != nil {
+		return 0
+	}
+	return len(encoded)
+}
+
+// CheckOutputSize returns an *OutputSizeError when the output of a translation is larger
+// than limit bytes, naming the clause of the query it mostly comes from. Starting from
+// the whole query, each clause is translated on its own, and the search descends into
+// the largest one for as long as it makes up more than half of its parent's size. A zero
+// limit is unlimited.
+func CheckOutputSize(trans Translator, ast parser.Node, s *schema.Schema, output *TranslatorOutput, limit int) error {
+	size := OutputSize(output)
+	if limit <= 0 || size <= limit {
+		return nil
+	}
+
+	construct, constructSize := ast, size
+	for {
+		var largest parser.Node
+		largestSize := 0
+		for _, clause := range clauses(construct) {
+			clauseOutput, err := trans.Translate(clause, s)
+			if err != nil {
+				continue
+			}
+			if clauseSize := OutputSize(clauseOutput); clauseSize > largestSize {
+				largest, largestSize = clause, clauseSize
+			}
+		}
+		if largest == nil || largestSize*2 <= constructSize {
+			break
+		}
+		construct, constructSize = largest, largestSize
+	}
+
+	rendered := parser.Canonical(construct)
+	if runes := []rune(rendered); len(runes) > maxConstructLength {
+		rendered = string(runes[:maxConstructLength]) + "..."
+	}
+	return &OutputSizeError{Size: size, Limit: limit, Construct: rendered, ConstructSize: constructSize, Pos: startPosition(construct)}
+}
+
+// startPosition returns the position a clause starts at. Boolean operators are
+// positioned at the operator, after their left operand.
+func startPosition(node parser.Node) parser.Position {
+	for {
+		op, ok := node.(*parser.BinaryOp)
+		if !ok || op.Left == nil {
+			return node.Position()
+		}
+		node = op.Left
+	}
+}
+
+// clauses returns the clauses a node is made of: the operands of a chain of the same
+// boolean operator, or the clause an operator, boost or group applies to
+func clauses(node parser.Node) []parser.Node {
+	switch n := node.(type) {
+	case *parser.BinaryOp:
+		var operands []parser.Node
+		for _, operand := range []parser.Node{n.Left, n.Right} {
+			if op, ok := operand.(*parser.BinaryOp); ok && op.Op == n.Op {
+				operands = append(operands, clauses(op)...)
+			} else {
+				operands = append(operands, operand)
+			}
+		}
+		return operands
+	case *parser.UnaryOp:
+		return []parser.Node{n.Operand}
+	case *parser.RequiredQuery:
+		return []parser.Node{n.Query}
+	case *parser.ProhibitedQuery:
+		return []parser.Node{n.Query}
+	case *parser.BoostQuery:
+		return []parser.Node{n.Query}
+	case *parser.GroupQuery:
+		return []parser.Node{n.Query}
+	}
+	return nil
+}
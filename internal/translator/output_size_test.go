@@ -0,0 +1,54 @@
+package translator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckOutputSize(t *testing.T) {
+	s := schema.NewSchema("orders", map[string]schema.Field{
+		"status": {Type: schema.TypeText},
+		"region": {Type: schema.TypeText},
+	}, schema.SchemaOptions{DefaultField: "status"})
+
+	regions := make([]string, 200)
+	for i := range regions {
+		regions[i] = fmt.Sprintf("r%d", i)
+	}
+	list := "region:(" + strings.Join(regions, ", ") + ")"
+
+	check := func(trans Translator, query string, limit int) error {
+		ast, err := parser.NewParser(query).Parse()
+		require.NoError(t, err)
+		output, err := trans.Translate(ast, s)
+		require.NoError(t, err)
+		return CheckOutputSize(trans, ast, s, output, limit)
+	}
+
+	for _, trans := range []Translator{NewPostgresTranslator(), NewMongoDBTranslator()} {
+		t.Run(trans.DatabaseType(), func(t *testing.T) {
+			assert.NoError(t, check(trans, "status:active AND "+list, 0), "zero is unlimited")
+			assert.NoError(t, check(trans, "status:active AND "+list, 1<<20))
+
+			var sizeErr *OutputSizeError
+			require.True(t, errors.As(check(trans, "status:active AND (region:ca OR NOT "+list+")", 500), &sizeErr))
+			assert.Equal(t, 500, sizeErr.Limit)
+			assert.Greater(t, sizeErr.Size, 500)
+			assert.True(t, strings.HasPrefix(sizeErr.Construct, "region:in(r"), sizeErr.Construct)
+			assert.True(t, strings.HasSuffix(sizeErr.Construct, "..."), "long constructs are shortened")
+			assert.Equal(t, 36, sizeErr.Pos.Offset)
+
+			// No clause stands out among many small ones
+			require.True(t, errors.As(check(trans, strings.Repeat("status:a OR ", 100)+"status:b", 500), &sizeErr))
+			assert.Equal(t, 0, sizeErr.Pos.Offset)
+			assert.Equal(t, sizeErr.Size, sizeErr.ConstructSize)
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package translator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/ir"
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// PartialIndexUsage is a partial index of a field a query reads, and whether it can
+// serve the query
+type PartialIndexUsage = rsearch.PartialIndexUsage
+
+// ValidatePartialIndexes checks that the predicate of every partial index of the schema
+// is a valid query on its fields
+func ValidatePartialIndexes(s *schema.Schema) error {
+	for fieldName, field := range s.Fields {
+		for _, index := range field.PartialIndexes {
+			ast, err := parser.NewParser(index.Where).Parse()
+			if err != nil {
+				return fmt.Errorf("partial index %q of field %q is not a valid query: %w", index.Name, fieldName, err)
+			}
+			if _, err := ir.Lower(ast, s); err != nil {
+				return fmt.Errorf("partial index %q of field %q: %w", index.Name, fieldName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// PartialIndexes reports, for every partial index of the fields a prepared query reads,
+// whether the query implies the index predicate, which databases require before serving
+// a query from a partial index. The predicate is implied when each of its AND-ed
+// conditions is also a condition the query requires, such as a default filter, once
+// both are resolved against the schema. Conditions implied only by other conditions,
+// such as price > 0 by price > 10, are not recognized, as not every planner does.
+func PartialIndexes(ast parser.Node, s *schema.Schema, fields []ReferencedField) []PartialIndexUsage {
+	var usages []PartialIndexUsage
+	var required []ir.Node
+	lowered := false
+
+	for _, referenced := range fields {
+		field, ok := s.Fields[referenced.Field]
+		if !ok || len(field.PartialIndexes) == 0 {
+			continue
+		}
+		if !lowered {
+			lowered = true
+			if query, err := ir.Lower(ast, s); err == nil {
+				required = ir.Conjuncts(query)
+			}
+		}
+		for _, index := range field.PartialIndexes {
+			usage := PartialIndexUsage{Field: referenced.Field, Index: index.Name}
+			usage.Missing = missingConditions(index.Where, s, required)
+			usage.Usable = len(usage.Missing) == 0
+			usages = append(usages, usage)
+		}
+	}
+	return usages
+}
+
+// missingConditions returns the AND-ed conditions of a predicate that are not among the
+// required clauses, in query syntax. A predicate that does not translate is missing
+// as a whole.
+func missingConditions(where string, s *schema.Schema, required []ir.Node) []string {
+	ast, err := parser.NewParser(where).Parse()
+	if err == nil {
+		ast, _, err = Prepare(ast, s, time.Now())
+	}
+	if err != nil {
+		return []string{where}
+	}
+
+	var missing []string
+	for _, condition := range andClauses(ast) {
+		node, err := ir.Lower(condition, s)
+		if err != nil || !requires(required, ir.Conjuncts(node)) {
+			missing = append(missing, parser.Canonical(condition))
+		}
+	}
+	return missing
+}
+
+// requires reports whether every one of the conditions is among the required clauses
+func requires(required, conditions []ir.Node) bool {
+	for _, condition := range conditions {
+		found := false
+		for _, clause := range required {
+			if ir.Equivalent(clause, condition) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// andClauses splits a query at its explicit top-level ANDs, looking through groups
+func andClauses(node parser.Node) []parser.Node {
+	switch n := node.(type) {
+	case *parser.GroupQuery:
+		return andClauses(n.Query)
+	case *parser.RequiredQuery:
+		return andClauses(n.Query)
+	case *parser.BinaryOp:
+		if n.Op == "AND" {
+			return append(andClauses(n.Left), andClauses(n.Right)...)
+		}
+	}
+	return []parser.Node{node}
+}
+
+// addPartialIndexes records the partial indexes of the fields a query reads in the
+// output's "partialIndexes" metadata
+func addPartialIndexes(output *TranslatorOutput, usages []PartialIndexUsage) {
+	if len(usages) == 0 {
+		return
+	}
+	if output.Metadata == nil {
+		output.Metadata = make(map[string]interface{})
+	}
+	output.Metadata["partialIndexes"] = usages
+}
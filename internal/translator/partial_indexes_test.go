@@ -0,0 +1,115 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartialIndexes(t *testing.T) {
+	testSchema := schema.NewSchema("orders", map[string]schema.Field{
+		"status": {Type: schema.TypeText, PartialIndexes: []schema.PartialIndex{
+			{Name: "live_status", Where: "NOT _exists_:deletedAt"},
+			{Name: "open_eu", Where: "region:eu AND (closed:false)"},
+		}},
+		"region":    {Type: schema.TypeText},
+		"closed":    {Type: schema.TypeBoolean},
+		"total":     {Type: schema.TypeFloat},
+		"deletedAt": {Type: schema.TypeDateTime, Aliases: []string{"removed"}},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+
+	tests := []struct {
+		name  string
+		query string
+		want  []PartialIndexUsage
+	}{
+		{
+			name:  "predicate required",
+			query: "status:shipped AND NOT _exists_:removed",
+			want: []PartialIndexUsage{
+				{Field: "status", Index: "live_status", Usable: true},
+				{Field: "status", Index: "open_eu", Missing: []string{"region:eu", "closed:false"}},
+			},
+		},
+		{
+			name:  "every condition required",
+			query: "closed:false AND status:(shipped OR packed) AND region:eu",
+			want: []PartialIndexUsage{
+				{Field: "status", Index: "live_status", Missing: []string{"NOT _exists_:deletedAt"}},
+				{Field: "status", Index: "open_eu", Usable: true},
+			},
+		},
+		{
+			name:  "optional conditions do not count",
+			query: "status:shipped OR NOT _exists_:deletedAt",
+			want: []PartialIndexUsage{
+				{Field: "status", Index: "live_status", Missing: []string{"NOT _exists_:deletedAt"}},
+				{Field: "status", Index: "open_eu", Missing: []string{"region:eu", "closed:false"}},
+			},
+		},
+		{
+			name:  "fields without partial indexes",
+			query: "total:>10 AND NOT _exists_:deletedAt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			output, err := NewPostgresTranslator().Translate(ast, testSchema)
+			require.NoError(t, err)
+			usages, _ := output.Metadata["partialIndexes"].([]PartialIndexUsage)
+			assert.Equal(t, tt.want, usages)
+		})
+	}
+}
+
+func TestPartialIndexes_DefaultFilters(t *testing.T) {
+	s := schema.NewSchema("orders", map[string]schema.Field{
+		"status": {Type: schema.TypeText, PartialIndexes: []schema.PartialIndex{
+			{Name: "live_status", Where: "NOT _exists_:deletedAt"},
+			{Name: "open_eu", Where: "region:eu AND (closed:false)"},
+		}},
+		"region":    {Type: schema.TypeText},
+		"closed":    {Type: schema.TypeBoolean},
+		"total":     {Type: schema.TypeFloat},
+		"deletedAt": {Type: schema.TypeDateTime, Aliases: []string{"removed"}},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+
+	s.Options.DefaultFilters = []schema.DefaultFilter{{Name: "live", Query: "NOT _exists_:deletedAt"}}
+
+	ast, err := parser.NewParser("status:shipped").Parse()
+	require.NoError(t, err)
+	ast, _, err = ApplyDefaultFilters(ast, s, nil)
+	require.NoError(t, err)
+
+	output, err := NewMongoDBTranslator().Translate(ast, s)
+	require.NoError(t, err)
+	assert.Equal(t, PartialIndexUsage{Field: "status", Index: "live_status", Usable: true}, output.Metadata["partialIndexes"].([]PartialIndexUsage)[0])
+}
+
+func TestValidatePartialIndexes(t *testing.T) {
+	s := schema.NewSchema("orders", map[string]schema.Field{
+		"status": {Type: schema.TypeText, PartialIndexes: []schema.PartialIndex{
+			{Name: "live_status", Where: "NOT _exists_:deletedAt"},
+			{Name: "open_eu", Where: "region:eu AND (closed:false)"},
+		}},
+		"region":    {Type: schema.TypeText},
+		"closed":    {Type: schema.TypeBoolean},
+		"total":     {Type: schema.TypeFloat},
+		"deletedAt": {Type: schema.TypeDateTime, Aliases: []string{"removed"}},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+
+	assert.NoError(t, ValidatePartialIndexes(s))
+
+	s.Fields["region"] = schema.Field{Type: schema.TypeText, PartialIndexes: []schema.PartialIndex{{Name: "broken", Where: "status:(open"}}}
+	assert.ErrorContains(t, ValidatePartialIndexes(s), `partial index "broken" of field "region" is not a valid query`)
+
+	s.Fields["region"] = schema.Field{Type: schema.TypeText, PartialIndexes: []schema.PartialIndex{{Name: "unknown", Where: "archived:true"}}}
+	assert.ErrorContains(t, ValidatePartialIndexes(s), "field archived not found")
+}
@@ -0,0 +1,139 @@
+package translator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// Quoted phrases match according to the field's phrase match mode (schema.Field.PhraseMatch):
+// "exact" compares the whole value, "contains" matches the phrase anywhere in the value and
+// "fulltext" uses the dialect's full-text phrase operator.
+
+// escapeLike escapes LIKE wildcards so a phrase only ever matches literally.
+// Backslash is the default LIKE escape character in PostgreSQL and MySQL.
+func escapeLike(phrase string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(phrase)
+}
+
+// textSearchConfig returns the PostgreSQL text search configuration of a field's
+// full-text searches as a string literal. The validator only accepts identifiers as
+// languages, so the literal needs no escaping; a constant configuration, unlike a bound
+// one, lets the database use an expression index on to_tsvector of the column.
+func textSearchConfig(field *schema.Field) string {
+	language := field.Fulltext.Language
+	if language == "" {
+		language = schema.DefaultTextSearchConfig
+	}
+	return "'" + language + "'"
+}
+
+// textSearch builds a MongoDB $text search, in the field's full-text language when it
+// sets one. PostgreSQL's simple configuration, which does not stem, is MongoDB's none.
+func textSearch(search string, field *schema.Field) map[string]interface{} {
+	text := map[string]interface{}{"$search": search}
+	switch language := field.Fulltext.Language; language {
+	case "":
+	case "simple":
+		text["$language"] = "none"
+	default:
+		text["$language"] = language
+	}
+	return map[string]interface{}{"$text": text}
+}
+
+// ngramParser reports whether a field's MySQL FULLTEXT index uses the ngram parser
+func ngramParser(field *schema.Field) bool {
+	return field.Fulltext.Parser == schema.FulltextParserNgram
+}
+
+// phrase translates a quoted phrase on a resolved column.
+func (p *PostgresTranslator) phrase(columnName string, field *schema.Field, phrase string) string {
+	switch field.PhraseMatch {
+	case schema.PhraseContains:
+		p.paramCount++
+		p.params = append(p.params, "%"+escapeLike(phrase)+"%")
+		p.paramTypes = append(p.paramTypes, string(field.Type))
+		return p.compare(columnName, "LIKE", field)
+	case schema.PhraseFulltext:
+		p.paramCount++
+		p.params = append(p.params, phrase)
+		p.paramTypes = append(p.paramTypes, string(field.Type))
+		config := textSearchConfig(field)
+		// sqllint:trusted the configuration is a validated identifier
+		return fmt.Sprintf("to_tsvector(%s, %s) @@ phraseto_tsquery(%s, $%d)", config, columnName, config, p.paramCount)
+	default:
+		p.paramCount++
+		p.params = append(p.params, phrase)
+		p.paramTypes = append(p.paramTypes, string(field.Type))
+		return p.compare(columnName, "=", field)
+	}
+}
+
+// phrase translates a quoted phrase on a resolved column.
+// Full-text phrases use MATCH...AGAINST in boolean mode, which requires a FULLTEXT index.
+func (m *MySQLTranslator) phrase(columnName string, field *schema.Field, phrase string) string {
+	switch field.PhraseMatch {
+	case schema.PhraseContains:
+		m.params = append(m.params, "%"+escapeLike(phrase)+"%")
+		m.paramTypes = append(m.paramTypes, string(field.Type))
+		return m.compare(columnName, "LIKE", field)
+	case schema.PhraseFulltext:
+		m.params = append(m.params, `"`+strings.ReplaceAll(phrase, `"`, "")+`"`)
+		m.paramTypes = append(m.paramTypes, string(field.Type))
+		return fmt.Sprintf("MATCH(%s) AGAINST(? IN BOOLEAN MODE)", columnName)
+	default:
+		m.params = append(m.params, phrase)
+		m.paramTypes = append(m.paramTypes, string(field.Type))
+		return m.compare(columnName, "=", field)
+	}
+}
+
+// phrase translates a quoted phrase on a resolved column.
+// SQLite has no default LIKE escape character, so contains matches declare one.
+// Full-text phrases use MATCH on an FTS5 table.
+func (s *SQLiteTranslator) phrase(columnName string, field *schema.Field, phrase string) string {
+	switch field.PhraseMatch {
+	case schema.PhraseContains:
+		s.params = append(s.params, "%"+escapeLike(phrase)+"%")
+		s.paramTypes = append(s.paramTypes, string(field.Type))
+		return fmt.Sprintf(`%s LIKE ? ESCAPE '\'`, columnName)
+	case schema.PhraseFulltext:
+		s.params = append(s.params, `"`+strings.ReplaceAll(phrase, `"`, `""`)+`"`)
+		s.paramTypes = append(s.paramTypes, string(field.Type))
+		return fmt.Sprintf("%s MATCH ?", columnName)
+	default:
+		s.params = append(s.params, phrase)
+		s.paramTypes = append(s.paramTypes, string(field.Type))
+		return s.compare(columnName, "=", field)
+	}
+}
+
+// phrase translates a quoted phrase on a resolved key.
+// MongoDB's $text searches the collection's text index rather than a single key, so
+// full-text phrases are not scoped to the field.
+func (m *MongoDBTranslator) phrase(columnName string, field *schema.Field, phrase string) map[string]interface{} {
+	switch field.PhraseMatch {
+	case schema.PhraseContains:
+		regex := regexp.QuoteMeta(phrase)
+		if !field.Unaccent {
+			return map[string]interface{}{
+				columnName: map[string]interface{}{"$regex": regex},
+			}
+		}
+		return map[string]interface{}{
+			columnName: map[string]interface{}{
+				"$regex":   foldRegex(regex),
+				"$options": "i",
+			},
+		}
+	case schema.PhraseFulltext:
+		return textSearch(`"`+strings.ReplaceAll(phrase, `"`, "")+`"`, field)
+	default:
+		return map[string]interface{}{
+			columnName: m.equals(field, phrase),
+		}
+	}
+}
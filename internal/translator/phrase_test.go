@@ -0,0 +1,144 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func translatePhrase(t *testing.T, tr Translator, query string) *TranslatorOutput {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"title":       {Type: schema.TypeText},
+		"description": {Type: schema.TypeText, PhraseMatch: schema.PhraseContains},
+		"body":        {Type: schema.TypeText, PhraseMatch: schema.PhraseFulltext},
+		"notes":       {Type: schema.TypeText, PhraseMatch: schema.PhraseContains, Unaccent: true},
+		"summary":     {Type: schema.TypeText, PhraseMatch: schema.PhraseFulltext, Fulltext: schema.FulltextOptions{Language: "german"}},
+		"tags":        {Type: schema.TypeText, PhraseMatch: schema.PhraseFulltext, Fulltext: schema.FulltextOptions{Language: "simple"}},
+	}, schema.SchemaOptions{DefaultField: "description"})
+
+	t.Helper()
+
+	ast, err := parser.NewParser(query).Parse()
+	require.NoError(t, err)
+	output, err := tr.Translate(ast, testSchema)
+	require.NoError(t, err)
+	return output
+}
+
+func TestPhrase_SQL(t *testing.T) {
+	tests := []struct {
+		name     string
+		tr       Translator
+		query    string
+		where    string
+		expected interface{}
+	}{
+		{"postgres exact", NewPostgresTranslator(), `title:"blue widget"`, "title = $1", "blue widget"},
+		{"postgres contains", NewPostgresTranslator(), `description:"blue widget"`, "description LIKE $1", "%blue widget%"},
+		{"postgres contains escapes wildcards", NewPostgresTranslator(), `description:"100% cotton_blend"`, "description LIKE $1", `%100\% cotton\_blend%`},
+		{"postgres contains unaccent", NewPostgresTranslator(), `notes:"creme brulee"`, "unaccent(notes) LIKE unaccent($1)", "%creme brulee%"},
+		{"postgres fulltext", NewPostgresTranslator(), `body:"blue widget"`, "to_tsvector('english', body) @@ phraseto_tsquery('english', $1)", "blue widget"},
+		{"postgres fulltext language", NewPostgresTranslator(), `summary:"blaue Widgets"`, "to_tsvector('german', summary) @@ phraseto_tsquery('german', $1)", "blaue Widgets"},
+		{"postgres standalone uses default field", NewPostgresTranslator(), `"blue widget"`, "description LIKE $1", "%blue widget%"},
+		{"mysql contains", NewMySQLTranslator(), `description:"blue widget"`, "description LIKE ?", "%blue widget%"},
+		{"mysql fulltext", NewMySQLTranslator(), `body:"blue widget"`, "MATCH(body) AGAINST(? IN BOOLEAN MODE)", `"blue widget"`},
+		{"sqlite contains", NewSQLiteTranslator(), `description:"blue widget"`, `description LIKE ? ESCAPE '\'`, "%blue widget%"},
+		{"sqlite fulltext", NewSQLiteTranslator(), `body:"say \"hi\""`, "body MATCH ?", `"say ""hi"""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := translatePhrase(t, tt.tr, tt.query)
+			assert.Equal(t, tt.where, output.WhereClause)
+			assert.Equal(t, []interface{}{tt.expected}, output.Parameters)
+		})
+	}
+}
+
+func TestPhrase_MongoDB(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected map[string]interface{}
+	}{
+		{
+			name:     "exact",
+			query:    `title:"blue widget"`,
+			expected: map[string]interface{}{"title": "blue widget"},
+		},
+		{
+			name:  "contains quotes metacharacters",
+			query: `description:"a+b (c)"`,
+			expected: map[string]interface{}{
+				"description": map[string]interface{}{"$regex": `a\+b \(c\)`},
+			},
+		},
+		{
+			name:  "fulltext",
+			query: `body:"blue widget"`,
+			expected: map[string]interface{}{
+				"$text": map[string]interface{}{"$search": `"blue widget"`},
+			},
+		},
+		{
+			name:  "fulltext language",
+			query: `summary:"blaue Widgets"`,
+			expected: map[string]interface{}{
+				"$text": map[string]interface{}{"$search": `"blaue Widgets"`, "$language": "german"},
+			},
+		},
+		{
+			name:  "fulltext simple language",
+			query: `tags:"blue widget"`,
+			expected: map[string]interface{}{
+				"$text": map[string]interface{}{"$search": `"blue widget"`, "$language": "none"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := translatePhrase(t, NewMongoDBTranslator(), tt.query)
+			assert.Equal(t, tt.expected, output.Filter)
+		})
+	}
+}
+
+func TestFulltextOptions_Proximity(t *testing.T) {
+	s := schema.NewSchema("articles", map[string]schema.Field{
+		"body":  {Type: schema.TypeText},
+		"title": {Type: schema.TypeText, Fulltext: schema.FulltextOptions{Language: "german", Parser: schema.FulltextParserNgram}},
+	}, schema.SchemaOptions{EnabledFeatures: schema.EnabledFeatures{Proximity: true}})
+
+	translate := func(tr Translator, query string) *TranslatorOutput {
+		ast, err := parser.NewParser(query).Parse()
+		require.NoError(t, err)
+		output, err := tr.Translate(ast, s)
+		require.NoError(t, err)
+		return output
+	}
+
+	output := translate(NewPostgresTranslator(), `title:"schnelle Lieferung"~2`)
+	assert.Equal(t, "to_tsvector('german', title) @@ phraseto_tsquery('german', $1)", output.WhereClause)
+	output = translate(NewPostgresTranslator(), `body:"fast delivery"~2`)
+	assert.Equal(t, "to_tsvector('english', body) @@ phraseto_tsquery('english', $1)", output.WhereClause)
+
+	// The ngram parser searches the phrase rather than any of its n-grams
+	output = translate(NewMySQLTranslator(), `title:"東京 大阪"~2`)
+	assert.Equal(t, "MATCH(title) AGAINST(? IN BOOLEAN MODE)", output.WhereClause)
+	assert.Equal(t, []interface{}{`"東京 大阪"`}, output.Parameters)
+	assert.Equal(t, rsearch.SubstitutePhrase, output.Metadata["downgrades"].([]Downgrade)[0].Substitute)
+	output = translate(NewMySQLTranslator(), `title:"東京都"~1`)
+	assert.Equal(t, []interface{}{`"東京都"`}, output.Parameters)
+	output = translate(NewMySQLTranslator(), `body:"fast delivery"~2`)
+	assert.Equal(t, []interface{}{"fast delivery"}, output.Parameters)
+
+	output = translate(NewMongoDBTranslator(), `title:"schnelle Lieferung"~2`)
+	assert.Equal(t, map[string]interface{}{
+		"$text": map[string]interface{}{"$search": `"schnelle Lieferung"`, "$language": "german"},
+	}, output.Filter)
+}
@@ -0,0 +1,76 @@
+package translator
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PlaceholderStyle is how parameters are referenced in PostgreSQL output. Clauses are
+// always built with numbered $N placeholders and rewritten once the whole clause is
+// known, so every translation path gets the same renumbering.
+type PlaceholderStyle string
+
+const (
+	// PlaceholderDollar numbers parameters: $1, $2 (lib/pq, pgx)
+	PlaceholderDollar PlaceholderStyle = "dollar"
+	// PlaceholderQuestion uses positional ? placeholders, for drivers and ORMs that
+	// rebind them; parameters are listed in the order the placeholders appear
+	PlaceholderQuestion PlaceholderStyle = "question"
+	// PlaceholderNamed names parameters @p1, @p2 (pgx.NamedArgs); @pN binds Parameters[N-1]
+	PlaceholderNamed PlaceholderStyle = "named"
+)
+
+// rewritePlaceholders rewrites the $N placeholders of a clause into a style. Quoted
+// literals and identifiers are copied unchanged. For the question style, where a
+// placeholder cannot name its parameter, parameters and their types are reordered to
+// follow the placeholders, and repeated for placeholders that reference one twice.
+func rewritePlaceholders(clause string, params []interface{}, paramTypes []string, style PlaceholderStyle) (string, []interface{}, []string) {
+	if style == "" || style == PlaceholderDollar || !strings.Contains(clause, "$") {
+		return clause, params, paramTypes
+	}
+
+	var b strings.Builder
+	orderedParams := make([]interface{}, 0, len(params))
+	orderedTypes := make([]string, 0, len(paramTypes))
+	var quote byte
+
+	for i := 0; i < len(clause); i++ {
+		c := clause[i]
+		switch {
+		case quote != 0:
+			// Doubled quotes escape themselves and toggle back on the next byte
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '$':
+			end := i + 1
+			for end < len(clause) && clause[end] >= '0' && clause[end] <= '9' {
+				end++
+			}
+			n, err := strconv.Atoi(clause[i+1 : end])
+			if err != nil || n < 1 || n > len(params) {
+				break
+			}
+
+			if style == PlaceholderQuestion {
+				b.WriteByte('?')
+				orderedParams = append(orderedParams, params[n-1])
+				if n <= len(paramTypes) {
+					orderedTypes = append(orderedTypes, paramTypes[n-1])
+				}
+			} else {
+				b.WriteString("@p" + strconv.Itoa(n))
+			}
+			i = end - 1
+			continue
+		}
+		b.WriteByte(c)
+	}
+
+	if style == PlaceholderQuestion {
+		return b.String(), orderedParams, orderedTypes
+	}
+	return b.String(), params, paramTypes
+}
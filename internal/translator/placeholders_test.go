@@ -0,0 +1,172 @@
+package translator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresTranslator_PlaceholderStyles(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":  {Type: schema.TypeText},
+		"price": {Type: schema.TypeFloat},
+		"notes": {Type: schema.TypeText, Collation: "und-x-$1", Unaccent: true},
+		"data":  {Type: schema.TypeJSON},
+	}, schema.SchemaOptions{EnabledFeatures: schema.EnabledFeatures{Fuzzy: true}})
+
+	tests := []struct {
+		name   string
+		query  string
+		style  PlaceholderStyle
+		where  string
+		params []interface{}
+	}{
+		{
+			name:   "dollar is the default",
+			query:  "name:a AND price:[1 TO 2]",
+			where:  "name = $1 AND price BETWEEN $2 AND $3",
+			params: []interface{}{"a", 1.0, 2.0},
+		},
+		{
+			name:   "question range",
+			query:  "name:a AND price:[1 TO 2]",
+			style:  PlaceholderQuestion,
+			where:  "name = ? AND price BETWEEN ? AND ?",
+			params: []interface{}{"a", 1.0, 2.0},
+		},
+		{
+			name:   "named range",
+			query:  "name:a AND price:[1 TO 2]",
+			style:  PlaceholderNamed,
+			where:  "name = @p1 AND price BETWEEN @p2 AND @p3",
+			params: []interface{}{"a", 1.0, 2.0},
+		},
+		{
+			name:   "question fuzzy",
+			query:  "name:laptop~2 OR price:>5",
+			style:  PlaceholderQuestion,
+			where:  "levenshtein(name, ?) <= ? OR price > ?",
+			params: []interface{}{"laptop", 2, 5.0},
+		},
+		{
+			name:   "quoted collation and unaccent",
+			query:  "notes:cafe",
+			style:  PlaceholderNamed,
+			where:  `unaccent(notes) COLLATE "und-x-$1" = unaccent(@p1)`,
+			params: []interface{}{"cafe"},
+		},
+		{
+			name:   "json casts are kept",
+			query:  "_exists_:data AND name:a",
+			style:  PlaceholderNamed,
+			where:  "data IS NOT NULL AND data != 'null'::jsonb AND name = @p1",
+			params: []interface{}{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			trans := NewPostgresTranslator()
+			if tt.style != "" {
+				trans = trans.WithPlaceholderStyle(tt.style)
+			}
+			output, err := trans.Translate(ast, s)
+			require.NoError(t, err)
+			assert.Equal(t, tt.where, output.WhereClause)
+			assert.Equal(t, tt.params, output.Parameters)
+			assert.Len(t, output.ParameterTypes, len(tt.params))
+		})
+	}
+}
+
+func TestPostgresTranslator_PlaceholderStyles_ManyParameters(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{"name": {Type: schema.TypeText}}, schema.SchemaOptions{})
+
+	terms := make([]string, 12)
+	for i := range terms {
+		terms[i] = "name:t" + string(rune('a'+i))
+	}
+	ast, err := parser.NewParser(strings.Join(terms, " OR ")).Parse()
+	require.NoError(t, err)
+
+	output, err := NewPostgresTranslator().WithPlaceholderStyle(PlaceholderNamed).Translate(ast, s)
+	require.NoError(t, err)
+	assert.Contains(t, output.WhereClause, "(name = @p1 OR name = @p2)")
+	assert.Contains(t, output.WhereClause, "OR name = @p10)")
+	assert.True(t, strings.HasSuffix(output.WhereClause, "OR name = @p12"))
+	assert.NotContains(t, output.WhereClause, "$")
+	assert.Len(t, output.Parameters, 12)
+	assert.Equal(t, "ta", output.Parameters[0])
+	assert.Equal(t, "tl", output.Parameters[11])
+}
+
+func TestRewritePlaceholders(t *testing.T) {
+	params := []interface{}{"a", "b", "c"}
+	types := []string{"text", "integer", "float"}
+
+	tests := []struct {
+		name   string
+		clause string
+		style  PlaceholderStyle
+		where  string
+		params []interface{}
+		types  []string
+	}{
+		{
+			name:   "question reorders out of order references",
+			clause: "x = $2 AND y = $1 AND z = $3",
+			style:  PlaceholderQuestion,
+			where:  "x = ? AND y = ? AND z = ?",
+			params: []interface{}{"b", "a", "c"},
+			types:  []string{"integer", "text", "float"},
+		},
+		{
+			name:   "question repeats reused references",
+			clause: "x = $1 OR y = $1",
+			style:  PlaceholderQuestion,
+			where:  "x = ? OR y = ?",
+			params: []interface{}{"a", "a"},
+			types:  []string{"text", "text"},
+		},
+		{
+			name:   "named keeps parameter order",
+			clause: "x = $2 AND y = $1",
+			style:  PlaceholderNamed,
+			where:  "x = @p2 AND y = @p1",
+			params: params,
+			types:  types,
+		},
+		{
+			name:   "literals and unknown references are copied",
+			clause: `x = '$1' AND "$2" = $3 AND y = $9 AND z = $`,
+			style:  PlaceholderQuestion,
+			where:  `x = '$1' AND "$2" = ? AND y = $9 AND z = $`,
+			params: []interface{}{"c"},
+			types:  []string{"float"},
+		},
+		{
+			name:   "escaped quotes stay inside the literal",
+			clause: `x = 'it''s $1' AND y = $1`,
+			style:  PlaceholderNamed,
+			where:  `x = 'it''s $1' AND y = @p1`,
+			params: params,
+			types:  types,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, gotParams, gotTypes := rewritePlaceholders(tt.clause, params, types, tt.style)
+			assert.Equal(t, tt.where, where)
+			assert.Equal(t, tt.params, gotParams)
+			assert.Equal(t, tt.types, gotTypes)
+		})
+	}
+}
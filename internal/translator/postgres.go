@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/infiniv/rsearch/internal/ir"
 	"github.com/infiniv/rsearch/internal/parser"
 	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
 )
 
 // PostgresTranslator translates AST nodes to PostgreSQL queries.
@@ -14,11 +16,20 @@ type PostgresTranslator struct {
 	params     []interface{}
 	paramTypes []string
 	boosts     []map[string]interface{}
+	downgrades []downgrade
+
+	placeholderStyle PlaceholderStyle
 }
 
 // NewPostgresTranslator creates a new PostgreSQL translator.
 func NewPostgresTranslator() *PostgresTranslator {
-	return &PostgresTranslator{}
+	return &PostgresTranslator{placeholderStyle: PlaceholderDollar}
+}
+
+// WithPlaceholderStyle sets how parameters are referenced in the WHERE clause.
+func (p *PostgresTranslator) WithPlaceholderStyle(style PlaceholderStyle) *PostgresTranslator {
+	p.placeholderStyle = style
+	return p
 }
 
 // DatabaseType returns the database type.
@@ -28,18 +39,33 @@ func (p *PostgresTranslator) DatabaseType() string {
 
 // Translate converts an AST node to a PostgreSQL query.
 func (p *PostgresTranslator) Translate(ast parser.Node, schema *schema.Schema) (*TranslatorOutput, error) {
-	// Reset state for new translation
-	p.paramCount = 0
-	p.params = make([]interface{}, 0)
-	p.paramTypes = make([]string, 0)
-	p.boosts = make([]map[string]interface{}, 0)
+	// Apply the schema's analyzer, operator whitelists and value rewrites
+	ast, warnings, err := prepareForTranslation(ast, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect state on a translator of this call, so that concurrent calls through a
+	// translator shared by the registry do not mix their parameters
+	p = &PostgresTranslator{
+		params:           make([]interface{}, 0),
+		paramTypes:       make([]string, 0),
+		boosts:           make([]map[string]interface{}, 0),
+		placeholderStyle: p.placeholderStyle,
+	}
+
+	query, err := ir.Lower(ast, schema)
+	if err != nil {
+		return nil, err
+	}
 
-	whereClause, err := p.translateNode(ast, schema)
+	whereClause, err := p.translateNode(query, schema)
 	if err != nil {
 		return nil, err
 	}
 
-	output := NewSQLOutput(whereClause, p.params, p.paramTypes)
+	whereClause, params, paramTypes := rewritePlaceholders(whereClause, p.params, p.paramTypes, p.placeholderStyle)
+	output := NewSQLOutput(whereClause, params, paramTypes)
 
 	// Add boost metadata if any boosts were collected
 	if len(p.boosts) > 0 {
@@ -49,384 +75,148 @@ func (p *PostgresTranslator) Translate(ast parser.Node, schema *schema.Schema) (
 		output.Metadata["boosts"] = p.boosts
 	}
 
+	output.ReferencedFields = ReferencedFields(ast, schema)
+	addPartialIndexes(output, PartialIndexes(ast, schema, output.ReferencedFields))
+	addWarnings(output, warnings)
+	addDowngrades(output, p.downgrades)
+	addCoercions(output, ast)
 	return output, nil
 }
 
-// translateNode recursively translates AST nodes.
-func (p *PostgresTranslator) translateNode(node parser.Node, schema *schema.Schema) (string, error) {
+// translateNode recursively translates IR nodes.
+func (p *PostgresTranslator) translateNode(node ir.Node, schema *schema.Schema) (string, error) {
 	switch n := node.(type) {
-	case *parser.FieldQuery:
-		return p.translateFieldQuery(n, schema)
-	case *parser.BinaryOp:
-		return p.translateBinaryOp(n, schema)
-	case *parser.RangeQuery:
-		return p.translateRangeQuery(n, schema)
-	case *parser.UnaryOp:
-		return p.translateUnaryOp(n, schema)
-	case *parser.ExistsQuery:
-		return p.translateExistsQuery(n, schema)
-	case *parser.BoostQuery:
-		return p.translateBoostQuery(n, schema)
-	case *parser.GroupQuery:
-		return p.translateGroupQuery(n, schema)
-	case *parser.RequiredQuery:
-		return p.translateRequiredQuery(n, schema)
-	case *parser.ProhibitedQuery:
-		return p.translateProhibitedQuery(n, schema)
-	case *parser.TermQuery:
-		return p.translateTermQuery(n, schema)
-	case *parser.PhraseQuery:
-		return p.translatePhraseQuery(n, schema)
-	case *parser.WildcardQuery:
-		return p.translateWildcardQuery(n, schema)
-	case *parser.FuzzyQuery:
-		return p.translateFuzzyQuery(n, schema)
-	case *parser.ProximityQuery:
-		return p.translateProximityQuery(n, schema)
-	case *parser.FieldGroupQuery:
-		return p.translateFieldGroupQuery(n, schema)
-	default:
-		return "", fmt.Errorf("unsupported node type: %s", node.Type())
-	}
-}
-
-// translateFieldQuery translates a simple field:value query.
-func (p *PostgresTranslator) translateFieldQuery(fq *parser.FieldQuery, schema *schema.Schema) (string, error) {
-	// Validate field exists in schema
-	columnName, field, err := schema.ResolveField(fq.Field)
-	if err != nil {
-		return "", fmt.Errorf("field %s not found in schema %s", fq.Field, schema.Name)
-	}
-
-	// Handle different value types
-	switch v := fq.Value.(type) {
-	case *parser.WildcardValue:
-		// Convert wildcard pattern to LIKE pattern
-		pattern := v.Pattern
-		pattern = strings.ReplaceAll(pattern, "*", "%")
-		pattern = strings.ReplaceAll(pattern, "?", "_")
-		p.paramCount++
-		p.params = append(p.params, pattern)
-		p.paramTypes = append(p.paramTypes, string(field.Type))
-		return fmt.Sprintf("%s LIKE $%d", columnName, p.paramCount), nil
-
-	case *parser.RegexValue:
-		// Use PostgreSQL regex operator
-		p.paramCount++
-		p.params = append(p.params, v.Pattern)
-		p.paramTypes = append(p.paramTypes, string(field.Type))
-		return fmt.Sprintf("%s ~ $%d", columnName, p.paramCount), nil
-
-	case *parser.PhraseValue:
-		// Phrase is exact match
-		p.paramCount++
-		p.params = append(p.params, v.Phrase)
-		p.paramTypes = append(p.paramTypes, string(field.Type))
-		return fmt.Sprintf("%s = $%d", columnName, p.paramCount), nil
-
+	case *ir.And:
+		return p.translateClauses(n.Clauses, "AND", schema)
+	case *ir.Or:
+		return p.translateClauses(n.Clauses, "OR", schema)
+	case *ir.Group:
+		clause, err := p.translateNode(n.Clause, schema)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(%s)", clause), nil
+	case *ir.Not:
+		operand, err := p.translateNode(n.Clause, schema)
+		if err != nil {
+			return "", err
+		}
+		return negateClause(n, operand), nil
+	case *ir.Boost:
+		// The boost is stored in metadata; the SQL is the same as the boosted clause
+		clause, err := p.translateNode(n.Clause, schema)
+		if err != nil {
+			return "", err
+		}
+		p.boosts = append(p.boosts, boostMetadata(n, schema))
+		return clause, nil
+	case *ir.Equal:
+		p.addParam(n.Value, n.Column.Def)
+		return p.compare(n.Column.Name, "=", n.Column.Def), nil
+	case *ir.Wildcard:
+		if lower, upper, ok := p.prefixRange(n); ok {
+			return prefixRangeClause(n.Column.Name, n.Column.Def, lower, upper, p.addParam, p.compare), nil
+		}
+		p.addParam(likePattern(n.Pattern), n.Column.Def)
+		return p.compare(n.Column.Name, "LIKE", n.Column.Def), nil
+	case *ir.Regex:
+		return p.translateRegex(n, schema), nil
+	case *ir.Phrase:
+		// Phrase matching depends on the field's phrase match mode
+		return p.phrase(n.Column.Name, n.Column.Def, n.Phrase), nil
+	case *ir.Range:
+		return p.translateRange(n), nil
+	case *ir.Exists:
+		return p.translateExists(n), nil
+	case *ir.Fuzzy:
+		return p.translateFuzzy(n, schema)
+	case *ir.Proximity:
+		return p.translateProximity(n, schema)
+	case *ir.In:
+		return p.translateIn(n, schema), nil
+	case *ir.Fragment:
+		return fragmentSQL(n, schema)
 	default:
-		// Simple equality
-		value := fq.Value.Value()
-		p.paramCount++
-		p.params = append(p.params, value)
-		p.paramTypes = append(p.paramTypes, string(field.Type))
-		return fmt.Sprintf("%s = $%d", columnName, p.paramCount), nil
+		return "", fmt.Errorf("unsupported node type: %T", node)
 	}
 }
 
-// translateBinaryOp translates AND/OR operations.
-func (p *PostgresTranslator) translateBinaryOp(bo *parser.BinaryOp, schema *schema.Schema) (string, error) {
-	left, err := p.translateNode(bo.Left, schema)
-	if err != nil {
-		return "", err
-	}
-
-	right, err := p.translateNode(bo.Right, schema)
-	if err != nil {
-		return "", err
-	}
-
-	// Determine if we need parentheses
-	leftNeedsParens := p.needsParentheses(bo.Left)
-	rightNeedsParens := p.needsParentheses(bo.Right)
-
-	if leftNeedsParens {
-		left = fmt.Sprintf("(%s)", left)
-	}
-	if rightNeedsParens {
-		right = fmt.Sprintf("(%s)", right)
+// translateClauses translates the clauses of AND or OR and joins them.
+func (p *PostgresTranslator) translateClauses(nodes []ir.Node, operator string, schema *schema.Schema) (string, error) {
+	clauses := make([]string, len(nodes))
+	for i, node := range nodes {
+		clause, err := p.translateNode(node, schema)
+		if err != nil {
+			return "", err
+		}
+		clauses[i] = clause
 	}
-
-	operator := strings.ToUpper(bo.Op)
-	return fmt.Sprintf("%s %s %s", left, operator, right), nil
+	return joinClauses(nodes, clauses, operator), nil
 }
 
-// needsParentheses determines if a node needs parentheses.
-func (p *PostgresTranslator) needsParentheses(node parser.Node) bool {
-	// Binary operations need parentheses when nested
-	_, isBinaryOp := node.(*parser.BinaryOp)
-	return isBinaryOp
+// addParam adds a parameter bound to a value of the field.
+func (p *PostgresTranslator) addParam(value interface{}, field *schema.Field) {
+	p.paramCount++
+	p.params = append(p.params, value)
+	p.paramTypes = append(p.paramTypes, string(field.Type))
 }
 
-// translateRangeQuery translates range queries like field:[start TO end].
-func (p *PostgresTranslator) translateRangeQuery(rq *parser.RangeQuery, schema *schema.Schema) (string, error) {
-	// Validate field exists in schema
-	columnName, field, err := schema.ResolveField(rq.Field)
-	if err != nil {
-		return "", fmt.Errorf("field %s not found in schema %s", rq.Field, schema.Name)
-	}
-
-	// Check for wildcard boundaries
-	startIsWildcard := p.isWildcard(rq.Start)
-	endIsWildcard := p.isWildcard(rq.End)
+// translateRegex translates regex queries (field:/pattern/).
+func (p *PostgresTranslator) translateRegex(r *ir.Regex, schema *schema.Schema) string {
+	columnName := r.Column.Name
 
-	// Handle fully bounded ranges (no wildcards)
-	if !startIsWildcard && !endIsWildcard && rq.InclusiveStart && rq.InclusiveEnd {
-		// Both inclusive: BETWEEN
-		p.paramCount++
-		p.params = append(p.params, rq.Start.Value())
-		p.paramTypes = append(p.paramTypes, string(field.Type))
+	// Use PostgreSQL regex operator
+	op, pattern := postgresRegexOp(r.Value, regexPattern(r.Value, schema))
+	p.addParam(pattern, r.Column.Def)
+	return fmt.Sprintf("%s %s $%d", columnName, op, p.paramCount)
+}
 
-		p.paramCount++
-		p.params = append(p.params, rq.End.Value())
-		p.paramTypes = append(p.paramTypes, string(field.Type))
+// translateRange translates range queries like field:[start TO end].
+func (p *PostgresTranslator) translateRange(r *ir.Range) string {
+	columnName, field := r.Column.Name, r.Column.Def
 
-		return fmt.Sprintf("%s BETWEEN $%d AND $%d", columnName, p.paramCount-1, p.paramCount), nil
+	// Both ends inclusive: BETWEEN
+	if r.Start != nil && r.End != nil && r.Start.Inclusive && r.End.Inclusive {
+		p.addParam(r.Start.Value, field)
+		p.addParam(r.End.Value, field)
+		return fmt.Sprintf("%s BETWEEN $%d AND $%d", columnName, p.paramCount-1, p.paramCount)
 	}
 
-	// Mixed or exclusive ranges, or unbounded ranges: use comparison operators
+	// Mixed or exclusive ranges, or open ranges: use comparison operators
 	var clauses []string
-
-	// Start condition (if not wildcard)
-	if !startIsWildcard {
-		p.paramCount++
-		p.params = append(p.params, rq.Start.Value())
-		p.paramTypes = append(p.paramTypes, string(field.Type))
-
-		if rq.InclusiveStart {
+	if r.Start != nil {
+		p.addParam(r.Start.Value, field)
+		if r.Start.Inclusive {
 			clauses = append(clauses, fmt.Sprintf("%s >= $%d", columnName, p.paramCount))
 		} else {
 			clauses = append(clauses, fmt.Sprintf("%s > $%d", columnName, p.paramCount))
 		}
 	}
-
-	// End condition (if not wildcard)
-	if !endIsWildcard {
-		p.paramCount++
-		p.params = append(p.params, rq.End.Value())
-		p.paramTypes = append(p.paramTypes, string(field.Type))
-
-		if rq.InclusiveEnd {
+	if r.End != nil {
+		p.addParam(r.End.Value, field)
+		if r.End.Inclusive {
 			clauses = append(clauses, fmt.Sprintf("%s <= $%d", columnName, p.paramCount))
 		} else {
 			clauses = append(clauses, fmt.Sprintf("%s < $%d", columnName, p.paramCount))
 		}
 	}
-
-	return strings.Join(clauses, " AND "), nil
-}
-
-// isWildcard checks if a ValueNode represents a wildcard (*).
-func (p *PostgresTranslator) isWildcard(v parser.ValueNode) bool {
-	if v == nil {
-		return false
-	}
-	val := v.Value()
-	if strVal, ok := val.(string); ok {
-		return strVal == "*"
-	}
-	return false
-}
-
-// translateUnaryOp translates unary operations (+, -, NOT).
-func (p *PostgresTranslator) translateUnaryOp(uo *parser.UnaryOp, schema *schema.Schema) (string, error) {
-	operand, err := p.translateNode(uo.Operand, schema)
-	if err != nil {
-		return "", err
-	}
-
-	// Handle different operators
-	switch uo.Op {
-	case "+":
-		// Required operator - just pass through
-		return operand, nil
-	case "-", "NOT":
-		// Prohibited or NOT operator - add NOT
-		// Wrap in parentheses if needed (for complex expressions or JSON exists checks)
-		if p.needsParenthesesForNot(uo.Operand, operand) {
-			operand = fmt.Sprintf("(%s)", operand)
-		}
-		return fmt.Sprintf("NOT %s", operand), nil
-	default:
-		return "", fmt.Errorf("unsupported unary operator: %s", uo.Op)
-	}
+	return strings.Join(clauses, " AND ")
 }
 
-// needsParenthesesForNot determines if operand needs parentheses in NOT context
-func (p *PostgresTranslator) needsParenthesesForNot(node parser.Node, sql string) bool {
-	// Binary operations always need parentheses
-	if _, isBinaryOp := node.(*parser.BinaryOp); isBinaryOp {
-		return true
-	}
-	// Multi-clause SQL (like JSON exists checks with AND) needs parentheses
-	if strings.Contains(sql, " AND ") || strings.Contains(sql, " OR ") {
-		return true
-	}
-	return false
-}
-
-// translateExistsQuery translates existence checks (_exists_:field).
-func (p *PostgresTranslator) translateExistsQuery(eq *parser.ExistsQuery, schema *schema.Schema) (string, error) {
-	// Validate field exists in schema
-	columnName, field, err := schema.ResolveField(eq.Field)
-	if err != nil {
-		return "", fmt.Errorf("field %s not found in schema %s", eq.Field, schema.Name)
-	}
+// translateExists translates existence checks (_exists_:field).
+func (p *PostgresTranslator) translateExists(e *ir.Exists) string {
+	columnName := e.Column.Name
 
-	// For JSON/JSONB fields, need special handling
-	if field.Type == "json" {
-		// JSON fields: check IS NOT NULL and not the JSON null value
-		return fmt.Sprintf("%s IS NOT NULL AND %s != 'null'::jsonb", columnName, columnName), nil
+	// JSON fields: check IS NOT NULL and not the JSON null value
+	if e.Column.Def.Type == "json" {
+		return fmt.Sprintf("%s IS NOT NULL AND %s != 'null'::jsonb", columnName, columnName)
 	}
-
-	// For regular fields: simple IS NOT NULL check
-	return fmt.Sprintf("%s IS NOT NULL", columnName), nil
+	return fmt.Sprintf("%s IS NOT NULL", columnName)
 }
 
-// translateBoostQuery translates boost queries (query^boost).
-// For SQL databases, boost is stored in metadata; the SQL is the same as the wrapped query.
-func (p *PostgresTranslator) translateBoostQuery(bq *parser.BoostQuery, schema *schema.Schema) (string, error) {
-	// Translate the wrapped query
-	sql, err := p.translateNode(bq.Query, schema)
-	if err != nil {
-		return "", err
-	}
-
-	// Store boost metadata with snake_case query type
-	queryType := p.toSnakeCase(bq.Query.Type())
-	boostInfo := map[string]interface{}{
-		"query": queryType,
-		"boost": bq.Boost,
-	}
-	p.boosts = append(p.boosts, boostInfo)
-
-	return sql, nil
-}
-
-// toSnakeCase converts CamelCase to snake_case
-func (p *PostgresTranslator) toSnakeCase(s string) string {
-	var result strings.Builder
-	for i, r := range s {
-		if i > 0 && r >= 'A' && r <= 'Z' {
-			result.WriteRune('_')
-		}
-		if r >= 'A' && r <= 'Z' {
-			result.WriteRune(r - 'A' + 'a')
-		} else {
-			result.WriteRune(r)
-		}
-	}
-	return result.String()
-}
-
-// translateGroupQuery translates parenthesized expressions.
-func (p *PostgresTranslator) translateGroupQuery(gq *parser.GroupQuery, schema *schema.Schema) (string, error) {
-	inner, err := p.translateNode(gq.Query, schema)
-	if err != nil {
-		return "", err
-	}
-	return fmt.Sprintf("(%s)", inner), nil
-}
-
-// translateRequiredQuery translates +term (required term).
-func (p *PostgresTranslator) translateRequiredQuery(rq *parser.RequiredQuery, schema *schema.Schema) (string, error) {
-	// Required terms pass through - they must match
-	return p.translateNode(rq.Query, schema)
-}
-
-// translateProhibitedQuery translates -term (prohibited term).
-func (p *PostgresTranslator) translateProhibitedQuery(pq *parser.ProhibitedQuery, schema *schema.Schema) (string, error) {
-	inner, err := p.translateNode(pq.Query, schema)
-	if err != nil {
-		return "", err
-	}
-	return fmt.Sprintf("NOT %s", inner), nil
-}
-
-// translateTermQuery translates standalone terms (uses default field).
-func (p *PostgresTranslator) translateTermQuery(tq *parser.TermQuery, schema *schema.Schema) (string, error) {
-	// Use default field from schema options
-	if schema.Options.DefaultField == "" {
-		return "", fmt.Errorf("standalone term '%s' requires a default field in schema", tq.Term)
-	}
-
-	columnName, field, err := schema.ResolveField(schema.Options.DefaultField)
-	if err != nil {
-		return "", fmt.Errorf("default field %s not found in schema %s", schema.Options.DefaultField, schema.Name)
-	}
-
-	p.paramCount++
-	p.params = append(p.params, tq.Term)
-	p.paramTypes = append(p.paramTypes, string(field.Type))
-	return fmt.Sprintf("%s = $%d", columnName, p.paramCount), nil
-}
-
-// translatePhraseQuery translates standalone phrases (uses default field).
-func (p *PostgresTranslator) translatePhraseQuery(pq *parser.PhraseQuery, schema *schema.Schema) (string, error) {
-	// Use default field from schema options
-	if schema.Options.DefaultField == "" {
-		return "", fmt.Errorf("standalone phrase '%s' requires a default field in schema", pq.Phrase)
-	}
-
-	columnName, field, err := schema.ResolveField(schema.Options.DefaultField)
-	if err != nil {
-		return "", fmt.Errorf("default field %s not found in schema %s", schema.Options.DefaultField, schema.Name)
-	}
-
-	p.paramCount++
-	p.params = append(p.params, pq.Phrase)
-	p.paramTypes = append(p.paramTypes, string(field.Type))
-	return fmt.Sprintf("%s = $%d", columnName, p.paramCount), nil
-}
-
-// translateWildcardQuery translates standalone wildcards (uses default field).
-func (p *PostgresTranslator) translateWildcardQuery(wq *parser.WildcardQuery, schema *schema.Schema) (string, error) {
-	// Use default field from schema options
-	if schema.Options.DefaultField == "" {
-		return "", fmt.Errorf("standalone wildcard '%s' requires a default field in schema", wq.Pattern)
-	}
-
-	columnName, field, err := schema.ResolveField(schema.Options.DefaultField)
-	if err != nil {
-		return "", fmt.Errorf("default field %s not found in schema %s", schema.Options.DefaultField, schema.Name)
-	}
-
-	// Convert wildcard pattern to LIKE pattern
-	pattern := wq.Pattern
-	pattern = strings.ReplaceAll(pattern, "*", "%")
-	pattern = strings.ReplaceAll(pattern, "?", "_")
-
-	p.paramCount++
-	p.params = append(p.params, pattern)
-	p.paramTypes = append(p.paramTypes, string(field.Type))
-	return fmt.Sprintf("%s LIKE $%d", columnName, p.paramCount), nil
-}
-
-// translateFuzzyQuery translates fuzzy search queries (term~distance).
-func (p *PostgresTranslator) translateFuzzyQuery(fq *parser.FuzzyQuery, schema *schema.Schema) (string, error) {
-	// Determine field - use provided field or default
-	fieldName := fq.Field
-	if fieldName == "" {
-		if schema.Options.DefaultField == "" {
-			return "", fmt.Errorf("fuzzy search '%s~%d' requires a field or default field in schema", fq.Term, fq.Distance)
-		}
-		fieldName = schema.Options.DefaultField
-	}
-
-	columnName, field, err := schema.ResolveField(fieldName)
-	if err != nil {
-		return "", fmt.Errorf("field %s not found in schema %s", fieldName, schema.Name)
-	}
+// translateFuzzy translates fuzzy search queries (term~distance).
+func (p *PostgresTranslator) translateFuzzy(f *ir.Fuzzy, schema *schema.Schema) (string, error) {
+	columnName, field := f.Column.Name, f.Column.Def
 
 	// Check if fuzzy search is enabled
 	if !schema.Options.EnabledFeatures.Fuzzy {
@@ -434,32 +224,25 @@ func (p *PostgresTranslator) translateFuzzyQuery(fq *parser.FuzzyQuery, schema *
 	}
 
 	// PostgreSQL with pg_trgm: use similarity or levenshtein
-	p.paramCount++
-	p.params = append(p.params, fq.Term)
-	p.paramTypes = append(p.paramTypes, string(field.Type))
+	p.addParam(f.Term, field)
+
+	if minSimilarity := schema.MinSimilarity(field); minSimilarity > 0 {
+		p.paramCount++
+		p.params = append(p.params, minSimilarity)
+		p.paramTypes = append(p.paramTypes, "float")
+		return fmt.Sprintf("similarity(%s, $%d) >= $%d", columnName, p.paramCount-1, p.paramCount), nil
+	}
 
 	p.paramCount++
-	p.params = append(p.params, fq.Distance)
+	p.params = append(p.params, f.Distance)
 	p.paramTypes = append(p.paramTypes, "integer")
 
 	return fmt.Sprintf("levenshtein(%s, $%d) <= $%d", columnName, p.paramCount-1, p.paramCount), nil
 }
 
-// translateProximityQuery translates proximity search queries ("phrase"~distance).
-func (p *PostgresTranslator) translateProximityQuery(pq *parser.ProximityQuery, schema *schema.Schema) (string, error) {
-	// Determine field - use provided field or default
-	fieldName := pq.Field
-	if fieldName == "" {
-		if schema.Options.DefaultField == "" {
-			return "", fmt.Errorf("proximity search requires a field or default field in schema")
-		}
-		fieldName = schema.Options.DefaultField
-	}
-
-	columnName, field, err := schema.ResolveField(fieldName)
-	if err != nil {
-		return "", fmt.Errorf("field %s not found in schema %s", fieldName, schema.Name)
-	}
+// translateProximity translates proximity search queries ("phrase"~distance).
+func (p *PostgresTranslator) translateProximity(px *ir.Proximity, schema *schema.Schema) (string, error) {
+	columnName, field := px.Column.Name, px.Column.Def
 
 	// Check if proximity search is enabled
 	if !schema.Options.EnabledFeatures.Proximity {
@@ -467,138 +250,36 @@ func (p *PostgresTranslator) translateProximityQuery(pq *parser.ProximityQuery,
 	}
 
 	// PostgreSQL with full-text search: use to_tsvector and <N> operator
-	words := strings.Fields(pq.Phrase)
+	words := strings.Fields(px.Phrase)
 	if len(words) < 2 {
 		// Fall back to simple phrase match
-		p.paramCount++
-		p.params = append(p.params, pq.Phrase)
-		p.paramTypes = append(p.paramTypes, string(field.Type))
-		return fmt.Sprintf("%s = $%d", columnName, p.paramCount), nil
+		p.downgrades = append(p.downgrades, newDowngrade(px.Query, "proximity", rsearch.SubstituteEquality,
+			"a single word has no distance to other words, so it is compared with the whole value"))
+		p.addParam(px.Phrase, field)
+		return p.compare(columnName, "=", field), nil
 	}
 
 	// Build tsquery with proximity
-	p.paramCount++
-	p.params = append(p.params, pq.Phrase)
-	p.paramTypes = append(p.paramTypes, string(field.Type))
-
-	return fmt.Sprintf("to_tsvector('english', %s) @@ phraseto_tsquery('english', $%d)", columnName, p.paramCount), nil
-}
-
-// translateFieldGroupQuery translates field:(value1 OR value2) queries.
-func (p *PostgresTranslator) translateFieldGroupQuery(fgq *parser.FieldGroupQuery, schema *schema.Schema) (string, error) {
-	if len(fgq.Queries) == 0 {
-		return "", fmt.Errorf("empty field group query")
-	}
-
-	// Validate field exists in schema
-	columnName, field, err := schema.ResolveField(fgq.Field)
-	if err != nil {
-		return "", fmt.Errorf("field %s not found in schema %s", fgq.Field, schema.Name)
-	}
-
-	// Translate each inner query, wrapping terms as field queries
-	var clauses []string
-	for _, q := range fgq.Queries {
-		var clause string
-		var err error
-
-		switch inner := q.(type) {
-		case *parser.TermQuery:
-			// Convert term to field query
-			p.paramCount++
-			p.params = append(p.params, inner.Term)
-			p.paramTypes = append(p.paramTypes, string(field.Type))
-			clause = fmt.Sprintf("%s = $%d", columnName, p.paramCount)
-		case *parser.WildcardQuery:
-			pattern := inner.Pattern
-			pattern = strings.ReplaceAll(pattern, "*", "%")
-			pattern = strings.ReplaceAll(pattern, "?", "_")
-			p.paramCount++
-			p.params = append(p.params, pattern)
-			p.paramTypes = append(p.paramTypes, string(field.Type))
-			clause = fmt.Sprintf("%s LIKE $%d", columnName, p.paramCount)
-		case *parser.BinaryOp:
-			// Recursively translate the binary operation
-			clause, err = p.translateFieldGroupBinaryOp(inner, columnName, field, schema)
-			if err != nil {
-				return "", err
-			}
-		default:
-			clause, err = p.translateNode(q, schema)
-			if err != nil {
-				return "", err
-			}
-		}
-		clauses = append(clauses, clause)
+	if px.Distance > 0 {
+		p.downgrades = append(p.downgrades, newDowngrade(px.Query, "proximity", rsearch.SubstitutePhrase,
+			fmt.Sprintf("phraseto_tsquery matches the words next to each other, not within %d words", px.Distance)))
 	}
+	p.addParam(px.Phrase, field)
 
-	if len(clauses) == 1 {
-		return clauses[0], nil
-	}
-
-	// Join with OR (default for field groups)
-	return fmt.Sprintf("(%s)", strings.Join(clauses, " OR ")), nil
+	config := textSearchConfig(field)
+	// sqllint:trusted the configuration is a validated identifier
+	return fmt.Sprintf("to_tsvector(%s, %s) @@ phraseto_tsquery(%s, $%d)", config, columnName, config, p.paramCount), nil
 }
 
-// translateFieldGroupBinaryOp handles binary operations within field groups.
-func (p *PostgresTranslator) translateFieldGroupBinaryOp(bo *parser.BinaryOp, columnName string, field *schema.Field, schema *schema.Schema) (string, error) {
-	var leftClause, rightClause string
-	var err error
-
-	// Handle left side
-	switch left := bo.Left.(type) {
-	case *parser.TermQuery:
-		p.paramCount++
-		p.params = append(p.params, left.Term)
-		p.paramTypes = append(p.paramTypes, string(field.Type))
-		leftClause = fmt.Sprintf("%s = $%d", columnName, p.paramCount)
-	case *parser.WildcardQuery:
-		pattern := left.Pattern
-		pattern = strings.ReplaceAll(pattern, "*", "%")
-		pattern = strings.ReplaceAll(pattern, "?", "_")
-		p.paramCount++
-		p.params = append(p.params, pattern)
-		p.paramTypes = append(p.paramTypes, string(field.Type))
-		leftClause = fmt.Sprintf("%s LIKE $%d", columnName, p.paramCount)
-	case *parser.BinaryOp:
-		leftClause, err = p.translateFieldGroupBinaryOp(left, columnName, field, schema)
-		if err != nil {
-			return "", err
-		}
-	default:
-		leftClause, err = p.translateNode(bo.Left, schema)
-		if err != nil {
-			return "", err
-		}
+// translateIn translates an in-list to IN, or to NOT IN when the list is excluded.
+func (p *PostgresTranslator) translateIn(in *ir.In, schema *schema.Schema) string {
+	columnName, field := in.Column.Name, in.Column.Def
+	for _, value := range in.Values {
+		p.addParam(value, field)
 	}
 
-	// Handle right side
-	switch right := bo.Right.(type) {
-	case *parser.TermQuery:
-		p.paramCount++
-		p.params = append(p.params, right.Term)
-		p.paramTypes = append(p.paramTypes, string(field.Type))
-		rightClause = fmt.Sprintf("%s = $%d", columnName, p.paramCount)
-	case *parser.WildcardQuery:
-		pattern := right.Pattern
-		pattern = strings.ReplaceAll(pattern, "*", "%")
-		pattern = strings.ReplaceAll(pattern, "?", "_")
-		p.paramCount++
-		p.params = append(p.params, pattern)
-		p.paramTypes = append(p.paramTypes, string(field.Type))
-		rightClause = fmt.Sprintf("%s LIKE $%d", columnName, p.paramCount)
-	case *parser.BinaryOp:
-		rightClause, err = p.translateFieldGroupBinaryOp(right, columnName, field, schema)
-		if err != nil {
-			return "", err
-		}
-	default:
-		rightClause, err = p.translateNode(bo.Right, schema)
-		if err != nil {
-			return "", err
-		}
+	if !in.Exclude {
+		return p.in(columnName, "IN", len(in.Values), field)
 	}
-
-	operator := strings.ToUpper(bo.Op)
-	return fmt.Sprintf("(%s %s %s)", leftClause, operator, rightClause), nil
+	return excludeList(columnName, p.in(columnName, "NOT IN", len(in.Values), field), schema)
 }
@@ -53,7 +53,7 @@ func TestPostgresTranslator_NumberFieldQuery(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, output)
 	assert.Equal(t, "rod_length = $1", output.WhereClause)
-	assert.Equal(t, "100", output.Parameters[0])
+	assert.Equal(t, int64(100), output.Parameters[0])
 	assert.Equal(t, []string{"integer"}, output.ParameterTypes)
 }
 
@@ -135,8 +135,8 @@ func TestPostgresTranslator_RangeQuery(t *testing.T) {
 	assert.NotNil(t, output)
 	assert.Equal(t, "rod_length BETWEEN $1 AND $2", output.WhereClause)
 	assert.Len(t, output.Parameters, 2)
-	assert.Equal(t, "50", output.Parameters[0])
-	assert.Equal(t, "500", output.Parameters[1])
+	assert.Equal(t, int64(50), output.Parameters[0])
+	assert.Equal(t, int64(500), output.Parameters[1])
 	assert.Equal(t, []string{"integer", "integer"}, output.ParameterTypes)
 }
 
@@ -160,8 +160,8 @@ func TestPostgresTranslator_RangeQuery_Exclusive(t *testing.T) {
 	assert.NotNil(t, output)
 	assert.Equal(t, "price > $1 AND price < $2", output.WhereClause)
 	assert.Len(t, output.Parameters, 2)
-	assert.Equal(t, "10", output.Parameters[0])
-	assert.Equal(t, "20", output.Parameters[1])
+	assert.Equal(t, float64(10), output.Parameters[0])
+	assert.Equal(t, float64(20), output.Parameters[1])
 }
 
 func TestPostgresTranslator_FieldNotInSchema(t *testing.T) {
@@ -420,7 +420,7 @@ func TestPostgresTranslator_ComplexExistsQuery(t *testing.T) {
 	assert.NotNil(t, output)
 	assert.Equal(t, "(name IS NOT NULL AND description IS NOT NULL) OR price = $1", output.WhereClause)
 	assert.Len(t, output.Parameters, 1)
-	assert.Equal(t, "100", output.Parameters[0])
+	assert.Equal(t, float64(100), output.Parameters[0])
 }
 
 // ProximityQuery Tests
@@ -0,0 +1,140 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/infiniv/rsearch/internal/ir"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// Prefix wildcards on fields with PrefixRange, such as code:abc*, translate to the range
+// code >= 'abc' AND code < 'abd' that a B-tree index serves, rather than to LIKE, which
+// most databases only serve from an index under conditions on the collation and the
+// pattern. The range matches the same values as the pattern only when the comparison
+// orders strings by code point, so each dialect keeps LIKE when the field's collation,
+// or the way the dialect's LIKE folds case, could make them differ.
+
+// prefixBounds returns the prefix of a pattern written prefix*, and the smallest string
+// greater than every string starting with it, or "" when there is none. ok is false for
+// other patterns.
+func prefixBounds(pattern string) (prefix, upper string, ok bool) {
+	prefix = strings.TrimSuffix(pattern, "*")
+	if prefix == "" || prefix == pattern || strings.ContainsAny(prefix, "*?") || !utf8.ValidString(prefix) {
+		return "", "", false
+	}
+	return prefix, successor(prefix), true
+}
+
+// successor returns the smallest string in code point order that is greater than every
+// string starting with prefix: the prefix with its last character incremented, dropping
+// trailing characters that cannot be, or "" when no character can be
+func successor(prefix string) string {
+	runes := []rune(prefix)
+	for len(runes) > 0 {
+		last := runes[len(runes)-1]
+		switch {
+		case last == utf8.MaxRune:
+			runes = runes[:len(runes)-1]
+			continue
+		case last == 0xD7FF:
+			// Surrogates are not characters; the next one after them is U+E000
+			runes[len(runes)-1] = 0xE000
+		default:
+			runes[len(runes)-1] = last + 1
+		}
+		return string(runes)
+	}
+	return ""
+}
+
+// prefixRangeClause builds the range matching prefix*, comparing the column through the
+// dialect's compare method after each bound is added as a parameter
+func prefixRangeClause(columnName string, field *schema.Field, lower, upper string, addParam func(interface{}, *schema.Field), compare func(string, string, *schema.Field) string) string {
+	addParam(lower, field)
+	clause := compare(columnName, ">=", field)
+	if upper == "" {
+		return clause
+	}
+	addParam(upper, field)
+	// sqllint:trusted both comparisons are built by the dialect's compare method
+	return fmt.Sprintf("%s AND %s", clause, compare(columnName, "<", field))
+}
+
+// prefixRange returns the bounds of the range replacing a wildcard match. PostgreSQL
+// compares by code point under the C collation; a field without a collation relies on
+// the column's, which setting prefixRange asserts is one of them.
+func (p *PostgresTranslator) prefixRange(w *ir.Wildcard) (lower, upper string, ok bool) {
+	field := w.Column.Def
+	if !field.PrefixRange || field.Unaccent {
+		return "", "", false
+	}
+	switch strings.ToLower(field.Collation) {
+	case "", "c", "posix", "ucs_basic":
+		return prefixBounds(w.Pattern)
+	}
+	return "", "", false
+}
+
+// prefixRange returns the bounds of the range replacing a wildcard match. MySQL compares
+// by code point under binary collations, such as utf8mb4_bin; those that pad with spaces
+// ignore trailing spaces, so bounds ending in one keep LIKE.
+func (m *MySQLTranslator) prefixRange(w *ir.Wildcard) (lower, upper string, ok bool) {
+	field := w.Column.Def
+	if !field.PrefixRange || field.Unaccent {
+		return "", "", false
+	}
+	if field.Collation != "" && !strings.HasSuffix(strings.ToLower(field.Collation), "_bin") {
+		return "", "", false
+	}
+	lower, upper, ok = prefixBounds(w.Pattern)
+	if !ok || strings.HasSuffix(lower, " ") || strings.HasSuffix(upper, " ") {
+		return "", "", false
+	}
+	return lower, upper, true
+}
+
+// prefixRange returns the bounds of the range replacing a wildcard match. SQLite's LIKE
+// ignores ASCII case, as does the NOCASE collation, so under NOCASE the range is taken on
+// the lowercased prefix. Under BINARY, only prefixes without ASCII letters, such as
+// product numbers, match the same values.
+func (s *SQLiteTranslator) prefixRange(w *ir.Wildcard) (lower, upper string, ok bool) {
+	field := w.Column.Def
+	if !field.PrefixRange || field.Unaccent {
+		return "", "", false
+	}
+	switch strings.ToUpper(field.Collation) {
+	case "NOCASE":
+		lower, upper, ok = prefixBounds(asciiLower(w.Pattern))
+		// NOCASE folds an uppercase bound, as the successor of @ is A, back into the range
+		if !ok || hasASCIIUpper(upper) {
+			return "", "", false
+		}
+		return lower, upper, true
+	case "", "BINARY":
+		if hasASCIILetter(w.Pattern) {
+			return "", "", false
+		}
+		return prefixBounds(w.Pattern)
+	}
+	return "", "", false
+}
+
+// asciiLower lowercases ASCII letters only, as SQLite's NOCASE does
+func asciiLower(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, s)
+}
+
+func hasASCIIUpper(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return r >= 'A' && r <= 'Z' }) >= 0
+}
+
+func hasASCIILetter(s string) bool {
+	return hasASCIIUpper(s) || strings.IndexFunc(s, func(r rune) bool { return r >= 'a' && r <= 'z' }) >= 0
+}
@@ -0,0 +1,142 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/ir"
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func translatePrefix(t *testing.T, tr Translator, query string) *TranslatorOutput {
+	t.Helper()
+
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"code":    {Type: schema.TypeText, PrefixRange: true},
+		"sku":     {Type: schema.TypeText, PrefixRange: true, Collation: "C"},
+		"city":    {Type: schema.TypeText, PrefixRange: true, Collation: "en_US"},
+		"ref":     {Type: schema.TypeText, PrefixRange: true, Collation: "utf8mb4_bin"},
+		"nocase":  {Type: schema.TypeText, PrefixRange: true, Collation: "NOCASE"},
+		"folded":  {Type: schema.TypeText, PrefixRange: true, Unaccent: true},
+		"name":    {Type: schema.TypeText},
+		"ordinal": {Type: schema.TypeText, PrefixRange: true, Collation: "utf8mb4_0900_ai_ci"},
+	}, schema.SchemaOptions{})
+
+	ast, err := parser.NewParser(query).Parse()
+	require.NoError(t, err)
+	output, err := tr.Translate(ast, testSchema)
+	require.NoError(t, err)
+	return output
+}
+
+func TestSuccessor(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"abc", "abd"},
+		{"ab9", "ab:"},
+		{"abz", "ab{"},
+		{"café", "cafê"},
+		{"a\U0010FFFF", "b"},
+		{"a\uD7FF", "a\uE000"},
+		{"\U0010FFFF", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, successor(tt.prefix), tt.prefix)
+	}
+}
+
+func TestPrefixRange_Postgres(t *testing.T) {
+	tests := []struct {
+		query  string
+		where  string
+		params []interface{}
+	}{
+		{"code:abc*", "code >= $1 AND code < $2", []interface{}{"abc", "abd"}},
+		{"sku:AB-1*", `sku COLLATE "C" >= $1 AND sku COLLATE "C" < $2`, []interface{}{"AB-1", "AB-2"}},
+		{"NOT code:abc*", "NOT (code >= $1 AND code < $2)", []interface{}{"abc", "abd"}},
+		{"code:abc* OR name:x", "code >= $1 AND code < $2 OR name = $3", []interface{}{"abc", "abd", "x"}},
+		// Only plain prefixes are ranges
+		{"code:a?c*", "code LIKE $1", []interface{}{"a_c%"}},
+		{"code:*bc", "code LIKE $1", []interface{}{"%bc"}},
+		{"code:a*c", "code LIKE $1", []interface{}{"a%c"}},
+		// Collations that may order the range differently, and unaccent, keep LIKE
+		{"city:ber*", `city COLLATE "en_US" LIKE $1`, []interface{}{"ber%"}},
+		{"folded:jos*", "unaccent(folded) LIKE unaccent($1)", []interface{}{"jos%"}},
+		{"name:abc*", "name LIKE $1", []interface{}{"abc%"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			output := translatePrefix(t, NewPostgresTranslator(), tt.query)
+			assert.Equal(t, tt.where, output.WhereClause)
+			assert.Equal(t, tt.params, output.Parameters)
+		})
+	}
+}
+
+func TestPrefixRange_MySQL(t *testing.T) {
+	tests := []struct {
+		query  string
+		where  string
+		params []interface{}
+	}{
+		{"code:abc*", "code >= ? AND code < ?", []interface{}{"abc", "abd"}},
+		{"ref:abc*", "ref >= ? COLLATE utf8mb4_bin AND ref < ? COLLATE utf8mb4_bin", []interface{}{"abc", "abd"}},
+		{"ordinal:abc*", "ordinal LIKE ? COLLATE utf8mb4_0900_ai_ci", []interface{}{"abc%"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			output := translatePrefix(t, NewMySQLTranslator(), tt.query)
+			assert.Equal(t, tt.where, output.WhereClause)
+			assert.Equal(t, tt.params, output.Parameters)
+		})
+	}
+}
+
+func TestPrefixRange_SQLite(t *testing.T) {
+	tests := []struct {
+		query  string
+		where  string
+		params []interface{}
+	}{
+		{"code:123*", "code >= ? AND code < ?", []interface{}{"123", "124"}},
+		{"nocase:AbC*", "nocase >= ? COLLATE NOCASE AND nocase < ? COLLATE NOCASE", []interface{}{"abc", "abd"}},
+		// LIKE ignores ASCII case, which BINARY comparisons do not
+		{"code:abc*", "code LIKE ?", []interface{}{"abc%"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			output := translatePrefix(t, NewSQLiteTranslator(), tt.query)
+			assert.Equal(t, tt.where, output.WhereClause)
+			assert.Equal(t, tt.params, output.Parameters)
+		})
+	}
+}
+
+func TestPrefixRange_UnsafeBounds(t *testing.T) {
+	wildcard := func(field schema.Field, pattern string) *ir.Wildcard {
+		return &ir.Wildcard{Column: ir.Column{Field: "code", Name: "code", Def: &field}, Pattern: pattern}
+	}
+
+	// Padding collations ignore trailing spaces
+	_, _, ok := NewMySQLTranslator().prefixRange(wildcard(schema.Field{Type: schema.TypeText, PrefixRange: true}, "ab *"))
+	assert.False(t, ok)
+	_, _, ok = NewMySQLTranslator().prefixRange(wildcard(schema.Field{Type: schema.TypeText, PrefixRange: true}, "ab\x1f*"))
+	assert.False(t, ok)
+
+	// The successor of @ is A, which NOCASE folds back into the range
+	_, _, ok = NewSQLiteTranslator().prefixRange(wildcard(schema.Field{Type: schema.TypeText, PrefixRange: true, Collation: "NOCASE"}, "x@*"))
+	assert.False(t, ok)
+}
+
+func TestPrefixRange_MongoDB(t *testing.T) {
+	output := translatePrefix(t, NewMongoDBTranslator(), "code:abc*")
+	assert.Equal(t, map[string]interface{}{"code": map[string]interface{}{"$regex": `^abc.*\z`, "$options": "s"}}, output.Filter)
+}
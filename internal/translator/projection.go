@@ -0,0 +1,84 @@
+package translator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// sqlIdentifierRegex matches identifiers that are safe to emit unquoted in a select list
+var sqlIdentifierRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ApplyProjection sets the select list (SQL) or projection document (MongoDB) of the output
+// to the requested schema fields. Every field must resolve through the schema, so clients can
+// only ever select columns the schema exposes. An empty field list leaves the output unchanged.
+// Mapped columns are aliased back to their field names, quoted for dbType.
+func ApplyProjection(output *TranslatorOutput, dbType string, fields []string, s *schema.Schema) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	columns := make([]string, 0, len(fields))
+	names := make([]string, 0, len(fields))
+	seen := make(map[string]bool, len(fields))
+
+	for _, field := range fields {
+		resolved := s.TraceResolution(field)
+		if resolved.Field == "" {
+			return fmt.Errorf("field %s not found in schema %s", field, s.Name)
+		}
+		if seen[resolved.Column] {
+			continue
+		}
+		seen[resolved.Column] = true
+
+		columns = append(columns, resolved.Column)
+		names = append(names, resolved.Field)
+	}
+
+	switch output.Type {
+	case "sql":
+		items := make([]string, len(columns))
+		for i, column := range columns {
+			// Columns are qualified with the schema's table alias, if it has one
+			unqualified := column
+			if alias := s.TableAlias(); alias != "" {
+				unqualified = strings.TrimPrefix(column, alias+".")
+			}
+			if !sqlIdentifierRegex.MatchString(unqualified) {
+				return fmt.Errorf("column %q cannot be projected: not a plain SQL identifier", column)
+			}
+			items[i] = column
+			// Alias mapped columns back to the schema field name, quoted to keep its case
+			if names[i] != column {
+				items[i] = column + " AS " + quoteAlias(dbType, names[i])
+			}
+		}
+		output.SelectClause = strings.Join(items, ", ")
+	case "mongodb":
+		projection := make(map[string]interface{}, len(columns))
+		for _, column := range columns {
+			if strings.HasPrefix(column, "$") {
+				return fmt.Errorf("column %q cannot be projected: not a plain document key", column)
+			}
+			projection[column] = 1
+		}
+		output.Projection = projection
+	default:
+		return fmt.Errorf("projection is not supported for output type %s", output.Type)
+	}
+
+	return nil
+}
+
+// quoteAlias quotes a select list alias for a SQL dialect: with backticks in MySQL and
+// double quotes elsewhere, doubling quotes in the name
+func quoteAlias(dbType, name string) string {
+	quote := `"`
+	if dbType == "mysql" {
+		quote = "`"
+	}
+	return quote + strings.ReplaceAll(name, quote, quote+quote) + quote
+}
@@ -0,0 +1,159 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyProjection_SQL(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":        {Type: schema.TypeText},
+		"price":       {Type: schema.TypeFloat, Aliases: []string{"cost"}},
+		"status":      {Type: schema.TypeText, Column: "product_status"},
+		"productCode": {Type: schema.TypeText},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+
+	output := NewSQLOutput("name = $1", []interface{}{"x"}, []string{"text"})
+
+	err := ApplyProjection(output, "postgres", []string{"name", "status", "cost", "price"}, testSchema)
+	require.NoError(t, err)
+
+	assert.Equal(t, `name, product_status AS "status", price`, output.SelectClause)
+	assert.Nil(t, output.Projection)
+}
+
+func TestApplyProjection_MySQLQuotesAliases(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":        {Type: schema.TypeText},
+		"price":       {Type: schema.TypeFloat, Aliases: []string{"cost"}},
+		"status":      {Type: schema.TypeText, Column: "product_status"},
+		"productCode": {Type: schema.TypeText},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+
+	output := NewSQLOutput("", nil, nil)
+
+	err := ApplyProjection(output, "mysql", []string{"name", "status", "productCode"}, testSchema)
+	require.NoError(t, err)
+
+	assert.Equal(t, "name, product_status AS `status`, product_code AS `productCode`", output.SelectClause)
+}
+
+func TestApplyProjection_AliasedToResolvedField(t *testing.T) {
+	// Both fields map to one column; the alias names the field the request resolved to
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"state":  {Type: schema.TypeText, Column: "product_status"},
+		"status": {Type: schema.TypeText, Column: "product_status", Aliases: []string{"orderStatus"}},
+	}, schema.SchemaOptions{})
+
+	for i := 0; i < 20; i++ {
+		output := NewSQLOutput("", nil, nil)
+		require.NoError(t, ApplyProjection(output, "postgres", []string{"orderStatus"}, s))
+		assert.Equal(t, `product_status AS "status"`, output.SelectClause)
+	}
+}
+
+func TestApplyProjection_SQLNamingConvention(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":        {Type: schema.TypeText},
+		"price":       {Type: schema.TypeFloat, Aliases: []string{"cost"}},
+		"status":      {Type: schema.TypeText, Column: "product_status"},
+		"productCode": {Type: schema.TypeText},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+
+	output := NewSQLOutput("", nil, nil)
+
+	err := ApplyProjection(output, "postgres", []string{"productCode"}, testSchema)
+	require.NoError(t, err)
+
+	assert.Equal(t, `product_code AS "productCode"`, output.SelectClause)
+}
+
+func TestApplyProjection_MongoDB(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":        {Type: schema.TypeText},
+		"price":       {Type: schema.TypeFloat, Aliases: []string{"cost"}},
+		"status":      {Type: schema.TypeText, Column: "product_status"},
+		"productCode": {Type: schema.TypeText},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+
+	output := NewMongoDBOutput(map[string]interface{}{})
+
+	err := ApplyProjection(output, "mongodb", []string{"name", "status"}, testSchema)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{"name": 1, "product_status": 1}, output.Projection)
+	assert.Empty(t, output.SelectClause)
+}
+
+func TestApplyProjection_Empty(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":        {Type: schema.TypeText},
+		"price":       {Type: schema.TypeFloat, Aliases: []string{"cost"}},
+		"status":      {Type: schema.TypeText, Column: "product_status"},
+		"productCode": {Type: schema.TypeText},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+
+	output := NewSQLOutput("", nil, nil)
+
+	require.NoError(t, ApplyProjection(output, "postgres", nil, testSchema))
+	assert.Empty(t, output.SelectClause)
+}
+
+func TestApplyProjection_Errors(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":        {Type: schema.TypeText},
+		"price":       {Type: schema.TypeFloat, Aliases: []string{"cost"}},
+		"status":      {Type: schema.TypeText, Column: "product_status"},
+		"productCode": {Type: schema.TypeText},
+	}, schema.SchemaOptions{NamingConvention: "snake_case"})
+
+	tests := []struct {
+		name   string
+		output *TranslatorOutput
+		fields []string
+		schema *schema.Schema
+		errMsg string
+	}{
+		{
+			name:   "unknown field",
+			output: NewSQLOutput("", nil, nil),
+			fields: []string{"name", "password_hash"},
+			schema: testSchema,
+			errMsg: "field password_hash not found in schema products",
+		},
+		{
+			name:   "raw SQL expression",
+			output: NewSQLOutput("", nil, nil),
+			fields: []string{"name, (SELECT 1)"},
+			schema: testSchema,
+			errMsg: "not found in schema",
+		},
+		{
+			name:   "unsafe SQL column",
+			output: NewSQLOutput("", nil, nil),
+			fields: []string{"address.city"},
+			schema: schema.NewSchema("people", map[string]schema.Field{
+				"address.city": {Type: schema.TypeText},
+			}, schema.SchemaOptions{}),
+			errMsg: "not a plain SQL identifier",
+		},
+		{
+			name:   "unsupported output",
+			output: NewElasticsearchOutput(nil),
+			fields: []string{"name"},
+			schema: testSchema,
+			errMsg: "not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ApplyProjection(tt.output, "postgres", tt.fields, tt.schema)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
@@ -35,56 +35,56 @@ func TestRangeQueryTranslation(t *testing.T) {
 			input:          "age:[18 TO 65]",
 			wantSQL:        "age BETWEEN $1 AND $2",
 			wantParamCount: 2,
-			wantParams:     []interface{}{"18", "65"},
+			wantParams:     []interface{}{int64(18), int64(65)},
 		},
 		{
 			name:           "Exclusive both sides - comparison operators",
 			input:          "price:{100 TO 1000}",
 			wantSQL:        "price > $1 AND price < $2",
 			wantParamCount: 2,
-			wantParams:     []interface{}{"100", "1000"},
+			wantParams:     []interface{}{100.0, 1000.0},
 		},
 		{
 			name:           "Mixed - inclusive start, exclusive end",
 			input:          "score:[50 TO 100}",
 			wantSQL:        "score >= $1 AND score < $2",
 			wantParamCount: 2,
-			wantParams:     []interface{}{"50", "100"},
+			wantParams:     []interface{}{int64(50), int64(100)},
 		},
 		{
 			name:           "Mixed - exclusive start, inclusive end",
 			input:          "rating:{0 TO 5]",
 			wantSQL:        "rating > $1 AND rating <= $2",
 			wantParamCount: 2,
-			wantParams:     []interface{}{"0", "5"},
+			wantParams:     []interface{}{0.0, 5.0},
 		},
 		{
 			name:           "Greater than or equal - comparison syntax",
 			input:          "age:>=18",
 			wantSQL:        "age >= $1",
 			wantParamCount: 1,
-			wantParams:     []interface{}{"18"},
+			wantParams:     []interface{}{int64(18)},
 		},
 		{
 			name:           "Greater than - comparison syntax",
 			input:          "price:>100",
 			wantSQL:        "price > $1",
 			wantParamCount: 1,
-			wantParams:     []interface{}{"100"},
+			wantParams:     []interface{}{100.0},
 		},
 		{
 			name:           "Less than or equal - comparison syntax",
 			input:          "age:<=65",
 			wantSQL:        "age <= $1",
 			wantParamCount: 1,
-			wantParams:     []interface{}{"65"},
+			wantParams:     []interface{}{int64(65)},
 		},
 		{
 			name:           "Less than - comparison syntax",
 			input:          "score:<100",
 			wantSQL:        "score < $1",
 			wantParamCount: 1,
-			wantParams:     []interface{}{"100"},
+			wantParams:     []interface{}{int64(100)},
 		},
 		{
 			name:           "Date range - inclusive",
@@ -119,56 +119,56 @@ func TestRangeQueryTranslation(t *testing.T) {
 			input:          "price:[100 TO *]",
 			wantSQL:        "price >= $1",
 			wantParamCount: 1,
-			wantParams:     []interface{}{"100"},
+			wantParams:     []interface{}{100.0},
 		},
 		{
 			name:           "Unbounded range - open start",
 			input:          "age:[* TO 18]",
 			wantSQL:        "age <= $1",
 			wantParamCount: 1,
-			wantParams:     []interface{}{"18"},
+			wantParams:     []interface{}{int64(18)},
 		},
 		{
 			name:           "Unbounded range - open start, exclusive end",
 			input:          "score:[* TO 100}",
 			wantSQL:        "score < $1",
 			wantParamCount: 1,
-			wantParams:     []interface{}{"100"},
+			wantParams:     []interface{}{int64(100)},
 		},
 		{
 			name:           "Zero value ranges",
 			input:          "count:[0 TO 100]",
 			wantSQL:        "count BETWEEN $1 AND $2",
 			wantParamCount: 2,
-			wantParams:     []interface{}{"0", "100"},
+			wantParams:     []interface{}{int64(0), int64(100)},
 		},
 		{
 			name:           "Decimal values",
 			input:          "rating:[0.0 TO 5.0]",
 			wantSQL:        "rating BETWEEN $1 AND $2",
 			wantParamCount: 2,
-			wantParams:     []interface{}{"0.0", "5.0"},
+			wantParams:     []interface{}{0.0, 5.0},
 		},
 		{
 			name:           "Large numbers",
 			input:          "salary:[50000 TO 150000]",
 			wantSQL:        "salary BETWEEN $1 AND $2",
 			wantParamCount: 2,
-			wantParams:     []interface{}{"50000", "150000"},
+			wantParams:     []interface{}{50000.0, 150000.0},
 		},
 		{
 			name:           "Same start and end - inclusive",
 			input:          "age:[18 TO 18]",
 			wantSQL:        "age BETWEEN $1 AND $2",
 			wantParamCount: 2,
-			wantParams:     []interface{}{"18", "18"},
+			wantParams:     []interface{}{int64(18), int64(18)},
 		},
 		{
 			name:           "Same start and end - exclusive",
 			input:          "age:{18 TO 18}",
 			wantSQL:        "age > $1 AND age < $2",
 			wantParamCount: 2,
-			wantParams:     []interface{}{"18", "18"},
+			wantParams:     []interface{}{int64(18), int64(18)},
 		},
 	}
 
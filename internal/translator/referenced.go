@@ -0,0 +1,109 @@
+package translator
+
+import (
+	"strings"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// ReferencedField is a schema field a translated query reads
+type ReferencedField struct {
+	Field  string // schema field name
+	Column string // column or document key, qualified with any table alias
+}
+
+// ReferencedFields lists the schema fields a query reads, in the order they first
+// appear, so callers can key caches, audit access or build select lists without parsing
+// the query again. Terms without a field count as reads of the default field. Fragment
+// pseudo-fields are not fields and are left out, as are names that do not resolve.
+func ReferencedFields(ast parser.Node, s *schema.Schema) []ReferencedField {
+	var fields []ReferencedField
+	seen := make(map[string]bool)
+
+	parser.Walk(ast, func(node parser.Node) bool {
+		name, ok := queriedField(node)
+		if !ok {
+			return true
+		}
+		if name == "" {
+			name = s.Options.DefaultField
+		}
+		resolved := s.TraceResolution(name)
+		if resolved.Field == "" {
+			return true
+		}
+		if !seen[resolved.Field] {
+			seen[resolved.Field] = true
+			fields = append(fields, ReferencedField{Field: resolved.Field, Column: resolved.Column})
+		}
+		// Members of a field group query its field
+		_, group := node.(*parser.FieldGroupQuery)
+		return !group
+	})
+	return fields
+}
+
+// queriedField returns the field a leaf query reads, "" for the default field, and
+// whether the node reads a field at all
+func queriedField(node parser.Node) (string, bool) {
+	switch n := node.(type) {
+	case *parser.FieldQuery:
+		return n.Field, true
+	case *parser.FieldGroupQuery:
+		return n.Field, true
+	case *parser.InListQuery:
+		return n.Field, true
+	case *parser.RangeQuery:
+		return n.Field, true
+	case *parser.ExistsQuery:
+		return n.Field, true
+	case *parser.FuzzyQuery:
+		return n.Field, true
+	case *parser.ProximityQuery:
+		return n.Field, true
+	case *parser.TermQuery, *parser.PhraseQuery, *parser.WildcardQuery:
+		return "", true
+	}
+	return "", false
+}
+
+// ReferencedFragments lists the schema fragments a query applies, named as the schema
+// defines them, in the order they first appear
+func ReferencedFragments(ast parser.Node, s *schema.Schema) []string {
+	var names []string
+	seen := make(map[string]bool)
+
+	parser.Walk(ast, func(node parser.Node) bool {
+		fq, ok := node.(*parser.FieldQuery)
+		if !ok {
+			return true
+		}
+		// Fields take precedence over fragments of the same name
+		if _, _, err := s.ResolveField(fq.Field); err == nil {
+			return true
+		}
+		name, ok := fragmentName(s, fq.Field)
+		if ok && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+		return true
+	})
+	return names
+}
+
+// fragmentName returns the name the schema defines a queried fragment under
+func fragmentName(s *schema.Schema, queried string) (string, bool) {
+	if _, exists := s.Fragments[queried]; exists {
+		return queried, true
+	}
+	if !s.Options.StrictFieldNames {
+		for name := range s.Fragments {
+			if strings.EqualFold(name, queried) {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,82 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReferencedFields(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"productCode": {Type: schema.TypeText, Column: "product_code"},
+		"name":        {Type: schema.TypeText},
+		"price":       {Type: schema.TypeFloat},
+		"region":      {Type: schema.TypeText},
+		"inStock":     {Type: schema.TypeBoolean, Column: "in_stock"},
+	}, schema.SchemaOptions{DefaultField: "name"})
+
+	tests := []struct {
+		query string
+		want  []ReferencedField
+	}{
+		{"productCode:13w42", []ReferencedField{{"productCode", "product_code"}}},
+		{"PRODUCTCODE:13w42 AND price:[10 TO 20]", []ReferencedField{{"productCode", "product_code"}, {"price", "price"}}},
+		{"region:(ca OR ny) region:in(tx wa) NOT _exists_:inStock", []ReferencedField{{"region", "region"}, {"inStock", "in_stock"}}},
+		{"widget", []ReferencedField{{"name", "name"}}},
+		{`"big deal" price:>10 name:wid*`, []ReferencedField{{"name", "name"}, {"price", "price"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ReferencedFields(ast, testSchema))
+		})
+	}
+}
+
+func TestReferencedFields_Output(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"productCode": {Type: schema.TypeText, Column: "product_code"},
+		"name":        {Type: schema.TypeText},
+		"price":       {Type: schema.TypeFloat},
+		"region":      {Type: schema.TypeText},
+		"inStock":     {Type: schema.TypeBoolean, Column: "in_stock"},
+	}, schema.SchemaOptions{DefaultField: "name"})
+
+	sch, err := testSchema.WithTableAlias("p")
+	require.NoError(t, err)
+
+	ast, err := parser.NewParser("productCode:13w42 OR price:>10").Parse()
+	require.NoError(t, err)
+
+	for _, trans := range []Translator{NewPostgresTranslator(), NewMySQLTranslator(), NewSQLiteTranslator(), NewMongoDBTranslator()} {
+		output, err := trans.Translate(ast, sch)
+		require.NoError(t, err)
+		assert.Equal(t, []ReferencedField{{"productCode", "p.product_code"}, {"price", "p.price"}}, output.ReferencedFields, trans.DatabaseType())
+	}
+}
+
+func TestReferencedFragments(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":    {Type: schema.TypeText},
+		"onSale":  {Type: schema.TypeBoolean, Column: "on_sale"},
+		"stockQt": {Type: schema.TypeInteger, Column: "stock_qty"},
+	}, schema.SchemaOptions{DefaultField: "name"})
+	s.Fragments = map[string]schema.Fragment{
+		"inStock": {SQL: "{stockQt} > 0"},
+		"onSale":  {SQL: "on_sale = true"},
+	}
+
+	ast, err := parser.NewParser("instock:true AND (name:widget OR NOT inStock:false) onSale:true").Parse()
+	require.NoError(t, err)
+	// onSale is a field, which takes precedence over the fragment
+	assert.Equal(t, []string{"inStock"}, ReferencedFragments(ast, s))
+
+	ast, err = parser.NewParser("name:widget").Parse()
+	require.NoError(t, err)
+	assert.Empty(t, ReferencedFragments(ast, s))
+}
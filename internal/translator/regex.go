@@ -0,0 +1,69 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// Regex flags map onto each dialect: PostgreSQL has ~* and embedded options, MongoDB
+// takes $options, and the REGEXP operator of MySQL and SQLite has neither, so a
+// case-insensitive match compares lowercased values and the m flag is rejected.
+
+// regexPattern returns the pattern of a regex value, anchored to the whole value when
+// the schema asks for OpenSearch semantics. The group keeps alternations such as a|b
+// inside the anchors and, unlike (?:...), is understood by every dialect.
+func regexPattern(v *parser.RegexValue, s *schema.Schema) string {
+	if s.AnchorRegex() {
+		return "^(" + v.Pattern + ")$"
+	}
+	return v.Pattern
+}
+
+// postgresRegexOp returns the operator and pattern for a regex match in PostgreSQL. The
+// embedded (?w) option makes ^ and $ match at line breaks while . still matches them,
+// which is the m flag of JavaScript and Lucene.
+func postgresRegexOp(v *parser.RegexValue, pattern string) (string, string) {
+	op := "~"
+	if v.HasFlag('i') {
+		op = "~*"
+	}
+	if v.HasFlag('m') {
+		pattern = "(?w)" + pattern
+	}
+	return op, pattern
+}
+
+// regexpOperand returns the column and pattern for "column REGEXP ?" in MySQL and
+// SQLite, lowering both for the i flag
+func regexpOperand(columnName string, v *parser.RegexValue, pattern, dialect string) (string, string, error) {
+	if v.HasFlag('m') {
+		return "", "", fmt.Errorf("regex flag 'm' in /%s/%s is not supported in %s, whose REGEXP has no multiline mode", v.Pattern, v.Flags, dialect)
+	}
+	if v.HasFlag('i') {
+		return fmt.Sprintf("LOWER(%s)", columnName), lowerRegex(pattern), nil
+	}
+	return columnName, pattern, nil
+}
+
+// lowerRegex lowercases the literal letters of a regex, leaving escapes such as \D, \W
+// and \S alone since their case carries meaning
+func lowerRegex(pattern string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		default:
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
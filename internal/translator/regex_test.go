@@ -0,0 +1,118 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegexFlags_SQL(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{EnabledFeatures: schema.EnabledFeatures{Regex: true}})
+
+	tests := []struct {
+		name      string
+		translate func(parser.Node, *schema.Schema) (*TranslatorOutput, error)
+		query     string
+		where     string
+		pattern   string
+	}{
+		{"postgres", NewPostgresTranslator().Translate, "name:/wid.*/", "name ~ $1", "wid.*"},
+		{"postgres i", NewPostgresTranslator().Translate, "name:/Wid.*/i", "name ~* $1", "Wid.*"},
+		{"postgres m", NewPostgresTranslator().Translate, "name:/^wid/m", "name ~ $1", "(?w)^wid"},
+		{"postgres im", NewPostgresTranslator().Translate, "name:/^wid/mi", "name ~* $1", "(?w)^wid"},
+		{"mysql", NewMySQLTranslator().Translate, "name:/Wid.*/", "name REGEXP ?", "Wid.*"},
+		{"mysql i", NewMySQLTranslator().Translate, `name:/Wid\D+/i`, "LOWER(name) REGEXP ?", `wid\D+`},
+		{"sqlite i", NewSQLiteTranslator().Translate, "name:/[A-Z]idget/i", "LOWER(name) REGEXP ?", "[a-z]idget"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			output, err := tt.translate(ast, testSchema)
+			require.NoError(t, err)
+			assert.Equal(t, tt.where, output.WhereClause)
+			assert.Equal(t, []interface{}{tt.pattern}, output.Parameters)
+		})
+	}
+}
+
+func TestRegexFlags_Unsupported(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{EnabledFeatures: schema.EnabledFeatures{Regex: true}})
+
+	ast, err := parser.NewParser("name:/^wid/m").Parse()
+	require.NoError(t, err)
+
+	_, err = NewMySQLTranslator().Translate(ast, testSchema)
+	assert.EqualError(t, err, "regex flag 'm' in /^wid/m is not supported in MySQL, whose REGEXP has no multiline mode")
+
+	_, err = NewSQLiteTranslator().Translate(ast, testSchema)
+	assert.EqualError(t, err, "regex flag 'm' in /^wid/m is not supported in SQLite, whose REGEXP has no multiline mode")
+}
+
+func TestRegexFlags_MongoDB(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{EnabledFeatures: schema.EnabledFeatures{Regex: true}})
+
+	ast, err := parser.NewParser("name:/^wid/mi").Parse()
+	require.NoError(t, err)
+
+	output, err := NewMongoDBTranslator().Translate(ast, testSchema)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name": map[string]interface{}{"$regex": "^wid", "$options": "im"},
+	}, output.Filter)
+}
+
+func TestRegexAnchoring(t *testing.T) {
+	anchored := schema.NewSchema("products", map[string]schema.Field{
+		"name": {Type: schema.TypeText},
+	}, schema.SchemaOptions{EnabledFeatures: schema.EnabledFeatures{Regex: true}, AnchorRegex: true})
+
+	tests := []struct {
+		name      string
+		translate func(parser.Node, *schema.Schema) (*TranslatorOutput, error)
+		query     string
+		where     string
+		pattern   string
+	}{
+		{"postgres", NewPostgresTranslator().Translate, "name:/wid.*|gad.*/", "name ~ $1", "^(wid.*|gad.*)$"},
+		{"postgres im", NewPostgresTranslator().Translate, "name:/wid.*/im", "name ~* $1", "(?w)^(wid.*)$"},
+		{"mysql i", NewMySQLTranslator().Translate, "name:/Wid.*/i", "LOWER(name) REGEXP ?", "^(wid.*)$"},
+		{"sqlite", NewSQLiteTranslator().Translate, "name:/wid.*/", "name REGEXP ?", "^(wid.*)$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			output, err := tt.translate(ast, anchored)
+			require.NoError(t, err)
+			assert.Equal(t, tt.where, output.WhereClause)
+			assert.Equal(t, []interface{}{tt.pattern}, output.Parameters)
+
+			// A per-request override turns anchoring off again
+			output, err = tt.translate(ast, anchored.WithAnchorRegex(false))
+			require.NoError(t, err)
+			assert.NotContains(t, output.Parameters[0], "^(")
+		})
+	}
+
+	ast, err := parser.NewParser("name:/wid.*/").Parse()
+	require.NoError(t, err)
+	output, err := NewMongoDBTranslator().Translate(ast, anchored)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name": map[string]interface{}{"$regex": "^(wid.*)$", "$options": ""},
+	}, output.Filter)
+}
@@ -0,0 +1,80 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinSimilarity_Postgres(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":  {Type: schema.TypeText, MinSimilarity: 0.4},
+		"title": {Type: schema.TypeText},
+	}, schema.SchemaOptions{EnabledFeatures: schema.EnabledFeatures{Fuzzy: true}})
+
+	ast, err := parser.NewParser("name:laptop~2 OR title:laptop~2").Parse()
+	require.NoError(t, err)
+
+	output, err := NewPostgresTranslator().Translate(ast, testSchema)
+	require.NoError(t, err)
+	assert.Equal(t, "similarity(name, $1) >= $2 OR levenshtein(title, $3) <= $4", output.WhereClause)
+	assert.Equal(t, []interface{}{"laptop", 0.4, "laptop", 2}, output.Parameters)
+	assert.Equal(t, []string{"text", "float", "text", "integer"}, output.ParameterTypes)
+
+	// A request threshold overrides the fields'
+	s, err := testSchema.WithMinSimilarity(0.7)
+	require.NoError(t, err)
+	output, err = NewPostgresTranslator().Translate(ast, s)
+	require.NoError(t, err)
+	assert.Equal(t, "similarity(name, $1) >= $2 OR similarity(title, $3) >= $4", output.WhereClause)
+	assert.Equal(t, []interface{}{"laptop", 0.7, "laptop", 0.7}, output.Parameters)
+}
+
+func TestMinSimilarity_MongoDB(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":  {Type: schema.TypeText, MinSimilarity: 0.4},
+		"title": {Type: schema.TypeText},
+	}, schema.SchemaOptions{EnabledFeatures: schema.EnabledFeatures{Fuzzy: true}})
+
+	ast, err := parser.NewParser("name:laptop~2").Parse()
+	require.NoError(t, err)
+
+	output, err := NewMongoDBTranslator().Translate(ast, testSchema)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"$text": map[string]interface{}{"$search": "laptop"}}, output.Filter)
+	assert.Equal(t, 0.4, output.Metadata["minTextScore"])
+
+	ast, err = parser.NewParser("title:laptop~2").Parse()
+	require.NoError(t, err)
+	output, err = NewMongoDBTranslator().Translate(ast, testSchema)
+	require.NoError(t, err)
+	assert.NotContains(t, output.Metadata, "minTextScore")
+}
+
+func TestMinSimilarity_MySQL(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":  {Type: schema.TypeText, MinSimilarity: 0.4},
+		"title": {Type: schema.TypeText},
+	}, schema.SchemaOptions{EnabledFeatures: schema.EnabledFeatures{Fuzzy: true}})
+
+	ast, err := parser.NewParser("name:laptop~2").Parse()
+	require.NoError(t, err)
+
+	_, err = NewMySQLTranslator().Translate(ast, testSchema)
+	assert.ErrorContains(t, err, "minimum similarity is not supported in MySQL")
+}
+
+func TestWithMinSimilarity_Invalid(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":  {Type: schema.TypeText, MinSimilarity: 0.4},
+		"title": {Type: schema.TypeText},
+	}, schema.SchemaOptions{EnabledFeatures: schema.EnabledFeatures{Fuzzy: true}})
+
+	for _, minSimilarity := range []float64{-0.1, 1.5} {
+		_, err := testSchema.WithMinSimilarity(minSimilarity)
+		assert.Error(t, err, minSimilarity)
+	}
+}
@@ -0,0 +1,88 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// SortKey is one key of a MongoDB sort document
+type SortKey = rsearch.SortKey
+
+// ApplySort sets the ORDER BY list (SQL) or sort keys (MongoDB) of the output to the
+// requested sort, or to the schema's default sort when none is requested. The schema's
+// tie-breaker is appended, ascending, unless the sort already orders by it, so equal
+// sort values never reorder between pages. Without a sort or tie-breaker the output is
+// left unchanged.
+func ApplySort(output *TranslatorOutput, sort []schema.SortField, s *schema.Schema) error {
+	if len(sort) == 0 {
+		sort = s.Options.DefaultSort
+	}
+	if s.Options.TieBreaker != "" {
+		sort = append(sort[:len(sort):len(sort)], schema.SortField{Field: s.Options.TieBreaker})
+	}
+	if len(sort) == 0 {
+		return nil
+	}
+
+	columns := make([]string, 0, len(sort))
+	descending := make([]bool, 0, len(sort))
+	seen := make(map[string]bool, len(sort))
+
+	for _, field := range sort {
+		column, _, err := s.ResolveField(field.Field)
+		if err != nil {
+			return fmt.Errorf("field %s not found in schema %s", field.Field, s.Name)
+		}
+		switch field.Order {
+		case "", rsearch.SortAsc, rsearch.SortDesc:
+		default:
+			return fmt.Errorf("invalid order %q for field %s, expected %q or %q", field.Order, field.Field, rsearch.SortAsc, rsearch.SortDesc)
+		}
+		// The first mention of a column decides its direction
+		if seen[column] {
+			continue
+		}
+		seen[column] = true
+
+		columns = append(columns, column)
+		descending = append(descending, field.Order == rsearch.SortDesc)
+	}
+
+	switch output.Type {
+	case "sql":
+		items := make([]string, len(columns))
+		for i, column := range columns {
+			unqualified := column
+			if alias := s.TableAlias(); alias != "" {
+				unqualified = strings.TrimPrefix(column, alias+".")
+			}
+			if !sqlIdentifierRegex.MatchString(unqualified) {
+				return fmt.Errorf("column %q cannot be sorted: not a plain SQL identifier", column)
+			}
+			items[i] = column
+			if descending[i] {
+				items[i] += " DESC"
+			}
+		}
+		output.OrderByClause = strings.Join(items, ", ")
+	case "mongodb":
+		keys := make([]SortKey, len(columns))
+		for i, column := range columns {
+			if strings.HasPrefix(column, "$") {
+				return fmt.Errorf("column %q cannot be sorted: not a plain document key", column)
+			}
+			keys[i] = SortKey{Key: column, Direction: 1}
+			if descending[i] {
+				keys[i].Direction = -1
+			}
+		}
+		output.Sort = keys
+	default:
+		return fmt.Errorf("sorting is not supported for output type %s", output.Type)
+	}
+
+	return nil
+}
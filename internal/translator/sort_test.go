@@ -0,0 +1,165 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplySort_SQL(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"id":        {Type: schema.TypeInteger},
+		"name":      {Type: schema.TypeText},
+		"price":     {Type: schema.TypeFloat, Aliases: []string{"cost"}},
+		"createdAt": {Type: schema.TypeDate, Column: "created_at"},
+	}, schema.SchemaOptions{TieBreaker: "id"})
+
+	output := NewSQLOutput("name = $1", []interface{}{"x"}, []string{"text"})
+
+	err := ApplySort(output, []schema.SortField{{Field: "cost", Order: rsearch.SortDesc}, {Field: "createdAt"}}, s)
+	require.NoError(t, err)
+
+	assert.Equal(t, "price DESC, created_at, id", output.OrderByClause)
+	assert.Nil(t, output.Sort)
+}
+
+func TestApplySort_MongoDB(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"id":        {Type: schema.TypeInteger},
+		"name":      {Type: schema.TypeText},
+		"price":     {Type: schema.TypeFloat, Aliases: []string{"cost"}},
+		"createdAt": {Type: schema.TypeDate, Column: "created_at"},
+	}, schema.SchemaOptions{TieBreaker: "id"})
+
+	output := NewMongoDBOutput(map[string]interface{}{})
+
+	err := ApplySort(output, []schema.SortField{{Field: "price", Order: rsearch.SortDesc}}, s)
+	require.NoError(t, err)
+
+	assert.Equal(t, []SortKey{{Key: "price", Direction: -1}, {Key: "id", Direction: 1}}, output.Sort)
+	assert.Empty(t, output.OrderByClause)
+}
+
+func TestApplySort_DefaultSort(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"id":        {Type: schema.TypeInteger},
+		"name":      {Type: schema.TypeText},
+		"price":     {Type: schema.TypeFloat, Aliases: []string{"cost"}},
+		"createdAt": {Type: schema.TypeDate, Column: "created_at"},
+	}, schema.SchemaOptions{
+		DefaultSort: []schema.SortField{{Field: "createdAt", Order: rsearch.SortDesc}},
+		TieBreaker:  "id",
+	})
+
+	output := NewSQLOutput("", nil, nil)
+	require.NoError(t, ApplySort(output, nil, s))
+	assert.Equal(t, "created_at DESC, id", output.OrderByClause)
+
+	// A requested sort replaces the default one
+	output = NewSQLOutput("", nil, nil)
+	require.NoError(t, ApplySort(output, []schema.SortField{{Field: "name"}}, s))
+	assert.Equal(t, "name, id", output.OrderByClause)
+
+	// The default sort is never modified by the tie-breaker
+	assert.Len(t, s.Options.DefaultSort, 1)
+}
+
+func TestApplySort_TieBreakerAlreadySorted(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"id":        {Type: schema.TypeInteger},
+		"name":      {Type: schema.TypeText},
+		"price":     {Type: schema.TypeFloat, Aliases: []string{"cost"}},
+		"createdAt": {Type: schema.TypeDate, Column: "created_at"},
+	}, schema.SchemaOptions{TieBreaker: "id"})
+
+	output := NewSQLOutput("", nil, nil)
+	require.NoError(t, ApplySort(output, []schema.SortField{{Field: "id", Order: rsearch.SortDesc}}, s))
+	assert.Equal(t, "id DESC", output.OrderByClause)
+
+	// Without any sort, results are ordered by the tie-breaker alone
+	output = NewSQLOutput("", nil, nil)
+	require.NoError(t, ApplySort(output, nil, s))
+	assert.Equal(t, "id", output.OrderByClause)
+}
+
+func TestApplySort_Empty(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"id":        {Type: schema.TypeInteger},
+		"name":      {Type: schema.TypeText},
+		"price":     {Type: schema.TypeFloat, Aliases: []string{"cost"}},
+		"createdAt": {Type: schema.TypeDate, Column: "created_at"},
+	}, schema.SchemaOptions{})
+
+	output := NewSQLOutput("", nil, nil)
+
+	require.NoError(t, ApplySort(output, nil, testSchema))
+	assert.Empty(t, output.OrderByClause)
+}
+
+func TestApplySort_TableAlias(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"id":        {Type: schema.TypeInteger},
+		"name":      {Type: schema.TypeText},
+		"price":     {Type: schema.TypeFloat, Aliases: []string{"cost"}},
+		"createdAt": {Type: schema.TypeDate, Column: "created_at"},
+	}, schema.SchemaOptions{TieBreaker: "id"})
+
+	s, err := testSchema.WithTableAlias("p")
+	require.NoError(t, err)
+	output := NewSQLOutput("", nil, nil)
+
+	require.NoError(t, ApplySort(output, []schema.SortField{{Field: "name"}}, s))
+	assert.Equal(t, "p.name, p.id", output.OrderByClause)
+}
+
+func TestApplySort_Errors(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"id":        {Type: schema.TypeInteger},
+		"name":      {Type: schema.TypeText},
+		"price":     {Type: schema.TypeFloat, Aliases: []string{"cost"}},
+		"createdAt": {Type: schema.TypeDate, Column: "created_at"},
+	}, schema.SchemaOptions{})
+
+	tests := []struct {
+		name   string
+		output *TranslatorOutput
+		sort   []schema.SortField
+		errMsg string
+	}{
+		{
+			name:   "unknown field",
+			output: NewSQLOutput("", nil, nil),
+			sort:   []schema.SortField{{Field: "password_hash"}},
+			errMsg: "field password_hash not found in schema products",
+		},
+		{
+			name:   "raw SQL expression",
+			output: NewSQLOutput("", nil, nil),
+			sort:   []schema.SortField{{Field: "name; DROP TABLE products"}},
+			errMsg: "not found in schema",
+		},
+		{
+			name:   "invalid order",
+			output: NewSQLOutput("", nil, nil),
+			sort:   []schema.SortField{{Field: "name", Order: "DESC NULLS FIRST"}},
+			errMsg: `invalid order "DESC NULLS FIRST" for field name`,
+		},
+		{
+			name:   "unsupported output",
+			output: &TranslatorOutput{Type: "elasticsearch"},
+			sort:   []schema.SortField{{Field: "name"}},
+			errMsg: "sorting is not supported for output type elasticsearch",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ApplySort(tt.output, tt.sort, testSchema)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
@@ -52,7 +52,7 @@ func TestSQLiteTranslator_NumberFieldQuery(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, output)
 	assert.Equal(t, "rod_length = ?", output.WhereClause)
-	assert.Equal(t, "100", output.Parameters[0])
+	assert.Equal(t, int64(100), output.Parameters[0])
 	assert.Equal(t, []string{"integer"}, output.ParameterTypes)
 }
 
@@ -157,8 +157,8 @@ func TestSQLiteTranslator_RangeQuery_Inclusive(t *testing.T) {
 	assert.NotNil(t, output)
 	assert.Equal(t, "rod_length BETWEEN ? AND ?", output.WhereClause)
 	assert.Len(t, output.Parameters, 2)
-	assert.Equal(t, "50", output.Parameters[0])
-	assert.Equal(t, "500", output.Parameters[1])
+	assert.Equal(t, int64(50), output.Parameters[0])
+	assert.Equal(t, int64(500), output.Parameters[1])
 	assert.Equal(t, []string{"integer", "integer"}, output.ParameterTypes)
 }
 
@@ -182,8 +182,8 @@ func TestSQLiteTranslator_RangeQuery_Exclusive(t *testing.T) {
 	assert.NotNil(t, output)
 	assert.Equal(t, "price > ? AND price < ?", output.WhereClause)
 	assert.Len(t, output.Parameters, 2)
-	assert.Equal(t, "10", output.Parameters[0])
-	assert.Equal(t, "20", output.Parameters[1])
+	assert.Equal(t, float64(10), output.Parameters[0])
+	assert.Equal(t, float64(20), output.Parameters[1])
 }
 
 func TestSQLiteTranslator_RangeQuery_Unbounded(t *testing.T) {
@@ -206,7 +206,7 @@ func TestSQLiteTranslator_RangeQuery_Unbounded(t *testing.T) {
 	assert.NotNil(t, output)
 	assert.Equal(t, "price >= ?", output.WhereClause)
 	assert.Len(t, output.Parameters, 1)
-	assert.Equal(t, "100", output.Parameters[0])
+	assert.Equal(t, float64(100), output.Parameters[0])
 }
 
 func TestSQLiteTranslator_WildcardQuery(t *testing.T) {
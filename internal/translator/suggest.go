@@ -0,0 +1,76 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// ValueSuggestion is a query listing the distinct values of a field
+type ValueSuggestion = rsearch.SuggestValuesResponse
+
+// SuggestValues builds the query listing up to limit distinct values of a field, in
+// order, for autocompletion. Values must start with prefix when it is set, which needs
+// a text field; otherwise they only need to be present. The schema's default filters
+// always apply, so suggestions never reveal values of rows the schema hides. A zero
+// limit is DefaultSuggestLimit.
+func SuggestValues(trans Translator, s *schema.Schema, fieldName, prefix string, limit int) (*ValueSuggestion, error) {
+	if limit == 0 {
+		limit = rsearch.DefaultSuggestLimit
+	}
+	if limit < 0 || limit > rsearch.MaxSuggestLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d, got %d", rsearch.MaxSuggestLimit, limit)
+	}
+
+	column, field, err := s.ResolveField(fieldName)
+	if err != nil {
+		return nil, err
+	}
+
+	var ast parser.Node = &parser.ExistsQuery{Field: fieldName}
+	if prefix != "" {
+		if field.Type != schema.TypeText {
+			return nil, fmt.Errorf("prefixes need a text field, %s is %s", fieldName, field.Type)
+		}
+		if strings.ContainsAny(prefix, "*?") {
+			return nil, fmt.Errorf("prefix %q must not contain wildcards", prefix)
+		}
+		ast = &parser.FieldQuery{Field: fieldName, Value: &parser.WildcardValue{Pattern: prefix + "*"}}
+	}
+
+	filtered, applied, err := ApplyDefaultFilters(ast, s, nil)
+	if err != nil {
+		return nil, err
+	}
+	output, err := trans.Translate(filtered, s)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestion := &ValueSuggestion{
+		Type:           trans.DatabaseType(),
+		Field:          fieldName,
+		Column:         column,
+		Limit:          limit,
+		DefaultFilters: applied,
+	}
+	if trans.DatabaseType() == "mongodb" {
+		suggestion.Pipeline = []map[string]interface{}{
+			{"$match": output.Filter},
+			{"$group": map[string]interface{}{"_id": "$" + column}},
+			{"$sort": map[string]interface{}{"_id": 1}},
+			{"$limit": limit},
+		}
+		return suggestion, nil
+	}
+
+	suggestion.SelectClause = "DISTINCT " + column + " AS value"
+	suggestion.WhereClause = output.WhereClause
+	suggestion.Parameters = output.Parameters
+	suggestion.ParameterTypes = output.ParameterTypes
+	suggestion.OrderByClause = "value"
+	return suggestion, nil
+}
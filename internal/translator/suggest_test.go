@@ -0,0 +1,97 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggestValues_SQL(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText, Column: "product_name"},
+		"status": {Type: schema.TypeText},
+		"price":  {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{
+		DefaultFilters: []schema.DefaultFilter{{Name: "active", Query: "status:active"}},
+	})
+
+	suggestion, err := SuggestValues(NewPostgresTranslator(), testSchema, "name", "wid", 5)
+	require.NoError(t, err)
+	assert.Equal(t, &ValueSuggestion{
+		Type:           "postgres",
+		Field:          "name",
+		Column:         "product_name",
+		SelectClause:   "DISTINCT product_name AS value",
+		WhereClause:    "(product_name LIKE $1) AND (status = $2)",
+		Parameters:     []interface{}{"wid%", "active"},
+		ParameterTypes: []string{"text", "text"},
+		OrderByClause:  "value",
+		Limit:          5,
+		DefaultFilters: []string{"active"},
+	}, suggestion)
+
+	// Without a prefix, any present value is suggested
+	suggestion, err = SuggestValues(NewSQLiteTranslator(), testSchema, "price", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "DISTINCT price AS value", suggestion.SelectClause)
+	assert.Equal(t, "(price IS NOT NULL) AND (status = ?)", suggestion.WhereClause)
+	assert.Equal(t, []interface{}{"active"}, suggestion.Parameters)
+	assert.Equal(t, 10, suggestion.Limit)
+}
+
+func TestSuggestValues_MongoDB(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText, Column: "product_name"},
+		"status": {Type: schema.TypeText},
+		"price":  {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{
+		DefaultFilters: []schema.DefaultFilter{{Name: "active", Query: "status:active"}},
+	})
+
+	suggestion, err := SuggestValues(NewMongoDBTranslator(), testSchema, "name", "", 20)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"$match": map[string]interface{}{"$and": []interface{}{
+			map[string]interface{}{"product_name": map[string]interface{}{"$exists": true, "$ne": nil}},
+			map[string]interface{}{"status": "active"},
+		}}},
+		{"$group": map[string]interface{}{"_id": "$product_name"}},
+		{"$sort": map[string]interface{}{"_id": 1}},
+		{"$limit": 20},
+	}, suggestion.Pipeline)
+	assert.Empty(t, suggestion.SelectClause)
+}
+
+func TestSuggestValues_Errors(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText, Column: "product_name"},
+		"status": {Type: schema.TypeText},
+		"price":  {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{
+		DefaultFilters: []schema.DefaultFilter{{Name: "active", Query: "status:active"}},
+	})
+
+	tests := []struct {
+		name   string
+		field  string
+		prefix string
+		limit  int
+		want   string
+	}{
+		{"unknown field", "color", "", 0, `field "color" not found in schema "products"`},
+		{"prefix on number", "price", "1", 0, "prefixes need a text field, price is float"},
+		{"wildcard prefix", "name", "w*", 0, `prefix "w*" must not contain wildcards`},
+		{"negative limit", "name", "", -1, "limit must be between 1 and 100, got -1"},
+		{"limit too large", "name", "", 101, "limit must be between 1 and 100, got 101"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SuggestValues(NewPostgresTranslator(), testSchema, tt.field, tt.prefix, tt.limit)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package translator
+
+import (
+	"strings"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// ExpandSynonyms returns a copy of the AST in which terms of text fields that belong to a
+// synonym group of the schema's analyzer match any term of the group. With the group
+// [laptop, notebook], an original weight of 2 and a synonym weight of 1, title:laptop
+// becomes (title:laptop^2 OR title:notebook^1), so the weights reach the boost metadata
+// scorers rank by. Terms that are already boosted keep their explicit boost.
+func ExpandSynonyms(ast parser.Node, s *schema.Schema) parser.Node {
+	options := s.Options.Analyzer
+	if len(options.Synonyms) == 0 {
+		return ast
+	}
+
+	groups := make(map[string][]string)
+	for _, group := range options.Synonyms {
+		for _, term := range group {
+			groups[strings.ToLower(term)] = group
+		}
+	}
+	originalWeight := weightOrDefault(options.OriginalWeight)
+	synonymWeight := weightOrDefault(options.SynonymWeight)
+
+	// expand rewrites one term into its weighted alternatives; build creates the clause
+	// matching a single term
+	expand := func(term string, pos parser.Position, build func(term string) parser.Node) parser.Node {
+		group, ok := groups[strings.ToLower(term)]
+		if !ok {
+			return nil
+		}
+		combined := parser.Node(&parser.BoostQuery{Query: build(term), Boost: originalWeight, Pos: pos})
+		for _, synonym := range group {
+			if strings.EqualFold(synonym, term) {
+				continue
+			}
+			boosted := &parser.BoostQuery{Query: build(synonym), Boost: synonymWeight, Pos: pos}
+			combined = &parser.BinaryOp{Op: "OR", Left: combined, Right: boosted, Pos: pos}
+		}
+		return &parser.GroupQuery{Query: combined, Pos: pos}
+	}
+
+	var rewrite func(node parser.Node) parser.Node
+	rewrite = func(node parser.Node) parser.Node {
+		switch n := node.(type) {
+		case *parser.BinaryOp:
+			return &parser.BinaryOp{Op: n.Op, Left: rewrite(n.Left), Right: rewrite(n.Right), Pos: n.Pos, Implicit: n.Implicit}
+		case *parser.UnaryOp:
+			return &parser.UnaryOp{Op: n.Op, Operand: rewrite(n.Operand), Pos: n.Pos}
+		case *parser.RequiredQuery:
+			return &parser.RequiredQuery{Query: rewrite(n.Query), Pos: n.Pos}
+		case *parser.ProhibitedQuery:
+			return &parser.ProhibitedQuery{Query: rewrite(n.Query), Pos: n.Pos}
+		case *parser.GroupQuery:
+			return &parser.GroupQuery{Query: rewrite(n.Query), Pos: n.Pos}
+		case *parser.TermQuery:
+			if !isTextField(s, s.Options.DefaultField) {
+				return node
+			}
+			expanded := expand(n.Term, n.Pos, func(term string) parser.Node {
+				return &parser.TermQuery{Term: term, Pos: n.Pos}
+			})
+			if expanded != nil {
+				return expanded
+			}
+		case *parser.FieldQuery:
+			term, ok := n.Value.(*parser.TermValue)
+			if !ok || !isTextField(s, n.Field) {
+				return node
+			}
+			expanded := expand(term.Term, n.Pos, func(value string) parser.Node {
+				return &parser.FieldQuery{Field: n.Field, Value: &parser.TermValue{Term: value, Pos: term.Pos}, Pos: n.Pos}
+			})
+			if expanded != nil {
+				return expanded
+			}
+		}
+		// Boosted queries keep their explicit weight, and field groups list values
+		// rather than clauses a boost could apply to
+		return node
+	}
+
+	return rewrite(ast)
+}
+
+// weightOrDefault treats an unset weight as neutral
+func weightOrDefault(weight float64) float64 {
+	if weight == 0 {
+		return 1
+	}
+	return weight
+}
+
+// isTextField reports whether a field holds text synonyms apply to
+func isTextField(s *schema.Schema, fieldName string) bool {
+	if fieldName == "" {
+		return false
+	}
+	_, field, err := s.ResolveField(fieldName)
+	return err == nil && field.Type == schema.TypeText
+}
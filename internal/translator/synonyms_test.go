@@ -0,0 +1,136 @@
+package translator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandSynonyms(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"title": {Type: schema.TypeText},
+		"body":  {Type: schema.TypeText},
+	}, schema.SchemaOptions{
+		DefaultField: "body",
+		Analyzer: schema.AnalyzerOptions{
+			Synonyms:       [][]string{{"laptop", "notebook", "portable"}},
+			OriginalWeight: 2,
+			SynonymWeight:  0.5,
+		},
+	})
+
+	tests := []struct {
+		query  string
+		where  string
+		params []interface{}
+	}{
+		{query: "title:laptop", where: "((title = $1 OR title = $2) OR title = $3)", params: []interface{}{"laptop", "notebook", "portable"}},
+		{query: "title:Notebook", where: "((title = $1 OR title = $2) OR title = $3)", params: []interface{}{"Notebook", "laptop", "portable"}},
+		{query: "cheap laptop", where: "body = $1 OR ((body = $2 OR body = $3) OR body = $4)", params: []interface{}{"cheap", "laptop", "notebook", "portable"}},
+		{query: "NOT title:laptop", where: "NOT (((title = $1 OR title = $2) OR title = $3))", params: []interface{}{"laptop", "notebook", "portable"}},
+		{query: "title:laptop^3", where: "title = $1", params: []interface{}{"laptop"}},
+		{query: "title:(laptop OR tablet)", where: "(title = $1 OR title = $2)", params: []interface{}{"laptop", "tablet"}},
+		{query: `title:"laptop bag"`, where: "title = $1", params: []interface{}{"laptop bag"}},
+		{query: "title:desktop", where: "title = $1", params: []interface{}{"desktop"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			output, err := NewPostgresTranslator().Translate(ast, s)
+			require.NoError(t, err)
+			assert.Equal(t, tt.where, output.WhereClause)
+			assert.Equal(t, tt.params, output.Parameters)
+		})
+	}
+}
+
+func TestExpandSynonyms_Weights(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"title": {Type: schema.TypeText},
+		"body":  {Type: schema.TypeText},
+	}, schema.SchemaOptions{
+		DefaultField: "body",
+		Analyzer: schema.AnalyzerOptions{
+			Synonyms:       [][]string{{"laptop", "notebook", "portable"}},
+			OriginalWeight: 2,
+			SynonymWeight:  0.5,
+		},
+	})
+
+	ast, err := parser.NewParser("title:laptop").Parse()
+	require.NoError(t, err)
+
+	output, err := NewPostgresTranslator().Translate(ast, testSchema)
+	require.NoError(t, err)
+
+	boosts := output.Metadata["boosts"].([]map[string]interface{})
+	require.Len(t, boosts, 3)
+	assert.Equal(t, map[string]interface{}{"query": "field_query", "boost": 2.0, "field": "title", "term": "laptop"}, boosts[0])
+	assert.Equal(t, map[string]interface{}{"query": "field_query", "boost": 0.5, "field": "title", "term": "notebook"}, boosts[1])
+	assert.Equal(t, map[string]interface{}{"query": "field_query", "boost": 0.5, "field": "title", "term": "portable"}, boosts[2])
+}
+
+func TestExpandSynonyms_DefaultWeights(t *testing.T) {
+	testSchema := schema.NewSchema("products", map[string]schema.Field{
+		"title": {Type: schema.TypeText},
+		"body":  {Type: schema.TypeText},
+	}, schema.SchemaOptions{
+		DefaultField: "body",
+		Analyzer: schema.AnalyzerOptions{
+			Synonyms: [][]string{{"laptop", "notebook", "portable"}},
+		},
+	})
+
+	ast, err := parser.NewParser("laptop").Parse()
+	require.NoError(t, err)
+
+	output, err := NewMongoDBTranslator().Translate(ast, testSchema)
+	require.NoError(t, err)
+
+	boosts := output.Metadata["boosts"].([]map[string]interface{})
+	require.Len(t, boosts, 3)
+	for _, boost := range boosts {
+		assert.Equal(t, 1.0, boost["boost"])
+		assert.Equal(t, "body", boost["field"])
+	}
+}
+
+func TestExpandSynonyms_NonTextField(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{"count": {Type: schema.TypeInteger}}, schema.SchemaOptions{
+		Analyzer: schema.AnalyzerOptions{Synonyms: [][]string{{"1", "one"}}},
+	})
+
+	ast, err := parser.NewParser("count:1").Parse()
+	require.NoError(t, err)
+	assert.Equal(t, ast, ExpandSynonyms(ast, s))
+}
+
+func TestExpandSynonyms_Idempotent(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"title": {Type: schema.TypeText},
+		"body":  {Type: schema.TypeText},
+	}, schema.SchemaOptions{
+		DefaultField: "body",
+		Analyzer: schema.AnalyzerOptions{
+			Synonyms:       [][]string{{"laptop", "notebook", "portable"}},
+			OriginalWeight: 2,
+			SynonymWeight:  1,
+		},
+	})
+
+	ast, err := parser.NewParser("title:laptop AND cheap notebook").Parse()
+	require.NoError(t, err)
+
+	prepared, _, err := Prepare(ast, s, time.Now())
+	require.NoError(t, err)
+	again, _, err := Prepare(prepared, s, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, prepared, again)
+}
@@ -0,0 +1,55 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslate_TableAlias(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"productCode": {Type: schema.TypeText},
+		"price":       {Type: schema.TypeFloat},
+		"name":        {Type: schema.TypeText, Unaccent: true},
+		"tags":        {Type: schema.TypeText},
+	}, schema.SchemaOptions{NamingConvention: "snake_case", DefaultField: "tags"})
+	aliased, err := s.WithTableAlias("p")
+	require.NoError(t, err)
+
+	query := "productCode:A1 AND price:[10 TO 20] AND name:jose AND _exists_:tags AND laptop"
+	ast, err := parser.NewParser(query).Parse()
+	require.NoError(t, err)
+
+	tests := []struct {
+		translator Translator
+		where      string
+	}{
+		{NewPostgresTranslator(), "(((p.product_code = $1 AND p.price BETWEEN $2 AND $3) AND unaccent(p.name) = unaccent($4)) AND p.tags IS NOT NULL) AND p.tags = $5"},
+		{NewMySQLTranslator(), "(((p.product_code = ? AND p.price BETWEEN ? AND ?) AND p.name = ? COLLATE utf8mb4_0900_ai_ci) AND p.tags IS NOT NULL) AND p.tags = ?"},
+		{NewSQLiteTranslator(), "(((p.product_code = ? AND p.price BETWEEN ? AND ?) AND p.name = ? COLLATE NOCASE) AND p.tags IS NOT NULL) AND p.tags = ?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.translator.DatabaseType(), func(t *testing.T) {
+			output, err := tt.translator.Translate(ast, aliased)
+			require.NoError(t, err)
+			assert.Equal(t, tt.where, output.WhereClause)
+		})
+	}
+}
+
+func TestApplyProjection_TableAlias(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":   {Type: schema.TypeText},
+		"status": {Type: schema.TypeText, Column: "product_status"},
+	}, schema.SchemaOptions{})
+	aliased, err := s.WithTableAlias("p")
+	require.NoError(t, err)
+
+	output := NewSQLOutput("", nil, nil)
+	require.NoError(t, ApplyProjection(output, "postgres", []string{"name", "status"}, aliased))
+	assert.Equal(t, `p.name AS "name", p.product_status AS "status"`, output.SelectClause)
+}
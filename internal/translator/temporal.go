@@ -0,0 +1,75 @@
+package translator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// ApplyAsOf ANDs the schema's temporal predicate into the query, so it only matches the
+// rows valid at asOf: validFrom <= asOf AND (validTo IS NULL OR validTo > asOf). asOf is
+// an RFC 3339 timestamp, a YYYY-MM-DD date, midnight in the schema's timezone, or "now".
+// An empty asOf leaves the query unchanged.
+func ApplyAsOf(ast parser.Node, s *schema.Schema, asOf string) (parser.Node, error) {
+	if asOf == "" {
+		return ast, nil
+	}
+	temporal := s.Options.Temporal
+	if temporal.ValidFrom == "" || temporal.ValidTo == "" {
+		return nil, fmt.Errorf("schema %s has no temporal fields", s.Name)
+	}
+
+	from, err := asOfValue(asOf, s, temporal.ValidFrom)
+	if err != nil {
+		return nil, err
+	}
+	to, err := asOfValue(asOf, s, temporal.ValidTo)
+	if err != nil {
+		return nil, err
+	}
+
+	predicate := &parser.BinaryOp{
+		Op: "AND",
+		Left: &parser.RangeQuery{
+			Field:          temporal.ValidFrom,
+			Start:          &parser.TermValue{Term: "*"},
+			End:            from,
+			InclusiveStart: true,
+			InclusiveEnd:   true,
+		},
+		Right: &parser.GroupQuery{Query: &parser.BinaryOp{
+			Op:   "OR",
+			Left: &parser.UnaryOp{Op: "NOT", Operand: &parser.ExistsQuery{Field: temporal.ValidTo}},
+			Right: &parser.RangeQuery{
+				Field: temporal.ValidTo,
+				Start: to,
+				End:   &parser.TermValue{Term: "*"},
+			},
+		}},
+	}
+
+	// Group both sides so neither can change the other's precedence
+	return &parser.BinaryOp{Op: "AND", Left: group(ast), Right: group(predicate)}, nil
+}
+
+// asOfValue returns asOf as a boundary of the named field, in the field's date format
+func asOfValue(asOf string, s *schema.Schema, fieldName string) (parser.ValueNode, error) {
+	if asOf == DateNow {
+		// Resolved to the current time at translation
+		return &parser.TermValue{Term: DateNow}, nil
+	}
+
+	layout, ok := dateLayout(s, fieldName)
+	if !ok {
+		return nil, fmt.Errorf("temporal field %s is not a date or datetime field", fieldName)
+	}
+	t, err := time.Parse(time.RFC3339, asOf)
+	if err != nil {
+		if t, err = time.ParseInLocation("2006-01-02", asOf, s.Location()); err != nil {
+			return nil, fmt.Errorf("invalid asOf %q, expected an RFC 3339 timestamp, a YYYY-MM-DD date or %q", asOf, DateNow)
+		}
+	}
+	return &parser.TermValue{Term: t.Format(layout)}, nil
+}
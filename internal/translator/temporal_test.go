@@ -0,0 +1,152 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyAsOf_Postgres(t *testing.T) {
+	s := schema.NewSchema("prices", map[string]schema.Field{
+		"sku":        {Type: schema.TypeText},
+		"valid_from": {Type: schema.TypeDateTime},
+		"valid_to":   {Type: schema.TypeDateTime},
+		"day":        {Type: schema.TypeDate},
+	}, schema.SchemaOptions{
+		Timezone: "Europe/Berlin",
+		Temporal: schema.TemporalOptions{ValidFrom: "valid_from", ValidTo: "valid_to"},
+	})
+
+	ast, err := parser.NewParser("sku:a OR sku:b").Parse()
+	require.NoError(t, err)
+
+	combined, err := ApplyAsOf(ast, s, "2024-06-01T12:00:00Z")
+	require.NoError(t, err)
+
+	output, err := NewPostgresTranslator().Translate(combined, s)
+	require.NoError(t, err)
+
+	// The client's OR stays grouped, so it cannot escape the temporal predicate
+	assert.Equal(t, "(sku = $1 OR sku = $2) AND (valid_from <= $3 AND (NOT valid_to IS NOT NULL OR valid_to > $4))", output.WhereClause)
+	assert.Equal(t, []interface{}{"a", "b", "2024-06-01T12:00:00Z", "2024-06-01T12:00:00Z"}, output.Parameters)
+}
+
+func TestApplyAsOf_MongoDB(t *testing.T) {
+	s := schema.NewSchema("prices", map[string]schema.Field{
+		"sku":        {Type: schema.TypeText},
+		"valid_from": {Type: schema.TypeDateTime},
+		"valid_to":   {Type: schema.TypeDateTime},
+		"day":        {Type: schema.TypeDate},
+	}, schema.SchemaOptions{
+		Timezone: "Europe/Berlin",
+		Temporal: schema.TemporalOptions{ValidFrom: "valid_from", ValidTo: "valid_to"},
+	})
+
+	ast, err := parser.NewParser("sku:a").Parse()
+	require.NoError(t, err)
+
+	combined, err := ApplyAsOf(ast, s, "2024-06-01T12:00:00Z")
+	require.NoError(t, err)
+
+	output, err := NewMongoDBTranslator().Translate(combined, s)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"$and": []interface{}{
+		map[string]interface{}{"sku": "a"},
+		map[string]interface{}{"valid_from": map[string]interface{}{"$lte": "2024-06-01T12:00:00Z"}},
+		map[string]interface{}{"$or": []interface{}{
+			map[string]interface{}{"$nor": []interface{}{
+				map[string]interface{}{"valid_to": map[string]interface{}{"$exists": true, "$ne": nil}},
+			}},
+			map[string]interface{}{"valid_to": map[string]interface{}{"$gt": "2024-06-01T12:00:00Z"}},
+		}},
+	}}, output.Filter)
+}
+
+func TestApplyAsOf_Values(t *testing.T) {
+	tests := []struct {
+		name     string
+		asOf     string
+		validTo  schema.FieldType
+		expected []interface{}
+	}{
+		{"timestamp", "2024-06-01T12:00:00+02:00", schema.TypeDateTime, []interface{}{"2024-06-01T12:00:00+02:00", "2024-06-01T12:00:00+02:00"}},
+		{"date in the schema timezone", "2024-06-01", schema.TypeDateTime, []interface{}{"2024-06-01T00:00:00+02:00", "2024-06-01T00:00:00+02:00"}},
+		{"date column", "2024-06-01T12:00:00Z", schema.TypeDate, []interface{}{"2024-06-01T12:00:00Z", "2024-06-01"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := schema.NewSchema("prices", map[string]schema.Field{
+				"sku":        {Type: schema.TypeText},
+				"valid_from": {Type: schema.TypeDateTime},
+				"valid_to":   {Type: tt.validTo},
+			}, schema.SchemaOptions{
+				Timezone: "Europe/Berlin",
+				Temporal: schema.TemporalOptions{ValidFrom: "valid_from", ValidTo: "valid_to"},
+			})
+			ast, err := parser.NewParser("sku:a").Parse()
+			require.NoError(t, err)
+
+			combined, err := ApplyAsOf(ast, s, tt.asOf)
+			require.NoError(t, err)
+
+			output, err := NewPostgresTranslator().Translate(combined, s)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, output.Parameters[1:])
+		})
+	}
+}
+
+func TestApplyAsOf_Now(t *testing.T) {
+	s := schema.NewSchema("prices", map[string]schema.Field{
+		"sku":        {Type: schema.TypeText},
+		"valid_from": {Type: schema.TypeDateTime},
+		"valid_to":   {Type: schema.TypeDateTime},
+		"day":        {Type: schema.TypeDate},
+	}, schema.SchemaOptions{
+		Timezone: "Europe/Berlin",
+		Temporal: schema.TemporalOptions{ValidFrom: "valid_from", ValidTo: "valid_to"},
+	})
+
+	ast, err := parser.NewParser("sku:a").Parse()
+	require.NoError(t, err)
+
+	combined, err := ApplyAsOf(ast, s, "now")
+	require.NoError(t, err)
+	assert.True(t, UsesDateKeywords(combined), "now must be resolved at translation, not cached")
+
+	output, err := NewPostgresTranslator().Translate(combined, s)
+	require.NoError(t, err)
+	require.Len(t, output.Parameters, 3)
+	assert.NotEqual(t, "now", output.Parameters[1])
+	assert.Equal(t, output.Parameters[1], output.Parameters[2])
+}
+
+func TestApplyAsOf_Errors(t *testing.T) {
+	testSchema := schema.NewSchema("prices", map[string]schema.Field{
+		"sku":        {Type: schema.TypeText},
+		"valid_from": {Type: schema.TypeDateTime},
+		"valid_to":   {Type: schema.TypeDateTime},
+		"day":        {Type: schema.TypeDate},
+	}, schema.SchemaOptions{
+		Timezone: "Europe/Berlin",
+		Temporal: schema.TemporalOptions{ValidFrom: "valid_from", ValidTo: "valid_to"},
+	})
+
+	ast, err := parser.NewParser("sku:a").Parse()
+	require.NoError(t, err)
+
+	_, err = ApplyAsOf(ast, testSchema, "last tuesday")
+	assert.EqualError(t, err, `invalid asOf "last tuesday", expected an RFC 3339 timestamp, a YYYY-MM-DD date or "now"`)
+
+	plain := schema.NewSchema("plain", map[string]schema.Field{"sku": {Type: schema.TypeText}}, schema.SchemaOptions{})
+	_, err = ApplyAsOf(ast, plain, "2024-06-01")
+	assert.EqualError(t, err, "schema plain has no temporal fields")
+
+	combined, err := ApplyAsOf(ast, plain, "")
+	require.NoError(t, err)
+	assert.Same(t, ast, combined)
+}
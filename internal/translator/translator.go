@@ -2,13 +2,18 @@ package translator
 
 import (
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/infiniv/rsearch/internal/parser"
 	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
 )
 
-// Translator converts AST nodes to database-specific query formats.
+// Translator converts AST nodes to database-specific query formats. Implementations
+// must be safe for concurrent use, as the registry shares one per database type.
 type Translator interface {
 	// Translate converts an AST node to database-specific output.
 	Translate(ast parser.Node, schema *schema.Schema) (*TranslatorOutput, error)
@@ -30,21 +35,229 @@ type TranslatorOutput struct {
 	// NoSQL-specific fields
 	Filter interface{} // MongoDB filter, ES query DSL
 
+	// Result shaping, set by ApplyProjection and ApplySort
+	SelectClause  string                 // SQL select list
+	Projection    map[string]interface{} // MongoDB projection document
+	OrderByClause string                 // SQL ORDER BY list
+	Sort          []SortKey              // MongoDB sort keys, in order
+
+	// ReferencedFields are the schema fields the query reads, see ReferencedFields
+	ReferencedFields []ReferencedField
+
 	// Metadata contains additional information about the query
 	Metadata map[string]interface{}
 }
 
+// Prepare runs the schema-driven steps every translator applies before translating:
+// unknown field handling, value length and list size limits, checked on the values as
+// written, value alias resolution, dictionaries, checked on the values stored, negation
+// binding, free-text analysis, synonym expansion, localized number parsing, unit
+// conversion, numeric literal normalization, date keyword expansion at now, existence
+// checks for wildcard-only values, range bound checks and operator whitelists. Whitelists
+// are checked last, so date keywords count as the ranges they expand to and field:* as an
+// existence check. It returns warnings about how the query was reinterpreted, such as
+// dropped clauses on unknown fields or swapped range bounds.
+// Preparing an already prepared AST does not change it.
+func Prepare(ast parser.Node, s *schema.Schema, now time.Time) (parser.Node, []Warning, error) {
+	ast, unknownWarnings, err := HandleUnknownFields(ast, s)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := CheckValueLengths(ast, s); err != nil {
+		return nil, nil, err
+	}
+	if err := CheckListSizes(ast, s); err != nil {
+		return nil, nil, err
+	}
+
+	ast = ResolveValueAliases(ast, s)
+	if err := CheckDictionaries(ast, s); err != nil {
+		return nil, nil, err
+	}
+
+	ast = BindNegations(ast, s)
+
+	ast, err = AnalyzeFreeText(ast, s)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ast = ExpandSynonyms(ast, s)
+
+	ast, err = LocalizeNumbers(ast, s)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ast, err = ConvertUnits(ast, s)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ast, err = NormalizeNumbers(ast, s)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ast = ResolveDateKeywords(ast, s, now)
+
+	ast = ResolveExistenceWildcards(ast, s)
+
+	ast, rangeWarnings, err := CheckRangeBounds(ast, s)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := CheckOperators(ast, s); err != nil {
+		return nil, nil, err
+	}
+	return ast, append(unknownWarnings, rangeWarnings...), nil
+}
+
+// prepareForTranslation prepares an AST at the current time, then encodes the values of
+// fields with a codec. Encoding is left out of Prepare so that preparing a prepared AST,
+// as the migration replay does before translating, does not encode values twice.
+func prepareForTranslation(ast parser.Node, s *schema.Schema) (parser.Node, []Warning, error) {
+	ast, warnings, err := Prepare(ast, s, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	ast, err = EncodeValues(ast, s)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ast, warnings, nil
+}
+
+// Warning is non-fatal information about a translation
+type Warning = rsearch.Warning
+
+// newWarning returns a warning about the construct at pos
+func newWarning(code string, pos parser.Position, message string) Warning {
+	return Warning{Code: code, Message: message, Position: pos.Offset, Line: pos.Line, Column: pos.Column}
+}
+
+// addWarnings appends warnings to the output's "warnings" metadata, which lists every
+// warning of a translation: those from Prepare, then downgrades and coercions
+func addWarnings(output *TranslatorOutput, warnings []Warning) {
+	if len(warnings) == 0 {
+		return
+	}
+	if output.Metadata == nil {
+		output.Metadata = make(map[string]interface{})
+	}
+	existing, _ := output.Metadata["warnings"].([]Warning)
+	output.Metadata["warnings"] = append(existing, warnings...)
+}
+
+// Downgrade is a construct translated to a weaker substitute the database supports
+type Downgrade = rsearch.Downgrade
+
+// downgrade is a Downgrade along with the position of the construct that fell back
+type downgrade struct {
+	Downgrade
+	pos parser.Position
+}
+
+// newDowngrade describes the fallback of a query node to a substitute
+func newDowngrade(node parser.Node, feature, substitute, reason string) downgrade {
+	return downgrade{
+		Downgrade: Downgrade{Original: parser.Canonical(node), Feature: feature, Substitute: substitute, Reason: reason},
+		pos:       node.Position(),
+	}
+}
+
+// addDowngrades records the substitutes a translation fell back to in the output's
+// "downgrades" metadata, and warns of each
+func addDowngrades(output *TranslatorOutput, downgrades []downgrade) {
+	if len(downgrades) == 0 {
+		return
+	}
+	recorded := make([]Downgrade, len(downgrades))
+	warnings := make([]Warning, len(downgrades))
+	for i, d := range downgrades {
+		recorded[i] = d.Downgrade
+		// sqllint:trusted a warning for the response, not SQL
+		warnings[i] = newWarning(rsearch.WarningDowngraded, d.pos, fmt.Sprintf("translated %s as %s: %s", d.Original, strings.ReplaceAll(d.Substitute, "_", " "), d.Reason))
+	}
+	if output.Metadata == nil {
+		output.Metadata = make(map[string]interface{})
+	}
+	output.Metadata["downgrades"] = recorded
+	addWarnings(output, warnings)
+}
+
+// Coercion is a value bound differently than written
+type Coercion = rsearch.Coercion
+
+// addCoercions records the values of a prepared AST a translation bound differently
+// than written in the output's "coercions" metadata, and warns of each
+func addCoercions(output *TranslatorOutput, ast parser.Node) {
+	coercions, warnings := coercionsOf(ast)
+	if len(coercions) == 0 {
+		return
+	}
+	if output.Metadata == nil {
+		output.Metadata = make(map[string]interface{})
+	}
+	output.Metadata["coercions"] = coercions
+	addWarnings(output, warnings)
+}
+
+// CoercionError is returned by CheckStrict for translations that bound values differently
+// than written
+type CoercionError struct {
+	Coercions []Coercion
+}
+
+func (e *CoercionError) Error() string {
+	values := make([]string, len(e.Coercions))
+	for i, c := range e.Coercions {
+		values[i] = c.Field + ":" + c.Value
+	}
+	return fmt.Sprintf("cannot bind %s exactly", strings.Join(values, ", "))
+}
+
+// DowngradeError is returned by CheckStrict for translations that fell back to weaker
+// substitutes
+type DowngradeError struct {
+	Downgrades []Downgrade
+}
+
+func (e *DowngradeError) Error() string {
+	constructs := make([]string, len(e.Downgrades))
+	for i, d := range e.Downgrades {
+		constructs[i] = d.Original
+	}
+	return fmt.Sprintf("cannot translate %s exactly for this database", strings.Join(constructs, ", "))
+}
+
+// CheckStrict returns a *DowngradeError when a translation fell back to weaker
+// substitutes, or else a *CoercionError when it bound values differently than written,
+// for callers that need the query to match exactly as written
+func CheckStrict(output *TranslatorOutput) error {
+	if downgrades, _ := output.Metadata["downgrades"].([]Downgrade); len(downgrades) > 0 {
+		return &DowngradeError{Downgrades: downgrades}
+	}
+	if coercions, _ := output.Metadata["coercions"].([]Coercion); len(coercions) > 0 {
+		return &CoercionError{Coercions: coercions}
+	}
+	return nil
+}
+
 // Registry manages translator instances.
+// Lookups read an immutable map without locking; registrations copy it.
 type Registry struct {
-	translators map[string]Translator
-	mu          sync.RWMutex
+	translators atomic.Pointer[map[string]Translator]
+	mu          sync.Mutex // serializes registrations
 }
 
 // NewRegistry creates a new translator registry.
 func NewRegistry() *Registry {
-	return &Registry{
-		translators: make(map[string]Translator),
-	}
+	r := &Registry{}
+	r.translators.Store(&map[string]Translator{})
+	return r
 }
 
 // Register adds a translator to the registry.
@@ -59,20 +272,24 @@ func (r *Registry) Register(dbType string, translator Translator) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.translators[dbType]; exists {
+	current := *r.translators.Load()
+	if _, exists := current[dbType]; exists {
 		return fmt.Errorf("translator for %s already registered", dbType)
 	}
 
-	r.translators[dbType] = translator
+	translators := make(map[string]Translator, len(current)+1)
+	for key, value := range current {
+		translators[key] = value
+	}
+	translators[dbType] = translator
+	r.translators.Store(&translators)
 	return nil
 }
 
-// Get retrieves a translator by database type.
+// Get retrieves a translator by database type. Translators keep the state of a
+// translation to the call, so the one returned can be used concurrently.
 func (r *Registry) Get(dbType string) (Translator, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	translator, exists := r.translators[dbType]
+	translator, exists := (*r.translators.Load())[dbType]
 	if !exists {
 		return nil, fmt.Errorf("translator for %s not found", dbType)
 	}
@@ -82,11 +299,10 @@ func (r *Registry) Get(dbType string) (Translator, error) {
 
 // List returns all registered database types.
 func (r *Registry) List() []string {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	translators := *r.translators.Load()
 
-	types := make([]string, 0, len(r.translators))
-	for dbType := range r.translators {
+	types := make([]string, 0, len(translators))
+	for dbType := range translators {
 		types = append(types, dbType)
 	}
 	return types
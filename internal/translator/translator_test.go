@@ -1,6 +1,8 @@
 package translator
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/infiniv/rsearch/internal/parser"
@@ -58,6 +60,50 @@ func TestRegistryGet(t *testing.T) {
 	assert.Contains(t, err.Error(), "not found")
 }
 
+// TestTranslate_Concurrent tests that translators shared by the registry can translate
+// concurrently, each call binding its own parameters.
+func TestTranslate_Concurrent(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"name":  {Type: schema.TypeText},
+		"price": {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{})
+
+	for _, translator := range []Translator{NewPostgresTranslator(), NewMySQLTranslator(), NewSQLiteTranslator(), NewMongoDBTranslator()} {
+		t.Run(translator.DatabaseType(), func(t *testing.T) {
+			queries := make([]string, 50)
+			want := make([]*TranslatorOutput, len(queries))
+			for i := range queries {
+				queries[i] = fmt.Sprintf("name:item%d^2 AND price:[%d TO %d]", i, i, i*2)
+				ast, err := parser.NewParser(queries[i]).Parse()
+				require.NoError(t, err)
+				want[i], err = translator.Translate(ast, s)
+				require.NoError(t, err)
+			}
+
+			var wg sync.WaitGroup
+			got := make([]*TranslatorOutput, len(queries))
+			errs := make([]error, len(queries))
+			for i := range queries {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					ast, err := parser.NewParser(queries[i]).Parse()
+					if err == nil {
+						got[i], err = translator.Translate(ast, s)
+					}
+					errs[i] = err
+				}(i)
+			}
+			wg.Wait()
+
+			for i := range queries {
+				require.NoError(t, errs[i])
+				assert.Equal(t, want[i], got[i], queries[i])
+			}
+		})
+	}
+}
+
 // TestRegistryList tests listing all translators.
 func TestRegistryList(t *testing.T) {
 	registry := NewRegistry()
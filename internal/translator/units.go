@@ -0,0 +1,124 @@
+package translator
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// unitFamily is a set of convertible units, each given as a multiple of the family's
+// smallest unit so conversions stay exact
+type unitFamily struct {
+	units    map[string]int64 // canonical unit names
+	suffixes map[string]int64 // the units plus their aliases
+}
+
+// unitFamilies lists the units numeric values may be suffixed with. Byte units with a
+// single letter prefix are decimal (1kb = 1000 bytes); the "i" forms are binary (1kib = 1024).
+var unitFamilies = []unitFamily{
+	newUnitFamily(map[string]int64{
+		"b":   1,
+		"kb":  1000,
+		"mb":  1000 * 1000,
+		"gb":  1000 * 1000 * 1000,
+		"tb":  1000 * 1000 * 1000 * 1000,
+		"kib": 1 << 10,
+		"mib": 1 << 20,
+		"gib": 1 << 30,
+		"tib": 1 << 40,
+	}, map[string]string{"byte": "b", "bytes": "b"}),
+	newUnitFamily(map[string]int64{
+		"ns":  1,
+		"us":  1000,
+		"ms":  1000 * 1000,
+		"s":   1000 * 1000 * 1000,
+		"min": 60 * 1000 * 1000 * 1000,
+		"h":   60 * 60 * 1000 * 1000 * 1000,
+		"d":   24 * 60 * 60 * 1000 * 1000 * 1000,
+		"w":   7 * 24 * 60 * 60 * 1000 * 1000 * 1000,
+	}, map[string]string{"µs": "us", "m": "min", "sec": "s"}),
+	newUnitFamily(map[string]int64{
+		"mm": 1,
+		"cm": 10,
+		"m":  1000,
+		"km": 1000 * 1000,
+	}, nil),
+}
+
+func newUnitFamily(units map[string]int64, aliases map[string]string) unitFamily {
+	suffixes := make(map[string]int64, len(units)+len(aliases))
+	for name, factor := range units {
+		suffixes[name] = factor
+	}
+	for alias, name := range aliases {
+		suffixes[alias] = units[name]
+	}
+	return unitFamily{units: units, suffixes: suffixes}
+}
+
+// unitValueRegex matches a number followed by a unit suffix, e.g. "10mb" or "1.5h"
+var unitValueRegex = regexp.MustCompile(`^([+-]?[0-9]+(?:\.[0-9]+)?)\s*([a-zA-Zµ]+)$`)
+
+// ConvertUnits returns a copy of the AST in which unit-suffixed values of numeric fields
+// are converted to the field's base unit, so "size:>10mb" on a field with unit "b" binds
+// 10000000. Fields whose unit is not a known base unit, such as "USD", are left as is.
+func ConvertUnits(ast parser.Node, s *schema.Schema) (parser.Node, error) {
+	return parser.MapValues(ast, func(fieldName string, value parser.ValueNode) (parser.ValueNode, error) {
+		term, ok := value.(*parser.TermValue)
+		if !ok {
+			return value, nil
+		}
+		_, field, err := s.ResolveField(fieldName)
+		if err != nil || (field.Type != schema.TypeInteger && field.Type != schema.TypeFloat) {
+			return value, nil
+		}
+		family, base, ok := baseUnit(field.Unit)
+		if !ok {
+			return value, nil
+		}
+
+		match := unitValueRegex.FindStringSubmatch(strings.TrimSpace(term.Term))
+		if match == nil {
+			return value, nil
+		}
+		suffix, ok := family.suffixes[strings.ToLower(match[2])]
+		if !ok {
+			return nil, fmt.Errorf("unknown unit %q in value %s for field %s (unit %s)", match[2], term.Term, fieldName, field.Unit)
+		}
+
+		number, _ := new(big.Rat).SetString(match[1])
+		number.Mul(number, new(big.Rat).SetInt64(suffix))
+		number.Quo(number, new(big.Rat).SetInt64(base))
+
+		if field.Type == schema.TypeInteger {
+			if !number.IsInt() {
+				return nil, fmt.Errorf("value %s is not a whole number of %s for integer field %s", term.Term, field.Unit, fieldName)
+			}
+			return &parser.NumberValue{Number: number.Num().String(), Pos: term.Pos}, nil
+		}
+		converted, _ := number.Float64()
+		return &parser.NumberValue{Number: strconv.FormatFloat(converted, 'f', -1, 64), Pos: term.Pos}, nil
+	})
+}
+
+// baseUnit looks up a field's unit among the known units, then their aliases, so that
+// "m" is metres while "bytes" still names a base unit
+func baseUnit(unit string) (unitFamily, int64, bool) {
+	unit = strings.ToLower(unit)
+	for _, family := range unitFamilies {
+		if factor, ok := family.units[unit]; ok {
+			return family, factor, true
+		}
+	}
+	for _, family := range unitFamilies {
+		if factor, ok := family.suffixes[unit]; ok {
+			return family, factor, true
+		}
+	}
+	return unitFamily{}, 0, false
+}
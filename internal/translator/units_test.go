@@ -0,0 +1,67 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertUnits(t *testing.T) {
+	s := schema.NewSchema("files", map[string]schema.Field{
+		"size":     {Type: schema.TypeInteger, Unit: "b"},
+		"duration": {Type: schema.TypeInteger, Unit: "ms"},
+		"distance": {Type: schema.TypeFloat, Unit: "km"},
+		"price":    {Type: schema.TypeFloat, Unit: "USD"},
+		"name":     {Type: schema.TypeText, Unit: "b"},
+	}, schema.SchemaOptions{})
+
+	tests := []struct {
+		query   string
+		want    []interface{}
+		wantErr string
+	}{
+		{query: "size:>10mb", want: []interface{}{int64(10000000)}},
+		{query: "size:[1KiB TO 1.5kib]", want: []interface{}{int64(1024), int64(1536)}},
+		{query: "size:(512b OR 2kb)", want: []interface{}{"512", "2000"}},
+		{query: "size:42", want: []interface{}{int64(42)}},
+		{query: "duration:<2h", want: []interface{}{int64(7200000)}},
+		{query: "duration:[30s TO 5m]", want: []interface{}{int64(30000), int64(300000)}},
+		{query: "distance:[500m TO 1km]", want: []interface{}{0.5, 1.0}},
+		{query: "distance:[1km TO 500m]", wantErr: "range on field distance starts at 1, after its end 0.5, at line 1, column 1"},
+		{query: "price:10USD", want: []interface{}{"10USD"}},
+		{query: "name:10mb", want: []interface{}{"10mb"}},
+		{query: "size:10parsecs", wantErr: `unknown unit "parsecs" in value 10parsecs for field size (unit b)`},
+		{query: "duration:1.5us", wantErr: "value 1.5us is not a whole number of ms for integer field duration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			output, err := NewPostgresTranslator().Translate(ast, s)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, output.Parameters)
+		})
+	}
+}
+
+func TestConvertUnits_MongoDB(t *testing.T) {
+	s := schema.NewSchema("files", map[string]schema.Field{
+		"size": {Type: schema.TypeInteger, Unit: "bytes"},
+	}, schema.SchemaOptions{})
+
+	ast, err := parser.NewParser("size:>=1gb").Parse()
+	require.NoError(t, err)
+
+	output, err := NewMongoDBTranslator().Translate(ast, s)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"size": map[string]interface{}{"$gte": int64(1000000000)}}, output.Filter)
+}
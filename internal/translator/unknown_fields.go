@@ -0,0 +1,161 @@
+package translator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// errAllFieldsUnknown is returned when dropping the clauses on unknown fields leaves
+// nothing to translate
+var errAllFieldsUnknown = errors.New("every clause of the query is on an unknown field")
+
+// HandleUnknownFields handles clauses on names that are neither schema fields nor
+// fragments according to the schema's UnknownFields option. By default the AST is
+// returned as it is, for translation to reject them. With "drop", it returns a copy of
+// the AST without them, dropping operators such as NOT with the clause they apply to;
+// with "default_field", a copy in which they query the default field instead, so
+// host:web1 searches the default field for web1. Either way it returns a warning for
+// each clause. Default filters ANDed into the query are left as they are: they restrict
+// what the caller may see, and must not be dropped or widened with the caller's clauses.
+func HandleUnknownFields(ast parser.Node, s *schema.Schema) (parser.Node, []Warning, error) {
+	policy := s.Options.UnknownFields
+	if policy != schema.UnknownFieldsDrop && policy != schema.UnknownFieldsDefaultField {
+		return ast, nil, nil
+	}
+
+	handled, warnings, err := handleUnknownFields(setAsideDefaultFilters(ast, s), s, policy)
+	if err != nil || len(warnings) == 0 {
+		return ast, nil, err
+	}
+	return restoreDefaultFilters(handled), warnings, nil
+}
+
+// filterClause holds a default filter out of unknown field handling. Walk and Transform
+// do not descend into it, and it has no field of its own.
+type filterClause struct {
+	parser.Node
+}
+
+// setAsideDefaultFilters returns a copy of the AST in which the groups ApplyDefaultFilters
+// ANDed in are held in filterClauses
+func setAsideDefaultFilters(ast parser.Node, s *schema.Schema) parser.Node {
+	filters := make(map[string]bool, len(s.Options.DefaultFilters))
+	for _, filter := range s.Options.DefaultFilters {
+		if filterAST, err := parser.NewParser(filter.Query).Parse(); err == nil {
+			filters[parser.Fingerprint(filterAST)] = true
+		}
+	}
+	if len(filters) == 0 {
+		return ast
+	}
+
+	return parser.Transform(ast, func(node parser.Node) parser.Node {
+		g, ok := node.(*parser.GroupQuery)
+		if !ok {
+			return node
+		}
+		// Filters nested in this one were set aside first
+		restored := restoreDefaultFilters(g).(*parser.GroupQuery)
+		if filters[parser.Fingerprint(restored)] || filters[parser.Fingerprint(restored.Query)] {
+			return &filterClause{Node: restored}
+		}
+		return node
+	})
+}
+
+// restoreDefaultFilters returns a copy of the AST with the filterClauses replaced by the
+// default filters they hold
+func restoreDefaultFilters(ast parser.Node) parser.Node {
+	return parser.Transform(ast, func(node parser.Node) parser.Node {
+		if clause, ok := node.(*filterClause); ok {
+			return restoreDefaultFilters(clause.Node)
+		}
+		return node
+	})
+}
+
+// handleUnknownFields drops or renames the clauses on unknown fields for HandleUnknownFields
+func handleUnknownFields(ast parser.Node, s *schema.Schema, policy string) (parser.Node, []Warning, error) {
+	var warnings []Warning
+	parser.Walk(ast, func(node parser.Node) bool {
+		field := nodeField(node)
+		if field == "" || !unknownField(field, s) {
+			return true
+		}
+		// sqllint:trusted a warning for the response, not SQL
+		message := fmt.Sprintf("dropped the clause on field %s, which is not in the schema", field)
+		if policy == schema.UnknownFieldsDefaultField {
+			// sqllint:trusted a warning for the response, not SQL
+			message = fmt.Sprintf("searched the default field %s instead of field %s, which is not in the schema", s.Options.DefaultField, field)
+		}
+		warnings = append(warnings, newWarning(rsearch.WarningUnknownField, node.Position(), message))
+		return false
+	})
+	if len(warnings) == 0 {
+		return ast, nil, nil
+	}
+
+	if policy == schema.UnknownFieldsDefaultField {
+		return renameFields(ast, func(name string) string {
+			if unknownField(name, s) {
+				return s.Options.DefaultField
+			}
+			return name
+		}), warnings, nil
+	}
+
+	dropped := parser.Transform(ast, func(node parser.Node) parser.Node {
+		switch n := node.(type) {
+		case *parser.BinaryOp:
+			switch {
+			case n.Left == nil:
+				return n.Right
+			case n.Right == nil:
+				return n.Left
+			}
+		case *parser.UnaryOp:
+			if n.Operand == nil {
+				return nil
+			}
+		case *parser.RequiredQuery:
+			if n.Query == nil {
+				return nil
+			}
+		case *parser.ProhibitedQuery:
+			if n.Query == nil {
+				return nil
+			}
+		case *parser.BoostQuery:
+			if n.Query == nil {
+				return nil
+			}
+		case *parser.GroupQuery:
+			if n.Query == nil {
+				return nil
+			}
+		default:
+			if field := nodeField(node); field != "" && unknownField(field, s) {
+				return nil
+			}
+		}
+		return node
+	})
+	if dropped == nil {
+		return nil, nil, errAllFieldsUnknown
+	}
+	return dropped, warnings, nil
+}
+
+// unknownField reports whether a field name resolves to neither a schema field nor a
+// fragment pseudo-field
+func unknownField(name string, s *schema.Schema) bool {
+	if _, _, err := s.ResolveField(name); err == nil {
+		return false
+	}
+	_, ok := s.Fragment(name)
+	return !ok
+}
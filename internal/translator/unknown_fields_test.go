@@ -0,0 +1,119 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleUnknownFields(t *testing.T) {
+	tests := []struct {
+		policy string
+		query  string
+		where  string
+		params []interface{}
+	}{
+		{policy: schema.UnknownFieldsDrop, query: "level:warn AND host:web1", where: "level = $1", params: []interface{}{"warn"}},
+		{policy: schema.UnknownFieldsDrop, query: "timeout AND NOT host:web1 AND -pod:(a OR b)", where: "message = $1", params: []interface{}{"timeout"}},
+		{policy: schema.UnknownFieldsDrop, query: "(host:web1 OR level:warn)^2 AND errors:true", where: "(level = $1) AND (level = 'error')", params: []interface{}{"warn"}},
+		{policy: schema.UnknownFieldsDefaultField, query: "level:warn AND host:web1", where: "level = $1 AND message = $2", params: []interface{}{"warn", "web1"}},
+		{policy: schema.UnknownFieldsDefaultField, query: `host:"web 1" OR pod:(a, b)`, where: "message = $1 OR message IN ($2, $3)", params: []interface{}{"web 1", "a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy+" "+tt.query, func(t *testing.T) {
+			s := schema.NewSchema("logs", map[string]schema.Field{
+				"message": {Type: schema.TypeText},
+				"level":   {Type: schema.TypeText},
+			}, schema.SchemaOptions{DefaultField: "message", UnknownFields: tt.policy})
+			s.Fragments = map[string]schema.Fragment{"errors": {SQL: "{level} = 'error'"}}
+
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			output, err := NewPostgresTranslator().Translate(ast, s)
+			require.NoError(t, err)
+			assert.Equal(t, tt.where, output.WhereClause)
+			assert.Equal(t, tt.params, output.Parameters)
+		})
+	}
+}
+
+func TestHandleUnknownFields_Warnings(t *testing.T) {
+	testSchema := schema.NewSchema("logs", map[string]schema.Field{
+		"message": {Type: schema.TypeText},
+		"level":   {Type: schema.TypeText},
+	}, schema.SchemaOptions{DefaultField: "message", UnknownFields: schema.UnknownFieldsDrop})
+
+	ast, err := parser.NewParser("level:warn AND host:web1").Parse()
+	require.NoError(t, err)
+
+	_, warnings, err := HandleUnknownFields(ast, testSchema)
+	require.NoError(t, err)
+	assert.Equal(t, []Warning{{
+		Code:     rsearch.WarningUnknownField,
+		Message:  "dropped the clause on field host, which is not in the schema",
+		Position: 15, Line: 1, Column: 16,
+	}}, warnings)
+
+	testSchema.Options.UnknownFields = schema.UnknownFieldsDefaultField
+	_, warnings, err = HandleUnknownFields(ast, testSchema)
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "searched the default field message instead of field host, which is not in the schema", warnings[0].Message)
+}
+
+func TestHandleUnknownFields_Error(t *testing.T) {
+	for _, policy := range []string{"", schema.UnknownFieldsError} {
+		testSchema := schema.NewSchema("logs", map[string]schema.Field{
+			"message": {Type: schema.TypeText},
+			"level":   {Type: schema.TypeText},
+		}, schema.SchemaOptions{DefaultField: "message", UnknownFields: policy})
+
+		ast, err := parser.NewParser("level:warn AND host:web1").Parse()
+		require.NoError(t, err)
+
+		_, err = NewPostgresTranslator().Translate(ast, testSchema)
+		assert.EqualError(t, err, "field host not found in schema logs")
+	}
+
+	testSchema := schema.NewSchema("logs", map[string]schema.Field{
+		"message": {Type: schema.TypeText},
+		"level":   {Type: schema.TypeText},
+	}, schema.SchemaOptions{DefaultField: "message", UnknownFields: schema.UnknownFieldsDrop})
+
+	ast, err := parser.NewParser("host:web1 OR NOT pod:a").Parse()
+	require.NoError(t, err)
+	_, err = NewPostgresTranslator().Translate(ast, testSchema)
+	assert.ErrorIs(t, err, errAllFieldsUnknown)
+}
+
+func TestHandleUnknownFields_KeepsDefaultFilters(t *testing.T) {
+	for _, policy := range []string{schema.UnknownFieldsDrop, schema.UnknownFieldsDefaultField} {
+		// A filter on a field the schema lacks, as registered before filters were checked
+		s := schema.NewSchema("logs", map[string]schema.Field{
+			"message": {Type: schema.TypeText},
+			"level":   {Type: schema.TypeText},
+		}, schema.SchemaOptions{
+			DefaultField:   "message",
+			UnknownFields:  policy,
+			DefaultFilters: []schema.DefaultFilter{{Name: "tenant", Query: "tenantId:42"}, {Name: "info", Query: "(level:info)"}},
+		})
+
+		ast, err := parser.NewParser("timeout AND host:web1").Parse()
+		require.NoError(t, err)
+		ast, _, err = ApplyDefaultFilters(ast, s, nil)
+		require.NoError(t, err)
+
+		handled, warnings, err := HandleUnknownFields(ast, s)
+		require.NoError(t, err, policy)
+		require.Len(t, warnings, 1, policy)
+		assert.Contains(t, warnings[0].Message, "field host", policy)
+		assert.Contains(t, parser.Render(handled), "(tenantId:42)", policy)
+		assert.Contains(t, parser.Render(handled), "(level:info)", policy)
+	}
+}
@@ -0,0 +1,70 @@
+package translator
+
+import (
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// ValueAlias is a value written in a query that was bound as the value stored for it
+type ValueAlias = rsearch.ValueAlias
+
+// ResolveValueAliases returns a copy of the AST in which the values of fields with value
+// aliases that are one of them are replaced by the value stored, so region:california
+// binds "ca" and region:"new york" binds "ny". Terms, phrases and numbers are resolved
+// in field queries, in-lists, field groups and range bounds; patterns and free text are
+// not, as they are not values of the field.
+func ResolveValueAliases(ast parser.Node, s *schema.Schema) parser.Node {
+	resolved, _ := parser.MapValues(ast, func(fieldName string, value parser.ValueNode) (parser.ValueNode, error) {
+		_, stored, pos, ok := storedValue(s, fieldName, value)
+		if !ok {
+			return value, nil
+		}
+		return &parser.TermValue{Term: stored, Pos: pos}, nil
+	})
+	return resolved
+}
+
+// ValueAliases lists the values of a query that are value aliases with the value stored
+// for each, in the order they are written, for explanations to map the values bound back
+// to the values written
+func ValueAliases(ast parser.Node, s *schema.Schema) []ValueAlias {
+	var aliases []ValueAlias
+	parser.MapValues(ast, func(fieldName string, value parser.ValueNode) (parser.ValueNode, error) {
+		if written, stored, pos, ok := storedValue(s, fieldName, value); ok {
+			aliases = append(aliases, ValueAlias{
+				Field:    fieldName,
+				Value:    written,
+				Stored:   stored,
+				Position: pos.Offset,
+				Line:     pos.Line,
+				Column:   pos.Column,
+			})
+		}
+		return value, nil
+	})
+	return aliases
+}
+
+// storedValue returns a value of a field as written and the value stored for it, when it
+// is one of the field's value aliases
+func storedValue(s *schema.Schema, fieldName string, value parser.ValueNode) (written, stored string, pos parser.Position, ok bool) {
+	_, field, err := s.ResolveField(fieldName)
+	if err != nil || len(field.ValueAliases) == 0 {
+		return "", "", pos, false
+	}
+
+	switch v := value.(type) {
+	case *parser.TermValue:
+		written, pos = v.Term, v.Pos
+	case *parser.PhraseValue:
+		written, pos = v.Phrase, v.Pos
+	case *parser.NumberValue:
+		written, pos = v.Number, v.Pos
+	default:
+		return "", "", pos, false
+	}
+
+	stored, ok = field.StoredValue(written)
+	return written, stored, pos, ok
+}
@@ -0,0 +1,83 @@
+package translator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveValueAliases(t *testing.T) {
+	testSchema := schema.NewSchema("stores", map[string]schema.Field{
+		"region":   {Type: schema.TypeText, ValueAliases: map[string]string{"california": "ca", "canada": "can", "new york": "ny"}},
+		"priority": {Type: schema.TypeInteger, ValueAliases: map[string]string{"low": "1", "high": "3"}},
+		"name":     {Type: schema.TypeText},
+	}, schema.SchemaOptions{DefaultField: "name"})
+
+	tests := []struct {
+		query  string
+		where  string
+		params []interface{}
+	}{
+		{query: "region:california", where: "region = $1", params: []interface{}{"ca"}},
+		{query: `region:"New York"`, where: "region = $1", params: []interface{}{"ny"}},
+		{query: "region:(California, canada, mx)", where: "region IN ($1, $2, $3)", params: []interface{}{"ca", "can", "mx"}},
+		{query: "region:(canada OR ca)", where: "(region = $1 OR region = $2)", params: []interface{}{"can", "ca"}},
+		{query: "priority:[low TO high] AND NOT priority:2", where: "priority BETWEEN $1 AND $2 AND NOT priority = $3", params: []interface{}{int64(1), int64(3), int64(2)}},
+		{query: "region:cal* OR california", where: "region LIKE $1 OR name = $2", params: []interface{}{"cal%", "california"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			output, err := NewPostgresTranslator().Translate(ast, testSchema)
+			require.NoError(t, err)
+			assert.Equal(t, tt.where, output.WhereClause)
+			assert.Equal(t, tt.params, output.Parameters)
+		})
+	}
+}
+
+func TestResolveValueAliases_Dictionary(t *testing.T) {
+	registry := schema.NewRegistry()
+	require.NoError(t, registry.RegisterDictionary("currencies", &listDictionary{values: []string{"USD", "EUR"}}))
+	s := schema.NewSchema("orders", map[string]schema.Field{
+		"currency": {Type: schema.TypeText, Dictionary: "currencies"},
+		"note":     {Type: schema.TypeText},
+	}, schema.SchemaOptions{DefaultField: "note"})
+	require.NoError(t, registry.Register(s))
+	field := s.Fields["currency"]
+	field.ValueAliases = map[string]string{"dollar": "USD"}
+	s.Fields["currency"] = field
+
+	ast, err := parser.NewParser("currency:dollar").Parse()
+	require.NoError(t, err)
+	_, _, err = Prepare(ast, s, time.Now())
+	assert.NoError(t, err, "dictionaries hold the values stored")
+}
+
+func TestExplain_ValueAliases(t *testing.T) {
+	testSchema := schema.NewSchema("stores", map[string]schema.Field{
+		"region":   {Type: schema.TypeText, ValueAliases: map[string]string{"california": "ca", "canada": "can", "new york": "ny"}},
+		"priority": {Type: schema.TypeInteger, ValueAliases: map[string]string{"low": "1", "high": "3"}},
+		"name":     {Type: schema.TypeText},
+	}, schema.SchemaOptions{DefaultField: "name"})
+
+	ast, err := parser.NewParser("region:California AND priority:(high, 2)").Parse()
+	require.NoError(t, err)
+
+	explanation := Explain(ast, testSchema)
+	assert.Equal(t, []ValueAlias{
+		{Field: "region", Value: "California", Stored: "ca", Position: 7, Line: 1, Column: 8},
+		{Field: "priority", Value: "high", Stored: "3", Position: 32, Line: 1, Column: 33},
+	}, explanation.Values)
+
+	ast, err = parser.NewParser("name:california").Parse()
+	require.NoError(t, err)
+	assert.Empty(t, Explain(ast, testSchema).Values)
+}
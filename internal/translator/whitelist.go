@@ -0,0 +1,150 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// OperatorNotAllowedError is returned when a query uses an operator outside a field's whitelist
+type OperatorNotAllowedError struct {
+	Field    string   // field as named in the query
+	Operator string   // operator used by the query
+	Allowed  []string // operators the field allows
+	Pos      parser.Position
+}
+
+func (e *OperatorNotAllowedError) Error() string {
+	return fmt.Sprintf("operator %s is not allowed on field %s (allowed: %s) at line %d, column %d",
+		e.Operator, e.Field, strings.Join(e.Allowed, ", "), e.Pos.Line, e.Pos.Column)
+}
+
+// HashedFieldError is returned when a query uses an operator other than an exact match on a
+// field stored as hashes, which can only be compared whole
+type HashedFieldError struct {
+	Field    string // field as named in the query
+	Operator string // operator used by the query
+	Pos      parser.Position
+}
+
+func (e *HashedFieldError) Error() string {
+	return fmt.Sprintf("operator %s cannot match hashed field %s, which only supports exact matches, at line %d, column %d",
+		e.Operator, e.Field, e.Pos.Line, e.Pos.Column)
+}
+
+// CheckOperators returns an *OperatorNotAllowedError for the first clause that uses an
+// operator its field does not allow, or a *HashedFieldError when the field is hashed and
+// the operator does not match whole values. Standalone terms are checked against the default
+// field. Unknown fields are left for the translators to report.
+func CheckOperators(ast parser.Node, s *schema.Schema) error {
+	return checkOperators(ast, s, s.Options.DefaultField)
+}
+
+// checkOperators checks a node whose standalone terms query defaultField
+func checkOperators(node parser.Node, s *schema.Schema, defaultField string) error {
+	switch n := node.(type) {
+	case *parser.BinaryOp:
+		if err := checkOperators(n.Left, s, defaultField); err != nil {
+			return err
+		}
+		return checkOperators(n.Right, s, defaultField)
+	case *parser.UnaryOp:
+		return checkOperators(n.Operand, s, defaultField)
+	case *parser.GroupQuery:
+		return checkOperators(n.Query, s, defaultField)
+	case *parser.RequiredQuery:
+		return checkOperators(n.Query, s, defaultField)
+	case *parser.ProhibitedQuery:
+		return checkOperators(n.Query, s, defaultField)
+	case *parser.BoostQuery:
+		return checkOperators(n.Query, s, defaultField)
+	case *parser.FieldQuery:
+		return checkOperator(s, n.Field, fieldQueryOperator(n), n.Pos)
+	case *parser.FieldGroupQuery:
+		if err := checkOperator(s, n.Field, OperatorFieldGroup, n.Pos); err != nil {
+			return err
+		}
+		for _, member := range n.Queries {
+			if err := checkFieldGroupMember(member, s, n.Field, defaultField); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *parser.InListQuery:
+		return checkOperator(s, n.Field, OperatorIn, n.Pos)
+	case *parser.RangeQuery:
+		return checkOperator(s, n.Field, OperatorRange, n.Pos)
+	case *parser.ExistsQuery:
+		return checkOperator(s, n.Field, OperatorExists, n.Pos)
+	case *parser.FuzzyQuery:
+		return checkOperator(s, fieldOrDefault(n.Field, defaultField), OperatorFuzzy, n.Pos)
+	case *parser.ProximityQuery:
+		return checkOperator(s, fieldOrDefault(n.Field, defaultField), OperatorProximity, n.Pos)
+	case *parser.TermQuery:
+		return checkOperator(s, defaultField, OperatorTerm, n.Pos)
+	case *parser.PhraseQuery:
+		return checkOperator(s, defaultField, OperatorPhrase, n.Pos)
+	case *parser.WildcardQuery:
+		return checkOperator(s, defaultField, OperatorWildcard, n.Pos)
+	}
+	return nil
+}
+
+// checkFieldGroupMember checks a member of field:(...). Terms are covered by the group
+// itself; wildcards match the group's field. Other members translate as standalone clauses.
+func checkFieldGroupMember(node parser.Node, s *schema.Schema, field, defaultField string) error {
+	switch n := node.(type) {
+	case *parser.TermQuery:
+		return nil
+	case *parser.WildcardQuery:
+		return checkOperator(s, field, OperatorWildcard, n.Pos)
+	case *parser.BinaryOp:
+		if err := checkFieldGroupMember(n.Left, s, field, defaultField); err != nil {
+			return err
+		}
+		return checkFieldGroupMember(n.Right, s, field, defaultField)
+	}
+	return checkOperators(node, s, defaultField)
+}
+
+// checkOperator checks a single operator use against the field's whitelist
+func checkOperator(s *schema.Schema, fieldName, operator string, pos parser.Position) error {
+	if fieldName == "" {
+		return nil
+	}
+	_, field, err := s.ResolveField(fieldName)
+	if err != nil || field.AllowsOperator(operator) {
+		return nil
+	}
+	if field.Hash != nil && !schema.IsExactOperator(operator) {
+		return &HashedFieldError{Field: fieldName, Operator: operator, Pos: pos}
+	}
+	return &OperatorNotAllowedError{
+		Field:    fieldName,
+		Operator: operator,
+		Allowed:  field.AllowedOperators(),
+		Pos:      pos,
+	}
+}
+
+// fieldQueryOperator returns the operator of a field:value query
+func fieldQueryOperator(fq *parser.FieldQuery) string {
+	switch fq.Value.(type) {
+	case *parser.WildcardValue:
+		return OperatorWildcard
+	case *parser.RegexValue:
+		return OperatorRegex
+	case *parser.PhraseValue:
+		return OperatorPhrase
+	}
+	return OperatorTerm
+}
+
+func fieldOrDefault(field, defaultField string) string {
+	if field != "" {
+		return field
+	}
+	return defaultField
+}
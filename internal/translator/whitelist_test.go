@@ -0,0 +1,82 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckOperators(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"status":      {Type: schema.TypeText, Operators: []string{"term", "field_group"}},
+		"description": {Type: schema.TypeText, PhraseMatch: schema.PhraseFulltext, Operators: []string{"phrase"}},
+		"name":        {Type: schema.TypeText, Aliases: []string{"title"}, Operators: []string{"term", "phrase", "fuzzy"}},
+		"price":       {Type: schema.TypeFloat},
+	}, schema.SchemaOptions{DefaultField: "name"})
+
+	tests := []struct {
+		query    string
+		field    string
+		operator string
+	}{
+		{query: "status:active"},
+		{query: "status:(active OR pending)"},
+		{query: `description:"blue widget"`},
+		{query: "price:[10 TO 20] AND NOT status:sold"},
+		{query: "laptop~1 OR title:laptop"},
+		{query: "unknown:x*"},
+		{query: "status:act*", field: "status", operator: "wildcard"},
+		{query: "status:[a TO m]", field: "status", operator: "range"},
+		{query: "status:(active OR pend*)", field: "status", operator: "wildcard"},
+		{query: "_exists_:status", field: "status", operator: "exists"},
+		{query: "description:widget", field: "description", operator: "term"},
+		{query: "price:>5 AND title:/lap.*/", field: "title", operator: "regex"},
+		{query: "lap*", field: "name", operator: "wildcard"},
+		{query: "price:(5,10)"},
+		{query: "status:(active,pending)", field: "status", operator: "in"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ast, err := parser.NewParser(tt.query).Parse()
+			require.NoError(t, err)
+
+			err = CheckOperators(ast, s)
+			if tt.operator == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var opErr *OperatorNotAllowedError
+			require.ErrorAs(t, err, &opErr)
+			assert.Equal(t, tt.field, opErr.Field)
+			assert.Equal(t, tt.operator, opErr.Operator)
+		})
+	}
+}
+
+func TestTranslate_OperatorWhitelist(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"status": {Type: schema.TypeText, Operators: []string{"term"}},
+	}, schema.SchemaOptions{})
+
+	ast, err := parser.NewParser("status:act*").Parse()
+	require.NoError(t, err)
+
+	for _, trans := range []Translator{NewPostgresTranslator(), NewMySQLTranslator(), NewSQLiteTranslator(), NewMongoDBTranslator()} {
+		_, err := trans.Translate(ast, s)
+		var opErr *OperatorNotAllowedError
+		assert.ErrorAs(t, err, &opErr, trans.DatabaseType())
+	}
+	assert.EqualError(t, CheckOperators(ast, s), "operator wildcard is not allowed on field status (allowed: term) at line 1, column 1")
+}
+
+func TestValidateDefaultFilters_OperatorWhitelist(t *testing.T) {
+	s := schema.NewSchema("products", map[string]schema.Field{
+		"deleted_at": {Type: schema.TypeDateTime, Operators: []string{"range"}},
+	}, schema.SchemaOptions{DefaultFilters: []schema.DefaultFilter{{Name: "not_deleted", Query: "NOT _exists_:deleted_at"}}})
+
+	assert.ErrorContains(t, ValidateDefaultFilters(s), "operator exists is not allowed on field deleted_at")
+}
@@ -0,0 +1,37 @@
+package translator
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Wildcard patterns translate to LIKE in the SQL dialects and to a regular expression in
+// MongoDB. In a pattern, * matches any run of characters and ? any single character, so
+// the regex must match every other character, including regex metacharacters, as itself.
+
+// wildcardRegex converts a wildcard pattern to an anchored regular expression matching
+// the same values. Literal runs are quoted with regexp.QuoteMeta, which escapes only
+// ASCII punctuation, an escape every dialect's regex syntax reads as the character
+// itself. The end is anchored with \z, as $ also matches before a final line break in
+// PCRE, and the regex must be matched with the s flag, so that * and ? match line breaks
+// as % and _ do in LIKE.
+func wildcardRegex(pattern string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for {
+		i := strings.IndexAny(pattern, "*?")
+		if i < 0 {
+			break
+		}
+		b.WriteString(regexp.QuoteMeta(pattern[:i]))
+		if pattern[i] == '*' {
+			b.WriteString(".*")
+		} else {
+			b.WriteString(".")
+		}
+		pattern = pattern[i+1:]
+	}
+	b.WriteString(regexp.QuoteMeta(pattern))
+	b.WriteString(`\z`)
+	return b.String()
+}
@@ -0,0 +1,94 @@
+package translator
+
+import (
+	"regexp"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// matchWildcard is the reference matcher for wildcard patterns: * matches any run of
+// characters, ? any single character and every other character itself
+func matchWildcard(pattern, value string) bool {
+	p, v := []rune(pattern), []rune(value)
+	// matched[j] reports whether the pattern read so far matches the first j characters
+	matched := make([]bool, len(v)+1)
+	matched[0] = true
+	for _, r := range p {
+		next := make([]bool, len(v)+1)
+		for j := range matched {
+			switch {
+			case r == '*':
+				next[j] = matched[j] || (j > 0 && next[j-1])
+			case j > 0:
+				next[j] = matched[j-1] && (r == '?' || r == v[j-1])
+			}
+		}
+		matched = next
+	}
+	return matched[len(v)]
+}
+
+func TestWildcardRegex(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{pattern: "lap*", want: `^lap.*\z`},
+		{pattern: "1?w42", want: `^1.w42\z`},
+		{pattern: `a\b*`, want: `^a\\b.*\z`},
+		{pattern: "a.b+(c)*", want: `^a\.b\+\(c\).*\z`},
+		{pattern: "$5^[x]{2}|?", want: `^\$5\^\[x\]\{2\}\|.\z`},
+		{pattern: "*", want: `^.*\z`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			assert.Equal(t, tt.want, wildcardRegex(tt.pattern))
+		})
+	}
+}
+
+func TestWildcardRegex_Matches(t *testing.T) {
+	tests := []struct {
+		pattern, value string
+		want           bool
+	}{
+		{pattern: `a\*`, value: `a\xyz`, want: true},
+		{pattern: `a\*`, value: `a\\`, want: true},
+		{pattern: `a\.`, value: `a\x`, want: false},
+		{pattern: "a.c", value: "abc", want: false},
+		{pattern: "ab*", value: "ab\ncd", want: true},
+		{pattern: "ab", value: "ab\n", want: false},
+		{pattern: "caf?", value: "café", want: true},
+	}
+
+	for _, tt := range tests {
+		re := regexp.MustCompile("(?s)" + wildcardRegex(tt.pattern))
+		assert.Equal(t, tt.want, re.MatchString(tt.value), "%q against %q", tt.pattern, tt.value)
+		assert.Equal(t, tt.want, matchWildcard(tt.pattern, tt.value), "reference: %q against %q", tt.pattern, tt.value)
+	}
+}
+
+func FuzzWildcardRegex(f *testing.F) {
+	f.Add("lap*", "laptop")
+	f.Add(`a\*`, `a\b`)
+	f.Add("a.b?(c)*", "a.bx(c)")
+	f.Add("$^[]{}|+", "$^[]{}|+")
+	f.Add("*?*", "ab\ncd")
+	f.Add("caf?", "café")
+
+	f.Fuzz(func(t *testing.T, pattern, value string) {
+		if !utf8.ValidString(pattern) || !utf8.ValidString(value) {
+			t.Skip()
+		}
+		re, err := regexp.Compile("(?s)" + wildcardRegex(pattern))
+		if err != nil {
+			t.Fatalf("pattern %q converts to invalid regex: %v", pattern, err)
+		}
+		if got, want := re.MatchString(value), matchWildcard(pattern, value); got != want {
+			t.Fatalf("pattern %q against %q: regex %q matched %v, want %v", pattern, value, re, got, want)
+		}
+	})
+}
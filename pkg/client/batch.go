@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// Client can run batches with rsearch.TranslateAll
+var _ rsearch.Translator = (*Client)(nil)
+
+// DefaultBatchConcurrency is the number of requests TranslateBatch keeps in flight by default
+const DefaultBatchConcurrency = 4
+
+// BatchResult is the outcome of one request of a batch
+type BatchResult struct {
+	Index    int // position of the request in the batch
+	Request  *TranslateRequest
+	Response *TranslateResponse
+	Err      error
+}
+
+// TranslateBatch translates requests concurrently and streams each result as soon as it
+// is available, so results arrive out of order; use Index to correlate them. At most
+// concurrency requests are in flight (DefaultBatchConcurrency when not positive). The
+// channel is closed once every request has a result; when ctx is cancelled, requests
+// not yet sent report ctx.Err(). The caller must drain the channel.
+func (c *Client) TranslateBatch(ctx context.Context, requests []*TranslateRequest, concurrency int) <-chan BatchResult {
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	results := make(chan BatchResult, concurrency)
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency && i < len(requests); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				result := BatchResult{Index: index, Request: requests[index]}
+				if err := ctx.Err(); err != nil {
+					result.Err = err
+				} else {
+					result.Response, result.Err = c.Translate(ctx, requests[index])
+				}
+				results <- result
+			}
+		}()
+	}
+
+	go func() {
+		for index := range requests {
+			indexes <- index
+		}
+		close(indexes)
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateBatch(t *testing.T) {
+	server := newTestServer(t)
+	c, err := New(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = c.RegisterSchema(ctx, &Schema{Name: "products", Fields: map[string]Field{"name": {Type: TypeText}}})
+	require.NoError(t, err)
+
+	requests := []*TranslateRequest{
+		{Schema: "products", Database: "postgres", Query: "name:a"},
+		{Schema: "products", Database: "postgres", Query: "name:b OR name:c"},
+		{Schema: "missing", Database: "postgres", Query: "name:a"},
+		{Schema: "products", Database: "mongodb", Query: "name:d"},
+		{Schema: "products", Database: "postgres", Query: "name:e"},
+	}
+
+	results := make(map[int]BatchResult)
+	for result := range c.TranslateBatch(ctx, requests, 2) {
+		results[result.Index] = result
+	}
+	require.Len(t, results, len(requests))
+
+	for i, result := range results {
+		assert.Same(t, requests[i], result.Request)
+	}
+	assert.Equal(t, "name = $1 OR name = $2", results[1].Response.WhereClause)
+	assert.True(t, IsNotFound(results[2].Err))
+	assert.Equal(t, "mongodb", results[3].Response.Type)
+	assert.NoError(t, results[4].Err)
+
+	// An empty batch closes the channel right away
+	for range c.TranslateBatch(ctx, nil, 0) {
+		t.Fatal("unexpected result")
+	}
+}
+
+func TestTranslateBatch_Cancelled(t *testing.T) {
+	server := newTestServer(t)
+	c, err := New(server.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	requests := []*TranslateRequest{{Schema: "s", Database: "postgres", Query: "a"}, {Schema: "s", Database: "postgres", Query: "b"}}
+	count := 0
+	for result := range c.TranslateBatch(ctx, requests, 1) {
+		assert.ErrorIs(t, result.Err, context.Canceled)
+		count++
+	}
+	assert.Equal(t, 2, count)
+}
@@ -0,0 +1,426 @@
+// Package client is a typed Go client for the rsearch REST API.
+//
+// Request and response types are the server's own, so they cannot drift:
+//
+//	c, err := client.New("http://localhost:8080", client.WithTimeout(5*time.Second))
+//	resp, err := c.Translate(ctx, &client.TranslateRequest{
+//		Schema:   "products",
+//		Database: "postgres",
+//		Query:    "name:laptop AND price:[500 TO 2000]",
+//	})
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// Types shared with the server
+type (
+	Schema            = schema.Schema
+	Field             = schema.Field
+	FieldType         = schema.FieldType
+	SchemaOptions     = schema.SchemaOptions
+	EnabledFeatures   = schema.EnabledFeatures
+	DefaultFilter     = schema.DefaultFilter
+	TranslateRequest  = rsearch.TranslateRequest
+	TranslateResponse = rsearch.TranslateResponse
+	FieldsResponse    = rsearch.FieldsResponse
+	SuggestRequest    = rsearch.SuggestValuesRequest
+	SuggestResponse   = rsearch.SuggestValuesResponse
+	FieldSuggestions  = rsearch.FieldSuggestionsResponse
+	FieldDoc          = rsearch.FieldDoc
+	FieldUse          = rsearch.FieldUse
+	HealthResponse    = rsearch.HealthResponse
+	Warning           = rsearch.Warning
+)
+
+// Field types
+const (
+	TypeText     = schema.TypeText
+	TypeInteger  = schema.TypeInteger
+	TypeFloat    = schema.TypeFloat
+	TypeBoolean  = schema.TypeBoolean
+	TypeDateTime = schema.TypeDateTime
+	TypeDate     = schema.TypeDate
+	TypeTime     = schema.TypeTime
+	TypeJSON     = schema.TypeJSON
+	TypeArray    = schema.TypeArray
+)
+
+// Defaults used by New
+const (
+	DefaultTimeout    = 30 * time.Second
+	DefaultMaxRetries = 3
+	DefaultMinBackoff = 100 * time.Millisecond
+	DefaultMaxBackoff = 5 * time.Second
+)
+
+// RequestIDHeader carries the ID correlating a request with the server's logs
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a context whose calls send requestID in RequestIDHeader,
+// so a caller can use its own request ID and find the calls in the server's logs.
+// Without one the server generates an ID, returned in Error.RequestID on failure.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// Client calls the rsearch REST API. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	timeout    time.Duration
+	apiKey     string
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTimeout sets the timeout of each attempt, including reading the response body;
+// zero disables it. The context passed to each call bounds all attempts together.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithAPIKey sends an API key with every request as X-API-Key
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithRetries sets how often a failed idempotent request is retried; zero disables retries
+func WithRetries(maxRetries int) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+	}
+}
+
+// WithBackoff sets the delay before the first retry and the cap of the exponential backoff
+func WithBackoff(min, max time.Duration) Option {
+	return func(c *Client) {
+		c.minBackoff = min
+		c.maxBackoff = max
+	}
+}
+
+// New creates a client for the server at baseURL, e.g. "http://localhost:8080"
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme must be http or https", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		timeout:    DefaultTimeout,
+		maxRetries: DefaultMaxRetries,
+		minBackoff: DefaultMinBackoff,
+		maxBackoff: DefaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Translate translates a query. Translation has no side effects, so it is retried like a read.
+func (c *Client) Translate(ctx context.Context, req *TranslateRequest) (*TranslateResponse, error) {
+	var resp TranslateResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/translate", req, &resp, true); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RegisterSchema registers a schema and returns it as stored by the server.
+// It is not retried, since a lost response would make the retry fail as a duplicate.
+func (c *Client) RegisterSchema(ctx context.Context, s *Schema) (*Schema, error) {
+	var resp struct {
+		Data *Schema `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/schemas", s, &resp, false); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// ListSchemas returns all registered schemas
+func (c *Client) ListSchemas(ctx context.Context) ([]*Schema, error) {
+	var resp struct {
+		Data []*Schema `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/schemas", nil, &resp, true); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// GetSchema returns a registered schema
+func (c *Client) GetSchema(ctx context.Context, name string) (*Schema, error) {
+	var s Schema
+	if err := c.do(ctx, http.MethodGet, "/api/v1/schemas/"+url.PathEscape(name), nil, &s, true); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// DeleteSchema deletes a registered schema
+func (c *Client) DeleteSchema(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/schemas/"+url.PathEscape(name), nil, nil, true)
+}
+
+// Fields returns the field documentation of a schema
+func (c *Client) Fields(ctx context.Context, name string) (*FieldsResponse, error) {
+	var resp FieldsResponse
+	if err := c.do(ctx, http.MethodGet, "/api/v1/schemas/"+url.PathEscape(name)+"/fields", nil, &resp, true); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SuggestValues returns the query listing distinct values of a field, for autocompletion
+func (c *Client) SuggestValues(ctx context.Context, req *SuggestRequest) (*SuggestResponse, error) {
+	var resp SuggestResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/suggest/values", req, &resp, true); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SuggestFields ranks the fields of a schema a partial or misspelled name may refer to.
+// A limit of zero uses the server's default.
+func (c *Client) SuggestFields(ctx context.Context, schemaName, partial string, limit int) (*FieldSuggestions, error) {
+	query := url.Values{"schema": {schemaName}, "q": {partial}}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var resp FieldSuggestions
+	if err := c.do(ctx, http.MethodGet, "/api/v1/suggest/fields?"+query.Encode(), nil, &resp, true); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Health checks whether the server is healthy
+func (c *Client) Health(ctx context.Context) (*HealthResponse, error) {
+	var resp HealthResponse
+	if err := c.do(ctx, http.MethodGet, "/health", nil, &resp, true); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// do sends a request, retrying transport errors and retryable statuses when retry is set.
+// body is encoded as JSON; a 2xx response is decoded into out unless out is nil.
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}, retry bool) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		delay, err := c.attempt(ctx, method, path, payload, out)
+		if err == nil || !retry || attempt >= c.maxRetries || delay < 0 {
+			return err
+		}
+
+		if backoff := c.backoff(attempt); delay < backoff {
+			delay = backoff
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt sends one request. On failure it returns the minimum delay before a retry,
+// or a negative delay when the failure is not retryable.
+func (c *Client) attempt(ctx context.Context, method, path string, payload []byte, out interface{}) (time.Duration, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	attemptCtx := ctx
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(attemptCtx, method, c.baseURL.String()+path, body)
+	if err != nil {
+		return -1, fmt.Errorf("failed to create request: %w", err)
+	}
+	// Pin the translate response format this client was written against
+	req.Header.Set("Accept", rsearch.FormatMediaType(rsearch.FormatLatest)+", application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if requestID, _ := ctx.Value(requestIDKey{}).(string); requestID != "" {
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return -1, ctx.Err()
+		}
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := NewError(resp.StatusCode, data)
+		if apiErr.RequestID == "" {
+			apiErr.RequestID = resp.Header.Get(RequestIDHeader)
+		}
+		if !apiErr.Temporary() {
+			return -1, apiErr
+		}
+		return retryAfter(resp.Header.Get("Retry-After")), apiErr
+	}
+
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return -1, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return 0, nil
+}
+
+// backoff returns the exponential delay before retry number attempt+1
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.minBackoff
+	for i := 0; i < attempt && delay < c.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > c.maxBackoff {
+		delay = c.maxBackoff
+	}
+	return delay
+}
+
+// retryAfter parses a Retry-After header given in seconds
+func retryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Error is a non-2xx response from the server
+type Error struct {
+	StatusCode int
+	Code       string // error code such as SCHEMA_NOT_FOUND; empty for endpoints without codes
+	Message    string
+	RequestID  string // ID of the failed request in the server's logs
+}
+
+func (e *Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("rsearch: %d %s: %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("rsearch: %d: %s", e.StatusCode, e.Message)
+}
+
+// Temporary reports whether the request may succeed when retried
+func (e *Error) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// IsNotFound reports whether err is a 404 response, such as an unknown schema
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// NewError decodes an error body, which is either {"error": {"code", "message"}}
+// or {"error": "...", "message": "..."} depending on the endpoint
+func NewError(statusCode int, body []byte) *Error {
+	apiErr := &Error{StatusCode: statusCode}
+
+	var envelope struct {
+		Error     json.RawMessage `json:"error"`
+		Message   string          `json:"message"`
+		RequestID string          `json:"requestId"`
+	}
+	if err := json.Unmarshal(body, &envelope); err == nil && len(envelope.Error) > 0 {
+		var detail rsearch.ErrorDetail
+		var text string
+		switch {
+		case json.Unmarshal(envelope.Error, &detail) == nil:
+			apiErr.Code = detail.Code
+			apiErr.Message = detail.Message
+			apiErr.RequestID = detail.RequestID
+		case json.Unmarshal(envelope.Error, &text) == nil:
+			apiErr.Message = text
+			apiErr.RequestID = envelope.RequestID
+			if envelope.Message != "" {
+				apiErr.Message = envelope.Message
+			}
+		}
+	}
+
+	if apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(statusCode)
+	}
+	return apiErr
+}
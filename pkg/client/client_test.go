@@ -0,0 +1,191 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/infiniv/rsearch/internal/api"
+	"github.com/infiniv/rsearch/internal/config"
+	"github.com/infiniv/rsearch/internal/observability"
+	"github.com/infiniv/rsearch/internal/ratelimit"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// newTestServer runs the real API routes
+func newTestServer(t *testing.T) *httptest.Server {
+	cfg := &config.Config{
+		Logging:  config.LoggingConfig{Level: "error", Format: "json", Output: "stdout"},
+		Features: config.FeaturesConfig{RequestIDHeader: "X-Request-ID"},
+	}
+	logger, err := observability.NewLogger(cfg.Logging.Level, cfg.Logging.Format, cfg.Logging.Output)
+	require.NoError(t, err)
+
+	translatorRegistry := translator.NewRegistry()
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+	translatorRegistry.Register("mongodb", translator.NewMongoDBTranslator())
+
+	rateLimiter := ratelimit.NewRateLimiter(100, 10)
+	t.Cleanup(rateLimiter.Stop)
+
+	server := httptest.NewServer(api.SetupRoutes(cfg, logger, nil, schema.NewRegistry(), translatorRegistry, rateLimiter, nil, nil, nil, nil))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_API(t *testing.T) {
+	server := newTestServer(t)
+	c, err := New(server.URL + "/")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	health, err := c.Health(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "healthy", health.Status)
+
+	registered, err := c.RegisterSchema(ctx, &Schema{
+		Name: "products",
+		Fields: map[string]Field{
+			"name":  {Type: TypeText, Description: "Product name"},
+			"price": {Type: TypeFloat},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "products", registered.Name)
+
+	_, err = c.RegisterSchema(ctx, &Schema{Name: "products", Fields: map[string]Field{"name": {Type: TypeText}}})
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+
+	schemas, err := c.ListSchemas(ctx)
+	require.NoError(t, err)
+	require.Len(t, schemas, 1)
+
+	s, err := c.GetSchema(ctx, "products")
+	require.NoError(t, err)
+	assert.Equal(t, TypeFloat, s.Fields["price"].Type)
+
+	fields, err := c.Fields(ctx, "products")
+	require.NoError(t, err)
+	require.Len(t, fields.Fields, 2)
+	assert.Equal(t, "Product name", fields.Fields[0].Description)
+
+	resp, err := c.Translate(ctx, &TranslateRequest{Schema: "products", Database: "postgres", Query: "name:laptop AND price:>100"})
+	require.NoError(t, err)
+	assert.Equal(t, "sql", resp.Type)
+	assert.Equal(t, rsearch.FormatLatest, resp.FormatVersion)
+	assert.Equal(t, "name = $1 AND price > $2", resp.WhereClause)
+	require.Len(t, resp.Parameters, 2)
+	assert.Equal(t, "laptop", resp.Parameters[0])
+
+	suggestion, err := c.SuggestValues(ctx, &SuggestRequest{Schema: "products", Database: "postgres", Field: "name", Prefix: "lap"})
+	require.NoError(t, err)
+	assert.Equal(t, "DISTINCT name AS value", suggestion.SelectClause)
+	assert.Equal(t, "name LIKE $1", suggestion.WhereClause)
+
+	fieldSuggestions, err := c.SuggestFields(ctx, "products", "nmae", 0)
+	require.NoError(t, err)
+	require.Len(t, fieldSuggestions.Fields, 1)
+	assert.Equal(t, "name", fieldSuggestions.Fields[0].Field)
+
+	resp, err = c.Translate(ctx, &TranslateRequest{Schema: "products", Database: "mongodb", Query: "name:laptop"})
+	require.NoError(t, err)
+	assert.Equal(t, "mongodb", resp.Type)
+	assert.Equal(t, map[string]interface{}{"name": "laptop"}, resp.Filter)
+
+	// Extended JSON filters decode with their BSON types
+	resp, err = c.Translate(ctx, &TranslateRequest{Schema: "products", Database: "mongodb", Query: "price:100", FilterFormat: rsearch.FilterFormatExtendedJSON})
+	require.NoError(t, err)
+	filter, err := BSONFilter(resp)
+	require.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "price", Value: 100.0}}, filter)
+
+	// Legacy error bodies carry only a message
+	_, err = c.Translate(ctx, &TranslateRequest{Schema: "missing", Database: "postgres", Query: "a:b"})
+	assert.True(t, IsNotFound(err))
+	assert.EqualError(t, err, "rsearch: 404: Schema not found: missing")
+
+	// Coded error bodies carry a code
+	_, err = c.Fields(ctx, "missing")
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "SCHEMA_NOT_FOUND", apiErr.Code)
+	assert.NotEmpty(t, apiErr.RequestID)
+
+	// Errors report the caller's request ID, to find them in the server's logs
+	_, err = c.Translate(ContextWithRequestID(ctx, "checkout-7f3a"), &TranslateRequest{Schema: "missing", Database: "postgres", Query: "a:b"})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "checkout-7f3a", apiErr.RequestID)
+
+	require.NoError(t, c.DeleteSchema(ctx, "products"))
+	_, err = c.GetSchema(ctx, "products")
+	assert.True(t, IsNotFound(err))
+}
+
+func TestClient_Retries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"status":"healthy","version":"1.0.0"}`)
+	}))
+	defer server.Close()
+
+	c, err := New(server.URL, WithBackoff(time.Millisecond, 5*time.Millisecond))
+	require.NoError(t, err)
+
+	health, err := c.Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "healthy", health.Status)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// Retries are bounded
+	calls.Store(-10)
+	c, err = New(server.URL, WithRetries(1), WithBackoff(time.Millisecond, time.Millisecond))
+	require.NoError(t, err)
+	_, err = c.Health(context.Background())
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Equal(t, int32(-8), calls.Load())
+
+	// Schema registration is not retried
+	calls.Store(0)
+	_, err = c.RegisterSchema(context.Background(), &Schema{Name: "s"})
+	require.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestClient_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	c, err := New(server.URL, WithTimeout(10*time.Millisecond), WithRetries(0))
+	require.NoError(t, err)
+
+	_, err = c.Health(context.Background())
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestNew_InvalidURL(t *testing.T) {
+	_, err := New("localhost:8080")
+	assert.Error(t, err)
+}
@@ -0,0 +1,27 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// BSONFilter decodes the MongoDB filter of a translation into a bson.D for the official
+// driver. Request it with FilterFormat rsearch.FilterFormatExtendedJSON so dates decode
+// as bson.DateTime and integers as int64; a plain filter decodes as relaxed Extended JSON.
+func BSONFilter(resp *TranslateResponse) (bson.D, error) {
+	if resp.Filter == nil {
+		return nil, errors.New("response has no MongoDB filter")
+	}
+	data, err := json.Marshal(resp.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode filter: %w", err)
+	}
+	var filter bson.D
+	if err := bson.UnmarshalExtJSON(data, false, &filter); err != nil {
+		return nil, fmt.Errorf("failed to decode filter: %w", err)
+	}
+	return filter, nil
+}
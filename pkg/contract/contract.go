@@ -0,0 +1,230 @@
+// Package contract lets services using rsearch pin the translations they depend on in
+// their own tests, so that an rsearch upgrade changing a translation fails their CI
+// rather than their production queries. Contracts are checked against an rsearch
+// embedded in the test process, built from the same module version the service uses:
+//
+//	func TestSearchContracts(t *testing.T) {
+//		srv := contract.NewServer(t)
+//		srv.RegisterSchemaFile(t, "testdata/products.json")
+//		srv.Verify(t, contract.Contract{
+//			Schema:        "products",
+//			Database:      "postgres",
+//			Query:         "name:laptop AND price:[500 TO 2000]",
+//			WhereContains: []string{"price BETWEEN"},
+//			Params:        contract.Count(3),
+//		})
+//		srv.VerifyFile(t, "testdata/contracts.yaml")
+//	}
+package contract
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/infiniv/rsearch/internal/api"
+	"github.com/infiniv/rsearch/internal/migration"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/infiniv/rsearch/pkg/client"
+	"go.yaml.in/yaml/v3"
+)
+
+// Contract is the expected translation of a query. Expectations left empty are not
+// checked, so a contract pins only what its service relies on.
+type Contract struct {
+	Name     string `json:"name,omitempty" yaml:"name,omitempty"` // subtest name; the query when empty
+	Schema   string `json:"schema" yaml:"schema"`
+	Database string `json:"database" yaml:"database"`
+	Query    string `json:"query" yaml:"query"`
+
+	Where         string        `json:"where,omitempty" yaml:"where,omitempty"`                 // the whole SQL WHERE clause
+	WhereContains []string      `json:"whereContains,omitempty" yaml:"whereContains,omitempty"` // parts of the SQL WHERE clause
+	Params        *int          `json:"params,omitempty" yaml:"params,omitempty"`               // number of bound parameters
+	Parameters    []interface{} `json:"parameters,omitempty" yaml:"parameters,omitempty"`       // bound parameters, compared as JSON
+	Filter        interface{}   `json:"filter,omitempty" yaml:"filter,omitempty"`               // MongoDB filter, compared as JSON
+	ErrorCode     string        `json:"errorCode,omitempty" yaml:"errorCode,omitempty"`         // the query must fail with this code
+}
+
+// Count returns a pointer to n, for Contract.Params
+func Count(n int) *int {
+	return &n
+}
+
+// name returns the subtest name of the contract
+func (c Contract) name() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Query
+}
+
+// Check compares a translation with the contract and returns how it differs, or nothing
+// when the contract holds
+func (c Contract) Check(resp *client.TranslateResponse, err error) []string {
+	if c.ErrorCode != "" {
+		var apiErr *client.Error
+		switch {
+		case err == nil:
+			return []string{fmt.Sprintf("expected error %s, got a translation", c.ErrorCode)}
+		case !errors.As(err, &apiErr):
+			return []string{fmt.Sprintf("expected error %s, got %v", c.ErrorCode, err)}
+		case apiErr.Code != c.ErrorCode:
+			return []string{fmt.Sprintf("expected error %s, got %s: %s", c.ErrorCode, apiErr.Code, apiErr.Message)}
+		}
+		return nil
+	}
+	if err != nil {
+		return []string{fmt.Sprintf("translation failed: %v", err)}
+	}
+
+	var failures []string
+	if c.Where != "" && resp.WhereClause != c.Where {
+		failures = append(failures, fmt.Sprintf("where clause is %q, expected %q", resp.WhereClause, c.Where))
+	}
+	for _, part := range c.WhereContains {
+		if !strings.Contains(resp.WhereClause, part) {
+			failures = append(failures, fmt.Sprintf("where clause %q does not contain %q", resp.WhereClause, part))
+		}
+	}
+	if c.Params != nil && len(resp.Parameters) != *c.Params {
+		failures = append(failures, fmt.Sprintf("binds %d parameters, expected %d", len(resp.Parameters), *c.Params))
+	}
+	if c.Parameters != nil && !jsonEqual(resp.Parameters, c.Parameters) {
+		failures = append(failures, fmt.Sprintf("binds %s, expected %s", jsonString(resp.Parameters), jsonString(c.Parameters)))
+	}
+	if c.Filter != nil && !jsonEqual(resp.Filter, c.Filter) {
+		failures = append(failures, fmt.Sprintf("filter is %s, expected %s", jsonString(resp.Filter), jsonString(c.Filter)))
+	}
+	return failures
+}
+
+// jsonEqual compares two values by their JSON encodings, so that 1 and 1.0 or a struct and
+// the map decoded from a contract file are equal
+func jsonEqual(a, b interface{}) bool {
+	var da, db interface{}
+	if json.Unmarshal([]byte(jsonString(a)), &da) != nil || json.Unmarshal([]byte(jsonString(b)), &db) != nil {
+		return false
+	}
+	return reflect.DeepEqual(da, db)
+}
+
+func jsonString(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+// Load reads a list of contracts from a JSON or YAML file
+func Load(path string) ([]Contract, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read contracts: %w", err)
+	}
+
+	var contracts []Contract
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		err = yaml.Unmarshal(data, &contracts)
+	} else {
+		err = json.Unmarshal(data, &contracts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid contracts in %s: %w", path, err)
+	}
+	return contracts, nil
+}
+
+// Server is an rsearch embedded in a test: the schema and translate endpoints, with
+// every built-in translator, served over HTTP
+type Server struct {
+	// URL of the server, for services calling rsearch themselves
+	URL string
+	// Client calls the server without retries
+	Client *client.Client
+}
+
+// NewServer starts an embedded rsearch, stopped when the test ends
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+	translatorRegistry.Register("mysql", translator.NewMySQLTranslator())
+	translatorRegistry.Register("sqlite", translator.NewSQLiteTranslator())
+	translatorRegistry.Register("mongodb", translator.NewMongoDBTranslator())
+
+	schemaHandler := api.NewHandler(schemaRegistry)
+	r := chi.NewRouter()
+	r.Post("/api/v1/schemas", schemaHandler.RegisterSchema)
+	r.Get("/api/v1/schemas", schemaHandler.ListSchemas)
+	r.Get("/api/v1/schemas/{name}", schemaHandler.GetSchema)
+	r.Get("/api/v1/schemas/{name}/fields", api.NewFieldsHandler(schemaRegistry, translatorRegistry).ServeHTTP)
+	r.Post("/api/v1/translate", api.NewTranslateHandler(schemaRegistry, translatorRegistry).ServeHTTP)
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	c, err := client.New(server.URL, client.WithRetries(0), client.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("contract: %v", err)
+	}
+	return &Server{URL: server.URL, Client: c}
+}
+
+// RegisterSchema registers a schema, failing the test when it is rejected
+func (s *Server) RegisterSchema(t testing.TB, sch *client.Schema) {
+	t.Helper()
+	if _, err := s.Client.RegisterSchema(context.Background(), sch); err != nil {
+		t.Fatalf("contract: failed to register schema %s: %v", sch.Name, err)
+	}
+}
+
+// RegisterSchemaFile registers a schema read from a JSON or YAML file, in the format of
+// the server's schema directory
+func (s *Server) RegisterSchemaFile(t testing.TB, path string) {
+	t.Helper()
+	sch, err := migration.LoadSchema(path)
+	if err != nil {
+		t.Fatalf("contract: %v", err)
+	}
+	s.RegisterSchema(t, sch)
+}
+
+// Verify translates the query of each contract in a subtest, which fails with every
+// difference from the contract
+func (s *Server) Verify(t *testing.T, contracts ...Contract) {
+	t.Helper()
+	for _, c := range contracts {
+		t.Run(c.name(), func(t *testing.T) {
+			resp, err := s.Client.Translate(context.Background(), &client.TranslateRequest{
+				Schema:   c.Schema,
+				Database: c.Database,
+				Query:    c.Query,
+			})
+			for _, failure := range c.Check(resp, err) {
+				t.Error(failure)
+			}
+		})
+	}
+}
+
+// VerifyFile verifies the contracts of a JSON or YAML file, see Load
+func (s *Server) VerifyFile(t *testing.T, path string) {
+	t.Helper()
+	contracts, err := Load(path)
+	if err != nil {
+		t.Fatalf("contract: %v", err)
+	}
+	s.Verify(t, contracts...)
+}
@@ -0,0 +1,58 @@
+package contract
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/infiniv/rsearch/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Verify(t *testing.T) {
+	srv := NewServer(t)
+	srv.RegisterSchemaFile(t, "testdata/products.json")
+
+	srv.Verify(t, Contract{
+		Schema:        "products",
+		Database:      "postgres",
+		Query:         "name:laptop AND price:>500",
+		WhereContains: []string{"name = $1", "price > $2"},
+		Params:        Count(2),
+	})
+	srv.VerifyFile(t, "testdata/contracts.yaml")
+}
+
+func TestContract_Check(t *testing.T) {
+	resp := &client.TranslateResponse{
+		WhereClause: "price BETWEEN $1 AND $2",
+		Parameters:  []interface{}{float64(500), float64(2000)},
+	}
+
+	assert.Empty(t, Contract{Where: "price BETWEEN $1 AND $2", Parameters: []interface{}{500, 2000}, Params: Count(2)}.Check(resp, nil))
+	assert.Equal(t, []string{
+		`where clause "price BETWEEN $1 AND $2" does not contain "price >"`,
+		"binds 2 parameters, expected 1",
+		"binds [500,2000], expected [500,1000]",
+	}, Contract{WhereContains: []string{"price >"}, Params: Count(1), Parameters: []interface{}{500, 1000}}.Check(resp, nil))
+
+	apiErr := &client.Error{StatusCode: http.StatusBadRequest, Code: "FIELD_NOT_FOUND", Message: "field not found"}
+	assert.Empty(t, Contract{ErrorCode: "FIELD_NOT_FOUND"}.Check(nil, apiErr))
+	assert.Equal(t, []string{"expected error PARSE_ERROR, got FIELD_NOT_FOUND: field not found"},
+		Contract{ErrorCode: "PARSE_ERROR"}.Check(nil, apiErr))
+	assert.Equal(t, []string{"expected error PARSE_ERROR, got a translation"}, Contract{ErrorCode: "PARSE_ERROR"}.Check(resp, nil))
+	assert.Equal(t, []string{"translation failed: connection refused"}, Contract{}.Check(nil, errors.New("connection refused")))
+}
+
+func TestLoad(t *testing.T) {
+	contracts, err := Load("testdata/contracts.yaml")
+	require.NoError(t, err)
+	require.Len(t, contracts, 3)
+	assert.Equal(t, "price range", contracts[0].Name)
+	assert.Equal(t, "status:active", contracts[1].name())
+	assert.Equal(t, "OPERATOR_NOT_ALLOWED", contracts[2].ErrorCode)
+
+	_, err = Load("testdata/missing.yaml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,102 @@
+// Package embedded translates queries inside the calling process, with the schema and
+// translate handlers of an rsearch server but no server or network in between, so batch
+// jobs can translate on every CPU of their own machine:
+//
+//	t := embedded.New()
+//	if err := t.RegisterSchema(sch); err != nil {
+//		return err
+//	}
+//	results, err := rsearch.TranslateAll(ctx, jobs, rsearch.BatchOptions{Translator: t})
+//
+// Translations are those of a server with the same module version and no configuration:
+// every built-in translator, and no plan cache, policies or quotas.
+package embedded
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/infiniv/rsearch/internal/api"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/infiniv/rsearch/pkg/client"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+)
+
+// Translator can run batches with rsearch.TranslateAll
+var _ rsearch.Translator = (*Translator)(nil)
+
+// Translator translates queries against the schemas registered with it. It is safe for
+// concurrent use.
+type Translator struct {
+	register  http.HandlerFunc
+	translate http.Handler
+}
+
+// New returns a translator with every built-in translator and no schemas
+func New() *Translator {
+	schemaRegistry := schema.NewRegistry()
+	translatorRegistry := translator.NewRegistry()
+	translatorRegistry.Register("postgres", translator.NewPostgresTranslator())
+	translatorRegistry.Register("mysql", translator.NewMySQLTranslator())
+	translatorRegistry.Register("sqlite", translator.NewSQLiteTranslator())
+	translatorRegistry.Register("mongodb", translator.NewMongoDBTranslator())
+
+	return &Translator{
+		register:  api.NewHandler(schemaRegistry).RegisterSchema,
+		translate: api.NewTranslateHandler(schemaRegistry, translatorRegistry),
+	}
+}
+
+// RegisterSchema registers a schema, validated as the server's schema endpoint does.
+// A rejected schema returns a *client.Error.
+func (t *Translator) RegisterSchema(s *client.Schema) error {
+	w, err := t.serve(context.Background(), t.register, "/api/v1/schemas", s)
+	if err != nil {
+		return err
+	}
+	if w.Code != http.StatusCreated {
+		return client.NewError(w.Code, w.Body.Bytes())
+	}
+	return nil
+}
+
+// Translate translates a request as the server's translate endpoint does. A rejected
+// request returns a *client.Error, as from a client, so callers handle both alike.
+func (t *Translator) Translate(ctx context.Context, req *rsearch.TranslateRequest) (*rsearch.TranslateResponse, error) {
+	w, err := t.serve(ctx, t.translate.ServeHTTP, "/api/v1/translate", req)
+	if err != nil {
+		return nil, err
+	}
+	if w.Code != http.StatusOK {
+		return nil, client.NewError(w.Code, w.Body.Bytes())
+	}
+
+	var resp rsearch.TranslateResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &resp, nil
+}
+
+// serve calls a handler with a POST of body to path and records its response
+func (t *Translator) serve(ctx context.Context, handler http.HandlerFunc, path string, body interface{}) (*httptest.ResponseRecorder, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept", rsearch.FormatMediaType(rsearch.FormatLatest))
+
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w, nil
+}
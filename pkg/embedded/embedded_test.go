@@ -0,0 +1,59 @@
+package embedded
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/infiniv/rsearch/pkg/client"
+	"github.com/infiniv/rsearch/pkg/rsearch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslator_TranslateAll(t *testing.T) {
+	translator := New()
+	require.NoError(t, translator.RegisterSchema(&client.Schema{
+		Name: "products",
+		Fields: map[string]client.Field{
+			"name":  {Type: client.TypeText},
+			"price": {Type: client.TypeFloat},
+		},
+	}))
+
+	jobs := []rsearch.Job{
+		{Request: &rsearch.TranslateRequest{Schema: "products", Database: "postgres", Query: "name:laptop AND price:>500"}},
+		{Request: &rsearch.TranslateRequest{Schema: "products", Database: "mongodb", Query: "name:laptop"}},
+		{Request: &rsearch.TranslateRequest{Schema: "products", Database: "postgres", Query: "password:x"}},
+		{Request: &rsearch.TranslateRequest{Schema: "orders", Database: "postgres", Query: "id:1"}},
+	}
+	results, err := rsearch.TranslateAll(context.Background(), jobs, rsearch.BatchOptions{Translator: translator, Workers: 2})
+	require.NoError(t, err)
+	require.Len(t, results, len(jobs))
+
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, "name = $1 AND price > $2", results[0].Response.WhereClause)
+	assert.Equal(t, []interface{}{"laptop", float64(500)}, results[0].Response.Parameters)
+
+	require.NoError(t, results[1].Err)
+	assert.Equal(t, map[string]interface{}{"name": "laptop"}, results[1].Response.Filter)
+
+	// Rejected requests fail as they do through a client
+	var apiErr *client.Error
+	require.True(t, errors.As(results[2].Err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Contains(t, apiErr.Message, "password")
+	assert.True(t, client.IsNotFound(results[3].Err))
+}
+
+func TestTranslator_RegisterSchemaRejected(t *testing.T) {
+	translator := New()
+	sch := &client.Schema{Name: "products", Fields: map[string]client.Field{"name": {Type: client.TypeText}}}
+	require.NoError(t, translator.RegisterSchema(sch))
+
+	var apiErr *client.Error
+	require.True(t, errors.As(translator.RegisterSchema(sch), &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Contains(t, apiErr.Message, "already exists")
+}
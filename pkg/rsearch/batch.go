@@ -0,0 +1,84 @@
+package rsearch
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// Translator translates a single request. *client.Client implements it over the REST API,
+// and *embedded.Translator in the calling process.
+type Translator interface {
+	Translate(ctx context.Context, req *TranslateRequest) (*TranslateResponse, error)
+}
+
+// TranslatorFunc adapts a function to a Translator
+type TranslatorFunc func(ctx context.Context, req *TranslateRequest) (*TranslateResponse, error)
+
+// Translate calls f(ctx, req)
+func (f TranslatorFunc) Translate(ctx context.Context, req *TranslateRequest) (*TranslateResponse, error) {
+	return f(ctx, req)
+}
+
+// Job is one request of a batch
+type Job struct {
+	Request *TranslateRequest
+}
+
+// JobResult is the outcome of the job at the same position of a batch
+type JobResult struct {
+	Response *TranslateResponse
+	Err      error
+}
+
+// BatchOptions configures TranslateAll
+type BatchOptions struct {
+	Translator Translator // required
+	Workers    int        // jobs translated at once, GOMAXPROCS when not positive
+}
+
+// ErrNoTranslator is returned by TranslateAll when the options have no translator
+var ErrNoTranslator = errors.New("batch options have no translator")
+
+// TranslateAll translates jobs on a pool of workers and returns one result per job, in
+// the order of the jobs. A failed job only sets the Err of its own result. When ctx is
+// cancelled, jobs not yet started report ctx.Err().
+func TranslateAll(ctx context.Context, jobs []Job, opts BatchOptions) ([]JobResult, error) {
+	if opts.Translator == nil {
+		return nil, ErrNoTranslator
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	results := make([]JobResult, len(jobs))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				if err := ctx.Err(); err != nil {
+					results[index].Err = err
+					continue
+				}
+				results[index].Response, results[index].Err = opts.Translator.Translate(ctx, jobs[index].Request)
+			}
+		}()
+	}
+
+	for index := range jobs {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results, nil
+}
@@ -0,0 +1,84 @@
+package rsearch
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateAll(t *testing.T) {
+	var inFlight, maxInFlight int32
+	translator := TranslatorFunc(func(ctx context.Context, req *TranslateRequest) (*TranslateResponse, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+
+		if strings.HasPrefix(req.Query, "bad") {
+			return nil, errors.New("cannot translate " + req.Query)
+		}
+		return &TranslateResponse{Type: req.Database, WhereClause: req.Query}, nil
+	})
+
+	queries := []string{"a", "b", "bad1", "c", "d", "bad2", "e", "f", "g", "h"}
+	jobs := make([]Job, len(queries))
+	for i, q := range queries {
+		jobs[i] = Job{Request: &TranslateRequest{Schema: "products", Database: "postgres", Query: q}}
+	}
+
+	results, err := TranslateAll(context.Background(), jobs, BatchOptions{Translator: translator, Workers: 3})
+	require.NoError(t, err)
+	require.Len(t, results, len(jobs))
+
+	// Results follow the jobs, and failures stay with their own job
+	for i, q := range queries {
+		if strings.HasPrefix(q, "bad") {
+			assert.EqualError(t, results[i].Err, "cannot translate "+q)
+			assert.Nil(t, results[i].Response)
+			continue
+		}
+		require.NoError(t, results[i].Err)
+		assert.Equal(t, q, results[i].Response.WhereClause)
+	}
+	assert.LessOrEqual(t, maxInFlight, int32(3))
+
+	// An empty batch returns no results
+	results, err = TranslateAll(context.Background(), nil, BatchOptions{Translator: translator})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestTranslateAll_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var calls int32
+	translator := TranslatorFunc(func(ctx context.Context, req *TranslateRequest) (*TranslateResponse, error) {
+		atomic.AddInt32(&calls, 1)
+		return &TranslateResponse{}, nil
+	})
+
+	jobs := []Job{{Request: &TranslateRequest{Query: "a"}}, {Request: &TranslateRequest{Query: "b"}}}
+	results, err := TranslateAll(ctx, jobs, BatchOptions{Translator: translator})
+	require.NoError(t, err)
+	for _, result := range results {
+		assert.ErrorIs(t, result.Err, context.Canceled)
+	}
+	assert.Zero(t, atomic.LoadInt32(&calls))
+}
+
+func TestTranslateAll_NoTranslator(t *testing.T) {
+	_, err := TranslateAll(context.Background(), []Job{{Request: &TranslateRequest{}}}, BatchOptions{})
+	assert.ErrorIs(t, err, ErrNoTranslator)
+}
@@ -0,0 +1,114 @@
+package rsearch
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// modulePath is the path of the rsearch module, whose version is read from the build
+// information of binaries embedding it
+const modulePath = "github.com/infiniv/rsearch"
+
+// Build details set at link time by release builds, for example with
+//
+//	-ldflags "-X github.com/infiniv/rsearch/pkg/rsearch.commit=$(git rev-parse HEAD)"
+//
+// Builds without them, such as go install, report the module version and the version
+// control stamp the Go toolchain embeds.
+var (
+	version   string
+	commit    string
+	buildDate string
+)
+
+// BuildInfo identifies the build of rsearch that is running, and so its translation
+// behavior
+type BuildInfo struct {
+	Version   string `json:"version"`             // release or module version
+	Commit    string `json:"commit,omitempty"`    // git commit the binary was built from
+	BuildDate string `json:"buildDate,omitempty"` // when the binary, or the commit when unknown, was made
+	Modified  bool   `json:"modified,omitempty"`  // built from a tree with uncommitted changes
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"` // GOOS/GOARCH
+}
+
+// ReadBuildInfo returns the build information of the running binary
+func ReadBuildInfo() BuildInfo {
+	info, _ := debug.ReadBuildInfo()
+	return buildInfo(BuildInfo{Version: version, Commit: commit, BuildDate: buildDate}, info)
+}
+
+// buildInfo completes the details set at link time from the Go build information, which
+// is nil when the binary has none
+func buildInfo(linked BuildInfo, info *debug.BuildInfo) BuildInfo {
+	build := linked
+	build.GoVersion = runtime.Version()
+	build.Platform = runtime.GOOS + "/" + runtime.GOARCH
+	if info == nil {
+		if build.Version == "" {
+			build.Version = Version
+		}
+		return build
+	}
+
+	build.GoVersion = info.GoVersion
+	module := &info.Main
+	if module.Path != modulePath {
+		// rsearch is a dependency of the binary, whose version control stamp is not ours
+		module = nil
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				module = dep
+				break
+			}
+		}
+	}
+	if build.Version == "" && module != nil && module.Version != "" && module.Version != "(devel)" {
+		build.Version = module.Version
+	}
+	if build.Version == "" {
+		build.Version = Version
+	}
+	if module != &info.Main {
+		return build
+	}
+
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if build.Commit == "" {
+				build.Commit = setting.Value
+			}
+		case "vcs.time":
+			if build.BuildDate == "" {
+				build.BuildDate = setting.Value
+			}
+		case "vcs.modified":
+			build.Modified = setting.Value == "true"
+		}
+	}
+	return build
+}
+
+// String formats the build information for logs and the version command, such as
+// "v1.2.0 (commit 1a2b3c4d5e6f, built 2026-01-02T03:04:05Z, go1.24.0 linux/amd64)"
+func (b BuildInfo) String() string {
+	details := make([]string, 0, 3)
+	if b.Commit != "" {
+		commit := b.Commit
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		if b.Modified {
+			commit += "-dirty"
+		}
+		details = append(details, "commit "+commit)
+	}
+	if b.BuildDate != "" {
+		details = append(details, "built "+b.BuildDate)
+	}
+	details = append(details, b.GoVersion+" "+b.Platform)
+	return fmt.Sprintf("%s (%s)", b.Version, strings.Join(details, ", "))
+}
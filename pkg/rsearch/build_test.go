@@ -0,0 +1,79 @@
+package rsearch
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+func TestBuildInfo(t *testing.T) {
+	stamped := &debug.BuildInfo{
+		GoVersion: "go1.24.0",
+		Main:      debug.Module{Path: modulePath, Version: "v1.2.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "1a2b3c4d5e6f7a8b9c0d"},
+			{Key: "vcs.time", Value: "2026-01-02T03:04:05Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+
+	tests := []struct {
+		name   string
+		linked BuildInfo
+		info   *debug.BuildInfo
+		want   BuildInfo
+	}{
+		{
+			name: "version control stamp",
+			info: stamped,
+			want: BuildInfo{Version: "v1.2.0", Commit: "1a2b3c4d5e6f7a8b9c0d", BuildDate: "2026-01-02T03:04:05Z", Modified: true, GoVersion: "go1.24.0", Platform: platform},
+		},
+		{
+			name:   "linked details take precedence",
+			linked: BuildInfo{Version: "v1.3.0", Commit: "ffff", BuildDate: "2026-02-01T00:00:00Z"},
+			info:   stamped,
+			want:   BuildInfo{Version: "v1.3.0", Commit: "ffff", BuildDate: "2026-02-01T00:00:00Z", Modified: true, GoVersion: "go1.24.0", Platform: platform},
+		},
+		{
+			name: "development build",
+			info: &debug.BuildInfo{GoVersion: "go1.24.0", Main: debug.Module{Path: modulePath, Version: "(devel)"}},
+			want: BuildInfo{Version: Version, GoVersion: "go1.24.0", Platform: platform},
+		},
+		{
+			name: "dependency of another binary",
+			info: &debug.BuildInfo{
+				GoVersion: "go1.24.0",
+				Main:      debug.Module{Path: "example.com/app", Version: "v0.1.0"},
+				Deps:      []*debug.Module{{Path: modulePath, Version: "v1.1.0"}},
+				Settings:  []debug.BuildSetting{{Key: "vcs.revision", Value: "abcd"}},
+			},
+			want: BuildInfo{Version: "v1.1.0", GoVersion: "go1.24.0", Platform: platform},
+		},
+		{
+			name: "no build information",
+			want: BuildInfo{Version: Version, GoVersion: runtime.Version(), Platform: platform},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildInfo(tt.linked, tt.info); got != tt.want {
+				t.Errorf("buildInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildInfo_String(t *testing.T) {
+	build := BuildInfo{Version: "v1.2.0", Commit: "1a2b3c4d5e6f7a8b9c0d", BuildDate: "2026-01-02T03:04:05Z", Modified: true, GoVersion: "go1.24.0", Platform: "linux/amd64"}
+	want := "v1.2.0 (commit 1a2b3c4d5e6f-dirty, built 2026-01-02T03:04:05Z, go1.24.0 linux/amd64)"
+	if got := build.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	build = BuildInfo{Version: "1.0.0", GoVersion: "go1.24.0", Platform: "linux/arm64"}
+	if got := build.String(); got != "1.0.0 (go1.24.0 linux/arm64)" {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -1,5 +1,10 @@
 package rsearch
 
+import (
+	"encoding/json"
+	"strconv"
+)
+
 // Version is the current version of rsearch
 const Version = "1.0.0"
 
@@ -9,6 +14,450 @@ type HealthResponse struct {
 	Version string `json:"version"`
 }
 
+// TranslateRequest represents the request body for the translate endpoint
+type TranslateRequest struct {
+	Schema   string   `json:"schema"`
+	Database string   `json:"database"`
+	Query    string   `json:"query"`
+	Fields   []string `json:"fields,omitempty"` // optional projection, validated against the schema
+
+	// SkipDefaultFilters names schema default filters to leave out; requires a bypass API key
+	SkipDefaultFilters []string `json:"skipDefaultFilters,omitempty"`
+
+	// TableAlias qualifies SQL column names, e.g. "p" for p.product_code = $1
+	TableAlias string `json:"tableAlias,omitempty"`
+
+	// Execution limits for the translated query; they can only tighten the schema's limits
+	TimeoutMs int `json:"timeoutMs,omitempty"`
+	MaxRows   int `json:"maxRows,omitempty"`
+
+	// MinSimilarity, between 0 and 1, makes fuzzy terms match by similarity at or above it
+	// instead of by edit distance, overriding the fields' minSimilarity
+	MinSimilarity float64 `json:"minSimilarity,omitempty"`
+
+	// AnchorRegex, when set, overrides the schema's anchorRegex: true makes regex values
+	// match the whole value as in OpenSearch, false lets them match anywhere in it
+	AnchorRegex *bool `json:"anchorRegex,omitempty"`
+
+	// FieldGroupOperator, when set, overrides the schema's fieldGroupOperator: "and" makes
+	// tags:(scala functional) need both values, "or" either of them
+	FieldGroupOperator string `json:"fieldGroupOperator,omitempty"`
+
+	// DisableFeatures turns off query features for this request only, e.g. ["fuzzy"] for
+	// input from untrusted widgets: "regex", "fuzzy", "proximity", "leadingWildcard" or
+	// "exists". Requests cannot enable features the schema or deployment disables.
+	DisableFeatures []string `json:"disableFeatures,omitempty"`
+
+	// Explain adds an explanation of how the query was interpreted to the response
+	Explain bool `json:"explain,omitempty"`
+
+	// Plan adds the plan the database would run the translation with to the response;
+	// it requires the server's executor, and a database of the executor's dialect
+	Plan bool `json:"plan,omitempty"`
+
+	// SQLFormat lays out the SQL WHERE clause: SQLFormatCompact (the default) or SQLFormatPretty
+	SQLFormat string `json:"sqlFormat,omitempty"`
+
+	// FilterFormat encodes the MongoDB filter: FilterFormatPlain (the default) or
+	// FilterFormatExtendedJSON, which keeps dates and 64-bit integers typed
+	FilterFormat string `json:"filterFormat,omitempty"`
+
+	// Facets ask for counts of the matching rows per bucket, next to the query itself
+	Facets []FacetRequest `json:"facets,omitempty"`
+
+	// Strict rejects queries the database cannot express exactly, with UNSUPPORTED_SYNTAX,
+	// instead of translating them with downgrades, and queries with values that would be
+	// coerced, with TYPE_MISMATCH
+	Strict bool `json:"strict,omitempty"`
+
+	// Sort orders the results, overriding the schema's defaultSort; the schema's
+	// tieBreaker is appended to either
+	Sort []SortField `json:"sort,omitempty"`
+
+	// AsOf, an RFC 3339 timestamp, a YYYY-MM-DD date or "now", restricts a schema with
+	// temporal fields to the rows valid at that time
+	AsOf string `json:"asOf,omitempty"`
+}
+
+// Directions of a SortField
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// SortField orders results by a schema field, ascending unless Order is SortDesc
+type SortField struct {
+	Field string `json:"field"`
+	Order string `json:"order,omitempty"`
+}
+
+// SortKey is one key of a MongoDB sort document: 1 for ascending, -1 for descending
+type SortKey struct {
+	Key       string `json:"key"`
+	Direction int    `json:"direction"`
+}
+
+// Layouts of the SQL WHERE clause selected by TranslateRequest.SQLFormat
+const (
+	SQLFormatCompact = "compact" // one line
+	SQLFormatPretty  = "pretty"  // one line per clause, with nested groups indented
+)
+
+// Encodings of the MongoDB filter selected by TranslateRequest.FilterFormat
+const (
+	FilterFormatPlain        = "plain"   // values as the query wrote them
+	FilterFormatExtendedJSON = "extjson" // canonical Extended JSON, typed by the schema's fields
+)
+
+// Kinds of security policy reported in AppliedPolicy
+const (
+	PolicyDefaultFilter = "default_filter" // a schema filter ANDed into every query
+	PolicyFeature       = "feature"        // a query capability restricted by the deployment
+)
+
+// Actions a security policy took on a query
+const (
+	PolicyActionInjected = "injected" // the policy added a condition to the query
+	PolicyActionBypassed = "bypassed" // an authorized caller opted out of the policy
+	PolicyActionBlocked  = "blocked"  // the policy rejected the query
+)
+
+// AppliedPolicy records a security policy that altered or rejected a query, so clients
+// and auditors can see what changed without reading server logs
+type AppliedPolicy struct {
+	Policy string `json:"policy"`           // kind of policy, such as default_filter
+	Name   string `json:"name"`             // the filter or feature the policy concerns
+	Action string `json:"action"`           // injected, bypassed or blocked
+	Reason string `json:"reason,omitempty"` // why, in words
+}
+
+// Explanation describes how a query was interpreted, returned when a translation
+// request sets explain
+type Explanation struct {
+	Fields []FieldResolution `json:"fields"`           // how each field named in the query was resolved
+	Values []ValueAlias      `json:"values,omitempty"` // values written as value aliases, and the values bound for them
+}
+
+// ValueAlias is a value written in a query as one of its field's value aliases, and the
+// value stored that was bound for it
+type ValueAlias struct {
+	Field    string `json:"field"`              // as named in the query
+	Value    string `json:"value"`              // as written
+	Stored   string `json:"stored"`             // the value bound
+	Position int    `json:"position,omitempty"` // byte offset
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+// FieldResolution traces how a field name written in a query was resolved to a schema field
+type FieldResolution struct {
+	Name   string           `json:"name"`             // as written in the query
+	Field  string           `json:"field,omitempty"`  // the schema field it resolved to; empty when none
+	Column string           `json:"column,omitempty"` // the column the field is stored in
+	Steps  []ResolutionStep `json:"steps"`            // strategies tried, in order, up to the first match
+}
+
+// ResolutionStep is one name resolution strategy tried for a field name
+type ResolutionStep struct {
+	Strategy  string `json:"strategy"`
+	Candidate string `json:"candidate,omitempty"` // name looked up, for conversions such as snake_case
+	Matched   bool   `json:"matched"`
+}
+
+// ExecutionHints tell the executor of a translated query which limits to enforce, in the
+// form the target database accepts them
+type ExecutionHints struct {
+	TimeoutMs     int      `json:"timeoutMs,omitempty"`     // effective statement timeout
+	MaxRows       int      `json:"maxRows,omitempty"`       // effective row limit; the limit option for MongoDB
+	Statements    []string `json:"statements,omitempty"`    // run first, in the same transaction (PostgreSQL)
+	OptimizerHint string   `json:"optimizerHint,omitempty"` // placed right after SELECT (MySQL)
+	LimitClause   string   `json:"limitClause,omitempty"`   // appended to the SQL query
+	MaxTimeMS     int      `json:"maxTimeMS,omitempty"`     // find or aggregate option (MongoDB)
+}
+
+// Kinds of facet selected by FacetRequest.Type
+const (
+	FacetDateHistogram = "date_histogram" // buckets of a date field per calendar interval
+	FacetTerms         = "terms"          // buckets of a field per distinct value
+)
+
+// Calendar intervals of a date histogram; weeks start on Monday
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// FacetRequest asks for the number of matching rows in each bucket of a field
+type FacetRequest struct {
+	Name     string        `json:"name"`               // identifies the facet in the response
+	Type     string        `json:"type"`               // FacetDateHistogram or FacetTerms
+	Field    string        `json:"field"`              // a field of the schema; a date field for histograms
+	Interval string        `json:"interval,omitempty"` // IntervalDay, IntervalWeek or IntervalMonth
+	Timezone string        `json:"timezone,omitempty"` // IANA time zone buckets start in; the schema's by default
+	Having   []FacetHaving `json:"having,omitempty"`   // conditions every returned bucket meets
+	Size     int           `json:"size,omitempty"`     // most frequent terms to return, all when zero
+}
+
+// FacetHaving keeps the buckets whose count compares to Count with Op: =, !=, >, >=,
+// < or <=. Conditions of a facet must all hold.
+type FacetHaving struct {
+	Op    string `json:"op"`
+	Count int64  `json:"count"`
+}
+
+// Facet is a facet translated for the target database. SQL facets are run as
+// SELECT selectClause FROM ... WHERE whereClause GROUP BY groupByClause
+// [HAVING havingClause] ORDER BY orderByClause [LIMIT limit], with the translation's
+// parameters, and return a bucket and a count column. MongoDB facets are a complete
+// aggregation pipeline.
+type Facet struct {
+	Name          string                   `json:"name"`
+	Type          string                   `json:"type"`
+	SelectClause  string                   `json:"selectClause,omitempty"`
+	GroupByClause string                   `json:"groupByClause,omitempty"`
+	HavingClause  string                   `json:"havingClause,omitempty"`
+	OrderByClause string                   `json:"orderByClause,omitempty"`
+	Limit         int                      `json:"limit,omitempty"`
+	Pipeline      []map[string]interface{} `json:"pipeline,omitempty"`
+}
+
+// Versions of the translate response format. Version 1 is the original response, with
+// only type, whereClause, parameters, parameterTypes and filter; version 2 adds every
+// other field. Clients ask for a version with ?format=v1 or an Accept header of
+// FormatMediaType(version), and get FormatLatest otherwise.
+const (
+	FormatV1     = 1
+	FormatV2     = 2
+	FormatLatest = FormatV2
+)
+
+// FormatMediaType returns the media type of a version of the translate response format,
+// application/vnd.rsearch.v<version>+json
+func FormatMediaType(version int) string {
+	return "application/vnd.rsearch.v" + strconv.Itoa(version) + "+json"
+}
+
+// TranslateResponse represents the response body for the translate endpoint.
+// SQL translations set WhereClause and Parameters; MongoDB translations set Filter.
+type TranslateResponse struct {
+	Type           string                 `json:"type"`
+	FormatVersion  int                    `json:"formatVersion"` // version of this response's format
+	WhereClause    string                 `json:"whereClause,omitempty"`
+	Parameters     []interface{}          `json:"parameters,omitempty"`
+	ParameterTypes []string               `json:"parameterTypes,omitempty"`
+	Filter         interface{}            `json:"filter,omitempty"`
+	SelectClause   string                 `json:"selectClause,omitempty"`
+	Projection     map[string]interface{} `json:"projection,omitempty"`
+	DefaultFilters []string               `json:"defaultFilters,omitempty"` // default filters applied
+	ExecutionHints *ExecutionHints        `json:"executionHints,omitempty"`
+	Warnings       []string               `json:"warnings,omitempty"`       // how the query was reinterpreted
+	WarningDetails []Warning              `json:"warningDetails,omitempty"` // every warning of the translation, with its code and position
+	Explain        *Explanation           `json:"explain,omitempty"`
+	Plan           json.RawMessage        `json:"plan,omitempty"`         // the database's plan for the translation, as it reports it
+	MinTextScore   float64                `json:"minTextScore,omitempty"` // MongoDB: text score fuzzy matches must reach
+
+	AppliedPolicies  []AppliedPolicy     `json:"appliedPolicies,omitempty"`  // security policies that altered the query
+	ReferencedFields []ReferencedField   `json:"referencedFields,omitempty"` // schema fields the query reads
+	Facets           []Facet             `json:"facets,omitempty"`           // requested facets, in request order
+	Downgrades       []Downgrade         `json:"downgrades,omitempty"`       // constructs translated to weaker substitutes
+	Coercions        []Coercion          `json:"coercions,omitempty"`        // values bound differently than written
+	PartialIndexes   []PartialIndexUsage `json:"partialIndexes,omitempty"`   // partial indexes of the fields read, and whether they serve the query
+	NormalizedQuery  string              `json:"normalizedQuery,omitempty"`  // the query translated, in canonical query syntax
+
+	// The order of the results: an ORDER BY list (SQL) or the keys of a sort document in
+	// order (MongoDB), ending with the schema's tie-breaker so pages are stable
+	OrderByClause string    `json:"orderByClause,omitempty"`
+	Sort          []SortKey `json:"sort,omitempty"`
+}
+
+// Bounds on the values a suggest request returns
+const (
+	DefaultSuggestLimit = 10
+	MaxSuggestLimit     = 100
+)
+
+// SuggestValuesRequest represents the request body for the suggest values endpoint
+type SuggestValuesRequest struct {
+	Schema   string `json:"schema"`
+	Database string `json:"database"`
+	Field    string `json:"field"`
+	Prefix   string `json:"prefix,omitempty"` // text fields only; values must start with it
+	Limit    int    `json:"limit,omitempty"`  // DefaultSuggestLimit when zero, at most MaxSuggestLimit
+}
+
+// SuggestValuesResponse is a query listing the distinct values of a field, restricted
+// by the schema's default filters. SQL suggestions are run as SELECT selectClause FROM
+// ... WHERE whereClause ORDER BY orderByClause LIMIT limit, with the parameters, and
+// return a value column. MongoDB suggestions are a complete aggregation pipeline.
+type SuggestValuesResponse struct {
+	Type           string                   `json:"type"`
+	Field          string                   `json:"field"`
+	Column         string                   `json:"column"`
+	SelectClause   string                   `json:"selectClause,omitempty"`
+	WhereClause    string                   `json:"whereClause,omitempty"`
+	Parameters     []interface{}            `json:"parameters,omitempty"`
+	ParameterTypes []string                 `json:"parameterTypes,omitempty"`
+	OrderByClause  string                   `json:"orderByClause,omitempty"`
+	Limit          int                      `json:"limit"`
+	Pipeline       []map[string]interface{} `json:"pipeline,omitempty"`
+	DefaultFilters []string                 `json:"defaultFilters,omitempty"` // default filters applied
+}
+
+// How a partial field name matched a suggested field, from best to worst
+const (
+	MatchExact        = "exact"        // the whole name, ignoring case and separators
+	MatchPrefix       = "prefix"       // the start of the name
+	MatchAbbreviation = "abbreviation" // the starts of consecutive words, e.g. prodcod for productCode
+	MatchFuzzy        = "fuzzy"        // within a few edits of the name or its start
+)
+
+// FieldSuggestion is a schema field a partial or misspelled field name may refer to
+type FieldSuggestion struct {
+	Field       string `json:"field"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Match       string `json:"match"`              // MatchExact, MatchPrefix, MatchAbbreviation or MatchFuzzy
+	Alias       string `json:"alias,omitempty"`    // the alias that matched, when not the field name
+	Distance    int    `json:"distance,omitempty"` // edits of a fuzzy match
+}
+
+// FieldSuggestionsResponse represents the response body of the suggest fields endpoint
+type FieldSuggestionsResponse struct {
+	Schema string            `json:"schema"`
+	Query  string            `json:"q"`
+	Fields []FieldSuggestion `json:"fields"` // best match first
+}
+
+// Substitutes a translator may fall back to, recorded in Downgrade.Substitute
+const (
+	SubstituteEquality   = "equality"    // an exact comparison with the whole value
+	SubstitutePhrase     = "phrase"      // a full-text match of the words next to each other
+	SubstituteAnyWord    = "any_word"    // a full-text match of any of the words
+	SubstituteSoundex    = "soundex"     // a match by SOUNDEX code, which ignores the edit distance
+	SubstituteTextSearch = "text_search" // a MongoDB $text search of the collection's text index
+)
+
+// Codes of translation warnings, in Warning.Code
+const (
+	WarningRangeSwapped = "RANGE_SWAPPED" // a range's bounds were swapped, per the schema's reversedRanges
+	WarningDowngraded   = "DOWNGRADED"    // a construct was translated to a weaker substitute, see Downgrade
+	WarningCoerced      = "VALUE_COERCED" // a value was bound differently than written, see Coercion
+	WarningUnknownField = "UNKNOWN_FIELD" // a clause on an unknown field was dropped or searched the default field, per the schema's unknownFields
+)
+
+// Warning is non-fatal information about a translation: the query was translated, but
+// not exactly as written. Position, Line and Column locate the construct in the query,
+// like ErrorInfo, and are zero when unknown.
+type Warning struct {
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	Position int    `json:"position,omitempty"` // byte offset
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+// Downgrade is a construct the target database cannot express, which was translated to
+// a weaker substitute instead, so the query matches differently than written
+type Downgrade struct {
+	Original   string `json:"original"`   // the construct in query syntax, e.g. name:"fast car"~3
+	Feature    string `json:"feature"`    // fuzzy or proximity
+	Substitute string `json:"substitute"` // SubstituteEquality, SubstitutePhrase, ...
+	Reason     string `json:"reason"`
+}
+
+// Coercion is a value bound differently than written to fit its field's type, such as a
+// float literal with more digits than a 64-bit float holds
+type Coercion struct {
+	Field  string      `json:"field"` // the field as written in the query
+	Value  string      `json:"value"` // the value as written
+	Bound  interface{} `json:"bound"` // the parameter bound instead
+	Reason string      `json:"reason"`
+}
+
+// ReferencedField is a schema field a translated query reads, including fields of
+// injected default filters and the default field of terms written without one
+type ReferencedField struct {
+	Field  string `json:"field"`  // schema field name
+	Column string `json:"column"` // column or document key, qualified with any table alias
+}
+
+// PartialIndexUsage tells whether a translated query can be served by a partial index
+// declared on a field it reads. Databases only use a partial index for queries whose
+// conditions imply its predicate.
+type PartialIndexUsage struct {
+	Field   string   `json:"field"`             // schema field the index is declared on
+	Index   string   `json:"index"`             // the index name
+	Usable  bool     `json:"usable"`            // the query requires every condition of the predicate
+	Missing []string `json:"missing,omitempty"` // conditions of the predicate the query does not require, in query syntax
+}
+
+// FieldDoc is the machine-readable documentation of one schema field
+type FieldDoc struct {
+	Name        string              `json:"name"`
+	Type        string              `json:"type"`
+	Column      string              `json:"column"`
+	Aliases     []string            `json:"aliases,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Examples    []string            `json:"examples,omitempty"`
+	Unit        string              `json:"unit,omitempty"`
+	Indexed     bool                `json:"indexed"`
+	Default     bool                `json:"default,omitempty"` // queried by terms without a field
+	Dialects    map[string]FieldUse `json:"dialects"`
+}
+
+// FieldUse describes how a field can be queried in one dialect
+type FieldUse struct {
+	Operators     []string `json:"operators"`
+	CaseSensitive *bool    `json:"caseSensitive,omitempty"` // omitted when it depends on database configuration
+}
+
+// FragmentDoc documents a named schema filter queried as a pseudo-field, e.g. inStock:true
+type FragmentDoc struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Dialects    []string `json:"dialects"` // registered dialects the fragment is defined for
+}
+
+// FieldsResponse represents the response body of the fields endpoint
+type FieldsResponse struct {
+	Schema    string        `json:"schema"`
+	Fields    []FieldDoc    `json:"fields"`
+	Fragments []FragmentDoc `json:"fragments,omitempty"`
+}
+
+// QueryPattern is a parameterized query from the pattern library
+type QueryPattern struct {
+	Name        string                    `json:"name"`
+	Description string                    `json:"description"`
+	Template    string                    `json:"template"` // the query with each parameter as {name}
+	Parameters  []PatternParameter        `json:"parameters"`
+	FieldTypes  []string                  `json:"fieldTypes"` // types of the fields it applies to
+	Query       string                    `json:"query"`      // rendered from the parameter examples, or the values requested
+	Previews    map[string]PatternPreview `json:"previews"`   // translations of the query by dialect
+}
+
+// PatternParameter is a parameter of a query pattern
+type PatternParameter struct {
+	Name        string `json:"name"`
+	Kind        string `json:"kind"` // field, integer, number or values (comma-separated)
+	Description string `json:"description"`
+	Example     string `json:"example"`
+}
+
+// PatternPreview is the translation of a query pattern in one dialect
+type PatternPreview struct {
+	WhereClause string        `json:"whereClause,omitempty"`
+	Parameters  []interface{} `json:"parameters,omitempty"`
+	Filter      interface{}   `json:"filter,omitempty"`
+	Error       string        `json:"error,omitempty"` // why the dialect cannot translate it
+}
+
+// PatternsResponse represents the response body of the patterns endpoint
+type PatternsResponse struct {
+	Patterns []QueryPattern `json:"patterns"`
+}
+
 // ErrorResponse represents the standard error response format
 type ErrorResponse struct {
 	Error ErrorDetail `json:"error"`
@@ -20,6 +469,19 @@ type ErrorDetail struct {
 	Message string      `json:"message"`
 	Details []ErrorInfo `json:"details,omitempty"`
 	Query   string      `json:"query,omitempty"`
+
+	AppliedPolicies []AppliedPolicy `json:"appliedPolicies,omitempty"` // security policies that rejected the query
+	RequestID       string          `json:"requestId,omitempty"`       // ID of the failed request, as in its logs
+	Quota           *QuotaUsage     `json:"quota,omitempty"`           // usage of the API key's quota, on QUOTA_EXCEEDED
+}
+
+// QuotaUsage is an API key's usage of its daily quotas. Zero limits are unlimited.
+type QuotaUsage struct {
+	QueriesLimit    int    `json:"queriesLimit"`
+	QueriesUsed     int    `json:"queriesUsed"`
+	ComplexityLimit int    `json:"complexityLimit"`
+	ComplexityUsed  int    `json:"complexityUsed"` // cumulative AST nodes of the day's queries
+	ResetsAt        string `json:"resetsAt"`       // RFC 3339 time usage resets, at midnight UTC
 }
 
 // ErrorInfo contains detailed error information
@@ -43,10 +505,18 @@ const (
 	ErrorCodeInvalidSchema      = "INVALID_SCHEMA"
 	ErrorCodeInternalError      = "INTERNAL_ERROR"
 	ErrorCodeRateLimited        = "RATE_LIMITED"
+	ErrorCodeQuotaExceeded      = "QUOTA_EXCEEDED"
 	ErrorCodeUnauthorized       = "UNAUTHORIZED"
 	ErrorCodeForbidden          = "FORBIDDEN"
 	ErrorCodeQueryTooLong       = "QUERY_TOO_LONG"
+	ErrorCodeQueryTooComplex    = "QUERY_TOO_COMPLEX"
 	ErrorCodeTooManyParameters  = "TOO_MANY_PARAMETERS"
 	ErrorCodeTimeout            = "TIMEOUT"
 	ErrorCodeServiceUnavailable = "SERVICE_UNAVAILABLE"
+	ErrorCodeOperatorNotAllowed = "OPERATOR_NOT_ALLOWED"
+	ErrorCodeInvalidRequest     = "INVALID_REQUEST"
+	ErrorCodeHashedField        = "HASHED_FIELD"
+	ErrorCodeUnknownValue       = "UNKNOWN_VALUE"
+	ErrorCodePatternNotFound    = "PATTERN_NOT_FOUND"
+	ErrorCodeHistoryNotFound    = "HISTORY_NOT_FOUND"
 )
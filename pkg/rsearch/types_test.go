@@ -26,6 +26,7 @@ func TestErrorCodes(t *testing.T) {
 		ErrorCodeSchemaExists,
 		ErrorCodeInvalidSchema,
 		ErrorCodeInternalError,
+		ErrorCodeInvalidRequest,
 	}
 
 	// Verify all codes are non-empty
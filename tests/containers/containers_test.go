@@ -0,0 +1,262 @@
+//go:build containers
+
+package containers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+	"github.com/infiniv/rsearch/internal/translator"
+	"github.com/infiniv/rsearch/tests/testhelper"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/mongodb"
+	"github.com/testcontainers/testcontainers-go/modules/mysql"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"modernc.org/sqlite"
+)
+
+// Column types of the products table per SQL dialect, in the order of columns
+var sqlTypes = map[string][]string{
+	"postgres": {"INTEGER PRIMARY KEY", "TEXT", "TEXT", "TEXT", "INTEGER", "DOUBLE PRECISION", "TEXT", "BOOLEAN", "TEXT"},
+	"mysql":    {"INTEGER PRIMARY KEY", "VARCHAR(255)", "VARCHAR(255)", "VARCHAR(255)", "INTEGER", "DOUBLE", "VARCHAR(255)", "BOOLEAN", "VARCHAR(255)"},
+	"sqlite":   {"INTEGER PRIMARY KEY", "TEXT", "TEXT", "TEXT", "INTEGER", "REAL", "TEXT", "BOOLEAN", "TEXT"},
+}
+
+// testCase is a documented test case with the rows it should select
+type testCase struct {
+	testhelper.TestCase
+	want []int64
+}
+
+// loadCases loads the documented test cases and evaluates each with the oracle. Cases
+// the oracle cannot evaluate, or on schemas that are not seeded, are returned without
+// expectations and skipped.
+func loadCases(t *testing.T) ([]testCase, map[string]*schema.Schema) {
+	schemas := testhelper.LoadSchemas(t, filepath.Join("..", "schemas.json"))
+
+	var cases []testCase
+	for _, tc := range testhelper.LoadTestCases(t, filepath.Join("..", "testcases.json")) {
+		c := testCase{TestCase: tc}
+		if tc.Schema == "products" {
+			ast, err := parser.NewParser(tc.Query).Parse()
+			require.NoError(t, err, tc.Query)
+			// Negations bind like in the translators, which the oracle does not reimplement
+			c.want, err = oracle(translator.BindNegations(ast, schemas[tc.Schema]), schemas[tc.Schema])
+			if errors.Is(err, errUnsupported) {
+				c.want = nil
+			} else {
+				require.NoError(t, err, tc.Query)
+				if c.want == nil {
+					c.want = []int64{}
+				}
+			}
+		}
+		cases = append(cases, c)
+	}
+	return cases, schemas
+}
+
+// translate runs the translation pipeline of the translate endpoint
+func translate(t *testing.T, query string, sch *schema.Schema, trans translator.Translator) *translator.TranslatorOutput {
+	ast, err := parser.NewParser(query).Parse()
+	require.NoError(t, err)
+	ast, _, err = translator.ApplyDefaultFilters(ast, sch, nil)
+	require.NoError(t, err)
+	output, err := trans.Translate(ast, sch)
+	require.NoError(t, err)
+	return output
+}
+
+// runSQL checks every test case against a seeded SQL database
+func runSQL(t *testing.T, db *sql.DB, dialect string, trans translator.Translator) {
+	ctx := context.Background()
+	seedSQL(t, db, dialect)
+
+	cases, schemas := loadCases(t)
+	for _, tc := range cases {
+		t.Run(tc.Description, func(t *testing.T) {
+			if tc.want == nil {
+				t.Skipf("%s: no oracle for this query", tc.Query)
+			}
+			output := translate(t, tc.Query, schemas[tc.Schema], trans)
+
+			rows, err := db.QueryContext(ctx, "SELECT id FROM products WHERE "+output.WhereClause+" ORDER BY id", output.Parameters...)
+			require.NoError(t, err, output.WhereClause)
+			defer rows.Close()
+
+			got := []int64{}
+			for rows.Next() {
+				var id int64
+				require.NoError(t, rows.Scan(&id))
+				got = append(got, id)
+			}
+			require.NoError(t, rows.Err())
+			assert.Equal(t, tc.want, got, "%s\n%s %v", tc.Query, output.WhereClause, output.Parameters)
+		})
+	}
+}
+
+// seedSQL creates and fills the products table
+func seedSQL(t *testing.T, db *sql.DB, dialect string) {
+	defs := make([]string, len(columns))
+	for i, column := range columns {
+		defs[i] = column + " " + sqlTypes[dialect][i]
+	}
+	create := fmt.Sprintf("CREATE TABLE products (%s)", strings.Join(defs, ", "))
+	if dialect == "mysql" {
+		// Compare text by bytes, like PostgreSQL, rather than ignoring case
+		create += " COLLATE utf8mb4_bin"
+	}
+	_, err := db.Exec(create)
+	require.NoError(t, err)
+
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = "?"
+		if dialect == "postgres" {
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+		}
+	}
+	insert := fmt.Sprintf("INSERT INTO products (%s) VALUES (%s)", strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	for _, row := range products {
+		values := make([]interface{}, len(columns))
+		for i, column := range columns {
+			values[i] = row[column]
+		}
+		_, err := db.Exec(insert, values...)
+		require.NoError(t, err)
+	}
+}
+
+func TestPostgres(t *testing.T) {
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+	ctx := context.Background()
+
+	container, err := postgres.Run(ctx, "postgres:16-alpine",
+		postgres.WithDatabase("rsearch"),
+		postgres.WithUsername("rsearch"),
+		postgres.WithPassword("rsearch"),
+		postgres.BasicWaitStrategies(),
+	)
+	testcontainers.CleanupContainer(t, container)
+	require.NoError(t, err)
+
+	dsn, err := container.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	runSQL(t, db, "postgres", translator.NewPostgresTranslator())
+}
+
+func TestMySQL(t *testing.T) {
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+	ctx := context.Background()
+
+	container, err := mysql.Run(ctx, "mysql:8.4",
+		mysql.WithDatabase("rsearch"),
+		mysql.WithUsername("rsearch"),
+		mysql.WithPassword("rsearch"),
+	)
+	testcontainers.CleanupContainer(t, container)
+	require.NoError(t, err)
+
+	dsn, err := container.ConnectionString(ctx)
+	require.NoError(t, err)
+	db, err := sql.Open("mysql", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	runSQL(t, db, "mysql", translator.NewMySQLTranslator())
+}
+
+// SQLite runs in process; REGEXP is a user function it leaves to applications
+func TestSQLite(t *testing.T) {
+	sqlite.MustRegisterDeterministicScalarFunction("regexp", 2, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		pattern, _ := args[0].(string)
+		value, _ := args[1].(string)
+		return regexp.MatchString(pattern, value)
+	})
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "rsearch.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	// LIKE ignores ASCII case by default; the pragma applies to the one connection
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec("PRAGMA case_sensitive_like = ON")
+	require.NoError(t, err)
+
+	runSQL(t, db, "sqlite", translator.NewSQLiteTranslator())
+}
+
+func TestMongoDB(t *testing.T) {
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+	ctx := context.Background()
+
+	container, err := mongodb.Run(ctx, "mongo:7")
+	testcontainers.CleanupContainer(t, container)
+	require.NoError(t, err)
+
+	uri, err := container.ConnectionString(ctx)
+	require.NoError(t, err)
+	client, err := mongo.Connect(options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	defer client.Disconnect(ctx)
+
+	// Missing fields stand for NULL columns
+	collection := client.Database("rsearch").Collection("products")
+	for _, row := range products {
+		doc := bson.M{}
+		for column, value := range row {
+			if value != nil {
+				doc[column] = value
+			}
+		}
+		_, err := collection.InsertOne(ctx, doc)
+		require.NoError(t, err)
+	}
+
+	trans := translator.NewMongoDBTranslator()
+	cases, schemas := loadCases(t)
+	for _, tc := range cases {
+		t.Run(tc.Description, func(t *testing.T) {
+			if tc.want == nil {
+				t.Skipf("%s: no oracle for this query", tc.Query)
+			}
+			output := translate(t, tc.Query, schemas[tc.Schema], trans)
+
+			cursor, err := collection.Find(ctx, output.Filter, options.Find().SetProjection(bson.M{"id": 1}))
+			require.NoError(t, err)
+			var docs []struct {
+				ID int64 `bson:"id"`
+			}
+			require.NoError(t, cursor.All(ctx, &docs))
+
+			got := []int64{}
+			for _, doc := range docs {
+				got = append(got, doc.ID)
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+			assert.Equal(t, tc.want, got, "%s\n%v", tc.Query, output.Filter)
+		})
+	}
+}
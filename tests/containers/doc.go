@@ -0,0 +1,11 @@
+// Package containers runs the documented test cases against real databases.
+//
+// The suite is behind the containers build tag, as it needs Docker:
+//
+//	go test -tags containers ./tests/containers/
+//
+// It starts PostgreSQL, MySQL and MongoDB with testcontainers and opens SQLite in
+// process, seeds each with the same products, and checks that every test case in
+// tests/testcases.json selects the rows an in-memory oracle selects. Tests are skipped
+// when Docker is not available.
+package containers
@@ -0,0 +1,250 @@
+//go:build containers
+
+package containers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/infiniv/rsearch/internal/parser"
+	"github.com/infiniv/rsearch/internal/schema"
+)
+
+// product is a seeded row, keyed by column name; a nil value is NULL, or a missing
+// field in MongoDB
+type product map[string]interface{}
+
+// products are seeded into every database. Only description is ever NULL, as SQL and
+// MongoDB disagree on whether NOT matches missing values. Names differ in case so that
+// case-insensitive matching shows up as a mismatch.
+var products = []product{
+	{"id": int64(1), "product_code": "13w42", "name": "Widget Pro", "description": "Heavy duty", "rod_length": int64(150), "price": 99.5, "region": "ca", "in_stock": true, "status": "active"},
+	{"id": int64(2), "product_code": "13w43", "name": "widget", "description": nil, "rod_length": int64(50), "price": 15.0, "region": "ny", "in_stock": true, "status": "active"},
+	{"id": int64(3), "product_code": "13w4x", "name": "gadget", "description": "Compact", "rod_length": int64(500), "price": -5.0, "region": "tx", "in_stock": false, "status": "discontinued"},
+	{"id": int64(4), "product_code": "22a10", "name": "laptop", "description": "Thin and light", "rod_length": int64(200), "price": 1200.0, "region": "ca", "in_stock": true, "status": "active"},
+	{"id": int64(5), "product_code": "22a11", "name": "Widget", "description": nil, "rod_length": int64(100), "price": 150.0, "region": "wa", "in_stock": false, "status": "discontinued"},
+	{"id": int64(6), "product_code": "9b7", "name": "midget widget", "description": "Small", "rod_length": int64(20), "price": 40.0, "region": "ny", "in_stock": true, "status": "active"},
+	{"id": int64(7), "product_code": "13w44", "name": "wigget", "description": "Typo", "rod_length": int64(300), "price": 10.0, "region": "ca", "in_stock": false, "status": "active"},
+	{"id": int64(8), "product_code": "x1", "name": "Laptop Stand", "description": "Aluminium", "rod_length": int64(120), "price": 35.0, "region": "tx", "in_stock": true, "status": "discontinued"},
+}
+
+// columns are the seeded columns in table order
+var columns = []string{"id", "product_code", "name", "description", "rod_length", "price", "region", "in_stock", "status"}
+
+// errUnsupported marks queries the oracle cannot evaluate, such as fuzzy matches whose
+// scoring is up to each database
+var errUnsupported = errors.New("not supported by the oracle")
+
+// oracle returns the ids of the products a query matches, in order
+func oracle(ast parser.Node, sch *schema.Schema) ([]int64, error) {
+	var ids []int64
+	for _, row := range products {
+		ok, err := matches(ast, row, sch)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			ids = append(ids, row["id"].(int64))
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// matches evaluates a query against a row
+func matches(node parser.Node, row product, sch *schema.Schema) (bool, error) {
+	switch n := node.(type) {
+	case *parser.BinaryOp:
+		left, err := matches(n.Left, row, sch)
+		if err != nil {
+			return false, err
+		}
+		right, err := matches(n.Right, row, sch)
+		if err != nil {
+			return false, err
+		}
+		if n.Op == "OR" || n.Op == "||" {
+			return left || right, nil
+		}
+		return left && right, nil
+	case *parser.UnaryOp:
+		ok, err := matches(n.Operand, row, sch)
+		return !ok, err
+	case *parser.RequiredQuery:
+		return matches(n.Query, row, sch)
+	case *parser.ProhibitedQuery:
+		ok, err := matches(n.Query, row, sch)
+		return !ok, err
+	case *parser.GroupQuery:
+		return matches(n.Query, row, sch)
+	case *parser.BoostQuery:
+		return matches(n.Query, row, sch)
+	case *parser.ExistsQuery:
+		column, _, err := sch.ResolveField(n.Field)
+		if err != nil {
+			return false, err
+		}
+		return row[column] != nil, nil
+	case *parser.FieldQuery:
+		column, field, err := sch.ResolveField(n.Field)
+		if err != nil {
+			return false, err
+		}
+		return matchValue(row[column], n.Value, field, sch)
+	case *parser.InListQuery:
+		column, field, err := sch.ResolveField(n.Field)
+		if err != nil {
+			return false, err
+		}
+		for _, value := range n.Values {
+			if ok, err := matchValue(row[column], value, field, sch); ok || err != nil {
+				return ok, err
+			}
+		}
+		return false, nil
+	case *parser.FieldGroupQuery:
+		column, field, err := sch.ResolveField(n.Field)
+		if err != nil {
+			return false, err
+		}
+		for _, member := range n.Queries {
+			if ok, err := matchMember(row[column], member, field, sch); ok || err != nil {
+				return ok, err
+			}
+		}
+		return false, nil
+	case *parser.RangeQuery:
+		column, field, err := sch.ResolveField(n.Field)
+		if err != nil {
+			return false, err
+		}
+		return inRange(row[column], n, field)
+	default:
+		return false, fmt.Errorf("%T: %w", node, errUnsupported)
+	}
+}
+
+// matchMember evaluates a member of a field group against a column value
+func matchMember(value interface{}, member parser.Node, field *schema.Field, sch *schema.Schema) (bool, error) {
+	switch m := member.(type) {
+	case *parser.TermQuery:
+		return matchValue(value, &parser.TermValue{Term: m.Term}, field, sch)
+	case *parser.WildcardQuery:
+		return matchValue(value, &parser.WildcardValue{Pattern: m.Pattern}, field, sch)
+	case *parser.BinaryOp:
+		left, err := matchMember(value, m.Left, field, sch)
+		if err != nil {
+			return false, err
+		}
+		right, err := matchMember(value, m.Right, field, sch)
+		if err != nil {
+			return false, err
+		}
+		if m.Op == "OR" || m.Op == "||" {
+			return left || right, nil
+		}
+		return left && right, nil
+	default:
+		return false, fmt.Errorf("field group member %T: %w", member, errUnsupported)
+	}
+}
+
+// matchValue compares a column value with a query value
+func matchValue(value interface{}, v parser.ValueNode, field *schema.Field, sch *schema.Schema) (bool, error) {
+	if value == nil {
+		return false, nil
+	}
+	switch qv := v.(type) {
+	case *parser.TermValue:
+		return equal(value, qv.Term, field)
+	case *parser.NumberValue:
+		return equal(value, qv.Number, field)
+	case *parser.PhraseValue:
+		return equal(value, qv.Phrase, field)
+	case *parser.WildcardValue:
+		pattern := regexp.QuoteMeta(qv.Pattern)
+		pattern = strings.ReplaceAll(pattern, `\*`, ".*")
+		pattern = strings.ReplaceAll(pattern, `\?`, ".")
+		return regexp.MustCompile("^" + pattern + "$").MatchString(fmt.Sprint(value)), nil
+	case *parser.RegexValue:
+		// Patterns match anywhere in the value unless the schema anchors them
+		pattern := qv.Pattern
+		if sch.AnchorRegex() {
+			pattern = "^(" + pattern + ")$"
+		}
+		if qv.Flags != "" {
+			pattern = "(?" + qv.Flags + ")" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(fmt.Sprint(value)), nil
+	default:
+		return false, fmt.Errorf("value %T: %w", v, errUnsupported)
+	}
+}
+
+// equal compares a column value with a literal of the field's type
+func equal(value interface{}, literal string, field *schema.Field) (bool, error) {
+	cmp, err := compare(value, literal, field)
+	return cmp == 0, err
+}
+
+// inRange reports whether a column value lies within a range, where * is unbounded
+func inRange(value interface{}, r *parser.RangeQuery, field *schema.Field) (bool, error) {
+	if value == nil {
+		return false, nil
+	}
+	for _, bound := range []struct {
+		value     parser.ValueNode
+		inclusive bool
+		sign      int
+	}{{r.Start, r.InclusiveStart, 1}, {r.End, r.InclusiveEnd, -1}} {
+		literal := fmt.Sprint(bound.value.Value())
+		if literal == "*" {
+			continue
+		}
+		cmp, err := compare(value, literal, field)
+		if err != nil {
+			return false, err
+		}
+		if cmp*bound.sign < 0 || (cmp == 0 && !bound.inclusive) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// compare orders a column value against a literal of the field's type
+func compare(value interface{}, literal string, field *schema.Field) (int, error) {
+	switch field.Type {
+	case schema.TypeInteger, schema.TypeFloat:
+		n, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return 0, err
+		}
+		var v float64
+		switch x := value.(type) {
+		case int64:
+			v = float64(x)
+		case float64:
+			v = x
+		}
+		switch {
+		case v < n:
+			return -1, nil
+		case v > n:
+			return 1, nil
+		}
+		return 0, nil
+	case schema.TypeText:
+		return strings.Compare(value.(string), literal), nil
+	default:
+		return 0, fmt.Errorf("%s fields: %w", field.Type, errUnsupported)
+	}
+}